@@ -180,48 +180,58 @@ func (dl *DataList) FillWithMode() *DataList {
 	defer func() {
 		go dl.updateTimestamp()
 	}()
-	type modeEntry struct {
-		value any
-		count int
-		first int
-	}
 	dl.AtomicDo(func(dl *DataList) {
-		entries := []modeEntry{}
-		for i, v := range dl.data {
-			if isMissing(v) {
-				continue
-			}
-			found := false
-			for j := range entries {
-				if reflect.DeepEqual(entries[j].value, v) {
-					entries[j].count++
-					found = true
-					break
-				}
-			}
-			if !found {
-				entries = append(entries, modeEntry{value: v, count: 1, first: i})
-			}
-		}
-		if len(entries) == 0 {
+		mode, ok := observedMode(dl.data)
+		if !ok {
 			dl.warn("FillWithMode", "No non-missing values to compute mode")
 			return
 		}
-		mode := entries[0]
-		for _, entry := range entries[1:] {
-			if entry.count > mode.count || entry.count == mode.count && entry.first < mode.first {
-				mode = entry
-			}
-		}
 		for i, v := range dl.data {
 			if isMissing(v) {
-				dl.data[i] = mode.value
+				dl.data[i] = mode
 			}
 		}
 	})
 	return dl
 }
 
+// observedMode returns the most frequent non-missing value, breaking ties by
+// first occurrence.
+func observedMode(data []any) (any, bool) {
+	type modeEntry struct {
+		value any
+		count int
+		first int
+	}
+	entries := []modeEntry{}
+	for i, v := range data {
+		if isMissing(v) {
+			continue
+		}
+		found := false
+		for j := range entries {
+			if reflect.DeepEqual(entries[j].value, v) {
+				entries[j].count++
+				found = true
+				break
+			}
+		}
+		if !found {
+			entries = append(entries, modeEntry{value: v, count: 1, first: i})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, false
+	}
+	mode := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.count > mode.count || entry.count == mode.count && entry.first < mode.first {
+			mode = entry
+		}
+	}
+	return mode.value, true
+}
+
 // FillByInterpolation fills missing sequence values by index, unlike LinearInterpolation which evaluates y at x.
 func (dl *DataList) FillByInterpolation(extrapolate ...bool) *DataList {
 	defer func() {
@@ -159,11 +159,71 @@ func (dt *DataTable) OrdinalEncode(opts OrdinalEncodeOptions) (*DataTable, *Ordi
 	return out, enc, nil
 }
 
+// NewOneHotEncoder returns an unfitted one-hot encoder; Fit learns its
+// categories from a table using opts.
+func NewOneHotEncoder(opts OneHotOptions) *OneHotEncoder {
+	return &OneHotEncoder{opts: normalizeOneHotOptions(opts)}
+}
+
+// NewLabelEncoder returns an unfitted label encoder; Fit learns its classes
+// from a table using opts.
+func NewLabelEncoder(opts LabelEncodeOptions) *LabelEncoder {
+	return &LabelEncoder{opts: opts}
+}
+
+// NewOrdinalEncoder returns an unfitted ordinal encoder; Fit learns its
+// classes from a table using opts.
+func NewOrdinalEncoder(opts OrdinalEncodeOptions) *OrdinalEncoder {
+	return &OrdinalEncoder{opts: opts}
+}
+
+// Fit (re)learns the categories from dt with the encoder's options.
+func (e *OneHotEncoder) Fit(dt *DataTable) error {
+	if e == nil || dt == nil {
+		return fmt.Errorf("OneHotEncoder.Fit: encoder and table must not be nil")
+	}
+	fitted, err := fitOneHotEncoder(dt, e.opts)
+	if err != nil {
+		return err
+	}
+	*e = *fitted
+	return nil
+}
+
+// Fit (re)learns the classes from dt with the encoder's options.
+func (e *LabelEncoder) Fit(dt *DataTable) error {
+	if e == nil || dt == nil {
+		return fmt.Errorf("LabelEncoder.Fit: encoder and table must not be nil")
+	}
+	fitted, err := fitLabelEncoder(dt, e.opts)
+	if err != nil {
+		return err
+	}
+	*e = *fitted
+	return nil
+}
+
+// Fit (re)learns the classes from dt with the encoder's options.
+func (e *OrdinalEncoder) Fit(dt *DataTable) error {
+	if e == nil || dt == nil {
+		return fmt.Errorf("OrdinalEncoder.Fit: encoder and table must not be nil")
+	}
+	fitted, err := fitOrdinalEncoder(dt, e.opts)
+	if err != nil {
+		return err
+	}
+	*e = *fitted
+	return nil
+}
+
 // Transform applies this fitted one-hot encoder to a new table.
 func (e *OneHotEncoder) Transform(dt *DataTable) (*DataTable, error) {
 	if e == nil {
 		return nil, fmt.Errorf("OneHotEncoder.Transform: encoder is nil")
 	}
+	if len(e.columns) == 0 {
+		return nil, fmt.Errorf("OneHotEncoder.Transform: encoder is not fitted")
+	}
 	out := NewDataTable()
 	var err error
 	dt.AtomicDo(func(t *DataTable) {
@@ -225,6 +285,9 @@ func (e *LabelEncoder) Transform(dt *DataTable) (*DataTable, error) {
 	if e == nil {
 		return nil, fmt.Errorf("LabelEncoder.Transform: encoder is nil")
 	}
+	if e.sourceRef == "" {
+		return nil, fmt.Errorf("LabelEncoder.Transform: encoder is not fitted")
+	}
 	classes, keyToID := e.classes, e.keyToID
 	if e.opts.Unknown == UnknownAsNew {
 		classes = append([]any(nil), e.classes...)
@@ -263,6 +326,9 @@ func (e *OrdinalEncoder) Transform(dt *DataTable) (*DataTable, error) {
 	if e == nil {
 		return nil, fmt.Errorf("OrdinalEncoder.Transform: encoder is nil")
 	}
+	if e.sourceRef == "" {
+		return nil, fmt.Errorf("OrdinalEncoder.Transform: encoder is not fitted")
+	}
 	classes, keyToID := e.classes, e.keyToID
 	if e.opts.Unknown == UnknownAsNew {
 		classes = append([]any(nil), e.classes...)
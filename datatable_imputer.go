@@ -0,0 +1,214 @@
+package insyra
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Imputer is the shared surface for fitted, reusable missing-value fillers.
+//
+// Unlike DataTable.FillWithMean/FillWithMedian/FillWithMode (stateless, in
+// place), an Imputer learns its fill values once and applies them to new
+// tables, so a test set is filled with training statistics rather than its
+// own.
+type Imputer interface {
+	Fit(dt *DataTable, cols ...string) error
+	Transform(dt *DataTable) (*DataTable, error)
+	FitTransform(dt *DataTable, cols ...string) (*DataTable, error)
+	InverseTransform(dt *DataTable) (*DataTable, error)
+	FillValues() map[string]any
+	Kind() string
+}
+
+type imputerColumn struct {
+	name string
+	fill any
+}
+
+// imputer is the embedded base shared by all concrete imputers.
+type imputer struct {
+	kind   string
+	cols   []imputerColumn
+	fitted bool
+}
+
+// MeanImputer fills missing numeric values with the training mean.
+type MeanImputer struct{ imputer }
+
+// MedianImputer fills missing numeric values with the training median.
+type MedianImputer struct{ imputer }
+
+// ModeImputer fills missing values of any type with the training mode
+// (ties go to the value seen first).
+type ModeImputer struct{ imputer }
+
+// NewMeanImputer returns an unfitted mean imputer.
+func NewMeanImputer() *MeanImputer { return &MeanImputer{imputer{kind: "mean"}} }
+
+// NewMedianImputer returns an unfitted median imputer.
+func NewMedianImputer() *MedianImputer { return &MedianImputer{imputer{kind: "median"}} }
+
+// NewModeImputer returns an unfitted mode imputer.
+func NewModeImputer() *ModeImputer { return &ModeImputer{imputer{kind: "mode"}} }
+
+// Kind returns the imputer family name ("mean", "median", "mode").
+func (m *imputer) Kind() string { return m.kind }
+
+// FillValues returns the fitted fill value keyed by column name.
+func (m *imputer) FillValues() map[string]any {
+	out := make(map[string]any, len(m.cols))
+	for _, c := range m.cols {
+		out[c.name] = c.fill
+	}
+	return out
+}
+
+// Fit learns a fill value for each of cols without modifying dt. With no
+// cols every column is used, skipping (like FillWithMean) columns that are
+// non-numeric for the mean and median imputers or have no observed values.
+// A listed column that cannot be fitted is an error.
+func (m *imputer) Fit(dt *DataTable, cols ...string) error {
+	if dt == nil {
+		return fmt.Errorf("%sImputer.Fit: table is nil", m.kind)
+	}
+	fitted := []imputerColumn{}
+	var err error
+	dt.AtomicDo(func(t *DataTable) {
+		explicit := len(cols) > 0
+		indices := make([]int, 0, len(t.columns))
+		if explicit {
+			seen := map[int]struct{}{}
+			for _, ref := range cols {
+				idx, _, ok := resolveEncodingColumn(t, ref)
+				if !ok {
+					err = fmt.Errorf("%sImputer.Fit: column %q not found", m.kind, ref)
+					return
+				}
+				if _, dup := seen[idx]; dup {
+					err = fmt.Errorf("%sImputer.Fit: column %q listed more than once", m.kind, ref)
+					return
+				}
+				seen[idx] = struct{}{}
+				indices = append(indices, idx)
+			}
+		} else {
+			for idx := range t.columns {
+				indices = append(indices, idx)
+			}
+		}
+		for _, idx := range indices {
+			name := t.columns[idx].name
+			if name == "" {
+				name = fallbackEncodingColumnName(idx)
+			}
+			fill, fitErr := m.fillValue(t.columns[idx].data)
+			if fitErr != nil {
+				if explicit {
+					err = fmt.Errorf("%sImputer.Fit: column %q: %w", m.kind, name, fitErr)
+					return
+				}
+				continue
+			}
+			fitted = append(fitted, imputerColumn{name: name, fill: fill})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	m.cols = fitted
+	m.fitted = true
+	return nil
+}
+
+// FitTransform fits on cols and immediately returns the filled table.
+func (m *imputer) FitTransform(dt *DataTable, cols ...string) (*DataTable, error) {
+	if err := m.Fit(dt, cols...); err != nil {
+		return nil, err
+	}
+	return m.Transform(dt)
+}
+
+// Transform fills missing cells (nil and NaN) of the fitted columns with the
+// fitted values and returns a new table. Other columns pass through; a
+// fitted column missing from dt is an error.
+func (m *imputer) Transform(dt *DataTable) (*DataTable, error) {
+	if !m.fitted {
+		return nil, fmt.Errorf("%sImputer.Transform: imputer is not fitted", m.kind)
+	}
+	if dt == nil {
+		return nil, fmt.Errorf("%sImputer.Transform: table is nil", m.kind)
+	}
+	out := NewDataTable()
+	var err error
+	dt.AtomicDo(func(t *DataTable) {
+		fillByIndex := map[int]any{}
+		for _, c := range m.cols {
+			idx, _, ok := resolveEncodingColumn(t, c.name)
+			if !ok {
+				err = fmt.Errorf("%sImputer.Transform: fitted column %q not found", m.kind, c.name)
+				return
+			}
+			fillByIndex[idx] = c.fill
+		}
+		outCols := make([]*DataList, len(t.columns))
+		for idx, col := range t.columns {
+			outCols[idx] = col.Clone()
+			fill, ok := fillByIndex[idx]
+			if !ok {
+				continue
+			}
+			for i, v := range outCols[idx].data {
+				if isMissing(v) {
+					outCols[idx].data[i] = fill
+				}
+			}
+		}
+		out.AppendCols(outCols...)
+		copyRowNamesNotAtomic(out, t)
+		out.name = t.name
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InverseTransform returns a copy of dt. Imputation cannot be undone; it is
+// provided so that pipelines containing an imputer stay invertible.
+func (m *imputer) InverseTransform(dt *DataTable) (*DataTable, error) {
+	if dt == nil {
+		return nil, fmt.Errorf("%sImputer.InverseTransform: table is nil", m.kind)
+	}
+	return dt.Clone(), nil
+}
+
+func (m *imputer) fillValue(data []any) (any, error) {
+	if m.kind == "mode" {
+		mode, ok := observedMode(data)
+		if !ok {
+			return nil, fmt.Errorf("no observed values")
+		}
+		return mode, nil
+	}
+	values := numericObservedValues(data)
+	for _, v := range data {
+		if isMissing(v) {
+			continue
+		}
+		if _, ok := ToFloat64Safe(v); !ok {
+			return nil, fmt.Errorf("non-numeric value %v", v)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no observed values")
+	}
+	if m.kind == "mean" {
+		return meanOf(values), nil
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2, nil
+	}
+	return values[mid], nil
+}
@@ -0,0 +1,135 @@
+package insyra
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func imputerTrainTable() *DataTable {
+	return NewDataTable(
+		NewDataList(1.0, nil, 3.0, 8.0, math.NaN()).SetName("x"),
+		NewDataList("a", "b", nil, "b", "a").SetName("group"),
+	)
+}
+
+func TestImputersLearnFillValues(t *testing.T) {
+	train := imputerTrainTable()
+	cases := []struct {
+		imp  Imputer
+		cols []string
+		want map[string]any
+	}{
+		{NewMeanImputer(), nil, map[string]any{"x": 4.0}},
+		{NewMedianImputer(), []string{"x"}, map[string]any{"x": 3.0}},
+		{NewModeImputer(), nil, map[string]any{"x": 1.0, "group": "a"}},
+	}
+	for _, c := range cases {
+		if err := c.imp.Fit(train, c.cols...); err != nil {
+			t.Fatalf("%s: %v", c.imp.Kind(), err)
+		}
+		if got := c.imp.FillValues(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s fill values %v, want %v", c.imp.Kind(), got, c.want)
+		}
+	}
+
+	if err := NewMeanImputer().Fit(train, "group"); err == nil {
+		t.Errorf("expected error fitting a mean imputer on a string column")
+	}
+	if _, err := NewModeImputer().Transform(train); err == nil {
+		t.Errorf("expected error transforming with an unfitted imputer")
+	}
+}
+
+func TestImputerFillsTestDataWithTrainingValues(t *testing.T) {
+	imp := NewMedianImputer()
+	if err := imp.Fit(imputerTrainTable(), "x"); err != nil {
+		t.Fatal(err)
+	}
+	test := NewDataTable(
+		NewDataList(nil, 100.0, math.NaN()).SetName("x"),
+		NewDataList("a", nil, "b").SetName("group"),
+	)
+	out, err := imp.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertApproxSlice(t, floatsOf(t, out.GetColByName("x")), []float64{3, 100, 3})
+	assertEncodeData(t, out.GetColByName("group"), []any{"a", nil, "b"})
+	if test.GetColByName("x").Get(0) != nil {
+		t.Errorf("Transform modified its input")
+	}
+	if _, err := imp.Transform(NewDataTable(NewDataList(1.0).SetName("y"))); err == nil {
+		t.Errorf("expected error for a missing fitted column")
+	}
+}
+
+func TestPipelineFitAndTransform(t *testing.T) {
+	train := NewDataTable(
+		NewDataList("a", "b", nil, "a").SetName("group"),
+		NewDataList(1.0, nil, 3.0, 8.0).SetName("x"),
+	)
+	newPipeline := func() *Pipeline {
+		return NewPipeline(
+			ColumnStep(NewMedianImputer(), "x"),
+			ColumnStep(NewModeImputer(), "group"),
+			NewOneHotEncoder(OneHotOptions{Columns: []string{"group"}}),
+			ColumnStep(NewStandardScaler(), "x"),
+		)
+	}
+	p := newPipeline()
+	if err := p.Fit(train); err != nil {
+		t.Fatal(err)
+	}
+	test := NewDataTable(
+		NewDataList("b", nil).SetName("group"),
+		NewDataList(nil, 5.0).SetName("x"),
+	)
+	got, err := p.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"group_a", "group_b", "x"}; !reflect.DeepEqual(got.ColNames(), want) {
+		t.Fatalf("columns %v, want %v", got.ColNames(), want)
+	}
+	// x is imputed with the training median 3, then standardised with the
+	// training mean 3.75 and sample SD of {1, 3, 3, 8}.
+	sd := math.Sqrt((2.75*2.75 + 0.75*0.75 + 0.75*0.75 + 4.25*4.25) / 3)
+	assertApproxSlice(t, floatsOf(t, got.GetColByName("x")), []float64{(3 - 3.75) / sd, (5 - 3.75) / sd})
+	assertEncodeData(t, got.GetColByName("group_a"), []any{0, 1})
+	assertEncodeData(t, got.GetColByName("group_b"), []any{1, 0})
+
+	fitted, err := newPipeline().FitTransform(train)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := p.Transform(train)
+	assertApproxSlice(t, floatsOf(t, fitted.GetColByName("x")), floatsOf(t, want.GetColByName("x")))
+
+	back, err := p.InverseTransform(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEncodeData(t, back.GetColByName("group"), []any{"b", "a"})
+	assertApproxSlice(t, floatsOf(t, back.GetColByName("x")), []float64{3, 5})
+
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	if err := SaveModel(path, p); err != nil {
+		t.Fatal(err)
+	}
+	var loaded Pipeline
+	if err := LoadModel(path, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := loaded.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertApproxSlice(t, floatsOf(t, reloaded.GetColByName("x")), floatsOf(t, got.GetColByName("x")))
+	assertEncodeData(t, reloaded.GetColByName("group_b"), []any{1, 0})
+	// The loaded pipeline keeps its column bindings and can be refitted.
+	if err := loaded.Fit(test); err != nil {
+		t.Fatal(err)
+	}
+}
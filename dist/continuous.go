@@ -0,0 +1,234 @@
+package dist
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Distribution is the behaviour shared by every distribution in the
+// package. LogProb is the log density for continuous distributions and the
+// log mass for discrete ones. Rand draws from src, or from the global
+// source when src is nil.
+type Distribution interface {
+	LogProb(x float64) float64
+	CDF(x float64) float64
+	Quantile(p float64) float64
+	Mean() float64
+	Variance() float64
+	Rand(src *rand.Rand) float64
+}
+
+// source adapts an optional *rand.Rand to the gonum Src field.
+func source(src *rand.Rand) rand.Source {
+	if src == nil {
+		return nil
+	}
+	return src
+}
+
+func invalidProbability(p float64) bool {
+	return math.IsNaN(p) || p < 0 || p > 1
+}
+
+// Normal is the normal distribution with mean Mu and standard deviation
+// Sigma.
+type Normal struct {
+	Mu, Sigma float64
+}
+
+func (d Normal) gonum(src *rand.Rand) distuv.Normal {
+	return distuv.Normal{Mu: d.Mu, Sigma: d.Sigma, Src: source(src)}
+}
+
+func (d Normal) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d Normal) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d Normal) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d Normal) Mean() float64               { return d.Mu }
+func (d Normal) Variance() float64           { return d.Sigma * d.Sigma }
+func (d Normal) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Normal) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// StudentsT is Student's t distribution with Nu degrees of freedom.
+type StudentsT struct {
+	Nu float64
+}
+
+func (d StudentsT) gonum(src *rand.Rand) distuv.StudentsT {
+	return distuv.StudentsT{Mu: 0, Sigma: 1, Nu: d.Nu, Src: source(src)}
+}
+
+func (d StudentsT) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d StudentsT) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d StudentsT) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d StudentsT) Mean() float64               { return d.gonum(nil).Mean() }
+func (d StudentsT) Variance() float64           { return d.gonum(nil).Variance() }
+func (d StudentsT) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d StudentsT) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// ChiSquared is the chi-square distribution with K degrees of freedom.
+type ChiSquared struct {
+	K float64
+}
+
+func (d ChiSquared) gonum(src *rand.Rand) distuv.ChiSquared {
+	return distuv.ChiSquared{K: d.K, Src: source(src)}
+}
+
+func (d ChiSquared) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d ChiSquared) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d ChiSquared) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d ChiSquared) Mean() float64               { return d.K }
+func (d ChiSquared) Variance() float64           { return 2 * d.K }
+func (d ChiSquared) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d ChiSquared) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// F is Fisher's F distribution with D1 and D2 degrees of freedom.
+type F struct {
+	D1, D2 float64
+}
+
+func (d F) gonum(src *rand.Rand) distuv.F {
+	return distuv.F{D1: d.D1, D2: d.D2, Src: source(src)}
+}
+
+func (d F) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d F) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d F) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d F) Mean() float64               { return d.gonum(nil).Mean() }
+func (d F) Variance() float64           { return d.gonum(nil).Variance() }
+func (d F) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d F) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// Gamma is the gamma distribution with the given Shape and Rate (R's
+// dgamma(x, shape, rate)).
+type Gamma struct {
+	Shape, Rate float64
+}
+
+func (d Gamma) gonum(src *rand.Rand) distuv.Gamma {
+	return distuv.Gamma{Alpha: d.Shape, Beta: d.Rate, Src: source(src)}
+}
+
+func (d Gamma) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d Gamma) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d Gamma) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d Gamma) Mean() float64               { return d.Shape / d.Rate }
+func (d Gamma) Variance() float64           { return d.Shape / (d.Rate * d.Rate) }
+func (d Gamma) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Gamma) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// Beta is the beta distribution with shape parameters Alpha and Beta.
+type Beta struct {
+	Alpha, Beta float64
+}
+
+func (d Beta) gonum(src *rand.Rand) distuv.Beta {
+	return distuv.Beta{Alpha: d.Alpha, Beta: d.Beta, Src: source(src)}
+}
+
+func (d Beta) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d Beta) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d Beta) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d Beta) Mean() float64               { return d.gonum(nil).Mean() }
+func (d Beta) Variance() float64           { return d.gonum(nil).Variance() }
+func (d Beta) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Beta) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// Exponential is the exponential distribution with the given Rate.
+type Exponential struct {
+	Rate float64
+}
+
+func (d Exponential) gonum(src *rand.Rand) distuv.Exponential {
+	return distuv.Exponential{Rate: d.Rate, Src: source(src)}
+}
+
+func (d Exponential) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d Exponential) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d Exponential) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d Exponential) Mean() float64               { return 1 / d.Rate }
+func (d Exponential) Variance() float64           { return 1 / (d.Rate * d.Rate) }
+func (d Exponential) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Exponential) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// LogNormal is the distribution of exp(X) for X ~ Normal(Mu, Sigma).
+type LogNormal struct {
+	Mu, Sigma float64
+}
+
+func (d LogNormal) gonum(src *rand.Rand) distuv.LogNormal {
+	return distuv.LogNormal{Mu: d.Mu, Sigma: d.Sigma, Src: source(src)}
+}
+
+func (d LogNormal) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d LogNormal) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d LogNormal) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d LogNormal) Mean() float64               { return d.gonum(nil).Mean() }
+func (d LogNormal) Variance() float64           { return d.gonum(nil).Variance() }
+func (d LogNormal) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d LogNormal) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
+
+// Weibull is the Weibull distribution with the given Shape and Scale (R's
+// dweibull(x, shape, scale)).
+type Weibull struct {
+	Shape, Scale float64
+}
+
+func (d Weibull) gonum(src *rand.Rand) distuv.Weibull {
+	return distuv.Weibull{K: d.Shape, Lambda: d.Scale, Src: source(src)}
+}
+
+func (d Weibull) PDF(x float64) float64       { return d.gonum(nil).Prob(x) }
+func (d Weibull) LogProb(x float64) float64   { return d.gonum(nil).LogProb(x) }
+func (d Weibull) CDF(x float64) float64       { return d.gonum(nil).CDF(x) }
+func (d Weibull) Mean() float64               { return d.gonum(nil).Mean() }
+func (d Weibull) Variance() float64           { return d.gonum(nil).Variance() }
+func (d Weibull) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Weibull) Quantile(p float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	return d.gonum(nil).Quantile(p)
+}
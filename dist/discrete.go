@@ -0,0 +1,224 @@
+package dist
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"gonum.org/v1/gonum/mathext"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+func isCount(x float64) bool {
+	return x >= 0 && x == math.Floor(x) && !math.IsInf(x, 0)
+}
+
+func logChoose(n, k float64) float64 {
+	a, _ := math.Lgamma(n + 1)
+	b, _ := math.Lgamma(k + 1)
+	c, _ := math.Lgamma(n - k + 1)
+	return a - b - c
+}
+
+// discreteQuantile returns the smallest integer k in [lower, upper] with
+// cdf(k) >= p, searching outwards from guess. The small fuzz mirrors R's
+// protection against rounding just below p.
+func discreteQuantile(cdf func(k float64) float64, p, guess, lower, upper float64) float64 {
+	if invalidProbability(p) {
+		return math.NaN()
+	}
+	if p == 0 {
+		return lower
+	}
+	if p == 1 {
+		return upper
+	}
+	target := p * (1 - 64*2.220446049250313e-16)
+	k := math.Max(lower, math.Min(upper, math.Floor(guess)))
+	if math.IsNaN(k) {
+		k = lower
+	}
+	if cdf(k) >= target {
+		for k > lower && cdf(k-1) >= target {
+			k--
+		}
+		return k
+	}
+	for k < upper && cdf(k) < target {
+		k++
+	}
+	return k
+}
+
+// normalGuess is a starting point for discrete quantile searches.
+func normalGuess(mean, variance, p float64) float64 {
+	return mean + math.Sqrt(variance)*distuv.UnitNormal.Quantile(p)
+}
+
+// Binomial is the number of successes in N trials with success probability
+// P.
+type Binomial struct {
+	N int
+	P float64
+}
+
+func (d Binomial) gonum(src *rand.Rand) distuv.Binomial {
+	return distuv.Binomial{N: float64(d.N), P: d.P, Src: source(src)}
+}
+
+func (d Binomial) PMF(x float64) float64 { return math.Exp(d.LogProb(x)) }
+func (d Binomial) LogProb(x float64) float64 {
+	if !isCount(x) || x > float64(d.N) {
+		return math.Inf(-1)
+	}
+	return d.gonum(nil).LogProb(x)
+}
+func (d Binomial) CDF(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x >= float64(d.N) {
+		return 1
+	}
+	return d.gonum(nil).CDF(math.Floor(x))
+}
+func (d Binomial) Mean() float64               { return float64(d.N) * d.P }
+func (d Binomial) Variance() float64           { return float64(d.N) * d.P * (1 - d.P) }
+func (d Binomial) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Binomial) Quantile(p float64) float64 {
+	return discreteQuantile(d.CDF, p, normalGuess(d.Mean(), d.Variance(), p), 0, float64(d.N))
+}
+
+// Poisson is the Poisson distribution with mean Lambda.
+type Poisson struct {
+	Lambda float64
+}
+
+func (d Poisson) gonum(src *rand.Rand) distuv.Poisson {
+	return distuv.Poisson{Lambda: d.Lambda, Src: source(src)}
+}
+
+func (d Poisson) PMF(x float64) float64 { return math.Exp(d.LogProb(x)) }
+func (d Poisson) LogProb(x float64) float64 {
+	if !isCount(x) {
+		return math.Inf(-1)
+	}
+	return d.gonum(nil).LogProb(x)
+}
+func (d Poisson) CDF(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	return d.gonum(nil).CDF(math.Floor(x))
+}
+func (d Poisson) Mean() float64               { return d.Lambda }
+func (d Poisson) Variance() float64           { return d.Lambda }
+func (d Poisson) Rand(src *rand.Rand) float64 { return d.gonum(src).Rand() }
+func (d Poisson) Quantile(p float64) float64 {
+	return discreteQuantile(d.CDF, p, normalGuess(d.Lambda, d.Lambda, p), 0, math.Inf(1))
+}
+
+// NegativeBinomial is the number of failures before the Size-th success
+// with success probability P (R's dnbinom(x, size, prob)). Size may be
+// fractional.
+type NegativeBinomial struct {
+	Size, P float64
+}
+
+func (d NegativeBinomial) PMF(x float64) float64 { return math.Exp(d.LogProb(x)) }
+func (d NegativeBinomial) LogProb(x float64) float64 {
+	if !isCount(x) || d.Size <= 0 || d.P <= 0 || d.P > 1 {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(x + d.Size)
+	b, _ := math.Lgamma(d.Size)
+	c, _ := math.Lgamma(x + 1)
+	return a - b - c + d.Size*math.Log(d.P) + x*math.Log1p(-d.P)
+}
+func (d NegativeBinomial) CDF(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if d.P == 1 {
+		return 1
+	}
+	return mathext.RegIncBeta(d.Size, math.Floor(x)+1, d.P)
+}
+func (d NegativeBinomial) Mean() float64     { return d.Size * (1 - d.P) / d.P }
+func (d NegativeBinomial) Variance() float64 { return d.Size * (1 - d.P) / (d.P * d.P) }
+
+// Rand draws from the gamma-Poisson mixture representation.
+func (d NegativeBinomial) Rand(src *rand.Rand) float64 {
+	if d.P == 1 {
+		return 0
+	}
+	lambda := distuv.Gamma{Alpha: d.Size, Beta: d.P / (1 - d.P), Src: source(src)}.Rand()
+	return distuv.Poisson{Lambda: lambda, Src: source(src)}.Rand()
+}
+func (d NegativeBinomial) Quantile(p float64) float64 {
+	return discreteQuantile(d.CDF, p, normalGuess(d.Mean(), d.Variance(), p), 0, math.Inf(1))
+}
+
+// Hypergeometric is the number of white balls among Draws balls drawn
+// without replacement from an urn of White white and Black black balls (R's
+// dhyper(x, m, n, k)).
+type Hypergeometric struct {
+	White, Black, Draws int
+}
+
+func (d Hypergeometric) support() (float64, float64) {
+	return float64(max(0, d.Draws-d.Black)), float64(min(d.Draws, d.White))
+}
+
+func (d Hypergeometric) PMF(x float64) float64 { return math.Exp(d.LogProb(x)) }
+func (d Hypergeometric) LogProb(x float64) float64 {
+	lo, hi := d.support()
+	if !isCount(x) || x < lo || x > hi {
+		return math.Inf(-1)
+	}
+	m, n, k := float64(d.White), float64(d.Black), float64(d.Draws)
+	return logChoose(m, x) + logChoose(n, k-x) - logChoose(m+n, k)
+}
+func (d Hypergeometric) CDF(x float64) float64 {
+	lo, hi := d.support()
+	if x < lo {
+		return 0
+	}
+	if x >= hi {
+		return 1
+	}
+	s := 0.0
+	for k := lo; k <= math.Floor(x); k++ {
+		s += d.PMF(k)
+	}
+	return math.Min(1, s)
+}
+func (d Hypergeometric) Mean() float64 {
+	return float64(d.Draws) * float64(d.White) / float64(d.White+d.Black)
+}
+func (d Hypergeometric) Variance() float64 {
+	N := float64(d.White + d.Black)
+	p := float64(d.White) / N
+	k := float64(d.Draws)
+	return k * p * (1 - p) * (N - k) / (N - 1)
+}
+
+// Rand inverts the CDF with a single uniform draw.
+func (d Hypergeometric) Rand(src *rand.Rand) float64 {
+	u := rand.Float64()
+	if src != nil {
+		u = src.Float64()
+	}
+	lo, hi := d.support()
+	c := 0.0
+	for k := lo; k < hi; k++ {
+		c += d.PMF(k)
+		if u < c {
+			return k
+		}
+	}
+	return hi
+}
+func (d Hypergeometric) Quantile(p float64) float64 {
+	lo, hi := d.support()
+	return discreteQuantile(d.CDF, p, lo, lo, hi)
+}
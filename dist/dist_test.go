@@ -0,0 +1,255 @@
+package dist
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mathext"
+)
+
+func almostEqual(a, b, eps float64) bool {
+	return math.Abs(a-b) <= eps
+}
+
+func TestDistributionValues(t *testing.T) {
+	// Reference values from R's d/p/q functions.
+	cases := []struct {
+		name      string
+		got, want float64
+	}{
+		{"qnorm(0.975)", Normal{0, 1}.Quantile(0.975), 1.959963984540054},
+		{"pt(2, 10)", StudentsT{10}.CDF(2), 0.9633059826146},
+		{"qt(0.975, 10)", StudentsT{10}.Quantile(0.975), 2.228138851986},
+		{"pchisq(3.84, 1)", ChiSquared{1}.CDF(3.84), 0.9499565},
+		{"qf(0.95, 3, 20)", F{3, 20}.Quantile(0.95), 3.098391},
+		{"qgamma(0.5, 2, 1)", Gamma{2, 1}.Quantile(0.5), 1.678347},
+		{"pweibull(1, 2, 1)", Weibull{2, 1}.CDF(1), 1 - math.Exp(-1)},
+		{"pbinom(3, 10, 0.5)", Binomial{10, 0.5}.CDF(3), 176.0 / 1024},
+		{"qbinom(0.5, 10, 0.3)", Binomial{10, 0.3}.Quantile(0.5), 3},
+		{"ppois(2, 3)", Poisson{3}.CDF(2), 0.4231901},
+		{"qpois(0.9, 4)", Poisson{4}.Quantile(0.9), 7},
+		{"dnbinom(3, 2, 0.5)", NegativeBinomial{2, 0.5}.PMF(3), 0.125},
+		{"pnbinom(3, 2, 0.5)", NegativeBinomial{2, 0.5}.CDF(3), 0.8125},
+		{"qnbinom(0.8125, 2, 0.5)", NegativeBinomial{2, 0.5}.Quantile(0.8125), 3},
+		{"dhyper(1, 5, 5, 3)", Hypergeometric{5, 5, 3}.PMF(1), 50.0 / 120},
+		{"phyper(1, 5, 5, 3)", Hypergeometric{5, 5, 3}.CDF(1), 0.5},
+		{"qhyper(0.6, 5, 5, 3)", Hypergeometric{5, 5, 3}.Quantile(0.6), 2},
+	}
+	for _, c := range cases {
+		if !almostEqual(c.got, c.want, 1e-6) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if (Poisson{3}).PMF(1.5) != 0 || !math.IsNaN((Normal{0, 1}).Quantile(1.5)) {
+		t.Error("invalid inputs should give zero mass and NaN quantiles")
+	}
+}
+
+func TestSampleIsSeeded(t *testing.T) {
+	opts := insyra.SamplingOptions{UseSeed: true, Seed: 42}
+	for _, d := range []Distribution{Normal{1, 2}, Gamma{3, 2}, Poisson{4}, NegativeBinomial{2, 0.3}, Hypergeometric{7, 5, 6}} {
+		a, err := Sample(d, 2000, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := Sample(d, 2000, opts)
+		av, bv := a.ToF64Slice(), b.ToF64Slice()
+		mean := 0.0
+		for i := range av {
+			if av[i] != bv[i] {
+				t.Fatalf("%T: seeded samples differ", d)
+			}
+			mean += av[i]
+		}
+		mean /= float64(len(av))
+		if math.Abs(mean-d.Mean()) > 4*math.Sqrt(d.Variance()/float64(len(av))) {
+			t.Errorf("%T: sample mean %v, want %v", d, mean, d.Mean())
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	x := []float64{2.1, 3.4, 1.9, 5.6, 2.8, 4.4, 3.1, 2.2, 6.3, 3.9}
+	dl := insyra.NewDataList(x)
+	n := float64(len(x))
+	mean, logMean := 0.0, 0.0
+	for _, v := range x {
+		mean += v
+		logMean += math.Log(v)
+	}
+	mean /= n
+	logMean /= n
+
+	normal, err := Fit(dl, FamilyNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - mean) * (v - mean)
+	}
+	sigma := math.Sqrt(ss / n)
+	wantLL := -n / 2 * (math.Log(2*math.Pi*sigma*sigma) + 1)
+	if !almostEqual(normal.Params[0], mean, 1e-12) || !almostEqual(normal.Params[1], sigma, 1e-12) || !almostEqual(normal.LogLikelihood, wantLL, 1e-9) {
+		t.Errorf("normal fit = %v, ll %v", normal.Params, normal.LogLikelihood)
+	}
+	if !almostEqual(normal.AIC, 4-2*wantLL, 1e-9) {
+		t.Errorf("AIC = %v", normal.AIC)
+	}
+
+	// The gamma MLE satisfies log(k) - digamma(k) = log(mean) - mean(log x).
+	gamma, err := Fit(dl, FamilyGamma)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := gamma.Params[0]
+	if !almostEqual(math.Log(k)-mathext.Digamma(k), math.Log(mean)-logMean, 1e-10) || !almostEqual(gamma.Params[1], k/mean, 1e-12) {
+		t.Errorf("gamma fit = %v", gamma.Params)
+	}
+
+	// Every other fit should be a local maximum of the log-likelihood.
+	for _, fam := range []Family{FamilyWeibull, FamilyLogNormal, FamilyExponential, FamilyChiSquared} {
+		fit, err := Fit(dl, fam)
+		if err != nil {
+			t.Fatal(fam, err)
+		}
+		checkLocalMax(t, fit, x)
+	}
+	counts := []float64{0, 3, 1, 7, 2, 0, 5, 12, 1, 4, 0, 9}
+	nb, err := Fit(insyra.NewDataList(counts), FamilyNegativeBinomial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, p := nb.Params[0], nb.Params[1]
+	for _, s := range []float64{size * 0.99, size * 1.01} {
+		cm := 0.0
+		for _, v := range counts {
+			cm += v
+		}
+		cm /= float64(len(counts))
+		if logLikelihood(NegativeBinomial{s, s / (s + cm)}, counts) > nb.LogLikelihood+1e-9 {
+			t.Errorf("negative binomial size %v is not the MLE (p %v)", size, p)
+		}
+	}
+
+	betaData := []float64{0.12, 0.45, 0.33, 0.81, 0.27, 0.55, 0.61, 0.38, 0.22, 0.49}
+	beta, err := Fit(insyra.NewDataList(betaData), FamilyBeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLocalMax(t, beta, betaData)
+
+	if _, err := Fit(dl, FamilyBinomial); err == nil {
+		t.Error("binomial fit without Trials should fail")
+	}
+	if _, err := Fit(dl, FamilyHypergeometric); err == nil {
+		t.Error("hypergeometric fit without Trials should fail")
+	}
+
+	fData, err := Sample(F{D1: 5, D2: 12}, 400, insyra.SamplingOptions{UseSeed: true, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fFit, err := Fit(fData, FamilyF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fx := fData.ToF64Slice()
+	checkLocalMax(t, fFit, fx)
+	if fFit.Params[0] < 3 || fFit.Params[0] > 8 || fFit.Params[1] < 7 || fFit.Params[1] > 25 {
+		t.Errorf("F fit = %v, want near (5, 12)", fFit.Params)
+	}
+}
+
+func TestFitHypergeometric(t *testing.T) {
+	// Successes drawn out of a population of 60 with 12 draws each time.
+	x := []float64{3, 5, 2, 4, 4, 6, 3, 2, 5, 4}
+	dl := insyra.NewDataList(x)
+	fit, err := Fit(dl, FamilyHypergeometric, FitOptions{Trials: 12, Population: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, bestLL := 0, math.Inf(-1)
+	for m := 0; m <= 60; m++ {
+		if ll := logLikelihood(Hypergeometric{White: m, Black: 60 - m, Draws: 12}, x); ll > bestLL {
+			best, bestLL = m, ll
+		}
+	}
+	h := fit.Distribution.(Hypergeometric)
+	if h.White != best || h.Black != 60-best || h.Draws != 12 || !almostEqual(fit.LogLikelihood, bestLL, 1e-12) {
+		t.Errorf("fit %+v, want m = %d", h, best)
+	}
+	if !almostEqual(fit.AIC, 2-2*bestLL, 1e-9) {
+		t.Errorf("AIC = %v", fit.AIC)
+	}
+
+	// With one sample the population MLE is the Lincoln-Petersen estimate
+	// floor(kM/x) when kM/x is not an integer.
+	one, err := Fit(insyra.NewDataList([]float64{7, 7}), FamilyHypergeometric, FitOptions{Trials: 30, Successes: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := one.Distribution.(Hypergeometric); got.White+got.Black != 30*50/7 {
+		t.Errorf("population = %d, want %d", got.White+got.Black, 30*50/7)
+	}
+
+	pop, err := Fit(dl, FamilyHypergeometric, FitOptions{Trials: 12, Successes: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hp := pop.Distribution.(Hypergeometric)
+	bestN, bestLL := 0, math.Inf(-1)
+	for n := 15 + 12 - 2; n <= 2000; n++ {
+		if ll := logLikelihood(Hypergeometric{White: 15, Black: n - 15, Draws: 12}, x); ll > bestLL {
+			bestN, bestLL = n, ll
+		}
+	}
+	if hp.White+hp.Black != bestN {
+		t.Errorf("population = %d, want %d", hp.White+hp.Black, bestN)
+	}
+
+	for _, opt := range []FitOptions{
+		{Trials: 12},
+		{Trials: 12, Population: 60, Successes: 15},
+		{Trials: 12, Successes: 4},
+		{Trials: 3, Population: 60},
+	} {
+		if _, err := Fit(dl, FamilyHypergeometric, opt); err == nil {
+			t.Errorf("%+v: expected error", opt)
+		}
+	}
+	if _, err := Fit(insyra.NewDataList([]float64{0, 0}), FamilyHypergeometric, FitOptions{Trials: 5, Successes: 3}); err == nil {
+		t.Error("expected error for an unbounded population")
+	}
+}
+
+// checkLocalMax perturbs each parameter and checks that the fitted
+// log-likelihood is not beaten.
+func checkLocalMax(t *testing.T, fit *FitResult, x []float64) {
+	t.Helper()
+	for i := range fit.Params {
+		for _, f := range []float64{0.995, 1.005} {
+			p := append([]float64(nil), fit.Params...)
+			p[i] *= f
+			var d Distribution
+			switch fit.Family {
+			case FamilyWeibull:
+				d = Weibull{p[0], p[1]}
+			case FamilyLogNormal:
+				d = LogNormal{p[0], p[1]}
+			case FamilyExponential:
+				d = Exponential{p[0]}
+			case FamilyChiSquared:
+				d = ChiSquared{p[0]}
+			case FamilyBeta:
+				d = Beta{p[0], p[1]}
+			case FamilyF:
+				d = F{p[0], p[1]}
+			}
+			if ll := logLikelihood(d, x); ll > fit.LogLikelihood+1e-9 {
+				t.Errorf("%s: perturbed params %v beat the fit (%v > %v)", fit.Family, p, ll, fit.LogLikelihood)
+			}
+		}
+	}
+}
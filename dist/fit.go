@@ -0,0 +1,500 @@
+package dist
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mathext"
+)
+
+type Family string
+
+const (
+	FamilyNormal           Family = "normal"
+	FamilyStudentsT        Family = "t"
+	FamilyChiSquared       Family = "chi-squared"
+	FamilyF                Family = "f"
+	FamilyGamma            Family = "gamma"
+	FamilyBeta             Family = "beta"
+	FamilyExponential      Family = "exponential"
+	FamilyLogNormal        Family = "lognormal"
+	FamilyWeibull          Family = "weibull"
+	FamilyBinomial         Family = "binomial"
+	FamilyPoisson          Family = "poisson"
+	FamilyNegativeBinomial Family = "negative-binomial"
+	FamilyHypergeometric   Family = "hypergeometric"
+)
+
+type FitOptions struct {
+	// Trials is the known number of trials for FamilyBinomial and the
+	// number of draws for FamilyHypergeometric.
+	Trials int
+	// Population and Successes are the hypergeometric population size and
+	// number of successes in it. Give exactly one; the other is estimated.
+	Population int
+	Successes  int
+}
+
+// FitResult is a maximum-likelihood fit. Distribution holds the fitted
+// value (for example a Normal) and Params its parameters in the order of
+// ParamNames.
+type FitResult struct {
+	Family        Family
+	Distribution  Distribution
+	ParamNames    []string
+	Params        []float64
+	LogLikelihood float64
+	AIC           float64
+	BIC           float64
+	N             int
+}
+
+// Fit estimates the parameters of a family by maximum likelihood. The
+// normal and lognormal standard deviations use the ML divisor n. The
+// Student's t fit estimates only the degrees of freedom of the standard t,
+// so the data must already be centred and scaled. The binomial fit needs
+// FitOptions.Trials; the hypergeometric fit needs Trials and one of
+// Population and Successes.
+func Fit(dl insyra.IDataList, family Family, opts ...FitOptions) (*FitResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt FitOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if dl == nil {
+		return nil, errors.New("data is nil")
+	}
+	x := make([]float64, 0, dl.Len())
+	for i, v := range dl.Data() {
+		f, ok := insyra.ToFloat64Safe(v)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("data must contain finite numeric values (index %d)", i)
+		}
+		x = append(x, f)
+	}
+	if len(x) < 2 {
+		return nil, errors.New("at least two observations are required")
+	}
+	n := float64(len(x))
+	mean, logMean := 0.0, 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= n
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - mean) * (v - mean)
+	}
+	positive := func() error {
+		for _, v := range x {
+			if v <= 0 {
+				return fmt.Errorf("%s fit requires positive data", family)
+			}
+			logMean += math.Log(v)
+		}
+		logMean /= n
+		return nil
+	}
+	counts := func() error {
+		for _, v := range x {
+			if !isCount(v) {
+				return fmt.Errorf("%s fit requires non-negative integer data", family)
+			}
+		}
+		return nil
+	}
+
+	var d Distribution
+	var names []string
+	var params []float64
+	switch family {
+	case FamilyNormal:
+		sigma := math.Sqrt(ss / n)
+		if sigma == 0 {
+			return nil, errors.New("data has zero variance")
+		}
+		d, names, params = Normal{Mu: mean, Sigma: sigma}, []string{"mu", "sigma"}, []float64{mean, sigma}
+	case FamilyLogNormal:
+		if err := positive(); err != nil {
+			return nil, err
+		}
+		v := 0.0
+		for _, xi := range x {
+			v += (math.Log(xi) - logMean) * (math.Log(xi) - logMean)
+		}
+		sigma := math.Sqrt(v / n)
+		if sigma == 0 {
+			return nil, errors.New("data has zero variance")
+		}
+		d, names, params = LogNormal{Mu: logMean, Sigma: sigma}, []string{"meanlog", "sdlog"}, []float64{logMean, sigma}
+	case FamilyExponential:
+		for _, v := range x {
+			if v < 0 {
+				return nil, errors.New("exponential fit requires non-negative data")
+			}
+		}
+		if mean == 0 {
+			return nil, errors.New("data mean must be positive")
+		}
+		d, names, params = Exponential{Rate: 1 / mean}, []string{"rate"}, []float64{1 / mean}
+	case FamilyGamma:
+		if err := positive(); err != nil {
+			return nil, err
+		}
+		shape, err := fitGammaShape(math.Log(mean) - logMean)
+		if err != nil {
+			return nil, err
+		}
+		d, names, params = Gamma{Shape: shape, Rate: shape / mean}, []string{"shape", "rate"}, []float64{shape, shape / mean}
+	case FamilyBeta:
+		a, b, err := fitBeta(x, mean, ss/(n-1))
+		if err != nil {
+			return nil, err
+		}
+		d, names, params = Beta{Alpha: a, Beta: b}, []string{"shape1", "shape2"}, []float64{a, b}
+	case FamilyWeibull:
+		if err := positive(); err != nil {
+			return nil, err
+		}
+		shape, scale, err := fitWeibull(x, logMean)
+		if err != nil {
+			return nil, err
+		}
+		d, names, params = Weibull{Shape: shape, Scale: scale}, []string{"shape", "scale"}, []float64{shape, scale}
+	case FamilyStudentsT:
+		nu := math.Exp(goldenMax(func(lnu float64) float64 {
+			return logLikelihood(StudentsT{Nu: math.Exp(lnu)}, x)
+		}, math.Log(1e-2), math.Log(1e6)))
+		d, names, params = StudentsT{Nu: nu}, []string{"df"}, []float64{nu}
+	case FamilyChiSquared:
+		if err := positive(); err != nil {
+			return nil, err
+		}
+		k := math.Exp(goldenMax(func(lk float64) float64 {
+			return logLikelihood(ChiSquared{K: math.Exp(lk)}, x)
+		}, math.Log(1e-4), math.Log(1e6)))
+		d, names, params = ChiSquared{K: k}, []string{"df"}, []float64{k}
+	case FamilyPoisson:
+		if err := counts(); err != nil {
+			return nil, err
+		}
+		if mean == 0 {
+			return nil, errors.New("data mean must be positive")
+		}
+		d, names, params = Poisson{Lambda: mean}, []string{"lambda"}, []float64{mean}
+	case FamilyBinomial:
+		if err := counts(); err != nil {
+			return nil, err
+		}
+		if opt.Trials <= 0 {
+			return nil, errors.New("binomial fit requires Trials")
+		}
+		for _, v := range x {
+			if v > float64(opt.Trials) {
+				return nil, errors.New("binomial data exceed Trials")
+			}
+		}
+		p := mean / float64(opt.Trials)
+		d, names, params = Binomial{N: opt.Trials, P: p}, []string{"size", "prob"}, []float64{float64(opt.Trials), p}
+	case FamilyNegativeBinomial:
+		if err := counts(); err != nil {
+			return nil, err
+		}
+		if ss/(n-1) <= mean {
+			return nil, errors.New("negative binomial fit requires overdispersed data (variance > mean)")
+		}
+		size := math.Exp(goldenMax(func(lr float64) float64 {
+			r := math.Exp(lr)
+			return logLikelihood(NegativeBinomial{Size: r, P: r / (r + mean)}, x)
+		}, math.Log(1e-6), math.Log(1e8)))
+		p := size / (size + mean)
+		d, names, params = NegativeBinomial{Size: size, P: p}, []string{"size", "prob"}, []float64{size, p}
+	case FamilyF:
+		if err := positive(); err != nil {
+			return nil, err
+		}
+		d1, d2 := fitF(x, mean)
+		d, names, params = F{D1: d1, D2: d2}, []string{"df1", "df2"}, []float64{d1, d2}
+	case FamilyHypergeometric:
+		if err := counts(); err != nil {
+			return nil, err
+		}
+		h, err := fitHypergeometric(x, opt)
+		if err != nil {
+			return nil, err
+		}
+		d, names, params = h, []string{"m", "n", "k"}, []float64{float64(h.White), float64(h.Black), float64(h.Draws)}
+	default:
+		return nil, fmt.Errorf("unknown distribution family %q", family)
+	}
+
+	ll := logLikelihood(d, x)
+	if math.IsInf(ll, 0) || math.IsNaN(ll) {
+		return nil, errors.New("data lie outside the support of the fitted distribution")
+	}
+	k := float64(len(params))
+	switch family {
+	case FamilyBinomial:
+		k = 1 // size is known
+	case FamilyHypergeometric:
+		k = 1 // two of m, n and k are known
+	}
+	return &FitResult{
+		Family:        family,
+		Distribution:  d,
+		ParamNames:    names,
+		Params:        params,
+		LogLikelihood: ll,
+		AIC:           2*k - 2*ll,
+		BIC:           k*math.Log(n) - 2*ll,
+		N:             len(x),
+	}, nil
+}
+
+func logLikelihood(d Distribution, x []float64) float64 {
+	s := 0.0
+	for _, v := range x {
+		s += d.LogProb(v)
+	}
+	return s
+}
+
+// fitGammaShape solves log(k) - digamma(k) = s by Newton's method from
+// Minka's closed-form starting value.
+func fitGammaShape(s float64) (float64, error) {
+	if s <= 0 {
+		return 0, errors.New("data has zero variance")
+	}
+	k := (3 - s + math.Sqrt((s-3)*(s-3)+24*s)) / (12 * s)
+	for range 100 {
+		g := math.Log(k) - mathext.Digamma(k) - s
+		dg := 1/k - trigamma(k)
+		next := k - g/dg
+		if next <= 0 {
+			next = k / 2
+		}
+		if math.Abs(next-k) < 1e-12*k {
+			return next, nil
+		}
+		k = next
+	}
+	return k, nil
+}
+
+// fitBeta runs Newton's method on the beta log-likelihood from the method
+// of moments estimates.
+func fitBeta(x []float64, mean, variance float64) (float64, float64, error) {
+	n := float64(len(x))
+	lx, l1x := 0.0, 0.0
+	for _, v := range x {
+		if v <= 0 || v >= 1 {
+			return 0, 0, errors.New("beta fit requires data in (0, 1)")
+		}
+		lx += math.Log(v)
+		l1x += math.Log1p(-v)
+	}
+	lx /= n
+	l1x /= n
+	if variance <= 0 {
+		return 0, 0, errors.New("data has zero variance")
+	}
+	common := mean*(1-mean)/variance - 1
+	a, b := mean*common, (1-mean)*common
+	if a <= 0 || b <= 0 {
+		a, b = 1, 1
+	}
+	for range 200 {
+		psiAB := mathext.Digamma(a + b)
+		g1 := psiAB - mathext.Digamma(a) + lx
+		g2 := psiAB - mathext.Digamma(b) + l1x
+		tAB := trigamma(a + b)
+		h11, h22, h12 := tAB-trigamma(a), tAB-trigamma(b), tAB
+		det := h11*h22 - h12*h12
+		da := (h22*g1 - h12*g2) / det
+		db := (h11*g2 - h12*g1) / det
+		step := 1.0
+		for a-step*da <= 0 || b-step*db <= 0 {
+			step /= 2
+		}
+		a, b = a-step*da, b-step*db
+		if math.Abs(step*da) < 1e-12*a && math.Abs(step*db) < 1e-12*b {
+			break
+		}
+	}
+	return a, b, nil
+}
+
+// fitWeibull solves the shape score equation by bisection; the data are
+// rescaled by their maximum so x^k cannot overflow.
+func fitWeibull(x []float64, logMean float64) (float64, float64, error) {
+	xmax := 0.0
+	for _, v := range x {
+		xmax = math.Max(xmax, v)
+	}
+	lmax := math.Log(xmax)
+	score := func(k float64) float64 {
+		num, den := 0.0, 0.0
+		for _, v := range x {
+			z := math.Log(v) - lmax
+			w := math.Exp(k * z)
+			num += w * z
+			den += w
+		}
+		return num/den - 1/k - (logMean - lmax)
+	}
+	lo, hi := 1e-3, 1.0
+	for score(hi) < 0 {
+		hi *= 2
+		if hi > 1e6 {
+			return 0, 0, errors.New("weibull shape does not converge (data have no spread)")
+		}
+	}
+	for range 200 {
+		mid := (lo + hi) / 2
+		if score(mid) < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+		if hi-lo < 1e-13*hi {
+			break
+		}
+	}
+	k := (lo + hi) / 2
+	s := 0.0
+	for _, v := range x {
+		s += math.Exp(k * (math.Log(v) - lmax))
+	}
+	scale := xmax * math.Pow(s/float64(len(x)), 1/k)
+	return k, scale, nil
+}
+
+// fitF maximises the F log-likelihood by alternating one-dimensional
+// searches over log d1 and log d2, starting d2 from the method of moments.
+// The likelihood is flat near its maximum, so the golden-section searches
+// cannot settle the log parameters much closer than 1e-6.
+func fitF(x []float64, mean float64) (float64, float64) {
+	ll := func(l1, l2 float64) float64 {
+		return logLikelihood(F{D1: math.Exp(l1), D2: math.Exp(l2)}, x)
+	}
+	lo, hi := math.Log(1e-2), math.Log(1e6)
+	l1, l2 := 0.0, math.Log(10)
+	if mean > 1 {
+		l2 = math.Log(math.Min(2*mean/(mean-1), 1e6))
+	}
+	for range 200 {
+		n1 := goldenMax(func(v float64) float64 { return ll(v, l2) }, lo, hi)
+		n2 := goldenMax(func(v float64) float64 { return ll(n1, v) }, lo, hi)
+		done := math.Abs(n1-l1) < 1e-6 && math.Abs(n2-l2) < 1e-6
+		l1, l2 = n1, n2
+		if done {
+			break
+		}
+	}
+	return math.Exp(l1), math.Exp(l2)
+}
+
+// fitHypergeometric estimates whichever of the population size and the
+// number of successes is not given. The likelihood is unimodal in either,
+// so a walk from the moment estimate finds the smallest maximiser.
+func fitHypergeometric(x []float64, opt FitOptions) (Hypergeometric, error) {
+	draws := opt.Trials
+	if draws <= 0 {
+		return Hypergeometric{}, errors.New("hypergeometric fit requires Trials (the number of draws)")
+	}
+	if (opt.Population > 0) == (opt.Successes > 0) {
+		return Hypergeometric{}, errors.New("hypergeometric fit requires exactly one of Population and Successes")
+	}
+	lo, hi, sum := x[0], x[0], 0.0
+	for _, v := range x {
+		if v > float64(draws) {
+			return Hypergeometric{}, errors.New("hypergeometric data exceed Trials")
+		}
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+		sum += v
+	}
+	xMin, xMax, mean := int(lo), int(hi), sum/float64(len(x))
+
+	var build func(int) Hypergeometric
+	var kMin, kMax, start int
+	if opt.Population > 0 {
+		pop := opt.Population
+		if draws > pop {
+			return Hypergeometric{}, errors.New("Trials exceeds Population")
+		}
+		build = func(s int) Hypergeometric { return Hypergeometric{White: s, Black: pop - s, Draws: draws} }
+		kMin, kMax = xMax, pop-draws+xMin
+		start = int(math.Round(mean * float64(pop) / float64(draws)))
+	} else {
+		succ := opt.Successes
+		if xMax > succ {
+			return Hypergeometric{}, errors.New("hypergeometric data exceed Successes")
+		}
+		if sum == 0 {
+			return Hypergeometric{}, errors.New("population size is unbounded when no successes are observed")
+		}
+		build = func(pop int) Hypergeometric { return Hypergeometric{White: succ, Black: pop - succ, Draws: draws} }
+		kMin, kMax = max(succ+draws-xMin, draws), math.MaxInt32
+		start = int(math.Min(float64(draws)*float64(succ)/mean, math.MaxInt32))
+	}
+	if kMin > kMax {
+		return Hypergeometric{}, errors.New("no hypergeometric parameters are consistent with the data")
+	}
+	ll := func(k int) float64 { return logLikelihood(build(k), x) }
+	k := min(max(start, kMin), kMax)
+	cur := ll(k)
+	for k < kMax {
+		next := ll(k + 1)
+		if next <= cur {
+			break
+		}
+		k, cur = k+1, next
+	}
+	for k > kMin {
+		prev := ll(k - 1)
+		if prev < cur {
+			break
+		}
+		k, cur = k-1, prev
+	}
+	return build(k), nil
+}
+
+// goldenMax maximises a unimodal f on [lo, hi].
+func goldenMax(f func(float64) float64, lo, hi float64) float64 {
+	const invPhi = 0.6180339887498949
+	a, b := lo, hi
+	c := b - invPhi*(b-a)
+	d := a + invPhi*(b-a)
+	fc, fd := f(c), f(d)
+	for range 200 {
+		if b-a < 1e-10 {
+			break
+		}
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - invPhi*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invPhi*(b-a)
+			fd = f(d)
+		}
+	}
+	return (a + b) / 2
+}
+
+// trigamma uses the recurrence up to x >= 6 and then the asymptotic series.
+func trigamma(x float64) float64 {
+	r := 0.0
+	for x < 6 {
+		r += 1 / (x * x)
+		x++
+	}
+	f := 1 / (x * x)
+	return r + 1/x + f/2 + f/x*(1.0/6-f*(1.0/30-f*(1.0/42-f/30)))
+}
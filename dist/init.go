@@ -0,0 +1,6 @@
+// `dist` package provides univariate probability distributions with
+// densities, cumulative probabilities, quantiles, seeded random sampling and
+// maximum-likelihood fitting.
+package dist
+
+func init() {}
@@ -0,0 +1,37 @@
+package dist
+
+import (
+	"errors"
+	"math/rand/v2"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// NewSource returns a seeded random source for the Rand methods, seeded the
+// same way as insyra.SamplingOptions.
+func NewSource(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
+}
+
+// Sample draws n values from d into a new DataList. With
+// SamplingOptions.UseSeed the draw is reproducible.
+func Sample(d Distribution, n int, options ...insyra.SamplingOptions) (*insyra.DataList, error) {
+	if d == nil {
+		return nil, errors.New("distribution is nil")
+	}
+	if n <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+	if len(options) > 1 {
+		return nil, errors.New("options accepts at most one value")
+	}
+	var src *rand.Rand
+	if len(options) == 1 && options[0].UseSeed {
+		src = NewSource(options[0].Seed)
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = d.Rand(src)
+	}
+	return insyra.NewDataList(values), nil
+}
@@ -0,0 +1,311 @@
+// Package modeljson holds the shared building blocks for serialising fitted
+// models: a versioned header, floats that survive NaN and ±Inf, and values
+// that keep their Go type across a JSON round trip.
+package modeljson
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	json "github.com/goccy/go-json"
+)
+
+// Version is written into every serialised model. Bump it when a payload
+// changes incompatibly; readers reject versions newer than their own.
+const Version = 1
+
+// Header identifies a serialised model.
+type Header struct {
+	Kind    string `json:"kind"`
+	Version int    `json:"version"`
+}
+
+// NewHeader returns the header for kind at the current Version.
+func NewHeader(kind string) Header {
+	return Header{Kind: kind, Version: Version}
+}
+
+// Check reports whether h describes a readable payload of the given kind.
+func (h Header) Check(kind string) error {
+	if h.Kind != kind {
+		return fmt.Errorf("model kind is %q, want %q", h.Kind, kind)
+	}
+	if h.Version < 1 || h.Version > Version {
+		return fmt.Errorf("unsupported %s model version %d", kind, h.Version)
+	}
+	return nil
+}
+
+// PeekKind returns the kind recorded in a serialised model.
+func PeekKind(data []byte) (string, error) {
+	var h Header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return "", err
+	}
+	if h.Kind == "" {
+		return "", fmt.Errorf("serialised model has no kind")
+	}
+	return h.Kind, nil
+}
+
+// Float is a float64 that encodes NaN and ±Inf as the strings "NaN",
+// "+Inf" and "-Inf", which plain JSON numbers cannot represent.
+type Float float64
+
+// MarshalJSON implements json.Marshaler.
+func (f Float) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	switch {
+	case math.IsNaN(v):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(v, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(v, -1):
+		return []byte(`"-Inf"`), nil
+	}
+	return strconv.AppendFloat(nil, v, 'g', -1, 64), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *Float) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		switch s {
+		case "NaN":
+			*f = Float(math.NaN())
+		case "+Inf":
+			*f = Float(math.Inf(1))
+		case "-Inf":
+			*f = Float(math.Inf(-1))
+		default:
+			return fmt.Errorf("invalid float %q", s)
+		}
+		return nil
+	}
+	v, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return err
+	}
+	*f = Float(v)
+	return nil
+}
+
+// Floats converts a slice for encoding.
+func Floats(x []float64) []Float {
+	if x == nil {
+		return nil
+	}
+	out := make([]Float, len(x))
+	for i, v := range x {
+		out[i] = Float(v)
+	}
+	return out
+}
+
+// ToFloats converts a decoded slice back to float64.
+func ToFloats(x []Float) []float64 {
+	if x == nil {
+		return nil
+	}
+	out := make([]float64, len(x))
+	for i, v := range x {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+// Matrix converts a row-major matrix for encoding.
+func Matrix(x [][]float64) [][]Float {
+	if x == nil {
+		return nil
+	}
+	out := make([][]Float, len(x))
+	for i, row := range x {
+		out[i] = Floats(row)
+	}
+	return out
+}
+
+// ToMatrix converts a decoded matrix back to float64.
+func ToMatrix(x [][]Float) [][]float64 {
+	if x == nil {
+		return nil
+	}
+	out := make([][]float64, len(x))
+	for i, row := range x {
+		out[i] = ToFloats(row)
+	}
+	return out
+}
+
+// Value is a scalar tagged with its Go type, so that an int category
+// decodes as int rather than float64 and keeps matching the same data.
+type Value struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// EncodeValue tags a scalar. Only nil, bool, string, the sized integer and
+// float types and time.Time are supported.
+func EncodeValue(v any) (Value, error) {
+	var typ string
+	switch x := v.(type) {
+	case nil:
+		return Value{Type: "nil"}, nil
+	case bool:
+		typ = "bool"
+	case string:
+		typ = "string"
+	case int:
+		typ = "int"
+	case int8:
+		typ = "int8"
+	case int16:
+		typ = "int16"
+	case int32:
+		typ = "int32"
+	case int64:
+		typ = "int64"
+	case uint:
+		typ = "uint"
+	case uint8:
+		typ = "uint8"
+	case uint16:
+		typ = "uint16"
+	case uint32:
+		typ = "uint32"
+	case uint64:
+		typ = "uint64"
+	case float32:
+		typ = "float32"
+		v = Float(x)
+	case float64:
+		typ = "float64"
+		v = Float(x)
+	case time.Time:
+		typ = "time"
+	default:
+		return Value{}, fmt.Errorf("cannot serialise value %v of type %T", v, v)
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Type: typ, Value: raw}, nil
+}
+
+// Decode restores the tagged scalar with its original Go type.
+func (v Value) Decode() (any, error) {
+	var err error
+	decode := func(dst any) {
+		err = json.Unmarshal(v.Value, dst)
+	}
+	var out any
+	switch v.Type {
+	case "nil":
+		return nil, nil
+	case "bool":
+		var x bool
+		decode(&x)
+		out = x
+	case "string":
+		var x string
+		decode(&x)
+		out = x
+	case "int":
+		var x int
+		decode(&x)
+		out = x
+	case "int8":
+		var x int8
+		decode(&x)
+		out = x
+	case "int16":
+		var x int16
+		decode(&x)
+		out = x
+	case "int32":
+		var x int32
+		decode(&x)
+		out = x
+	case "int64":
+		var x int64
+		decode(&x)
+		out = x
+	case "uint":
+		var x uint
+		decode(&x)
+		out = x
+	case "uint8":
+		var x uint8
+		decode(&x)
+		out = x
+	case "uint16":
+		var x uint16
+		decode(&x)
+		out = x
+	case "uint32":
+		var x uint32
+		decode(&x)
+		out = x
+	case "uint64":
+		var x uint64
+		decode(&x)
+		out = x
+	case "float32":
+		var x Float
+		decode(&x)
+		out = float32(x)
+	case "float64":
+		var x Float
+		decode(&x)
+		out = float64(x)
+	case "time":
+		var x time.Time
+		decode(&x)
+		out = x
+	default:
+		return nil, fmt.Errorf("unknown value type %q", v.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EncodeValues tags every element of vals.
+func EncodeValues(vals []any) ([]Value, error) {
+	if vals == nil {
+		return nil, nil
+	}
+	out := make([]Value, len(vals))
+	for i, v := range vals {
+		enc, err := EncodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = enc
+	}
+	return out, nil
+}
+
+// DecodeValues restores every element of vals.
+func DecodeValues(vals []Value) ([]any, error) {
+	if vals == nil {
+		return nil, nil
+	}
+	out := make([]any, len(vals))
+	for i, v := range vals {
+		dec, err := v.Decode()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = dec
+	}
+	return out, nil
+}
@@ -0,0 +1,415 @@
+package insyra
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/HazelnutParadise/insyra/internal/modeljson"
+	json "github.com/goccy/go-json"
+)
+
+// SaveModel writes a fitted model (a scaler, an encoder, a Pipeline or any
+// other value with a MarshalJSON method) to filePath as JSON.
+func SaveModel(filePath string, model json.Marshaler) error {
+	if model == nil {
+		return fmt.Errorf("SaveModel: model is nil")
+	}
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0o644)
+}
+
+// LoadModel reads a model written by SaveModel into model, which must be a
+// pointer of the same kind, e.g. LoadModel(path, &StandardScaler{}).
+func LoadModel(filePath string, model json.Unmarshaler) error {
+	if model == nil {
+		return fmt.Errorf("LoadModel: model is nil")
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	return model.UnmarshalJSON(data)
+}
+
+// ======================== Scalers ========================
+
+type scalerJSON struct {
+	modeljson.Header
+	FeatureMin modeljson.Float    `json:"feature_min"`
+	FeatureMax modeljson.Float    `json:"feature_max"`
+	Fitted     bool               `json:"fitted"`
+	Columns    []scalerColumnJSON `json:"columns"`
+}
+
+type scalerColumnJSON struct {
+	Ref    string          `json:"ref"`
+	Name   string          `json:"name"`
+	Center modeljson.Float `json:"center"`
+	Scale  modeljson.Float `json:"scale"`
+	Gain   modeljson.Float `json:"gain"`
+	Offset modeljson.Float `json:"offset"`
+
+	Mean      modeljson.Float `json:"mean"`
+	Std       modeljson.Float `json:"std"`
+	Min       modeljson.Float `json:"min"`
+	Max       modeljson.Float `json:"max"`
+	Median    modeljson.Float `json:"median"`
+	Q1        modeljson.Float `json:"q1"`
+	Q3        modeljson.Float `json:"q3"`
+	IQR       modeljson.Float `json:"iqr"`
+	MaxAbs    modeljson.Float `json:"max_abs"`
+	OutputMin modeljson.Float `json:"output_min"`
+	OutputMax modeljson.Float `json:"output_max"`
+}
+
+// MarshalJSON serialises the fitted scaler.
+func (s *scaler) MarshalJSON() ([]byte, error) {
+	out := scalerJSON{
+		Header:     modeljson.NewHeader(s.kind),
+		FeatureMin: modeljson.Float(s.featureMin),
+		FeatureMax: modeljson.Float(s.featureMax),
+		Fitted:     s.fitted,
+		Columns:    make([]scalerColumnJSON, len(s.cols)),
+	}
+	for i, c := range s.cols {
+		p := c.params
+		out.Columns[i] = scalerColumnJSON{
+			Ref: c.ref, Name: c.name,
+			Center: modeljson.Float(c.center), Scale: modeljson.Float(c.scale),
+			Gain: modeljson.Float(c.gain), Offset: modeljson.Float(c.offset),
+			Mean: modeljson.Float(p.Mean), Std: modeljson.Float(p.Std),
+			Min: modeljson.Float(p.Min), Max: modeljson.Float(p.Max),
+			Median: modeljson.Float(p.Median), Q1: modeljson.Float(p.Q1), Q3: modeljson.Float(p.Q3),
+			IQR: modeljson.Float(p.IQR), MaxAbs: modeljson.Float(p.MaxAbs),
+			OutputMin: modeljson.Float(p.OutputMin), OutputMax: modeljson.Float(p.OutputMax),
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (s *scaler) unmarshalKind(data []byte, kind string) error {
+	var in scalerJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(kind); err != nil {
+		return err
+	}
+	s.kind = kind
+	s.featureMin = float64(in.FeatureMin)
+	s.featureMax = float64(in.FeatureMax)
+	s.fitted = in.Fitted
+	s.cols = make([]scalerColumn, len(in.Columns))
+	for i, c := range in.Columns {
+		s.cols[i] = scalerColumn{
+			ref: c.Ref, name: c.Name,
+			center: float64(c.Center), scale: float64(c.Scale),
+			gain: float64(c.Gain), offset: float64(c.Offset),
+			params: ScalerParams{
+				Column: c.Name, Kind: kind,
+				Mean: float64(c.Mean), Std: float64(c.Std),
+				Min: float64(c.Min), Max: float64(c.Max),
+				Median: float64(c.Median), Q1: float64(c.Q1), Q3: float64(c.Q3),
+				IQR: float64(c.IQR), MaxAbs: float64(c.MaxAbs),
+				OutputMin: float64(c.OutputMin), OutputMax: float64(c.OutputMax),
+			},
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON restores a scaler written by MarshalJSON.
+func (s *StandardScaler) UnmarshalJSON(data []byte) error {
+	return s.unmarshalKind(data, "standard")
+}
+
+// UnmarshalJSON restores a scaler written by MarshalJSON.
+func (s *MinMaxScaler) UnmarshalJSON(data []byte) error {
+	return s.unmarshalKind(data, "minmax")
+}
+
+// UnmarshalJSON restores a scaler written by MarshalJSON.
+func (s *RobustScaler) UnmarshalJSON(data []byte) error {
+	return s.unmarshalKind(data, "robust")
+}
+
+// UnmarshalJSON restores a scaler written by MarshalJSON.
+func (s *MaxAbsScaler) UnmarshalJSON(data []byte) error {
+	return s.unmarshalKind(data, "maxabs")
+}
+
+// ======================== Imputers ========================
+
+type imputerJSON struct {
+	modeljson.Header
+	Fitted  bool                `json:"fitted"`
+	Columns []imputerColumnJSON `json:"columns"`
+}
+
+type imputerColumnJSON struct {
+	Name string          `json:"name"`
+	Fill modeljson.Value `json:"fill"`
+}
+
+// MarshalJSON serialises the fitted imputer, keeping the Go type of every
+// fill value.
+func (m *imputer) MarshalJSON() ([]byte, error) {
+	out := imputerJSON{
+		Header:  modeljson.NewHeader(m.kind),
+		Fitted:  m.fitted,
+		Columns: make([]imputerColumnJSON, len(m.cols)),
+	}
+	for i, c := range m.cols {
+		fill, err := modeljson.EncodeValue(c.fill)
+		if err != nil {
+			return nil, fmt.Errorf("%sImputer.MarshalJSON: column %q: %w", m.kind, c.name, err)
+		}
+		out.Columns[i] = imputerColumnJSON{Name: c.name, Fill: fill}
+	}
+	return json.Marshal(out)
+}
+
+func (m *imputer) unmarshalKind(data []byte, kind string) error {
+	var in imputerJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(kind); err != nil {
+		return err
+	}
+	cols := make([]imputerColumn, len(in.Columns))
+	for i, c := range in.Columns {
+		fill, err := c.Fill.Decode()
+		if err != nil {
+			return fmt.Errorf("%sImputer.UnmarshalJSON: column %q: %w", kind, c.Name, err)
+		}
+		cols[i] = imputerColumn{name: c.Name, fill: fill}
+	}
+	m.kind, m.fitted, m.cols = kind, in.Fitted, cols
+	return nil
+}
+
+// UnmarshalJSON restores an imputer written by MarshalJSON.
+func (m *MeanImputer) UnmarshalJSON(data []byte) error {
+	return m.unmarshalKind(data, "mean")
+}
+
+// UnmarshalJSON restores an imputer written by MarshalJSON.
+func (m *MedianImputer) UnmarshalJSON(data []byte) error {
+	return m.unmarshalKind(data, "median")
+}
+
+// UnmarshalJSON restores an imputer written by MarshalJSON.
+func (m *ModeImputer) UnmarshalJSON(data []byte) error {
+	return m.unmarshalKind(data, "mode")
+}
+
+// ======================== Encoders ========================
+
+type oneHotEncoderJSON struct {
+	modeljson.Header
+	Options OneHotOptions      `json:"options"`
+	Columns []oneHotColumnJSON `json:"columns"`
+}
+
+type oneHotColumnJSON struct {
+	SourceRef   string            `json:"source_ref"`
+	SourceName  string            `json:"source_name"`
+	SourceIndex int               `json:"source_index"`
+	Prefix      string            `json:"prefix"`
+	Categories  []modeljson.Value `json:"categories"`
+}
+
+// scalarEncoderJSON covers LabelEncoder and OrdinalEncoder, which share
+// their fitted state; Order is only set for ordinal encoders.
+type scalarEncoderJSON struct {
+	modeljson.Header
+	Column       string            `json:"column"`
+	NewColumn    string            `json:"new_column"`
+	SortBy       LabelSort         `json:"sort_by"`
+	Order        []modeljson.Value `json:"order,omitempty"`
+	HandleNaN    NaNPolicy         `json:"handle_nan"`
+	Unknown      UnknownPolicy     `json:"unknown"`
+	KeepOriginal bool              `json:"keep_original"`
+	SourceRef    string            `json:"source_ref"`
+	SourceName   string            `json:"source_name"`
+	EncodedName  string            `json:"encoded_name"`
+	Classes      []modeljson.Value `json:"classes"`
+}
+
+// MarshalJSON serialises the fitted encoder, keeping the Go type of every
+// category so a reloaded encoder matches the same values.
+func (e *OneHotEncoder) MarshalJSON() ([]byte, error) {
+	out := oneHotEncoderJSON{
+		Header:  modeljson.NewHeader(e.Kind()),
+		Options: e.opts,
+		Columns: make([]oneHotColumnJSON, len(e.columns)),
+	}
+	for i, c := range e.columns {
+		cats, err := modeljson.EncodeValues(c.categories)
+		if err != nil {
+			return nil, fmt.Errorf("OneHotEncoder.MarshalJSON: %w", err)
+		}
+		out.Columns[i] = oneHotColumnJSON{
+			SourceRef:   c.sourceRef,
+			SourceName:  c.sourceName,
+			SourceIndex: c.sourceIndex,
+			Prefix:      c.prefix,
+			Categories:  cats,
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores an encoder written by MarshalJSON.
+func (e *OneHotEncoder) UnmarshalJSON(data []byte) error {
+	var in oneHotEncoderJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check("onehot"); err != nil {
+		return err
+	}
+	e.opts = normalizeOneHotOptions(in.Options)
+	e.columns = make([]oneHotColumnState, len(in.Columns))
+	for i, c := range in.Columns {
+		cats, err := modeljson.DecodeValues(c.Categories)
+		if err != nil {
+			return fmt.Errorf("OneHotEncoder.UnmarshalJSON: %w", err)
+		}
+		state := oneHotColumnState{
+			sourceRef:   c.SourceRef,
+			sourceName:  c.SourceName,
+			sourceIndex: c.SourceIndex,
+			prefix:      c.Prefix,
+			keyToIndex:  map[string]int{},
+		}
+		for _, v := range cats {
+			addCategory(&state.categories, state.keyToIndex, v)
+		}
+		state.outputColumns = oneHotOutputNames(state, e.opts)
+		e.columns[i] = state
+	}
+	e.refreshOutputColumns()
+	return nil
+}
+
+// MarshalJSON serialises the fitted encoder.
+func (e *LabelEncoder) MarshalJSON() ([]byte, error) {
+	classes, err := modeljson.EncodeValues(e.classes)
+	if err != nil {
+		return nil, fmt.Errorf("LabelEncoder.MarshalJSON: %w", err)
+	}
+	return json.Marshal(scalarEncoderJSON{
+		Header:       modeljson.NewHeader(e.Kind()),
+		Column:       e.opts.Column,
+		NewColumn:    e.opts.NewColumn,
+		SortBy:       e.opts.SortBy,
+		HandleNaN:    e.opts.HandleNaN,
+		Unknown:      e.opts.Unknown,
+		KeepOriginal: e.opts.KeepOriginal,
+		SourceRef:    e.sourceRef,
+		SourceName:   e.sourceName,
+		EncodedName:  e.encodedName,
+		Classes:      classes,
+	})
+}
+
+// UnmarshalJSON restores an encoder written by MarshalJSON.
+func (e *LabelEncoder) UnmarshalJSON(data []byte) error {
+	var in scalarEncoderJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check("label"); err != nil {
+		return err
+	}
+	classes, keyToID, err := decodeEncoderClasses(in.Classes)
+	if err != nil {
+		return fmt.Errorf("LabelEncoder.UnmarshalJSON: %w", err)
+	}
+	e.opts = LabelEncodeOptions{
+		Column:       in.Column,
+		NewColumn:    in.NewColumn,
+		SortBy:       in.SortBy,
+		HandleNaN:    in.HandleNaN,
+		Unknown:      in.Unknown,
+		KeepOriginal: in.KeepOriginal,
+	}
+	e.sourceRef, e.sourceName, e.encodedName = in.SourceRef, in.SourceName, in.EncodedName
+	e.classes, e.keyToID = classes, keyToID
+	return nil
+}
+
+// MarshalJSON serialises the fitted encoder.
+func (e *OrdinalEncoder) MarshalJSON() ([]byte, error) {
+	classes, err := modeljson.EncodeValues(e.classes)
+	if err != nil {
+		return nil, fmt.Errorf("OrdinalEncoder.MarshalJSON: %w", err)
+	}
+	order, err := modeljson.EncodeValues(e.opts.Order)
+	if err != nil {
+		return nil, fmt.Errorf("OrdinalEncoder.MarshalJSON: %w", err)
+	}
+	return json.Marshal(scalarEncoderJSON{
+		Header:       modeljson.NewHeader(e.Kind()),
+		Column:       e.opts.Column,
+		NewColumn:    e.opts.NewColumn,
+		Order:        order,
+		HandleNaN:    e.opts.HandleNaN,
+		Unknown:      e.opts.Unknown,
+		KeepOriginal: e.opts.KeepOriginal,
+		SourceRef:    e.sourceRef,
+		SourceName:   e.sourceName,
+		EncodedName:  e.encodedName,
+		Classes:      classes,
+	})
+}
+
+// UnmarshalJSON restores an encoder written by MarshalJSON.
+func (e *OrdinalEncoder) UnmarshalJSON(data []byte) error {
+	var in scalarEncoderJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check("ordinal"); err != nil {
+		return err
+	}
+	classes, keyToID, err := decodeEncoderClasses(in.Classes)
+	if err != nil {
+		return fmt.Errorf("OrdinalEncoder.UnmarshalJSON: %w", err)
+	}
+	order, err := modeljson.DecodeValues(in.Order)
+	if err != nil {
+		return fmt.Errorf("OrdinalEncoder.UnmarshalJSON: %w", err)
+	}
+	e.opts = OrdinalEncodeOptions{
+		Column:       in.Column,
+		Order:        order,
+		NewColumn:    in.NewColumn,
+		HandleNaN:    in.HandleNaN,
+		Unknown:      in.Unknown,
+		KeepOriginal: in.KeepOriginal,
+	}
+	e.sourceRef, e.sourceName, e.encodedName = in.SourceRef, in.SourceName, in.EncodedName
+	e.classes, e.keyToID = classes, keyToID
+	return nil
+}
+
+func decodeEncoderClasses(vals []modeljson.Value) ([]any, map[string]int, error) {
+	decoded, err := modeljson.DecodeValues(vals)
+	if err != nil {
+		return nil, nil, err
+	}
+	classes := make([]any, 0, len(decoded))
+	keyToID := make(map[string]int, len(decoded))
+	for _, v := range decoded {
+		if !addCategory(&classes, keyToID, v) {
+			return nil, nil, fmt.Errorf("duplicate class %v", v)
+		}
+	}
+	return classes, keyToID, nil
+}
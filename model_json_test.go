@@ -0,0 +1,192 @@
+package insyra
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	json "github.com/goccy/go-json"
+)
+
+func TestScalerJSONRoundTrip(t *testing.T) {
+	dt := scaleTestTable()
+	fitted := []Scaler{NewStandardScaler(), NewMinMaxScaler(-1, 1), NewRobustScaler(), NewMaxAbsScaler()}
+	empty := []json.Unmarshaler{&StandardScaler{}, &MinMaxScaler{}, &RobustScaler{}, &MaxAbsScaler{}}
+	for i, sc := range fitted {
+		if err := sc.Fit(dt, "age", "income"); err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(sc)
+		if err != nil {
+			t.Fatalf("%s: %v", sc.Kind(), err)
+		}
+		if err := empty[i].UnmarshalJSON(data); err != nil {
+			t.Fatalf("%s: %v", sc.Kind(), err)
+		}
+		loaded := empty[i].(Scaler)
+		if !reflect.DeepEqual(loaded.Params(), sc.Params()) {
+			t.Errorf("%s params %v, want %v", sc.Kind(), loaded.Params(), sc.Params())
+		}
+		want, _ := sc.Transform(dt)
+		got, err := loaded.Transform(dt)
+		if err != nil {
+			t.Fatalf("%s: %v", sc.Kind(), err)
+		}
+		assertApproxSlice(t, floatsOf(t, got.GetColByName("income")), floatsOf(t, want.GetColByName("income")))
+	}
+
+	// A payload is only accepted by the scaler kind that wrote it.
+	data, _ := json.Marshal(fitted[0])
+	if err := (&MinMaxScaler{}).UnmarshalJSON(data); err == nil {
+		t.Errorf("expected kind mismatch error")
+	}
+	if err := (&StandardScaler{}).UnmarshalJSON([]byte(`{"kind":"standard","version":99}`)); err == nil {
+		t.Errorf("expected unsupported version error")
+	}
+}
+
+func TestEncoderJSONKeepsCategoryTypes(t *testing.T) {
+	dt := NewDataTable(
+		NewDataList("red", "blue", nil, "red").SetName("color"),
+		NewDataList(1, 2, 1, 3).SetName("grade"),
+	)
+	_, onehot, err := dt.OneHotEncode(OneHotOptions{Columns: []string{"color", "grade"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, label, err := dt.LabelEncode(LabelEncodeOptions{Column: "grade", NewColumn: "grade_id", KeepOriginal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ordinal, err := dt.OrdinalEncode(OrdinalEncodeOptions{Column: "grade", Order: []any{3, 2, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		enc    Encoder
+		loaded json.Unmarshaler
+	}{
+		{onehot, &OneHotEncoder{}},
+		{label, &LabelEncoder{}},
+		{ordinal, &OrdinalEncoder{}},
+	}
+	for _, c := range cases {
+		data, err := json.Marshal(c.enc)
+		if err != nil {
+			t.Fatalf("%s: %v", c.enc.Kind(), err)
+		}
+		if err := c.loaded.UnmarshalJSON(data); err != nil {
+			t.Fatalf("%s: %v", c.enc.Kind(), err)
+		}
+		want, _ := c.enc.Transform(dt)
+		got, err := c.loaded.(Encoder).Transform(dt)
+		if err != nil {
+			t.Fatalf("%s: %v", c.enc.Kind(), err)
+		}
+		if !reflect.DeepEqual(got.ColNames(), want.ColNames()) {
+			t.Fatalf("%s columns %v, want %v", c.enc.Kind(), got.ColNames(), want.ColNames())
+		}
+		for _, name := range want.ColNames() {
+			if !reflect.DeepEqual(got.GetColByName(name).Data(), want.GetColByName(name).Data()) {
+				t.Errorf("%s column %s = %v, want %v", c.enc.Kind(), name, got.GetColByName(name).Data(), want.GetColByName(name).Data())
+			}
+		}
+	}
+	if classes := cases[1].loaded.(*LabelEncoder).Classes(); !reflect.DeepEqual(classes, []any{1, 2, 3}) {
+		t.Errorf("reloaded classes %#v lost their int type", classes)
+	}
+}
+
+func TestPipelineSaveAndLoad(t *testing.T) {
+	train := NewDataTable(
+		NewDataList("a", "b", "a", "c").SetName("group"),
+		NewDataList(1.0, 2.0, 3.0, 10.0).SetName("x"),
+	)
+	encoded, onehot, err := train.OneHotEncode(OneHotOptions{Columns: []string{"group"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, scaler, err := encoded.StandardScale("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPipeline(onehot, scaler)
+
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	if err := SaveModel(path, p); err != nil {
+		t.Fatal(err)
+	}
+	var loaded Pipeline
+	if err := LoadModel(path, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if kinds := []string{loaded.Steps()[0].Kind(), loaded.Steps()[1].Kind()}; !reflect.DeepEqual(kinds, []string{"onehot", "standard"}) {
+		t.Fatalf("loaded steps %v", kinds)
+	}
+
+	test := NewDataTable(
+		NewDataList("c", "a").SetName("group"),
+		NewDataList(4.0, 0.0).SetName("x"),
+	)
+	want, err := p.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.ColNames(), want.ColNames()) {
+		t.Fatalf("columns %v, want %v", got.ColNames(), want.ColNames())
+	}
+	assertApproxSlice(t, floatsOf(t, got.GetColByName("x")), floatsOf(t, want.GetColByName("x")))
+
+	back, err := loaded.InverseTransform(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEncodeData(t, back.GetColByName("group"), []any{"c", "a"})
+	assertApproxSlice(t, floatsOf(t, back.GetColByName("x")), []float64{4, 0})
+
+	if err := loaded.UnmarshalJSON([]byte(`{"kind":"pipeline","version":1,"steps":[{"kind":"mystery","version":1}]}`)); err == nil || !strings.Contains(err.Error(), "mystery") {
+		t.Errorf("expected unknown step kind error, got %v", err)
+	}
+}
+
+func TestNestedPipelineSaveAndLoad(t *testing.T) {
+	train := NewDataTable(NewDataList(1.0, 2.0, 3.0, 10.0).SetName("x"))
+	_, standard, err := train.StandardScale("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, minmax, err := train.MinMaxScale(0, 1, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPipeline(NewPipeline(standard), minmax)
+
+	path := filepath.Join(t.TempDir(), "nested.json")
+	if err := SaveModel(path, p); err != nil {
+		t.Fatal(err)
+	}
+	var loaded Pipeline
+	if err := LoadModel(path, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	inner, ok := loaded.Steps()[0].(*Pipeline)
+	if !ok || len(inner.Steps()) != 1 || inner.Steps()[0].Kind() != "standard" {
+		t.Fatalf("loaded inner step %#v", loaded.Steps()[0])
+	}
+
+	test := NewDataTable(NewDataList(4.0, 0.0).SetName("x"))
+	want, err := p.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loaded.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertApproxSlice(t, floatsOf(t, got.GetColByName("x")), floatsOf(t, want.GetColByName("x")))
+}
@@ -0,0 +1,304 @@
+package insyra
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/HazelnutParadise/insyra/internal/modeljson"
+	json "github.com/goccy/go-json"
+)
+
+// PipelineStep is a fitted transformation that a Pipeline can chain. Every
+// scaler and encoder is a PipelineStep; other packages contribute their own
+// kinds (for example fitted models in stats) via RegisterPipelineStep so that
+// a saved Pipeline can be loaded again.
+type PipelineStep interface {
+	Transform(dt *DataTable) (*DataTable, error)
+	Kind() string
+}
+
+// InvertiblePipelineStep is a PipelineStep that can undo its transform.
+type InvertiblePipelineStep interface {
+	PipelineStep
+	InverseTransform(dt *DataTable) (*DataTable, error)
+}
+
+// PipelineFitter is a PipelineStep that Pipeline.Fit can (re)fit. Steps
+// that do not implement it are treated as already fitted.
+type PipelineFitter interface {
+	PipelineStep
+	Fit(dt *DataTable) error
+}
+
+// ColumnFitter is a step fitted on a list of columns, such as a scaler or
+// an imputer. Wrap it with ColumnStep to use it as a PipelineFitter.
+type ColumnFitter interface {
+	PipelineStep
+	Fit(dt *DataTable, cols ...string) error
+}
+
+var (
+	pipelineStepMu    sync.RWMutex
+	pipelineStepKinds = map[string]func() PipelineStep{
+		"standard": func() PipelineStep { return &StandardScaler{} },
+		"minmax":   func() PipelineStep { return &MinMaxScaler{} },
+		"robust":   func() PipelineStep { return &RobustScaler{} },
+		"maxabs":   func() PipelineStep { return &MaxAbsScaler{} },
+		"onehot":   func() PipelineStep { return &OneHotEncoder{} },
+		"label":    func() PipelineStep { return &LabelEncoder{} },
+		"ordinal":  func() PipelineStep { return &OrdinalEncoder{} },
+		"mean":     func() PipelineStep { return &MeanImputer{} },
+		"median":   func() PipelineStep { return &MedianImputer{} },
+		"mode":     func() PipelineStep { return &ModeImputer{} },
+		"columns":  func() PipelineStep { return &columnStep{} },
+		"pipeline": func() PipelineStep { return &Pipeline{} },
+	}
+)
+
+// RegisterPipelineStep makes a step kind loadable from JSON. newStep must
+// return a pointer whose UnmarshalJSON accepts the step's MarshalJSON
+// output. Registering the same kind twice panics.
+func RegisterPipelineStep(kind string, newStep func() PipelineStep) {
+	pipelineStepMu.Lock()
+	defer pipelineStepMu.Unlock()
+	if newStep == nil {
+		panic("insyra: RegisterPipelineStep factory is nil")
+	}
+	if _, dup := pipelineStepKinds[kind]; dup {
+		panic("insyra: RegisterPipelineStep called twice for kind " + kind)
+	}
+	pipelineStepKinds[kind] = newStep
+}
+
+// Pipeline chains steps and applies them in order. Fit learns every step's
+// parameters from one training table, so Transform on new data never sees
+// statistics of that data. It serialises as a single JSON document, so a
+// preprocessing chain fitted once can be saved with SaveModel and reused by
+// later batch jobs.
+type Pipeline struct {
+	steps []PipelineStep
+}
+
+// NewPipeline returns a pipeline applying steps in the given order.
+func NewPipeline(steps ...PipelineStep) *Pipeline {
+	return &Pipeline{steps: append([]PipelineStep(nil), steps...)}
+}
+
+// Steps returns the pipeline's steps in application order.
+func (p *Pipeline) Steps() []PipelineStep {
+	return append([]PipelineStep(nil), p.steps...)
+}
+
+// Fit fits every PipelineFitter step in order, each on the output of the
+// steps before it, so later steps only ever see transformed training data.
+func (p *Pipeline) Fit(dt *DataTable) error {
+	_, err := p.fit(dt, false)
+	return err
+}
+
+// FitTransform fits the pipeline on dt and returns dt transformed by it.
+func (p *Pipeline) FitTransform(dt *DataTable) (*DataTable, error) {
+	return p.fit(dt, true)
+}
+
+func (p *Pipeline) fit(dt *DataTable, transformLast bool) (*DataTable, error) {
+	if p == nil {
+		return nil, fmt.Errorf("Pipeline.Fit: pipeline is nil")
+	}
+	if dt == nil {
+		return nil, fmt.Errorf("Pipeline.Fit: table is nil")
+	}
+	out := dt
+	for i, step := range p.steps {
+		if f, ok := step.(PipelineFitter); ok {
+			if err := f.Fit(out); err != nil {
+				return nil, fmt.Errorf("Pipeline.Fit: step %d (%s): %w", i, step.Kind(), err)
+			}
+		}
+		if i == len(p.steps)-1 && !transformLast {
+			break
+		}
+		var err error
+		out, err = step.Transform(out)
+		if err != nil {
+			return nil, fmt.Errorf("Pipeline.Fit: step %d (%s): %w", i, step.Kind(), err)
+		}
+	}
+	return out, nil
+}
+
+// Transform applies every step in order and returns the final table.
+func (p *Pipeline) Transform(dt *DataTable) (*DataTable, error) {
+	if p == nil {
+		return nil, fmt.Errorf("Pipeline.Transform: pipeline is nil")
+	}
+	out := dt
+	for i, step := range p.steps {
+		var err error
+		out, err = step.Transform(out)
+		if err != nil {
+			return nil, fmt.Errorf("Pipeline.Transform: step %d (%s): %w", i, step.Kind(), err)
+		}
+	}
+	return out, nil
+}
+
+// InverseTransform undoes the steps in reverse order. Every step must
+// implement InvertiblePipelineStep.
+func (p *Pipeline) InverseTransform(dt *DataTable) (*DataTable, error) {
+	if p == nil {
+		return nil, fmt.Errorf("Pipeline.InverseTransform: pipeline is nil")
+	}
+	out := dt
+	for i := len(p.steps) - 1; i >= 0; i-- {
+		step, ok := p.steps[i].(InvertiblePipelineStep)
+		if !ok {
+			return nil, fmt.Errorf("Pipeline.InverseTransform: step %d (%s) is not invertible", i, p.steps[i].Kind())
+		}
+		var err error
+		out, err = step.InverseTransform(out)
+		if err != nil {
+			return nil, fmt.Errorf("Pipeline.InverseTransform: step %d (%s): %w", i, step.Kind(), err)
+		}
+	}
+	return out, nil
+}
+
+// Kind returns "pipeline".
+func (p *Pipeline) Kind() string { return "pipeline" }
+
+type pipelineJSON struct {
+	modeljson.Header
+	Steps []json.RawMessage `json:"steps"`
+}
+
+// MarshalJSON serialises every step into one document.
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	out := pipelineJSON{Header: modeljson.NewHeader(p.Kind()), Steps: make([]json.RawMessage, len(p.steps))}
+	for i, step := range p.steps {
+		if _, ok := step.(json.Marshaler); !ok {
+			return nil, fmt.Errorf("Pipeline.MarshalJSON: step %d (%s) cannot be serialised", i, step.Kind())
+		}
+		raw, err := json.Marshal(step)
+		if err != nil {
+			return nil, fmt.Errorf("Pipeline.MarshalJSON: step %d (%s): %w", i, step.Kind(), err)
+		}
+		out.Steps[i] = raw
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a pipeline written by MarshalJSON. Steps of kinds
+// registered by other packages load only when that package is imported.
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	var in pipelineJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(p.Kind()); err != nil {
+		return err
+	}
+	steps := make([]PipelineStep, len(in.Steps))
+	for i, raw := range in.Steps {
+		step, err := decodePipelineStep(raw)
+		if err != nil {
+			return fmt.Errorf("Pipeline.UnmarshalJSON: step %d: %w", i, err)
+		}
+		steps[i] = step
+	}
+	p.steps = steps
+	return nil
+}
+
+// decodePipelineStep builds a step of the kind recorded in raw and restores
+// it from raw.
+func decodePipelineStep(raw json.RawMessage) (PipelineStep, error) {
+	kind, err := modeljson.PeekKind(raw)
+	if err != nil {
+		return nil, err
+	}
+	pipelineStepMu.RLock()
+	newStep, ok := pipelineStepKinds[kind]
+	pipelineStepMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %q; import the package that provides it", kind)
+	}
+	step := newStep()
+	u, ok := step.(json.Unmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("kind %q cannot be deserialised", kind)
+	}
+	if err := u.UnmarshalJSON(raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", kind, err)
+	}
+	return step, nil
+}
+
+// ColumnStep binds a column-fitted step to cols so that Pipeline.Fit can
+// fit it; with no cols the step chooses its own default columns. The
+// returned step serialises with its column list and inner step.
+func ColumnStep(step ColumnFitter, cols ...string) PipelineStep {
+	return &columnStep{step: step, cols: append([]string(nil), cols...)}
+}
+
+type columnStep struct {
+	step ColumnFitter
+	cols []string
+}
+
+func (c *columnStep) Fit(dt *DataTable) error {
+	return c.step.Fit(dt, c.cols...)
+}
+
+func (c *columnStep) Transform(dt *DataTable) (*DataTable, error) {
+	return c.step.Transform(dt)
+}
+
+func (c *columnStep) InverseTransform(dt *DataTable) (*DataTable, error) {
+	inv, ok := c.step.(InvertiblePipelineStep)
+	if !ok {
+		return nil, fmt.Errorf("%s step is not invertible", c.step.Kind())
+	}
+	return inv.InverseTransform(dt)
+}
+
+// Kind reports the wrapped step's kind, so errors and Steps() read the same
+// whether or not a step is bound to columns.
+func (c *columnStep) Kind() string { return c.step.Kind() }
+
+type columnStepJSON struct {
+	modeljson.Header
+	Columns []string        `json:"columns"`
+	Step    json.RawMessage `json:"step"`
+}
+
+func (c *columnStep) MarshalJSON() ([]byte, error) {
+	if _, ok := c.step.(json.Marshaler); !ok {
+		return nil, fmt.Errorf("%s step cannot be serialised", c.step.Kind())
+	}
+	raw, err := json.Marshal(c.step)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(columnStepJSON{Header: modeljson.NewHeader("columns"), Columns: c.cols, Step: raw})
+}
+
+func (c *columnStep) UnmarshalJSON(data []byte) error {
+	var in columnStepJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check("columns"); err != nil {
+		return err
+	}
+	step, err := decodePipelineStep(in.Step)
+	if err != nil {
+		return err
+	}
+	fitter, ok := step.(ColumnFitter)
+	if !ok {
+		return fmt.Errorf("%s step is not fitted on columns", step.Kind())
+	}
+	c.step, c.cols = fitter, in.Columns
+	return nil
+}
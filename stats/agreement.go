@@ -0,0 +1,456 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+type KappaWeighting string
+
+const (
+	KappaUnweighted KappaWeighting = "unweighted"
+	// KappaLinear weights disagreements by |i - j| / (k - 1).
+	KappaLinear KappaWeighting = "linear"
+	// KappaQuadratic weights disagreements by (i - j)² / (k - 1)².
+	KappaQuadratic KappaWeighting = "quadratic"
+)
+
+type KappaOptions struct {
+	Weighting       KappaWeighting
+	ConfidenceLevel float64
+	// Levels orders the categories for weighted kappa. The default is
+	// sorted order, with numbers compared numerically.
+	Levels []any
+}
+
+// CohenKappaResult holds Cohen's kappa for two raters. The standard error
+// is the large-sample one of Fleiss, Cohen and Everitt (1969) and sets the
+// confidence interval; the z test uses the standard error under κ = 0.
+// Table cross-tabulates the ratings with the first rater in rows.
+type CohenKappaResult struct {
+	Kappa              float64
+	StdError           float64
+	ZValue             float64
+	PValue             float64
+	ConfidenceInterval [2]float64
+	ObservedAgreement  float64
+	ExpectedAgreement  float64
+	Weighting          KappaWeighting
+	Categories         []any
+	Table              *insyra.DataTable
+	N                  int
+}
+
+// CohenKappa measures agreement between two raters who classified the same
+// subjects, correcting for chance agreement. Weighted kappa gives partial
+// credit to near misses on an ordinal scale.
+func CohenKappa(rater1, rater2 insyra.IDataList, opts ...KappaOptions) (*CohenKappaResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt KappaOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	weighting := opt.Weighting
+	if weighting == "" {
+		weighting = KappaUnweighted
+	}
+	if weighting != KappaUnweighted && weighting != KappaLinear && weighting != KappaQuadratic {
+		return nil, fmt.Errorf("unsupported kappa weighting %q", weighting)
+	}
+	conf := opt.ConfidenceLevel
+	if conf == 0 {
+		conf = 0.95
+	}
+	if conf <= 0 || conf >= 1 {
+		return nil, errors.New("confidence level must be in (0, 1)")
+	}
+	if rater1 == nil || rater2 == nil {
+		return nil, errors.New("input DataLists cannot be nil")
+	}
+	v1, v2 := rater1.Data(), rater2.Data()
+	if len(v1) == 0 || len(v1) != len(v2) {
+		return nil, errors.New("both DataLists must be non-empty and have the same length")
+	}
+	for i := range v1 {
+		if v1[i] == nil || v2[i] == nil {
+			return nil, fmt.Errorf("missing rating at index %d", i)
+		}
+	}
+	categories := opt.Levels
+	if categories == nil {
+		categories = sortedClasses(append(append([]any(nil), v1...), v2...))
+	}
+	idx1, idx2 := encodeClasses(v1, categories), encodeClasses(v2, categories)
+	k, n := len(categories), len(v1)
+	if k < 2 {
+		return nil, errors.New("kappa requires at least two categories")
+	}
+	counts := make([][]float64, k)
+	for i := range counts {
+		counts[i] = make([]float64, k)
+	}
+	for i := range n {
+		if idx1[i] < 0 || idx2[i] < 0 {
+			return nil, fmt.Errorf("rating at index %d is not one of the levels", i)
+		}
+		counts[idx1[i]][idx2[i]]++
+	}
+
+	w := make([][]float64, k)
+	for i := range k {
+		w[i] = make([]float64, k)
+		for j := range k {
+			d := math.Abs(float64(i-j)) / float64(k-1)
+			switch weighting {
+			case KappaLinear:
+				w[i][j] = 1 - d
+			case KappaQuadratic:
+				w[i][j] = 1 - d*d
+			default:
+				if i == j {
+					w[i][j] = 1
+				}
+			}
+		}
+	}
+	N := float64(n)
+	rowP := make([]float64, k)
+	colP := make([]float64, k)
+	for i := range k {
+		for j := range k {
+			rowP[i] += counts[i][j] / N
+			colP[j] += counts[i][j] / N
+		}
+	}
+	var po, pe float64
+	for i := range k {
+		for j := range k {
+			po += w[i][j] * counts[i][j] / N
+			pe += w[i][j] * rowP[i] * colP[j]
+		}
+	}
+	if pe == 1 {
+		return nil, errors.New("expected agreement is 1; kappa is undefined")
+	}
+	kappa := (po - pe) / (1 - pe)
+
+	// Fleiss, Cohen and Everitt (1969), with w̄ᵢ. and w̄.ⱼ the weights
+	// averaged over the other rater's margin.
+	wRow := make([]float64, k)
+	wCol := make([]float64, k)
+	for i := range k {
+		for j := range k {
+			wRow[i] += colP[j] * w[i][j]
+			wCol[j] += rowP[i] * w[i][j]
+		}
+	}
+	var s, s0 float64
+	for i := range k {
+		for j := range k {
+			d := w[i][j] - (wRow[i]+wCol[j])*(1-kappa)
+			s += counts[i][j] / N * d * d
+			d0 := w[i][j] - (wRow[i] + wCol[j])
+			s0 += rowP[i] * colP[j] * d0 * d0
+		}
+	}
+	den := N * (1 - pe) * (1 - pe)
+	se := math.Sqrt(math.Max(s-math.Pow(kappa-pe*(1-kappa), 2), 0) / den)
+	se0 := math.Sqrt(math.Max(s0-pe*pe, 0) / den)
+	z := kappa / se0
+	zc := zQuantile(1 - (1-conf)/2)
+
+	cols := make([]*insyra.DataList, k+1)
+	cols[0] = insyra.NewDataList().SetName("Rater1")
+	for j, c := range categories {
+		cols[j+1] = insyra.NewDataList().SetName(fmt.Sprint(c))
+	}
+	for i, c := range categories {
+		cols[0].Append(c)
+		for j := range k {
+			cols[j+1].Append(counts[i][j])
+		}
+	}
+	return &CohenKappaResult{
+		Kappa:              kappa,
+		StdError:           se,
+		ZValue:             z,
+		PValue:             zPValue(z, TwoSided),
+		ConfidenceInterval: [2]float64{kappa - zc*se, kappa + zc*se},
+		ObservedAgreement:  po,
+		ExpectedAgreement:  pe,
+		Weighting:          weighting,
+		Categories:         categories,
+		Table:              insyra.NewDataTable(cols...),
+		N:                  n,
+	}, nil
+}
+
+// FleissKappaResult holds Fleiss' kappa. The standard error is the one
+// under κ = 0 from Fleiss, Nee and Landis (1979), as irr::kappam.fleiss
+// reports. Categories has one row per category with columns Category,
+// Proportion and Kappa.
+type FleissKappaResult struct {
+	Kappa             float64
+	StdError          float64
+	ZValue            float64
+	PValue            float64
+	ObservedAgreement float64
+	ExpectedAgreement float64
+	Categories        *insyra.DataTable
+	Subjects          int
+	Raters            int
+}
+
+// FleissKappa measures agreement among a fixed number of raters. Rows of
+// dataTable are subjects and columns are raters; each cell is the category
+// that rater assigned.
+func FleissKappa(dataTable insyra.IDataTable) (*FleissKappaResult, error) {
+	if dataTable == nil {
+		return nil, errors.New("data table is nil")
+	}
+	var rows [][]any
+	dataTable.AtomicDo(func(dt *insyra.DataTable) {
+		r, _ := dt.Size()
+		rows = make([][]any, r)
+		for i := range r {
+			rows[i] = dt.GetRow(i).Data()
+		}
+	})
+	N := len(rows)
+	if N < 2 {
+		return nil, errors.New("at least two subjects are required")
+	}
+	m := len(rows[0])
+	if m < 2 {
+		return nil, errors.New("at least two raters are required")
+	}
+	var all []any
+	for i, row := range rows {
+		for j, v := range row {
+			if v == nil {
+				return nil, fmt.Errorf("missing rating for subject %d, rater %d", i, j)
+			}
+		}
+		all = append(all, row...)
+	}
+	categories := sortedClasses(all)
+	k := len(categories)
+	if k < 2 {
+		return nil, errors.New("kappa requires at least two categories")
+	}
+	counts := make([][]float64, N)
+	for i, row := range rows {
+		counts[i] = make([]float64, k)
+		for _, c := range encodeClasses(row, categories) {
+			counts[i][c]++
+		}
+	}
+
+	fm := float64(m)
+	total := float64(N) * fm
+	p := make([]float64, k)
+	pBar := 0.0
+	for i := range N {
+		agree := 0.0
+		for j := range k {
+			p[j] += counts[i][j] / total
+			agree += counts[i][j] * (counts[i][j] - 1)
+		}
+		pBar += agree / (fm * (fm - 1)) / float64(N)
+	}
+	var pe, pq, pqqp float64
+	for _, pj := range p {
+		pe += pj * pj
+		pq += pj * (1 - pj)
+		pqqp += pj * (1 - pj) * (1 - 2*pj)
+	}
+	kappa := (pBar - pe) / (1 - pe)
+	se := math.Sqrt(2) / (pq * math.Sqrt(float64(N)*fm*(fm-1))) * math.Sqrt(pq*pq-pqqp)
+	z := kappa / se
+
+	catCol := insyra.NewDataList().SetName("Category")
+	propCol := insyra.NewDataList().SetName("Proportion")
+	kapCol := insyra.NewDataList().SetName("Kappa")
+	for j, c := range categories {
+		dis := 0.0
+		for i := range N {
+			dis += counts[i][j] * (fm - counts[i][j])
+		}
+		catCol.Append(c)
+		propCol.Append(p[j])
+		kapCol.Append(1 - dis/(float64(N)*fm*(fm-1)*p[j]*(1-p[j])))
+	}
+	return &FleissKappaResult{
+		Kappa:             kappa,
+		StdError:          se,
+		ZValue:            z,
+		PValue:            zPValue(z, TwoSided),
+		ObservedAgreement: pBar,
+		ExpectedAgreement: pe,
+		Categories:        insyra.NewDataTable(catCol, propCol, kapCol),
+		Subjects:          N,
+		Raters:            m,
+	}, nil
+}
+
+type ICCOptions struct {
+	ConfidenceLevel float64
+}
+
+// ICCEstimate is one of the Shrout and Fleiss (1979) intraclass
+// correlations. Type is ICC1, ICC2 or ICC3 for single ratings and ICC1k,
+// ICC2k or ICC3k for the mean of k ratings.
+type ICCEstimate struct {
+	Type               string
+	Description        string
+	ICC                float64
+	F                  float64
+	DF1                float64
+	DF2                float64
+	PValue             float64
+	ConfidenceInterval [2]float64
+}
+
+// ICCResult holds the six intraclass correlations and the two-way ANOVA
+// mean squares they come from. Table has one row per estimate, ready for
+// Show().
+type ICCResult struct {
+	Estimates  []ICCEstimate
+	Table      *insyra.DataTable
+	MSSubjects float64
+	MSWithin   float64
+	MSRaters   float64
+	MSResidual float64
+	Subjects   int
+	Raters     int
+}
+
+// ICC computes intraclass correlations for a subjects × raters table of
+// numeric ratings (rows are subjects), with the F tests and confidence
+// intervals of psych::ICC:
+//
+//   - ICC1: one-way random effects, absolute agreement.
+//   - ICC2: two-way random effects, absolute agreement.
+//   - ICC3: two-way mixed effects, consistency.
+func ICC(dataTable insyra.IDataTable, opts ...ICCOptions) (*ICCResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	conf := 0.95
+	if len(opts) == 1 && opts[0].ConfidenceLevel != 0 {
+		conf = opts[0].ConfidenceLevel
+	}
+	if conf <= 0 || conf >= 1 {
+		return nil, errors.New("confidence level must be in (0, 1)")
+	}
+	if dataTable == nil {
+		return nil, errors.New("data table is nil")
+	}
+	x, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	n, k := len(x), len(x[0])
+	if n < 2 || k < 2 {
+		return nil, errors.New("ICC requires at least two subjects and two raters")
+	}
+	fn, fk := float64(n), float64(k)
+	grand := 0.0
+	rowM := make([]float64, n)
+	colM := make([]float64, k)
+	for i, row := range x {
+		for j, v := range row {
+			grand += v
+			rowM[i] += v / fk
+			colM[j] += v / fn
+		}
+	}
+	grand /= fn * fk
+	var ssRows, ssCols, ssTotal float64
+	for i := range n {
+		ssRows += fk * (rowM[i] - grand) * (rowM[i] - grand)
+	}
+	for j := range k {
+		ssCols += fn * (colM[j] - grand) * (colM[j] - grand)
+	}
+	for _, row := range x {
+		for _, v := range row {
+			ssTotal += (v - grand) * (v - grand)
+		}
+	}
+	ssErr := ssTotal - ssRows - ssCols
+	msb := ssRows / (fn - 1)
+	msw := (ssCols + ssErr) / (fn * (fk - 1))
+	msj := ssCols / (fk - 1)
+	mse := ssErr / ((fn - 1) * (fk - 1))
+	if mse <= 0 || msw <= 0 {
+		return nil, errors.New("ratings have no residual variation; ICC is undefined")
+	}
+
+	alpha := 1 - conf
+	qf := func(d1, d2 float64) float64 { return distuv.F{D1: d1, D2: d2}.Quantile(1 - alpha/2) }
+	df1, dfw, dfe := fn-1, fn*(fk-1), (fn-1)*(fk-1)
+
+	icc1 := (msb - msw) / (msb + (fk-1)*msw)
+	icc2 := (msb - mse) / (msb + (fk-1)*mse + fk*(msj-mse)/fn)
+	icc3 := (msb - mse) / (msb + (fk-1)*mse)
+
+	f1 := msb / msw
+	f1L, f1U := f1/qf(df1, dfw), f1*qf(dfw, df1)
+	f3 := msb / mse
+	f3L, f3U := f3/qf(df1, dfe), f3*qf(dfe, df1)
+
+	// Satterthwaite degrees of freedom for ICC2.
+	fj := msj / mse
+	vn := (fk - 1) * (fn - 1) * math.Pow(fk*icc2*fj+fn*(1+(fk-1)*icc2)-fk*icc2, 2)
+	vd := (fn-1)*fk*fk*icc2*icc2*fj*fj + math.Pow(fn*(1+(fk-1)*icc2)-fk*icc2, 2)
+	v := vn / vd
+	f2U, f2L := qf(df1, v), qf(v, df1)
+	l2 := fn * (msb - f2U*mse) / (f2U*(fk*msj+(fk*fn-fk-fn)*mse) + fn*msb)
+	u2 := fn * (f2L*msb - mse) / (fk*msj + (fk*fn-fk-fn)*mse + fn*f2L*msb)
+
+	p1 := fOneTailedPValue(f1, df1, dfw)
+	p3 := fOneTailedPValue(f3, df1, dfe)
+	est := []ICCEstimate{
+		{"ICC1", "single raters, one-way random (absolute agreement)", icc1, f1, df1, dfw, p1,
+			[2]float64{(f1L - 1) / (f1L + fk - 1), (f1U - 1) / (f1U + fk - 1)}},
+		{"ICC2", "single raters, two-way random (absolute agreement)", icc2, f3, df1, dfe, p3,
+			[2]float64{l2, u2}},
+		{"ICC3", "single raters, two-way mixed (consistency)", icc3, f3, df1, dfe, p3,
+			[2]float64{(f3L - 1) / (f3L + fk - 1), (f3U - 1) / (f3U + fk - 1)}},
+		{"ICC1k", "average raters, one-way random (absolute agreement)", (msb - msw) / msb, f1, df1, dfw, p1,
+			[2]float64{1 - 1/f1L, 1 - 1/f1U}},
+		{"ICC2k", "average raters, two-way random (absolute agreement)", (msb - mse) / (msb + (msj-mse)/fn), f3, df1, dfe, p3,
+			[2]float64{l2 * fk / (1 + l2*(fk-1)), u2 * fk / (1 + u2*(fk-1))}},
+		{"ICC3k", "average raters, two-way mixed (consistency)", (msb - mse) / msb, f3, df1, dfe, p3,
+			[2]float64{1 - 1/f3L, 1 - 1/f3U}},
+	}
+
+	names := []string{"Type", "Description", "ICC", "F", "DF1", "DF2", "P", "Lower", "Upper"}
+	cols := make([]*insyra.DataList, len(names))
+	for i, name := range names {
+		cols[i] = insyra.NewDataList().SetName(name)
+	}
+	for _, e := range est {
+		for i, val := range []any{e.Type, e.Description, e.ICC, e.F, e.DF1, e.DF2, e.PValue,
+			e.ConfidenceInterval[0], e.ConfidenceInterval[1]} {
+			cols[i].Append(val)
+		}
+	}
+	return &ICCResult{
+		Estimates:  est,
+		Table:      insyra.NewDataTable(cols...),
+		MSSubjects: msb,
+		MSWithin:   msw,
+		MSRaters:   msj,
+		MSResidual: mse,
+		Subjects:   n,
+		Raters:     k,
+	}, nil
+}
@@ -0,0 +1,215 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+func TestCohenKappa(t *testing.T) {
+	r1, r2 := expandCounts([][]int{{20, 5}, {10, 15}}, []any{"yes", "no"}, []any{"yes", "no"})
+	res, err := CohenKappa(r1, r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// po = 0.7, pe = 0.5. The standard errors follow Fleiss, Cohen and
+	// Everitt (1969), worked by hand for this table.
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"kappa", res.Kappa, 0.4},
+		{"observed", res.ObservedAgreement, 0.7},
+		{"expected", res.ExpectedAgreement, 0.5},
+		{"se", res.StdError, math.Sqrt(0.2016) / (0.5 * math.Sqrt(50))},
+		{"z", res.ZValue, 0.4 / (math.Sqrt(0.24) / (0.5 * math.Sqrt(50)))},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if res.N != 50 || len(res.Categories) != 2 || res.Categories[0] != "no" {
+		t.Fatalf("n = %d, categories = %v", res.N, res.Categories)
+	}
+	if res.ConfidenceInterval[0] >= res.Kappa || res.ConfidenceInterval[1] <= res.Kappa {
+		t.Fatalf("interval %v does not contain kappa", res.ConfidenceInterval)
+	}
+	if got := res.Table.GetElementByNumberIndex(0, 1); got != 15.0 {
+		t.Fatalf("table[no][no] = %v, want 15", got)
+	}
+
+	// With two categories every weighting scheme agrees.
+	for _, w := range []KappaWeighting{KappaLinear, KappaQuadratic} {
+		weighted, err := CohenKappa(r1, r2, KappaOptions{Weighting: w})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(weighted.Kappa-0.4) > 1e-12 || math.Abs(weighted.StdError-res.StdError) > 1e-12 {
+			t.Fatalf("%s: kappa = %v, se = %v", w, weighted.Kappa, weighted.StdError)
+		}
+	}
+}
+
+func TestCohenKappaWeighted(t *testing.T) {
+	table := [][]int{{12, 4, 1}, {3, 15, 5}, {0, 4, 16}}
+	r1, r2 := expandCounts(table, []any{1, 2, 3}, []any{1, 2, 3})
+	n := 60.0
+	rowP := []float64{17 / n, 23 / n, 20 / n}
+	colP := []float64{15 / n, 23 / n, 22 / n}
+	for _, tc := range []struct {
+		w    KappaWeighting
+		disW func(d float64) float64
+	}{
+		{KappaLinear, func(d float64) float64 { return d / 2 }},
+		{KappaQuadratic, func(d float64) float64 { return d * d / 4 }},
+	} {
+		// 1 - Σ v o / Σ v e with disagreement weights v.
+		var obs, exp float64
+		for i := range 3 {
+			for j := range 3 {
+				v := tc.disW(math.Abs(float64(i - j)))
+				obs += v * float64(table[i][j]) / n
+				exp += v * rowP[i] * colP[j]
+			}
+		}
+		res, err := CohenKappa(r1, r2, KappaOptions{Weighting: tc.w})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 1 - obs/exp; math.Abs(res.Kappa-want) > 1e-12 {
+			t.Fatalf("%s kappa = %v, want %v", tc.w, res.Kappa, want)
+		}
+	}
+
+	// Levels fixes the category order; reversing it leaves kappa unchanged
+	// because the distances between categories are the same.
+	res, err := CohenKappa(r1, r2, KappaOptions{Weighting: KappaLinear, Levels: []any{3, 2, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, _ := CohenKappa(r1, r2, KappaOptions{Weighting: KappaLinear})
+	if math.Abs(res.Kappa-ref.Kappa) > 1e-12 {
+		t.Fatalf("reversed levels kappa = %v, want %v", res.Kappa, ref.Kappa)
+	}
+	if _, err := CohenKappa(r1, r2, KappaOptions{Levels: []any{1, 2}}); err == nil {
+		t.Fatal("expected error for a rating outside Levels")
+	}
+}
+
+func TestFleissKappa(t *testing.T) {
+	// Fleiss (1971) as worked on Wikipedia: 10 subjects, 14 raters, five
+	// categories; each row gives how many raters chose each category.
+	counts := [][]int{
+		{0, 0, 0, 0, 14},
+		{0, 2, 6, 4, 2},
+		{0, 0, 3, 5, 6},
+		{0, 3, 9, 2, 0},
+		{2, 2, 8, 1, 1},
+		{7, 7, 0, 0, 0},
+		{3, 2, 6, 3, 0},
+		{2, 5, 3, 2, 2},
+		{6, 5, 2, 1, 0},
+		{0, 2, 2, 3, 7},
+	}
+	rows := make([][]float64, len(counts))
+	for i, c := range counts {
+		for cat, k := range c {
+			for range k {
+				rows[i] = append(rows[i], float64(cat+1))
+			}
+		}
+	}
+	res, err := FleissKappa(dataTableFromMatrix(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Kappa-0.20993) > 5e-5 {
+		t.Fatalf("kappa = %v, want 0.210", res.Kappa)
+	}
+	if math.Abs(res.ObservedAgreement-0.37802) > 5e-5 || math.Abs(res.ExpectedAgreement-0.21276) > 5e-5 {
+		t.Fatalf("P = %v, Pe = %v", res.ObservedAgreement, res.ExpectedAgreement)
+	}
+	if res.Subjects != 10 || res.Raters != 14 {
+		t.Fatalf("subjects = %d, raters = %d", res.Subjects, res.Raters)
+	}
+	wantP := []float64{0.143, 0.200, 0.279, 0.150, 0.229}
+	for j, want := range wantP {
+		if got := res.Categories.GetElementByNumberIndex(j, 1).(float64); math.Abs(got-want) > 5e-4 {
+			t.Fatalf("category %d proportion = %v, want %v", j+1, got, want)
+		}
+	}
+	if res.StdError <= 0 || math.Abs(res.ZValue-res.Kappa/res.StdError) > 1e-12 || res.PValue > 1e-6 {
+		t.Fatalf("se = %v, z = %v, p = %v", res.StdError, res.ZValue, res.PValue)
+	}
+}
+
+func TestICCShroutFleiss(t *testing.T) {
+	// Shrout and Fleiss (1979), Table 2: six targets rated by four judges.
+	rows := [][]float64{
+		{9, 2, 5, 8},
+		{6, 1, 3, 2},
+		{8, 4, 6, 8},
+		{7, 1, 2, 6},
+		{10, 5, 6, 9},
+		{6, 2, 4, 7},
+	}
+	res, err := ICC(dataTableFromMatrix(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// psych::ICC on the same data.
+	want := []struct {
+		typ          string
+		icc, f       float64
+		lower, upper float64
+	}{
+		{"ICC1", 0.17, 1.795, -0.133, 0.72},
+		{"ICC2", 0.29, 11.03, 0.019, 0.76},
+		{"ICC3", 0.71, 11.03, 0.342, 0.95},
+		{"ICC1k", 0.44, 1.795, -0.884, 0.91},
+		{"ICC2k", 0.62, 11.03, 0.071, 0.93},
+		{"ICC3k", 0.91, 11.03, 0.676, 0.99},
+	}
+	if len(res.Estimates) != len(want) {
+		t.Fatalf("got %d estimates", len(res.Estimates))
+	}
+	for i, w := range want {
+		e := res.Estimates[i]
+		if e.Type != w.typ || math.Abs(e.ICC-w.icc) > 0.005 || math.Abs(e.F-w.f) > 0.005 {
+			t.Errorf("%s: icc = %v, F = %v; want %v, %v", e.Type, e.ICC, e.F, w.icc, w.f)
+		}
+		if math.Abs(e.ConfidenceInterval[0]-w.lower) > 0.005 || math.Abs(e.ConfidenceInterval[1]-w.upper) > 0.005 {
+			t.Errorf("%s: interval = %v, want [%v, %v]", e.Type, e.ConfidenceInterval, w.lower, w.upper)
+		}
+	}
+	if e := res.Estimates[0]; e.DF1 != 5 || e.DF2 != 18 || math.Abs(e.PValue-0.165) > 0.001 {
+		t.Errorf("ICC1 test: df = (%v, %v), p = %v", e.DF1, e.DF2, e.PValue)
+	}
+	if e := res.Estimates[2]; e.DF2 != 15 || math.Abs(e.PValue-0.00013) > 0.00001 {
+		t.Errorf("ICC3 test: df2 = %v, p = %v", e.DF2, e.PValue)
+	}
+	if r, c := res.Table.Size(); r != 6 || c != 9 {
+		t.Fatalf("table size = %d x %d", r, c)
+	}
+}
+
+func TestAgreementValidation(t *testing.T) {
+	a := insyra.NewDataList(1, 2, 1)
+	if _, err := CohenKappa(a, insyra.NewDataList(1, 2)); err == nil {
+		t.Fatal("expected error for unequal lengths")
+	}
+	if _, err := CohenKappa(a, a, KappaOptions{Weighting: "cubic"}); err == nil {
+		t.Fatal("expected error for unknown weighting")
+	}
+	if _, err := CohenKappa(insyra.NewDataList(1, 1), insyra.NewDataList(1, 1)); err == nil {
+		t.Fatal("expected error for a single category")
+	}
+	if _, err := FleissKappa(dataTableFromMatrix([][]float64{{1}, {2}})); err == nil {
+		t.Fatal("expected error for a single rater")
+	}
+	if _, err := ICC(dataTableFromMatrix([][]float64{{1, 2}, {3, 4}}), ICCOptions{ConfidenceLevel: 1.5}); err == nil {
+		t.Fatal("expected error for invalid confidence level")
+	}
+}
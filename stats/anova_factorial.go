@@ -0,0 +1,354 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ANOVASSType selects how FactorialANOVA partitions sums of squares when the
+// design is unbalanced. The zero value means ANOVATypeII.
+type ANOVASSType int
+
+const (
+	// ANOVATypeI is sequential: each term is adjusted for the terms listed
+	// before it (R's anova()).
+	ANOVATypeI ANOVASSType = iota + 1
+	// ANOVATypeII adjusts each term for every term that does not contain it
+	// (car::Anova's default).
+	ANOVATypeII
+	// ANOVATypeIII adjusts each term for all other terms, using sum-to-zero
+	// contrasts (car::Anova(type = 3) with contr.sum).
+	ANOVATypeIII
+)
+
+func (t ANOVASSType) String() string {
+	switch t {
+	case ANOVATypeI:
+		return "I"
+	case ANOVATypeII:
+		return "II"
+	case ANOVATypeIII:
+		return "III"
+	}
+	return fmt.Sprintf("ANOVASSType(%d)", int(t))
+}
+
+type FactorialANOVAOptions struct {
+	Response string
+	// Factors lists the categorical columns. Their interactions are added
+	// up to MaxInteraction.
+	Factors []string
+	// Covariates lists numeric columns entered as main effects only
+	// (ANCOVA with a common slope across cells).
+	Covariates []string
+	// MaxInteraction caps the order of factor interactions; 0 fits the full
+	// factorial and 1 a main-effects model.
+	MaxInteraction int
+	// SSType defaults to ANOVATypeII.
+	SSType ANOVASSType
+}
+
+// FactorialANOVATerm is one row of a factorial ANOVA table. EtaSquared is
+// partial η² and OmegaSquared partial ω², (SS - df·MSE) / (SS + (N - df)·MSE),
+// which is negative when F < 1.
+type FactorialANOVATerm struct {
+	Name string
+	ANOVAResultComponent
+	OmegaSquared float64
+}
+
+// FactorialANOVAResult holds an N-way ANOVA or ANCOVA table. Terms are
+// ordered covariates, factor main effects, then factor interactions by
+// increasing order; interaction names join the factors with ":".
+type FactorialANOVAResult struct {
+	SSType   ANOVASSType
+	Terms    []FactorialANOVATerm
+	Residual ANOVAResultComponent
+	TotalSS  float64
+	NObs     int
+}
+
+// Term returns the table row with the given name, or nil.
+func (r *FactorialANOVAResult) Term(name string) *FactorialANOVATerm {
+	for i := range r.Terms {
+		if r.Terms[i].Name == name {
+			return &r.Terms[i]
+		}
+	}
+	return nil
+}
+
+type anovaTerm struct {
+	name string
+	mask uint64 // variables in the term; covariates get their own bits
+	cols [][]float64
+}
+
+// FactorialANOVA fits a linear model with factor main effects, their
+// interactions and optional covariates to a long-format DataTable, and tests
+// every term with the requested type of sums of squares. Cells may have
+// unequal sizes but none may be empty.
+func FactorialANOVA(dataTable insyra.IDataTable, opts FactorialANOVAOptions) (*FactorialANOVAResult, error) {
+	ssType := opts.SSType
+	if ssType == 0 {
+		ssType = ANOVATypeII
+	}
+	if ssType < ANOVATypeI || ssType > ANOVATypeIII {
+		return nil, fmt.Errorf("unsupported sums of squares type %d", int(ssType))
+	}
+	if opts.Response == "" {
+		return nil, errors.New("response column is required")
+	}
+	if len(opts.Factors) == 0 {
+		return nil, errors.New("at least one factor is required")
+	}
+	if len(opts.Factors)+len(opts.Covariates) > 64 {
+		return nil, errors.New("too many factors and covariates")
+	}
+	if opts.MaxInteraction < 0 {
+		return nil, errors.New("MaxInteraction must be non-negative")
+	}
+	maxOrder := opts.MaxInteraction
+	if maxOrder == 0 || maxOrder > len(opts.Factors) {
+		maxOrder = len(opts.Factors)
+	}
+
+	names := append([]string{opts.Response}, opts.Covariates...)
+	names = append(names, opts.Factors...)
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("column %q is used more than once", name)
+		}
+		seen[name] = true
+	}
+	raw, n, err := rawColumnsByName(dataTable, names)
+	if err != nil {
+		return nil, err
+	}
+	y, err := numericColumn(raw[0], opts.Response)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []anovaTerm
+	for c, name := range opts.Covariates {
+		x, err := numericColumn(raw[1+c], name)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, anovaTerm{name: name, mask: 1 << uint(len(opts.Factors)+c), cols: [][]float64{x}})
+	}
+	coding := make([][][]float64, len(opts.Factors))
+	for f, name := range opts.Factors {
+		codes, levels, err := factorLevels(raw[1+len(opts.Covariates)+f], name)
+		if err != nil {
+			return nil, err
+		}
+		if len(levels) < 2 {
+			return nil, fmt.Errorf("factor %q needs at least two levels", name)
+		}
+		coding[f] = sumContrasts(codes, len(levels))
+	}
+	for order := 1; order <= maxOrder; order++ {
+		for _, set := range combinations(len(opts.Factors), order) {
+			t := anovaTerm{cols: [][]float64{ones(n)}}
+			parts := make([]string, len(set))
+			for k, f := range set {
+				parts[k] = opts.Factors[f]
+				t.mask |= 1 << uint(f)
+				t.cols = interactColumns(t.cols, coding[f])
+			}
+			t.name = strings.Join(parts, ":")
+			terms = append(terms, t)
+		}
+	}
+
+	full := make([]int, len(terms))
+	p := 1
+	for i := range terms {
+		full[i] = i
+		p += len(terms[i].cols)
+	}
+	dfRes := n - p
+	if dfRes <= 0 {
+		return nil, fmt.Errorf("not enough observations: %d rows for %d parameters", n, p)
+	}
+	rssFull, ok := anovaRSS(y, terms, full)
+	if !ok {
+		return nil, errors.New("design matrix is rank deficient; check for empty cells or collinear covariates")
+	}
+
+	ss := make([]float64, len(terms))
+	switch ssType {
+	case ANOVATypeI:
+		prev, _ := anovaRSS(y, terms, nil)
+		for i := range terms {
+			cur, _ := anovaRSS(y, terms, full[:i+1])
+			ss[i] = prev - cur
+			prev = cur
+		}
+	case ANOVATypeII:
+		for i, t := range terms {
+			var base []int
+			for j, u := range terms {
+				if u.mask&t.mask != t.mask {
+					base = append(base, j)
+				}
+			}
+			without, _ := anovaRSS(y, terms, base)
+			with, _ := anovaRSS(y, terms, append(base, i))
+			ss[i] = without - with
+		}
+	case ANOVATypeIII:
+		for i := range terms {
+			others := append(append([]int{}, full[:i]...), full[i+1:]...)
+			without, _ := anovaRSS(y, terms, others)
+			ss[i] = without - rssFull
+		}
+	}
+
+	mse := rssFull / float64(dfRes)
+	res := &FactorialANOVAResult{
+		SSType:   ssType,
+		Terms:    make([]FactorialANOVATerm, len(terms)),
+		Residual: newANOVAWithinComponent(rssFull, dfRes),
+		NObs:     n,
+	}
+	for i, t := range terms {
+		s := math.Max(ss[i], 0)
+		df := len(t.cols)
+		res.Terms[i] = FactorialANOVATerm{
+			Name:                 t.name,
+			ANOVAResultComponent: newANOVABetweenComponent(s, df, rssFull, dfRes),
+			OmegaSquared:         (s - float64(df)*mse) / (s + float64(n-df)*mse),
+		}
+	}
+	mean := 0.0
+	for _, v := range y {
+		mean += v
+	}
+	mean /= float64(n)
+	for _, v := range y {
+		res.TotalSS += (v - mean) * (v - mean)
+	}
+	return res, nil
+}
+
+// sumContrasts codes a factor with k levels as k-1 columns of R's
+// contr.sum: level j < k-1 is the unit vector e_j and the last level is -1
+// in every column.
+func sumContrasts(codes []int, k int) [][]float64 {
+	cols := make([][]float64, k-1)
+	for j := range cols {
+		cols[j] = make([]float64, len(codes))
+	}
+	for i, c := range codes {
+		if c == k-1 {
+			for j := range cols {
+				cols[j][i] = -1
+			}
+			continue
+		}
+		cols[c][i] = 1
+	}
+	return cols
+}
+
+// interactColumns returns every elementwise product of a column in a with a
+// column in b.
+func interactColumns(a, b [][]float64) [][]float64 {
+	out := make([][]float64, 0, len(a)*len(b))
+	for _, ca := range a {
+		for _, cb := range b {
+			col := make([]float64, len(ca))
+			for i := range col {
+				col[i] = ca[i] * cb[i]
+			}
+			out = append(out, col)
+		}
+	}
+	return out
+}
+
+// combinations lists the k-subsets of {0, ..., n-1} in lexicographic order,
+// which is the order R gives interaction terms of the same degree.
+func combinations(n, k int) [][]int {
+	var out [][]int
+	cur := make([]int, 0, k)
+	var rec func(start int)
+	rec = func(start int) {
+		if len(cur) == k {
+			out = append(out, append([]int{}, cur...))
+			return
+		}
+		for i := start; i <= n-(k-len(cur)); i++ {
+			cur = append(cur, i)
+			rec(i + 1)
+			cur = cur[:len(cur)-1]
+		}
+	}
+	rec(0)
+	return out
+}
+
+func ones(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = 1
+	}
+	return out
+}
+
+// anovaRSS fits y on an intercept plus the columns of the selected terms by
+// QR and returns the residual sum of squares. ok is false when the design
+// is rank deficient.
+func anovaRSS(y []float64, terms []anovaTerm, selected []int) (rss float64, ok bool) {
+	n := len(y)
+	p := 1
+	for _, i := range selected {
+		p += len(terms[i].cols)
+	}
+	X := mat.NewDense(n, p, nil)
+	for r := range n {
+		X.Set(r, 0, 1)
+	}
+	c := 1
+	for _, i := range selected {
+		for _, col := range terms[i].cols {
+			for r, v := range col {
+				X.Set(r, c, v)
+			}
+			c++
+		}
+	}
+	var qr mat.QR
+	qr.Factorize(X)
+	var R mat.Dense
+	qr.RTo(&R)
+	maxDiag := 0.0
+	for j := range p {
+		maxDiag = math.Max(maxDiag, math.Abs(R.At(j, j)))
+	}
+	for j := range p {
+		if math.Abs(R.At(j, j)) <= 1e-10*maxDiag {
+			return math.NaN(), false
+		}
+	}
+	var beta mat.Dense
+	if err := qr.SolveTo(&beta, false, mat.NewDense(n, 1, append([]float64(nil), y...))); err != nil {
+		return math.NaN(), false
+	}
+	var fitted mat.Dense
+	fitted.Mul(X, &beta)
+	for r := range n {
+		d := y[r] - fitted.At(r, 0)
+		rss += d * d
+	}
+	return rss, true
+}
@@ -0,0 +1,324 @@
+package stats_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// longANOVATable builds a long-format table from cells given in A-major
+// order, as TwoWayANOVA takes them.
+func longANOVATable(levelsB int, cells [][]float64) *insyra.DataTable {
+	a, b, y := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	for c, cell := range cells {
+		for _, v := range cell {
+			a.Append(string(rune('p' + c/levelsB)))
+			b.Append(string(rune('x' + c%levelsB)))
+			y.Append(v)
+		}
+	}
+	return insyra.NewDataTable(a.SetName("A"), b.SetName("B"), y.SetName("y"))
+}
+
+func TestFactorialANOVABalancedMatchesTwoWay(t *testing.T) {
+	cells := [][]float64{
+		{4.1, 5.3, 6.0, 5.2}, {7.4, 8.1, 6.9, 7.7}, {3.2, 2.8, 4.4, 3.9},
+		{6.3, 5.1, 5.8, 6.6}, {9.0, 8.2, 9.7, 8.8}, {2.1, 3.5, 2.6, 3.0},
+	}
+	dls := make([]insyra.IDataList, len(cells))
+	for i, c := range cells {
+		dls[i] = insyra.NewDataList(c)
+	}
+	ref, err := stats.TwoWayANOVA(2, 3, dls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dt := longANOVATable(3, cells)
+	for _, ss := range []stats.ANOVASSType{stats.ANOVATypeI, stats.ANOVATypeII, stats.ANOVATypeIII} {
+		res, err := stats.FactorialANOVA(dt, stats.FactorialANOVAOptions{Response: "y", Factors: []string{"A", "B"}, SSType: ss})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]stats.ANOVAResultComponent{"A": ref.FactorA, "B": ref.FactorB, "A:B": ref.Interaction}
+		for name, w := range want {
+			got := res.Term(name)
+			if got == nil {
+				t.Fatalf("type %v: term %s missing", ss, name)
+			}
+			if got.DF != w.DF || !floatAlmostEqual(got.SumOfSquares, w.SumOfSquares, 1e-9) ||
+				!floatAlmostEqual(got.F, w.F, 1e-8) || !floatAlmostEqual(got.P, w.P, 1e-9) ||
+				!floatAlmostEqual(got.EtaSquared, w.EtaSquared, 1e-10) {
+				t.Errorf("type %v %s = %+v, want %+v", ss, name, got.ANOVAResultComponent, w)
+			}
+		}
+		if res.Residual.DF != ref.Within.DF || !floatAlmostEqual(res.Residual.SumOfSquares, ref.Within.SumOfSquares, 1e-9) {
+			t.Errorf("type %v residual %+v, want %+v", ss, res.Residual, ref.Within)
+		}
+		if !floatAlmostEqual(res.TotalSS, ref.TotalSS, 1e-9) {
+			t.Errorf("TotalSS %v, want %v", res.TotalSS, ref.TotalSS)
+		}
+	}
+}
+
+func TestFactorialANOVAUnbalancedSumsOfSquares(t *testing.T) {
+	cells := [][]float64{{3, 4, 5, 6, 4}, {7, 9}, {2, 3, 1}, {8, 6, 7, 9, 10, 8}}
+	dt := longANOVATable(2, cells)
+	fit := func(ss stats.ANOVASSType, factors ...string) *stats.FactorialANOVAResult {
+		res, err := stats.FactorialANOVA(dt, stats.FactorialANOVAOptions{Response: "y", Factors: factors, SSType: ss})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	var means, inv [4]float64
+	for c, cell := range cells {
+		for _, v := range cell {
+			means[c] += v
+		}
+		means[c] /= float64(len(cell))
+		inv[c] = 1 / float64(len(cell))
+	}
+	// Type III tests unweighted-means contrasts: SS = (c'm)² / Σ c²/n.
+	contrastSS := func(c [4]float64) float64 {
+		num, den := 0.0, 0.0
+		for i := range c {
+			num += c[i] * means[i]
+			den += c[i] * c[i] * inv[i]
+		}
+		return num * num / den
+	}
+	t3 := fit(stats.ANOVATypeIII, "A", "B")
+	if got, want := t3.Term("A").SumOfSquares, contrastSS([4]float64{1, 1, -1, -1}); !floatAlmostEqual(got, want, 1e-9) {
+		t.Errorf("type III SS(A) = %v, want %v", got, want)
+	}
+	if got, want := t3.Term("B").SumOfSquares, contrastSS([4]float64{1, -1, 1, -1}); !floatAlmostEqual(got, want, 1e-9) {
+		t.Errorf("type III SS(B) = %v, want %v", got, want)
+	}
+	interaction := contrastSS([4]float64{1, -1, -1, 1})
+	for _, ss := range []stats.ANOVASSType{stats.ANOVATypeI, stats.ANOVATypeII, stats.ANOVATypeIII} {
+		if got := fit(ss, "A", "B").Term("A:B").SumOfSquares; !floatAlmostEqual(got, interaction, 1e-9) {
+			t.Errorf("type %v SS(A:B) = %v, want %v", ss, got, interaction)
+		}
+	}
+
+	// Type I enters A first, so SS(A) is its one-way SS.
+	var groups [2][]float64
+	for c, cell := range cells {
+		groups[c/2] = append(groups[c/2], cell...)
+	}
+	oneWay, err := stats.OneWayANOVA(insyra.NewDataList(groups[0]), insyra.NewDataList(groups[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := fit(stats.ANOVATypeI, "A", "B")
+	if got := t1.Term("A").SumOfSquares; !floatAlmostEqual(got, oneWay.Factor.SumOfSquares, 1e-9) {
+		t.Errorf("type I SS(A) = %v, want %v", got, oneWay.Factor.SumOfSquares)
+	}
+	sum := t1.Residual.SumOfSquares
+	for _, term := range t1.Terms {
+		sum += term.SumOfSquares
+	}
+	if !floatAlmostEqual(sum, t1.TotalSS, 1e-9) {
+		t.Errorf("type I SS sum to %v, want TotalSS %v", sum, t1.TotalSS)
+	}
+
+	// Type II SS(A) is A adjusted for B, i.e. type I with B entered first.
+	t2 := fit(stats.ANOVATypeII, "A", "B")
+	if got, want := t2.Term("A").SumOfSquares, fit(stats.ANOVATypeI, "B", "A").Term("A").SumOfSquares; !floatAlmostEqual(got, want, 1e-9) {
+		t.Errorf("type II SS(A) = %v, want %v", got, want)
+	}
+	if floatAlmostEqual(t2.Term("A").SumOfSquares, t3.Term("A").SumOfSquares, 1e-6) {
+		t.Errorf("type II and III should differ on this unbalanced design")
+	}
+
+	a := t3.Term("A")
+	mse := t3.Residual.SumOfSquares / float64(t3.Residual.DF)
+	wantOmega := (a.SumOfSquares - mse) / (a.SumOfSquares + float64(t3.NObs-1)*mse)
+	if !floatAlmostEqual(a.OmegaSquared, wantOmega, 1e-12) {
+		t.Errorf("partial ω² = %v, want %v", a.OmegaSquared, wantOmega)
+	}
+	if want := a.SumOfSquares / (a.SumOfSquares + t3.Residual.SumOfSquares); !floatAlmostEqual(a.EtaSquared, want, 1e-12) {
+		t.Errorf("partial η² = %v, want %v", a.EtaSquared, want)
+	}
+}
+
+func TestFactorialANCOVACovariate(t *testing.T) {
+	x := [][]float64{{1, 2, 3, 4}, {2, 3, 5, 6}, {1, 4, 5, 7}}
+	y := [][]float64{{2.1, 2.9, 4.2, 4.8}, {4.0, 5.2, 6.8, 8.1}, {1.5, 4.4, 5.1, 7.3}}
+	g, xs, ys := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	var sxy, sxx float64
+	for k := range x {
+		var mx, my float64
+		for i := range x[k] {
+			g.Append([]string{"a", "b", "c"}[k])
+			xs.Append(x[k][i])
+			ys.Append(y[k][i])
+			mx += x[k][i] / 4
+			my += y[k][i] / 4
+		}
+		for i := range x[k] {
+			sxy += (x[k][i] - mx) * (y[k][i] - my)
+			sxx += (x[k][i] - mx) * (x[k][i] - mx)
+		}
+	}
+	dt := insyra.NewDataTable(g.SetName("group"), xs.SetName("x"), ys.SetName("y"))
+	res, err := stats.FactorialANOVA(dt, stats.FactorialANOVAOptions{Response: "y", Factors: []string{"group"}, Covariates: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := []string{res.Terms[0].Name, res.Terms[1].Name}; !reflect.DeepEqual(names, []string{"x", "group"}) {
+		t.Fatalf("terms %v", names)
+	}
+	// Adjusted for group, the covariate's SS is the pooled within-group
+	// regression SS.
+	if got, want := res.Term("x").SumOfSquares, sxy*sxy/sxx; !floatAlmostEqual(got, want, 1e-9) {
+		t.Errorf("SS(x) = %v, want %v", got, want)
+	}
+	if res.Residual.DF != 12-4 || res.Term("group").DF != 2 {
+		t.Errorf("df group %d, residual %d", res.Term("group").DF, res.Residual.DF)
+	}
+}
+
+// mtcarsANOVATable holds mpg, cyl, am and wt from R's mtcars, with cyl and am
+// as factors.
+func mtcarsANOVATable() *insyra.DataTable {
+	rows := [][4]float64{
+		{21.0, 6, 1, 2.620}, {21.0, 6, 1, 2.875}, {22.8, 4, 1, 2.320}, {21.4, 6, 0, 3.215},
+		{18.7, 8, 0, 3.440}, {18.1, 6, 0, 3.460}, {14.3, 8, 0, 3.570}, {24.4, 4, 0, 3.190},
+		{22.8, 4, 0, 3.150}, {19.2, 6, 0, 3.440}, {17.8, 6, 0, 3.440}, {16.4, 8, 0, 4.070},
+		{17.3, 8, 0, 3.730}, {15.2, 8, 0, 3.780}, {10.4, 8, 0, 5.250}, {10.4, 8, 0, 5.424},
+		{14.7, 8, 0, 5.345}, {32.4, 4, 1, 2.200}, {30.4, 4, 1, 1.615}, {33.9, 4, 1, 1.835},
+		{21.5, 4, 0, 2.465}, {15.5, 8, 0, 3.520}, {15.2, 8, 0, 3.435}, {13.3, 8, 0, 3.840},
+		{19.2, 8, 0, 3.845}, {27.3, 4, 1, 1.935}, {26.0, 4, 1, 2.140}, {30.4, 4, 1, 1.513},
+		{15.8, 8, 1, 3.170}, {19.7, 6, 1, 2.770}, {15.0, 8, 1, 3.570}, {21.4, 4, 1, 2.780},
+	}
+	mpg, cyl, am, wt := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	for _, r := range rows {
+		mpg.Append(r[0])
+		cyl.Append(fmt.Sprint(r[1]))
+		am.Append(fmt.Sprint(r[2]))
+		wt.Append(r[3])
+	}
+	return insyra.NewDataTable(mpg.SetName("mpg"), cyl.SetName("cyl"), am.SetName("am"), wt.SetName("wt"))
+}
+
+// TestFactorialANOVAMtcarsReference checks the unbalanced 3x2 design
+// mpg ~ cyl * am and the ANCOVA mpg ~ wt + cyl * am against
+//
+//	options(contrasts = c("contr.sum", "contr.poly"))
+//	car::Anova(lm(mpg ~ factor(cyl) * factor(am), mtcars), type = 2)  # and type = 3
+//	car::Anova(lm(mpg ~ wt + factor(cyl) * factor(am), mtcars), type = 2)  # and type = 3
+//
+// The expected values were computed in exact rational arithmetic as the
+// model comparisons car::Anova performs for these designs: type II compares
+// each term against all terms that do not contain it, type III drops the
+// term's sum-to-zero columns from the full model.
+func TestFactorialANOVAMtcarsReference(t *testing.T) {
+	type row struct {
+		ss float64
+		df int
+		f  float64
+	}
+	cases := []struct {
+		name       string
+		covariates []string
+		ssType     stats.ANOVASSType
+		rss        float64
+		dfRes      int
+		terms      map[string]row
+	}{
+		{"two-way II", nil, stats.ANOVATypeII, 239.05916666666667, 26, map[string]row{
+			"cyl":    {456.4009212802305, 2, 24.81901053773855},
+			"am":     {36.766919492544496, 1, 3.998758634255077},
+			"cyl:am": {25.436511243386242, 2, 1.3832334930921055},
+		}},
+		{"two-way III", nil, stats.ANOVATypeIII, 239.05916666666667, 26, map[string]row{
+			"cyl":    {410.4638921957672, 2, 22.320962098831767},
+			"am":     {29.867350427350427, 1, 3.2483636663633946},
+			"cyl:am": {25.436511243386242, 2, 1.3832334930921055},
+		}},
+		{"ANCOVA II", []string{"wt"}, stats.ANOVATypeII, 163.6869793258161, 25, map[string]row{
+			"wt":     {75.37218734085056, 1, 11.51163453123897},
+			"cyl":    {95.35136370989402, 2, 7.281532418050397},
+			"am":     {0.09031415583215804, 1, 0.013793729379718908},
+			"cyl:am": {19.281354186729477, 2, 1.472425774651131},
+		}},
+		{"ANCOVA III", []string{"wt"}, stats.ANOVATypeIII, 163.6869793258161, 25, map[string]row{
+			"wt":     {75.37218734085056, 1, 11.51163453123897},
+			"cyl":    {96.87159269625117, 2, 7.3976251116032525},
+			"am":     {0.003824273568471087, 1, 0.0005840833498519965},
+			"cyl:am": {19.281354186729477, 2, 1.472425774651131},
+		}},
+	}
+	dt := mtcarsANOVATable()
+	for _, tc := range cases {
+		res, err := stats.FactorialANOVA(dt, stats.FactorialANOVAOptions{
+			Response: "mpg", Factors: []string{"cyl", "am"}, Covariates: tc.covariates, SSType: tc.ssType,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if res.Residual.DF != tc.dfRes || !floatAlmostEqual(res.Residual.SumOfSquares, tc.rss, 1e-9) {
+			t.Errorf("%s: residual SS %v on %d df, want %v on %d", tc.name, res.Residual.SumOfSquares, res.Residual.DF, tc.rss, tc.dfRes)
+		}
+		if len(res.Terms) != len(tc.terms) {
+			t.Fatalf("%s: %d terms, want %d", tc.name, len(res.Terms), len(tc.terms))
+		}
+		for name, w := range tc.terms {
+			got := res.Term(name)
+			if got == nil {
+				t.Fatalf("%s: term %s missing", tc.name, name)
+			}
+			if got.DF != w.df || !floatAlmostEqual(got.SumOfSquares, w.ss, 1e-9) || !floatAlmostEqual(got.F, w.f, 1e-9) {
+				t.Errorf("%s %s: SS %v, df %d, F %v; want %v, %d, %v", tc.name, name, got.SumOfSquares, got.DF, got.F, w.ss, w.df, w.f)
+			}
+		}
+	}
+}
+
+func TestFactorialANOVATermsAndErrors(t *testing.T) {
+	a, b, c, y := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	z := seededNormals(3, 48)
+	for i := range 48 {
+		a.Append(i % 2)
+		b.Append((i / 2) % 2)
+		c.Append((i / 4) % 3)
+		y.Append(z[i])
+	}
+	dt := insyra.NewDataTable(a.SetName("A"), b.SetName("B"), c.SetName("C"), y.SetName("y"))
+	res, err := stats.FactorialANOVA(dt, stats.FactorialANOVAOptions{Response: "y", Factors: []string{"A", "B", "C"}, MaxInteraction: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, term := range res.Terms {
+		names = append(names, term.Name)
+	}
+	if want := []string{"A", "B", "C", "A:B", "A:C", "B:C"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("terms %v, want %v", names, want)
+	}
+	if res.Term("A:C").DF != 2 || res.Residual.DF != 48-1-1-1-2-1-2-2 {
+		t.Errorf("df A:C %d, residual %d", res.Term("A:C").DF, res.Residual.DF)
+	}
+
+	empty := longANOVATable(2, [][]float64{{1, 2}, {3, 4}, {5, 6}, {}})
+	if _, err := stats.FactorialANOVA(empty, stats.FactorialANOVAOptions{Response: "y", Factors: []string{"A", "B"}}); err == nil {
+		t.Errorf("expected error for an empty cell")
+	}
+	bad := []stats.FactorialANOVAOptions{
+		{Factors: []string{"A"}},
+		{Response: "y"},
+		{Response: "y", Factors: []string{"missing"}},
+		{Response: "y", Factors: []string{"A", "A"}},
+		{Response: "y", Factors: []string{"A"}, SSType: 4},
+	}
+	for _, opt := range bad {
+		if _, err := stats.FactorialANOVA(dt, opt); err == nil {
+			t.Errorf("expected error for %+v", opt)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/parallel"
+	"github.com/HazelnutParadise/insyra/stats/internal/parutil"
+)
+
+type BootstrapCIMethod string
+
+const (
+	BootstrapPercentile  BootstrapCIMethod = "percentile"
+	BootstrapBCa         BootstrapCIMethod = "bca"
+	BootstrapStudentized BootstrapCIMethod = "studentized"
+)
+
+// BootstrapOptions configures Bootstrap and BootstrapTable. Seed and UseSeed
+// follow insyra.SamplingOptions; a seeded run is reproducible regardless of
+// how many workers execute it.
+type BootstrapOptions struct {
+	// Method defaults to BootstrapPercentile.
+	Method          BootstrapCIMethod
+	ConfidenceLevel float64
+	Seed            uint64
+	UseSeed         bool
+	// InnerB is the number of inner resamples used to estimate each
+	// replicate's standard error for studentized intervals (default 50).
+	InnerB int
+}
+
+// BootstrapResult holds the bootstrap distribution of a statistic. Bias is
+// mean(Replicates) - Estimate and StdError is the standard deviation of the
+// replicates.
+type BootstrapResult struct {
+	Estimate        float64
+	Bias            float64
+	StdError        float64
+	CI              [2]float64
+	Method          BootstrapCIMethod
+	ConfidenceLevel float64
+	B               int
+	Replicates      []float64
+}
+
+// Bootstrap resamples a numeric DataList with replacement B times and
+// evaluates statFn on each resample. statFn is called concurrently and must
+// not retain or modify its argument.
+func Bootstrap(data insyra.IDataList, statFn func(sample []float64) float64, B int, opts ...BootstrapOptions) (*BootstrapResult, error) {
+	if data == nil {
+		return nil, errors.New("data is nil")
+	}
+	if statFn == nil {
+		return nil, errors.New("statFn is nil")
+	}
+	x, err := numericColumn(data.Data(), data.GetName())
+	if err != nil {
+		return nil, err
+	}
+	eval := func(idx []int) float64 {
+		sample := make([]float64, len(idx))
+		for i, j := range idx {
+			sample[i] = x[j]
+		}
+		return statFn(sample)
+	}
+	return bootstrapIndices(len(x), eval, B, opts)
+}
+
+// BootstrapTable resamples the rows of a numeric DataTable, so statistics
+// that depend on paired columns (correlations, regression slopes) keep
+// their rows together. statFn receives the resampled columns in table order.
+func BootstrapTable(dataTable insyra.IDataTable, statFn func(columns [][]float64) float64, B int, opts ...BootstrapOptions) (*BootstrapResult, error) {
+	if statFn == nil {
+		return nil, errors.New("statFn is nil")
+	}
+	if dataTable == nil {
+		return nil, errors.New("data table is nil")
+	}
+	var names []string
+	dataTable.AtomicDo(func(dt *insyra.DataTable) { names = dt.ColNames() })
+	raw, n, err := rawColumnsByName(dataTable, names)
+	if err != nil {
+		return nil, err
+	}
+	cols := make([][]float64, len(raw))
+	for j := range raw {
+		if cols[j], err = numericColumn(raw[j], names[j]); err != nil {
+			return nil, err
+		}
+	}
+	eval := func(idx []int) float64 {
+		sample := make([][]float64, len(cols))
+		for j, col := range cols {
+			sample[j] = make([]float64, len(idx))
+			for i, k := range idx {
+				sample[j][i] = col[k]
+			}
+		}
+		return statFn(sample)
+	}
+	return bootstrapIndices(n, eval, B, opts)
+}
+
+// bootstrapIndices is the resampling engine shared by the public entry
+// points; eval receives the row indices of one resample.
+func bootstrapIndices(n int, eval func(idx []int) float64, B int, opts []BootstrapOptions) (*BootstrapResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt BootstrapOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	method := opt.Method
+	if method == "" {
+		method = BootstrapPercentile
+	}
+	switch method {
+	case BootstrapPercentile, BootstrapBCa, BootstrapStudentized:
+	default:
+		return nil, errors.New("unsupported bootstrap interval method")
+	}
+	if n < 2 {
+		return nil, errors.New("bootstrap needs at least two observations")
+	}
+	if B < 2 {
+		return nil, errors.New("B must be at least 2")
+	}
+	innerB := opt.InnerB
+	if innerB <= 0 {
+		innerB = 50
+	}
+	cl := resolveConfidenceLevel(opt.ConfidenceLevel)
+
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	estimate := eval(all)
+	if math.IsNaN(estimate) || math.IsInf(estimate, 0) {
+		return nil, errors.New("statistic is not finite on the original data")
+	}
+
+	seed := resolveSeed(opt.Seed, opt.UseSeed)
+	reps := make([]float64, B)
+	var tStats []float64
+	if method == BootstrapStudentized {
+		tStats = make([]float64, B)
+	}
+	runParallelChunks(B, func(start, end int) {
+		idx := make([]int, n)
+		inner := make([]int, n)
+		innerReps := make([]float64, innerB)
+		for b := start; b < end; b++ {
+			rng := replicateRNG(seed, uint64(b))
+			for i := range idx {
+				idx[i] = rng.IntN(n)
+			}
+			reps[b] = eval(idx)
+			if tStats == nil {
+				continue
+			}
+			for r := range innerB {
+				for i := range inner {
+					inner[i] = idx[rng.IntN(n)]
+				}
+				innerReps[r] = eval(inner)
+			}
+			tStats[b] = (reps[b] - estimate) / sampleStdDev(innerReps)
+		}
+	})
+	for _, v := range reps {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errors.New("statistic is not finite on a bootstrap resample")
+		}
+	}
+
+	mean := sampleMean(reps)
+	se := sampleStdDev(reps)
+	sorted := append([]float64(nil), reps...)
+	sort.Float64s(sorted)
+	alpha := (1 - cl) / 2
+
+	var ci [2]float64
+	switch method {
+	case BootstrapPercentile:
+		ci = [2]float64{sortedQuantile(sorted, alpha), sortedQuantile(sorted, 1-alpha)}
+	case BootstrapBCa:
+		below := 0
+		for _, v := range reps {
+			if v < estimate {
+				below++
+			}
+		}
+		z0 := zQuantile(float64(below) / float64(B))
+		if math.IsInf(z0, 0) {
+			return nil, errors.New("BCa interval undefined: estimate lies outside the bootstrap distribution")
+		}
+		accel := jackknifeAcceleration(n, eval)
+		adjust := func(p float64) float64 {
+			zp := z0 + zQuantile(p)
+			return zCDF(z0 + zp/(1-accel*zp))
+		}
+		ci = [2]float64{sortedQuantile(sorted, adjust(alpha)), sortedQuantile(sorted, adjust(1-alpha))}
+	case BootstrapStudentized:
+		ts := make([]float64, 0, B)
+		for _, v := range tStats {
+			if !math.IsNaN(v) && !math.IsInf(v, 0) {
+				ts = append(ts, v)
+			}
+		}
+		if len(ts) < 2 {
+			return nil, errors.New("studentized interval undefined: replicate standard errors are zero")
+		}
+		sort.Float64s(ts)
+		ci = [2]float64{estimate - sortedQuantile(ts, 1-alpha)*se, estimate - sortedQuantile(ts, alpha)*se}
+	}
+
+	return &BootstrapResult{
+		Estimate:        estimate,
+		Bias:            mean - estimate,
+		StdError:        se,
+		CI:              ci,
+		Method:          method,
+		ConfidenceLevel: cl,
+		B:               B,
+		Replicates:      reps,
+	}, nil
+}
+
+// jackknifeAcceleration estimates the BCa acceleration constant from the
+// leave-one-out values of the statistic.
+func jackknifeAcceleration(n int, eval func(idx []int) float64) float64 {
+	jack := make([]float64, n)
+	runParallelChunks(n, func(start, end int) {
+		idx := make([]int, n-1)
+		for i := start; i < end; i++ {
+			k := 0
+			for j := range n {
+				if j != i {
+					idx[k] = j
+					k++
+				}
+			}
+			jack[i] = eval(idx)
+		}
+	})
+	mean := sampleMean(jack)
+	num, den := 0.0, 0.0
+	for _, v := range jack {
+		d := mean - v
+		num += d * d * d
+		den += d * d
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / (6 * math.Pow(den, 1.5))
+}
+
+// runParallelChunks splits [0, n) into one contiguous chunk per worker and
+// runs them through a parallel group. Resampling statistics are
+// user-supplied and usually far heavier than the goroutine launch, so no
+// size threshold is applied beyond needing more than one worker.
+func runParallelChunks(n int, chunk func(start, end int)) {
+	workers := parutil.MaxWorkers(n)
+	if workers <= 1 {
+		chunk(0, n)
+		return
+	}
+	fns := make([]any, 0, workers)
+	for w := range workers {
+		start, end := parutil.ChunkBounds(n, workers, w)
+		if start >= end {
+			continue
+		}
+		fns = append(fns, func() { chunk(start, end) })
+	}
+	parallel.GroupUp(fns...).Run().AwaitNoResult()
+}
+
+// resolveSeed returns seed when useSeed is set and a fresh random seed
+// otherwise; every Seed/UseSeed option pair goes through it.
+func resolveSeed(seed uint64, useSeed bool) uint64 {
+	if !useSeed {
+		return rand.Uint64()
+	}
+	return seed
+}
+
+// replicateRNG gives every replicate its own stream so results do not
+// depend on how replicates are divided between workers.
+func replicateRNG(seed, replicate uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, replicate^0x9E3779B97F4A7C15))
+}
+
+// sortedQuantile is the type-7 quantile of sorted data.
+func sortedQuantile(sorted []float64, p float64) float64 {
+	n := len(sorted)
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 1 {
+		return sorted[n-1]
+	}
+	h := p * float64(n-1)
+	lo := int(math.Floor(h))
+	if lo+1 >= n {
+		return sorted[n-1]
+	}
+	return sorted[lo] + (h-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+func sampleMean(x []float64) float64 {
+	s := 0.0
+	for _, v := range x {
+		s += v
+	}
+	return s / float64(len(x))
+}
+
+func sampleStdDev(x []float64) float64 {
+	mean := sampleMean(x)
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(ss / float64(len(x)-1))
+}
@@ -0,0 +1,130 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+func sampleMeanOf(x []float64) float64 {
+	s := 0.0
+	for _, v := range x {
+		s += v
+	}
+	return s / float64(len(x))
+}
+
+func TestBootstrapMean(t *testing.T) {
+	x := seededNormals(21, 60)
+	for i := range x {
+		x[i] = 5 + 2*x[i]
+	}
+	dl := insyra.NewDataList(x)
+	opts := stats.BootstrapOptions{Seed: 7, UseSeed: true}
+	res, err := stats.Bootstrap(dl, sampleMeanOf, 4000, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := stats.Bootstrap(dl, sampleMeanOf, 4000, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range res.Replicates {
+		if res.Replicates[i] != again.Replicates[i] {
+			t.Fatalf("seeded replicates differ at %d", i)
+		}
+	}
+
+	// The bootstrap SE of the mean approaches the plug-in s*sqrt((n-1)/n)/sqrt(n).
+	n := float64(len(x))
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - sampleMeanOf(x)) * (v - sampleMeanOf(x))
+	}
+	sd := math.Sqrt(ss / (n - 1))
+	want := sd * math.Sqrt((n-1)/n) / math.Sqrt(n)
+	if math.Abs(res.StdError-want)/want > 0.05 {
+		t.Errorf("StdError = %v, want about %v", res.StdError, want)
+	}
+	if res.Estimate != sampleMeanOf(x) || res.CI[0] >= res.Estimate || res.CI[1] <= res.Estimate {
+		t.Errorf("estimate %v, CI %v", res.Estimate, res.CI)
+	}
+	tq := 2.000995 // qt(0.975, 59)
+	for _, method := range []stats.BootstrapCIMethod{stats.BootstrapBCa, stats.BootstrapStudentized} {
+		r, err := stats.Bootstrap(dl, sampleMeanOf, 2000, stats.BootstrapOptions{Method: method, Seed: 3, UseSeed: true})
+		if err != nil {
+			t.Fatal(method, err)
+		}
+		// For a mean of near-normal data all intervals approximate the t interval.
+		lo, hi := res.Estimate-tq*sd/math.Sqrt(n), res.Estimate+tq*sd/math.Sqrt(n)
+		if math.Abs(r.CI[0]-lo) > 0.15*(hi-lo) || math.Abs(r.CI[1]-hi) > 0.15*(hi-lo) {
+			t.Errorf("%s CI = %v, t interval = [%v, %v]", method, r.CI, lo, hi)
+		}
+	}
+}
+
+func TestBootstrapTablePairsRows(t *testing.T) {
+	x := seededNormals(31, 80)
+	noise := seededNormals(32, 80)
+	y := make([]float64, len(x))
+	for i := range x {
+		y[i] = x[i] + 0.5*noise[i]
+	}
+	dt := insyra.NewDataTable(insyra.NewDataList(x).SetName("x"), insyra.NewDataList(y).SetName("y"))
+	slope := func(cols [][]float64) float64 {
+		mx, my := sampleMeanOf(cols[0]), sampleMeanOf(cols[1])
+		sxy, sxx := 0.0, 0.0
+		for i := range cols[0] {
+			sxy += (cols[0][i] - mx) * (cols[1][i] - my)
+			sxx += (cols[0][i] - mx) * (cols[0][i] - mx)
+		}
+		return sxy / sxx
+	}
+	res, err := stats.BootstrapTable(dt, slope, 1000, stats.BootstrapOptions{Seed: 1, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CI[0] > 1 || res.CI[1] < 1 || res.CI[1]-res.CI[0] > 0.5 {
+		t.Errorf("slope CI = %v", res.CI)
+	}
+	if _, err := stats.Bootstrap(insyra.NewDataList(x), sampleMeanOf, 100, stats.BootstrapOptions{}, stats.BootstrapOptions{}); err == nil {
+		t.Error("expected error for multiple opts")
+	}
+}
+
+func TestPermutationTest(t *testing.T) {
+	// Of the 20 splits of {1..6} into two triples only the observed one and
+	// its mirror reach |mean difference| = 3, so the exact p-value is 0.1.
+	x := insyra.NewDataList(1, 2, 3)
+	y := insyra.NewDataList(4, 5, 6)
+	res, err := stats.PermutationTest(x, y, nil, stats.PermutationTestOptions{Permutations: 20000, Seed: 5, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Statistic != -3 || math.Abs(res.PValue-0.1) > 0.01 {
+		t.Errorf("statistic = %v, p = %v", res.Statistic, res.PValue)
+	}
+	less, err := stats.PermutationTest(x, y, nil, stats.PermutationTestOptions{Alternative: stats.Less, Permutations: 20000, Seed: 5, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(less.PValue-0.05) > 0.01 {
+		t.Errorf("one-sided p = %v", less.PValue)
+	}
+
+	// Paired sign flips: all five differences positive gives 2/32 two-sided.
+	before := insyra.NewDataList(10, 12, 9, 11, 14)
+	after := insyra.NewDataList(11, 14, 12, 15, 19)
+	paired, err := stats.PermutationTest(after, before, nil, stats.PermutationTestOptions{Design: stats.PermutationPaired, Permutations: 20000, Seed: 9, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(paired.PValue-1.0/16) > 0.01 {
+		t.Errorf("paired p = %v", paired.PValue)
+	}
+	if _, err := stats.PermutationTest(before, insyra.NewDataList(1, 2), nil, stats.PermutationTestOptions{Design: stats.PermutationPaired}); err == nil {
+		t.Error("expected error for unequal paired lengths")
+	}
+}
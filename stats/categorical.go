@@ -0,0 +1,1046 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// contingencyCounts cross-tabulates two paired DataLists into a count table
+// whose rows and columns follow the sorted category labels, as in
+// ChiSquareIndependenceTest.
+func contingencyCounts(rowData, colData insyra.IDataList) ([][]float64, []string, []string, error) {
+	if rowData == nil || colData == nil {
+		return nil, nil, nil, errors.New("input DataLists cannot be nil")
+	}
+	rowVals := rowData.Data()
+	colVals := colData.Data()
+	if len(rowVals) == 0 || len(colVals) == 0 {
+		return nil, nil, nil, errors.New("input DataLists cannot be empty")
+	}
+	if len(rowVals) != len(colVals) {
+		return nil, nil, nil, errors.New("both DataLists must have the same length")
+	}
+	rowKeys, colKeys, rowIdx, colIdx := crossTabulate(rowVals, colVals)
+	table := make([][]float64, len(rowKeys))
+	for i := range table {
+		table[i] = make([]float64, len(colKeys))
+	}
+	for i := range rowIdx {
+		table[rowIdx[i]][colIdx[i]]++
+	}
+	return table, rowKeys, colKeys, nil
+}
+
+func tableMargins(table [][]float64) (rowSums, colSums []float64, total float64) {
+	rowSums = make([]float64, len(table))
+	colSums = make([]float64, len(table[0]))
+	for i, row := range table {
+		for j, v := range row {
+			rowSums[i] += v
+			colSums[j] += v
+			total += v
+		}
+	}
+	return rowSums, colSums, total
+}
+
+func lchoose(n, k float64) float64 {
+	a, _ := math.Lgamma(n + 1)
+	b, _ := math.Lgamma(k + 1)
+	c, _ := math.Lgamma(n - k + 1)
+	return a - b - c
+}
+
+func lfactorial(n float64) float64 {
+	v, _ := math.Lgamma(n + 1)
+	return v
+}
+
+// FisherExactOptions configures FisherExactTest. Alternative and
+// ConfidenceLevel only apply to 2×2 tables.
+type FisherExactOptions struct {
+	Alternative     AlternativeHypothesis // default TwoSided
+	ConfidenceLevel float64               // default 0.95
+}
+
+// FisherExactResult holds the result of Fisher's exact test.
+//
+// Statistic is the probability of the observed table under independence
+// with the margins fixed; DF is nil. For 2×2 tables OddsRatio is the
+// conditional maximum likelihood estimate and CI its exact interval, as in
+// R's fisher.test; for larger tables OddsRatio is NaN and CI is nil.
+type FisherExactResult struct {
+	testResultBase
+	OddsRatio float64
+	RowLevels []string
+	ColLevels []string
+}
+
+// FisherExactTest performs Fisher's exact test of independence on two paired
+// categorical DataLists. 2×2 tables use the hypergeometric distribution
+// directly; larger tables are enumerated with a network algorithm (Mehta &
+// Patel, 1983) that prunes sub-tables whose probability bounds decide them
+// wholesale. The two-sided p-value sums the probabilities of tables no more
+// likely than the observed one. Very large tables with many observations can
+// still be expensive.
+func FisherExactTest(rowData, colData insyra.IDataList, opts ...FisherExactOptions) (*FisherExactResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt FisherExactOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.Alternative == "" {
+		opt.Alternative = TwoSided
+	}
+	if !isValidAlt(opt.Alternative) {
+		return nil, errors.New("invalid alternative hypothesis")
+	}
+	if opt.ConfidenceLevel != 0 && (opt.ConfidenceLevel <= 0 || opt.ConfidenceLevel >= 1) {
+		return nil, errors.New("confidenceLevel must be between 0 and 1")
+	}
+	cl := resolveConfidenceLevel(opt.ConfidenceLevel)
+
+	table, rowKeys, colKeys, err := contingencyCounts(rowData, colData)
+	if err != nil {
+		return nil, err
+	}
+	if len(rowKeys) < 2 || len(colKeys) < 2 {
+		return nil, errors.New("Fisher's exact test requires at least two row and column categories")
+	}
+	res := &FisherExactResult{RowLevels: rowKeys, ColLevels: colKeys, OddsRatio: math.NaN()}
+	if len(rowKeys) == 2 && len(colKeys) == 2 {
+		f := newFisher2x2(table)
+		res.Statistic = f.density(1)[int(f.x-f.lo)]
+		res.PValue = f.pValue(opt.Alternative)
+		res.OddsRatio = f.mle()
+		ci := f.confInt(opt.Alternative, cl)
+		res.CI = &ci
+		res.EffectSizes = []EffectSizeEntry{{Type: "odds_ratio", Value: res.OddsRatio}}
+		return res, nil
+	}
+	if opt.Alternative != TwoSided {
+		return nil, errors.New("one-sided alternatives are only defined for 2×2 tables")
+	}
+	res.Statistic, res.PValue = fisherNetworkPValue(table)
+	return res, nil
+}
+
+// fisher2x2 follows the conditional (noncentral hypergeometric) model of
+// R's fisher.test for a 2×2 table with x = table[0][0].
+type fisher2x2 struct {
+	x, lo, hi float64
+	logdc     []float64
+}
+
+func newFisher2x2(t [][]float64) *fisher2x2 {
+	m := t[0][0] + t[1][0]
+	n := t[0][1] + t[1][1]
+	k := t[0][0] + t[0][1]
+	f := &fisher2x2{x: t[0][0], lo: math.Max(0, k-n), hi: math.Min(k, m)}
+	for s := f.lo; s <= f.hi; s++ {
+		f.logdc = append(f.logdc, lchoose(m, s)+lchoose(n, k-s)-lchoose(m+n, k))
+	}
+	return f
+}
+
+// density returns the noncentral hypergeometric probabilities over the
+// support for odds ratio ncp.
+func (f *fisher2x2) density(ncp float64) []float64 {
+	d := make([]float64, len(f.logdc))
+	maxD := math.Inf(-1)
+	for i, l := range f.logdc {
+		d[i] = l + math.Log(ncp)*(f.lo+float64(i))
+		maxD = math.Max(maxD, d[i])
+	}
+	sum := 0.0
+	for i := range d {
+		d[i] = math.Exp(d[i] - maxD)
+		sum += d[i]
+	}
+	for i := range d {
+		d[i] /= sum
+	}
+	return d
+}
+
+func (f *fisher2x2) mean(ncp float64) float64 {
+	switch {
+	case ncp == 0:
+		return f.lo
+	case math.IsInf(ncp, 1):
+		return f.hi
+	}
+	m := 0.0
+	for i, p := range f.density(ncp) {
+		m += (f.lo + float64(i)) * p
+	}
+	return m
+}
+
+// cdf returns P(X <= q), or P(X >= q) when upper is set.
+func (f *fisher2x2) cdf(q, ncp float64, upper bool) float64 {
+	switch {
+	case ncp == 0:
+		if upper {
+			return boolFloat(q <= f.lo)
+		}
+		return boolFloat(q >= f.lo)
+	case math.IsInf(ncp, 1):
+		if upper {
+			return boolFloat(q <= f.hi)
+		}
+		return boolFloat(q >= f.hi)
+	}
+	p := 0.0
+	for i, d := range f.density(ncp) {
+		s := f.lo + float64(i)
+		if (upper && s >= q) || (!upper && s <= q) {
+			p += d
+		}
+	}
+	return p
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (f *fisher2x2) pValue(alt AlternativeHypothesis) float64 {
+	switch alt {
+	case Less:
+		return f.cdf(f.x, 1, false)
+	case Greater:
+		return f.cdf(f.x, 1, true)
+	}
+	const relErr = 1 + 1e-7
+	d := f.density(1)
+	obs := d[int(f.x-f.lo)]
+	p := 0.0
+	for _, v := range d {
+		if v <= obs*relErr {
+			p += v
+		}
+	}
+	return math.Min(1, p)
+}
+
+const fisherRootTol = 1e-12
+
+// mle is the conditional maximum likelihood estimate of the odds ratio.
+func (f *fisher2x2) mle() float64 {
+	switch {
+	case f.x == f.lo:
+		return 0
+	case f.x == f.hi:
+		return math.Inf(1)
+	}
+	mu := f.mean(1)
+	switch {
+	case mu > f.x:
+		return uniroot(func(t float64) float64 { return f.mean(t) - f.x }, 0, 1, fisherRootTol)
+	case mu < f.x:
+		return 1 / uniroot(func(t float64) float64 { return f.mean(1/t) - f.x }, math.SmallestNonzeroFloat64, 1, fisherRootTol)
+	}
+	return 1
+}
+
+func (f *fisher2x2) upperLimit(alpha float64) float64 {
+	if f.x == f.hi {
+		return math.Inf(1)
+	}
+	p := f.cdf(f.x, 1, false)
+	switch {
+	case p < alpha:
+		return uniroot(func(t float64) float64 { return f.cdf(f.x, t, false) - alpha }, 0, 1, fisherRootTol)
+	case p > alpha:
+		return 1 / uniroot(func(t float64) float64 { return f.cdf(f.x, 1/t, false) - alpha }, math.SmallestNonzeroFloat64, 1, fisherRootTol)
+	}
+	return 1
+}
+
+func (f *fisher2x2) lowerLimit(alpha float64) float64 {
+	if f.x == f.lo {
+		return 0
+	}
+	p := f.cdf(f.x, 1, true)
+	switch {
+	case p > alpha:
+		return uniroot(func(t float64) float64 { return f.cdf(f.x, t, true) - alpha }, 0, 1, fisherRootTol)
+	case p < alpha:
+		return 1 / uniroot(func(t float64) float64 { return f.cdf(f.x, 1/t, true) - alpha }, math.SmallestNonzeroFloat64, 1, fisherRootTol)
+	}
+	return 1
+}
+
+func (f *fisher2x2) confInt(alt AlternativeHypothesis, cl float64) [2]float64 {
+	switch alt {
+	case Less:
+		return [2]float64{0, f.upperLimit(1 - cl)}
+	case Greater:
+		return [2]float64{f.lowerLimit(1 - cl), math.Inf(1)}
+	}
+	alpha := (1 - cl) / 2
+	return [2]float64{f.lowerLimit(alpha), f.upperLimit(alpha)}
+}
+
+// fisherNetwork enumerates r×c tables column by column. A node is the
+// multiset of row totals still to be filled before column j; the log
+// probability contributed by the remaining columns of any completion lies
+// between the node's bounds, so whole sub-networks are either counted in
+// closed form or discarded without enumeration.
+type fisherNetwork struct {
+	colSums []float64
+	thr     float64
+	bounds  map[string][2]float64
+	partial map[string]float64
+}
+
+func fisherNetworkPValue(table [][]float64) (tableProb, pValue float64) {
+	if len(table) > len(table[0]) {
+		t := make([][]float64, len(table[0]))
+		for j := range t {
+			t[j] = make([]float64, len(table))
+			for i := range table {
+				t[j][i] = table[i][j]
+			}
+		}
+		table = t
+	}
+	rowSums, colSums, total := tableMargins(table)
+	logConst := -lfactorial(total)
+	for _, r := range rowSums {
+		logConst += lfactorial(r)
+	}
+	obs := logConst
+	for j, c := range colSums {
+		obs += lfactorial(c)
+		for i := range table {
+			obs -= lfactorial(table[i][j])
+		}
+	}
+	net := &fisherNetwork{
+		colSums: colSums,
+		thr:     obs + math.Log1p(1e-7),
+		bounds:  map[string][2]float64{},
+		partial: map[string]float64{},
+	}
+	return math.Exp(obs), math.Min(1, net.sum(0, rowSums, logConst))
+}
+
+func fisherNodeKey(j int, rows []float64) string {
+	sorted := slices.Clone(rows)
+	slices.Sort(sorted)
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(j))
+	for _, r := range sorted {
+		b.WriteByte(',')
+		b.WriteString(strconv.Itoa(int(r)))
+	}
+	return b.String()
+}
+
+// fillings calls fn with every way of splitting column j's total over rows
+// without exceeding the remaining row totals.
+func (f *fisherNetwork) fillings(j int, rows []float64, fn func(cell []float64)) {
+	cell := make([]float64, len(rows))
+	capacity := make([]float64, len(rows)+1)
+	for i := len(rows) - 1; i >= 0; i-- {
+		capacity[i] = capacity[i+1] + rows[i]
+	}
+	var rec func(i int, left float64)
+	rec = func(i int, left float64) {
+		if i == len(rows)-1 {
+			cell[i] = left
+			fn(cell)
+			return
+		}
+		for v := math.Max(0, left-capacity[i+1]); v <= math.Min(rows[i], left); v++ {
+			cell[i] = v
+			rec(i+1, left-v)
+		}
+	}
+	rec(0, f.colSums[j])
+}
+
+func (f *fisherNetwork) columnLog(j int, cell []float64) float64 {
+	v := lfactorial(f.colSums[j])
+	for _, c := range cell {
+		v -= lfactorial(c)
+	}
+	return v
+}
+
+func subtractCells(rows, cell []float64) []float64 {
+	out := make([]float64, len(rows))
+	for i := range rows {
+		out[i] = rows[i] - cell[i]
+	}
+	return out
+}
+
+// bound returns the largest and smallest log weight of a completion from
+// node (j, rows).
+func (f *fisherNetwork) bound(j int, rows []float64) (hi, lo float64) {
+	if j == len(f.colSums)-1 {
+		v := f.columnLog(j, rows)
+		return v, v
+	}
+	key := fisherNodeKey(j, rows)
+	if b, ok := f.bounds[key]; ok {
+		return b[0], b[1]
+	}
+	hi, lo = math.Inf(-1), math.Inf(1)
+	f.fillings(j, rows, func(cell []float64) {
+		h, l := f.bound(j+1, subtractCells(rows, cell))
+		c := f.columnLog(j, cell)
+		hi = math.Max(hi, c+h)
+		lo = math.Min(lo, c+l)
+	})
+	f.bounds[key] = [2]float64{hi, lo}
+	return hi, lo
+}
+
+// sum returns the total probability of completions from node (j, rows)
+// whose table probability does not exceed the threshold, given the log
+// probability past accumulated on the way to the node.
+func (f *fisherNetwork) sum(j int, rows []float64, past float64) float64 {
+	hi, lo := f.bound(j, rows)
+	if past+hi <= f.thr {
+		remaining := 0.0
+		for _, r := range rows {
+			remaining += r
+			past -= lfactorial(r)
+		}
+		return math.Exp(past + lfactorial(remaining))
+	}
+	if past+lo > f.thr {
+		return 0
+	}
+	key := fisherNodeKey(j, rows) + "|" + strconv.FormatFloat(math.Round(past*1e9), 'f', 0, 64)
+	if v, ok := f.partial[key]; ok {
+		return v
+	}
+	total := 0.0
+	f.fillings(j, rows, func(cell []float64) {
+		total += f.sum(j+1, subtractCells(rows, cell), past+f.columnLog(j, cell))
+	})
+	f.partial[key] = total
+	return total
+}
+
+// McNemarOptions configures McNemarTest. By default a 2×2 table uses the
+// continuity-corrected chi-square statistic, as R's mcnemar.test does.
+type McNemarOptions struct {
+	NoCorrection bool
+	// Exact uses the binomial distribution of the discordant pairs (2×2
+	// tables only).
+	Exact bool
+}
+
+// McNemarResult holds the result of McNemar's test. For k > 2 categories
+// it is Bowker's test of symmetry, with DF the number of off-diagonal pairs
+// that have any observations. For 2×2 tables EffectSizes contains the
+// paired odds ratio b/c.
+type McNemarResult struct {
+	testResultBase
+	Levels []string
+	Method string // "asymptotic", "exact" or "bowker"
+}
+
+// McNemarTest tests marginal homogeneity of two paired categorical
+// responses, such as the same subjects classified before and after a
+// treatment. Both lists are classified on the union of their categories.
+func McNemarTest(data1, data2 insyra.IDataList, opts ...McNemarOptions) (*McNemarResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt McNemarOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if data1 == nil || data2 == nil {
+		return nil, errors.New("input DataLists cannot be nil")
+	}
+	v1, v2 := data1.Data(), data2.Data()
+	if len(v1) == 0 || len(v1) != len(v2) {
+		return nil, errors.New("both DataLists must be non-empty and have the same length")
+	}
+	// Tabulate both lists on one set of levels by stacking them.
+	keys, _, idx, _ := crossTabulate(append(slices.Clone(v1), v2...), append(slices.Clone(v2), v1...))
+	k := len(keys)
+	if k < 2 {
+		return nil, errors.New("McNemar's test requires at least two categories")
+	}
+	n := len(v1)
+	table := make([][]float64, k)
+	for i := range table {
+		table[i] = make([]float64, k)
+	}
+	for i := range n {
+		table[idx[i]][idx[n+i]]++
+	}
+
+	res := &McNemarResult{Levels: keys}
+	if k == 2 {
+		b, c := table[0][1], table[1][0]
+		if b+c == 0 {
+			return nil, errors.New("there are no discordant pairs")
+		}
+		res.EffectSizes = []EffectSizeEntry{{Type: "odds_ratio", Value: b / c}}
+		if opt.Exact {
+			binom := distuv.Binomial{N: b + c, P: 0.5}
+			res.Method = "exact"
+			res.Statistic = b
+			res.PValue = math.Min(1, 2*binom.CDF(math.Min(b, c)))
+			return res, nil
+		}
+		yates := 1.0
+		if opt.NoCorrection {
+			yates = 0
+		}
+		d := math.Max(math.Abs(b-c)-yates, 0)
+		df := 1.0
+		res.Method = "asymptotic"
+		res.Statistic = d * d / (b + c)
+		res.PValue = chiSquaredPValue(res.Statistic, df)
+		res.DF = &df
+		return res, nil
+	}
+	if opt.Exact {
+		return nil, errors.New("the exact test is only available for 2×2 tables")
+	}
+	stat, df := 0.0, 0.0
+	for i := range k {
+		for j := i + 1; j < k; j++ {
+			s := table[i][j] + table[j][i]
+			if s == 0 {
+				continue
+			}
+			d := table[i][j] - table[j][i]
+			stat += d * d / s
+			df++
+		}
+	}
+	if df == 0 {
+		return nil, errors.New("there are no discordant pairs")
+	}
+	res.Method = "bowker"
+	res.Statistic = stat
+	res.PValue = chiSquaredPValue(stat, df)
+	res.DF = &df
+	return res, nil
+}
+
+// CochranQResult holds the result of Cochran's Q test. DF = k-1 and
+// EffectSizes contains Serlin's η²_Q = Q / (n(k-1)).
+type CochranQResult struct {
+	testResultBase
+	NSubjects   int
+	KConditions int
+}
+
+// CochranQTest tests whether k related binary outcomes share one success
+// probability. Each IDataList is one subject's outcomes across the k
+// conditions, coded as booleans or 0/1, as FriedmanTest takes its rows.
+func CochranQTest(subjects ...insyra.IDataList) (*CochranQResult, error) {
+	n := len(subjects)
+	if n < 2 {
+		return nil, errors.New("at least two subjects are required")
+	}
+	k := -1
+	colSums := []float64{}
+	var rowSq, total float64
+	for s, subj := range subjects {
+		if subj == nil {
+			return nil, fmt.Errorf("subject %d is nil", s)
+		}
+		raw := subj.Data()
+		if k < 0 {
+			k = len(raw)
+			if k < 2 {
+				return nil, errors.New("each subject must have at least two conditions")
+			}
+			colSums = make([]float64, k)
+		} else if len(raw) != k {
+			return nil, fmt.Errorf("subject %d has %d conditions, want %d", s, len(raw), k)
+		}
+		row := 0.0
+		for j, v := range raw {
+			x, ok := binaryValue(v)
+			if !ok {
+				return nil, fmt.Errorf("subject %d condition %d is not binary", s, j)
+			}
+			colSums[j] += x
+			row += x
+		}
+		rowSq += row * row
+		total += row
+	}
+	colSq := 0.0
+	for _, c := range colSums {
+		colSq += c * c
+	}
+	kf := float64(k)
+	den := kf*total - rowSq
+	if den == 0 {
+		return nil, errors.New("every subject has the same outcome in all conditions")
+	}
+	q := (kf - 1) * (kf*colSq - total*total) / den
+	df := kf - 1
+	return &CochranQResult{
+		testResultBase: testResultBase{
+			Statistic:   q,
+			PValue:      chiSquaredPValue(q, df),
+			DF:          &df,
+			EffectSizes: []EffectSizeEntry{{Type: "eta_squared_q", Value: q / (float64(n) * (kf - 1))}},
+		},
+		NSubjects:   n,
+		KConditions: k,
+	}, nil
+}
+
+func binaryValue(v any) (float64, bool) {
+	if b, ok := v.(bool); ok {
+		return boolFloat(b), true
+	}
+	f, ok := insyra.ToFloat64Safe(v)
+	if !ok || (f != 0 && f != 1) {
+		return 0, false
+	}
+	return f, true
+}
+
+// CMHOptions configures CochranMantelHaenszelTest.
+type CMHOptions struct {
+	// NoCorrection drops the continuity correction of the 2×2×K statistic.
+	NoCorrection    bool
+	ConfidenceLevel float64 // for the common odds ratio (default 0.95)
+}
+
+// CMHResult holds the result of a Cochran-Mantel-Haenszel test. For 2×2×K
+// tables CommonOddsRatio is the Mantel-Haenszel estimate and CI its
+// Robins-Breslow-Greenland interval; otherwise CommonOddsRatio is NaN, CI
+// is nil and the generalized statistic has (I-1)(J-1) degrees of freedom.
+type CMHResult struct {
+	testResultBase
+	CommonOddsRatio float64
+	NStrata         int
+	RowLevels       []string
+	ColLevels       []string
+}
+
+// CochranMantelHaenszelTest tests conditional independence of two
+// categorical variables across the levels of a stratifying variable, as R's
+// mantelhaen.test does. Every stratum needs at least two observations.
+func CochranMantelHaenszelTest(rowData, colData, strata insyra.IDataList, opts ...CMHOptions) (*CMHResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt CMHOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.ConfidenceLevel != 0 && (opt.ConfidenceLevel <= 0 || opt.ConfidenceLevel >= 1) {
+		return nil, errors.New("confidenceLevel must be between 0 and 1")
+	}
+	cl := resolveConfidenceLevel(opt.ConfidenceLevel)
+	if rowData == nil || colData == nil || strata == nil {
+		return nil, errors.New("input DataLists cannot be nil")
+	}
+	rowVals, colVals, strataVals := rowData.Data(), colData.Data(), strata.Data()
+	if len(rowVals) == 0 || len(rowVals) != len(colVals) || len(rowVals) != len(strataVals) {
+		return nil, errors.New("all DataLists must be non-empty and have the same length")
+	}
+	rowKeys, colKeys, rowIdx, colIdx := crossTabulate(rowVals, colVals)
+	strataKeys, _, strataIdx, _ := crossTabulate(strataVals, strataVals)
+	I, J, K := len(rowKeys), len(colKeys), len(strataKeys)
+	if I < 2 || J < 2 {
+		return nil, errors.New("each variable must have at least two categories")
+	}
+	tables := make([][][]float64, K)
+	for s := range tables {
+		tables[s] = make([][]float64, I)
+		for i := range tables[s] {
+			tables[s][i] = make([]float64, J)
+		}
+	}
+	for i := range rowIdx {
+		tables[strataIdx[i]][rowIdx[i]][colIdx[i]]++
+	}
+	for s, t := range tables {
+		if _, _, total := tableMargins(t); total < 2 {
+			return nil, fmt.Errorf("stratum %q has fewer than two observations", strataKeys[s])
+		}
+	}
+
+	res := &CMHResult{NStrata: K, RowLevels: rowKeys, ColLevels: colKeys, CommonOddsRatio: math.NaN()}
+	if I == 2 && J == 2 {
+		var sx, sy, sv float64
+		var sDiag, sOffd, sP, sPQ, sQ float64
+		for _, t := range tables {
+			rows, cols, n := tableMargins(t)
+			sx += t[0][0]
+			sy += rows[0] * cols[0] / n
+			sv += rows[0] * rows[1] * cols[0] * cols[1] / (n * n * (n - 1))
+			ad, bc := t[0][0]*t[1][1]/n, t[0][1]*t[1][0]/n
+			p, q := (t[0][0]+t[1][1])/n, (t[0][1]+t[1][0])/n
+			sDiag += ad
+			sOffd += bc
+			sP += p * ad
+			sPQ += p*bc + q*ad
+			sQ += q * bc
+		}
+		diff := math.Abs(sx - sy)
+		if !opt.NoCorrection && diff >= 0.5 {
+			diff -= 0.5
+		}
+		df := 1.0
+		res.Statistic = diff * diff / sv
+		res.PValue = chiSquaredPValue(res.Statistic, df)
+		res.DF = &df
+		res.CommonOddsRatio = sDiag / sOffd
+		sd := math.Sqrt(sP/(2*sDiag*sDiag) + sPQ/(2*sDiag*sOffd) + sQ/(2*sOffd*sOffd))
+		z := zQuantile(1 - (1-cl)/2)
+		res.CI = &[2]float64{res.CommonOddsRatio * math.Exp(-z*sd), res.CommonOddsRatio * math.Exp(z*sd)}
+		res.EffectSizes = []EffectSizeEntry{{Type: "odds_ratio", Value: res.CommonOddsRatio}}
+		return res, nil
+	}
+
+	// Generalized CMH statistic on the first (I-1)×(J-1) cells, stacked
+	// column-major as in mantelhaen.test.
+	p := (I - 1) * (J - 1)
+	dev := make([]float64, p)
+	V := mat.NewSymDense(p, nil)
+	for _, t := range tables {
+		rows, cols, n := tableMargins(t)
+		for j := range J - 1 {
+			for i := range I - 1 {
+				dev[j*(I-1)+i] += t[i][j] - rows[i]*cols[j]/n
+			}
+		}
+		scale := n * n * (n - 1)
+		for j1 := range J - 1 {
+			for i1 := range I - 1 {
+				a := j1*(I-1) + i1
+				for j2 := range J - 1 {
+					for i2 := range I - 1 {
+						b := j2*(I-1) + i2
+						if b < a {
+							continue
+						}
+						cv := -cols[j1] * cols[j2]
+						if j1 == j2 {
+							cv += n * cols[j1]
+						}
+						rv := -rows[i1] * rows[i2]
+						if i1 == i2 {
+							rv += n * rows[i1]
+						}
+						V.SetSym(a, b, V.At(a, b)+cv*rv/scale)
+					}
+				}
+			}
+		}
+	}
+	var sol mat.VecDense
+	if err := sol.SolveVec(V, mat.NewVecDense(p, dev)); err != nil {
+		return nil, errors.New("the CMH covariance matrix is singular")
+	}
+	df := float64(p)
+	res.Statistic = mat.Dot(mat.NewVecDense(p, dev), &sol)
+	res.PValue = chiSquaredPValue(res.Statistic, df)
+	res.DF = &df
+	return res, nil
+}
+
+// ProportionCIMethod selects the confidence interval reported by the
+// proportion z-tests.
+type ProportionCIMethod string
+
+const (
+	// ProportionCIWilson is the Wilson score interval; for a difference
+	// of proportions it is Newcombe's hybrid score interval.
+	ProportionCIWilson ProportionCIMethod = "wilson"
+	// ProportionCIAgrestiCoull adds z²/2 successes and failures (one
+	// sample) or, for a difference, one of each per group (Agresti-Caffo).
+	ProportionCIAgrestiCoull ProportionCIMethod = "agresti-coull"
+	// ProportionCIWald is the normal approximation around the estimate.
+	ProportionCIWald ProportionCIMethod = "wald"
+)
+
+type ProportionTestOptions struct {
+	CIMethod        ProportionCIMethod // default ProportionCIWilson
+	ConfidenceLevel float64            // default 0.95
+}
+
+// ProportionTestResult holds the result of a proportion z-test. Statistic
+// is z and EffectSizes contains Cohen's h.
+type ProportionTestResult struct {
+	testResultBase
+	Proportion  float64  // proportion of the first (or only) sample
+	Proportion2 *float64 // proportion of the second sample (nil if not applicable)
+	N           int
+	N2          *int
+	CIMethod    ProportionCIMethod
+}
+
+func resolveProportionOptions(alt AlternativeHypothesis, opts []ProportionTestOptions) (ProportionTestOptions, error) {
+	if len(opts) > 1 {
+		return ProportionTestOptions{}, errors.New("opts accepts at most one value")
+	}
+	var opt ProportionTestOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if !isValidAlt(alt) {
+		return opt, errors.New("invalid alternative hypothesis")
+	}
+	if opt.ConfidenceLevel != 0 && (opt.ConfidenceLevel <= 0 || opt.ConfidenceLevel >= 1) {
+		return opt, errors.New("confidenceLevel must be between 0 and 1")
+	}
+	opt.ConfidenceLevel = resolveConfidenceLevel(opt.ConfidenceLevel)
+	switch opt.CIMethod {
+	case "":
+		opt.CIMethod = ProportionCIWilson
+	case ProportionCIWilson, ProportionCIAgrestiCoull, ProportionCIWald:
+	default:
+		return opt, fmt.Errorf("unsupported CI method %q", opt.CIMethod)
+	}
+	return opt, nil
+}
+
+// ciQuantile is the normal quantile for a two-sided or one-sided interval.
+func ciQuantile(alt AlternativeHypothesis, cl float64) float64 {
+	if alt == TwoSided {
+		return zQuantile(1 - (1-cl)/2)
+	}
+	return zQuantile(cl)
+}
+
+// oneSidedCI keeps only the bound that the alternative tests, replacing
+// the other with the edge of the parameter space.
+func oneSidedCI(lo, hi, min, max float64, alt AlternativeHypothesis) *[2]float64 {
+	switch alt {
+	case Greater:
+		hi = max
+	case Less:
+		lo = min
+	}
+	return &[2]float64{lo, hi}
+}
+
+func wilsonInterval(x, n, z float64) (lo, hi float64) {
+	p := x / n
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	half := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / (1 + z*z/n)
+	return center - half, center + half
+}
+
+func proportionInterval(x, n, z float64, method ProportionCIMethod) (lo, hi float64) {
+	switch method {
+	case ProportionCIWilson:
+		return wilsonInterval(x, n, z)
+	case ProportionCIAgrestiCoull:
+		nt := n + z*z
+		pt := (x + z*z/2) / nt
+		half := z * math.Sqrt(pt*(1-pt)/nt)
+		return math.Max(0, pt-half), math.Min(1, pt+half)
+	}
+	p := x / n
+	half := z * math.Sqrt(p*(1-p)/n)
+	return math.Max(0, p-half), math.Min(1, p+half)
+}
+
+func checkProportionCounts(x, n int) error {
+	if n <= 0 {
+		return errors.New("sample size must be positive")
+	}
+	if x < 0 || x > n {
+		return errors.New("successes must be between 0 and the sample size")
+	}
+	return nil
+}
+
+// OneSampleProportionZTest tests a proportion against p0 with the score
+// statistic z = (p̂ - p0) / √(p0(1-p0)/n), without continuity correction.
+func OneSampleProportionZTest(successes, n int, p0 float64, alternative AlternativeHypothesis, opts ...ProportionTestOptions) (*ProportionTestResult, error) {
+	opt, err := resolveProportionOptions(alternative, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProportionCounts(successes, n); err != nil {
+		return nil, err
+	}
+	if p0 <= 0 || p0 >= 1 {
+		return nil, errors.New("p0 must be between 0 and 1")
+	}
+	x, nf := float64(successes), float64(n)
+	p := x / nf
+	z := (p - p0) / math.Sqrt(p0*(1-p0)/nf)
+	lo, hi := proportionInterval(x, nf, ciQuantile(alternative, opt.ConfidenceLevel), opt.CIMethod)
+	return &ProportionTestResult{
+		testResultBase: testResultBase{
+			Statistic:   z,
+			PValue:      zPValue(z, alternative),
+			CI:          oneSidedCI(lo, hi, 0, 1, alternative),
+			EffectSizes: []EffectSizeEntry{{Type: "cohen_h", Value: CohenH(p, p0)}},
+		},
+		Proportion: p,
+		N:          n,
+		CIMethod:   opt.CIMethod,
+	}, nil
+}
+
+// TwoSampleProportionZTest tests p1 = p2 with the pooled z statistic,
+// without continuity correction. CI is for p1 - p2.
+func TwoSampleProportionZTest(successes1, n1, successes2, n2 int, alternative AlternativeHypothesis, opts ...ProportionTestOptions) (*ProportionTestResult, error) {
+	opt, err := resolveProportionOptions(alternative, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProportionCounts(successes1, n1); err != nil {
+		return nil, err
+	}
+	if err := checkProportionCounts(successes2, n2); err != nil {
+		return nil, err
+	}
+	x1, x2, m1, m2 := float64(successes1), float64(successes2), float64(n1), float64(n2)
+	p1, p2 := x1/m1, x2/m2
+	pool := (x1 + x2) / (m1 + m2)
+	if pool == 0 || pool == 1 {
+		return nil, errors.New("the pooled proportion must be strictly between 0 and 1")
+	}
+	z := (p1 - p2) / math.Sqrt(pool*(1-pool)*(1/m1+1/m2))
+	q := ciQuantile(alternative, opt.ConfidenceLevel)
+	d := p1 - p2
+	var lo, hi float64
+	switch opt.CIMethod {
+	case ProportionCIWilson:
+		l1, u1 := wilsonInterval(x1, m1, q)
+		l2, u2 := wilsonInterval(x2, m2, q)
+		lo = d - math.Sqrt((p1-l1)*(p1-l1)+(u2-p2)*(u2-p2))
+		hi = d + math.Sqrt((u1-p1)*(u1-p1)+(p2-l2)*(p2-l2))
+	case ProportionCIAgrestiCoull:
+		a1, a2 := (x1+1)/(m1+2), (x2+1)/(m2+2)
+		half := q * math.Sqrt(a1*(1-a1)/(m1+2)+a2*(1-a2)/(m2+2))
+		lo, hi = a1-a2-half, a1-a2+half
+	default:
+		half := q * math.Sqrt(p1*(1-p1)/m1+p2*(1-p2)/m2)
+		lo, hi = d-half, d+half
+	}
+	lo, hi = math.Max(-1, lo), math.Min(1, hi)
+	return &ProportionTestResult{
+		testResultBase: testResultBase{
+			Statistic:   z,
+			PValue:      zPValue(z, alternative),
+			CI:          oneSidedCI(lo, hi, -1, 1, alternative),
+			EffectSizes: []EffectSizeEntry{{Type: "cohen_h", Value: CohenH(p1, p2)}},
+		},
+		Proportion:  p1,
+		Proportion2: &p2,
+		N:           n1,
+		N2:          &n2,
+		CIMethod:    opt.CIMethod,
+	}, nil
+}
+
+// CramersV returns Cramér's V, √(χ² / (n·(min(r, c) - 1))), for two paired
+// categorical DataLists.
+func CramersV(rowData, colData insyra.IDataList) (float64, error) {
+	table, rowKeys, colKeys, err := contingencyCounts(rowData, colData)
+	if err != nil {
+		return 0, err
+	}
+	if len(rowKeys) < 2 || len(colKeys) < 2 {
+		return 0, errors.New("Cramér's V requires at least two row and column categories")
+	}
+	rows, cols, n := tableMargins(table)
+	chi2 := 0.0
+	for i := range table {
+		for j := range table[i] {
+			e := rows[i] * cols[j] / n
+			chi2 += (table[i][j] - e) * (table[i][j] - e) / e
+		}
+	}
+	return math.Sqrt(chi2 / (n * float64(min(len(rowKeys), len(colKeys))-1))), nil
+}
+
+// RatioEstimate is an odds ratio or relative risk with a Wald interval on
+// the log scale.
+type RatioEstimate struct {
+	Estimate        float64
+	LogSE           float64
+	CI              [2]float64
+	ConfidenceLevel float64
+	RowLevels       []string
+	ColLevels       []string
+}
+
+func twoByTwo(rowData, colData insyra.IDataList, confidenceLevel []float64) ([][]float64, *RatioEstimate, error) {
+	cl, err := resolveOptionalConfidenceLevel(confidenceLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	table, rowKeys, colKeys, err := contingencyCounts(rowData, colData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rowKeys) != 2 || len(colKeys) != 2 {
+		return nil, nil, errors.New("a 2×2 table is required")
+	}
+	return table, &RatioEstimate{ConfidenceLevel: cl, RowLevels: rowKeys, ColLevels: colKeys}, nil
+}
+
+func (r *RatioEstimate) setLogInterval(estimate, logSE float64) {
+	z := zQuantile(1 - (1-r.ConfidenceLevel)/2)
+	r.Estimate = estimate
+	r.LogSE = logSE
+	r.CI = [2]float64{estimate * math.Exp(-z*logSE), estimate * math.Exp(z*logSE)}
+}
+
+// OddsRatio returns the sample odds ratio (a·d)/(b·c) of a 2×2 table with
+// Woolf's log-scale interval. Rows and columns follow the sorted
+// category labels, so a is the count of the first row and first column
+// levels. When a cell is zero, 0.5 is added to every cell (Haldane-Anscombe).
+func OddsRatio(rowData, colData insyra.IDataList, confidenceLevel ...float64) (*RatioEstimate, error) {
+	t, res, err := twoByTwo(rowData, colData, confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+	a, b, c, d := t[0][0], t[0][1], t[1][0], t[1][1]
+	if a == 0 || b == 0 || c == 0 || d == 0 {
+		a, b, c, d = a+0.5, b+0.5, c+0.5, d+0.5
+	}
+	res.setLogInterval(a*d/(b*c), math.Sqrt(1/a+1/b+1/c+1/d))
+	return res, nil
+}
+
+// RelativeRisk returns the risk of the first column level in the first row
+// relative to the second row, [a/(a+b)] / [c/(c+d)], with the Katz
+// log-scale interval. When a or c is zero, 0.5 is added to every cell.
+func RelativeRisk(rowData, colData insyra.IDataList, confidenceLevel ...float64) (*RatioEstimate, error) {
+	t, res, err := twoByTwo(rowData, colData, confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+	a, b, c, d := t[0][0], t[0][1], t[1][0], t[1][1]
+	if a == 0 || c == 0 {
+		a, b, c, d = a+0.5, b+0.5, c+0.5, d+0.5
+	}
+	n1, n2 := a+b, c+d
+	res.setLogInterval((a/n1)/(c/n2), math.Sqrt(1/a-1/n1+1/c-1/n2))
+	return res, nil
+}
@@ -0,0 +1,301 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// expandCounts turns a count table into paired observation lists.
+func expandCounts(counts [][]int, rowNames, colNames []string) (*insyra.DataList, *insyra.DataList) {
+	rows, cols := insyra.NewDataList(), insyra.NewDataList()
+	for i, row := range counts {
+		for j, c := range row {
+			for range c {
+				rows.Append(rowNames[i])
+				cols.Append(colNames[j])
+			}
+		}
+	}
+	return rows, cols
+}
+
+func relClose(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol*math.Abs(want)
+}
+
+// TestFisherExactTeaTasting uses the tea-tasting example from R's
+// ?fisher.test documentation.
+func TestFisherExactTeaTasting(t *testing.T) {
+	guess, truth := expandCounts([][]int{{3, 1}, {1, 3}}, []string{"Milk", "Tea"}, []string{"Milk", "Tea"})
+	greater, err := stats.FisherExactTest(guess, truth, stats.FisherExactOptions{Alternative: stats.Greater})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !relClose(greater.PValue, 0.2428571, 1e-6) || !relClose(greater.OddsRatio, 6.408309, 1e-5) {
+		t.Errorf("p = %v, OR = %v", greater.PValue, greater.OddsRatio)
+	}
+	// R prints 0.3135693 and 621.9337505 for the interval limits below;
+	// its uniroot stops at tolerance eps^0.25, and at R's upper limit the
+	// lower tail is 0.02517 rather than 0.025. These are the exact roots.
+	if !relClose(greater.CI[0], 0.3135738, 1e-6) || !relClose(greater.CI[0], 0.3135693, 1e-4) || !math.IsInf(greater.CI[1], 1) {
+		t.Errorf("CI = %v", *greater.CI)
+	}
+	two, err := stats.FisherExactTest(guess, truth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !relClose(two.PValue, 0.4857143, 1e-6) || !relClose(two.CI[0], 0.2117329, 1e-4) || !relClose(two.CI[1], 626.24353, 1e-6) {
+		t.Errorf("two-sided p = %v, CI = %v", two.PValue, *two.CI)
+	}
+	// P(observed table) = C(4,3)·C(4,1)/C(8,4).
+	if !floatAlmostEqual(two.Statistic, 16.0/70, 1e-12) {
+		t.Errorf("table probability %v", two.Statistic)
+	}
+}
+
+// TestFisherExactJobSatisfaction uses the 4×4 job satisfaction table from
+// R's ?fisher.test (Agresti, 2002, p. 57), p = 0.7827.
+func TestFisherExactJobSatisfaction(t *testing.T) {
+	job := [][]int{{1, 3, 10, 6}, {2, 3, 10, 7}, {1, 6, 14, 12}, {0, 1, 9, 11}}
+	income, satisfaction := expandCounts(job, []string{"i1", "i2", "i3", "i4"}, []string{"s1", "s2", "s3", "s4"})
+	res, err := stats.FisherExactTest(income, satisfaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(res.PValue, 0.7827, 5e-5) {
+		t.Errorf("p = %v, want 0.7827", res.PValue)
+	}
+	if res.CI != nil || !math.IsNaN(res.OddsRatio) {
+		t.Errorf("r×c tables have no odds ratio")
+	}
+
+	// The network algorithm agrees with the 2×2 hypergeometric path and
+	// does not depend on orientation.
+	rect := [][]int{{3, 0, 2}, {1, 4, 1}}
+	r1, c1 := expandCounts(rect, []string{"a", "b"}, []string{"x", "y", "z"})
+	wide, err := stats.FisherExactTest(r1, c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tall, err := stats.FisherExactTest(c1, r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(wide.PValue, tall.PValue, 1e-12) {
+		t.Errorf("p %v vs transposed %v", wide.PValue, tall.PValue)
+	}
+	// Brute force over the 2×3 tables with these margins.
+	lf := func(n int) float64 { v, _ := math.Lgamma(float64(n) + 1); return v }
+	prob := func(a, b int) float64 {
+		c := 5 - a - b
+		return math.Exp(lf(5) + lf(6) + lf(4) + lf(4) + lf(3) - lf(11) -
+			lf(a) - lf(b) - lf(c) - lf(4-a) - lf(4-b) - lf(3-c))
+	}
+	obs, want := prob(3, 0), 0.0
+	for a := 0; a <= 4; a++ {
+		for b := 0; b <= 4; b++ {
+			if c := 5 - a - b; c >= 0 && c <= 3 && 4-a >= 0 && 4-b >= 0 {
+				if p := prob(a, b); p <= obs*(1+1e-7) {
+					want += p
+				}
+			}
+		}
+	}
+	if !floatAlmostEqual(wide.PValue, want, 1e-12) || !floatAlmostEqual(wide.Statistic, obs, 1e-12) {
+		t.Errorf("p = %v, want %v", wide.PValue, want)
+	}
+}
+
+// TestMcNemarPerformance uses the presidential approval example from R's
+// ?mcnemar.test (Agresti, 1990, p. 350).
+func TestMcNemarPerformance(t *testing.T) {
+	first, second := expandCounts([][]int{{794, 150}, {86, 570}}, []string{"approve", "disapprove"}, []string{"approve", "disapprove"})
+	res, err := stats.McNemarTest(first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (|150 - 86| - 1)² / 236 = 16.818, p = 4.115e-05.
+	if !floatAlmostEqual(res.Statistic, 63.0*63/236, 1e-12) || !relClose(res.PValue, 4.115e-5, 1e-3) || *res.DF != 1 {
+		t.Errorf("statistic %v, p %v", res.Statistic, res.PValue)
+	}
+	raw, _ := stats.McNemarTest(first, second, stats.McNemarOptions{NoCorrection: true})
+	if want := 64.0 * 64 / 236; !floatAlmostEqual(raw.Statistic, want, 1e-12) {
+		t.Errorf("uncorrected statistic %v, want %v", raw.Statistic, want)
+	}
+
+	// Exact: 1 vs 6 discordant pairs, p = 2·P(Bin(7, 0.5) <= 1) = 16/128.
+	a, b := expandCounts([][]int{{5, 1}, {6, 4}}, []string{"no", "yes"}, []string{"no", "yes"})
+	exact, err := stats.McNemarTest(a, b, stats.McNemarOptions{Exact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(exact.PValue, 16.0/128, 1e-12) || exact.Method != "exact" {
+		t.Errorf("exact p %v", exact.PValue)
+	}
+
+	// Bowker: pairs (1,2): 4 vs 2, (1,3): 3 vs 1, (2,3): 0 vs 0 (skipped).
+	c, d := expandCounts([][]int{{5, 4, 3}, {2, 6, 0}, {1, 0, 7}}, []string{"a", "b", "c"}, []string{"a", "b", "c"})
+	bowker, err := stats.McNemarTest(c, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4.0/6 + 4.0/4; !floatAlmostEqual(bowker.Statistic, want, 1e-12) || *bowker.DF != 2 {
+		t.Errorf("Bowker statistic %v df %v, want %v df 2", bowker.Statistic, *bowker.DF, want)
+	}
+}
+
+func TestCochranQ(t *testing.T) {
+	res, err := stats.CochranQTest(
+		insyra.NewDataList(1, 1, 0),
+		insyra.NewDataList(1, 0, 0),
+		insyra.NewDataList(true, true, true),
+		insyra.NewDataList(0, 0, 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// C = (3, 2, 1), R = (2, 1, 3, 0): Q = 2·(3·14 - 36) / (18 - 14) = 3.
+	if !floatAlmostEqual(res.Statistic, 3, 1e-12) || !floatAlmostEqual(res.PValue, math.Exp(-1.5), 1e-12) {
+		t.Errorf("Q = %v, p = %v", res.Statistic, res.PValue)
+	}
+	if !floatAlmostEqual(res.EffectSizes[0].Value, 3.0/8, 1e-12) {
+		t.Errorf("η²_Q = %v", res.EffectSizes[0].Value)
+	}
+	if _, err := stats.CochranQTest(insyra.NewDataList(1, 2), insyra.NewDataList(0, 1)); err == nil {
+		t.Errorf("expected error for a non-binary outcome")
+	}
+}
+
+// TestCMHUCBAdmissions uses UCBAdmissions from R's ?mantelhaen.test.
+func TestCMHUCBAdmissions(t *testing.T) {
+	depts := map[string][][]int{
+		"A": {{89, 512}, {19, 313}},
+		"B": {{17, 353}, {8, 207}},
+		"C": {{202, 120}, {391, 205}},
+		"D": {{131, 138}, {244, 279}},
+		"E": {{94, 53}, {299, 138}},
+		"F": {{24, 22}, {317, 351}},
+	}
+	admit, gender, dept := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	for name, counts := range depts {
+		r, c := expandCounts(counts, []string{"Admitted", "Rejected"}, []string{"Female", "Male"})
+		for i := range r.Len() {
+			admit.Append(r.Get(i))
+			gender.Append(c.Get(i))
+			dept.Append(name)
+		}
+	}
+	res, err := stats.CochranMantelHaenszelTest(admit, gender, dept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// R reports the Male/Female odds ratio 0.9046968 (0.7719074, 1.0603298);
+	// with Female first here the ratio is inverted.
+	if !relClose(res.Statistic, 1.4269, 1e-4) || !relClose(res.PValue, 0.2323, 1e-3) {
+		t.Errorf("X² = %v, p = %v", res.Statistic, res.PValue)
+	}
+	if !relClose(res.CommonOddsRatio, 1/0.9046968, 1e-6) ||
+		!relClose(res.CI[0], 1/1.0603298, 1e-6) || !relClose(res.CI[1], 1/0.7719074, 1e-6) {
+		t.Errorf("OR = %v, CI = %v", res.CommonOddsRatio, *res.CI)
+	}
+
+	// With one stratum the generalized statistic is (n-1)/n times Pearson's.
+	rows, cols := expandCounts([][]int{{10, 4, 6}, {3, 9, 5}, {2, 5, 11}}, []string{"a", "b", "c"}, []string{"x", "y", "z"})
+	one := insyra.NewDataList()
+	for range rows.Len() {
+		one.Append("s")
+	}
+	gen, err := stats.CochranMantelHaenszelTest(rows, cols, one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chi, _ := stats.ChiSquareIndependenceTest(rows, cols)
+	n := float64(rows.Len())
+	if !floatAlmostEqual(gen.Statistic, chi.Statistic*(n-1)/n, 1e-9) || *gen.DF != 4 {
+		t.Errorf("generalized CMH %v, want %v", gen.Statistic, chi.Statistic*(n-1)/n)
+	}
+}
+
+// TestProportionIntervalsNewcombe checks the worked examples in Newcombe
+// (1998), Statistics in Medicine 17, 857-872 and 873-890.
+func TestProportionIntervalsNewcombe(t *testing.T) {
+	wilson, err := stats.OneSampleProportionZTest(81, 263, 0.5, stats.TwoSided)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(wilson.CI[0], 0.2553, 5e-5) || !floatAlmostEqual(wilson.CI[1], 0.3662, 5e-5) {
+		t.Errorf("Wilson CI %v", *wilson.CI)
+	}
+	wantZ := (81.0/263 - 0.5) / math.Sqrt(0.25/263)
+	if !floatAlmostEqual(wilson.Statistic, wantZ, 1e-12) {
+		t.Errorf("z = %v, want %v", wilson.Statistic, wantZ)
+	}
+	wald, _ := stats.OneSampleProportionZTest(81, 263, 0.5, stats.TwoSided, stats.ProportionTestOptions{CIMethod: stats.ProportionCIWald})
+	if !floatAlmostEqual(wald.CI[0], 0.2522, 5e-5) || !floatAlmostEqual(wald.CI[1], 0.3638, 5e-5) {
+		t.Errorf("Wald CI %v", *wald.CI)
+	}
+	ac, _ := stats.OneSampleProportionZTest(0, 20, 0.1, stats.TwoSided, stats.ProportionTestOptions{CIMethod: stats.ProportionCIAgrestiCoull})
+	if ac.CI[0] != 0 || ac.CI[1] < 0.1 {
+		t.Errorf("Agresti-Coull CI for 0/20 %v", *ac.CI)
+	}
+
+	diff, err := stats.TwoSampleProportionZTest(56, 70, 48, 80, stats.TwoSided)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(diff.CI[0], 0.0524, 5e-5) || !floatAlmostEqual(diff.CI[1], 0.3339, 5e-5) {
+		t.Errorf("Newcombe hybrid score CI %v", *diff.CI)
+	}
+	diffWald, _ := stats.TwoSampleProportionZTest(56, 70, 48, 80, stats.TwoSided, stats.ProportionTestOptions{CIMethod: stats.ProportionCIWald})
+	if !floatAlmostEqual(diffWald.CI[0], 0.0575, 5e-5) || !floatAlmostEqual(diffWald.CI[1], 0.3425, 5e-5) {
+		t.Errorf("Wald difference CI %v", *diffWald.CI)
+	}
+	// Pooled z² equals the uncorrected chi-square of the 2×2 table.
+	g, o := expandCounts([][]int{{56, 14}, {48, 32}}, []string{"g1", "g2"}, []string{"s", "f"})
+	chi, _ := stats.ChiSquareIndependenceTest(g, o)
+	if !floatAlmostEqual(diff.Statistic*diff.Statistic, chi.Statistic, 1e-9) {
+		t.Errorf("z² = %v, chi-square %v", diff.Statistic*diff.Statistic, chi.Statistic)
+	}
+	greater, _ := stats.TwoSampleProportionZTest(56, 70, 48, 80, stats.Greater)
+	if greater.CI[1] != 1 || !floatAlmostEqual(greater.PValue, diff.PValue/2, 1e-12) {
+		t.Errorf("one-sided CI %v, p %v", *greater.CI, greater.PValue)
+	}
+}
+
+func TestContingencyEffectSizes(t *testing.T) {
+	// Exposed: 10 cases, 20 controls; unexposed: 5 cases, 30 controls.
+	exposure, outcome := expandCounts([][]int{{10, 20}, {5, 30}}, []string{"exposed", "unexposed"}, []string{"case", "control"})
+	or, err := stats.OddsRatio(exposure, outcome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	se := math.Sqrt(1.0/10 + 1.0/20 + 1.0/5 + 1.0/30)
+	if !floatAlmostEqual(or.Estimate, 3, 1e-12) || !floatAlmostEqual(or.LogSE, se, 1e-12) ||
+		!floatAlmostEqual(or.CI[0], 3*math.Exp(-1.959963984540054*se), 1e-9) {
+		t.Errorf("OR %+v", or)
+	}
+	rr, err := stats.RelativeRisk(exposure, outcome, 0.9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrSE := math.Sqrt(1.0/10 - 1.0/30 + 1.0/5 - 1.0/35)
+	if !floatAlmostEqual(rr.Estimate, (10.0/30)/(5.0/35), 1e-12) || !floatAlmostEqual(rr.CI[1], rr.Estimate*math.Exp(1.6448536269514722*rrSE), 1e-9) {
+		t.Errorf("RR %+v", rr)
+	}
+
+	v, err := stats.CramersV(expandCounts([][]int{{10, 0, 0}, {0, 7, 0}, {0, 0, 4}}, []string{"a", "b", "c"}, []string{"x", "y", "z"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(v, 1, 1e-12) {
+		t.Errorf("V = %v for perfect association", v)
+	}
+	chi, _ := stats.ChiSquareIndependenceTest(exposure, outcome)
+	v2, _ := stats.CramersV(exposure, outcome)
+	if !floatAlmostEqual(v2, math.Sqrt(chi.Statistic/65), 1e-12) {
+		t.Errorf("V = %v, want φ = %v", v2, math.Sqrt(chi.Statistic/65))
+	}
+}
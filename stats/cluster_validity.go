@@ -0,0 +1,478 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	internalcluster "github.com/HazelnutParadise/insyra/stats/internal/clustering"
+)
+
+// GapStatisticOptions configures GapStatistic. B is the number of
+// reference data sets (default 100, as R's cluster::clusGap) and NStart and
+// IterMax are passed to KMeans. Seed and UseSeed make the reference draws
+// and k-means starts reproducible.
+type GapStatisticOptions struct {
+	B       int
+	NStart  int
+	IterMax int
+	Seed    uint64
+	UseSeed bool
+}
+
+// GapStatisticResult holds the gap curve for k = 1..KMax. OptimalK is the
+// smallest k with Gap[k] >= Gap[k+1] - SE[k+1] (Tibshirani, Walther and
+// Hastie, 2001).
+type GapStatisticResult struct {
+	K        []int
+	LogW     []float64
+	ExpLogW  []float64
+	Gap      []float64
+	SE       []float64
+	OptimalK int
+}
+
+// ElbowResult is the k-means within-cluster sum of squares for k = 1..KMax.
+// Elbow is the k farthest from the straight line joining the curve's end
+// points.
+type ElbowResult struct {
+	K           []int
+	TotWithinSS []float64
+	Elbow       int
+}
+
+// ClusterFunc clusters a table into k groups and returns 1-based labels,
+// e.g. a wrapper around KMeans or CutTreeByK.
+type ClusterFunc func(dataTable insyra.IDataTable, k int) ([]int, error)
+
+// CalinskiHarabasz returns the variance ratio criterion: between-cluster
+// over within-cluster dispersion, each divided by its degrees of freedom.
+// Higher is better. labels must be positive integers.
+func CalinskiHarabasz(dataTable insyra.IDataTable, labels insyra.IDataList) (float64, error) {
+	data, labs, err := validityInputs(dataTable, labels)
+	if err != nil {
+		return 0, err
+	}
+	return calinskiHarabasz(data, labs), nil
+}
+
+// DaviesBouldin returns the mean, over clusters, of the worst ratio of
+// summed within-cluster scatter to centroid separation. Lower is better.
+// labels must be positive integers.
+func DaviesBouldin(dataTable insyra.IDataTable, labels insyra.IDataList) (float64, error) {
+	data, labs, err := validityInputs(dataTable, labels)
+	if err != nil {
+		return 0, err
+	}
+	return daviesBouldin(data, labs), nil
+}
+
+// AdjustedRandIndex compares two labelings of the same rows, correcting the
+// Rand index for chance: 1 for identical partitions, about 0 for random
+// ones. Labels may be any comparable values.
+func AdjustedRandIndex(a, b insyra.IDataList) (float64, error) {
+	table, rowSums, colSums, n, err := contingency(a, b)
+	if err != nil {
+		return 0, err
+	}
+	comb2 := func(x float64) float64 { return x * (x - 1) / 2 }
+	index, sumA, sumB := 0.0, 0.0, 0.0
+	for _, row := range table {
+		for _, v := range row {
+			index += comb2(v)
+		}
+	}
+	for _, v := range rowSums {
+		sumA += comb2(v)
+	}
+	for _, v := range colSums {
+		sumB += comb2(v)
+	}
+	expected := sumA * sumB / comb2(n)
+	maxIndex := (sumA + sumB) / 2
+	if maxIndex == expected {
+		return 1, nil
+	}
+	return (index - expected) / (maxIndex - expected), nil
+}
+
+// NormalizedMutualInfo is the mutual information of two labelings divided
+// by the arithmetic mean of their entropies (scikit-learn's default).
+func NormalizedMutualInfo(a, b insyra.IDataList) (float64, error) {
+	table, rowSums, colSums, n, err := contingency(a, b)
+	if err != nil {
+		return 0, err
+	}
+	entropy := func(sums []float64) float64 {
+		h := 0.0
+		for _, v := range sums {
+			if v > 0 {
+				h -= v / n * math.Log(v/n)
+			}
+		}
+		return h
+	}
+	mi := 0.0
+	for i, row := range table {
+		for j, v := range row {
+			if v > 0 {
+				mi += v / n * math.Log(v*n/(rowSums[i]*colSums[j]))
+			}
+		}
+	}
+	ha, hb := entropy(rowSums), entropy(colSums)
+	if ha == 0 && hb == 0 {
+		return 1, nil
+	}
+	return math.Max(mi, 0) / ((ha + hb) / 2), nil
+}
+
+// ElbowCurve runs KMeans for k = 1..kMax and reports the total
+// within-cluster sum of squares.
+func ElbowCurve(dataTable insyra.IDataTable, kMax int, opts ...KMeansOptions) (*ElbowResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var options KMeansOptions
+	if len(opts) == 1 {
+		options = opts[0]
+	}
+	if kMax < 2 || kMax > len(data) {
+		return nil, errors.New("kMax must be between 2 and the row count")
+	}
+	res := &ElbowResult{}
+	for k := 1; k <= kMax; k++ {
+		got, err := internalcluster.KMeans(data, k, internalcluster.KMeansOptions(options))
+		if err != nil {
+			return nil, err
+		}
+		res.K = append(res.K, k)
+		res.TotWithinSS = append(res.TotWithinSS, got.TotWithinSS)
+	}
+	// Distance from each point to the chord between the first and last
+	// points, with both axes scaled to [0, 1].
+	first, last := res.TotWithinSS[0], res.TotWithinSS[kMax-1]
+	best := -1.0
+	for i, w := range res.TotWithinSS {
+		x := float64(i) / float64(kMax-1)
+		y := 1.0
+		if first != last {
+			y = (w - last) / (first - last)
+		}
+		if d := (1 - x) - y; d > best {
+			best, res.Elbow = d, res.K[i]
+		}
+	}
+	return res, nil
+}
+
+// GapStatistic compares the k-means log within-cluster dispersion with its
+// expectation under B uniform reference data sets drawn over the range of
+// each column, for k = 1..kMax. Reference sets are clustered in parallel.
+func GapStatistic(dataTable insyra.IDataTable, kMax int, opts ...GapStatisticOptions) (*GapStatisticResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var options GapStatisticOptions
+	if len(opts) == 1 {
+		options = opts[0]
+	}
+	if options.B < 0 {
+		return nil, errors.New("B must not be negative")
+	}
+	if options.B == 0 {
+		options.B = 100
+	}
+	n, p := len(data), len(data[0])
+	if kMax < 1 || kMax >= n {
+		return nil, errors.New("kMax must be between 1 and the row count minus one")
+	}
+	seed := resolveSeed(options.Seed, options.UseSeed)
+
+	logW := func(x [][]float64, k int, kmSeed int64) (float64, error) {
+		got, err := internalcluster.KMeans(x, k, internalcluster.KMeansOptions{NStart: options.NStart, IterMax: options.IterMax, Seed: &kmSeed})
+		if err != nil {
+			return 0, err
+		}
+		return math.Log(got.TotWithinSS), nil
+	}
+
+	res := &GapStatisticResult{}
+	for k := 1; k <= kMax; k++ {
+		w, err := logW(data, k, int64(seed>>1))
+		if err != nil {
+			return nil, err
+		}
+		res.K = append(res.K, k)
+		res.LogW = append(res.LogW, w)
+	}
+
+	lo, hi := make([]float64, p), make([]float64, p)
+	for j := range p {
+		lo[j], hi[j] = math.Inf(1), math.Inf(-1)
+		for _, row := range data {
+			lo[j] = math.Min(lo[j], row[j])
+			hi[j] = math.Max(hi[j], row[j])
+		}
+	}
+	ref := make([][]float64, options.B)
+	errs := make([]error, options.B)
+	runParallelChunks(options.B, func(start, end int) {
+		for b := start; b < end; b++ {
+			rng := replicateRNG(seed, uint64(b)+1)
+			x := make([][]float64, n)
+			for i := range x {
+				x[i] = make([]float64, p)
+				for j := range p {
+					x[i][j] = lo[j] + (hi[j]-lo[j])*rng.Float64()
+				}
+			}
+			ref[b] = make([]float64, kMax)
+			for k := 1; k <= kMax; k++ {
+				ref[b][k-1], errs[b] = logW(x, k, int64(rng.Uint64()>>1))
+				if errs[b] != nil {
+					return
+				}
+			}
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	for k := range kMax {
+		vals := make([]float64, options.B)
+		for b := range vals {
+			vals[b] = ref[b][k]
+		}
+		mean := sampleMean(vals)
+		sd := 0.0
+		for _, v := range vals {
+			sd += (v - mean) * (v - mean)
+		}
+		sd = math.Sqrt(sd / float64(options.B))
+		res.ExpLogW = append(res.ExpLogW, mean)
+		res.Gap = append(res.Gap, mean-res.LogW[k])
+		res.SE = append(res.SE, sd*math.Sqrt(1+1/float64(options.B)))
+	}
+	res.OptimalK = kMax
+	for k := 0; k < kMax-1; k++ {
+		if res.Gap[k] >= res.Gap[k+1]-res.SE[k+1] {
+			res.OptimalK = res.K[k]
+			break
+		}
+	}
+	return res, nil
+}
+
+// SelectK runs clusterFn for k = kMin..kMax and returns one row per k with
+// the columns K, WithinSS, Silhouette, CalinskiHarabasz and DaviesBouldin.
+// Indices that are undefined for a single cluster are NaN.
+func SelectK(dataTable insyra.IDataTable, kMin, kMax int, clusterFn ClusterFunc) (insyra.IDataTable, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if clusterFn == nil {
+		return nil, errors.New("clusterFn must not be nil")
+	}
+	if kMin < 1 || kMax < kMin || kMax > len(data) {
+		return nil, errors.New("k range must satisfy 1 <= kMin <= kMax <= row count")
+	}
+	kCol := insyra.NewDataList().SetName("K")
+	wssCol := insyra.NewDataList().SetName("WithinSS")
+	silCol := insyra.NewDataList().SetName("Silhouette")
+	chCol := insyra.NewDataList().SetName("CalinskiHarabasz")
+	dbCol := insyra.NewDataList().SetName("DaviesBouldin")
+	for k := kMin; k <= kMax; k++ {
+		labels, err := clusterFn(dataTable, k)
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) != len(data) {
+			return nil, errors.New("clusterFn must return one label per row")
+		}
+		for _, l := range labels {
+			if l <= 0 {
+				return nil, errors.New("cluster labels must be positive integers")
+			}
+		}
+		sil, ch, db := math.NaN(), math.NaN(), math.NaN()
+		if distinctInts(labels) > 1 {
+			s, err := internalcluster.Silhouette(data, labels)
+			if err != nil {
+				return nil, err
+			}
+			sil, ch, db = s.Average, calinskiHarabasz(data, labels), daviesBouldin(data, labels)
+		}
+		kCol.Append(k)
+		wssCol.Append(withinSS(data, labels))
+		silCol.Append(sil)
+		chCol.Append(ch)
+		dbCol.Append(db)
+	}
+	return insyra.NewDataTable(kCol, wssCol, silCol, chCol, dbCol), nil
+}
+
+func validityInputs(dataTable insyra.IDataTable, labels insyra.IDataList) ([][]float64, []int, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	labs, err := intLabelsFromDataList(labels, len(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, l := range labs {
+		if l <= 0 {
+			return nil, nil, errors.New("labels must be positive integers")
+		}
+	}
+	if k := distinctInts(labs); k < 2 || k >= len(data) {
+		return nil, nil, errors.New("labels must define between 2 and n-1 clusters")
+	}
+	return data, labs, nil
+}
+
+func distinctInts(xs []int) int {
+	seen := map[int]bool{}
+	for _, x := range xs {
+		seen[x] = true
+	}
+	return len(seen)
+}
+
+// clusterCentroids returns the sorted labels, their centroids and sizes.
+func clusterCentroids(data [][]float64, labels []int) ([]int, map[int][]float64, map[int]float64) {
+	p := len(data[0])
+	centroids := map[int][]float64{}
+	sizes := map[int]float64{}
+	for i, l := range labels {
+		if centroids[l] == nil {
+			centroids[l] = make([]float64, p)
+		}
+		for j, v := range data[i] {
+			centroids[l][j] += v
+		}
+		sizes[l]++
+	}
+	keys := make([]int, 0, len(centroids))
+	for l, c := range centroids {
+		for j := range c {
+			c[j] /= sizes[l]
+		}
+		keys = append(keys, l)
+	}
+	sort.Ints(keys)
+	return keys, centroids, sizes
+}
+
+func withinSS(data [][]float64, labels []int) float64 {
+	_, centroids, _ := clusterCentroids(data, labels)
+	w := 0.0
+	for i, l := range labels {
+		w += squaredDistance(data[i], centroids[l])
+	}
+	return w
+}
+
+func calinskiHarabasz(data [][]float64, labels []int) float64 {
+	keys, centroids, sizes := clusterCentroids(data, labels)
+	p := len(data[0])
+	mean := make([]float64, p)
+	for _, row := range data {
+		for j, v := range row {
+			mean[j] += v / float64(len(data))
+		}
+	}
+	between := 0.0
+	for _, l := range keys {
+		between += sizes[l] * squaredDistance(centroids[l], mean)
+	}
+	within := withinSS(data, labels)
+	n, k := float64(len(data)), float64(len(keys))
+	if within == 0 {
+		return math.Inf(1)
+	}
+	return (between / (k - 1)) / (within / (n - k))
+}
+
+func daviesBouldin(data [][]float64, labels []int) float64 {
+	keys, centroids, sizes := clusterCentroids(data, labels)
+	scatter := map[int]float64{}
+	for i, l := range labels {
+		scatter[l] += math.Sqrt(squaredDistance(data[i], centroids[l])) / sizes[l]
+	}
+	total := 0.0
+	for _, a := range keys {
+		worst := 0.0
+		for _, b := range keys {
+			if a == b {
+				continue
+			}
+			sep := math.Sqrt(squaredDistance(centroids[a], centroids[b]))
+			if sep == 0 {
+				worst = math.Inf(1)
+				continue
+			}
+			worst = math.Max(worst, (scatter[a]+scatter[b])/sep)
+		}
+		total += worst
+	}
+	return total / float64(len(keys))
+}
+
+func squaredDistance(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return s
+}
+
+// contingency cross-tabulates two labelings.
+func contingency(a, b insyra.IDataList) ([][]float64, []float64, []float64, float64, error) {
+	if a == nil || b == nil {
+		return nil, nil, nil, 0, errors.New("labelings must not be nil")
+	}
+	av, bv := a.Data(), b.Data()
+	if len(av) != len(bv) {
+		return nil, nil, nil, 0, errors.New("labelings must have the same length")
+	}
+	if len(av) < 2 {
+		return nil, nil, nil, 0, errors.New("labelings must have at least 2 elements")
+	}
+	ca, _, err := factorLevels(av, "a")
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	cb, _, err := factorLevels(bv, "b")
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	ka, kb := 0, 0
+	for i := range ca {
+		ka, kb = max(ka, ca[i]+1), max(kb, cb[i]+1)
+	}
+	table := make([][]float64, ka)
+	for i := range table {
+		table[i] = make([]float64, kb)
+	}
+	rowSums, colSums := make([]float64, ka), make([]float64, kb)
+	for i := range ca {
+		table[ca[i]][cb[i]]++
+		rowSums[ca[i]]++
+		colSums[cb[i]]++
+	}
+	return table, rowSums, colSums, float64(len(ca)), nil
+}
@@ -0,0 +1,87 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+func TestClusterValidityIndices(t *testing.T) {
+	dt := dataTableFromRows([][]float64{{1, 1}, {1, 2}, {5, 5}, {6, 5}})
+	labels := insyra.NewDataList(1, 1, 2, 2)
+	ch, err := stats.CalinskiHarabasz(dt, labels)
+	if err != nil || !floatAlmostEqual(ch, 65, 1e-12) {
+		t.Errorf("Calinski-Harabasz = %v (%v), want 65", ch, err)
+	}
+	db, err := stats.DaviesBouldin(dt, labels)
+	if err != nil || !floatAlmostEqual(db, 1/math.Sqrt(32.5), 1e-12) {
+		t.Errorf("Davies-Bouldin = %v (%v)", db, err)
+	}
+
+	// scikit-learn documentation examples.
+	a, b := insyra.NewDataList(0, 0, 1, 1), insyra.NewDataList(0, 0, 1, 2)
+	ari, err := stats.AdjustedRandIndex(a, b)
+	if err != nil || !floatAlmostEqual(ari, 0.5714285714285715, 1e-12) {
+		t.Errorf("ARI = %v (%v)", ari, err)
+	}
+	nmi, err := stats.NormalizedMutualInfo(a, b)
+	if err != nil || !floatAlmostEqual(nmi, 0.8, 1e-12) {
+		t.Errorf("NMI = %v (%v)", nmi, err)
+	}
+	same, _ := stats.AdjustedRandIndex(insyra.NewDataList("x", "x", "y"), insyra.NewDataList(2, 2, 1))
+	if same != 1 {
+		t.Errorf("ARI of relabelled partition = %v", same)
+	}
+}
+
+func TestSelectingK(t *testing.T) {
+	dt, _ := blobTable(31, 30, [][2]float64{{0, 0}, {6, 0}, {3, 6}}, []float64{0.6, 0.6, 0.6})
+
+	seed := int64(3)
+	elbow, err := stats.ElbowCurve(dt, 8, stats.KMeansOptions{NStart: 5, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elbow.Elbow != 3 {
+		t.Errorf("elbow at k=%d: %v", elbow.Elbow, elbow.TotWithinSS)
+	}
+
+	gap, err := stats.GapStatistic(dt, 6, stats.GapStatisticOptions{B: 20, NStart: 5, Seed: 7, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap.OptimalK != 3 {
+		t.Errorf("gap statistic chose k=%d: %v", gap.OptimalK, gap.Gap)
+	}
+
+	kmeans := func(d insyra.IDataTable, k int) ([]int, error) {
+		res, err := stats.KMeans(d, k, stats.KMeansOptions{NStart: 5, Seed: &seed})
+		if err != nil {
+			return nil, err
+		}
+		return res.Cluster, nil
+	}
+	table, err := stats.SelectK(dt, 1, 5, kmeans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := table.(*insyra.DataTable)
+	if r, c := out.Size(); r != 5 || c != 5 {
+		t.Fatalf("SelectK table is %dx%d", r, c)
+	}
+	sil := out.GetColByName("Silhouette").Data()
+	if !math.IsNaN(sil[0].(float64)) {
+		t.Errorf("silhouette for k=1 should be NaN, got %v", sil[0])
+	}
+	best := 1
+	for i := 2; i < len(sil); i++ {
+		if sil[i].(float64) > sil[best].(float64) {
+			best = i
+		}
+	}
+	if got := out.GetColByName("K").Get(best); got != 3 {
+		t.Errorf("best silhouette at k=%v", got)
+	}
+}
@@ -26,6 +26,8 @@ type KMeansResult struct {
 	Size        []int
 	Iter        int
 	IFault      int
+
+	centers [][]float64
 }
 
 type AgglomerativeMethod string
@@ -96,9 +98,36 @@ func KMeans(dataTable insyra.IDataTable, centers int, opts ...KMeansOptions) (*K
 		Size:        append([]int(nil), got.Size...),
 		Iter:        got.Iter,
 		IFault:      got.IFault,
+		centers:     got.Centers,
 	}, nil
 }
 
+// Predict assigns each row of dataTable to the nearest fitted center in
+// Euclidean distance, returning 1-based cluster labels as in Cluster. The
+// columns must match those the model was fitted on.
+func (r *KMeansResult) Predict(dataTable insyra.IDataTable) ([]int, error) {
+	if r == nil || len(r.centers) == 0 {
+		return nil, errors.New("k-means result is not fitted")
+	}
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(data[0]) != len(r.centers[0]) {
+		return nil, fmt.Errorf("data has %d columns, k-means was fitted on %d", len(data[0]), len(r.centers[0]))
+	}
+	out := make([]int, len(data))
+	for i, row := range data {
+		best := math.Inf(1)
+		for c, center := range r.centers {
+			if d := squaredDistance(row, center); d < best {
+				best, out[i] = d, c+1
+			}
+		}
+	}
+	return out, nil
+}
+
 func HierarchicalAgglomerative(dataTable insyra.IDataTable, method AgglomerativeMethod) (*HierarchicalResult, error) {
 	data, labels, err := numericMatrixFromTable(dataTable)
 	if err != nil {
@@ -0,0 +1,192 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// blobTable draws n points around each centre with the matching spread.
+func blobTable(seed uint64, n int, centres [][2]float64, spreads []float64) (*insyra.DataTable, []int) {
+	z := seededNormals(seed, 2*n*len(centres))
+	x := insyra.NewDataList().SetName("x")
+	y := insyra.NewDataList().SetName("y")
+	var truth []int
+	for c, centre := range centres {
+		for i := 0; i < n; i++ {
+			k := 2 * (c*n + i)
+			x.Append(centre[0] + spreads[c]*z[k])
+			y.Append(centre[1] + spreads[c]*z[k+1])
+			truth = append(truth, c)
+		}
+	}
+	return insyra.NewDataTable(x, y), truth
+}
+
+// samePartition reports whether two labelings agree up to relabeling.
+func samePartition(a []int, b []int) bool {
+	ab, ba := map[int]int{}, map[int]int{}
+	for i := range a {
+		if v, ok := ab[a[i]]; ok && v != b[i] {
+			return false
+		}
+		if v, ok := ba[b[i]]; ok && v != a[i] {
+			return false
+		}
+		ab[a[i]], ba[b[i]] = b[i], a[i]
+	}
+	return true
+}
+
+func TestGaussianMixture(t *testing.T) {
+	dt, truth := blobTable(11, 60, [][2]float64{{0, 0}, {6, 6}}, []float64{1, 0.5})
+	seed := int64(1)
+	fit, err := stats.GaussianMixture(dt, 2, stats.GMMOptions{Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fit.Converged || !samePartition(fit.Cluster, truth) {
+		t.Fatalf("converged %v, clusters %v", fit.Converged, fit.Cluster)
+	}
+	if !floatAlmostEqual(fit.Weights[0], 0.5, 1e-6) || fit.NParams != 11 {
+		t.Errorf("weights %v, params %d", fit.Weights, fit.NParams)
+	}
+
+	// One full component is the multivariate normal MLE.
+	one, err := stats.GaussianMixture(dt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := tableToFloatMatrix(dt)
+	n := float64(len(data))
+	var mean [2]float64
+	for _, r := range data {
+		mean[0] += r[0] / n
+		mean[1] += r[1] / n
+	}
+	var s [2][2]float64
+	for _, r := range data {
+		for a := range 2 {
+			for b := range 2 {
+				s[a][b] += (r[a] - mean[a]) * (r[b] - mean[b]) / n
+			}
+		}
+	}
+	if !floatAlmostEqual(one.Covariances[0][0][1], s[0][1], 1e-9) || !floatAlmostEqual(one.Covariances[0][0][0], s[0][0]+1e-6, 1e-9) {
+		t.Errorf("covariance %v, want %v", one.Covariances[0], s)
+	}
+	det := (s[0][0]+1e-6)*(s[1][1]+1e-6) - s[0][1]*s[0][1]
+	wantLL := -n / 2 * (2*math.Log(2*math.Pi) + math.Log(det) + 2)
+	if !floatAlmostEqual(one.LogLikelihood, wantLL, 1e-3) {
+		t.Errorf("log-likelihood %v, want %v", one.LogLikelihood, wantLL)
+	}
+
+	sel, err := stats.SelectGaussianMixture(dt, 4, stats.GMMOptions{Covariance: stats.GMMDiag, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sel.Best.Weights) != 2 {
+		t.Errorf("BIC selected %d components: %v", len(sel.Best.Weights), sel.BIC)
+	}
+}
+
+func TestPAMFindsOptimalMedoids(t *testing.T) {
+	dt, truth := blobTable(5, 5, [][2]float64{{0, 0}, {5, 0}, {0, 5}}, []float64{0.7, 0.7, 0.7})
+	data := tableToFloatMatrix(dt)
+	manhattan := func(a, b []float64) float64 { return math.Abs(a[0]-b[0]) + math.Abs(a[1]-b[1]) }
+
+	for _, opt := range []stats.PAMOptions{{}, {Distance: manhattan}} {
+		dist := opt.Distance
+		if dist == nil {
+			dist = func(a, b []float64) float64 { return math.Hypot(a[0]-b[0], a[1]-b[1]) }
+		}
+		got, err := stats.PAM(dt, 3, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !samePartition(got.Cluster, truth) {
+			t.Fatalf("clusters %v", got.Cluster)
+		}
+		// Exhaustive search over all medoid triples.
+		best := math.Inf(1)
+		n := len(data)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				for k := j + 1; k < n; k++ {
+					cost := 0.0
+					for _, r := range data {
+						cost += math.Min(dist(r, data[i]), math.Min(dist(r, data[j]), dist(r, data[k])))
+					}
+					best = math.Min(best, cost)
+				}
+			}
+		}
+		if !floatAlmostEqual(got.Cost, best, 1e-9) {
+			t.Errorf("PAM cost %v, optimum %v", got.Cost, best)
+		}
+	}
+}
+
+func TestHDBSCANAndOPTICS(t *testing.T) {
+	// A dense and a sparse cluster that no single DBSCAN radius separates
+	// cleanly, plus three far outliers.
+	dt, truth := blobTable(21, 50, [][2]float64{{0, 0}, {8, 8}}, []float64{0.15, 1.2})
+	for _, p := range [][2]float64{{-10, 10}, {20, -5}, {15, 25}} {
+		dt.AppendRowsByColName(map[string]any{"x": p[0], "y": p[1]})
+		truth = append(truth, -1)
+	}
+	res, err := stats.HDBSCAN(dt, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Stability) != 2 {
+		t.Fatalf("found %d clusters: %v", len(res.Stability), res.Cluster)
+	}
+	clustered := [2]int{}
+	for i, c := range truth {
+		if c >= 0 && res.Cluster[i] != 0 {
+			clustered[c]++
+		}
+		if c < 0 && res.Cluster[i] != 0 {
+			t.Errorf("outlier %d assigned to cluster %d", i, res.Cluster[i])
+		}
+		if c >= 0 && res.Cluster[i] != 0 && res.Cluster[i] != res.Cluster[c*50] && res.Cluster[c*50] != 0 {
+			t.Errorf("point %d split from its blob", i)
+		}
+		if res.Probabilities[i] < 0 || res.Probabilities[i] > 1 {
+			t.Errorf("probability %v out of range", res.Probabilities[i])
+		}
+	}
+	if clustered[0] < 40 || clustered[1] < 40 {
+		t.Errorf("too many blob points labelled noise: %v clustered", clustered)
+	}
+
+	// Cutting an OPTICS ordering at eps reproduces DBSCAN's core points.
+	opt, err := stats.OPTICS(dt, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opt.Order) != len(truth) || !math.IsInf(opt.Reachability[opt.Order[0]], 1) {
+		t.Fatalf("bad ordering %v", opt.Order)
+	}
+	extracted, err := opt.ExtractDBSCAN(0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := stats.DBSCAN(dt, 0.5, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a, b []int
+	for i, seed := range db.IsSeed {
+		if seed {
+			a = append(a, extracted[i])
+			b = append(b, db.Cluster[i])
+		}
+	}
+	if len(a) == 0 || !samePartition(a, b) {
+		t.Errorf("OPTICS extraction %v disagrees with DBSCAN %v on core points", a, b)
+	}
+}
@@ -496,3 +496,26 @@ func TestSilhouette_R(t *testing.T) {
 		})
 	}
 }
+
+func TestKMeansPredictAssignsNearestCenter(t *testing.T) {
+	dt, _ := blobTable(41, 20, [][2]float64{{0, 0}, {5, 5}}, []float64{0.5, 0.5})
+	seed := int64(2)
+	fit, err := stats.KMeans(dt, 2, stats.KMeansOptions{NStart: 3, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fit.Predict(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fit.Cluster) {
+		t.Errorf("Predict on training data %v, Cluster %v", got, fit.Cluster)
+	}
+	fresh, err := fit.Predict(dataTableFromRows([][]float64{{0.2, -0.1}, {4.8, 5.3}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh[0] != fit.Cluster[0] || fresh[1] != fit.Cluster[20] {
+		t.Errorf("new points assigned %v", fresh)
+	}
+}
@@ -10,6 +10,13 @@ const (
 	Binomial GLMFamily = "binomial"
 	Poisson  GLMFamily = "poisson"
 	Gaussian GLMFamily = "gaussian"
+	// Gamma, InverseGaussian and Tweedie estimate the dispersion; Tweedie
+	// needs GLMOptions.TweediePower.
+	Gamma           GLMFamily = "gamma"
+	InverseGaussian GLMFamily = "inverse_gaussian"
+	// NegativeBinomial estimates theta unless GLMOptions.Theta fixes it.
+	NegativeBinomial GLMFamily = "negative_binomial"
+	Tweedie          GLMFamily = "tweedie"
 )
 
 const (
@@ -18,6 +25,10 @@ const (
 	Identity GLMLink = "identity"
 	Probit   GLMLink = "probit"
 	Cloglog  GLMLink = "cloglog"
+	// Inverse is the canonical Gamma link, 1/mu.
+	Inverse GLMLink = "inverse"
+	// InverseSquared is the canonical inverse Gaussian link, 1/mu^2.
+	InverseSquared GLMLink = "1/mu^2"
 )
 
 const (
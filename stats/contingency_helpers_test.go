@@ -0,0 +1,18 @@
+package stats
+
+import "github.com/HazelnutParadise/insyra"
+
+// expandCounts turns a table of counts into paired observations: table[i][j]
+// pairs of (rowLabels[i], colLabels[j]).
+func expandCounts(table [][]int, rowLabels, colLabels []any) (*insyra.DataList, *insyra.DataList) {
+	rows, cols := insyra.NewDataList(), insyra.NewDataList()
+	for i, row := range table {
+		for j, c := range row {
+			for range c {
+				rows.Append(rowLabels[i])
+				cols.Append(colLabels[j])
+			}
+		}
+	}
+	return rows, cols
+}
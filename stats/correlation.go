@@ -22,6 +22,23 @@ const (
 	PearsonCorrelation CorrelationMethod = iota
 	KendallCorrelation
 	SpearmanCorrelation
+	// PartialCorrelation and SemipartialCorrelation control each pair for
+	// all other columns of the table, so they are only available through
+	// CorrelationMatrix; use PartialCorrelationTest or
+	// SemipartialCorrelationTest for explicit covariates.
+	PartialCorrelation
+	SemipartialCorrelation
+	// PolychoricCorrelation treats both variables as ordinal categories of
+	// latent normal variables; TetrachoricCorrelation is the binary case.
+	PolychoricCorrelation
+	TetrachoricCorrelation
+	// PointBiserialCorrelation is Pearson's r where one variable is
+	// dichotomous.
+	PointBiserialCorrelation
+	// DistanceCorrelation uses 999 permutations and an unseeded generator
+	// for its p-value, so matrix p-values vary between runs; use
+	// DistanceCorrelationTest to set the count or the seed.
+	DistanceCorrelation
 )
 
 // CorrelationAnalysis calculates correlation matrix, p-value matrix and Bartlett test.
@@ -77,6 +94,20 @@ func CorrelationMatrix(dataTable insyra.IDataTable, method CorrelationMethod) (c
 		return nil, nil, err
 	}
 
+	if method == PartialCorrelation || method == SemipartialCorrelation {
+		matrix, pmatrix, err := controlledCorrelationMatrix(colSlices, method == SemipartialCorrelation)
+		if err != nil {
+			return nil, nil, err
+		}
+		dt.AppendRowsFromDataList(buildCorrTableRows(matrix, colNames)...)
+		dt.AppendRowsFromDataList(buildColNameRow(colNames))
+		dt.SetRowToColNames(-1)
+		pdt.AppendRowsFromDataList(buildCorrTableRows(pmatrix, colNames)...)
+		pdt.AppendRowsFromDataList(buildColNameRow(colNames))
+		pdt.SetRowToColNames(-1)
+		return dt, pdt, nil
+	}
+
 	matrix := make([][]float64, n)
 	pmatrix := make([][]float64, n)
 	for i := range matrix {
@@ -121,11 +152,18 @@ func CorrelationMatrix(dataTable insyra.IDataTable, method CorrelationMethod) (c
 	// Per-pair cost: Pearson/Spearman are O(rows) (~5µs at rows=500). Kendall
 	// is O(rows²) so it always wants parallel as soon as we have ≥2 pairs.
 	// Pearson/Spearman want pairs ≥ 4 to amortise goroutine launch (~18µs at
-	// 24 workers). rows ≥ 50 ensures per-pair has actual work.
+	// 24 workers). rows ≥ 50 ensures per-pair has actual work. Polychoric
+	// pairs run a likelihood search and distance pairs a permutation test,
+	// so they always go parallel.
 	goPar := pairs >= 2 && rows >= 50
-	if method != KendallCorrelation {
+	switch method {
+	case KendallCorrelation:
+	case PolychoricCorrelation, TetrachoricCorrelation, DistanceCorrelation:
+		goPar = pairs >= 2
+	default:
 		goPar = pairs >= 4 && rows >= 50
 	}
+	seed := resolveSeed(0, false)
 	parutil.Run(pairs, goPar, func(p int) {
 		i, j := pairAt(p)
 		var statVal, pval float64
@@ -137,6 +175,13 @@ func CorrelationMatrix(dataTable insyra.IDataTable, method CorrelationMethod) (c
 			statVal, pval, perr = kendallOnSlices(colSlices[i], colSlices[j])
 		case SpearmanCorrelation:
 			statVal, pval, perr = spearmanOnSlices(colSlices[i], colSlices[j], ranks[i], ranks[j])
+		case PolychoricCorrelation, TetrachoricCorrelation:
+			statVal, _, pval, perr = polychoricOnSlices(colSlices[i], colSlices[j], method == TetrachoricCorrelation)
+		case PointBiserialCorrelation:
+			statVal, pval, perr = pointBiserialOnSlices(colSlices[i], colSlices[j])
+		case DistanceCorrelation:
+			rng := replicateRNG(seed, uint64(p))
+			statVal, pval, perr = distanceCorrelationOnSlices(colSlices[i], colSlices[j], defaultDistancePermutations, rng)
 		default:
 			perr = errors.New("unsupported method")
 		}
@@ -304,6 +349,33 @@ func kendallOnSlices(x, y []float64) (statVal, pValue float64, err error) {
 	return tau, 2 * (1 - zCDF(math.Abs(z))), nil
 }
 
+// pointBiserialOnSlices is Pearson's r with its t test, after checking that
+// at least one of the variables takes exactly two values.
+func pointBiserialOnSlices(x, y []float64) (statVal, pValue float64, err error) {
+	if !isDichotomous(x) && !isDichotomous(y) {
+		return math.NaN(), math.NaN(), errors.New("point-biserial correlation requires one dichotomous variable")
+	}
+	return pearsonOnSlices(x, y)
+}
+
+// isDichotomous reports whether v takes exactly two distinct values.
+func isDichotomous(v []float64) bool {
+	var a, b float64
+	seen := 0
+	for _, x := range v {
+		switch {
+		case seen == 0:
+			a, seen = x, 1
+		case x == a:
+		case seen == 1:
+			b, seen = x, 2
+		case x != b:
+			return false
+		}
+	}
+	return seen == 2
+}
+
 func spearmanOnSlices(rawX, rawY, rankX, rankY []float64) (statVal, pValue float64, err error) {
 	n := len(rawX)
 	if n != len(rawY) || n < 2 {
@@ -402,6 +474,22 @@ func Correlation(dlX, dlY insyra.IDataList, method CorrelationMethod) (*Correlat
 				result = kendallCorrelationWithStats(dlx, dly)
 			case SpearmanCorrelation:
 				result, err = spearmanCorrelationWithStats(dlx, dly)
+			case PolychoricCorrelation, TetrachoricCorrelation:
+				result, err = polychoricCorrelationWithStats(dlx, dly, method == TetrachoricCorrelation)
+			case PointBiserialCorrelation:
+				if !isDichotomous(dlx.ToF64Slice()) && !isDichotomous(dly.ToF64Slice()) {
+					err = errors.New("point-biserial correlation requires one dichotomous variable")
+					return
+				}
+				result, err = pearsonCorrelationWithStats(dlx, dly)
+			case DistanceCorrelation:
+				var res *CorrelationResult
+				if res, err = DistanceCorrelationTest(dlx, dly); err == nil {
+					result = *res
+				}
+			case PartialCorrelation, SemipartialCorrelation:
+				err = errors.New("partial correlations need covariates; use PartialCorrelationTest or SemipartialCorrelationTest")
+				return
 			default:
 				err = errors.New("unsupported method")
 				return
@@ -524,6 +612,26 @@ func pearsonCorrelationWithStats(dlX, dlY insyra.IDataList) (CorrelationResult,
 	return result, nil
 }
 
+// polychoricCorrelationWithStats reports the polychoric (or tetrachoric)
+// correlation with the Wald z test of ρ = 0 and a Wald interval clipped to
+// [-1, 1].
+func polychoricCorrelationWithStats(dlX, dlY insyra.IDataList, binary bool) (CorrelationResult, error) {
+	result := CorrelationResult{}
+	rho, se, p, err := polychoricOnSlices(dlX.ToF64Slice(), dlY.ToF64Slice(), binary)
+	if err != nil {
+		return result, err
+	}
+	result.Statistic = rho
+	result.PValue = p
+	if math.IsNaN(se) {
+		result.CI = nanCIPtr()
+		return result, nil
+	}
+	margin := zMarginOfError(defaultConfidenceLevel, se)
+	result.CI = &[2]float64{math.Max(rho-margin, -1), math.Min(rho+margin, 1)}
+	return result, nil
+}
+
 // kendallTauBStats computes Kendall's τ-b along with the S-statistic and the
 // asymptotic variance with full tie correction.
 //
@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// defaultDistancePermutations is the permutation count used when none is
+// given, including every pair of a DistanceCorrelation CorrelationMatrix.
+const defaultDistancePermutations = 999
+
+type DistanceCorrelationOptions struct {
+	Permutations int // default 999
+	Seed         uint64
+	UseSeed      bool
+}
+
+// DistanceCorrelationTest returns the distance correlation of Székely, Rizzo
+// and Bakirov (2007), which is zero only under independence and so detects
+// non-monotone association. The p-value is the permutation test of
+// energy::dcor.test: (1 + #{permuted ≥ observed}) / (R + 1). Memory and time
+// per permutation grow with n².
+func DistanceCorrelationTest(dlX, dlY insyra.IDataList, opts ...DistanceCorrelationOptions) (*CorrelationResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt DistanceCorrelationOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.Permutations < 0 {
+		return nil, errors.New("permutations must be non-negative")
+	}
+	if opt.Permutations == 0 {
+		opt.Permutations = defaultDistancePermutations
+	}
+	if dlX == nil || dlY == nil {
+		return nil, errors.New("input DataLists cannot be nil")
+	}
+	x, y := dlX.ToF64Slice(), dlY.ToF64Slice()
+	rng := replicateRNG(resolveSeed(opt.Seed, opt.UseSeed), 0)
+	dcor, p, err := distanceCorrelationOnSlices(x, y, opt.Permutations, rng)
+	if err != nil {
+		return nil, err
+	}
+	res := &CorrelationResult{}
+	res.Statistic = dcor
+	res.PValue = p
+	return res, nil
+}
+
+func distanceCorrelationOnSlices(x, y []float64, permutations int, rng *rand.Rand) (dcor, pValue float64, err error) {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return math.NaN(), math.NaN(), errors.New("invalid input length or insufficient data")
+	}
+	for i := range x {
+		if math.IsNaN(x[i]) || math.IsInf(x[i], 0) || math.IsNaN(y[i]) || math.IsInf(y[i], 0) {
+			return math.NaN(), math.NaN(), errors.New("distance correlation requires finite values")
+		}
+	}
+	if !hasVarianceFloats(x) || !hasVarianceFloats(y) {
+		return math.NaN(), math.NaN(), errors.New("cannot calculate correlation due to zero variance")
+	}
+	a := doublyCenteredDistances(x)
+	b := doublyCenteredDistances(y)
+	var vxy, vxx, vyy float64
+	for i := range n {
+		for j := range n {
+			vxy += a[i][j] * b[i][j]
+			vxx += a[i][j] * a[i][j]
+			vyy += b[i][j] * b[i][j]
+		}
+	}
+	dcor = math.Sqrt(math.Max(vxy, 0) / math.Sqrt(vxx*vyy))
+
+	// Permuting y permutes the rows and columns of b; the denominator is
+	// unchanged so the cross term alone orders the replicates. The small
+	// tolerance keeps permutations that tie in exact arithmetic counted.
+	tol := 1e-12 * math.Abs(vxy)
+	extreme := 0
+	for range permutations {
+		perm := rng.Perm(n)
+		s := 0.0
+		for i := range n {
+			bi := b[perm[i]]
+			ai := a[i]
+			for j := range n {
+				s += ai[j] * bi[perm[j]]
+			}
+		}
+		if s >= vxy-tol {
+			extreme++
+		}
+	}
+	return dcor, float64(1+extreme) / float64(permutations+1), nil
+}
+
+// doublyCenteredDistances returns the matrix |v_i - v_j| with row, column
+// and grand means removed.
+func doublyCenteredDistances(v []float64) [][]float64 {
+	n := len(v)
+	d := make([][]float64, n)
+	rowMean := make([]float64, n)
+	grand := 0.0
+	for i := range n {
+		d[i] = make([]float64, n)
+		for j := range n {
+			d[i][j] = math.Abs(v[i] - v[j])
+			rowMean[i] += d[i][j]
+		}
+		grand += rowMean[i]
+		rowMean[i] /= float64(n)
+	}
+	grand /= float64(n * n)
+	for i := range n {
+		for j := range n {
+			d[i][j] += grand - rowMean[i] - rowMean[j]
+		}
+	}
+	return d
+}
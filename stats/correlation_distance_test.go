@@ -0,0 +1,63 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+func TestDistanceCorrelationTest(t *testing.T) {
+	n := 40
+	xs := make([]float64, n)
+	quad := make([]float64, n)
+	for i := range n {
+		xs[i] = float64(i-n/2) / 4
+		quad[i] = xs[i] * xs[i]
+	}
+	x := insyra.NewDataList(xs)
+
+	// Distance correlation is invariant to affine maps and equals 1 for
+	// a linear relation.
+	lin := make([]float64, n)
+	for i, v := range xs {
+		lin[i] = 3 - 2*v
+	}
+	res, err := DistanceCorrelationTest(x, insyra.NewDataList(lin), DistanceCorrelationOptions{Seed: 1, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Statistic-1) > 1e-12 {
+		t.Fatalf("dcor = %v, want 1", res.Statistic)
+	}
+
+	// A symmetric quadratic has zero Pearson correlation but strong
+	// distance correlation.
+	opt := DistanceCorrelationOptions{Permutations: 199, Seed: 3, UseSeed: true}
+	res, err = DistanceCorrelationTest(x, insyra.NewDataList(quad), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Statistic < 0.4 || res.PValue != 1.0/200 {
+		t.Fatalf("dcor = %v, p = %v", res.Statistic, res.PValue)
+	}
+	again, _ := DistanceCorrelationTest(x, insyra.NewDataList(quad), opt)
+	if again.PValue != res.PValue {
+		t.Fatalf("seeded p-values differ: %v vs %v", again.PValue, res.PValue)
+	}
+
+	viaCorr, err := Correlation(x, insyra.NewDataList(quad), DistanceCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(viaCorr.Statistic-res.Statistic) > 1e-12 {
+		t.Fatalf("Correlation dcor = %v, want %v", viaCorr.Statistic, res.Statistic)
+	}
+
+	if _, err := DistanceCorrelationTest(x, x, DistanceCorrelationOptions{Permutations: -1}); err == nil {
+		t.Fatal("expected error for negative permutations")
+	}
+	if _, err := DistanceCorrelationTest(x, insyra.NewDataList(make([]float64, n))); err == nil {
+		t.Fatal("expected error for zero variance")
+	}
+}
@@ -0,0 +1,151 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// PartialCorrelationTest returns the Pearson correlation between dlX and dlY
+// after removing the linear effect of the covariates from both, with the
+// t test on n - 2 - q degrees of freedom (q covariates) used by
+// ppcor::pcor.test. The confidence interval is the Fisher interval with the
+// sample size reduced by q.
+func PartialCorrelationTest(dlX, dlY insyra.IDataList, covariates ...insyra.IDataList) (*CorrelationResult, error) {
+	return controlledCorrelation(dlX, dlY, covariates, false)
+}
+
+// SemipartialCorrelationTest returns the correlation between dlX and the
+// part of dlY not explained by the covariates (the covariates are removed
+// from dlY only), with the same t test as PartialCorrelationTest
+// (ppcor::spcor.test).
+func SemipartialCorrelationTest(dlX, dlY insyra.IDataList, covariates ...insyra.IDataList) (*CorrelationResult, error) {
+	return controlledCorrelation(dlX, dlY, covariates, true)
+}
+
+func controlledCorrelation(dlX, dlY insyra.IDataList, covariates []insyra.IDataList, semi bool) (*CorrelationResult, error) {
+	if dlX == nil || dlY == nil {
+		return nil, errors.New("input DataLists cannot be nil")
+	}
+	if len(covariates) == 0 {
+		return nil, errors.New("at least one covariate is required")
+	}
+	x, z, n, err := linearModelInputs(dlX, covariates)
+	if err != nil {
+		return nil, err
+	}
+	y, _, ny, err := linearModelInputs(dlY, covariates)
+	if err != nil {
+		return nil, err
+	}
+	if ny != n {
+		return nil, errors.New("invalid input length or insufficient data")
+	}
+	q := len(covariates)
+	if n-2-q <= 0 {
+		return nil, errors.New("need more observations than covariates plus two")
+	}
+	ry, err := residualizeOn(z, y)
+	if err != nil {
+		return nil, err
+	}
+	rx := x
+	if !semi {
+		if rx, err = residualizeOn(z, x); err != nil {
+			return nil, err
+		}
+	}
+	r, _, err := pearsonOnSlices(rx, ry)
+	if err != nil {
+		return nil, err
+	}
+	res := &CorrelationResult{}
+	res.Statistic = r
+	res.PValue, res.DF = controlledCorrelationPValue(r, n, q)
+	res.CI = pearsonFisherCI(r, float64(n-q), defaultConfidenceLevel)
+	return res, nil
+}
+
+// residualizeOn returns the least-squares residuals of v on the columns of z.
+func residualizeOn(z *mat.Dense, v []float64) ([]float64, error) {
+	var beta mat.VecDense
+	if err := beta.SolveVec(z, mat.NewVecDense(len(v), append([]float64(nil), v...))); err != nil {
+		return nil, errors.New("covariates are collinear")
+	}
+	return linearResiduals(z, v, beta.RawVector().Data), nil
+}
+
+// controlledCorrelationPValue is the two-sided t test of a partial or
+// semi-partial correlation with q covariates.
+func controlledCorrelationPValue(r float64, n, q int) (float64, *float64) {
+	df := float64(n - 2 - q)
+	if df <= 0 || math.IsNaN(r) {
+		return math.NaN(), &df
+	}
+	if math.Abs(r) >= 1 {
+		return 0, &df
+	}
+	t := r * math.Sqrt(df/(1-r*r))
+	return tTwoTailedPValue(t, df), &df
+}
+
+// controlledCorrelationMatrix computes partial correlations of every pair of
+// columns given all remaining columns from the inverse of the Pearson
+// correlation matrix P: -P_ij / √(P_ii P_jj). For semi-partial correlations
+// the column variable is residualised and the row variable is not, so the
+// matrix is not symmetric: pcor_ij / √(P_ii (1 - pcor_ij²)), as in
+// ppcor::spcor.
+func controlledCorrelationMatrix(cols [][]float64, semi bool) (corr, p [][]float64, err error) {
+	k := len(cols)
+	n := len(cols[0])
+	data := mat.NewDense(n, k, nil)
+	for j, c := range cols {
+		if len(c) != n {
+			return nil, nil, errors.New("invalid input length or insufficient data")
+		}
+		if !hasVarianceFloats(c) {
+			return nil, nil, errors.New("cannot calculate correlation due to zero variance")
+		}
+		for i, v := range c {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, nil, errors.New("partial correlation requires finite values")
+			}
+			data.Set(i, j, v)
+		}
+	}
+	var r mat.SymDense
+	stat.CorrelationMatrix(&r, data, nil)
+	rows := make([][]float64, k)
+	for i := range k {
+		rows[i] = make([]float64, k)
+		for j := range k {
+			rows[i][j] = r.At(i, j)
+		}
+	}
+	prec := invertSymmetric(rows)
+	if prec == nil {
+		return nil, nil, errors.New("correlation matrix is singular; partial correlations are undefined")
+	}
+	corr = make([][]float64, k)
+	p = make([][]float64, k)
+	for i := range k {
+		corr[i] = make([]float64, k)
+		p[i] = make([]float64, k)
+		corr[i][i] = 1
+		for j := range k {
+			if i == j {
+				continue
+			}
+			pc := -prec[i][j] / math.Sqrt(prec[i][i]*prec[j][j])
+			if semi {
+				pc /= math.Sqrt(prec[i][i] * (1 - pc*pc))
+			}
+			corr[i][j] = pc
+			p[i][j], _ = controlledCorrelationPValue(pc, n, k-2)
+		}
+	}
+	return corr, p, nil
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+var partialX = []float64{4.1, 5.3, 6.0, 6.8, 7.7, 8.1, 9.6, 10.2, 11.5, 12.0, 13.4, 14.1}
+var partialY = []float64{2.0, 2.9, 2.7, 4.1, 4.4, 4.0, 5.6, 5.1, 6.9, 6.2, 7.8, 7.5}
+var partialZ = []float64{1, 2, 2, 3, 4, 3, 5, 5, 6, 6, 7, 8}
+
+func TestPartialCorrelationTest(t *testing.T) {
+	x, y, z := insyra.NewDataList(partialX), insyra.NewDataList(partialY), insyra.NewDataList(partialZ)
+	rxy, _, _ := pearsonOnSlices(partialX, partialY)
+	rxz, _, _ := pearsonOnSlices(partialX, partialZ)
+	ryz, _, _ := pearsonOnSlices(partialY, partialZ)
+
+	res, err := PartialCorrelationTest(x, y, z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := (rxy - rxz*ryz) / math.Sqrt((1-rxz*rxz)*(1-ryz*ryz))
+	if math.Abs(res.Statistic-want) > 1e-12 {
+		t.Fatalf("partial r = %v, want %v", res.Statistic, want)
+	}
+	if res.DF == nil || *res.DF != 9 {
+		t.Fatalf("df = %v, want 9", res.DF)
+	}
+	tStat := want * math.Sqrt(9/(1-want*want))
+	if math.Abs(res.PValue-tTwoTailedPValue(tStat, 9)) > 1e-12 {
+		t.Fatalf("p = %v", res.PValue)
+	}
+	if res.CI == nil || res.CI[0] >= want || res.CI[1] <= want {
+		t.Fatalf("interval %v does not contain r", res.CI)
+	}
+
+	semi, err := SemipartialCorrelationTest(x, y, z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSemi := (rxy - rxz*ryz) / math.Sqrt(1-ryz*ryz)
+	if math.Abs(semi.Statistic-wantSemi) > 1e-12 {
+		t.Fatalf("semi-partial r = %v, want %v", semi.Statistic, wantSemi)
+	}
+
+	if _, err := PartialCorrelationTest(x, y); err == nil {
+		t.Fatal("expected error without covariates")
+	}
+	if _, err := PartialCorrelationTest(x, y, z, z); err == nil {
+		t.Fatal("expected error for collinear covariates")
+	}
+}
+
+func TestCorrelationMatrixPartial(t *testing.T) {
+	dt := insyra.NewDataTable(
+		insyra.NewDataList(partialX).SetName("x"),
+		insyra.NewDataList(partialY).SetName("y"),
+		insyra.NewDataList(partialZ).SetName("z"),
+	)
+	x, y, z := insyra.NewDataList(partialX), insyra.NewDataList(partialY), insyra.NewDataList(partialZ)
+
+	corr, p, err := CorrelationMatrix(dt, PartialCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, _ := PartialCorrelationTest(x, y, z)
+	if got := corr.GetElementByNumberIndex(0, 1).(float64); math.Abs(got-res.Statistic) > 1e-10 {
+		t.Fatalf("matrix partial r = %v, want %v", got, res.Statistic)
+	}
+	if got := p.GetElementByNumberIndex(1, 0).(float64); math.Abs(got-res.PValue) > 1e-10 {
+		t.Fatalf("matrix partial p = %v, want %v", got, res.PValue)
+	}
+
+	// Rows are the raw variable and columns the residualised one.
+	semi, _, err := CorrelationMatrix(dt, SemipartialCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sxy, _ := SemipartialCorrelationTest(x, y, z)
+	syx, _ := SemipartialCorrelationTest(y, x, z)
+	if got := semi.GetElementByNumberIndex(0, 1).(float64); math.Abs(got-sxy.Statistic) > 1e-10 {
+		t.Fatalf("semi[x][y] = %v, want %v", got, sxy.Statistic)
+	}
+	if got := semi.GetElementByNumberIndex(1, 0).(float64); math.Abs(got-syx.Statistic) > 1e-10 {
+		t.Fatalf("semi[y][x] = %v, want %v", got, syx.Statistic)
+	}
+
+	if _, err := Correlation(x, y, PartialCorrelation); err == nil {
+		t.Fatal("expected Correlation to reject PartialCorrelation")
+	}
+}
+
+func TestPointBiserialCorrelation(t *testing.T) {
+	g := insyra.NewDataList([]float64{0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 0})
+	y := insyra.NewDataList(partialY)
+	res, err := Correlation(g, y, PointBiserialCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Correlation(g, y, PearsonCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Statistic != want.Statistic || res.PValue != want.PValue {
+		t.Fatalf("point-biserial = (%v, %v), want Pearson (%v, %v)", res.Statistic, res.PValue, want.Statistic, want.PValue)
+	}
+	if _, err := Correlation(insyra.NewDataList(partialX), y, PointBiserialCorrelation); err == nil {
+		t.Fatal("expected error without a dichotomous variable")
+	}
+}
@@ -0,0 +1,364 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// polychoricMaxCategories guards against treating a continuous column as
+// ordinal: every distinct value becomes a category and a threshold.
+const polychoricMaxCategories = 20
+
+// polychoricOnSlices returns the two-step maximum likelihood polychoric
+// correlation of x and y (Olsson, 1979). Thresholds are fixed at the normal
+// quantiles of the cumulative marginal proportions and ρ maximises the
+// likelihood of the contingency table. The standard error is taken from the
+// observed information for ρ with the thresholds treated as known, as
+// polycor::polychor(std.err = TRUE) does for the two-step estimator. With
+// binary = true both variables must have exactly two categories
+// (tetrachoric correlation).
+func polychoricOnSlices(x, y []float64, binary bool) (rho, se, pValue float64, err error) {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return math.NaN(), math.NaN(), math.NaN(), errors.New("invalid input length or insufficient data")
+	}
+	xLevels, xIdx, err := ordinalCategories(x)
+	if err != nil {
+		return math.NaN(), math.NaN(), math.NaN(), err
+	}
+	yLevels, yIdx, err := ordinalCategories(y)
+	if err != nil {
+		return math.NaN(), math.NaN(), math.NaN(), err
+	}
+	if binary && (len(xLevels) != 2 || len(yLevels) != 2) {
+		return math.NaN(), math.NaN(), math.NaN(), errors.New("tetrachoric correlation requires two binary variables")
+	}
+	counts := make([][]float64, len(xLevels))
+	for i := range counts {
+		counts[i] = make([]float64, len(yLevels))
+	}
+	for i := range n {
+		counts[xIdx[i]][yIdx[i]]++
+	}
+	rho, se, pValue = polychoricFromCounts(counts)
+	return rho, se, pValue, nil
+}
+
+// polychoricFromCounts is the two-step estimate for a contingency table
+// whose rows and columns are ordered categories. Counts need not be
+// integers.
+func polychoricFromCounts(counts [][]float64) (rho, se, pValue float64) {
+	a := ordinalThresholds(counts, false)
+	b := ordinalThresholds(counts, true)
+
+	cellProb := func(r float64, i, j int) float64 {
+		return bivariateNormalCDF(a[i+1], b[j+1], r) - bivariateNormalCDF(a[i], b[j+1], r) -
+			bivariateNormalCDF(a[i+1], b[j], r) + bivariateNormalCDF(a[i], b[j], r)
+	}
+	logLik := func(r float64) float64 {
+		ll := 0.0
+		for i, row := range counts {
+			for j, c := range row {
+				if c > 0 {
+					ll += c * math.Log(math.Max(cellProb(r, i, j), 1e-300))
+				}
+			}
+		}
+		return ll
+	}
+	// score is dℓ/dρ, using ∂Φ₂(h, k; ρ)/∂ρ = φ₂(h, k; ρ).
+	score := func(r float64) float64 {
+		s := 0.0
+		for i, row := range counts {
+			for j, c := range row {
+				if c > 0 {
+					d := bivariateNormalPDF(a[i+1], b[j+1], r) - bivariateNormalPDF(a[i], b[j+1], r) -
+						bivariateNormalPDF(a[i+1], b[j], r) + bivariateNormalPDF(a[i], b[j], r)
+					s += c * d / math.Max(cellProb(r, i, j), 1e-300)
+				}
+			}
+		}
+		return s
+	}
+
+	// Golden-section search on the log-likelihood, which is unimodal in ρ
+	// for fixed thresholds.
+	const bound = 0.9999
+	lo, hi := -bound, bound
+	g := (math.Sqrt(5) - 1) / 2
+	c, d := hi-g*(hi-lo), lo+g*(hi-lo)
+	fc, fd := logLik(c), logLik(d)
+	for hi-lo > 1e-10 {
+		if fc > fd {
+			hi, d, fd = d, c, fc
+			c = hi - g*(hi-lo)
+			fc = logLik(c)
+		} else {
+			lo, c, fc = c, d, fd
+			d = lo + g*(hi-lo)
+			fd = logLik(d)
+		}
+	}
+	rho = (lo + hi) / 2
+
+	const h = 1e-5
+	info := -(score(math.Min(rho+h, 1-1e-12)) - score(math.Max(rho-h, -1+1e-12))) / (2 * h)
+	if info <= 0 || math.Abs(rho) >= bound-1e-6 {
+		return rho, math.NaN(), math.NaN()
+	}
+	se = 1 / math.Sqrt(info)
+	return rho, se, zPValue(rho/se, TwoSided)
+}
+
+// ordinalCategories maps each value to the index of its sorted distinct
+// level.
+func ordinalCategories(v []float64) ([]float64, []int, error) {
+	levels := append([]float64(nil), v...)
+	sort.Float64s(levels)
+	k := 0
+	for i, x := range levels {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, nil, errors.New("polychoric correlation requires finite values")
+		}
+		if i == 0 || x != levels[k-1] {
+			levels[k] = x
+			k++
+		}
+	}
+	levels = levels[:k]
+	if k < 2 {
+		return nil, nil, errors.New("cannot calculate correlation due to zero variance")
+	}
+	if k > polychoricMaxCategories {
+		return nil, nil, fmt.Errorf("polychoric correlation needs ordinal data; found %d distinct values (at most %d)", k, polychoricMaxCategories)
+	}
+	idx := make([]int, len(v))
+	for i, x := range v {
+		idx[i] = sort.SearchFloat64s(levels, x)
+	}
+	return levels, idx, nil
+}
+
+// ordinalThresholds returns -Inf, the normal quantiles of the cumulative
+// marginal proportions, and +Inf, for the rows of counts (or the columns
+// when cols is true).
+func ordinalThresholds(counts [][]float64, cols bool) []float64 {
+	var margin []float64
+	if cols {
+		margin = make([]float64, len(counts[0]))
+		for _, row := range counts {
+			for j, c := range row {
+				margin[j] += c
+			}
+		}
+	} else {
+		margin = make([]float64, len(counts))
+		for i, row := range counts {
+			for _, c := range row {
+				margin[i] += c
+			}
+		}
+	}
+	total := 0.0
+	for _, m := range margin {
+		total += m
+	}
+	t := make([]float64, len(margin)+1)
+	t[0] = math.Inf(-1)
+	cum := 0.0
+	for i := 0; i < len(margin)-1; i++ {
+		cum += margin[i]
+		t[i+1] = zQuantile(cum / total)
+	}
+	t[len(margin)] = math.Inf(1)
+	return t
+}
+
+// polychoricMatrix fills a correlation matrix with pairwise polychoric (or
+// tetrachoric) correlations and smooths it to be positive definite when
+// needed.
+func polychoricMatrix(cols [][]float64, binary bool) ([][]float64, error) {
+	p := len(cols)
+	r := make([][]float64, p)
+	for i := range r {
+		r[i] = make([]float64, p)
+		r[i][i] = 1
+	}
+	for i := range p {
+		for j := i + 1; j < p; j++ {
+			rho, _, _, err := polychoricOnSlices(cols[i], cols[j], binary)
+			if err != nil {
+				return nil, fmt.Errorf("columns %d and %d: %w", i, j, err)
+			}
+			r[i][j], r[j][i] = rho, rho
+		}
+	}
+	return smoothCorrelation(r), nil
+}
+
+// bivariateNormalCDF returns P(X < h, Y < k) for standard bivariate normal
+// variables with correlation r, using Genz's BVND algorithm (Drezner and
+// Wesolowsky, 1990, with Gauss-Legendre quadrature); accurate to about
+// 1e-15.
+func bivariateNormalCDF(h, k, r float64) float64 {
+	switch {
+	case math.IsInf(h, -1) || math.IsInf(k, -1):
+		return 0
+	case math.IsInf(h, 1):
+		return zCDF(k)
+	case math.IsInf(k, 1):
+		return zCDF(h)
+	}
+	return bvnUpper(-h, -k, r)
+}
+
+// bivariateNormalPDF is the standard bivariate normal density.
+func bivariateNormalPDF(h, k, r float64) float64 {
+	if math.IsInf(h, 0) || math.IsInf(k, 0) {
+		return 0
+	}
+	s := 1 - r*r
+	return math.Exp(-(h*h-2*r*h*k+k*k)/(2*s)) / (2 * math.Pi * math.Sqrt(s))
+}
+
+// Gauss-Legendre weights and abscissae (negative half) for 6, 12 and 20
+// points.
+var (
+	bvnWeights = [3][]float64{
+		{0.1713244923791705, 0.3607615730481384, 0.4679139345726904},
+		{0.04717533638651177, 0.1069393259953183, 0.1600783285433464,
+			0.2031674267230659, 0.2334925365383547, 0.2491470458134029},
+		{0.01761400713915212, 0.04060142980038694, 0.06267204833410906,
+			0.08327674157670475, 0.1019301198172404, 0.1181945319615184,
+			0.1316886384491766, 0.1420961093183821, 0.1491729864726037,
+			0.1527533871307259},
+	}
+	bvnNodes = [3][]float64{
+		{-0.9324695142031522, -0.6612093864662647, -0.2386191860831970},
+		{-0.9815606342467191, -0.9041172563704750, -0.7699026741943050,
+			-0.5873179542866171, -0.3678314989981802, -0.1252334085114692},
+		{-0.9931285991850949, -0.9639719272779138, -0.9122344282513259,
+			-0.8391169718222188, -0.7463319064601508, -0.6360536807265150,
+			-0.5108670019508271, -0.3737060887154196, -0.2277858511416451,
+			-0.07652652113349733},
+	}
+)
+
+// bvnUpper returns P(X > h, Y > k).
+func bvnUpper(h, k, r float64) float64 {
+	ng := 2
+	switch {
+	case math.Abs(r) < 0.3:
+		ng = 0
+	case math.Abs(r) < 0.75:
+		ng = 1
+	}
+	w, x := bvnWeights[ng], bvnNodes[ng]
+	hk := h * k
+	bvn := 0.0
+	if math.Abs(r) < 0.925 {
+		hs := (h*h + k*k) / 2
+		asr := math.Asin(r)
+		for i := range x {
+			for _, sign := range []float64{-1, 1} {
+				sn := math.Sin(asr * (sign*x[i] + 1) / 2)
+				bvn += w[i] * math.Exp((sn*hk-hs)/(1-sn*sn))
+			}
+		}
+		return bvn*asr/(4*math.Pi) + zCDF(-h)*zCDF(-k)
+	}
+	if r < 0 {
+		k, hk = -k, -hk
+	}
+	if math.Abs(r) < 1 {
+		as := (1 - r) * (1 + r)
+		a := math.Sqrt(as)
+		bs := (h - k) * (h - k)
+		c := (4 - hk) / 8
+		d := (12 - hk) / 16
+		bvn = a * math.Exp(-(bs/as+hk)/2) * (1 - c*(bs-as)*(1-d*bs/5)/3 + c*d*as*as/5)
+		if hk > -160 {
+			b := math.Sqrt(bs)
+			bvn -= math.Exp(-hk/2) * math.Sqrt(2*math.Pi) * zCDF(-b/a) * b * (1 - c*bs*(1-d*bs/5)/3)
+		}
+		a /= 2
+		for i := range x {
+			for _, sign := range []float64{-1, 1} {
+				xs := math.Pow(a*(sign*x[i]+1), 2)
+				rs := math.Sqrt(1 - xs)
+				bvn += a * w[i] * math.Exp(-(bs/xs+hk)/2) *
+					(math.Exp(-hk*xs/(2*(1+rs)*(1+rs)))/rs - (1 + c*xs*(1+d*xs)))
+			}
+		}
+		bvn = -bvn / (2 * math.Pi)
+	}
+	if r > 0 {
+		return bvn + zCDF(-math.Max(h, k))
+	}
+	bvn = -bvn
+	if k > h {
+		if h < 0 {
+			bvn += zCDF(k) - zCDF(h)
+		} else {
+			bvn += zCDF(-h) - zCDF(-k)
+		}
+	}
+	return bvn
+}
+
+// smoothCorrelation makes a pairwise correlation matrix positive definite
+// the way psych::cor.smooth does: eigenvalues below 1e-12 are raised to
+// 1e-10, the spectrum is rescaled to sum to p and the result is rescaled to
+// a unit diagonal. Positive definite matrices are returned unchanged.
+func smoothCorrelation(r [][]float64) [][]float64 {
+	p := len(r)
+	sym := mat.NewSymDense(p, nil)
+	for i := range p {
+		for j := i; j < p; j++ {
+			sym.SetSym(i, j, r[i][j])
+		}
+	}
+	var eig mat.EigenSym
+	if !eig.Factorize(sym, true) {
+		return r
+	}
+	ev := eig.Values(nil)
+	const tol = 1e-12
+	if floats.Min(ev) >= tol {
+		return r
+	}
+	total := 0.0
+	for i, v := range ev {
+		if v < tol {
+			ev[i] = 100 * tol
+		}
+		total += ev[i]
+	}
+	var vecs mat.Dense
+	eig.VectorsTo(&vecs)
+	out := make([][]float64, p)
+	for i := range p {
+		out[i] = make([]float64, p)
+		for j := range p {
+			for m := range p {
+				out[i][j] += vecs.At(i, m) * vecs.At(j, m) * ev[m] * float64(p) / total
+			}
+		}
+	}
+	for i := range p {
+		for j := range p {
+			if i != j {
+				out[i][j] /= math.Sqrt(out[i][i] * out[j][j])
+			}
+		}
+	}
+	for i := range p {
+		out[i][i] = 1
+	}
+	return out
+}
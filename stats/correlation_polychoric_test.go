@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+func TestBivariateNormalCDF(t *testing.T) {
+	// Φ₂(0, 0; ρ) = 1/4 + asin(ρ)/(2π) across all three quadrature
+	// branches.
+	for _, r := range []float64{-0.97, -0.5, 0, 0.2, 0.6, 0.8, 0.95, 0.999} {
+		want := 0.25 + math.Asin(r)/(2*math.Pi)
+		if got := bivariateNormalCDF(0, 0, r); math.Abs(got-want) > 1e-14 {
+			t.Errorf("Φ₂(0, 0; %v) = %v, want %v", r, got, want)
+		}
+	}
+	// Independence factorises.
+	if got, want := bivariateNormalCDF(0.7, -1.2, 0), zCDF(0.7)*zCDF(-1.2); math.Abs(got-want) > 1e-14 {
+		t.Errorf("Φ₂(0.7, -1.2; 0) = %v, want %v", got, want)
+	}
+	if got := bivariateNormalCDF(math.Inf(1), 0.3, 0.5); math.Abs(got-zCDF(0.3)) > 1e-15 {
+		t.Errorf("Φ₂(∞, 0.3) = %v", got)
+	}
+}
+
+func TestTetrachoricCorrelation(t *testing.T) {
+	// With even margins the thresholds are 0 and the likelihood is
+	// maximised where Φ₂(0, 0; ρ) equals the observed p₀₀, so
+	// ρ = sin(2π(p₀₀ - 1/4)).
+	x, y := expandCounts([][]int{{35, 15}, {15, 35}}, []any{0.0, 1.0}, []any{0.0, 1.0})
+	res, err := Correlation(x, y, TetrachoricCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := math.Sin(2 * math.Pi * (0.35 - 0.25))
+	if math.Abs(res.Statistic-want) > 1e-8 {
+		t.Fatalf("tetrachoric r = %v, want %v", res.Statistic, want)
+	}
+	if !(res.PValue > 0 && res.PValue < 0.01) {
+		t.Fatalf("p = %v", res.PValue)
+	}
+	if res.CI == nil || res.CI[0] >= want || res.CI[1] <= want {
+		t.Fatalf("interval %v does not contain r", res.CI)
+	}
+
+	poly, err := Correlation(x, y, PolychoricCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poly.Statistic != res.Statistic {
+		t.Fatalf("polychoric on binary data = %v, want %v", poly.Statistic, res.Statistic)
+	}
+
+	three := insyra.NewDataList([]float64{1, 2, 3, 1, 2, 3})
+	if _, err := Correlation(three, three, TetrachoricCorrelation); err == nil {
+		t.Fatal("expected error for three categories")
+	}
+	cont := insyra.NewDataList()
+	for i := range 30 {
+		cont.Append(float64(i) * 0.1)
+	}
+	if _, err := Correlation(cont, cont, PolychoricCorrelation); err == nil {
+		t.Fatal("expected error for continuous data")
+	}
+}
+
+// ordinalItems draws Likert items from a one-factor latent model.
+func ordinalItems(n int) *insyra.DataTable {
+	rng := replicateRNG(7, 0)
+	loadings := []float64{0.8, 0.7, 0.75, 0.6}
+	cuts := []float64{-1, -0.3, 0.4, 1.1}
+	cols := make([]*insyra.DataList, len(loadings))
+	for j := range cols {
+		cols[j] = insyra.NewDataList()
+	}
+	for range n {
+		f := rng.NormFloat64()
+		for j, l := range loadings {
+			v := l*f + math.Sqrt(1-l*l)*rng.NormFloat64()
+			cat := 1.0
+			for _, c := range cuts {
+				if v > c {
+					cat++
+				}
+			}
+			cols[j].Append(cat)
+		}
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+// TestPolychoricExpectedTable feeds a table whose cell proportions are the
+// bivariate normal probabilities for known thresholds and ρ. The marginal
+// thresholds are then exact, so the two-step estimate (polycor::polychor's
+// default) must return ρ, and the observed information equals the Fisher
+// information N Σ (∂π/∂ρ)² / π.
+func TestPolychoricExpectedTable(t *testing.T) {
+	a := []float64{math.Inf(-1), -0.8, 0.3, 1.2, math.Inf(1)}
+	b := []float64{math.Inf(-1), -0.5, 0.6, math.Inf(1)}
+	const n = 500
+	for _, rho := range []float64{0.45, -0.3, 0.85} {
+		counts := make([][]float64, len(a)-1)
+		fisher := 0.0
+		for i := range counts {
+			counts[i] = make([]float64, len(b)-1)
+			for j := range counts[i] {
+				pi := bivariateNormalCDF(a[i+1], b[j+1], rho) - bivariateNormalCDF(a[i], b[j+1], rho) -
+					bivariateNormalCDF(a[i+1], b[j], rho) + bivariateNormalCDF(a[i], b[j], rho)
+				d := bivariateNormalPDF(a[i+1], b[j+1], rho) - bivariateNormalPDF(a[i], b[j+1], rho) -
+					bivariateNormalPDF(a[i+1], b[j], rho) + bivariateNormalPDF(a[i], b[j], rho)
+				counts[i][j] = n * pi
+				fisher += n * d * d / pi
+			}
+		}
+		// The golden-section search settles ρ to about √ε of the flat
+		// log-likelihood.
+		got, se, p := polychoricFromCounts(counts)
+		if math.Abs(got-rho) > 1e-7 {
+			t.Errorf("ρ = %v: estimate %v", rho, got)
+		}
+		if want := 1 / math.Sqrt(fisher); math.Abs(se-want) > 1e-6*want {
+			t.Errorf("ρ = %v: se %v, want %v", rho, se, want)
+		}
+		if want := zPValue(got/se, TwoSided); p != want {
+			t.Errorf("ρ = %v: p %v, want %v", rho, p, want)
+		}
+	}
+}
+
+func TestPolychoricCorrelationMatrix(t *testing.T) {
+	dt := ordinalItems(400)
+	poly, p, err := CorrelationMatrix(dt, PolychoricCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pearson, _, err := CorrelationMatrix(dt, PearsonCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each entry is the pairwise estimate, and categorisation attenuates
+	// Pearson's r relative to it.
+	pair, err := Correlation(dt.GetColByNumber(0), dt.GetColByNumber(1), PolychoricCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := poly.GetElementByNumberIndex(0, 1).(float64)
+	rr := pearson.GetElementByNumberIndex(0, 1).(float64)
+	if rp != pair.Statistic || rp <= rr {
+		t.Fatalf("polychoric r = %v (pairwise %v), Pearson r = %v", rp, pair.Statistic, rr)
+	}
+	if pv := p.GetElementByNumberIndex(0, 1).(float64); pv != pair.PValue {
+		t.Fatalf("p = %v, pairwise %v", pv, pair.PValue)
+	}
+
+	// One factor on three items is just identified, so psych::fa(cor =
+	// "poly") reproduces the polychoric matrix exactly and the loadings are
+	// λᵢ = √(rᵢⱼ rᵢₖ / rⱼₖ).
+	three := insyra.NewDataTable(dt.GetColByNumber(0), dt.GetColByNumber(1), dt.GetColByNumber(2))
+	r3, _, err := CorrelationMatrix(three, PolychoricCorrelation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := func(i, j int) float64 { return r3.GetElementByNumberIndex(i, j).(float64) }
+	want := []float64{
+		math.Sqrt(r(0, 1) * r(0, 2) / r(1, 2)),
+		math.Sqrt(r(0, 1) * r(1, 2) / r(0, 2)),
+		math.Sqrt(r(0, 2) * r(1, 2) / r(0, 1)),
+	}
+	opt := DefaultFactorAnalysisOptions()
+	opt.Count = FactorCountSpec{Method: FactorCountFixed, FixedK: 1}
+	opt.Correlation = PolychoricCorrelation
+	opt.OptimFactr = 1
+	model, err := FactorAnalysis(three, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range want {
+		if got := math.Abs(model.Loadings.GetElementByNumberIndex(i, 0).(float64)); math.Abs(got-w) > 1e-5 {
+			t.Errorf("loading %d = %v, want %v", i, got, w)
+		}
+	}
+	if _, err := FactorAnalysis(dt, FactorAnalysisOptions{Correlation: DistanceCorrelation}); err == nil {
+		t.Fatal("expected error for unsupported correlation")
+	}
+}
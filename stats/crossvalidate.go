@@ -0,0 +1,248 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// FitPredictFunc trains a model on one fold's training rows and returns
+// its predictions for the test rows, one per row.
+type FitPredictFunc func(train insyra.IDataTable, trainLabels insyra.IDataList, test insyra.IDataTable) (insyra.IDataList, error)
+
+// CrossValidateOptions configures fold assignment and scoring.
+type CrossValidateOptions struct {
+	// Stratified keeps each fold's class proportions close to the whole
+	// data's.
+	Stratified bool
+	// Groups, if set, keeps all rows sharing a group value in the same fold.
+	// It cannot be combined with Stratified.
+	Groups insyra.IDataList
+	// Score, if set, is applied to each fold's actual labels and
+	// predictions, e.g. a wrapper around ConfusionMatrix or
+	// RegressionMetrics.
+	Score func(actual, predicted insyra.IDataList) (float64, error)
+	// Sampling controls the shuffle before rows or groups are dealt into
+	// folds; set UseSeed for reproducible folds.
+	Sampling insyra.SamplingOptions
+}
+
+// CrossValidationFold is one train/test split and its predictions.
+type CrossValidationFold struct {
+	TrainIndices []int
+	TestIndices  []int
+	Predictions  insyra.IDataList
+	Score        float64 // NaN without a Score function
+}
+
+// CrossValidateResult collects the folds. Predictions holds the
+// out-of-fold prediction of every row in the original row order.
+type CrossValidateResult struct {
+	Folds       []CrossValidationFold
+	Predictions insyra.IDataList
+	MeanScore   float64
+	StdScore    float64
+}
+
+// CrossValidate runs k-fold cross-validation of fitPredictFn. Rows are
+// shuffled with opts.Sampling and dealt into k folds, optionally stratified
+// by label or grouped by opts.Groups; each fold is predicted by a model fit
+// on the other k-1 folds.
+func CrossValidate(dataTable insyra.IDataTable, labels insyra.IDataList, k int, fitPredictFn FitPredictFunc, opts ...CrossValidateOptions) (*CrossValidateResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt CrossValidateOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if dataTable == nil || labels == nil {
+		return nil, errors.New("data table and labels must not be nil")
+	}
+	if fitPredictFn == nil {
+		return nil, errors.New("fitPredictFn must not be nil")
+	}
+	var names []string
+	dataTable.AtomicDo(func(dt *insyra.DataTable) { names = dt.ColNames() })
+	columns, n, err := rawColumnsByName(dataTable, names)
+	if err != nil {
+		return nil, err
+	}
+	labelValues := labels.Data()
+	if len(labelValues) != n {
+		return nil, errors.New("labels length must match row count")
+	}
+	if k < 2 || k > n {
+		return nil, errors.New("k must be between 2 and the number of rows")
+	}
+
+	var foldOf []int
+	switch {
+	case opt.Stratified && opt.Groups != nil:
+		return nil, errors.New("stratified and grouped folds cannot be combined")
+	case opt.Groups != nil:
+		foldOf, err = groupedFolds(opt.Groups.Data(), n, k, opt.Sampling)
+	case opt.Stratified:
+		foldOf, err = stratifiedFolds(labelValues, k, opt.Sampling)
+	default:
+		foldOf = dealFolds(shuffledIndices(n, opt.Sampling), k, make([]int, n), 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CrossValidateResult{Folds: make([]CrossValidationFold, k)}
+	oof := make([]any, n)
+	scores := make([]float64, 0, k)
+	for f := range k {
+		fold := CrossValidationFold{Score: math.NaN()}
+		for i, fi := range foldOf {
+			if fi == f {
+				fold.TestIndices = append(fold.TestIndices, i)
+			} else {
+				fold.TrainIndices = append(fold.TrainIndices, i)
+			}
+		}
+		if len(fold.TestIndices) == 0 || len(fold.TrainIndices) == 0 {
+			return nil, errors.New("a fold is empty; use fewer folds")
+		}
+		train := tableRowSubset(names, columns, fold.TrainIndices)
+		test := tableRowSubset(names, columns, fold.TestIndices)
+		pred, err := fitPredictFn(train, listSubset(labelValues, fold.TrainIndices), test)
+		if err != nil {
+			return nil, err
+		}
+		if pred == nil || pred.Len() != len(fold.TestIndices) {
+			return nil, errors.New("fitPredictFn must return one prediction per test row")
+		}
+		for j, i := range fold.TestIndices {
+			oof[i] = pred.Get(j)
+		}
+		fold.Predictions = pred
+		if opt.Score != nil {
+			score, err := opt.Score(listSubset(labelValues, fold.TestIndices), pred)
+			if err != nil {
+				return nil, err
+			}
+			fold.Score = score
+			scores = append(scores, score)
+		}
+		res.Folds[f] = fold
+	}
+	res.Predictions = insyra.NewDataList(oof...)
+	res.MeanScore, res.StdScore = math.NaN(), math.NaN()
+	if len(scores) > 0 {
+		res.MeanScore = sampleMean(scores)
+		if len(scores) > 1 {
+			res.StdScore = sampleStdDev(scores)
+		}
+	}
+	return res, nil
+}
+
+// shuffledIndices permutes 0..n-1 through DataList.Shuffle so fold
+// assignment follows the same seeding as the rest of insyra's sampling.
+func shuffledIndices(n int, sampling insyra.SamplingOptions) []int {
+	idx := make([]any, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	shuffled := insyra.NewDataList(idx...).Shuffle(sampling).Data()
+	out := make([]int, n)
+	for i, v := range shuffled {
+		out[i] = v.(int)
+	}
+	return out
+}
+
+// dealFolds assigns rows to folds round-robin, starting at fold offset.
+func dealFolds(rows []int, k int, foldOf []int, offset int) []int {
+	for j, i := range rows {
+		foldOf[i] = (offset + j) % k
+	}
+	return foldOf
+}
+
+func stratifiedFolds(labels []any, k int, sampling insyra.SamplingOptions) ([]int, error) {
+	byClass := map[string][]int{}
+	var order []any
+	for _, i := range shuffledIndices(len(labels), sampling) {
+		v := labels[i]
+		if v == nil {
+			return nil, errors.New("labels must not contain nil values")
+		}
+		key := classKey(v)
+		if _, ok := byClass[key]; !ok {
+			order = append(order, v)
+		}
+		byClass[key] = append(byClass[key], i)
+	}
+	sortClassLevels(order)
+	// Continue the round-robin across classes so fold sizes stay within one.
+	foldOf := make([]int, len(labels))
+	offset := 0
+	for _, v := range order {
+		rows := byClass[classKey(v)]
+		dealFolds(rows, k, foldOf, offset)
+		offset += len(rows)
+	}
+	return foldOf, nil
+}
+
+// groupedFolds assigns whole groups, largest first, to the fold with the
+// fewest rows so far (scikit-learn's GroupKFold, after a seeded shuffle
+// that breaks ties between equal-sized groups).
+func groupedFolds(groups []any, n, k int, sampling insyra.SamplingOptions) ([]int, error) {
+	if len(groups) != n {
+		return nil, errors.New("groups length must match row count")
+	}
+	members := map[string][]int{}
+	var keys []string
+	for _, i := range shuffledIndices(n, sampling) {
+		if groups[i] == nil {
+			return nil, errors.New("groups must not contain nil values")
+		}
+		key := classKey(groups[i])
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], i)
+	}
+	if len(keys) < k {
+		return nil, errors.New("k must not exceed the number of groups")
+	}
+	sort.SliceStable(keys, func(a, b int) bool { return len(members[keys[a]]) > len(members[keys[b]]) })
+	foldOf := make([]int, n)
+	sizes := make([]int, k)
+	for _, key := range keys {
+		f := 0
+		for j := range sizes {
+			if sizes[j] < sizes[f] {
+				f = j
+			}
+		}
+		for _, i := range members[key] {
+			foldOf[i] = f
+		}
+		sizes[f] += len(members[key])
+	}
+	return foldOf, nil
+}
+
+func tableRowSubset(names []string, columns [][]any, rows []int) *insyra.DataTable {
+	cols := make([]*insyra.DataList, len(names))
+	for j, name := range names {
+		cols[j] = listSubset(columns[j], rows).SetName(name)
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+func listSubset(values []any, rows []int) *insyra.DataList {
+	out := make([]any, len(rows))
+	for j, i := range rows {
+		out[j] = values[i]
+	}
+	return insyra.NewDataList(out...)
+}
@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	internalcluster "github.com/HazelnutParadise/insyra/stats/internal/clustering"
+)
+
+// HDBSCANOptions configures HDBSCAN. MinClusterSize defaults to minPts.
+type HDBSCANOptions struct {
+	MinClusterSize int
+}
+
+// HDBSCANResult labels noise 0 and clusters 1..k. Probabilities is each
+// row's membership strength in its cluster (0 for noise) and Stability the
+// excess-of-mass stability of each selected cluster.
+type HDBSCANResult struct {
+	Cluster       []int
+	Probabilities []float64
+	Stability     []float64
+	CoreDistance  []float64
+}
+
+// OPTICSResult is a density-based ordering. Order lists 0-based row
+// indices in processing order; Reachability and CoreDistance are indexed by
+// row and are +Inf where undefined.
+type OPTICSResult struct {
+	Order        []int
+	Reachability []float64
+	CoreDistance []float64
+	MinPts       int
+	Eps          float64
+}
+
+// HDBSCAN clusters data of varying density without a distance threshold.
+// minPts sets the core-distance neighbourhood (counting the point itself,
+// as in R's dbscan::hdbscan).
+func HDBSCAN(dataTable insyra.IDataTable, minPts int, opts ...HDBSCANOptions) (*HDBSCANResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	minClusterSize := minPts
+	if len(opts) == 1 && opts[0].MinClusterSize > 0 {
+		minClusterSize = opts[0].MinClusterSize
+	}
+	got, err := internalcluster.HDBSCAN(data, minPts, minClusterSize)
+	if err != nil {
+		return nil, err
+	}
+	return &HDBSCANResult{
+		Cluster:       got.Cluster,
+		Probabilities: got.Probabilities,
+		Stability:     got.Stability,
+		CoreDistance:  got.CoreDistance,
+	}, nil
+}
+
+// OPTICS computes the OPTICS ordering with neighbourhood radius eps
+// (0 or +Inf for unbounded) and minPts.
+func OPTICS(dataTable insyra.IDataTable, eps float64, minPts int) (*OPTICSResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if eps == 0 {
+		eps = math.Inf(1)
+	}
+	got, err := internalcluster.OPTICS(data, eps, minPts)
+	if err != nil {
+		return nil, err
+	}
+	return &OPTICSResult{
+		Order:        got.Order,
+		Reachability: got.Reachability,
+		CoreDistance: got.CoreDistance,
+		MinPts:       minPts,
+		Eps:          eps,
+	}, nil
+}
+
+// ExtractDBSCAN returns the DBSCAN clustering at radius epsCl <= Eps from
+// the ordering (noise 0, clusters 1..k), as R's dbscan::extractDBSCAN.
+func (r *OPTICSResult) ExtractDBSCAN(epsCl float64) ([]int, error) {
+	if r == nil {
+		return nil, errors.New("result must not be nil")
+	}
+	if epsCl <= 0 || epsCl > r.Eps {
+		return nil, errors.New("epsCl must be in (0, Eps]")
+	}
+	return internalcluster.ExtractDBSCAN(&internalcluster.OPTICSResult{
+		Order:        r.Order,
+		Reachability: r.Reachability,
+		CoreDistance: r.CoreDistance,
+	}, epsCl), nil
+}
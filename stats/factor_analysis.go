@@ -103,6 +103,13 @@ type FactorAnalysisOptions struct {
 	MaxIter    int     // Optional: default 100
 	MinErr     float64 // Optional: default 0.001 (R's min.err)
 
+	// Correlation selects the matrix that is factored: PearsonCorrelation
+	// (default), or PolychoricCorrelation / TetrachoricCorrelation for
+	// ordinal or binary items (psych::fa with cor = "poly" / "tet"). The
+	// pairwise matrix is smoothed as psych::cor.smooth does when it is not
+	// positive definite.
+	Correlation CorrelationMethod
+
 	// OptimFactr controls the L-BFGS-B convergence tolerance used by ML
 	// and MINRES factor extraction: the optimizer terminates when the
 	// relative function change drops below OptimFactr * machine epsilon.
@@ -211,6 +218,12 @@ func (r *FactorAnalysisResult) Show(startEndRange ...any) {
 // FactorModel holds the factor analysis model
 type FactorModel struct {
 	FactorAnalysisResult
+
+	// Training standardisation and score weights, kept for Score.
+	means        []float64
+	sds          []float64
+	scoreWeights *mat.Dense
+	factorNames  []string
 }
 
 // -------------------------
@@ -273,6 +286,12 @@ func normalizeFactorAnalysisOptions(opt FactorAnalysisOptions) (FactorAnalysisOp
 		return opt, fmt.Errorf("unsupported factor extraction method: %s", opt.Extraction)
 	}
 
+	switch opt.Correlation {
+	case PearsonCorrelation, PolychoricCorrelation, TetrachoricCorrelation:
+	default:
+		return opt, errors.New("factor analysis supports Pearson, polychoric and tetrachoric correlations")
+	}
+
 	if opt.Rotation.Method == "" {
 		opt.Rotation.Method = defaults.Rotation.Method
 	}
@@ -456,6 +475,18 @@ func FactorAnalysis(dt insyra.IDataTable, opt FactorAnalysisOptions) (*FactorMod
 		rowNames = newRowNames
 	}
 
+	var ordinalCorr [][]float64
+	if opt.Correlation != PearsonCorrelation {
+		cols := make([][]float64, colNum)
+		for j := range cols {
+			cols[j] = mat.Col(nil, j, data)
+		}
+		ordinalCorr, err = polychoricMatrix(cols, opt.Correlation == TetrachoricCorrelation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute correlation matrix: %w", err)
+		}
+	}
+
 	// Step 2: Standardize data (always performed for factor analysis)
 	means = make([]float64, colNum)
 	sds = make([]float64, colNum)
@@ -476,7 +507,15 @@ func FactorAnalysis(dt insyra.IDataTable, opt FactorAnalysisOptions) (*FactorMod
 	var corrMatrix *mat.SymDense
 	var corrForAdequacy *mat.SymDense
 	corrMatrix = mat.NewSymDense(colNum, nil)
-	stat.CorrelationMatrix(corrMatrix, data, nil)
+	if ordinalCorr != nil {
+		for i := range colNum {
+			for j := i; j < colNum; j++ {
+				corrMatrix.SetSym(i, j, ordinalCorr[i][j])
+			}
+		}
+	} else {
+		stat.CorrelationMatrix(corrMatrix, data, nil)
+	}
 	corrForAdequacy = corrMatrix
 	if corrForAdequacy == nil {
 		corrForAdequacy = mat.NewSymDense(colNum, nil)
@@ -959,7 +998,42 @@ func FactorAnalysis(dt insyra.IDataTable, opt FactorAnalysisOptions) (*FactorMod
 		result.ScoreCovariance = scoreCovTable
 	}
 
-	return &FactorModel{FactorAnalysisResult: result}, nil
+	return &FactorModel{
+		FactorAnalysisResult: result,
+		means:                means,
+		sds:                  sds,
+		scoreWeights:         scoreWeights,
+		factorNames:          factorColNames,
+	}, nil
+}
+
+// Score computes factor scores for new observations with the fitted score
+// coefficients. The columns of dt must be in the training order; they are
+// standardised with the training means and standard deviations, so Score
+// on the complete training rows reproduces Scores.
+func (m *FactorModel) Score(dt insyra.IDataTable) (insyra.IDataTable, error) {
+	if m == nil {
+		return nil, errors.New("nil FactorModel")
+	}
+	if m.scoreWeights == nil {
+		return nil, errors.New("factor scores were not computed for this model")
+	}
+	data, rowNames, err := numericMatrixFromTable(dt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data[0]) != len(m.means) {
+		return nil, fmt.Errorf("data has %d columns, factor model was fitted on %d", len(data[0]), len(m.means))
+	}
+	z := mat.NewDense(len(data), len(m.means), nil)
+	for i, row := range data {
+		for j, v := range row {
+			z.Set(i, j, (v-m.means[j])/m.sds[j])
+		}
+	}
+	var scores mat.Dense
+	scores.Mul(z, m.scoreWeights)
+	return matrixToDataTableWithNames(&scores, tableNameFactorScores, m.factorNames, rowNames), nil
 }
 
 // computeSigma computes the reproduced correlation matrix: Sigma = L * Phi * L^T + U
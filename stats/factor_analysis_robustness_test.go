@@ -319,3 +319,58 @@ func TestFactorAnalysis_RejectsZeroFixedK(t *testing.T) {
 		t.Fatalf("expected error for FixedK = 0")
 	}
 }
+
+func TestFactorModelScoresNewData(t *testing.T) {
+	rows := make([][]float64, 60)
+	z := seededNormals(17, 6*len(rows))
+	for i := range rows {
+		f1, f2 := z[6*i], z[6*i+1]
+		rows[i] = []float64{
+			0.8*f1 + 0.4*z[6*i+2],
+			0.7*f1 + 0.5*z[6*i+3],
+			0.8*f2 + 0.4*z[6*i+4],
+			0.7*f2 + 0.5*z[6*i+5],
+		}
+	}
+	opt := stats.DefaultFactorAnalysisOptions()
+	opt.Count = stats.FactorCountSpec{Method: stats.FactorCountFixed, FixedK: 2}
+	opt.Rotation.Method = stats.FactorRotationVarimax
+	model, err := stats.FactorAnalysis(tableFromMatrix(rows), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := model.Score(tableFromMatrix(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tableToFloatMatrix(model.Scores.(*insyra.DataTable))
+	have := tableToFloatMatrix(got.(*insyra.DataTable))
+	for i := range want {
+		for j := range want[i] {
+			if !floatAlmostEqual(have[i][j], want[i][j], 1e-10) {
+				t.Fatalf("score[%d][%d] = %v, want %v", i, j, have[i][j], want[i][j])
+			}
+		}
+	}
+
+	// A single new row is scored against the training standardisation.
+	one, err := model.Score(tableFromMatrix(rows[:1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := one.Size(); r != 1 || c != 2 {
+		t.Fatalf("scored table is %dx%d", r, c)
+	}
+	if _, err := model.Score(tableFromMatrix([][]float64{{1, 2, 3}})); err == nil {
+		t.Errorf("expected error for mismatched column count")
+	}
+
+	opt.Scoring = stats.FactorScoreNone
+	unscored, err := stats.FactorAnalysis(tableFromMatrix(rows), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unscored.Score(tableFromMatrix(rows)); err == nil {
+		t.Errorf("expected error when scoring was disabled")
+	}
+}
@@ -0,0 +1,238 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// RandomForestOptions configures a random forest. Zero values select the
+// defaults noted on each field; tree options mean the same as in
+// DecisionTreeOptions.
+type RandomForestOptions struct {
+	// NTrees is the number of trees (default 100).
+	NTrees          int
+	MaxDepth        int
+	MinSamplesSplit int
+	MinSamplesLeaf  int
+	// MaxFeatures is the number of features tried at each split (default
+	// sqrt(p) for classification and p/3 for regression).
+	MaxFeatures int
+	Criterion   TreeCriterion
+	// Seed and UseSeed make the bootstrap samples and feature subsets
+	// reproducible regardless of how many cores are used.
+	Seed    uint64
+	UseSeed bool
+}
+
+// RandomForestModel is a fitted forest. OOBError is the out-of-bag
+// misclassification rate for classifiers and the out-of-bag mean squared
+// error for regressors; it is NaN if no row was ever out of bag.
+type RandomForestModel struct {
+	FeatureNames       []string
+	FeatureImportances []float64
+	Classes            insyra.IDataList
+	NTrees             int
+	OOBError           float64
+
+	trees  []*cartTree
+	schema *treeSchema
+}
+
+// RandomForestClassifier trains a forest of classification trees on
+// bootstrap samples, building the trees in parallel.
+func RandomForestClassifier(trainData insyra.IDataTable, trainLabels insyra.IDataList, opts ...RandomForestOptions) (*RandomForestModel, error) {
+	opt, err := parseRandomForestOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newTreeTrainingData(trainData, trainLabels, true)
+	if err != nil {
+		return nil, err
+	}
+	return fitRandomForest(data, opt)
+}
+
+// RandomForestRegressor trains a forest of regression trees on bootstrap
+// samples, building the trees in parallel.
+func RandomForestRegressor(trainData insyra.IDataTable, trainTargets insyra.IDataList, opts ...RandomForestOptions) (*RandomForestModel, error) {
+	opt, err := parseRandomForestOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newTreeTrainingData(trainData, trainTargets, false)
+	if err != nil {
+		return nil, err
+	}
+	return fitRandomForest(data, opt)
+}
+
+// Predict returns the majority vote of the averaged class probabilities for
+// a classifier, or the mean prediction for a regressor.
+func (m *RandomForestModel) Predict(testData insyra.IDataTable) (insyra.IDataList, error) {
+	if m == nil || len(m.trees) == 0 {
+		return nil, errors.New("model is not fitted")
+	}
+	x, err := m.schema.encode(testData)
+	if err != nil {
+		return nil, err
+	}
+	out := insyra.NewDataList()
+	for i := range x[0] {
+		value := m.predictRow(x, i)
+		if m.schema.classification {
+			out.Append(m.schema.classes[argmax(value)])
+		} else {
+			out.Append(value[0])
+		}
+	}
+	return out, nil
+}
+
+// PredictProba returns the class probabilities averaged over all trees.
+func (m *RandomForestModel) PredictProba(testData insyra.IDataTable) (insyra.IDataTable, error) {
+	if m == nil || len(m.trees) == 0 {
+		return nil, errors.New("model is not fitted")
+	}
+	if !m.schema.classification {
+		return nil, errors.New("probabilities are only available for classifiers")
+	}
+	x, err := m.schema.encode(testData)
+	if err != nil {
+		return nil, err
+	}
+	probs := make([][]float64, len(x[0]))
+	for i := range probs {
+		probs[i] = m.predictRow(x, i)
+	}
+	return matrixToNamedDataTable(probs, m.schema.classNames()), nil
+}
+
+func (m *RandomForestModel) predictRow(x [][]float64, row int) []float64 {
+	var sum []float64
+	for _, tree := range m.trees {
+		value := tree.predict(x, row)
+		if sum == nil {
+			sum = make([]float64, len(value))
+		}
+		for j, v := range value {
+			sum[j] += v
+		}
+	}
+	for j := range sum {
+		sum[j] /= float64(len(m.trees))
+	}
+	return sum
+}
+
+func parseRandomForestOptions(opts []RandomForestOptions) (RandomForestOptions, error) {
+	if len(opts) > 1 {
+		return RandomForestOptions{}, errors.New("opts accepts at most one value")
+	}
+	opt := RandomForestOptions{}
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.NTrees < 0 {
+		return opt, errors.New("NTrees must not be negative")
+	}
+	if opt.NTrees == 0 {
+		opt.NTrees = 100
+	}
+	return opt, nil
+}
+
+func fitRandomForest(data *treeTrainingData, opt RandomForestOptions) (*RandomForestModel, error) {
+	maxFeatures := opt.MaxFeatures
+	if maxFeatures == 0 {
+		p := float64(len(data.x))
+		if data.k > 0 {
+			maxFeatures = int(math.Sqrt(p))
+		} else {
+			maxFeatures = int(p / 3)
+		}
+		maxFeatures = max(maxFeatures, 1)
+	}
+	params, err := newCARTParams(DecisionTreeOptions{
+		MaxDepth:        opt.MaxDepth,
+		MinSamplesSplit: opt.MinSamplesSplit,
+		MinSamplesLeaf:  opt.MinSamplesLeaf,
+		MaxFeatures:     maxFeatures,
+		Criterion:       opt.Criterion,
+	}, data)
+	if err != nil {
+		return nil, err
+	}
+	seed := resolveSeed(opt.Seed, opt.UseSeed)
+
+	trees := make([]*cartTree, opt.NTrees)
+	inBag := make([][]bool, opt.NTrees)
+	runParallelChunks(opt.NTrees, func(start, end int) {
+		for t := start; t < end; t++ {
+			rng := replicateRNG(seed, uint64(t))
+			rows := make([]int, data.n)
+			inBag[t] = make([]bool, data.n)
+			for i := range rows {
+				rows[i] = rng.IntN(data.n)
+				inBag[t][rows[i]] = true
+			}
+			trees[t] = growCART(data, rows, params, rng)
+		}
+	})
+
+	importance := make([]float64, len(data.x))
+	for _, tree := range trees {
+		// Average the per-tree normalised importances, as scikit-learn does.
+		for j, v := range normalizedImportances(tree.importance) {
+			importance[j] += v
+		}
+	}
+
+	return &RandomForestModel{
+		FeatureNames:       append([]string(nil), data.schema.names...),
+		FeatureImportances: normalizedImportances(importance),
+		Classes:            data.schema.classList(),
+		NTrees:             opt.NTrees,
+		OOBError:           oobError(data, trees, inBag),
+		trees:              trees,
+		schema:             data.schema,
+	}, nil
+}
+
+// oobError aggregates, for every training row, the predictions of the trees
+// whose bootstrap sample left it out.
+func oobError(data *treeTrainingData, trees []*cartTree, inBag [][]bool) float64 {
+	width := max(data.k, 1)
+	loss, scored := 0.0, 0
+	sum := make([]float64, width)
+	for i := 0; i < data.n; i++ {
+		clear(sum)
+		votes := 0
+		for t, tree := range trees {
+			if inBag[t][i] {
+				continue
+			}
+			for j, v := range tree.predict(data.x, i) {
+				sum[j] += v
+			}
+			votes++
+		}
+		if votes == 0 {
+			continue
+		}
+		scored++
+		if data.k > 0 {
+			if argmax(sum) != int(data.y[i]) {
+				loss++
+			}
+		} else {
+			d := sum[0]/float64(votes) - data.y[i]
+			loss += d * d
+		}
+	}
+	if scored == 0 {
+		return math.NaN()
+	}
+	return loss / float64(scored)
+}
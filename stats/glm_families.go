@@ -108,3 +108,144 @@ func (gaussianFamily) logLikContrib(y, mu, w float64) float64 {
 func (gaussianFamily) initMu(y, w float64) float64      { return y }
 func (gaussianFamily) dispersionFixed() (float64, bool) { return math.NaN(), false }
 func (gaussianFamily) name() string                     { return string(Gaussian) }
+
+// glmDispersedFamily is implemented by families whose log-likelihood
+// depends on the dispersion. Like R, GLM evaluates it at deviance / Σw.
+type glmDispersedFamily interface {
+	logLikDispersion(y, mu, w, phi float64) float64
+}
+
+type gammaFamily struct{}
+
+func (gammaFamily) canonicalLink() glmLink { return inverseLink{} }
+
+func (gammaFamily) variance(mu float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	return mu * mu
+}
+
+func (gammaFamily) devianceResidualSq(y, mu, w float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	return 2 * w * (-math.Log(y/mu) + (y-mu)/mu)
+}
+
+func (f gammaFamily) logLikContrib(y, mu, w float64) float64 {
+	return f.logLikDispersion(y, mu, w, 1)
+}
+
+// logLikDispersion is the gamma density with shape 1/phi and scale mu*phi.
+func (gammaFamily) logLikDispersion(y, mu, w, phi float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	shape := 1 / phi
+	scale := mu * phi
+	lg, _ := math.Lgamma(shape)
+	return w * ((shape-1)*math.Log(y) - y/scale - lg - shape*math.Log(scale))
+}
+
+func (gammaFamily) initMu(y, w float64) float64      { return y }
+func (gammaFamily) dispersionFixed() (float64, bool) { return math.NaN(), false }
+func (gammaFamily) name() string                     { return string(Gamma) }
+
+type inverseGaussianFamily struct{}
+
+func (inverseGaussianFamily) canonicalLink() glmLink { return inverseSquaredLink{} }
+
+func (inverseGaussianFamily) variance(mu float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	return mu * mu * mu
+}
+
+func (inverseGaussianFamily) devianceResidualSq(y, mu, w float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	d := y - mu
+	return w * d * d / (y * mu * mu)
+}
+
+func (f inverseGaussianFamily) logLikContrib(y, mu, w float64) float64 {
+	return f.logLikDispersion(y, mu, w, 1)
+}
+
+func (inverseGaussianFamily) logLikDispersion(y, mu, w, phi float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	d := y - mu
+	return w * (-0.5*math.Log(2*math.Pi*phi*y*y*y) - d*d/(2*phi*y*mu*mu))
+}
+
+func (inverseGaussianFamily) initMu(y, w float64) float64      { return y }
+func (inverseGaussianFamily) dispersionFixed() (float64, bool) { return math.NaN(), false }
+func (inverseGaussianFamily) name() string                     { return string(InverseGaussian) }
+
+// negativeBinomialFamily is the NB2 family with variance mu + mu²/theta. Its
+// default link is log, as in MASS::glm.nb, rather than the canonical one,
+// which depends on theta.
+type negativeBinomialFamily struct {
+	theta float64
+}
+
+func (negativeBinomialFamily) canonicalLink() glmLink { return logLink{} }
+
+func (f negativeBinomialFamily) variance(mu float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	return mu + mu*mu/f.theta
+}
+
+func (f negativeBinomialFamily) devianceResidualSq(y, mu, w float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	th := f.theta
+	return 2 * w * (y*math.Log(math.Max(y, 1)/mu) - (y+th)*math.Log((y+th)/(mu+th)))
+}
+
+func (f negativeBinomialFamily) logLikContrib(y, mu, w float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	th := f.theta
+	a, _ := math.Lgamma(th + y)
+	b, _ := math.Lgamma(th)
+	c, _ := math.Lgamma(y + 1)
+	ll := a - b - c + th*math.Log(th) - (th+y)*math.Log(th+mu)
+	if y > 0 {
+		ll += y * math.Log(mu)
+	}
+	return w * ll
+}
+
+func (negativeBinomialFamily) initMu(y, w float64) float64 {
+	if y <= 0 {
+		return 1.0 / 6
+	}
+	return y
+}
+
+func (negativeBinomialFamily) dispersionFixed() (float64, bool) { return 1, true }
+func (negativeBinomialFamily) name() string                     { return string(NegativeBinomial) }
+
+// tweedieFamily has variance mu^power with 1 < power < 2, the compound
+// Poisson-gamma range with a point mass at zero. Its density has no closed
+// form, so the log-likelihood is NaN (R's statmod::tweedie reports no AIC
+// either). The default link is log.
+type tweedieFamily struct {
+	power float64
+}
+
+func (tweedieFamily) canonicalLink() glmLink { return logLink{} }
+
+func (f tweedieFamily) variance(mu float64) float64 {
+	return math.Pow(math.Max(mu, glmProbEps), f.power)
+}
+
+func (f tweedieFamily) devianceResidualSq(y, mu, w float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	p := f.power
+	return 2 * w * (math.Pow(y, 2-p)/((1-p)*(2-p)) - y*math.Pow(mu, 1-p)/(1-p) + math.Pow(mu, 2-p)/(2-p))
+}
+
+func (tweedieFamily) logLikContrib(y, mu, w float64) float64 { return math.NaN() }
+
+func (tweedieFamily) initMu(y, w float64) float64 {
+	if y <= 0 {
+		return 0.1
+	}
+	return y
+}
+
+func (tweedieFamily) dispersionFixed() (float64, bool) { return math.NaN(), false }
+func (tweedieFamily) name() string                     { return string(Tweedie) }
@@ -24,3 +24,33 @@ func TestGLMFamiliesCoreValues(t *testing.T) {
 		t.Fatalf("gaussian deviance = %.15g", got)
 	}
 }
+
+func TestGLMPositiveFamiliesDeviance(t *testing.T) {
+	if got, want := (gammaFamily{}).devianceResidualSq(3, 2, 1), 2*(-math.Log(1.5)+0.5); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("gamma deviance = %.15g, want %.15g", got, want)
+	}
+	if got, want := (inverseGaussianFamily{}).devianceResidualSq(3, 2, 1), 1.0/12; math.Abs(got-want) > 1e-12 {
+		t.Fatalf("inverse gaussian deviance = %.15g, want %.15g", got, want)
+	}
+	// A huge theta approaches the Poisson family.
+	nb := negativeBinomialFamily{theta: 1e7}
+	if got, want := nb.devianceResidualSq(3, 2.5, 1), (poissonFamily{}).devianceResidualSq(3, 2.5, 1); math.Abs(got-want) > 1e-6 {
+		t.Fatalf("negative binomial deviance = %.15g, want %.15g", got, want)
+	}
+	if got, want := nb.logLikContrib(3, 2.5, 1), (poissonFamily{}).logLikContrib(3, 2.5, 1); math.Abs(got-want) > 1e-6 {
+		t.Fatalf("negative binomial logLik = %.15g, want %.15g", got, want)
+	}
+	// Tweedie deviance tends to Poisson and gamma at the ends of (1, 2).
+	if got, want := (tweedieFamily{power: 1 + 1e-7}).devianceResidualSq(3, 2.5, 1), (poissonFamily{}).devianceResidualSq(3, 2.5, 1); math.Abs(got-want) > 1e-5 {
+		t.Fatalf("tweedie p→1 deviance = %.15g, want %.15g", got, want)
+	}
+	if got, want := (tweedieFamily{power: 2 - 1e-7}).devianceResidualSq(3, 2.5, 1), (gammaFamily{}).devianceResidualSq(3, 2.5, 1); math.Abs(got-want) > 1e-5 {
+		t.Fatalf("tweedie p→2 deviance = %.15g, want %.15g", got, want)
+	}
+	if got := trigamma(1); math.Abs(got-math.Pi*math.Pi/6) > 1e-12 {
+		t.Fatalf("trigamma(1) = %.15g", got)
+	}
+	if got := trigamma(0.5); math.Abs(got-math.Pi*math.Pi/2) > 1e-12 {
+		t.Fatalf("trigamma(0.5) = %.15g", got)
+	}
+}
@@ -93,7 +93,8 @@ func fitIRLS(X *mat.Dense, y []float64, fam glmFamily, link glmLink, opts irlsOp
 	iterations := 0
 	for iter := 1; iter <= maxIter; iter++ {
 		for i := range n {
-			dmudeta := math.Max(math.Abs(link.muEta(eta[i])), glmSmall)
+			// Keep the sign of dmu/deta: the inverse links are decreasing.
+			dmudeta := math.Copysign(math.Max(math.Abs(link.muEta(eta[i])), glmSmall), link.muEta(eta[i]))
 			v := math.Max(fam.variance(mu[i]), glmSmall)
 			workingW[i] = weights[i] * dmudeta * dmudeta / v
 			z[i] = (eta[i] - offset[i]) + (y[i]-mu[i])/dmudeta
@@ -297,3 +298,86 @@ func detectSeparation(fam glmFamily, beta []float64, covUnscaled [][]float64) bo
 	}
 	return false
 }
+
+// newtonObjective evaluates a log-likelihood together with its score vector
+// and observed information matrix at beta.
+type newtonObjective func(beta []float64) (logLik float64, score []float64, info *mat.Dense, err error)
+
+// fitNewton maximises a concave log-likelihood by Newton-Raphson with step
+// halving. It uses the same deviance-based convergence rule as fitIRLS
+// (deviance = -2 logLik) and reports the inverse information as
+// covUnscaled. If 20 halvings do not improve the log-likelihood it stops
+// at the current point with converged = false.
+func fitNewton(start []float64, obj newtonObjective, opts irlsOptions) (*irlsFit, error) {
+	maxIter := opts.maxIter
+	if maxIter <= 0 {
+		maxIter = defaultIRLSMaxIter
+	}
+	tol := opts.tolerance
+	if tol <= 0 {
+		tol = defaultIRLSTolerance
+	}
+	k := len(start)
+	beta := append([]float64(nil), start...)
+	ll, score, info, err := obj(beta)
+	if err != nil {
+		return nil, err
+	}
+	converged := false
+	iterations := 0
+	for iter := 1; iter <= maxIter; iter++ {
+		var step mat.VecDense
+		if err := step.SolveVec(info, mat.NewVecDense(k, score)); err != nil {
+			return nil, fmt.Errorf("information matrix is singular: %w", err)
+		}
+		devOld := -2 * ll
+		scale := 1.0
+		var next []float64
+		var llNext float64
+		var scoreNext []float64
+		var infoNext *mat.Dense
+		accepted := false
+		for range 20 {
+			next = make([]float64, k)
+			for j := range k {
+				next[j] = beta[j] + scale*step.AtVec(j)
+			}
+			llNext, scoreNext, infoNext, err = obj(next)
+			if err == nil && !math.IsNaN(llNext) && llNext >= ll-1e-10*math.Abs(ll) {
+				accepted = true
+				break
+			}
+			scale /= 2
+		}
+		if !accepted {
+			// Step halving failed: stop at the last accepted point and
+			// report non-convergence, as fitIRLS does at maxIter.
+			break
+		}
+		beta, ll, score, info = next, llNext, scoreNext, infoNext
+		iterations = iter
+		dev := -2 * ll
+		if math.Abs(dev-devOld)/(math.Abs(dev)+0.1) < tol {
+			converged = true
+			break
+		}
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(info); err != nil {
+		return nil, fmt.Errorf("information matrix is singular: %w", err)
+	}
+	cov := make([][]float64, k)
+	for i := range k {
+		cov[i] = make([]float64, k)
+		for j := range k {
+			cov[i][j] = inv.At(i, j)
+		}
+	}
+	return &irlsFit{
+		beta:        beta,
+		covUnscaled: cov,
+		deviance:    -2 * ll,
+		iterations:  iterations,
+		converged:   converged,
+	}, nil
+}
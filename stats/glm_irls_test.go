@@ -70,3 +70,20 @@ func TestSolveWeightedNormalEquations(t *testing.T) {
 		t.Fatalf("beta = %v, want [1 2]", beta)
 	}
 }
+
+func TestFitNewtonStepHalvingFailure(t *testing.T) {
+	// The information has the wrong sign, so every Newton step points
+	// downhill and no amount of halving improves the log-likelihood.
+	obj := func(beta []float64) (float64, []float64, *mat.Dense, error) {
+		d := beta[0] - 1
+		return -d * d, []float64{-2 * d}, mat.NewDense(1, 1, []float64{-2}), nil
+	}
+	fit, err := fitNewton([]float64{3}, obj, irlsOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fit.converged || fit.beta[0] != 3 || fit.deviance != 8 || fit.iterations != 0 {
+		t.Fatalf("converged = %v, beta = %v, deviance = %v after %d iterations",
+			fit.converged, fit.beta, fit.deviance, fit.iterations)
+	}
+}
@@ -66,6 +66,43 @@ func (identityLink) mu(eta float64) float64 { return eta }
 func (identityLink) muEta(float64) float64  { return 1 }
 func (identityLink) name() string           { return string(Identity) }
 
+// inverseLink and inverseSquaredLink need a positive linear predictor; a
+// non-positive eta is clamped so IRLS can step back into the valid region.
+type inverseLink struct{}
+
+func (inverseLink) eta(mu float64) float64 {
+	return 1 / math.Max(mu, glmProbEps)
+}
+
+func (inverseLink) mu(eta float64) float64 {
+	return 1 / math.Max(eta, glmSmall)
+}
+
+func (inverseLink) muEta(eta float64) float64 {
+	eta = math.Max(eta, glmSmall)
+	return -1 / (eta * eta)
+}
+
+func (inverseLink) name() string { return string(Inverse) }
+
+type inverseSquaredLink struct{}
+
+func (inverseSquaredLink) eta(mu float64) float64 {
+	mu = math.Max(mu, glmProbEps)
+	return 1 / (mu * mu)
+}
+
+func (inverseSquaredLink) mu(eta float64) float64 {
+	return 1 / math.Sqrt(math.Max(eta, glmSmall))
+}
+
+func (inverseSquaredLink) muEta(eta float64) float64 {
+	eta = math.Max(eta, glmSmall)
+	return -0.5 / (eta * math.Sqrt(eta))
+}
+
+func (inverseSquaredLink) name() string { return string(InverseSquared) }
+
 type probitLink struct{}  // TODO: implement in a follow-up issue.
 type cloglogLink struct{} // TODO: implement in a follow-up issue.
 
@@ -27,3 +27,20 @@ func TestGLMLinksRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestGLMInverseLinks(t *testing.T) {
+	for _, link := range []glmLink{inverseLink{}, inverseSquaredLink{}} {
+		t.Run(link.name(), func(t *testing.T) {
+			mu := 1.75
+			eta := link.eta(mu)
+			if got := link.mu(eta); math.Abs(got-mu) > 1e-12 {
+				t.Fatalf("round trip = %.15g, want %.15g", got, mu)
+			}
+			h := 1e-6
+			want := (link.mu(eta+h) - link.mu(eta-h)) / (2 * h)
+			if got := link.muEta(eta); math.Abs(got-want) > 1e-6 {
+				t.Fatalf("muEta = %.15g, want %.15g", got, want)
+			}
+		})
+	}
+}
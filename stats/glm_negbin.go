@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/mathext"
+)
+
+const (
+	negBinMaxIter   = 25
+	negBinTolerance = 1e-8
+)
+
+// fitNegativeBinomial estimates theta by maximum likelihood, alternating
+// IRLS for the coefficients with a Newton step for theta as MASS::glm.nb
+// does. It starts from a Poisson fit and returns the final IRLS fit with
+// theta and its standard error.
+func fitNegativeBinomial(X *mat.Dense, y []float64, link glmLink, opts irlsOptions, weights []float64) (*irlsFit, float64, float64, error) {
+	fit, err := fitIRLS(X, y, poissonFamily{}, link, opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	theta, err := negBinThetaML(y, fit.mu, weights, math.NaN())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	_, k := X.Dims()
+	d1 := math.Sqrt(2 * math.Max(1, float64(len(y)-k)))
+	ll := glmLogLik(y, fit.mu, weights, negativeBinomialFamily{theta: theta}, 1)
+	llOld := ll + 2*d1
+	del := 1.0
+	for iter := 0; iter < negBinMaxIter && math.Abs(llOld-ll)/d1+math.Abs(del) > negBinTolerance; iter++ {
+		fit, err = fitIRLS(X, y, negativeBinomialFamily{theta: theta}, link, opts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		prev := theta
+		if theta, err = negBinThetaML(y, fit.mu, weights, theta); err != nil {
+			return nil, 0, 0, err
+		}
+		del = prev - theta
+		llOld = ll
+		ll = glmLogLik(y, fit.mu, weights, negativeBinomialFamily{theta: theta}, 1)
+	}
+	fit, err = fitIRLS(X, y, negativeBinomialFamily{theta: theta}, link, opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	_, info := negBinThetaScore(y, fit.mu, weights, theta)
+	return fit, theta, 1 / math.Sqrt(info), nil
+}
+
+// negBinThetaML maximises the negative binomial likelihood over theta for
+// fixed means by Newton's method (MASS::theta.ml). A NaN start uses the
+// moment estimate n / Σ w(y/mu - 1)².
+func negBinThetaML(y, mu, weights []float64, start float64) (float64, error) {
+	theta := start
+	if math.IsNaN(theta) {
+		n, s := 0.0, 0.0
+		for i := range y {
+			d := y[i]/mu[i] - 1
+			n += weights[i]
+			s += weights[i] * d * d
+		}
+		theta = n / s
+	}
+	for range negBinMaxIter {
+		score, info := negBinThetaScore(y, mu, weights, theta)
+		del := score / info
+		theta = math.Abs(theta + del)
+		if math.IsNaN(theta) || math.IsInf(theta, 0) {
+			break
+		}
+		if math.Abs(del) <= 1.220703e-4 {
+			return theta, nil
+		}
+	}
+	if math.IsNaN(theta) || math.IsInf(theta, 0) || theta <= 0 {
+		return 0, errors.New("negative binomial theta did not converge; the data may not be overdispersed")
+	}
+	return theta, nil
+}
+
+// negBinThetaScore returns the derivative of the log-likelihood in theta
+// and the observed information.
+func negBinThetaScore(y, mu, weights []float64, theta float64) (score, info float64) {
+	for i := range y {
+		w, yi, m := weights[i], y[i], mu[i]
+		score += w * (mathext.Digamma(theta+yi) - mathext.Digamma(theta) + math.Log(theta) + 1 - math.Log(theta+m) - (yi+theta)/(m+theta))
+		info += w * (-trigamma(theta+yi) + trigamma(theta) - 1/theta + 2/(m+theta) - (yi+theta)/((m+theta)*(m+theta)))
+	}
+	return score, info
+}
+
+// trigamma evaluates ψ'(x) for x > 0 by recurrence up to x >= 10 and the
+// asymptotic series.
+func trigamma(x float64) float64 {
+	r := 0.0
+	for x < 10 {
+		r += 1 / (x * x)
+		x++
+	}
+	t := 1 / x
+	t2 := t * t
+	return r + t + t2/2 + t*t2*(1.0/6-t2*(1.0/30-t2*(1.0/42-t2*(1.0/30-t2*5/66))))
+}
@@ -17,6 +17,16 @@ func glmLogLik(y, mu, weights []float64, fam glmFamily, dispersion float64) floa
 		}
 		return sum
 	}
+	if df, ok := fam.(glmDispersedFamily); ok {
+		if dispersion <= 0 || math.IsNaN(dispersion) {
+			return math.NaN()
+		}
+		sum := 0.0
+		for i := range y {
+			sum += df.logLikDispersion(y[i], mu[i], weights[i], dispersion)
+		}
+		return sum
+	}
 	sum := 0.0
 	for i := range y {
 		sum += fam.logLikContrib(y[i], mu[i], weights[i])
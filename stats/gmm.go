@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	internalcluster "github.com/HazelnutParadise/insyra/stats/internal/clustering"
+)
+
+type GMMCovarianceType string
+
+const (
+	GMMFull      GMMCovarianceType = "full"
+	GMMTied      GMMCovarianceType = "tied"
+	GMMDiag      GMMCovarianceType = "diag"
+	GMMSpherical GMMCovarianceType = "spherical"
+)
+
+// GMMOptions configures GaussianMixture. Zero values select the defaults:
+// full covariances, 100 EM iterations, a tolerance of 1e-6 on the mean
+// log-likelihood change, 1e-6 added to covariance diagonals and one start.
+type GMMOptions struct {
+	Covariance GMMCovarianceType
+	MaxIter    int
+	Tol        float64
+	RegCovar   float64
+	NInit      int
+	Seed       *int64
+}
+
+// GMMResult is a fitted Gaussian mixture. Cluster holds 1-based
+// maximum-posterior assignments and Probabilities the posterior membership
+// of each row in each component. Covariances are always full p×p matrices
+// (diagonal for GMMDiag and GMMSpherical, shared for GMMTied).
+type GMMResult struct {
+	Cluster       []int
+	Weights       []float64
+	Means         insyra.IDataTable
+	Covariances   [][][]float64
+	Probabilities insyra.IDataTable
+	LogLikelihood float64
+	AIC           float64
+	BIC           float64
+	NParams       int
+	Iter          int
+	Converged     bool
+}
+
+// GMMSelectionResult lists the BIC of each candidate number of components;
+// Best is the fit with the lowest BIC.
+type GMMSelectionResult struct {
+	Components []int
+	BIC        []float64
+	Best       *GMMResult
+}
+
+// GaussianMixture fits a mixture of Gaussians by expectation-maximisation,
+// starting from a k-means partition.
+func GaussianMixture(dataTable insyra.IDataTable, components int, opts ...GMMOptions) (*GMMResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var options GMMOptions
+	if len(opts) == 1 {
+		options = opts[0]
+	}
+	return fitGaussianMixture(data, components, options)
+}
+
+// SelectGaussianMixture fits 1..maxComponents components and keeps the
+// model with the lowest BIC.
+func SelectGaussianMixture(dataTable insyra.IDataTable, maxComponents int, opts ...GMMOptions) (*GMMSelectionResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var options GMMOptions
+	if len(opts) == 1 {
+		options = opts[0]
+	}
+	if maxComponents < 1 || maxComponents > len(data) {
+		return nil, errors.New("maxComponents must be between 1 and the row count")
+	}
+	res := &GMMSelectionResult{}
+	for k := 1; k <= maxComponents; k++ {
+		fit, err := fitGaussianMixture(data, k, options)
+		if err != nil {
+			return nil, err
+		}
+		res.Components = append(res.Components, k)
+		res.BIC = append(res.BIC, fit.BIC)
+		if res.Best == nil || fit.BIC < res.Best.BIC {
+			res.Best = fit
+		}
+	}
+	return res, nil
+}
+
+func fitGaussianMixture(data [][]float64, components int, options GMMOptions) (*GMMResult, error) {
+	got, err := internalcluster.GMM(data, components, internalcluster.GMMOptions{
+		Covariance: string(options.Covariance),
+		MaxIter:    options.MaxIter,
+		Tol:        options.Tol,
+		RegCovar:   options.RegCovar,
+		NInit:      options.NInit,
+		Seed:       options.Seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(data))
+	return &GMMResult{
+		Cluster:       got.Cluster,
+		Weights:       got.Weights,
+		Means:         matrixToDataTable(got.Means, "V"),
+		Covariances:   got.Covariances,
+		Probabilities: matrixToDataTable(got.Resp, "C"),
+		LogLikelihood: got.LogLikelihood,
+		AIC:           -2*got.LogLikelihood + 2*float64(got.NParams),
+		BIC:           -2*got.LogLikelihood + math.Log(n)*float64(got.NParams),
+		NParams:       got.NParams,
+		Iter:          got.Iter,
+		Converged:     got.Converged,
+	}, nil
+}
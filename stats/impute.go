@@ -0,0 +1,822 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/HazelnutParadise/insyra"
+	internalknn "github.com/HazelnutParadise/insyra/stats/internal/knn"
+	"gonum.org/v1/gonum/mat"
+)
+
+// ============================================================
+// Shared table handling
+// ============================================================
+
+// imputeFrame is a table read for imputation: the raw values of the fitted
+// columns, their positions in the table and which cells are missing.
+type imputeFrame struct {
+	table   *insyra.DataTable
+	pos     []int
+	raw     [][]any
+	missing [][]bool
+	rows    int
+}
+
+// readImputeFrame reads the columns named in names (all columns when names
+// is nil). Columns are matched by name, so new data may reorder columns or
+// carry extra ones, which pass through untouched.
+func readImputeFrame(dataTable insyra.IDataTable, names []string) (*imputeFrame, []string, error) {
+	if dataTable == nil {
+		return nil, nil, errors.New("data table is nil")
+	}
+	f := &imputeFrame{}
+	var err error
+	dataTable.AtomicDo(func(dt *insyra.DataTable) {
+		f.table = dt
+		f.rows, _ = dt.Size()
+		colNames := dt.ColNames()
+		index := make(map[string]int, len(colNames))
+		for j, name := range colNames {
+			if name == "" {
+				if names == nil {
+					err = fmt.Errorf("column %d has no name", j)
+					return
+				}
+				continue
+			}
+			if _, dup := index[name]; dup {
+				err = fmt.Errorf("column name %q is not unique", name)
+				return
+			}
+			index[name] = j
+		}
+		if names == nil {
+			names = colNames
+		}
+		for _, name := range names {
+			j, ok := index[name]
+			if !ok {
+				err = fmt.Errorf("fitted column %q not found", name)
+				return
+			}
+			data := dt.GetColByNumber(j).Data()
+			miss := make([]bool, len(data))
+			for i, v := range data {
+				miss[i] = isMissingValue(v)
+			}
+			f.pos = append(f.pos, j)
+			f.raw = append(f.raw, data)
+			f.missing = append(f.missing, miss)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.rows == 0 || len(f.pos) == 0 {
+		return nil, nil, errors.New("data table is empty")
+	}
+	return f, names, nil
+}
+
+func isMissingValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	f, ok := insyra.ToFloat64Safe(v)
+	return ok && math.IsNaN(f)
+}
+
+// numeric returns column j as float64 with NaN for missing cells.
+func (f *imputeFrame) numeric(j int, name string) ([]float64, error) {
+	out := make([]float64, f.rows)
+	for i, v := range f.raw[j] {
+		if f.missing[j][i] {
+			out[i] = math.NaN()
+			continue
+		}
+		x, ok := insyra.ToFloat64Safe(v)
+		if !ok {
+			return nil, fmt.Errorf("column %q has non-numeric value %v", name, v)
+		}
+		out[i] = x
+	}
+	return out, nil
+}
+
+// complete returns a copy of the table with the missing cells of every
+// fitted column j replaced by fill(j, i).
+func (f *imputeFrame) complete(names []string, fill func(j, i int) any) insyra.IDataTable {
+	out := f.table.Clone()
+	for j, pos := range f.pos {
+		vals := append([]any(nil), f.raw[j]...)
+		for i, miss := range f.missing[j] {
+			if miss {
+				vals[i] = fill(j, i)
+			}
+		}
+		out.UpdateColByNumber(pos, insyra.NewDataList(vals...).SetName(names[j]))
+	}
+	return out
+}
+
+// ============================================================
+// KNN imputation
+// ============================================================
+
+// KNNImputeOptions configures KNNImpute.
+type KNNImputeOptions struct {
+	// K is the number of donor rows used for each missing cell (default 5).
+	K         int
+	Weighting KNNWeighting
+	Algorithm KNNAlgorithm
+	LeafSize  int
+	// Imputations > 1 produces that many completed tables. Instead of the
+	// donors' (weighted) mean, each missing cell then takes the value of one
+	// of its K donors drawn at random, with probability proportional to the
+	// donor weight.
+	Imputations int
+	// Seed and UseSeed make the random donor draws reproducible.
+	Seed    uint64
+	UseSeed bool
+}
+
+// KNNImputeResult is a fitted KNN imputer. It keeps the training table so
+// Transform can fill new rows from training donors only.
+type KNNImputeResult struct {
+	// Imputed holds the completed training tables, one per imputation.
+	Imputed     []insyra.IDataTable
+	K           int
+	Imputations int
+
+	colNames []string
+	train    [][]float64 // rows × columns, NaN where missing
+	means    []float64
+	options  internalknn.Options
+	seed     uint64
+}
+
+// KNNImpute fills missing numeric values (nil or NaN) with the mean of the
+// K nearest training rows. For each missing cell, distances use only the
+// columns observed in that row, and donors are the rows observed on those
+// columns and on the target column; with no donor the column mean is used.
+// Columns should be on comparable scales, so standardise them first when
+// units differ.
+func KNNImpute(dataTable insyra.IDataTable, opts ...KNNImputeOptions) (*KNNImputeResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt KNNImputeOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.K == 0 {
+		opt.K = 5
+	}
+	if opt.Imputations == 0 {
+		opt.Imputations = 1
+	}
+	if opt.K < 1 {
+		return nil, errors.New("k must be positive")
+	}
+	if opt.Imputations < 1 {
+		return nil, errors.New("imputations must be positive")
+	}
+	switch opt.Weighting {
+	case "", KNNUniformWeighting, KNNDistanceWeighting:
+	default:
+		return nil, fmt.Errorf("unsupported KNN weighting %q", opt.Weighting)
+	}
+	switch opt.Algorithm {
+	case "", KNNAuto, KNNBruteForce, KNNKDTree, KNNBallTree:
+	default:
+		return nil, fmt.Errorf("unsupported KNN algorithm %q", opt.Algorithm)
+	}
+
+	frame, names, err := readImputeFrame(dataTable, nil)
+	if err != nil {
+		return nil, err
+	}
+	p := len(names)
+	train := make([][]float64, frame.rows)
+	for i := range train {
+		train[i] = make([]float64, p)
+	}
+	means := make([]float64, p)
+	for j, name := range names {
+		col, err := frame.numeric(j, name)
+		if err != nil {
+			return nil, err
+		}
+		sum, n := 0.0, 0
+		for i, v := range col {
+			train[i][j] = v
+			if !math.IsNaN(v) {
+				sum += v
+				n++
+			}
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("column %q has no observed values", name)
+		}
+		means[j] = sum / float64(n)
+	}
+
+	r := &KNNImputeResult{
+		K:           opt.K,
+		Imputations: opt.Imputations,
+		colNames:    names,
+		train:       train,
+		means:       means,
+		options: internalknn.Options{
+			Weighting: internalknn.Weighting(opt.Weighting),
+			Algorithm: internalknn.Algorithm(opt.Algorithm),
+			LeafSize:  opt.LeafSize,
+		},
+		seed: resolveSeed(opt.Seed, opt.UseSeed),
+	}
+	r.Imputed, err = r.impute(frame, train)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Transform fills the fitted columns of new data using training donors and
+// returns the first completed table.
+func (r *KNNImputeResult) Transform(dataTable insyra.IDataTable) (insyra.IDataTable, error) {
+	all, err := r.TransformAll(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// TransformAll returns one completed table per imputation.
+func (r *KNNImputeResult) TransformAll(dataTable insyra.IDataTable) ([]insyra.IDataTable, error) {
+	if r == nil || r.train == nil {
+		return nil, errors.New("KNN imputer is not fitted")
+	}
+	frame, _, err := readImputeFrame(dataTable, r.colNames)
+	if err != nil {
+		return nil, err
+	}
+	query := make([][]float64, frame.rows)
+	for i := range query {
+		query[i] = make([]float64, len(r.colNames))
+	}
+	for j, name := range r.colNames {
+		col, err := frame.numeric(j, name)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range col {
+			query[i][j] = v
+		}
+	}
+	return r.impute(frame, query)
+}
+
+// impute fills every missing cell of query, grouping rows by their pattern
+// of observed columns so each (pattern, target column) pair needs a single
+// neighbour search over its donor set.
+func (r *KNNImputeResult) impute(frame *imputeFrame, query [][]float64) ([]insyra.IDataTable, error) {
+	p := len(r.colNames)
+	m := r.Imputations
+	fills := make([]map[[2]int]float64, m)
+	for k := range fills {
+		fills[k] = map[[2]int]float64{}
+	}
+	rngs := make([]*rand.Rand, m)
+	for k := range rngs {
+		rngs[k] = replicateRNG(r.seed, uint64(k))
+	}
+
+	patterns := map[string][]int{}
+	var order []string
+	for i, row := range query {
+		key := make([]byte, p)
+		incomplete := false
+		for j, v := range row {
+			key[j] = '1'
+			if math.IsNaN(v) {
+				key[j] = '0'
+				incomplete = true
+			}
+		}
+		if !incomplete {
+			continue
+		}
+		if _, seen := patterns[string(key)]; !seen {
+			order = append(order, string(key))
+		}
+		patterns[string(key)] = append(patterns[string(key)], i)
+	}
+
+	for _, key := range order {
+		rows := patterns[key]
+		var observed []int
+		for j := range p {
+			if key[j] == '1' {
+				observed = append(observed, j)
+			}
+		}
+		for target := range p {
+			if key[target] == '1' {
+				continue
+			}
+			var donors [][]float64
+			var values []float64
+			for _, trow := range r.train {
+				if math.IsNaN(trow[target]) || !observedOn(trow, observed) {
+					continue
+				}
+				donors = append(donors, project(trow, observed))
+				values = append(values, trow[target])
+			}
+			if len(observed) == 0 || len(donors) == 0 {
+				for _, i := range rows {
+					for k := range m {
+						v := r.means[target]
+						if m > 1 && len(values) > 0 {
+							v = values[rngs[k].IntN(len(values))]
+						}
+						fills[k][[2]int{target, i}] = v
+					}
+				}
+				continue
+			}
+			queries := make([][]float64, len(rows))
+			for q, i := range rows {
+				queries[q] = project(query[i], observed)
+			}
+			k := min(r.K, len(donors))
+			if m == 1 {
+				got, err := internalknn.Regress(donors, queries, values, k, r.options)
+				if err != nil {
+					return nil, err
+				}
+				for q, i := range rows {
+					fills[0][[2]int{target, i}] = got.Predictions[q]
+				}
+				continue
+			}
+			got, err := internalknn.Neighbors(donors, queries, k, r.options)
+			if err != nil {
+				return nil, err
+			}
+			for q, i := range rows {
+				for imp := range m {
+					d := drawDonor(got.Distances[q], r.options.Weighting, rngs[imp])
+					fills[imp][[2]int{target, i}] = values[got.Indices[q][d]]
+				}
+			}
+		}
+	}
+
+	out := make([]insyra.IDataTable, m)
+	for k := range m {
+		out[k] = frame.complete(r.colNames, func(j, i int) any { return fills[k][[2]int{j, i}] })
+	}
+	return out, nil
+}
+
+func observedOn(row []float64, cols []int) bool {
+	for _, j := range cols {
+		if math.IsNaN(row[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+func project(row []float64, cols []int) []float64 {
+	out := make([]float64, len(cols))
+	for c, j := range cols {
+		out[c] = row[j]
+	}
+	return out
+}
+
+// drawDonor picks one neighbour, uniformly or with probability proportional
+// to inverse distance. Exact matches take all the weight, as in KNNRegress.
+func drawDonor(dists []float64, weighting internalknn.Weighting, rng *rand.Rand) int {
+	if weighting != internalknn.DistanceWeighting {
+		return rng.IntN(len(dists))
+	}
+	var exact []int
+	for i, d := range dists {
+		if d == 0 {
+			exact = append(exact, i)
+		}
+	}
+	if len(exact) > 0 {
+		return exact[rng.IntN(len(exact))]
+	}
+	total := 0.0
+	for _, d := range dists {
+		total += 1 / d
+	}
+	u := rng.Float64() * total
+	for i, d := range dists {
+		u -= 1 / d
+		if u <= 0 {
+			return i
+		}
+	}
+	return len(dists) - 1
+}
+
+// ============================================================
+// Multiple imputation by chained equations
+// ============================================================
+
+// MICEOptions configures MICE.
+type MICEOptions struct {
+	// MaxIter is the number of passes over the columns (default 10).
+	MaxIter int
+	// Imputations > 1 produces that many completed tables. Each chain then
+	// draws regression coefficients from their approximate posterior and
+	// adds residual noise (or a Bernoulli draw for binary columns), so the
+	// spread between tables reflects imputation uncertainty. With a single
+	// imputation the fitted conditional means are used instead.
+	Imputations int
+	// Seed and UseSeed make the draws reproducible.
+	Seed    uint64
+	UseSeed bool
+}
+
+// MICEResult is a fitted chained-equations imputer. It records every
+// regression fitted along each chain, so Transform replays the same
+// sequence on new data without refitting.
+type MICEResult struct {
+	// Imputed holds the completed training tables, one per imputation.
+	Imputed     []insyra.IDataTable
+	Imputations int
+	MaxIter     int
+
+	colNames []string
+	binary   []bool
+	levels   [][]any   // the two classes of each binary column, coded 0 and 1
+	start    []float64 // initial fill: column mean, or the modal class code
+	chains   [][]miceStep
+	seed     uint64
+}
+
+// miceStep is one regression of a chain: column col on an intercept and the
+// remaining columns in table order.
+type miceStep struct {
+	col   int
+	coef  []float64
+	sigma float64
+}
+
+// MICE imputes missing values by chained equations. Numeric columns are
+// modelled with LinearRegression and binary columns (exactly two distinct
+// non-numeric values) with LogisticRegression, each on all other columns.
+// Missing cells start at the column mean or mode and are then re-imputed
+// column by column for MaxIter passes. Every column is modelled, including
+// those complete in the training data, so new data may have missing values
+// anywhere.
+func MICE(dataTable insyra.IDataTable, opts ...MICEOptions) (*MICEResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt MICEOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.MaxIter == 0 {
+		opt.MaxIter = 10
+	}
+	if opt.Imputations == 0 {
+		opt.Imputations = 1
+	}
+	if opt.MaxIter < 1 {
+		return nil, errors.New("max iterations must be positive")
+	}
+	if opt.Imputations < 1 {
+		return nil, errors.New("imputations must be positive")
+	}
+
+	frame, names, err := readImputeFrame(dataTable, nil)
+	if err != nil {
+		return nil, err
+	}
+	p := len(names)
+	if p < 2 {
+		return nil, errors.New("MICE needs at least two columns")
+	}
+	r := &MICEResult{
+		Imputations: opt.Imputations,
+		MaxIter:     opt.MaxIter,
+		colNames:    names,
+		binary:      make([]bool, p),
+		levels:      make([][]any, p),
+		start:       make([]float64, p),
+		chains:      make([][]miceStep, opt.Imputations),
+		seed:        resolveSeed(opt.Seed, opt.UseSeed),
+	}
+	for j, name := range names {
+		if col, err := frame.numeric(j, name); err == nil {
+			sum, n := 0.0, 0
+			for _, v := range col {
+				if !math.IsNaN(v) {
+					sum += v
+					n++
+				}
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("column %q has no observed values", name)
+			}
+			r.start[j] = sum / float64(n)
+			continue
+		}
+		levels, counts := []any{}, map[string]int{}
+		for i, v := range frame.raw[j] {
+			if frame.missing[j][i] {
+				continue
+			}
+			key := classKey(v)
+			if counts[key] == 0 {
+				levels = append(levels, v)
+			}
+			counts[key]++
+		}
+		if len(levels) != 2 {
+			return nil, fmt.Errorf("column %q has %d categories; MICE imputes numeric and binary columns only", name, len(levels))
+		}
+		r.binary[j], r.levels[j] = true, levels
+		if counts[classKey(levels[1])] > counts[classKey(levels[0])] {
+			r.start[j] = 1
+		}
+	}
+
+	x, err := r.encode(frame)
+	if err != nil {
+		return nil, err
+	}
+	r.Imputed = make([]insyra.IDataTable, opt.Imputations)
+	for k := range opt.Imputations {
+		work := cloneColumns(x)
+		chain, err := r.fitChain(work, frame.missing, replicateRNG(r.seed, uint64(k)))
+		if err != nil {
+			return nil, err
+		}
+		r.chains[k] = chain
+		r.Imputed[k] = r.decode(frame, work)
+	}
+	return r, nil
+}
+
+// Transform imputes new data by replaying the first fitted chain.
+func (r *MICEResult) Transform(dataTable insyra.IDataTable) (insyra.IDataTable, error) {
+	all, err := r.TransformAll(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	return all[0], nil
+}
+
+// TransformAll imputes new data once per fitted chain.
+func (r *MICEResult) TransformAll(dataTable insyra.IDataTable) ([]insyra.IDataTable, error) {
+	if r == nil || len(r.chains) == 0 {
+		return nil, errors.New("MICE imputer is not fitted")
+	}
+	frame, _, err := readImputeFrame(dataTable, r.colNames)
+	if err != nil {
+		return nil, err
+	}
+	x, err := r.encode(frame)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]insyra.IDataTable, len(r.chains))
+	for k, chain := range r.chains {
+		work := cloneColumns(x)
+		rng := replicateRNG(r.seed^0x5851F42D4C957F2D, uint64(k))
+		for _, step := range chain {
+			r.applyStep(step, work, frame.missing[step.col], rng)
+		}
+		out[k] = r.decode(frame, work)
+	}
+	return out, nil
+}
+
+// encode returns the fitted columns as float64 (binary columns coded 0/1),
+// with missing cells set to their starting values.
+func (r *MICEResult) encode(frame *imputeFrame) ([][]float64, error) {
+	x := make([][]float64, len(r.colNames))
+	for j, name := range r.colNames {
+		if !r.binary[j] {
+			col, err := frame.numeric(j, name)
+			if err != nil {
+				return nil, err
+			}
+			for i := range col {
+				if frame.missing[j][i] {
+					col[i] = r.start[j]
+				}
+			}
+			x[j] = col
+			continue
+		}
+		col := make([]float64, frame.rows)
+		for i, v := range frame.raw[j] {
+			switch {
+			case frame.missing[j][i]:
+				col[i] = r.start[j]
+			case classEqual(v, r.levels[j][0]):
+			case classEqual(v, r.levels[j][1]):
+				col[i] = 1
+			default:
+				return nil, fmt.Errorf("column %q has class %v not seen during fitting", name, v)
+			}
+		}
+		x[j] = col
+	}
+	return x, nil
+}
+
+func (r *MICEResult) decode(frame *imputeFrame, x [][]float64) insyra.IDataTable {
+	return frame.complete(r.colNames, func(j, i int) any {
+		if r.binary[j] {
+			return r.levels[j][int(x[j][i])]
+		}
+		return x[j][i]
+	})
+}
+
+func (r *MICEResult) fitChain(x [][]float64, missing [][]bool, rng *rand.Rand) ([]miceStep, error) {
+	stochastic := r.Imputations > 1
+	var chain []miceStep
+	for range r.MaxIter {
+		for j, name := range r.colNames {
+			step, err := r.fitStep(x, missing[j], j, stochastic, rng)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", name, err)
+			}
+			r.applyStep(step, x, missing[j], rng)
+			chain = append(chain, step)
+		}
+	}
+	return chain, nil
+}
+
+// fitStep regresses column j on the others over the rows where j is
+// observed. For stochastic chains the coefficients are drawn from their
+// normal approximation (and, for numeric columns, sigma from its scaled
+// inverse chi-square posterior), as in van Buuren's mice "norm" and
+// "logreg" methods.
+func (r *MICEResult) fitStep(x [][]float64, missing []bool, j int, stochastic bool, rng *rand.Rand) (miceStep, error) {
+	var rows []int
+	for i, miss := range missing {
+		if !miss {
+			rows = append(rows, i)
+		}
+	}
+	p := len(x)
+	if len(rows) < p+2 {
+		return miceStep{}, fmt.Errorf("%d observed values is too few for %d predictors", len(rows), p-1)
+	}
+	y := make([]any, len(rows))
+	for o, i := range rows {
+		y[o] = x[j][i]
+	}
+	var xs []insyra.IDataList
+	design := mat.NewDense(len(rows), p, nil)
+	for o := range rows {
+		design.Set(o, 0, 1)
+	}
+	c := 1
+	for k := range p {
+		if k == j {
+			continue
+		}
+		vals := make([]any, len(rows))
+		for o, i := range rows {
+			vals[o] = x[k][i]
+			design.Set(o, c, x[k][i])
+		}
+		xs = append(xs, insyra.NewDataList(vals...))
+		c++
+	}
+
+	step := miceStep{col: j}
+	var cov mat.Dense
+	if r.binary[j] {
+		fit, err := LogisticRegressionWithOptions(LogisticRegressionOptions{PositiveClass: 1.0, SeparationPolicy: SepRidge}, insyra.NewDataList(y...), xs...)
+		if err != nil {
+			return miceStep{}, err
+		}
+		step.coef = append([]float64(nil), fit.Coefficients...)
+		if !stochastic {
+			return step, nil
+		}
+		w := mat.NewDense(len(rows), p, nil)
+		for o, pr := range fit.FittedProbabilities {
+			for k := range p {
+				w.Set(o, k, design.At(o, k)*pr*(1-pr))
+			}
+		}
+		var info mat.Dense
+		info.Mul(design.T(), w)
+		if err := cov.Inverse(&info); err != nil {
+			return miceStep{}, errors.New("information matrix is singular")
+		}
+	} else {
+		fit, err := LinearRegression(insyra.NewDataList(y...), xs...)
+		if err != nil {
+			return miceStep{}, err
+		}
+		step.coef = append([]float64(nil), fit.Coefficients...)
+		rss := 0.0
+		for _, e := range fit.Residuals {
+			rss += e * e
+		}
+		df := len(rows) - p
+		step.sigma = math.Sqrt(rss / float64(df))
+		if !stochastic {
+			return step, nil
+		}
+		chi := 0.0
+		for range df {
+			z := rng.NormFloat64()
+			chi += z * z
+		}
+		step.sigma = math.Sqrt(rss / chi)
+		var xtx mat.Dense
+		xtx.Mul(design.T(), design)
+		if err := cov.Inverse(&xtx); err != nil {
+			return miceStep{}, errors.New("predictors are collinear")
+		}
+		cov.Scale(step.sigma*step.sigma, &cov)
+	}
+	sym := mat.NewSymDense(p, nil)
+	for a := range p {
+		for b := a; b < p; b++ {
+			sym.SetSym(a, b, (cov.At(a, b)+cov.At(b, a))/2)
+		}
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(sym) {
+		return miceStep{}, errors.New("coefficient covariance is not positive definite")
+	}
+	var l mat.TriDense
+	chol.LTo(&l)
+	z := make([]float64, p)
+	for k := range z {
+		z[k] = rng.NormFloat64()
+	}
+	for a := range p {
+		for b := 0; b <= a; b++ {
+			step.coef[a] += l.At(a, b) * z[b]
+		}
+	}
+	return step, nil
+}
+
+// applyStep re-imputes the missing cells of step.col from the other columns.
+func (r *MICEResult) applyStep(step miceStep, x [][]float64, missing []bool, rng *rand.Rand) {
+	stochastic := r.Imputations > 1
+	for i, miss := range missing {
+		if !miss {
+			continue
+		}
+		eta := step.coef[0]
+		c := 1
+		for k := range x {
+			if k == step.col {
+				continue
+			}
+			eta += step.coef[c] * x[k][i]
+			c++
+		}
+		switch {
+		case r.binary[step.col] && stochastic:
+			x[step.col][i] = 0
+			if rng.Float64() < 1/(1+math.Exp(-eta)) {
+				x[step.col][i] = 1
+			}
+		case r.binary[step.col]:
+			x[step.col][i] = 0
+			if eta >= 0 {
+				x[step.col][i] = 1
+			}
+		case stochastic:
+			x[step.col][i] = eta + step.sigma*rng.NormFloat64()
+		default:
+			x[step.col][i] = eta
+		}
+	}
+}
+
+func cloneColumns(x [][]float64) [][]float64 {
+	out := make([][]float64, len(x))
+	for j, col := range x {
+		out[j] = append([]float64(nil), col...)
+	}
+	return out
+}
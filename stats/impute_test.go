@@ -0,0 +1,248 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+func TestKNNImputeUsesTrainingDonors(t *testing.T) {
+	train := insyra.NewDataTable(
+		insyra.NewDataList(1.0, 2.0, 3.0, 10.0, 11.0, nil).SetName("x"),
+		insyra.NewDataList(1.0, 2.0, nil, 10.0, 12.0, nil).SetName("y"),
+	)
+	imp, err := stats.KNNImpute(train, stats.KNNImputeOptions{K: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filled := imp.Imputed[0].(*insyra.DataTable)
+	// Row 3 (x=3): nearest donors with y observed are x=2 and x=1.
+	if got := filled.GetColByName("y").Get(2); got != 1.5 {
+		t.Errorf("y[2] = %v, want 1.5", got)
+	}
+	// A row with nothing observed falls back to the column means.
+	if got := filled.GetColByName("x").Get(5); !floatAlmostEqual(got.(float64), 27.0/5, 1e-12) {
+		t.Errorf("x[5] = %v, want %v", got, 27.0/5)
+	}
+	if train.GetColByName("y").Get(2) != nil {
+		t.Errorf("KNNImpute modified its input")
+	}
+
+	// New data may reorder columns and carry extra ones.
+	test := insyra.NewDataTable(
+		insyra.NewDataList("a", "b").SetName("id"),
+		insyra.NewDataList(nil, 1.5).SetName("y"),
+		insyra.NewDataList(10.5, nil).SetName("x"),
+	)
+	out, err := imp.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(*insyra.DataTable)
+	if y := got.GetColByName("y").Get(0); y != 11.0 {
+		t.Errorf("test y[0] = %v, want 11", y)
+	}
+	if x := got.GetColByName("x").Get(1); x != 1.5 {
+		t.Errorf("test x[1] = %v, want 1.5", x)
+	}
+	if id := got.GetColByName("id").Get(1); id != "b" {
+		t.Errorf("extra column changed: %v", id)
+	}
+}
+
+func TestKNNImputeMultipleDrawsFromDonors(t *testing.T) {
+	train := insyra.NewDataTable(
+		insyra.NewDataList(1.0, 2.0, 3.0, 4.0, 20.0).SetName("x"),
+		insyra.NewDataList(10.0, 20.0, nil, 40.0, 99.0).SetName("y"),
+	)
+	opt := stats.KNNImputeOptions{K: 3, Imputations: 20, Seed: 9, UseSeed: true}
+	imp, err := stats.KNNImpute(train, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imp.Imputed) != 20 {
+		t.Fatalf("got %d imputed tables, want 20", len(imp.Imputed))
+	}
+	seen := map[float64]bool{}
+	for _, tbl := range imp.Imputed {
+		v := tbl.(*insyra.DataTable).GetColByName("y").Get(2).(float64)
+		if v != 10 && v != 20 && v != 40 {
+			t.Fatalf("drew %v, which is not one of the 3 nearest donors", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("20 draws all gave the same donor")
+	}
+	again, _ := stats.KNNImpute(train, opt)
+	for k := range imp.Imputed {
+		if again.Imputed[k].(*insyra.DataTable).GetColByName("y").Get(2) != imp.Imputed[k].(*insyra.DataTable).GetColByName("y").Get(2) {
+			t.Fatalf("draws differ for the same seed")
+		}
+	}
+}
+
+func miceTrainTable(n int) *insyra.DataTable {
+	z := seededNormals(17, 3*n)
+	x, y, g := insyra.NewDataList(), insyra.NewDataList(), insyra.NewDataList()
+	for i := range n {
+		xi := z[i]
+		x.Append(xi)
+		if i%5 == 0 {
+			y.Append(nil)
+		} else {
+			y.Append(2*xi + 1 + 0.05*z[n+i])
+		}
+		switch {
+		case i%7 == 3:
+			g.Append(nil)
+		case xi+0.3*z[2*n+i] > 0:
+			g.Append("high")
+		default:
+			g.Append("low")
+		}
+	}
+	return insyra.NewDataTable(x.SetName("x"), y.SetName("y"), g.SetName("group"))
+}
+
+func TestMICEImputesFromOtherColumns(t *testing.T) {
+	train := miceTrainTable(120)
+	imp, err := stats.MICE(train, stats.MICEOptions{MaxIter: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filled := imp.Imputed[0].(*insyra.DataTable)
+	xs, ys := filled.GetColByName("x").Data(), filled.GetColByName("y").Data()
+	for i := 0; i < 120; i += 5 {
+		want := 2*xs[i].(float64) + 1
+		if !floatAlmostEqual(ys[i].(float64), want, 0.1) {
+			t.Errorf("y[%d] = %v, want about %v", i, ys[i], want)
+		}
+	}
+	groups := filled.GetColByName("group").Data()
+	for i := 3; i < 120; i += 7 {
+		if g := groups[i]; g != "high" && g != "low" {
+			t.Fatalf("group[%d] imputed as %v", i, g)
+		}
+		if x := xs[i].(float64); math.Abs(x) > 1 && (x > 0) != (groups[i] == "high") {
+			t.Errorf("group[%d] = %v for x = %v", i, groups[i], x)
+		}
+	}
+
+	test := insyra.NewDataTable(
+		insyra.NewDataList(0.5, -1.0).SetName("x"),
+		insyra.NewDataList(nil, nil).SetName("y"),
+		insyra.NewDataList("high", nil).SetName("group"),
+	)
+	out, err := imp.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.(*insyra.DataTable)
+	if y := got.GetColByName("y").Get(0).(float64); !floatAlmostEqual(y, 2, 0.1) {
+		t.Errorf("test y[0] = %v, want about 2", y)
+	}
+	if g := got.GetColByName("group").Get(1); g != "low" {
+		t.Errorf("test group[1] = %v, want low", g)
+	}
+
+	bad := insyra.NewDataTable(
+		insyra.NewDataList(0.5).SetName("x"),
+		insyra.NewDataList(1.0).SetName("y"),
+		insyra.NewDataList("medium").SetName("group"),
+	)
+	if _, err := imp.Transform(bad); err == nil {
+		t.Errorf("expected error for an unseen class")
+	}
+}
+
+func TestMICEMultipleImputationsVary(t *testing.T) {
+	train := miceTrainTable(120)
+	imp, err := stats.MICE(train, stats.MICEOptions{MaxIter: 5, Imputations: 5, Seed: 3, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := imp.Imputed[0].(*insyra.DataTable).GetColByName("y").Get(0).(float64)
+	varies := false
+	for _, tbl := range imp.Imputed {
+		y := tbl.(*insyra.DataTable).GetColByName("y").Get(0).(float64)
+		x := tbl.(*insyra.DataTable).GetColByName("x").Get(0).(float64)
+		if !floatAlmostEqual(y, 2*x+1, 0.5) {
+			t.Errorf("draw %v is far from the regression mean %v", y, 2*x+1)
+		}
+		varies = varies || y != first
+	}
+	if !varies {
+		t.Errorf("all imputations are identical")
+	}
+
+	all, err := imp.TransformAll(insyra.NewDataTable(
+		insyra.NewDataList(1.0).SetName("x"),
+		insyra.NewDataList(nil).SetName("y"),
+		insyra.NewDataList("high").SetName("group"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("TransformAll returned %d tables, want 5", len(all))
+	}
+}
+
+func TestImputersJSONRoundTrip(t *testing.T) {
+	train := miceTrainTable(60)
+	test := insyra.NewDataTable(
+		insyra.NewDataList(0.2, nil).SetName("x"),
+		insyra.NewDataList(nil, 3.0).SetName("y"),
+		insyra.NewDataList(nil, "low").SetName("group"),
+	)
+	mice, err := stats.MICE(train, stats.MICEOptions{MaxIter: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mice2 stats.MICEResult
+	roundTrip(t, mice, &mice2)
+	want, _ := mice.Transform(test)
+	got, err := mice2.Transform(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"x", "y", "group"} {
+		g, w := got.(*insyra.DataTable).GetColByName(name).Data(), want.(*insyra.DataTable).GetColByName(name).Data()
+		for i := range w {
+			if g[i] != w[i] {
+				t.Errorf("%s[%d] = %v after reload, want %v", name, i, g[i], w[i])
+			}
+		}
+	}
+
+	numeric := insyra.NewDataTable(train.GetColByName("x"), train.GetColByName("y"))
+	knn, err := stats.KNNImpute(numeric, stats.KNNImputeOptions{K: 3, Weighting: stats.KNNDistanceWeighting})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var knn2 stats.KNNImputeResult
+	roundTrip(t, knn, &knn2)
+	numTest := insyra.NewDataTable(test.GetColByName("x"), test.GetColByName("y"))
+	wantKNN, _ := knn.Transform(numTest)
+	gotKNN, err := knn2.Transform(numTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameTable(t, gotKNN, wantKNN)
+}
+
+func TestKNNImputeJSONRejectsInvalidPayload(t *testing.T) {
+	for name, payload := range map[string]string{
+		"short row": `{"kind":"knn_impute","version":1,"columns":["x","y"],"train":[[1,2],[3]],"means":[2,2],"k":1,"imputations":1}`,
+		"weighting": `{"kind":"knn_impute","version":1,"columns":["x"],"train":[[1]],"means":[1],"k":1,"imputations":1,"weighting":"cubic"}`,
+		"algorithm": `{"kind":"knn_impute","version":1,"columns":["x"],"train":[[1]],"means":[1],"k":1,"imputations":1,"algorithm":"lsh"}`,
+	} {
+		var r stats.KNNImputeResult
+		if err := r.UnmarshalJSON([]byte(payload)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package clustering
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra/stats/internal/knn"
+)
+
+type HDBSCANResult struct {
+	Cluster       []int
+	Probabilities []float64
+	Stability     []float64
+	CoreDistance  []float64
+}
+
+type OPTICSResult struct {
+	Order        []int
+	Reachability []float64
+	CoreDistance []float64
+}
+
+// CoreDistances returns each point's distance to its minPts-th nearest
+// neighbour, counting the point itself, from the KNN tree search.
+func CoreDistances(data [][]float64, minPts int) ([]float64, error) {
+	if minPts < 1 || minPts > len(data) {
+		return nil, errors.New("minPts must be between 1 and the row count")
+	}
+	got, err := knn.Neighbors(data, data, minPts, knn.Options{})
+	if err != nil {
+		return nil, err
+	}
+	core := make([]float64, len(data))
+	for i, d := range got.Distances {
+		core[i] = d[len(d)-1]
+	}
+	return core, nil
+}
+
+// HDBSCAN runs the algorithm of Campello, Moulavi and Sander (2013): a
+// minimum spanning tree of the mutual reachability distance, condensed with
+// minClusterSize and cut at the clusters of maximal stability (excess of
+// mass). Noise is labelled 0, clusters 1..k.
+func HDBSCAN(data [][]float64, minPts, minClusterSize int) (*HDBSCANResult, error) {
+	n := len(data)
+	if n < 2 {
+		return nil, errors.New("data must have at least 2 rows")
+	}
+	if minClusterSize < 2 {
+		return nil, errors.New("minClusterSize must be at least 2")
+	}
+	core, err := CoreDistances(data, minPts)
+	if err != nil {
+		return nil, err
+	}
+	edges := mutualReachabilityMST(data, core)
+	sort.SliceStable(edges, func(a, b int) bool { return edges[a].dist < edges[b].dist })
+
+	// Single-linkage hierarchy: leaves 0..n-1, merges n..2n-2.
+	type slNode struct {
+		left, right int
+		dist        float64
+		size        int
+	}
+	nodes := make([]slNode, 2*n-1)
+	for i := range n {
+		nodes[i] = slNode{left: -1, right: -1, size: 1}
+	}
+	parent := make([]int, 2*n-1)
+	for i := range parent {
+		parent[i] = i
+	}
+	for m, e := range edges {
+		a, b := find(parent, e.a), find(parent, e.b)
+		id := n + m
+		nodes[id] = slNode{left: a, right: b, dist: e.dist, size: nodes[a].size + nodes[b].size}
+		parent[a], parent[b] = id, id
+	}
+
+	// Condense the hierarchy. Each condensed cluster records its parent,
+	// birth lambda and the (lambda, size) of everything leaving it.
+	type condensed struct {
+		parent    int
+		birth     float64
+		children  []int
+		stability float64
+		maxLambda float64
+	}
+	var clusters []condensed
+	pointCluster := make([]int, n)
+	pointLambda := make([]float64, n)
+	lambdaOf := func(d float64) float64 {
+		if d <= 0 {
+			return math.MaxFloat64
+		}
+		return 1 / d
+	}
+	var leaves func(node int, out []int) []int
+	leaves = func(node int, out []int) []int {
+		if node < n {
+			return append(out, node)
+		}
+		out = leaves(nodes[node].left, out)
+		return leaves(nodes[node].right, out)
+	}
+	newCluster := func(parentCluster int, birth float64) int {
+		clusters = append(clusters, condensed{parent: parentCluster, birth: birth})
+		if parentCluster >= 0 {
+			clusters[parentCluster].children = append(clusters[parentCluster].children, len(clusters)-1)
+		}
+		return len(clusters) - 1
+	}
+	fallOut := func(node, c int, lambda float64) {
+		for _, pt := range leaves(node, nil) {
+			pointCluster[pt], pointLambda[pt] = c, lambda
+			clusters[c].stability += lambda - clusters[c].birth
+			clusters[c].maxLambda = math.Max(clusters[c].maxLambda, lambda)
+		}
+	}
+	var condense func(node, c int)
+	condense = func(node, c int) {
+		for {
+			nd := nodes[node]
+			lambda := lambdaOf(nd.dist)
+			l, r := nodes[nd.left], nodes[nd.right]
+			switch {
+			case l.size >= minClusterSize && r.size >= minClusterSize:
+				clusters[c].stability += float64(nd.size) * (lambda - clusters[c].birth)
+				clusters[c].maxLambda = math.Max(clusters[c].maxLambda, lambda)
+				condense(nd.left, newCluster(c, lambda))
+				condense(nd.right, newCluster(c, lambda))
+				return
+			case l.size < minClusterSize && r.size < minClusterSize:
+				fallOut(nd.left, c, lambda)
+				fallOut(nd.right, c, lambda)
+				return
+			case l.size < minClusterSize:
+				fallOut(nd.left, c, lambda)
+				node = nd.right
+			default:
+				fallOut(nd.right, c, lambda)
+				node = nd.left
+			}
+		}
+	}
+	condense(2*n-2, newCluster(-1, 0))
+
+	// Excess-of-mass selection, bottom-up; children always have larger ids
+	// than their parent. The root is never selected.
+	selected := make([]bool, len(clusters))
+	subtree := make([]float64, len(clusters))
+	for c := len(clusters) - 1; c >= 1; c-- {
+		childSum := 0.0
+		for _, ch := range clusters[c].children {
+			childSum += subtree[ch]
+		}
+		if len(clusters[c].children) == 0 || clusters[c].stability >= childSum {
+			selected[c] = true
+			subtree[c] = clusters[c].stability
+			var deselect func(int)
+			deselect = func(x int) {
+				for _, ch := range clusters[x].children {
+					selected[ch] = false
+					deselect(ch)
+				}
+			}
+			deselect(c)
+		} else {
+			subtree[c] = childSum
+		}
+	}
+
+	label := make([]int, len(clusters))
+	res := &HDBSCANResult{Cluster: make([]int, n), Probabilities: make([]float64, n), CoreDistance: core}
+	for c := range clusters {
+		if selected[c] {
+			res.Stability = append(res.Stability, clusters[c].stability)
+			label[c] = len(res.Stability)
+		}
+	}
+	for pt := range n {
+		for c := pointCluster[pt]; c >= 0; c = clusters[c].parent {
+			if !selected[c] {
+				continue
+			}
+			res.Cluster[pt] = label[c]
+			// Membership strength relative to the largest lambda leaving
+			// the selected cluster (points of descendant clusters score 1).
+			if maxLambda := clusters[c].maxLambda; maxLambda > 0 {
+				res.Probabilities[pt] = math.Min(pointLambda[pt], maxLambda) / maxLambda
+			}
+			break
+		}
+	}
+	return res, nil
+}
+
+type mstEdge struct {
+	a, b int
+	dist float64
+}
+
+// mutualReachabilityMST is Prim's algorithm on the dense mutual
+// reachability graph, max(core(a), core(b), d(a, b)).
+func mutualReachabilityMST(data [][]float64, core []float64) []mstEdge {
+	n := len(data)
+	inTree := make([]bool, n)
+	best := make([]float64, n)
+	from := make([]int, n)
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+	edges := make([]mstEdge, 0, n-1)
+	cur := 0
+	inTree[0] = true
+	for len(edges) < n-1 {
+		next := -1
+		for j := range n {
+			if inTree[j] {
+				continue
+			}
+			d := math.Max(euclidean(data[cur], data[j]), math.Max(core[cur], core[j]))
+			if d < best[j] {
+				best[j], from[j] = d, cur
+			}
+			if next < 0 || best[j] < best[next] {
+				next = j
+			}
+		}
+		inTree[next] = true
+		edges = append(edges, mstEdge{a: from[next], b: next, dist: best[next]})
+		cur = next
+	}
+	return edges
+}
+
+// OPTICS orders the points by density reachability (Ankerst et al. 1999).
+// eps bounds the neighbourhood radius and may be +Inf; undefined core and
+// reachability distances are +Inf.
+func OPTICS(data [][]float64, eps float64, minPts int) (*OPTICSResult, error) {
+	n := len(data)
+	if n == 0 {
+		return nil, errors.New("data must not be empty")
+	}
+	if eps <= 0 || math.IsNaN(eps) {
+		return nil, errors.New("eps must be greater than 0")
+	}
+	core, err := CoreDistances(data, minPts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range core {
+		if core[i] > eps {
+			core[i] = math.Inf(1)
+		}
+	}
+	reach := make([]float64, n)
+	for i := range reach {
+		reach[i] = math.Inf(1)
+	}
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	root := buildKdRange(data, idx, 16)
+	eps2 := eps * eps
+	processed := make([]bool, n)
+	order := make([]int, 0, n)
+	var seeds opticsSeeds
+	var nbrs []int
+	expand := func(p int) {
+		processed[p] = true
+		order = append(order, p)
+		if math.IsInf(core[p], 1) {
+			return
+		}
+		nbrs = nbrs[:0]
+		kdRangeSearch(root, data, data[p], eps2, &nbrs)
+		for _, q := range nbrs {
+			if processed[q] {
+				continue
+			}
+			if r := math.Max(core[p], euclidean(data[p], data[q])); r < reach[q] {
+				reach[q] = r
+				heap.Push(&seeds, opticsSeed{reach: r, point: q})
+			}
+		}
+	}
+	for start := range n {
+		if processed[start] {
+			continue
+		}
+		expand(start)
+		for seeds.Len() > 0 {
+			s := heap.Pop(&seeds).(opticsSeed)
+			// Entries are pushed again whenever a reachability drops, so
+			// skip the stale copies.
+			if processed[s.point] || s.reach != reach[s.point] {
+				continue
+			}
+			expand(s.point)
+		}
+	}
+	return &OPTICSResult{Order: order, Reachability: reach, CoreDistance: core}, nil
+}
+
+// opticsSeed is an entry in the OPTICS seed list.
+type opticsSeed struct {
+	reach float64
+	point int
+}
+
+// opticsSeeds is a min-heap of seeds ordered by reachability, then by
+// point index so that ties resolve the same way on every run.
+type opticsSeeds []opticsSeed
+
+func (h opticsSeeds) Len() int { return len(h) }
+func (h opticsSeeds) Less(i, j int) bool {
+	if h[i].reach != h[j].reach {
+		return h[i].reach < h[j].reach
+	}
+	return h[i].point < h[j].point
+}
+func (h opticsSeeds) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *opticsSeeds) Push(x any)   { *h = append(*h, x.(opticsSeed)) }
+func (h *opticsSeeds) Pop() any {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// ExtractDBSCAN cuts an OPTICS ordering at epsCl, which yields the DBSCAN
+// clustering at that radius up to border-point assignment.
+func ExtractDBSCAN(res *OPTICSResult, epsCl float64) []int {
+	cluster := make([]int, len(res.Order))
+	id := 0
+	for _, p := range res.Order {
+		if res.Reachability[p] > epsCl {
+			if res.CoreDistance[p] <= epsCl {
+				id++
+				cluster[p] = id
+			}
+			continue
+		}
+		cluster[p] = id
+	}
+	return cluster
+}
@@ -0,0 +1,92 @@
+package clustering
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+// opticsLinearScan is the textbook OPTICS loop: every expansion scans all n
+// points and the next seed is found by a linear search. OPTICS must produce
+// the same ordering with its KD range search and seed heap.
+func opticsLinearScan(data [][]float64, eps float64, core []float64) ([]int, []float64) {
+	n := len(data)
+	reach := make([]float64, n)
+	for i := range reach {
+		reach[i] = math.Inf(1)
+	}
+	processed := make([]bool, n)
+	seeds := make([]bool, n)
+	order := make([]int, 0, n)
+	expand := func(p int) {
+		processed[p], seeds[p] = true, false
+		order = append(order, p)
+		if math.IsInf(core[p], 1) {
+			return
+		}
+		for q := range n {
+			if processed[q] {
+				continue
+			}
+			d := euclidean(data[p], data[q])
+			if d > eps {
+				continue
+			}
+			reach[q] = math.Min(reach[q], math.Max(core[p], d))
+			seeds[q] = true
+		}
+	}
+	for start := range n {
+		if processed[start] {
+			continue
+		}
+		expand(start)
+		for {
+			next := -1
+			for q := range n {
+				if seeds[q] && (next < 0 || reach[q] < reach[next]) {
+					next = q
+				}
+			}
+			if next < 0 {
+				break
+			}
+			expand(next)
+		}
+	}
+	return order, reach
+}
+
+func TestOPTICSHeapVsLinearScanEquivalence(t *testing.T) {
+	cfgs := []struct {
+		name string
+		n, p int
+		eps  float64
+	}{
+		{"tiny", 8, 2, 0.5},
+		{"small", 60, 2, 0.6},
+		{"small_inf", 60, 3, math.Inf(1)},
+		{"medium", 300, 4, 1.0},
+		{"medium_high_dim", 300, 16, 2.5},
+	}
+	for _, c := range cfgs {
+		for _, mode := range dataModes {
+			t.Run(c.name+"_"+mode.name, func(t *testing.T) {
+				rng := rand.New(rand.NewPCG(uint64(c.n), uint64(c.p)*7+3))
+				data := mode.gen(rng, c.n, c.p)
+				res, err := OPTICS(data, c.eps, 4)
+				if err != nil {
+					t.Fatal(err)
+				}
+				order, reach := opticsLinearScan(data, c.eps, res.CoreDistance)
+				if !reflect.DeepEqual(res.Order, order) {
+					t.Fatalf("order differs:\nheap   %v\nlinear %v", res.Order, order)
+				}
+				if !reflect.DeepEqual(res.Reachability, reach) {
+					t.Fatalf("reachability differs:\nheap   %v\nlinear %v", res.Reachability, reach)
+				}
+			})
+		}
+	}
+}
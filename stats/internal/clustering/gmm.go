@@ -0,0 +1,297 @@
+package clustering
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	CovarianceFull      = "full"
+	CovarianceTied      = "tied"
+	CovarianceDiag      = "diag"
+	CovarianceSpherical = "spherical"
+)
+
+type GMMOptions struct {
+	Covariance string
+	MaxIter    int
+	Tol        float64
+	RegCovar   float64
+	NInit      int
+	Seed       *int64
+}
+
+type GMMResult struct {
+	Weights       []float64
+	Means         [][]float64
+	Covariances   [][][]float64
+	Resp          [][]float64
+	Cluster       []int
+	LogLikelihood float64
+	NParams       int
+	Iter          int
+	Converged     bool
+}
+
+// GMM fits a Gaussian mixture by EM. Each start is initialised from a
+// k-means partition (as scikit-learn does); the start with the highest
+// log-likelihood wins.
+func GMM(data [][]float64, k int, opts GMMOptions) (*GMMResult, error) {
+	n := len(data)
+	if n == 0 {
+		return nil, errors.New("data must not be empty")
+	}
+	if k <= 0 || k > n {
+		return nil, errors.New("components must be between 1 and the row count")
+	}
+	switch opts.Covariance {
+	case "":
+		opts.Covariance = CovarianceFull
+	case CovarianceFull, CovarianceTied, CovarianceDiag, CovarianceSpherical:
+	default:
+		return nil, errors.New("unsupported covariance type")
+	}
+	if opts.MaxIter <= 0 {
+		opts.MaxIter = 100
+	}
+	if opts.Tol <= 0 {
+		opts.Tol = 1e-6
+	}
+	if opts.RegCovar < 0 {
+		return nil, errors.New("RegCovar must not be negative")
+	}
+	if opts.RegCovar == 0 {
+		opts.RegCovar = 1e-6
+	}
+	if opts.NInit <= 0 {
+		opts.NInit = 1
+	}
+
+	var best *GMMResult
+	for s := range opts.NInit {
+		kmOpts := KMeansOptions{NStart: 1, IterMax: 10}
+		if opts.Seed != nil {
+			seed := *opts.Seed + int64(s)
+			kmOpts.Seed = &seed
+		}
+		km, err := KMeans(data, k, kmOpts)
+		if err != nil {
+			return nil, err
+		}
+		resp := make([][]float64, n)
+		for i, c := range km.Cluster {
+			resp[i] = make([]float64, k)
+			resp[i][c-1] = 1
+		}
+		got, err := gmmEM(data, resp, opts)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || got.LogLikelihood > best.LogLikelihood {
+			best = got
+		}
+	}
+	return best, nil
+}
+
+func gmmEM(data, resp [][]float64, opts GMMOptions) (*GMMResult, error) {
+	n, p, k := len(data), len(data[0]), len(resp[0])
+	res := &GMMResult{Resp: resp}
+	prev := math.Inf(-1)
+	for iter := 1; iter <= opts.MaxIter; iter++ {
+		res.Weights, res.Means, res.Covariances = gmmMStep(data, res.Resp, opts.Covariance, opts.RegCovar)
+		ll, newResp, err := gmmEStep(data, res.Weights, res.Means, res.Covariances)
+		if err != nil {
+			return nil, err
+		}
+		res.Resp, res.LogLikelihood, res.Iter = newResp, ll, iter
+		if math.Abs(ll-prev) < opts.Tol*float64(n) {
+			res.Converged = true
+			break
+		}
+		prev = ll
+	}
+	// Report the parameters that produced the final responsibilities.
+	res.Weights, res.Means, res.Covariances = gmmMStep(data, res.Resp, opts.Covariance, opts.RegCovar)
+	ll, newResp, err := gmmEStep(data, res.Weights, res.Means, res.Covariances)
+	if err != nil {
+		return nil, err
+	}
+	res.Resp, res.LogLikelihood = newResp, ll
+
+	res.Cluster = make([]int, n)
+	for i, r := range res.Resp {
+		best := 0
+		for c := range r {
+			if r[c] > r[best] {
+				best = c
+			}
+		}
+		res.Cluster[i] = best + 1
+	}
+	res.NParams = k - 1 + k*p
+	switch opts.Covariance {
+	case CovarianceFull:
+		res.NParams += k * p * (p + 1) / 2
+	case CovarianceTied:
+		res.NParams += p * (p + 1) / 2
+	case CovarianceDiag:
+		res.NParams += k * p
+	case CovarianceSpherical:
+		res.NParams += k
+	}
+	return res, nil
+}
+
+// gmmMStep returns mixing weights, means and covariances (always as full
+// matrices, diagonal where the covariance type requires it).
+func gmmMStep(data, resp [][]float64, covType string, reg float64) ([]float64, [][]float64, [][][]float64) {
+	n, p, k := len(data), len(data[0]), len(resp[0])
+	nk := make([]float64, k)
+	means := make([][]float64, k)
+	for c := range k {
+		means[c] = make([]float64, p)
+	}
+	for i, row := range data {
+		for c := range k {
+			nk[c] += resp[i][c]
+			for j, v := range row {
+				means[c][j] += resp[i][c] * v
+			}
+		}
+	}
+	for c := range k {
+		nk[c] += 10 * math.SmallestNonzeroFloat64
+		for j := range p {
+			means[c][j] /= nk[c]
+		}
+	}
+	covs := make([][][]float64, k)
+	for c := range k {
+		cov := newSquare(p)
+		for i, row := range data {
+			r := resp[i][c]
+			if r == 0 {
+				continue
+			}
+			for a := range p {
+				da := row[a] - means[c][a]
+				for b := 0; b <= a; b++ {
+					cov[a][b] += r * da * (row[b] - means[c][b])
+				}
+			}
+		}
+		for a := range p {
+			for b := 0; b <= a; b++ {
+				cov[b][a] = cov[a][b]
+			}
+		}
+		covs[c] = cov
+	}
+
+	weights := make([]float64, k)
+	for c := range k {
+		weights[c] = nk[c] / float64(n)
+	}
+	switch covType {
+	case CovarianceTied:
+		tied := newSquare(p)
+		for c := range k {
+			for a := range p {
+				for b := range p {
+					tied[a][b] += covs[c][a][b] / float64(n)
+				}
+			}
+		}
+		for a := range p {
+			tied[a][a] += reg
+		}
+		for c := range k {
+			covs[c] = tied
+		}
+	case CovarianceDiag, CovarianceSpherical:
+		for c := range k {
+			diag := newSquare(p)
+			avg := 0.0
+			for a := range p {
+				diag[a][a] = covs[c][a][a]/nk[c] + reg
+				avg += diag[a][a] / float64(p)
+			}
+			if covType == CovarianceSpherical {
+				for a := range p {
+					diag[a][a] = avg
+				}
+			}
+			covs[c] = diag
+		}
+	default:
+		for c := range k {
+			for a := range p {
+				for b := range p {
+					covs[c][a][b] /= nk[c]
+				}
+				covs[c][a][a] += reg
+			}
+		}
+	}
+	return weights, means, covs
+}
+
+// gmmEStep returns the log-likelihood and the responsibilities.
+func gmmEStep(data [][]float64, weights []float64, means [][]float64, covs [][][]float64) (float64, [][]float64, error) {
+	n, p, k := len(data), len(data[0]), len(weights)
+	chols := make([]*mat.Cholesky, k)
+	logDets := make([]float64, k)
+	for c := range k {
+		flat := make([]float64, 0, p*p)
+		for _, row := range covs[c] {
+			flat = append(flat, row...)
+		}
+		chol := &mat.Cholesky{}
+		if ok := chol.Factorize(mat.NewSymDense(p, flat)); !ok {
+			return 0, nil, errors.New("component covariance is not positive definite; increase RegCovar")
+		}
+		chols[c] = chol
+		logDets[c] = chol.LogDet()
+	}
+	resp := make([][]float64, n)
+	ll := 0.0
+	diff := mat.NewVecDense(p, nil)
+	sol := mat.NewVecDense(p, nil)
+	logProb := make([]float64, k)
+	for i, row := range data {
+		maxLP := math.Inf(-1)
+		for c := range k {
+			for j := range p {
+				diff.SetVec(j, row[j]-means[c][j])
+			}
+			if err := chols[c].SolveVecTo(sol, diff); err != nil {
+				return 0, nil, err
+			}
+			maha := mat.Dot(diff, sol)
+			logProb[c] = math.Log(weights[c]) - 0.5*(float64(p)*math.Log(2*math.Pi)+logDets[c]+maha)
+			maxLP = math.Max(maxLP, logProb[c])
+		}
+		sum := 0.0
+		for c := range k {
+			sum += math.Exp(logProb[c] - maxLP)
+		}
+		lse := maxLP + math.Log(sum)
+		ll += lse
+		resp[i] = make([]float64, k)
+		for c := range k {
+			resp[i][c] = math.Exp(logProb[c] - lse)
+		}
+	}
+	return ll, resp, nil
+}
+
+func newSquare(p int) [][]float64 {
+	out := make([][]float64, p)
+	for i := range out {
+		out[i] = make([]float64, p)
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package clustering
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra/stats/internal/parutil"
+)
+
+type PAMResult struct {
+	Medoids []int
+	Cluster []int
+	Cost    float64
+	Size    []int
+	Swaps   int
+}
+
+// DissimilarityMatrix evaluates dist over every pair of rows.
+func DissimilarityMatrix(data [][]float64, dist func(a, b []float64) float64) [][]float64 {
+	n := len(data)
+	out := make([][]float64, n)
+	for i := range out {
+		out[i] = make([]float64, n)
+	}
+	p := 0
+	if n > 0 {
+		p = len(data[0])
+	}
+	// Same break-even as the Euclidean distance matrix (n²·p ≈ 200K).
+	parutil.Run(n, n*n*p >= 200000, func(i int) {
+		for j := i + 1; j < n; j++ {
+			out[i][j] = dist(data[i], data[j])
+		}
+	})
+	for i := range n {
+		for j := i + 1; j < n; j++ {
+			out[j][i] = out[i][j]
+		}
+	}
+	return out
+}
+
+// PAM partitions around medoids with the BUILD and SWAP phases of Kaufman
+// and Rousseeuw, as in R's cluster::pam, on a symmetric dissimilarity
+// matrix.
+func PAM(diss [][]float64, k int, maxIter int) (*PAMResult, error) {
+	n := len(diss)
+	if n == 0 {
+		return nil, errors.New("data must not be empty")
+	}
+	if k <= 0 || k > n {
+		return nil, errors.New("k must be between 1 and the row count")
+	}
+	for i := range diss {
+		if len(diss[i]) != n {
+			return nil, errors.New("dissimilarity matrix must be square")
+		}
+		for _, v := range diss[i] {
+			if math.IsNaN(v) || v < 0 {
+				return nil, errors.New("dissimilarities must be non-negative numbers")
+			}
+		}
+	}
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+
+	isMedoid := make([]bool, n)
+	var medoids []int
+	nearest := make([]float64, n)
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+	// BUILD: greedily add the point that lowers the total cost the most.
+	for len(medoids) < k {
+		best, bestGain := -1, math.Inf(-1)
+		for h := range n {
+			if isMedoid[h] {
+				continue
+			}
+			gain := 0.0
+			for j := range n {
+				if len(medoids) == 0 {
+					gain -= diss[j][h]
+				} else {
+					gain += math.Max(nearest[j]-diss[j][h], 0)
+				}
+			}
+			if gain > bestGain {
+				best, bestGain = h, gain
+			}
+		}
+		isMedoid[best] = true
+		medoids = append(medoids, best)
+		for j := range n {
+			nearest[j] = math.Min(nearest[j], diss[j][best])
+		}
+	}
+
+	// SWAP: apply the best medoid/non-medoid exchange while it helps.
+	swaps := 0
+	for range maxIter {
+		near, second, nearIdx := pamNearest(diss, medoids)
+		bestDelta, bestM, bestH := 0.0, -1, -1
+		for m, i := range medoids {
+			for h := range n {
+				if isMedoid[h] {
+					continue
+				}
+				delta := 0.0
+				for j := range n {
+					if nearIdx[j] == i {
+						delta += math.Min(diss[j][h], second[j]) - near[j]
+					} else {
+						delta += math.Min(diss[j][h]-near[j], 0)
+					}
+				}
+				if delta < bestDelta-1e-10 {
+					bestDelta, bestM, bestH = delta, m, h
+				}
+			}
+		}
+		if bestM < 0 {
+			break
+		}
+		isMedoid[medoids[bestM]] = false
+		isMedoid[bestH] = true
+		medoids[bestM] = bestH
+		swaps++
+	}
+
+	res := &PAMResult{Medoids: medoids, Cluster: make([]int, n), Size: make([]int, k), Swaps: swaps}
+	near, _, nearIdx := pamNearest(diss, medoids)
+	index := make(map[int]int, k)
+	for m, i := range medoids {
+		index[i] = m
+	}
+	for j := range n {
+		c := index[nearIdx[j]]
+		res.Cluster[j] = c + 1
+		res.Size[c]++
+		res.Cost += near[j]
+	}
+	return res, nil
+}
+
+// pamNearest returns, for every point, the distance to its nearest and
+// second-nearest medoid and the nearest medoid's row index.
+func pamNearest(diss [][]float64, medoids []int) ([]float64, []float64, []int) {
+	n := len(diss)
+	near := make([]float64, n)
+	second := make([]float64, n)
+	nearIdx := make([]int, n)
+	for j := range n {
+		near[j], second[j] = math.Inf(1), math.Inf(1)
+		for _, i := range medoids {
+			d := diss[j][i]
+			if d < near[j] {
+				second[j] = near[j]
+				near[j], nearIdx[j] = d, i
+			} else if d < second[j] {
+				second[j] = d
+			}
+		}
+	}
+	return near, second, nearIdx
+}
@@ -183,3 +183,49 @@ func lbfgsb(start, lower, upper []float64,
 		Task:      res.Task,
 	}, nil
 }
+
+// BoundedOptions configures MinimizeBounded. Zero values fall back to the
+// R optim() defaults used by lbfgsb.
+type BoundedOptions struct {
+	M        int
+	Factr    float64
+	PgTol    float64
+	MaxIter  int
+	Parscale []float64
+}
+
+// BoundedResult is the outcome of MinimizeBounded.
+type BoundedResult struct {
+	X         []float64
+	F         float64
+	Iters     int
+	Converged bool
+}
+
+// MinimizeBounded exposes the L-BFGS-B driver to the other stats packages
+// (time-series, SEM, mixed-model and non-linear likelihood fitting). Bounds
+// follow the lbfgsb convention: ±Inf means unbounded on that side. grad
+// receives a zeroed g on every call.
+func MinimizeBounded(start, lower, upper []float64,
+	fn func([]float64) float64,
+	grad func(g, x []float64),
+	opts BoundedOptions,
+) (*BoundedResult, error) {
+	if fn == nil || grad == nil {
+		return nil, errors.New("lbfgsb: nil objective or gradient")
+	}
+	gradWrap := func(g, x []float64) {
+		for i := range g {
+			g[i] = 0
+		}
+		grad(g, x)
+	}
+	res, err := lbfgsb(start, lower, upper, fn, gradWrap, lbfgsbParams{
+		M: opts.M, Factr: opts.Factr, PgTol: opts.PgTol,
+		MaxIter: opts.MaxIter, Parscale: opts.Parscale,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BoundedResult{X: res.X, F: res.F, Iters: res.Iters, Converged: res.Converged}, nil
+}
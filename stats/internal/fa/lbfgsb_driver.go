@@ -112,12 +112,16 @@ func setulbDriver(n, m int, x, l, u []float64, nbd []int,
 		}, nil
 	}
 
+	// nenter / ileave persist across iterations as in mainlb: for
+	// unconstrained problems the GCP search (and freev) is skipped after
+	// the first iteration, and formk reuses the last partition.
+	nenter, ileave := 0, n+1
+
 	// ============================ main loop ===============================
 	for iter < maxIter {
 		// (1) Generalized Cauchy point.
 		var info int
 		var wrk bool
-		var ileave, nenter int
 
 		if !cnstnd && col > 0 {
 			dcopy(n, x, 1, z, 1)
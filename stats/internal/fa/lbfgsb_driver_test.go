@@ -0,0 +1,46 @@
+package fa
+
+import (
+	"math"
+	"testing"
+)
+
+// TestMinimizeBoundedUnconstrained runs the driver with no finite bounds.
+// mainlb then skips the Cauchy point and freev after the first iteration,
+// so nenter / ileave must keep their values across iterations for formk;
+// resetting them every iteration made formk index indx2[-1].
+func TestMinimizeBoundedUnconstrained(t *testing.T) {
+	const n = 6
+	rosen := func(x []float64) float64 {
+		s := 0.0
+		for i := 0; i < n-1; i++ {
+			a, b := x[i+1]-x[i]*x[i], 1-x[i]
+			s += 100*a*a + b*b
+		}
+		return s
+	}
+	grad := func(g, x []float64) {
+		for i := 0; i < n-1; i++ {
+			a := x[i+1] - x[i]*x[i]
+			g[i] += -400*x[i]*a - 2*(1-x[i])
+			g[i+1] += 200 * a
+		}
+	}
+	start := []float64{-1.2, 1, -1.2, 1, -1.2, 1}
+	lower, upper := make([]float64, n), make([]float64, n)
+	for i := range lower {
+		lower[i], upper[i] = math.Inf(-1), math.Inf(1)
+	}
+	res, err := MinimizeBounded(start, lower, upper, rosen, grad, BoundedOptions{Factr: 1, MaxIter: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Converged || res.Iters < 10 {
+		t.Fatalf("converged = %v after %d iterations", res.Converged, res.Iters)
+	}
+	for i, v := range res.X {
+		if math.Abs(v-1) > 1e-5 {
+			t.Fatalf("x[%d] = %v, want 1 (f = %v)", i, v, res.F)
+		}
+	}
+}
@@ -0,0 +1,672 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type LMMMethod string
+
+const (
+	LMMREML LMMMethod = "REML"
+	LMMML   LMMMethod = "ML"
+)
+
+// LMMRandomEffect is one random-effect term: a random intercept for each
+// level of Group plus correlated random slopes for the listed numeric
+// columns, i.e. lme4's (1 + slope1 + ... | Group).
+type LMMRandomEffect struct {
+	Group  string
+	Slopes []string
+}
+
+type LMMOptions struct {
+	Response string
+	// Fixed lists the fixed-effect columns; an intercept is always
+	// included. Columns holding strings or booleans are factors coded with
+	// R's treatment contrasts against their first sorted level, and their
+	// coefficients are named column + level (e.g. "dosehigh").
+	Fixed  []string
+	Random []LMMRandomEffect
+	// Method defaults to LMMREML.
+	Method          LMMMethod
+	ConfidenceLevel float64
+	MaxIter         int
+}
+
+// LMMResult holds a linear mixed-model fit estimated through lme4's
+// profiled (RE)ML deviance.
+//
+// VarianceComponents has columns Group, Name, Variance and StdDev with a
+// final Residual row; RandomCovariances holds the full covariance matrix of
+// each random-effect term. RandomEffects holds the conditional modes (BLUPs)
+// per term with one row per group level. ICC is the share of the total
+// variance attributable to the random intercepts. PValues use the normal
+// approximation to the t statistics.
+type LMMResult struct {
+	Method              LMMMethod
+	FixedEffectNames    []string
+	Coefficients        []float64
+	StandardErrors      []float64
+	TValues             []float64
+	PValues             []float64
+	ConfidenceIntervals [][2]float64
+	VarianceComponents  *insyra.DataTable
+	RandomCovariances   [][][]float64
+	RandomEffects       []*insyra.DataTable
+	ResidualVariance    float64
+	ICC                 float64
+	LogLikelihood       float64
+	Deviance            float64
+	AIC                 float64
+	BIC                 float64
+	NObs                int
+	NGroups             []int
+	Fitted              []float64
+	Residuals           []float64
+	Converged           bool
+	Iterations          int
+	ConfidenceLevel     float64
+
+	data *lmmData
+	opts LMMOptions
+}
+
+// LMM fits a linear mixed-effects model from a DataTable.
+func LMM(dataTable insyra.IDataTable, opts LMMOptions) (*LMMResult, error) {
+	method := opts.Method
+	if method == "" {
+		method = LMMREML
+	}
+	if method != LMMREML && method != LMMML {
+		return nil, fmt.Errorf("unsupported LMM method %q", method)
+	}
+	if opts.Response == "" {
+		return nil, errors.New("response column is required")
+	}
+	if len(opts.Random) == 0 {
+		return nil, errors.New("at least one random-effect term is required")
+	}
+	data, err := buildLMMData(dataTable, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.Method = method
+	return fitLMM(data, opts)
+}
+
+type lmmTerm struct {
+	group  string
+	names  []string // (Intercept), slopes...
+	levels []any
+	m      int
+	offset int // first column of this term in Z
+}
+
+type lmmData struct {
+	n, p, q int
+	y       []float64
+	X       [][]float64 // n×p
+	// Per-row sparse Z: zIdx[i][k] is the column of zVal[i][k].
+	zIdx    [][]int
+	zVal    [][]float64
+	terms   []lmmTerm
+	fixed   []string
+	nTheta  int
+	ZtZ     []float64 // q×q
+	ZtX     []float64 // q×p
+	Zty     []float64
+	XtX     []float64 // p×p
+	Xty     []float64
+	yty     float64
+	lowerTh []float64
+}
+
+func buildLMMData(dataTable insyra.IDataTable, opts LMMOptions) (*lmmData, error) {
+	names := []string{opts.Response}
+	names = append(names, opts.Fixed...)
+	for _, re := range opts.Random {
+		if re.Group == "" {
+			return nil, errors.New("random-effect group column is required")
+		}
+		names = append(names, re.Group)
+		names = append(names, re.Slopes...)
+	}
+	cols, n, err := rawColumnsByName(dataTable, names)
+	if err != nil {
+		return nil, err
+	}
+	col := map[string][]any{}
+	for i, name := range names {
+		col[name] = cols[i]
+	}
+	y, err := numericColumn(col[opts.Response], opts.Response)
+	if err != nil {
+		return nil, err
+	}
+	d := &lmmData{n: n, y: y}
+	d.fixed = []string{"(Intercept)"}
+	var fixedVals [][]float64
+	for _, name := range opts.Fixed {
+		if !isCategoricalColumn(col[name]) {
+			v, err := numericColumn(col[name], name)
+			if err != nil {
+				return nil, err
+			}
+			fixedVals = append(fixedVals, v)
+			d.fixed = append(d.fixed, name)
+			continue
+		}
+		codes, levels, err := factorLevels(col[name], name)
+		if err != nil {
+			return nil, err
+		}
+		if len(levels) < 2 {
+			return nil, fmt.Errorf("fixed factor %q needs at least two levels", name)
+		}
+		fixedVals = append(fixedVals, treatmentContrasts(codes, len(levels))...)
+		for _, lv := range levels[1:] {
+			d.fixed = append(d.fixed, fmt.Sprintf("%s%v", name, lv))
+		}
+	}
+	d.p = len(d.fixed)
+	d.X = make([][]float64, n)
+	for i := range n {
+		row := make([]float64, d.p)
+		row[0] = 1
+		for j := range fixedVals {
+			row[j+1] = fixedVals[j][i]
+		}
+		d.X[i] = row
+	}
+
+	d.zIdx = make([][]int, n)
+	d.zVal = make([][]float64, n)
+	for _, re := range opts.Random {
+		codes, levels, err := factorLevels(col[re.Group], re.Group)
+		if err != nil {
+			return nil, err
+		}
+		slopeVals := make([][]float64, len(re.Slopes))
+		for j, name := range re.Slopes {
+			if slopeVals[j], err = numericColumn(col[name], name); err != nil {
+				return nil, err
+			}
+		}
+		term := lmmTerm{
+			group:  re.Group,
+			names:  append([]string{"(Intercept)"}, re.Slopes...),
+			levels: levels,
+			m:      1 + len(re.Slopes),
+			offset: d.q,
+		}
+		for i := range n {
+			base := term.offset + codes[i]*term.m
+			d.zIdx[i] = append(d.zIdx[i], base)
+			d.zVal[i] = append(d.zVal[i], 1)
+			for j := range re.Slopes {
+				d.zIdx[i] = append(d.zIdx[i], base+j+1)
+				d.zVal[i] = append(d.zVal[i], slopeVals[j][i])
+			}
+		}
+		d.q += len(levels) * term.m
+		for c := range term.m {
+			for r := c; r < term.m; r++ {
+				if r == c {
+					d.lowerTh = append(d.lowerTh, 0)
+				} else {
+					d.lowerTh = append(d.lowerTh, math.Inf(-1))
+				}
+			}
+		}
+		d.nTheta += term.m * (term.m + 1) / 2
+		d.terms = append(d.terms, term)
+	}
+	if n <= d.p+1 {
+		return nil, errors.New("too few observations for the fixed effects")
+	}
+
+	// Cross-products used by every deviance evaluation.
+	q, p := d.q, d.p
+	d.ZtZ = make([]float64, q*q)
+	d.ZtX = make([]float64, q*p)
+	d.Zty = make([]float64, q)
+	d.XtX = make([]float64, p*p)
+	d.Xty = make([]float64, p)
+	for i := range n {
+		for a, ca := range d.zIdx[i] {
+			va := d.zVal[i][a]
+			for b, cb := range d.zIdx[i] {
+				d.ZtZ[ca*q+cb] += va * d.zVal[i][b]
+			}
+			for j := range p {
+				d.ZtX[ca*p+j] += va * d.X[i][j]
+			}
+			d.Zty[ca] += va * y[i]
+		}
+		for a := range p {
+			for b := range p {
+				d.XtX[a*p+b] += d.X[i][a] * d.X[i][b]
+			}
+			d.Xty[a] += d.X[i][a] * y[i]
+		}
+		d.yty += y[i] * y[i]
+	}
+	return d, nil
+}
+
+// lmmTermFactors unpacks theta into the lower-triangular relative
+// covariance factors of each term (column-major, as in lme4).
+func (d *lmmData) lmmTermFactors(theta []float64) [][]float64 {
+	out := make([][]float64, len(d.terms))
+	k := 0
+	for t, term := range d.terms {
+		m := term.m
+		T := make([]float64, m*m)
+		for c := range m {
+			for r := c; r < m; r++ {
+				T[r*m+c] = theta[k]
+				k++
+			}
+		}
+		out[t] = T
+	}
+	return out
+}
+
+// applyLambdaT overwrites rows of a q×w row-major matrix with Λ'·M, where Λ
+// is block diagonal with one copy of T per group level.
+func (d *lmmData) applyLambdaT(M []float64, w int, factors [][]float64) []float64 {
+	out := make([]float64, len(M))
+	for t, term := range d.terms {
+		T := factors[t]
+		m := term.m
+		for l := range term.levels {
+			base := term.offset + l*m
+			for a := range m {
+				for c := range w {
+					s := 0.0
+					for b := a; b < m; b++ {
+						// (T')[a][b] = T[b][a]
+						s += T[b*m+a] * M[(base+b)*w+c]
+					}
+					out[(base+a)*w+c] = s
+				}
+			}
+		}
+	}
+	return out
+}
+
+type lmmSolution struct {
+	deviance float64
+	beta     []float64
+	b        []float64
+	sigma2   float64
+	covBeta  [][]float64
+}
+
+// evaluate computes the profiled deviance (REML criterion when reml) at
+// theta by solving the penalised least-squares problem.
+func (d *lmmData) evaluate(theta []float64, reml bool, full bool) (*lmmSolution, error) {
+	q, p, n := d.q, d.p, d.n
+	factors := d.lmmTermFactors(theta)
+
+	// A = Λ'Z'ZΛ + I
+	left := d.applyLambdaT(d.ZtZ, q, factors)
+	leftT := make([]float64, q*q)
+	for i := range q {
+		for j := range q {
+			leftT[j*q+i] = left[i*q+j]
+		}
+	}
+	A := d.applyLambdaT(leftT, q, factors)
+	sym := mat.NewSymDense(q, nil)
+	for i := range q {
+		for j := i; j < q; j++ {
+			v := 0.5 * (A[i*q+j] + A[j*q+i])
+			if i == j {
+				v++
+			}
+			sym.SetSym(i, j, v)
+		}
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(sym) {
+		return nil, errors.New("random-effects system is not positive definite")
+	}
+	logDetL2 := chol.LogDet()
+	var L mat.TriDense
+	chol.LTo(&L)
+
+	lzx := d.applyLambdaT(d.ZtX, p, factors)
+	lzy := d.applyLambdaT(d.Zty, 1, factors)
+	forwardSolveLower(&L, lzx, p)
+	forwardSolveLower(&L, lzy, 1)
+	rzx := mat.NewDense(q, p, lzx)
+	cu := mat.NewVecDense(q, lzy)
+
+	M := mat.NewSymDense(p, nil)
+	v := mat.NewVecDense(p, nil)
+	for a := range p {
+		s := d.Xty[a]
+		for k := range q {
+			s -= rzx.At(k, a) * cu.AtVec(k)
+		}
+		v.SetVec(a, s)
+		for b := a; b < p; b++ {
+			s := d.XtX[a*p+b]
+			for k := range q {
+				s -= rzx.At(k, a) * rzx.At(k, b)
+			}
+			M.SetSym(a, b, s)
+		}
+	}
+	var cholX mat.Cholesky
+	if !cholX.Factorize(M) {
+		return nil, errors.New("fixed-effects design is rank deficient")
+	}
+	var betaVec mat.VecDense
+	if err := cholX.SolveVecTo(&betaVec, v); err != nil {
+		return nil, err
+	}
+	beta := make([]float64, p)
+	for j := range p {
+		beta[j] = betaVec.AtVec(j)
+	}
+
+	// u = L'^{-1}(cu - RZX β); b = Λ u
+	rhs := make([]float64, q)
+	for k := range q {
+		s := cu.AtVec(k)
+		for j := range p {
+			s -= rzx.At(k, j) * beta[j]
+		}
+		rhs[k] = s
+	}
+	backSolveLowerT(&L, rhs)
+	u := mat.NewVecDense(q, rhs)
+	b := make([]float64, q)
+	for t, term := range d.terms {
+		T := factors[t]
+		m := term.m
+		for l := range term.levels {
+			base := term.offset + l*m
+			for a := range m {
+				s := 0.0
+				for c := 0; c <= a; c++ {
+					s += T[a*m+c] * u.AtVec(base+c)
+				}
+				b[base+a] = s
+			}
+		}
+	}
+	pwrss := 0.0
+	for k := range q {
+		pwrss += u.AtVec(k) * u.AtVec(k)
+	}
+	for i := range n {
+		r := d.y[i]
+		for j := range p {
+			r -= d.X[i][j] * beta[j]
+		}
+		for k, c := range d.zIdx[i] {
+			r -= d.zVal[i][k] * b[c]
+		}
+		pwrss += r * r
+	}
+
+	nf := float64(n)
+	var dev, sigma2 float64
+	if reml {
+		df := float64(n - p)
+		sigma2 = pwrss / df
+		dev = logDetL2 + cholX.LogDet() + df*(1+math.Log(2*math.Pi*pwrss/df))
+	} else {
+		sigma2 = pwrss / nf
+		dev = logDetL2 + nf*(1+math.Log(2*math.Pi*pwrss/nf))
+	}
+	sol := &lmmSolution{deviance: dev, beta: beta, b: b, sigma2: sigma2}
+	if full {
+		var inv mat.SymDense
+		if err := cholX.InverseTo(&inv); err != nil {
+			return nil, err
+		}
+		sol.covBeta = make([][]float64, p)
+		for i := range p {
+			sol.covBeta[i] = make([]float64, p)
+			for j := range p {
+				sol.covBeta[i][j] = sigma2 * inv.At(i, j)
+			}
+		}
+	}
+	return sol, nil
+}
+
+// forwardSolveLower overwrites the q×w row-major B with L⁻¹B.
+func forwardSolveLower(L *mat.TriDense, B []float64, w int) {
+	q, _ := L.Dims()
+	for c := range w {
+		for i := range q {
+			s := B[i*w+c]
+			for k := 0; k < i; k++ {
+				s -= L.At(i, k) * B[k*w+c]
+			}
+			B[i*w+c] = s / L.At(i, i)
+		}
+	}
+}
+
+// backSolveLowerT overwrites b with L'⁻¹b.
+func backSolveLowerT(L *mat.TriDense, b []float64) {
+	q, _ := L.Dims()
+	for i := q - 1; i >= 0; i-- {
+		s := b[i]
+		for k := i + 1; k < q; k++ {
+			s -= L.At(k, i) * b[k]
+		}
+		b[i] = s / L.At(i, i)
+	}
+}
+
+func fitLMM(d *lmmData, opts LMMOptions) (*LMMResult, error) {
+	reml := opts.Method == LMMREML
+	theta := make([]float64, d.nTheta)
+	for i, lo := range d.lowerTh {
+		if lo == 0 {
+			theta[i] = 1
+		}
+	}
+	obj := func(th []float64) float64 {
+		sol, err := d.evaluate(th, reml, false)
+		if err != nil {
+			return math.Inf(1)
+		}
+		return sol.deviance
+	}
+	upper := make([]float64, d.nTheta)
+	for i := range upper {
+		upper[i] = math.Inf(1)
+	}
+	res, err := minimizeNumeric(obj, theta, boundedMinimizeOptions{lower: d.lowerTh, upper: upper, maxIter: opts.MaxIter})
+	if err != nil {
+		return nil, fmt.Errorf("LMM optimisation failed: %w", err)
+	}
+	theta = res.X
+	sol, err := d.evaluate(theta, reml, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p := d.p
+	se := make([]float64, p)
+	tv := make([]float64, p)
+	pv := make([]float64, p)
+	for j := range p {
+		se[j] = math.Sqrt(sol.covBeta[j][j])
+		tv[j] = sol.beta[j] / se[j]
+		pv[j] = zPValue(tv[j], TwoSided)
+	}
+	cl := resolveConfidenceLevel(opts.ConfidenceLevel)
+	cis := buildGLMCoeffCIs(sol.beta, se, cl)
+
+	factors := d.lmmTermFactors(theta)
+	groupCol := insyra.NewDataList().SetName("Group")
+	nameCol := insyra.NewDataList().SetName("Name")
+	varCol := insyra.NewDataList().SetName("Variance")
+	sdCol := insyra.NewDataList().SetName("StdDev")
+	covs := make([][][]float64, len(d.terms))
+	blups := make([]*insyra.DataTable, len(d.terms))
+	nGroups := make([]int, len(d.terms))
+	interceptVar := 0.0
+	for t, term := range d.terms {
+		m := term.m
+		T := factors[t]
+		cov := make([][]float64, m)
+		for a := range m {
+			cov[a] = make([]float64, m)
+			for c := range m {
+				s := 0.0
+				for k := 0; k <= min(a, c); k++ {
+					s += T[a*m+k] * T[c*m+k]
+				}
+				cov[a][c] = sol.sigma2 * s
+			}
+		}
+		covs[t] = cov
+		interceptVar += cov[0][0]
+		nGroups[t] = len(term.levels)
+		for a := range m {
+			groupCol.Append(term.group)
+			nameCol.Append(term.names[a])
+			varCol.Append(cov[a][a])
+			sdCol.Append(math.Sqrt(cov[a][a]))
+		}
+
+		levelCol := insyra.NewDataList().SetName(term.group)
+		effCols := make([]*insyra.DataList, m)
+		for a := range m {
+			effCols[a] = insyra.NewDataList().SetName(term.names[a])
+		}
+		for l, level := range term.levels {
+			levelCol.Append(level)
+			for a := range m {
+				effCols[a].Append(sol.b[term.offset+l*m+a])
+			}
+		}
+		tableCols := []*insyra.DataList{levelCol}
+		for _, c := range effCols {
+			tableCols = append(tableCols, c)
+		}
+		blups[t] = insyra.NewDataTable(tableCols...)
+	}
+	groupCol.Append("Residual")
+	nameCol.Append("")
+	varCol.Append(sol.sigma2)
+	sdCol.Append(math.Sqrt(sol.sigma2))
+
+	fitted := make([]float64, d.n)
+	resid := make([]float64, d.n)
+	for i := range d.n {
+		f := 0.0
+		for j := range p {
+			f += d.X[i][j] * sol.beta[j]
+		}
+		for k, c := range d.zIdx[i] {
+			f += d.zVal[i][k] * sol.b[c]
+		}
+		fitted[i] = f
+		resid[i] = d.y[i] - f
+	}
+
+	npar := p + d.nTheta + 1
+	logLik := -sol.deviance / 2
+	return &LMMResult{
+		Method:              opts.Method,
+		FixedEffectNames:    append([]string(nil), d.fixed...),
+		Coefficients:        sol.beta,
+		StandardErrors:      se,
+		TValues:             tv,
+		PValues:             pv,
+		ConfidenceIntervals: cis,
+		VarianceComponents:  insyra.NewDataTable(groupCol, nameCol, varCol, sdCol),
+		RandomCovariances:   covs,
+		RandomEffects:       blups,
+		ResidualVariance:    sol.sigma2,
+		ICC:                 interceptVar / (interceptVar + sol.sigma2),
+		LogLikelihood:       logLik,
+		Deviance:            sol.deviance,
+		AIC:                 glmAIC(logLik, npar),
+		BIC:                 glmBIC(logLik, npar, d.n),
+		NObs:                d.n,
+		NGroups:             nGroups,
+		Fitted:              fitted,
+		Residuals:           resid,
+		Converged:           res.Converged,
+		Iterations:          res.Iters,
+		ConfidenceLevel:     cl,
+		data:                d,
+		opts:                opts,
+	}, nil
+}
+
+// LMMLRTResult is a likelihood-ratio comparison of two nested mixed
+// models. Refitted reports whether REML fits were refitted with ML first.
+type LMMLRTResult struct {
+	testResultBase
+	LogLikReduced float64
+	LogLikFull    float64
+	Refitted      bool
+}
+
+// LMMLikelihoodRatioTest compares nested mixed models like lme4's
+// anova(reduced, full): REML fits are refitted by ML, and the statistic
+// 2*(logLik_full - logLik_reduced) is referred to a chi-square with the
+// difference in parameter counts. Tests on variance components lie on the
+// boundary of the parameter space, so their p-values are conservative.
+func LMMLikelihoodRatioTest(reduced, full *LMMResult) (*LMMLRTResult, error) {
+	if reduced == nil || full == nil || reduced.data == nil || full.data == nil {
+		return nil, errors.New("both models must be fitted with LMM")
+	}
+	if reduced.NObs != full.NObs || reduced.opts.Response != full.opts.Response {
+		return nil, errors.New("models must be fitted to the same response and observations")
+	}
+	kReduced := reduced.data.p + reduced.data.nTheta
+	kFull := full.data.p + full.data.nTheta
+	if kFull <= kReduced {
+		return nil, errors.New("full model must have more parameters than the reduced model")
+	}
+	refit := func(r *LMMResult) (*LMMResult, error) {
+		if r.Method == LMMML {
+			return r, nil
+		}
+		opts := r.opts
+		opts.Method = LMMML
+		return fitLMM(r.data, opts)
+	}
+	refitted := reduced.Method != LMMML || full.Method != LMMML
+	r0, err := refit(reduced)
+	if err != nil {
+		return nil, err
+	}
+	r1, err := refit(full)
+	if err != nil {
+		return nil, err
+	}
+	stat := math.Max(0, 2*(r1.LogLikelihood-r0.LogLikelihood))
+	df := float64(kFull - kReduced)
+	return &LMMLRTResult{
+		testResultBase: testResultBase{
+			Statistic: stat,
+			PValue:    chiSquaredPValue(stat, df),
+			DF:        &df,
+		},
+		LogLikReduced: r0.LogLikelihood,
+		LogLikFull:    r1.LogLikelihood,
+		Refitted:      refitted,
+	}, nil
+}
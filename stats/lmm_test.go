@@ -0,0 +1,315 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// dyestuffTable is lme4's Dyestuff data: yield of dyestuff for five
+// preparations from each of six batches.
+func dyestuffTable() *insyra.DataTable {
+	yields := [][]float64{
+		{1545, 1440, 1440, 1520, 1580},
+		{1540, 1555, 1490, 1560, 1495},
+		{1595, 1550, 1605, 1510, 1560},
+		{1445, 1440, 1595, 1465, 1545},
+		{1595, 1630, 1515, 1635, 1625},
+		{1520, 1455, 1450, 1480, 1445},
+	}
+	batch := insyra.NewDataList().SetName("Batch")
+	yield := insyra.NewDataList().SetName("Yield")
+	for b, row := range yields {
+		for _, v := range row {
+			batch.Append(string(rune('A' + b)))
+			yield.Append(v)
+		}
+	}
+	return insyra.NewDataTable(batch, yield)
+}
+
+func TestLMMDyestuffREML(t *testing.T) {
+	res, err := stats.LMM(dyestuffTable(), stats.LMMOptions{
+		Response: "Yield",
+		Random:   []stats.LMMRandomEffect{{Group: "Batch"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lme4: lmer(Yield ~ 1 + (1 | Batch), Dyestuff) -> intercept 1527.50
+	// (SE 19.38), Batch variance 1764.0, residual 2451.3, REML criterion
+	// 319.65.
+	if !floatAlmostEqual(res.Coefficients[0], 1527.5, 1e-8) {
+		t.Errorf("intercept = %v", res.Coefficients[0])
+	}
+	if !floatAlmostEqual(res.StandardErrors[0], 19.38, 0.01) {
+		t.Errorf("SE = %v", res.StandardErrors[0])
+	}
+	batchVar := res.RandomCovariances[0][0][0]
+	if !floatAlmostEqual(batchVar, 1764.0, 0.5) || !floatAlmostEqual(res.ResidualVariance, 2451.3, 0.5) {
+		t.Errorf("variances = %v, %v", batchVar, res.ResidualVariance)
+	}
+	if !floatAlmostEqual(res.Deviance, 319.65, 0.01) {
+		t.Errorf("REML criterion = %v", res.Deviance)
+	}
+
+	// Balanced one-way REML equals the ANOVA estimators.
+	msw, msb := 2451.25, 11271.5
+	if !floatAlmostEqual(batchVar, (msb-msw)/5, 0.05) || !floatAlmostEqual(res.ResidualVariance, msw, 0.05) {
+		t.Errorf("ANOVA cross-check failed: %v, %v", batchVar, res.ResidualVariance)
+	}
+	if !floatAlmostEqual(res.ICC, batchVar/(batchVar+res.ResidualVariance), 1e-12) {
+		t.Errorf("ICC = %v", res.ICC)
+	}
+	if r, c := res.VarianceComponents.Size(); r != 2 || c != 4 {
+		t.Errorf("variance table size = %dx%d", r, c)
+	}
+	if r, _ := res.RandomEffects[0].Size(); r != 6 || res.NGroups[0] != 6 {
+		t.Errorf("random effects rows = %d, groups = %v", r, res.NGroups)
+	}
+	// BLUPs sum to zero in a balanced random-intercept model.
+	sum := 0.0
+	for _, v := range res.RandomEffects[0].GetColByName("(Intercept)").Data() {
+		sum += v.(float64)
+	}
+	if math.Abs(sum) > 1e-6 {
+		t.Errorf("BLUP sum = %v", sum)
+	}
+}
+
+func TestLMMDyestuffML(t *testing.T) {
+	res, err := stats.LMM(dyestuffTable(), stats.LMMOptions{
+		Response: "Yield",
+		Random:   []stats.LMMRandomEffect{{Group: "Batch"}},
+		Method:   stats.LMMML,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lme4: lmer(..., REML = FALSE) -> Batch variance 1388.3, residual
+	// 2451.3, intercept SE 17.69, deviance 327.33.
+	if !floatAlmostEqual(res.RandomCovariances[0][0][0], 1388.3, 0.5) {
+		t.Errorf("batch variance = %v", res.RandomCovariances[0][0][0])
+	}
+	if !floatAlmostEqual(res.StandardErrors[0], 17.69, 0.01) {
+		t.Errorf("SE = %v", res.StandardErrors[0])
+	}
+	if !floatAlmostEqual(res.Deviance, 327.33, 0.01) || !floatAlmostEqual(res.LogLikelihood, -163.66, 0.01) {
+		t.Errorf("deviance = %v, logLik = %v", res.Deviance, res.LogLikelihood)
+	}
+	if !floatAlmostEqual(res.AIC, 333.33, 0.01) {
+		t.Errorf("AIC = %v", res.AIC)
+	}
+}
+
+// sleepstudyTable is lme4's sleepstudy data: average reaction time (ms) of
+// 18 subjects over ten days of sleep deprivation.
+func sleepstudyTable() *insyra.DataTable {
+	subjects := []struct {
+		id       int
+		reaction []float64
+	}{
+		{308, []float64{249.5600, 258.7047, 250.8006, 321.4398, 356.8519, 414.6901, 382.2038, 290.1486, 430.5853, 466.3535}},
+		{309, []float64{222.7339, 205.2658, 202.9778, 204.7070, 207.7161, 215.9618, 213.6303, 217.7272, 224.2957, 237.3142}},
+		{310, []float64{199.0539, 194.3322, 234.3200, 232.8416, 229.3074, 220.4579, 235.4208, 255.7511, 261.0125, 247.5153}},
+		{330, []float64{321.5426, 300.4002, 283.8565, 285.1330, 285.7973, 297.5855, 280.2396, 318.2613, 305.3495, 354.0487}},
+		{331, []float64{287.6079, 285.0000, 301.8206, 320.1153, 316.2773, 293.3187, 290.0750, 334.8177, 293.7469, 371.5811}},
+		{332, []float64{234.8606, 242.8118, 272.9613, 309.7688, 317.4629, 309.9976, 454.1619, 346.8311, 330.3003, 253.8644}},
+		{333, []float64{283.8424, 289.5550, 276.7693, 299.8097, 297.1710, 338.1665, 332.0265, 348.8399, 333.3600, 362.0428}},
+		{334, []float64{265.4731, 276.2012, 243.3647, 254.6723, 279.0244, 284.1912, 305.5248, 331.5229, 335.7469, 377.2990}},
+		{335, []float64{241.6083, 273.9472, 254.4907, 270.8021, 251.4519, 254.6362, 245.4523, 235.3110, 235.7541, 237.2466}},
+		{337, []float64{312.3666, 313.8058, 291.6112, 346.1222, 365.7324, 391.8385, 404.2601, 416.6923, 455.8643, 458.9167}},
+		{349, []float64{236.1032, 230.3167, 238.9256, 254.9220, 250.7103, 269.7744, 281.5648, 308.1020, 336.2806, 351.6451}},
+		{350, []float64{256.2968, 243.4543, 256.2046, 255.5271, 268.9165, 329.7247, 379.4445, 362.9184, 394.4872, 389.0527}},
+		{351, []float64{250.5265, 300.0576, 269.8939, 280.5891, 271.8274, 304.6336, 287.7466, 266.5955, 321.5418, 347.5655}},
+		{352, []float64{221.6771, 298.1939, 326.8785, 346.8555, 348.7402, 352.8287, 354.4266, 360.4326, 375.6406, 388.5417}},
+		{369, []float64{271.9235, 268.4369, 257.2424, 277.6566, 314.8222, 317.2135, 298.1353, 348.1229, 340.2800, 366.5131}},
+		{370, []float64{225.2640, 234.5235, 238.9008, 240.4730, 267.5373, 344.1937, 281.1481, 347.5855, 365.1630, 372.2288}},
+		{371, []float64{269.8804, 272.4428, 277.8989, 281.7895, 279.1705, 284.5120, 259.2658, 304.6306, 350.7807, 369.4692}},
+		{372, []float64{269.4117, 273.4740, 297.5968, 310.6316, 287.1726, 329.6076, 334.4818, 343.2199, 369.1417, 364.1236}},
+	}
+	subject := insyra.NewDataList().SetName("Subject")
+	days := insyra.NewDataList().SetName("Days")
+	reaction := insyra.NewDataList().SetName("Reaction")
+	for _, s := range subjects {
+		for d, r := range s.reaction {
+			subject.Append(s.id)
+			days.Append(float64(d))
+			reaction.Append(r)
+		}
+	}
+	return insyra.NewDataTable(subject, days, reaction)
+}
+
+func TestLMMSleepstudyRandomSlopes(t *testing.T) {
+	dt := sleepstudyTable()
+	slopes := stats.LMMOptions{
+		Response: "Reaction",
+		Fixed:    []string{"Days"},
+		Random:   []stats.LMMRandomEffect{{Group: "Subject", Slopes: []string{"Days"}}},
+	}
+	full, err := stats.LMM(dt, slopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// lme4: lmer(Reaction ~ Days + (Days | Subject), sleepstudy) ->
+	// fixed effects 251.405 (SE 6.825) and 10.467 (SE 1.546); Subject
+	// variances 612.10 and 35.07 with correlation 0.066; residual 654.94;
+	// REML criterion 1743.6.
+	wantCoef, wantSE := []float64{251.405, 10.467}, []float64{6.825, 1.546}
+	for j := range wantCoef {
+		if !floatAlmostEqual(full.Coefficients[j], wantCoef[j], 5e-4) || !floatAlmostEqual(full.StandardErrors[j], wantSE[j], 5e-4) {
+			t.Errorf("fixed effect %d = %v (SE %v), want %v (SE %v)", j, full.Coefficients[j], full.StandardErrors[j], wantCoef[j], wantSE[j])
+		}
+	}
+	cov := full.RandomCovariances[0]
+	corr := cov[0][1] / math.Sqrt(cov[0][0]*cov[1][1])
+	if !floatAlmostEqual(cov[0][0], 612.10, 0.05) || !floatAlmostEqual(cov[1][1], 35.07, 0.005) || !floatAlmostEqual(corr, 0.066, 5e-4) {
+		t.Errorf("random-effect covariance = %v (correlation %v)", cov, corr)
+	}
+	if !floatAlmostEqual(cov[0][1], cov[1][0], 1e-12) {
+		t.Errorf("covariance not symmetric: %v", cov)
+	}
+	if !floatAlmostEqual(full.ResidualVariance, 654.94, 0.005) || !floatAlmostEqual(full.Deviance, 1743.6, 0.05) {
+		t.Errorf("residual = %v, REML criterion = %v", full.ResidualVariance, full.Deviance)
+	}
+
+	// lme4: lmer(Reaction ~ Days + (1 | Subject), sleepstudy) -> Subject
+	// variance 1378.2, residual 960.5, REML criterion 1786.5.
+	intercepts := slopes
+	intercepts.Random = []stats.LMMRandomEffect{{Group: "Subject"}}
+	reduced, err := stats.LMM(dt, intercepts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(reduced.RandomCovariances[0][0][0], 1378.2, 0.05) || !floatAlmostEqual(reduced.ResidualVariance, 960.5, 0.05) ||
+		!floatAlmostEqual(reduced.Deviance, 1786.5, 0.05) {
+		t.Errorf("intercept model: variance %v, residual %v, REML criterion %v",
+			reduced.RandomCovariances[0][0][0], reduced.ResidualVariance, reduced.Deviance)
+	}
+
+	// anova(fm2, fm1) refits with ML: logLik -897.04 and -875.97, AIC
+	// 1802.1 and 1763.9, Chisq 42.139 on 2 df, p 7.072e-10.
+	lrt, err := stats.LMMLikelihoodRatioTest(reduced, full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lrt.Refitted || *lrt.DF != 2 || !floatAlmostEqual(lrt.Statistic, 42.139, 5e-4) ||
+		!floatAlmostEqual(lrt.LogLikReduced, -897.04, 0.005) || !floatAlmostEqual(lrt.PValue, 7.072e-10, 5e-13) {
+		t.Errorf("LRT = %v on %v df, p = %v, logLik %v vs %v", lrt.Statistic, *lrt.DF, lrt.PValue, lrt.LogLikReduced, lrt.LogLikFull)
+	}
+	ml := slopes
+	ml.Method = stats.LMMML
+	fullML, err := stats.LMM(dt, ml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(fullML.LogLikelihood, -875.97, 0.005) || !floatAlmostEqual(fullML.AIC, 1763.9, 0.05) {
+		t.Errorf("ML logLik = %v, AIC = %v", fullML.LogLikelihood, fullML.AIC)
+	}
+}
+
+func TestLMMRandomSlopesAndLRT(t *testing.T) {
+	const groups, per = 30, 10
+	noise := seededNormals(11, groups*per)
+	re := seededNormals(12, 2*groups)
+	g := insyra.NewDataList().SetName("Subject")
+	x := insyra.NewDataList().SetName("Days")
+	y := insyra.NewDataList().SetName("Reaction")
+	for s := range groups {
+		b0, b1 := 3*re[2*s], 1.5*re[2*s+1]
+		for d := range per {
+			g.Append(s)
+			x.Append(float64(d))
+			y.Append(10 + b0 + (2+b1)*float64(d) + noise[s*per+d])
+		}
+	}
+	dt := insyra.NewDataTable(g, x, y)
+
+	full, err := stats.LMM(dt, stats.LMMOptions{
+		Response: "Reaction",
+		Fixed:    []string{"Days"},
+		Random:   []stats.LMMRandomEffect{{Group: "Subject", Slopes: []string{"Days"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !full.Converged {
+		t.Error("full model did not converge")
+	}
+	if !floatAlmostEqual(full.Coefficients[1], 2, 0.6) {
+		t.Errorf("slope = %v", full.Coefficients[1])
+	}
+	cov := full.RandomCovariances[0]
+	if len(cov) != 2 || !floatAlmostEqual(cov[1][1], 2.25, 1.2) || !floatAlmostEqual(full.ResidualVariance, 1, 0.25) {
+		t.Errorf("covariance = %v, residual = %v", cov, full.ResidualVariance)
+	}
+	if !floatAlmostEqual(cov[0][1], cov[1][0], 1e-12) {
+		t.Errorf("covariance not symmetric: %v", cov)
+	}
+
+	reduced, err := stats.LMM(dt, stats.LMMOptions{
+		Response: "Reaction",
+		Fixed:    []string{"Days"},
+		Random:   []stats.LMMRandomEffect{{Group: "Subject"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lrt, err := stats.LMMLikelihoodRatioTest(reduced, full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lrt.Refitted || *lrt.DF != 2 || lrt.PValue > 1e-10 {
+		t.Errorf("LRT = %+v", lrt)
+	}
+	if _, err := stats.LMMLikelihoodRatioTest(full, reduced); err == nil {
+		t.Error("expected error when the models are swapped")
+	}
+}
+
+func TestLMMCategoricalFixedEffect(t *testing.T) {
+	dt := sleepstudyTable()
+	// A string factor is coded like the equivalent 0/1 indicator column.
+	phase := insyra.NewDataList().SetName("Phase")
+	late := insyra.NewDataList().SetName("Late")
+	for _, d := range dt.GetColByName("Days").Data() {
+		if d.(float64) >= 5 {
+			phase.Append("late")
+			late.Append(1.0)
+		} else {
+			phase.Append("early")
+			late.Append(0.0)
+		}
+	}
+	dt.AppendCols(phase, late)
+	opts := stats.LMMOptions{
+		Response: "Reaction",
+		Fixed:    []string{"Days", "Phase"},
+		Random:   []stats.LMMRandomEffect{{Group: "Subject"}},
+	}
+	factor, err := stats.LMM(dt, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Fixed = []string{"Days", "Late"}
+	indicator, err := stats.LMM(dt, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := factor.FixedEffectNames; len(got) != 3 || got[2] != "Phaselate" {
+		t.Fatalf("fixed-effect names = %v", got)
+	}
+	for j := range 3 {
+		if !floatAlmostEqual(factor.Coefficients[j], indicator.Coefficients[j], 1e-8) ||
+			!floatAlmostEqual(factor.StandardErrors[j], indicator.StandardErrors[j], 1e-8) {
+			t.Errorf("coefficient %d = %v (SE %v), want %v (SE %v)", j,
+				factor.Coefficients[j], factor.StandardErrors[j], indicator.Coefficients[j], indicator.StandardErrors[j])
+		}
+	}
+	if !floatAlmostEqual(factor.Deviance, indicator.Deviance, 1e-8) {
+		t.Errorf("deviance = %v, want %v", factor.Deviance, indicator.Deviance)
+	}
+}
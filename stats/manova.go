@@ -0,0 +1,479 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+// HotellingT2Result reports Hotelling's T² test of mean vectors. Statistic
+// is T² and PValue comes from its exact F transformation on (DF, DF2)
+// degrees of freedom. EffectSizes holds the Mahalanobis distance D between
+// the means (or between the mean and μ).
+type HotellingT2Result struct {
+	testResultBase
+	F        float64
+	DF2      float64
+	N        int       // sample size of the first group (or the only group)
+	N2       *int      // sample size of the second group (nil if not applicable)
+	MeanDiff []float64 // x̄ - μ, or the first group's means minus the second's
+}
+
+// SingleSampleHotellingT2 tests whether the mean vector of the columns of
+// responses equals mu, T² = n (x̄ - μ)' S⁻¹ (x̄ - μ) with
+// F = (n - p) / (p (n - 1)) T² on (p, n - p) degrees of freedom. A nil mu
+// tests the zero vector.
+func SingleSampleHotellingT2(responses insyra.IDataTable, mu []float64) (*HotellingT2Result, error) {
+	rows, _, err := manovaResponses(responses)
+	if err != nil {
+		return nil, err
+	}
+	n, p := len(rows), len(rows[0])
+	if mu == nil {
+		mu = make([]float64, p)
+	}
+	if len(mu) != p {
+		return nil, fmt.Errorf("mu has %d values but there are %d responses", len(mu), p)
+	}
+	if n <= p {
+		return nil, errors.New("need more observations than responses")
+	}
+	mean, cov := meanAndSSCP(rows, nil)
+	scaleMatrix(cov, 1/float64(n-1))
+	diff := make([]float64, p)
+	for j := range p {
+		diff[j] = mean[j] - mu[j]
+	}
+	d2, err := mahalanobisSquared(diff, cov)
+	if err != nil {
+		return nil, err
+	}
+	return newHotellingT2Result(float64(n)*d2, d2, p, n-p, float64(n-p)/float64(p*(n-1)), n, nil, diff), nil
+}
+
+// TwoSampleHotellingT2 tests whether two groups share a mean vector, using
+// the pooled covariance: T² = n₁n₂/(n₁ + n₂) d' S⁻¹ d with
+// F = (N - p - 1) / (p (N - 2)) T² on (p, N - p - 1) degrees of freedom.
+// groups must have exactly two levels, which are ordered by their sorted
+// labels.
+func TwoSampleHotellingT2(responses insyra.IDataTable, groups insyra.IDataList) (*HotellingT2Result, error) {
+	rows, codes, levels, _, err := manovaInputs(responses, groups)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) != 2 {
+		return nil, fmt.Errorf("two-sample Hotelling's T² needs exactly two groups, found %d", len(levels))
+	}
+	N, p := len(rows), len(rows[0])
+	if N-p-1 <= 0 {
+		return nil, errors.New("need more observations than responses plus one")
+	}
+	means, within, sizes := groupSSCP(rows, codes, 2)
+	scaleMatrix(within, 1/float64(N-2))
+	diff := make([]float64, p)
+	for j := range p {
+		diff[j] = means[0][j] - means[1][j]
+	}
+	d2, err := mahalanobisSquared(diff, within)
+	if err != nil {
+		return nil, err
+	}
+	n1, n2 := sizes[0], sizes[1]
+	t2 := float64(n1*n2) / float64(N) * d2
+	return newHotellingT2Result(t2, d2, p, N-p-1, float64(N-p-1)/float64(p*(N-2)), n1, &n2, diff), nil
+}
+
+func newHotellingT2Result(t2, d2 float64, df1, df2 int, scale float64, n int, n2 *int, diff []float64) *HotellingT2Result {
+	res := &HotellingT2Result{N: n, N2: n2, MeanDiff: diff}
+	res.Statistic = t2
+	res.F = scale * t2
+	df := float64(df1)
+	res.DF = &df
+	res.DF2 = float64(df2)
+	res.PValue = fOneTailedPValue(res.F, df, res.DF2)
+	res.EffectSizes = []EffectSizeEntry{{Type: "mahalanobis_d", Value: math.Sqrt(d2)}}
+	return res
+}
+
+// MANOVATest is one multivariate test statistic with its F approximation.
+type MANOVATest struct {
+	Statistic float64
+	F         float64
+	DF1       float64
+	DF2       float64
+	PValue    float64
+}
+
+// MANOVAResult holds a one-way MANOVA. The four statistics are functions of
+// the eigenvalues of E⁻¹H, where H is the between-group (hypothesis) and E
+// the within-group (error) SSCP matrix, with the F approximations of R's
+// summary.manova. Roy's F is an upper bound, so its p-value is a lower
+// bound. With two groups or one response all four F tests coincide.
+type MANOVAResult struct {
+	Pillai          MANOVATest
+	Wilks           MANOVATest
+	HotellingLawley MANOVATest
+	Roy             MANOVATest
+	Eigenvalues     []float64 // descending
+	Groups          []any
+	DFHypothesis    int
+	DFError         int
+	N               int
+	HypothesisSSCP  *insyra.DataTable
+	ErrorSSCP       *insyra.DataTable
+}
+
+// OneWayMANOVA tests whether the groups share a mean vector for the
+// responses in the columns of responses. Groups are ordered by their
+// sorted labels.
+func OneWayMANOVA(responses insyra.IDataTable, groups insyra.IDataList) (*MANOVAResult, error) {
+	rows, codes, levels, names, err := manovaInputs(responses, groups)
+	if err != nil {
+		return nil, err
+	}
+	g := len(levels)
+	if g < 2 {
+		return nil, errors.New("MANOVA needs at least two groups")
+	}
+	N, p := len(rows), len(rows[0])
+	dfe := N - g
+	if dfe < p {
+		return nil, errors.New("need at least as many error degrees of freedom as responses")
+	}
+	means, E, sizes := groupSSCP(rows, codes, g)
+	grand, _ := meanAndSSCP(rows, nil)
+	H := make([][]float64, p)
+	for a := range p {
+		H[a] = make([]float64, p)
+		for b := range p {
+			for k := range g {
+				H[a][b] += float64(sizes[k]) * (means[k][a] - grand[a]) * (means[k][b] - grand[b])
+			}
+		}
+	}
+	eig, err := relativeEigenvalues(H, E)
+	if err != nil {
+		return nil, err
+	}
+
+	q := float64(g - 1)
+	pf := float64(p)
+	df := float64(dfe)
+	s := math.Min(pf, q)
+	m := (math.Abs(pf-q) - 1) / 2
+	nn := (df - pf - 1) / 2
+
+	var pillai, wilks, hl float64
+	wilks = 1
+	for _, l := range eig {
+		pillai += l / (1 + l)
+		wilks /= 1 + l
+		hl += l
+	}
+	res := &MANOVAResult{
+		Eigenvalues:    eig,
+		Groups:         levels,
+		DFHypothesis:   g - 1,
+		DFError:        dfe,
+		N:              N,
+		HypothesisSSCP: matrixToDataTableWithNames(symDense(H), "HypothesisSSCP", names, names),
+		ErrorSSCP:      matrixToDataTableWithNames(symDense(E), "ErrorSSCP", names, names),
+	}
+
+	t1, t2 := 2*m+s+1, 2*nn+s+1
+	res.Pillai = newMANOVATest(pillai, t2/t1*pillai/(s-pillai), s*t1, s*t2)
+
+	w1 := df - (pf-q+1)/2
+	w2 := (pf*q - 2) / 4
+	w3 := 1.0
+	if d := pf*pf + q*q - 5; d > 0 {
+		w3 = math.Sqrt((pf*pf*q*q - 4) / d)
+	}
+	wdf2 := w1*w3 - 2*w2
+	res.Wilks = newMANOVATest(wilks, (math.Pow(wilks, -1/w3)-1)*wdf2/(pf*q), pf*q, wdf2)
+
+	h2 := 2 * (s*nn + 1)
+	res.HotellingLawley = newMANOVATest(hl, h2*hl/(s*s*t1), s*t1, h2)
+
+	r1 := math.Max(pf, q)
+	r2 := df - r1 + q
+	res.Roy = newMANOVATest(eig[0], r2*eig[0]/r1, r1, r2)
+	return res, nil
+}
+
+func newMANOVATest(stat, f, df1, df2 float64) MANOVATest {
+	return MANOVATest{
+		Statistic: stat,
+		F:         f,
+		DF1:       df1,
+		DF2:       df2,
+		PValue:    fOneTailedPValue(f, df1, df2),
+	}
+}
+
+// BoxMResult reports Box's M test of equal covariance matrices across
+// groups. Statistic is the chi-square approximation M(1 - c) on
+// DF = p(p + 1)(g - 1)/2 degrees of freedom, as in biotools::boxM.
+type BoxMResult struct {
+	testResultBase
+	M                float64
+	GroupLogDets     []float64 // log-determinants of the group covariance matrices
+	PooledLogDet     float64
+	Groups           []any
+	GroupSizes       []int
+	PooledCovariance *insyra.DataTable
+}
+
+// BoxM tests whether the responses have the same covariance matrix in every
+// group. The test is sensitive to non-normality, so a small p-value with
+// large samples is common; it is usually read at α = 0.001. Every group
+// needs more observations than responses.
+func BoxM(responses insyra.IDataTable, groups insyra.IDataList) (*BoxMResult, error) {
+	rows, codes, levels, names, err := manovaInputs(responses, groups)
+	if err != nil {
+		return nil, err
+	}
+	g := len(levels)
+	if g < 2 {
+		return nil, errors.New("Box's M test needs at least two groups")
+	}
+	N, p := len(rows), len(rows[0])
+	members := make([][][]float64, g)
+	for i, c := range codes {
+		members[c] = append(members[c], rows[i])
+	}
+	pooled := make([][]float64, p)
+	for a := range pooled {
+		pooled[a] = make([]float64, p)
+	}
+	logDets := make([]float64, g)
+	sizes := make([]int, g)
+	m, invSum := 0.0, 0.0
+	for k, rs := range members {
+		sizes[k] = len(rs)
+		if len(rs) <= p {
+			return nil, fmt.Errorf("group %v needs more observations than responses", levels[k])
+		}
+		_, sscp := meanAndSSCP(rs, nil)
+		for a := range p {
+			for b := range p {
+				pooled[a][b] += sscp[a][b]
+			}
+		}
+		dfk := float64(len(rs) - 1)
+		scaleMatrix(sscp, 1/dfk)
+		ld, err := logDetSPD(sscp)
+		if err != nil {
+			return nil, fmt.Errorf("group %v: %w", levels[k], err)
+		}
+		logDets[k] = ld
+		m -= dfk * ld
+		invSum += 1 / dfk
+	}
+	dfe := float64(N - g)
+	scaleMatrix(pooled, 1/dfe)
+	pooledLD, err := logDetSPD(pooled)
+	if err != nil {
+		return nil, err
+	}
+	m += dfe * pooledLD
+
+	pf, gf := float64(p), float64(g)
+	c := (invSum - 1/dfe) * (2*pf*pf + 3*pf - 1) / (6 * (pf + 1) * (gf - 1))
+	df := pf * (pf + 1) * (gf - 1) / 2
+	res := &BoxMResult{
+		M:                m,
+		GroupLogDets:     logDets,
+		PooledLogDet:     pooledLD,
+		Groups:           levels,
+		GroupSizes:       sizes,
+		PooledCovariance: matrixToDataTableWithNames(symDense(pooled), "PooledCovariance", names, names),
+	}
+	res.Statistic = m * (1 - c)
+	res.DF = &df
+	res.PValue = chiSquaredPValue(res.Statistic, df)
+	return res, nil
+}
+
+// manovaResponses reads the numeric response columns and their names.
+func manovaResponses(responses insyra.IDataTable) ([][]float64, []string, error) {
+	if responses == nil {
+		return nil, nil, errors.New("responses must not be nil")
+	}
+	rows, _, err := numericMatrixFromTable(responses)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := outlierColumnNames(responses)
+	return rows, names, nil
+}
+
+// manovaInputs reads the responses and codes the groups by their sorted
+// labels.
+func manovaInputs(responses insyra.IDataTable, groups insyra.IDataList) ([][]float64, []int, []any, []string, error) {
+	rows, names, err := manovaResponses(responses)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if groups == nil {
+		return nil, nil, nil, nil, errors.New("groups must not be nil")
+	}
+	var raw []any
+	groups.AtomicDo(func(dl *insyra.DataList) { raw = dl.Data() })
+	if len(raw) != len(rows) {
+		return nil, nil, nil, nil, errors.New("groups must have one value per row of responses")
+	}
+	codes, levels, err := factorLevels(raw, "groups")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return rows, codes, levels, names, nil
+}
+
+// meanAndSSCP returns the column means of rows and the matrix of sums of
+// squares and cross-products about them. When idx is non-nil only those
+// rows are used.
+func meanAndSSCP(rows [][]float64, idx []int) ([]float64, [][]float64) {
+	if idx == nil {
+		idx = make([]int, len(rows))
+		for i := range idx {
+			idx[i] = i
+		}
+	}
+	p := len(rows[0])
+	mean := make([]float64, p)
+	for _, i := range idx {
+		for j, v := range rows[i] {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(idx))
+	}
+	sscp := make([][]float64, p)
+	for a := range sscp {
+		sscp[a] = make([]float64, p)
+	}
+	for _, i := range idx {
+		r := rows[i]
+		for a := range p {
+			da := r[a] - mean[a]
+			for b := a; b < p; b++ {
+				sscp[a][b] += da * (r[b] - mean[b])
+			}
+		}
+	}
+	for a := range p {
+		for b := range a {
+			sscp[a][b] = sscp[b][a]
+		}
+	}
+	return mean, sscp
+}
+
+// groupSSCP returns the group mean vectors, the pooled within-group SSCP
+// matrix and the group sizes.
+func groupSSCP(rows [][]float64, codes []int, g int) ([][]float64, [][]float64, []int) {
+	idx := make([][]int, g)
+	for i, c := range codes {
+		idx[c] = append(idx[c], i)
+	}
+	p := len(rows[0])
+	within := make([][]float64, p)
+	for a := range within {
+		within[a] = make([]float64, p)
+	}
+	means := make([][]float64, g)
+	sizes := make([]int, g)
+	for k := range g {
+		var sscp [][]float64
+		means[k], sscp = meanAndSSCP(rows, idx[k])
+		sizes[k] = len(idx[k])
+		for a := range p {
+			for b := range p {
+				within[a][b] += sscp[a][b]
+			}
+		}
+	}
+	return means, within, sizes
+}
+
+func scaleMatrix(a [][]float64, f float64) {
+	for _, row := range a {
+		for j := range row {
+			row[j] *= f
+		}
+	}
+}
+
+// mahalanobisSquared returns d' S⁻¹ d.
+func mahalanobisSquared(d []float64, s [][]float64) (float64, error) {
+	var chol mat.Cholesky
+	if !chol.Factorize(symDense(s)) {
+		return 0, errors.New("covariance matrix of the responses is singular")
+	}
+	var x mat.VecDense
+	if err := chol.SolveVecTo(&x, mat.NewVecDense(len(d), append([]float64(nil), d...))); err != nil {
+		return 0, errors.New("covariance matrix of the responses is singular")
+	}
+	return mat.Dot(&x, mat.NewVecDense(len(d), d)), nil
+}
+
+// relativeEigenvalues returns the eigenvalues of E⁻¹H in descending order,
+// computed as those of the symmetric L⁻¹ H L⁻ᵀ with E = LLᵀ.
+func relativeEigenvalues(h, e [][]float64) ([]float64, error) {
+	p := len(e)
+	var chol mat.Cholesky
+	if !chol.Factorize(symDense(e)) {
+		return nil, errors.New("within-group SSCP matrix is singular")
+	}
+	var l, lInv mat.TriDense
+	chol.LTo(&l)
+	if err := lInv.InverseTri(&l); err != nil {
+		return nil, errors.New("within-group SSCP matrix is singular")
+	}
+	var tmp, m mat.Dense
+	tmp.Mul(&lInv, symDense(h))
+	m.Mul(&tmp, lInv.T())
+	sym := mat.NewSymDense(p, nil)
+	for i := range p {
+		for j := i; j < p; j++ {
+			sym.SetSym(i, j, (m.At(i, j)+m.At(j, i))/2)
+		}
+	}
+	var eig mat.EigenSym
+	if !eig.Factorize(sym, false) {
+		return nil, errors.New("eigendecomposition failed")
+	}
+	vals := eig.Values(nil)
+	for i, v := range vals {
+		vals[i] = math.Max(v, 0)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(vals)))
+	return vals, nil
+}
+
+// logDetSPD returns the log-determinant of a symmetric positive definite
+// matrix.
+func logDetSPD(a [][]float64) (float64, error) {
+	var chol mat.Cholesky
+	if !chol.Factorize(symDense(a)) {
+		return 0, errors.New("covariance matrix is singular")
+	}
+	return chol.LogDet(), nil
+}
+
+func symDense(a [][]float64) *mat.SymDense {
+	p := len(a)
+	s := mat.NewSymDense(p, nil)
+	for i := range p {
+		for j := i; j < p; j++ {
+			s.SetSym(i, j, a[i][j])
+		}
+	}
+	return s
+}
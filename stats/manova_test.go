@@ -0,0 +1,222 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+var (
+	manovaY1    = []float64{5.1, 4.8, 5.6, 5.0, 5.3, 4.6, 6.2, 6.8, 6.0, 6.5, 7.1, 6.3, 5.9, 6.4, 7.0, 6.6, 7.4, 6.1}
+	manovaY2    = []float64{3.4, 3.1, 3.3, 3.6, 3.0, 3.2, 2.8, 3.0, 2.6, 2.9, 3.2, 2.7, 3.1, 3.3, 2.9, 3.6, 3.0, 3.4}
+	manovaY3    = []float64{1.4, 1.6, 1.3, 1.5, 1.9, 1.2, 4.5, 4.1, 3.9, 4.6, 4.8, 4.0, 5.6, 5.1, 5.9, 5.4, 6.1, 5.2}
+	manovaGroup = []any{"a", "a", "a", "a", "a", "a", "b", "b", "b", "b", "b", "b", "c", "c", "c", "c", "c", "c"}
+)
+
+func manovaTable(cols ...[]float64) *insyra.DataTable {
+	lists := make([]*insyra.DataList, len(cols))
+	for j, c := range cols {
+		lists[j] = insyra.NewDataList(c)
+	}
+	return insyra.NewDataTable(lists...)
+}
+
+func TestOneWayMANOVAReducesToANOVA(t *testing.T) {
+	res, err := OneWayMANOVA(manovaTable(manovaY1), insyra.NewDataList(manovaGroup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	anova, err := OneWayANOVA(
+		insyra.NewDataList(manovaY1[:6]), insyra.NewDataList(manovaY1[6:12]), insyra.NewDataList(manovaY1[12:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tst := range map[string]MANOVATest{
+		"Pillai": res.Pillai, "Wilks": res.Wilks, "HotellingLawley": res.HotellingLawley, "Roy": res.Roy,
+	} {
+		if math.Abs(tst.F-anova.Factor.F) > 1e-9*anova.Factor.F || tst.DF1 != 2 || tst.DF2 != 15 {
+			t.Errorf("%s: F(%v, %v) = %v, want F(2, 15) = %v", name, tst.DF1, tst.DF2, tst.F, anova.Factor.F)
+		}
+		if math.Abs(tst.PValue-anova.Factor.P) > 1e-12 {
+			t.Errorf("%s: p = %v, want %v", name, tst.PValue, anova.Factor.P)
+		}
+	}
+}
+
+func TestOneWayMANOVA(t *testing.T) {
+	groups := insyra.NewDataList(manovaGroup)
+	res, err := OneWayMANOVA(manovaTable(manovaY1, manovaY2, manovaY3), groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.DFHypothesis != 2 || res.DFError != 15 || res.N != 18 || len(res.Eigenvalues) != 3 {
+		t.Fatalf("df = (%d, %d), n = %d, eigenvalues = %v", res.DFHypothesis, res.DFError, res.N, res.Eigenvalues)
+	}
+
+	// Wilks' Λ = |E| / |E + H|, computed without the eigenvalues.
+	e, h := sscpRows(res.ErrorSSCP), sscpRows(res.HypothesisSSCP)
+	eh := make([][]float64, 3)
+	for i := range eh {
+		eh[i] = make([]float64, 3)
+		for j := range eh[i] {
+			eh[i][j] = e[i][j] + h[i][j]
+		}
+	}
+	ldE, _ := logDetSPD(e)
+	ldEH, _ := logDetSPD(eh)
+	if want := math.Exp(ldE - ldEH); math.Abs(res.Wilks.Statistic-want) > 1e-12 {
+		t.Fatalf("Wilks = %v, want %v", res.Wilks.Statistic, want)
+	}
+	if res.Pillai.DF1 != 6 || res.Pillai.DF2 != 28 || res.Wilks.DF1 != 6 || res.Wilks.DF2 != 26 ||
+		res.HotellingLawley.DF1 != 6 || res.HotellingLawley.DF2 != 24 || res.Roy.DF1 != 3 || res.Roy.DF2 != 14 {
+		t.Fatalf("unexpected degrees of freedom: %+v", res)
+	}
+	if res.Pillai.PValue > 1e-6 || res.Roy.PValue > res.Pillai.PValue {
+		t.Fatalf("Pillai p = %v, Roy p = %v", res.Pillai.PValue, res.Roy.PValue)
+	}
+
+	// Every statistic is invariant to non-singular affine maps of the
+	// responses.
+	mixed := make([][]float64, 3)
+	for j := range mixed {
+		mixed[j] = make([]float64, len(manovaY1))
+	}
+	for i := range manovaY1 {
+		mixed[0][i] = 2*manovaY1[i] - manovaY2[i] + 10
+		mixed[1][i] = manovaY2[i] + 0.5*manovaY3[i]
+		mixed[2][i] = -3 * manovaY3[i]
+	}
+	again, err := OneWayMANOVA(manovaTable(mixed...), groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := [][2]float64{
+		{res.Pillai.Statistic, again.Pillai.Statistic},
+		{res.Wilks.Statistic, again.Wilks.Statistic},
+		{res.HotellingLawley.Statistic, again.HotellingLawley.Statistic},
+		{res.Roy.Statistic, again.Roy.Statistic},
+	}
+	for _, pr := range pairs {
+		if math.Abs(pr[0]-pr[1]) > 1e-9*math.Max(1, math.Abs(pr[0])) {
+			t.Fatalf("statistic changed under an affine map: %v vs %v", pr[0], pr[1])
+		}
+	}
+
+	if _, err := OneWayMANOVA(manovaTable(manovaY1[:3], manovaY2[:3], manovaY3[:3]), insyra.NewDataList("a", "b", "c")); err == nil {
+		t.Fatal("expected error without error degrees of freedom")
+	}
+	if _, err := OneWayMANOVA(manovaTable(manovaY1), insyra.NewDataList("a")); err == nil {
+		t.Fatal("expected error for mismatched groups")
+	}
+}
+
+func sscpRows(dt *insyra.DataTable) [][]float64 {
+	rows, cols := dt.Size()
+	out := make([][]float64, rows)
+	for i := range rows {
+		out[i] = make([]float64, cols)
+		for j := range cols {
+			out[i][j] = dt.GetElementByNumberIndex(i, j).(float64)
+		}
+	}
+	return out
+}
+
+func TestTwoSampleHotellingT2(t *testing.T) {
+	responses := manovaTable(manovaY1[:12], manovaY2[:12], manovaY3[:12])
+	groups := insyra.NewDataList(manovaGroup[:12])
+	res, err := TwoSampleHotellingT2(responses, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *res.DF != 3 || res.DF2 != 8 || res.N != 6 || *res.N2 != 6 {
+		t.Fatalf("df = (%v, %v), n = (%d, %d)", *res.DF, res.DF2, res.N, *res.N2)
+	}
+	if want := sampleMean(manovaY3[:6]) - sampleMean(manovaY3[6:12]); math.Abs(res.MeanDiff[2]-want) > 1e-12 {
+		t.Fatalf("mean difference = %v, want %v", res.MeanDiff[2], want)
+	}
+
+	// With two groups every MANOVA statistic is a function of T², and all
+	// four F tests equal Hotelling's.
+	man, err := OneWayMANOVA(responses, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 / (1 + res.Statistic/10); math.Abs(man.Wilks.Statistic-want) > 1e-12 {
+		t.Fatalf("Wilks = %v, want %v", man.Wilks.Statistic, want)
+	}
+	for _, tst := range []MANOVATest{man.Pillai, man.Wilks, man.HotellingLawley, man.Roy} {
+		if math.Abs(tst.F-res.F) > 1e-9*res.F || math.Abs(tst.PValue-res.PValue) > 1e-12 {
+			t.Fatalf("MANOVA F = %v (p = %v), want %v (p = %v)", tst.F, tst.PValue, res.F, res.PValue)
+		}
+	}
+
+	if _, err := TwoSampleHotellingT2(manovaTable(manovaY1, manovaY2), insyra.NewDataList(manovaGroup)); err == nil {
+		t.Fatal("expected error for three groups")
+	}
+}
+
+func TestSingleSampleHotellingT2(t *testing.T) {
+	// With one response T² is the square of the one-sample t statistic.
+	res, err := SingleSampleHotellingT2(manovaTable(manovaY1), []float64{6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, err := SingleSampleTTest(insyra.NewDataList(manovaY1), 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Statistic-tt.Statistic*tt.Statistic) > 1e-10 {
+		t.Fatalf("T² = %v, want t² = %v", res.Statistic, tt.Statistic*tt.Statistic)
+	}
+	if math.Abs(res.PValue-tt.PValue) > 1e-12 || res.F != res.Statistic || *res.DF != 1 || res.DF2 != 17 {
+		t.Fatalf("F(%v, %v) = %v, p = %v, want p = %v", *res.DF, res.DF2, res.F, res.PValue, tt.PValue)
+	}
+
+	multi, err := SingleSampleHotellingT2(manovaTable(manovaY1, manovaY2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multi.PValue > 1e-10 || multi.EffectSizes[0].Value <= 0 {
+		t.Fatalf("p = %v, D = %v", multi.PValue, multi.EffectSizes[0].Value)
+	}
+	if _, err := SingleSampleHotellingT2(manovaTable(manovaY1, manovaY2), []float64{1}); err == nil {
+		t.Fatal("expected error for a short mu")
+	}
+}
+
+func TestBoxM(t *testing.T) {
+	groups := insyra.NewDataList(manovaGroup)
+
+	// With one response M = (N - g) ln s²_p - Σ (n_k - 1) ln s²_k.
+	res, err := BoxM(manovaTable(manovaY1), groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pooled, m := 0.0, 0.0
+	for k := range 3 {
+		_, sd := meanSD(manovaY1[6*k : 6*k+6])
+		pooled += 5 * sd * sd
+		m -= 5 * math.Log(sd*sd)
+	}
+	m += 15 * math.Log(pooled/15)
+	if math.Abs(res.M-m) > 1e-10 {
+		t.Fatalf("M = %v, want %v", res.M, m)
+	}
+	c := (3.0/5 - 1.0/15) * 4 / 24
+	if math.Abs(res.Statistic-m*(1-c)) > 1e-10 || *res.DF != 2 {
+		t.Fatalf("chi-square = %v on %v df, want %v on 2", res.Statistic, *res.DF, m*(1-c))
+	}
+
+	multi, err := BoxM(manovaTable(manovaY1, manovaY2), groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *multi.DF != 6 || len(multi.GroupLogDets) != 3 || multi.PValue <= 0 || multi.PValue > 1 {
+		t.Fatalf("df = %v, p = %v", *multi.DF, multi.PValue)
+	}
+	if _, err := BoxM(manovaTable(manovaY1[:4], manovaY2[:4], manovaY3[:4]), insyra.NewDataList("a", "a", "b", "b")); err == nil {
+		t.Fatal("expected error for groups smaller than the number of responses")
+	}
+}
@@ -0,0 +1,438 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// ClassMetrics holds the one-vs-rest metrics of a single class.
+type ClassMetrics struct {
+	Class     any
+	Precision float64
+	Recall    float64
+	F1        float64
+	Support   int // number of actual members
+}
+
+// ConfusionMatrixResult is a confusion matrix with the usual derived
+// metrics. Counts[i][j] is the number of rows whose actual class is
+// Classes[i] and whose predicted class is Classes[j]. Precision, recall and
+// F1 are 0 when undefined, as in scikit-learn's zero_division=0.
+type ConfusionMatrixResult struct {
+	Classes    []any
+	Counts     [][]int
+	Accuracy   float64
+	PerClass   []ClassMetrics
+	MacroF1    float64
+	WeightedF1 float64
+}
+
+// RegressionMetricsResult summarises prediction errors. MAPE is a fraction
+// (0.05 is 5%) and is NaN if any actual value is zero.
+type RegressionMetricsResult struct {
+	N    int
+	MAE  float64
+	MSE  float64
+	RMSE float64
+	MAPE float64
+	R2   float64
+}
+
+// ROCCurveResult is the receiver operating characteristic curve, one point
+// per distinct score threshold, starting at (0, 0).
+type ROCCurveResult struct {
+	FPR        []float64
+	TPR        []float64
+	Thresholds []float64
+	AUC        float64
+}
+
+// PRCurveResult is the precision-recall curve, one point per distinct score
+// threshold in decreasing order. AUC is the average precision, the
+// step-wise area used by scikit-learn's average_precision_score.
+type PRCurveResult struct {
+	Precision  []float64
+	Recall     []float64
+	Thresholds []float64
+	AUC        float64
+}
+
+// CalibrationResult bins predicted probabilities into equal-width bins;
+// empty bins are omitted.
+type CalibrationResult struct {
+	BinLower         []float64
+	BinUpper         []float64
+	MeanPredicted    []float64
+	FractionPositive []float64
+	Counts           []int
+}
+
+// ConfusionMatrix compares actual and predicted class labels. Classes are
+// the union of both lists in sorted order.
+func ConfusionMatrix(actual, predicted insyra.IDataList) (*ConfusionMatrixResult, error) {
+	if actual == nil || predicted == nil {
+		return nil, errors.New("actual and predicted must not be nil")
+	}
+	a, p := actual.Data(), predicted.Data()
+	if len(a) != len(p) {
+		return nil, errors.New("actual and predicted must have the same length")
+	}
+	if len(a) == 0 {
+		return nil, errors.New("actual and predicted must not be empty")
+	}
+	var classes []any
+	index := map[string]int{}
+	for _, v := range append(append([]any(nil), a...), p...) {
+		if v == nil {
+			return nil, errors.New("labels must not contain nil values")
+		}
+		if _, ok := index[classKey(v)]; !ok {
+			index[classKey(v)] = len(classes)
+			classes = append(classes, v)
+		}
+	}
+	sortClassLevels(classes)
+	for i, c := range classes {
+		index[classKey(c)] = i
+	}
+
+	k := len(classes)
+	counts := make([][]int, k)
+	for i := range counts {
+		counts[i] = make([]int, k)
+	}
+	correct := 0
+	for i := range a {
+		ai, pi := index[classKey(a[i])], index[classKey(p[i])]
+		counts[ai][pi]++
+		if ai == pi {
+			correct++
+		}
+	}
+
+	res := &ConfusionMatrixResult{
+		Classes:  classes,
+		Counts:   counts,
+		Accuracy: float64(correct) / float64(len(a)),
+		PerClass: make([]ClassMetrics, k),
+	}
+	for c := range k {
+		tp := float64(counts[c][c])
+		actualN, predictedN := 0, 0
+		for j := range k {
+			actualN += counts[c][j]
+			predictedN += counts[j][c]
+		}
+		m := ClassMetrics{Class: classes[c], Support: actualN}
+		if predictedN > 0 {
+			m.Precision = tp / float64(predictedN)
+		}
+		if actualN > 0 {
+			m.Recall = tp / float64(actualN)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		res.PerClass[c] = m
+		res.MacroF1 += m.F1 / float64(k)
+		res.WeightedF1 += m.F1 * float64(actualN) / float64(len(a))
+	}
+	return res, nil
+}
+
+// RegressionMetrics returns MAE, MSE, RMSE, MAPE and R² of numeric
+// predictions.
+func RegressionMetrics(actual, predicted insyra.IDataList) (*RegressionMetricsResult, error) {
+	y, yhat, err := pairedMetricVectors(actual, predicted)
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(y))
+	res := &RegressionMetricsResult{N: len(y)}
+	mean := sampleMean(y)
+	ssTot, ape := 0.0, 0.0
+	for i := range y {
+		d := y[i] - yhat[i]
+		res.MAE += math.Abs(d)
+		res.MSE += d * d
+		ssTot += (y[i] - mean) * (y[i] - mean)
+		if y[i] == 0 {
+			ape = math.NaN()
+		} else {
+			ape += math.Abs(d / y[i])
+		}
+	}
+	res.R2 = 1 - res.MSE/ssTot
+	if ssTot == 0 {
+		res.R2 = math.NaN()
+	}
+	res.MAE /= n
+	res.MSE /= n
+	res.RMSE = math.Sqrt(res.MSE)
+	res.MAPE = ape / n
+	return res, nil
+}
+
+// ROCCurve computes the ROC curve of scores (larger means more likely
+// positive) against actual labels, where positive names the positive class.
+func ROCCurve(actual insyra.IDataList, scores insyra.IDataList, positive any) (*ROCCurveResult, error) {
+	points, nPos, nNeg, err := binaryScorePoints(actual, scores, positive)
+	if err != nil {
+		return nil, err
+	}
+	if nNeg == 0 {
+		return nil, errors.New("actual must contain negative cases")
+	}
+	res := &ROCCurveResult{FPR: []float64{0}, TPR: []float64{0}, Thresholds: []float64{math.Inf(1)}}
+	for _, pt := range points {
+		fpr, tpr := pt.fp/nNeg, pt.tp/nPos
+		last := len(res.FPR) - 1
+		res.AUC += (fpr - res.FPR[last]) * (tpr + res.TPR[last]) / 2
+		res.FPR = append(res.FPR, fpr)
+		res.TPR = append(res.TPR, tpr)
+		res.Thresholds = append(res.Thresholds, pt.threshold)
+	}
+	return res, nil
+}
+
+// PRCurve computes the precision-recall curve of scores against actual
+// labels.
+func PRCurve(actual insyra.IDataList, scores insyra.IDataList, positive any) (*PRCurveResult, error) {
+	points, nPos, _, err := binaryScorePoints(actual, scores, positive)
+	if err != nil {
+		return nil, err
+	}
+	res := &PRCurveResult{}
+	prevRecall := 0.0
+	for _, pt := range points {
+		precision, recall := pt.tp/(pt.tp+pt.fp), pt.tp/nPos
+		res.AUC += (recall - prevRecall) * precision
+		prevRecall = recall
+		res.Precision = append(res.Precision, precision)
+		res.Recall = append(res.Recall, recall)
+		res.Thresholds = append(res.Thresholds, pt.threshold)
+	}
+	return res, nil
+}
+
+// LogLoss is the mean negative log-likelihood of predicted probabilities of
+// the positive class. Probabilities are clipped to [1e-15, 1-1e-15].
+func LogLoss(actual insyra.IDataList, probabilities insyra.IDataList, positive any) (float64, error) {
+	y, p, err := binaryProbabilities(actual, probabilities, positive)
+	if err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range y {
+		q := clipProbability(p[i])
+		if y[i] == 1 {
+			loss -= math.Log(q)
+		} else {
+			loss -= math.Log(1 - q)
+		}
+	}
+	return loss / float64(len(y)), nil
+}
+
+// MulticlassLogLoss is the log loss of a probability table with one column
+// per class, named after the class labels as returned by the PredictProba
+// methods.
+func MulticlassLogLoss(actual insyra.IDataList, probabilities insyra.IDataTable) (float64, error) {
+	if actual == nil || probabilities == nil {
+		return 0, errors.New("actual and probabilities must not be nil")
+	}
+	labels := actual.Data()
+	var names []string
+	probabilities.AtomicDo(func(dt *insyra.DataTable) { names = dt.ColNames() })
+	raw, n, err := rawColumnsByName(probabilities, names)
+	if err != nil {
+		return 0, err
+	}
+	if n != len(labels) || n == 0 {
+		return 0, errors.New("probabilities must have one non-empty row per actual label")
+	}
+	column := make(map[string]int, len(names))
+	for j, name := range names {
+		column[name] = j
+	}
+	loss := 0.0
+	for i, label := range labels {
+		j, ok := column[fmt.Sprint(label)]
+		if !ok {
+			return 0, fmt.Errorf("no probability column for class %v", label)
+		}
+		total := 0.0
+		for c := range raw {
+			v, ok := insyra.ToFloat64Safe(raw[c][i])
+			if !ok || v < 0 {
+				return 0, errors.New("probabilities must be non-negative numbers")
+			}
+			total += v
+		}
+		if total <= 0 {
+			return 0, errors.New("probability rows must not sum to zero")
+		}
+		v, _ := insyra.ToFloat64Safe(raw[j][i])
+		loss -= math.Log(clipProbability(v / total))
+	}
+	return loss / float64(n), nil
+}
+
+// BrierScore is the mean squared difference between the predicted
+// probability of the positive class and the 0/1 outcome.
+func BrierScore(actual insyra.IDataList, probabilities insyra.IDataList, positive any) (float64, error) {
+	y, p, err := binaryProbabilities(actual, probabilities, positive)
+	if err != nil {
+		return 0, err
+	}
+	score := 0.0
+	for i := range y {
+		score += (p[i] - y[i]) * (p[i] - y[i])
+	}
+	return score / float64(len(y)), nil
+}
+
+// CalibrationCurve groups predicted probabilities into equal-width bins on
+// [0, 1] and reports the observed positive rate of each.
+func CalibrationCurve(actual insyra.IDataList, probabilities insyra.IDataList, positive any, bins int) (*CalibrationResult, error) {
+	if bins < 1 {
+		return nil, errors.New("bins must be at least 1")
+	}
+	y, p, err := binaryProbabilities(actual, probabilities, positive)
+	if err != nil {
+		return nil, err
+	}
+	sumP := make([]float64, bins)
+	sumY := make([]float64, bins)
+	counts := make([]int, bins)
+	for i := range y {
+		b := min(int(p[i]*float64(bins)), bins-1)
+		sumP[b] += p[i]
+		sumY[b] += y[i]
+		counts[b]++
+	}
+	res := &CalibrationResult{}
+	for b := range bins {
+		if counts[b] == 0 {
+			continue
+		}
+		res.BinLower = append(res.BinLower, float64(b)/float64(bins))
+		res.BinUpper = append(res.BinUpper, float64(b+1)/float64(bins))
+		res.MeanPredicted = append(res.MeanPredicted, sumP[b]/float64(counts[b]))
+		res.FractionPositive = append(res.FractionPositive, sumY[b]/float64(counts[b]))
+		res.Counts = append(res.Counts, counts[b])
+	}
+	return res, nil
+}
+
+func pairedMetricVectors(actual, predicted insyra.IDataList) ([]float64, []float64, error) {
+	if actual == nil || predicted == nil {
+		return nil, nil, errors.New("actual and predicted must not be nil")
+	}
+	y, err := numericColumn(actual.Data(), "actual")
+	if err != nil {
+		return nil, nil, err
+	}
+	yhat, err := numericColumn(predicted.Data(), "predicted")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(y) != len(yhat) {
+		return nil, nil, errors.New("actual and predicted must have the same length")
+	}
+	if len(y) == 0 {
+		return nil, nil, errors.New("actual and predicted must not be empty")
+	}
+	return y, yhat, nil
+}
+
+// binaryOutcomes codes actual labels as 1 for positive and 0 otherwise and
+// pairs them with numeric scores.
+func binaryOutcomes(actual, scores insyra.IDataList, positive any) ([]float64, []float64, error) {
+	if actual == nil || scores == nil {
+		return nil, nil, errors.New("actual and scores must not be nil")
+	}
+	if positive == nil {
+		return nil, nil, errors.New("positive class must not be nil")
+	}
+	labels := actual.Data()
+	s, err := numericColumn(scores.Data(), "scores")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(labels) != len(s) {
+		return nil, nil, errors.New("actual and scores must have the same length")
+	}
+	if len(s) == 0 {
+		return nil, nil, errors.New("actual and scores must not be empty")
+	}
+	y := make([]float64, len(labels))
+	hasPos := false
+	for i, v := range labels {
+		if v == nil {
+			return nil, nil, errors.New("labels must not contain nil values")
+		}
+		if classEqual(v, positive) {
+			y[i] = 1
+			hasPos = true
+		}
+	}
+	if !hasPos {
+		return nil, nil, errors.New("actual must contain positive cases")
+	}
+	return y, s, nil
+}
+
+func binaryProbabilities(actual, probabilities insyra.IDataList, positive any) ([]float64, []float64, error) {
+	y, p, err := binaryOutcomes(actual, probabilities, positive)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range p {
+		if v < 0 || v > 1 {
+			return nil, nil, errors.New("probabilities must lie in [0, 1]")
+		}
+	}
+	return y, p, nil
+}
+
+type scorePoint struct {
+	threshold float64
+	tp, fp    float64
+}
+
+// binaryScorePoints returns cumulative true and false positive counts at
+// each distinct score, from the highest score down.
+func binaryScorePoints(actual, scores insyra.IDataList, positive any) ([]scorePoint, float64, float64, error) {
+	y, s, err := binaryOutcomes(actual, scores, positive)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	order := make([]int, len(s))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return s[order[a]] > s[order[b]] })
+	var points []scorePoint
+	tp, fp := 0.0, 0.0
+	for i, idx := range order {
+		if y[idx] == 1 {
+			tp++
+		} else {
+			fp++
+		}
+		if i == len(order)-1 || s[order[i+1]] != s[idx] {
+			points = append(points, scorePoint{threshold: s[idx], tp: tp, fp: fp})
+		}
+	}
+	return points, tp, fp, nil
+}
+
+func clipProbability(p float64) float64 {
+	const eps = 1e-15
+	return math.Min(math.Max(p, eps), 1-eps)
+}
@@ -0,0 +1,153 @@
+package stats_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// Reference values are the examples from the scikit-learn metrics
+// documentation.
+func TestConfusionMatrix(t *testing.T) {
+	res, err := stats.ConfusionMatrix(insyra.NewDataList(2, 0, 2, 2, 0, 1), insyra.NewDataList(0, 0, 2, 2, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]int{{2, 0, 0}, {0, 0, 1}, {1, 0, 2}}
+	if !reflect.DeepEqual(res.Counts, want) {
+		t.Fatalf("counts = %v, want %v", res.Counts, want)
+	}
+	if !floatAlmostEqual(res.Accuracy, 4.0/6, 1e-12) {
+		t.Errorf("accuracy = %v", res.Accuracy)
+	}
+	if !floatAlmostEqual(res.PerClass[0].F1, 0.8, 1e-12) || res.PerClass[1].F1 != 0 || !floatAlmostEqual(res.PerClass[2].F1, 2.0/3, 1e-12) {
+		t.Errorf("per-class F1 = %+v", res.PerClass)
+	}
+	if !floatAlmostEqual(res.MacroF1, 0.4888888888888889, 1e-12) || !floatAlmostEqual(res.WeightedF1, 0.6, 1e-12) {
+		t.Errorf("macro %v weighted %v", res.MacroF1, res.WeightedF1)
+	}
+}
+
+func TestBinaryClassificationMetrics(t *testing.T) {
+	y := insyra.NewDataList(0, 0, 1, 1)
+	scores := insyra.NewDataList(0.1, 0.4, 0.35, 0.8)
+	roc, err := stats.ROCCurve(y, scores, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(roc.AUC, 0.75, 1e-12) {
+		t.Errorf("ROC AUC = %v, want 0.75", roc.AUC)
+	}
+	wantFPR := []float64{0, 0, 0.5, 0.5, 1}
+	if !reflect.DeepEqual(roc.FPR, wantFPR) {
+		t.Errorf("FPR = %v, want %v", roc.FPR, wantFPR)
+	}
+	pr, err := stats.PRCurve(y, scores, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(pr.AUC, 0.8333333333333333, 1e-12) {
+		t.Errorf("average precision = %v", pr.AUC)
+	}
+
+	brier, err := stats.BrierScore(insyra.NewDataList(0, 1, 1, 0), insyra.NewDataList(0.1, 0.9, 0.8, 0.3), 1)
+	if err != nil || !floatAlmostEqual(brier, 0.0375, 1e-12) {
+		t.Errorf("Brier = %v (%v)", brier, err)
+	}
+
+	spam := insyra.NewDataList("spam", "ham", "ham", "spam")
+	loss, err := stats.LogLoss(spam, insyra.NewDataList(0.9, 0.1, 0.2, 0.65), "spam")
+	if err != nil || !floatAlmostEqual(loss, 0.21616187468057912, 1e-12) {
+		t.Errorf("log loss = %v (%v)", loss, err)
+	}
+	probs := insyra.NewDataTable(
+		insyra.NewDataList(0.1, 0.9, 0.8, 0.35).SetName("ham"),
+		insyra.NewDataList(0.9, 0.1, 0.2, 0.65).SetName("spam"),
+	)
+	multi, err := stats.MulticlassLogLoss(spam, probs)
+	if err != nil || !floatAlmostEqual(multi, loss, 1e-12) {
+		t.Errorf("multiclass log loss = %v (%v)", multi, err)
+	}
+
+	cal, err := stats.CalibrationCurve(insyra.NewDataList(0, 0, 1, 1, 1), insyra.NewDataList(0.1, 0.2, 0.6, 0.9, 0.95), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cal.Counts, []int{2, 3}) || cal.FractionPositive[0] != 0 || cal.FractionPositive[1] != 1 {
+		t.Errorf("calibration = %+v", cal)
+	}
+}
+
+func TestRegressionMetrics(t *testing.T) {
+	res, err := stats.RegressionMetrics(insyra.NewDataList(3, -0.5, 2, 7), insyra.NewDataList(2.5, 0.0, 2, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MAE != 0.5 || res.MSE != 0.375 || !floatAlmostEqual(res.RMSE, math.Sqrt(0.375), 1e-15) {
+		t.Errorf("MAE %v MSE %v RMSE %v", res.MAE, res.MSE, res.RMSE)
+	}
+	if !floatAlmostEqual(res.R2, 0.9486081370449679, 1e-12) {
+		t.Errorf("R2 = %v", res.R2)
+	}
+	if !floatAlmostEqual(res.MAPE, (0.5/3+1+1.0/7)/4, 1e-12) {
+		t.Errorf("MAPE = %v", res.MAPE)
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	train, labels, _ := treeFixture(90, 5)
+	fitPredict := func(tr insyra.IDataTable, trLabels insyra.IDataList, te insyra.IDataTable) (insyra.IDataList, error) {
+		model, err := stats.DecisionTreeClassifier(tr, trLabels)
+		if err != nil {
+			return nil, err
+		}
+		return model.Predict(te)
+	}
+	accuracy := func(actual, predicted insyra.IDataList) (float64, error) {
+		cm, err := stats.ConfusionMatrix(actual, predicted)
+		if err != nil {
+			return 0, err
+		}
+		return cm.Accuracy, nil
+	}
+	opts := stats.CrossValidateOptions{Stratified: true, Score: accuracy, Sampling: insyra.SamplingOptions{Seed: 9, UseSeed: true}}
+	res, err := stats.CrossValidate(train, labels, 5, fitPredict, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Predictions.Len() != 90 || res.MeanScore < 0.95 {
+		t.Fatalf("out-of-fold predictions %d, mean accuracy %v", res.Predictions.Len(), res.MeanScore)
+	}
+	for _, fold := range res.Folds {
+		if len(fold.TestIndices) != 18 {
+			t.Errorf("stratified fold has %d rows, want 18", len(fold.TestIndices))
+		}
+	}
+	again, _ := stats.CrossValidate(train, labels, 5, fitPredict, opts)
+	if !reflect.DeepEqual(again.Folds[0].TestIndices, res.Folds[0].TestIndices) {
+		t.Error("seeded folds differ")
+	}
+
+	groups := insyra.NewDataList()
+	for i := 0; i < 90; i++ {
+		groups.Append(i / 10)
+	}
+	grouped, err := stats.CrossValidate(train, labels, 3, fitPredict, stats.CrossValidateOptions{Groups: groups})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fold := range grouped.Folds {
+		inTest := map[int]bool{}
+		for _, i := range fold.TestIndices {
+			inTest[i/10] = true
+		}
+		for _, i := range fold.TrainIndices {
+			if inTest[i/10] {
+				t.Fatalf("group %d is split across train and test", i/10)
+			}
+		}
+	}
+}
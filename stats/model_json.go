@@ -0,0 +1,855 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/internal/modeljson"
+	internalknn "github.com/HazelnutParadise/insyra/stats/internal/knn"
+	json "github.com/goccy/go-json"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Fitted models serialise to versioned JSON with enough state to Predict,
+// Transform or Score after reloading. Save them with insyra.SaveModel and
+// restore with insyra.LoadModel, or wrap them with PipelineStep to chain
+// them after preprocessing in an insyra.Pipeline.
+
+const (
+	modelKindGLM    = "glm"
+	modelKindPCA    = "pca"
+	modelKindKMeans = "kmeans"
+	modelKindFactor = "factor"
+	modelKindKNNImp = "knn_impute"
+	modelKindMICE   = "mice"
+)
+
+func init() {
+	insyra.RegisterPipelineStep(modelKindPCA, func() insyra.PipelineStep { return &pcaStep{r: &PCAResult{}} })
+	insyra.RegisterPipelineStep(modelKindKMeans, func() insyra.PipelineStep { return &kmeansStep{r: &KMeansResult{}} })
+	insyra.RegisterPipelineStep(modelKindFactor, func() insyra.PipelineStep { return &factorStep{m: &FactorModel{}} })
+	insyra.RegisterPipelineStep(modelKindGLM, func() insyra.PipelineStep { return &glmStep{r: &GLMResult{}} })
+	insyra.RegisterPipelineStep(modelKindKNNImp, func() insyra.PipelineStep { return &knnImputeStep{r: &KNNImputeResult{}} })
+	insyra.RegisterPipelineStep(modelKindMICE, func() insyra.PipelineStep { return &miceImputeStep{r: &MICEResult{}} })
+}
+
+// tableJSON stores a numeric table column by column.
+type tableJSON struct {
+	Name     string              `json:"name,omitempty"`
+	Columns  []string            `json:"columns"`
+	RowNames []string            `json:"row_names,omitempty"`
+	Data     [][]modeljson.Float `json:"data"`
+}
+
+func encodeTable(t insyra.IDataTable) (*tableJSON, error) {
+	if t == nil {
+		return nil, nil
+	}
+	var out *tableJSON
+	var err error
+	t.AtomicDo(func(dt *insyra.DataTable) {
+		out = &tableJSON{Name: dt.GetName(), Columns: dt.ColNames()}
+		for _, name := range dt.RowNames() {
+			if name != "" {
+				out.RowNames = dt.RowNames()
+				break
+			}
+		}
+		_, cols := dt.Size()
+		out.Data = make([][]modeljson.Float, cols)
+		for j := range cols {
+			raw := dt.GetColByNumber(j).Data()
+			col := make([]modeljson.Float, len(raw))
+			for i, v := range raw {
+				f, ok := insyra.ToFloat64Safe(v)
+				if !ok && v != nil {
+					err = fmt.Errorf("table %q has non-numeric value %v", out.Name, v)
+					return
+				}
+				if v == nil {
+					f = math.NaN()
+				}
+				col[i] = modeljson.Float(f)
+			}
+			out.Data[j] = col
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (t *tableJSON) decode() insyra.IDataTable {
+	if t == nil {
+		return nil
+	}
+	cols := make([]*insyra.DataList, len(t.Data))
+	for j, data := range t.Data {
+		vals := make([]any, len(data))
+		for i, v := range data {
+			vals[i] = float64(v)
+		}
+		cols[j] = insyra.NewDataList(vals...)
+		if j < len(t.Columns) {
+			cols[j].SetName(t.Columns[j])
+		}
+	}
+	dt := insyra.NewDataTable(cols...)
+	if t.Name != "" {
+		dt.SetName(t.Name)
+	}
+	if len(t.RowNames) > 0 {
+		dt.SetRowNames(t.RowNames)
+	}
+	return dt
+}
+
+// ======================== GLM ========================
+
+type glmJSON struct {
+	modeljson.Header
+	Family              GLMFamily            `json:"family"`
+	Link                GLMLink              `json:"link"`
+	Coefficients        []modeljson.Float    `json:"coefficients"`
+	StandardErrors      []modeljson.Float    `json:"standard_errors"`
+	ZValues             []modeljson.Float    `json:"z_values"`
+	PValues             []modeljson.Float    `json:"p_values"`
+	ConfidenceIntervals [][2]modeljson.Float `json:"confidence_intervals"`
+	LinearPredictors    []modeljson.Float    `json:"linear_predictors"`
+	FittedValues        []modeljson.Float    `json:"fitted_values"`
+	Residuals           []modeljson.Float    `json:"residuals"`
+	PearsonResiduals    []modeljson.Float    `json:"pearson_residuals"`
+	DevianceResiduals   []modeljson.Float    `json:"deviance_residuals"`
+	Deviance            modeljson.Float      `json:"deviance"`
+	NullDeviance        modeljson.Float      `json:"null_deviance"`
+	LogLikelihood       modeljson.Float      `json:"log_likelihood"`
+	NullLogLikelihood   modeljson.Float      `json:"null_log_likelihood"`
+	AIC                 modeljson.Float      `json:"aic"`
+	BIC                 modeljson.Float      `json:"bic"`
+	PearsonChi2         modeljson.Float      `json:"pearson_chi2"`
+	Dispersion          modeljson.Float      `json:"dispersion"`
+	DFResidual          int                  `json:"df_residual"`
+	Iterations          int                  `json:"iterations"`
+	Converged           bool                 `json:"converged"`
+	ConfidenceLevel     modeljson.Float      `json:"confidence_level"`
+	HasOffset           bool                 `json:"has_offset"`
+	Theta               modeljson.Float      `json:"theta,omitempty"`
+	ThetaSE             modeljson.Float      `json:"theta_se,omitempty"`
+	TweediePower        modeljson.Float      `json:"tweedie_power,omitempty"`
+}
+
+// MarshalJSON serialises the fitted GLM.
+func (r *GLMResult) MarshalJSON() ([]byte, error) {
+	ci := make([][2]modeljson.Float, len(r.ConfidenceIntervals))
+	for i, c := range r.ConfidenceIntervals {
+		ci[i] = [2]modeljson.Float{modeljson.Float(c[0]), modeljson.Float(c[1])}
+	}
+	return json.Marshal(glmJSON{
+		Header:              modeljson.NewHeader(modelKindGLM),
+		Family:              r.Family,
+		Link:                r.Link,
+		Coefficients:        modeljson.Floats(r.Coefficients),
+		StandardErrors:      modeljson.Floats(r.StandardErrors),
+		ZValues:             modeljson.Floats(r.ZValues),
+		PValues:             modeljson.Floats(r.PValues),
+		ConfidenceIntervals: ci,
+		LinearPredictors:    modeljson.Floats(r.LinearPredictors),
+		FittedValues:        modeljson.Floats(r.FittedValues),
+		Residuals:           modeljson.Floats(r.Residuals),
+		PearsonResiduals:    modeljson.Floats(r.PearsonResiduals),
+		DevianceResiduals:   modeljson.Floats(r.DevianceResiduals),
+		Deviance:            modeljson.Float(r.Deviance),
+		NullDeviance:        modeljson.Float(r.NullDeviance),
+		LogLikelihood:       modeljson.Float(r.LogLikelihood),
+		NullLogLikelihood:   modeljson.Float(r.NullLogLikelihood),
+		AIC:                 modeljson.Float(r.AIC),
+		BIC:                 modeljson.Float(r.BIC),
+		PearsonChi2:         modeljson.Float(r.PearsonChi2),
+		Dispersion:          modeljson.Float(r.Dispersion),
+		DFResidual:          r.DFResidual,
+		Iterations:          r.Iterations,
+		Converged:           r.Converged,
+		ConfidenceLevel:     modeljson.Float(r.ConfidenceLevel),
+		HasOffset:           r.hasOffset,
+		Theta:               modeljson.Float(r.Theta),
+		ThetaSE:             modeljson.Float(r.ThetaSE),
+		TweediePower:        modeljson.Float(r.TweediePower),
+	})
+}
+
+// UnmarshalJSON restores a GLM written by MarshalJSON.
+func (r *GLMResult) UnmarshalJSON(data []byte) error {
+	var in glmJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindGLM); err != nil {
+		return err
+	}
+	fam, err := resolveGLMFamily(in.Family, float64(in.Theta), float64(in.TweediePower))
+	if err != nil {
+		return err
+	}
+	link, linkName, err := resolveGLMLink(fam, in.Link)
+	if err != nil {
+		return err
+	}
+	ci := make([][2]float64, len(in.ConfidenceIntervals))
+	for i, c := range in.ConfidenceIntervals {
+		ci[i] = [2]float64{float64(c[0]), float64(c[1])}
+	}
+	*r = GLMResult{
+		Family:              in.Family,
+		Link:                linkName,
+		Coefficients:        modeljson.ToFloats(in.Coefficients),
+		StandardErrors:      modeljson.ToFloats(in.StandardErrors),
+		ZValues:             modeljson.ToFloats(in.ZValues),
+		PValues:             modeljson.ToFloats(in.PValues),
+		ConfidenceIntervals: ci,
+		LinearPredictors:    modeljson.ToFloats(in.LinearPredictors),
+		FittedValues:        modeljson.ToFloats(in.FittedValues),
+		Residuals:           modeljson.ToFloats(in.Residuals),
+		PearsonResiduals:    modeljson.ToFloats(in.PearsonResiduals),
+		DevianceResiduals:   modeljson.ToFloats(in.DevianceResiduals),
+		Deviance:            float64(in.Deviance),
+		NullDeviance:        float64(in.NullDeviance),
+		LogLikelihood:       float64(in.LogLikelihood),
+		NullLogLikelihood:   float64(in.NullLogLikelihood),
+		AIC:                 float64(in.AIC),
+		BIC:                 float64(in.BIC),
+		PearsonChi2:         float64(in.PearsonChi2),
+		Dispersion:          float64(in.Dispersion),
+		DFResidual:          in.DFResidual,
+		Iterations:          in.Iterations,
+		Converged:           in.Converged,
+		ConfidenceLevel:     float64(in.ConfidenceLevel),
+		Theta:               float64(in.Theta),
+		ThetaSE:             float64(in.ThetaSE),
+		TweediePower:        float64(in.TweediePower),
+		family:              fam,
+		link:                link,
+		hasOffset:           in.HasOffset,
+	}
+	return nil
+}
+
+// ======================== PCA ========================
+
+type pcaJSON struct {
+	modeljson.Header
+	ColNames          []string            `json:"col_names"`
+	Means             []modeljson.Float   `json:"means"`
+	Stds              []modeljson.Float   `json:"stds"`
+	Loadings          [][]modeljson.Float `json:"loadings"`
+	Eigenvalues       []modeljson.Float   `json:"eigenvalues"`
+	ExplainedVariance []modeljson.Float   `json:"explained_variance"`
+}
+
+// MarshalJSON serialises the fitted PCA.
+func (r *PCAResult) MarshalJSON() ([]byte, error) {
+	if r.loadings == nil {
+		return nil, errors.New("PCA result is not fitted")
+	}
+	return json.Marshal(pcaJSON{
+		Header:            modeljson.NewHeader(modelKindPCA),
+		ColNames:          r.colNames,
+		Means:             modeljson.Floats(r.means),
+		Stds:              modeljson.Floats(r.stds),
+		Loadings:          modeljson.Matrix(denseRows(r.loadings)),
+		Eigenvalues:       modeljson.Floats(r.Eigenvalues),
+		ExplainedVariance: modeljson.Floats(r.ExplainedVariance),
+	})
+}
+
+// UnmarshalJSON restores a PCA written by MarshalJSON.
+func (r *PCAResult) UnmarshalJSON(data []byte) error {
+	var in pcaJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindPCA); err != nil {
+		return err
+	}
+	loadings, err := rowsDense(modeljson.ToMatrix(in.Loadings))
+	if err != nil {
+		return err
+	}
+	p, k := loadings.Dims()
+	if len(in.Means) != p || len(in.Stds) != p {
+		return errors.New("PCA standardisation does not match loadings")
+	}
+	*r = PCAResult{
+		Components:        matrixToDataTableWithNames(loadings, "", pcaComponentNames(k), nil),
+		Eigenvalues:       modeljson.ToFloats(in.Eigenvalues),
+		ExplainedVariance: modeljson.ToFloats(in.ExplainedVariance),
+		colNames:          in.ColNames,
+		means:             modeljson.ToFloats(in.Means),
+		stds:              modeljson.ToFloats(in.Stds),
+		loadings:          loadings,
+	}
+	return nil
+}
+
+// ======================== k-means ========================
+
+type kmeansJSON struct {
+	modeljson.Header
+	Cluster     []int               `json:"cluster"`
+	Centers     [][]modeljson.Float `json:"centers"`
+	TotSS       modeljson.Float     `json:"tot_ss"`
+	WithinSS    []modeljson.Float   `json:"within_ss"`
+	TotWithinSS modeljson.Float     `json:"tot_within_ss"`
+	BetweenSS   modeljson.Float     `json:"between_ss"`
+	Size        []int               `json:"size"`
+	Iter        int                 `json:"iter"`
+	IFault      int                 `json:"ifault"`
+}
+
+// MarshalJSON serialises the fitted k-means model.
+func (r *KMeansResult) MarshalJSON() ([]byte, error) {
+	if len(r.centers) == 0 {
+		return nil, errors.New("k-means result is not fitted")
+	}
+	return json.Marshal(kmeansJSON{
+		Header:      modeljson.NewHeader(modelKindKMeans),
+		Cluster:     r.Cluster,
+		Centers:     modeljson.Matrix(r.centers),
+		TotSS:       modeljson.Float(r.TotSS),
+		WithinSS:    modeljson.Floats(r.WithinSS),
+		TotWithinSS: modeljson.Float(r.TotWithinSS),
+		BetweenSS:   modeljson.Float(r.BetweenSS),
+		Size:        r.Size,
+		Iter:        r.Iter,
+		IFault:      r.IFault,
+	})
+}
+
+// UnmarshalJSON restores a k-means model written by MarshalJSON.
+func (r *KMeansResult) UnmarshalJSON(data []byte) error {
+	var in kmeansJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindKMeans); err != nil {
+		return err
+	}
+	centers := modeljson.ToMatrix(in.Centers)
+	if len(centers) == 0 {
+		return errors.New("k-means model has no centers")
+	}
+	*r = KMeansResult{
+		Cluster:     in.Cluster,
+		Centers:     matrixToDataTable(centers, "V"),
+		TotSS:       float64(in.TotSS),
+		WithinSS:    modeljson.ToFloats(in.WithinSS),
+		TotWithinSS: float64(in.TotWithinSS),
+		BetweenSS:   float64(in.BetweenSS),
+		Size:        in.Size,
+		Iter:        in.Iter,
+		IFault:      in.IFault,
+		centers:     centers,
+	}
+	return nil
+}
+
+// ======================== Factor analysis ========================
+
+type factorJSON struct {
+	modeljson.Header
+	Loadings             *tableJSON          `json:"loadings"`
+	UnrotatedLoadings    *tableJSON          `json:"unrotated_loadings"`
+	Structure            *tableJSON          `json:"structure"`
+	Uniquenesses         *tableJSON          `json:"uniquenesses"`
+	Communalities        *tableJSON          `json:"communalities"`
+	SamplingAdequacy     *tableJSON          `json:"sampling_adequacy"`
+	BartlettTest         *bartlettJSON       `json:"bartlett_test"`
+	Phi                  *tableJSON          `json:"phi"`
+	RotationMatrix       *tableJSON          `json:"rotation_matrix"`
+	Eigenvalues          *tableJSON          `json:"eigenvalues"`
+	ExplainedProportion  *tableJSON          `json:"explained_proportion"`
+	CumulativeProportion *tableJSON          `json:"cumulative_proportion"`
+	Scores               *tableJSON          `json:"scores"`
+	ScoreCoefficients    *tableJSON          `json:"score_coefficients"`
+	ScoreCovariance      *tableJSON          `json:"score_covariance"`
+	Converged            bool                `json:"converged"`
+	RotationConverged    bool                `json:"rotation_converged"`
+	Iterations           int                 `json:"iterations"`
+	CountUsed            int                 `json:"count_used"`
+	Messages             []string            `json:"messages"`
+	Means                []modeljson.Float   `json:"means"`
+	Sds                  []modeljson.Float   `json:"sds"`
+	ScoreWeights         [][]modeljson.Float `json:"score_weights,omitempty"`
+	FactorNames          []string            `json:"factor_names"`
+}
+
+type bartlettJSON struct {
+	ChiSquare        modeljson.Float `json:"chi_square"`
+	DegreesOfFreedom int             `json:"degrees_of_freedom"`
+	PValue           modeljson.Float `json:"p_value"`
+	SampleSize       int             `json:"sample_size"`
+}
+
+// MarshalJSON serialises the fitted factor model, including every output
+// table and the training standardisation used by Score.
+func (m *FactorModel) MarshalJSON() ([]byte, error) {
+	out := factorJSON{
+		Header:            modeljson.NewHeader(modelKindFactor),
+		Converged:         m.Converged,
+		RotationConverged: m.RotationConverged,
+		Iterations:        m.Iterations,
+		CountUsed:         m.CountUsed,
+		Messages:          m.Messages,
+		Means:             modeljson.Floats(m.means),
+		Sds:               modeljson.Floats(m.sds),
+		FactorNames:       m.factorNames,
+	}
+	if m.scoreWeights != nil {
+		out.ScoreWeights = modeljson.Matrix(denseRows(m.scoreWeights))
+	}
+	if b := m.BartlettTest; b != nil {
+		out.BartlettTest = &bartlettJSON{
+			ChiSquare:        modeljson.Float(b.ChiSquare),
+			DegreesOfFreedom: b.DegreesOfFreedom,
+			PValue:           modeljson.Float(b.PValue),
+			SampleSize:       b.SampleSize,
+		}
+	}
+	tables := []struct {
+		dst **tableJSON
+		src insyra.IDataTable
+	}{
+		{&out.Loadings, m.Loadings},
+		{&out.UnrotatedLoadings, m.UnrotatedLoadings},
+		{&out.Structure, m.Structure},
+		{&out.Uniquenesses, m.Uniquenesses},
+		{&out.Communalities, m.Communalities},
+		{&out.SamplingAdequacy, m.SamplingAdequacy},
+		{&out.Phi, m.Phi},
+		{&out.RotationMatrix, m.RotationMatrix},
+		{&out.Eigenvalues, m.Eigenvalues},
+		{&out.ExplainedProportion, m.ExplainedProportion},
+		{&out.CumulativeProportion, m.CumulativeProportion},
+		{&out.Scores, m.Scores},
+		{&out.ScoreCoefficients, m.ScoreCoefficients},
+		{&out.ScoreCovariance, m.ScoreCovariance},
+	}
+	for _, t := range tables {
+		enc, err := encodeTable(t.src)
+		if err != nil {
+			return nil, err
+		}
+		*t.dst = enc
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a factor model written by MarshalJSON.
+func (m *FactorModel) UnmarshalJSON(data []byte) error {
+	var in factorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindFactor); err != nil {
+		return err
+	}
+	if len(in.Means) != len(in.Sds) {
+		return errors.New("factor model standardisation is inconsistent")
+	}
+	var weights *mat.Dense
+	if in.ScoreWeights != nil {
+		var err error
+		weights, err = rowsDense(modeljson.ToMatrix(in.ScoreWeights))
+		if err != nil {
+			return err
+		}
+		if r, _ := weights.Dims(); r != len(in.Means) {
+			return errors.New("factor score weights do not match the standardisation")
+		}
+	}
+	*m = FactorModel{
+		FactorAnalysisResult: FactorAnalysisResult{
+			Loadings:             in.Loadings.decode(),
+			UnrotatedLoadings:    in.UnrotatedLoadings.decode(),
+			Structure:            in.Structure.decode(),
+			Uniquenesses:         in.Uniquenesses.decode(),
+			Communalities:        in.Communalities.decode(),
+			SamplingAdequacy:     in.SamplingAdequacy.decode(),
+			Phi:                  in.Phi.decode(),
+			RotationMatrix:       in.RotationMatrix.decode(),
+			Eigenvalues:          in.Eigenvalues.decode(),
+			ExplainedProportion:  in.ExplainedProportion.decode(),
+			CumulativeProportion: in.CumulativeProportion.decode(),
+			Scores:               in.Scores.decode(),
+			ScoreCoefficients:    in.ScoreCoefficients.decode(),
+			ScoreCovariance:      in.ScoreCovariance.decode(),
+			Converged:            in.Converged,
+			RotationConverged:    in.RotationConverged,
+			Iterations:           in.Iterations,
+			CountUsed:            in.CountUsed,
+			Messages:             in.Messages,
+		},
+		means:        modeljson.ToFloats(in.Means),
+		sds:          modeljson.ToFloats(in.Sds),
+		scoreWeights: weights,
+		factorNames:  in.FactorNames,
+	}
+	if b := in.BartlettTest; b != nil {
+		m.BartlettTest = &BartlettTestResult{
+			ChiSquare:        float64(b.ChiSquare),
+			DegreesOfFreedom: b.DegreesOfFreedom,
+			PValue:           float64(b.PValue),
+			SampleSize:       b.SampleSize,
+		}
+	}
+	return nil
+}
+
+// ======================== Imputers ========================
+
+// The completed training tables (Imputed) are not saved; a reloaded imputer
+// only transforms new data.
+
+type knnImputeJSON struct {
+	modeljson.Header
+	Columns     []string            `json:"columns"`
+	Train       [][]modeljson.Float `json:"train"`
+	Means       []modeljson.Float   `json:"means"`
+	K           int                 `json:"k"`
+	Imputations int                 `json:"imputations"`
+	Weighting   string              `json:"weighting"`
+	Algorithm   string              `json:"algorithm"`
+	LeafSize    int                 `json:"leaf_size"`
+	Seed        uint64              `json:"seed"`
+}
+
+// MarshalJSON serialises the fitted KNN imputer, including its training rows.
+func (r *KNNImputeResult) MarshalJSON() ([]byte, error) {
+	if r.train == nil {
+		return nil, errors.New("KNN imputer is not fitted")
+	}
+	return json.Marshal(knnImputeJSON{
+		Header:      modeljson.NewHeader(modelKindKNNImp),
+		Columns:     r.colNames,
+		Train:       modeljson.Matrix(r.train),
+		Means:       modeljson.Floats(r.means),
+		K:           r.K,
+		Imputations: r.Imputations,
+		Weighting:   string(r.options.Weighting),
+		Algorithm:   string(r.options.Algorithm),
+		LeafSize:    r.options.LeafSize,
+		Seed:        r.seed,
+	})
+}
+
+// UnmarshalJSON restores a KNN imputer written by MarshalJSON.
+func (r *KNNImputeResult) UnmarshalJSON(data []byte) error {
+	var in knnImputeJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindKNNImp); err != nil {
+		return err
+	}
+	if len(in.Train) == 0 || len(in.Means) != len(in.Columns) || in.K < 1 || in.Imputations < 1 {
+		return errors.New("KNN imputer payload is incomplete")
+	}
+	for _, row := range in.Train {
+		if len(row) != len(in.Columns) {
+			return errors.New("KNN imputer training rows do not match the fitted columns")
+		}
+	}
+	switch KNNWeighting(in.Weighting) {
+	case "", KNNUniformWeighting, KNNDistanceWeighting:
+	default:
+		return fmt.Errorf("unsupported KNN weighting %q", in.Weighting)
+	}
+	switch KNNAlgorithm(in.Algorithm) {
+	case "", KNNAuto, KNNBruteForce, KNNKDTree, KNNBallTree:
+	default:
+		return fmt.Errorf("unsupported KNN algorithm %q", in.Algorithm)
+	}
+	*r = KNNImputeResult{
+		K:           in.K,
+		Imputations: in.Imputations,
+		colNames:    in.Columns,
+		train:       modeljson.ToMatrix(in.Train),
+		means:       modeljson.ToFloats(in.Means),
+		options: internalknn.Options{
+			Weighting: internalknn.Weighting(in.Weighting),
+			Algorithm: internalknn.Algorithm(in.Algorithm),
+			LeafSize:  in.LeafSize,
+		},
+		seed: in.Seed,
+	}
+	return nil
+}
+
+type miceJSON struct {
+	modeljson.Header
+	Columns     []string            `json:"columns"`
+	Binary      []bool              `json:"binary"`
+	Levels      [][]modeljson.Value `json:"levels"`
+	Start       []modeljson.Float   `json:"start"`
+	Chains      [][]miceStepJSON    `json:"chains"`
+	MaxIter     int                 `json:"max_iter"`
+	Imputations int                 `json:"imputations"`
+	Seed        uint64              `json:"seed"`
+}
+
+type miceStepJSON struct {
+	Col   int               `json:"col"`
+	Coef  []modeljson.Float `json:"coef"`
+	Sigma modeljson.Float   `json:"sigma"`
+}
+
+// MarshalJSON serialises every regression of every fitted chain.
+func (r *MICEResult) MarshalJSON() ([]byte, error) {
+	if len(r.chains) == 0 {
+		return nil, errors.New("MICE imputer is not fitted")
+	}
+	out := miceJSON{
+		Header:      modeljson.NewHeader(modelKindMICE),
+		Columns:     r.colNames,
+		Binary:      r.binary,
+		Levels:      make([][]modeljson.Value, len(r.levels)),
+		Start:       modeljson.Floats(r.start),
+		Chains:      make([][]miceStepJSON, len(r.chains)),
+		MaxIter:     r.MaxIter,
+		Imputations: r.Imputations,
+		Seed:        r.seed,
+	}
+	for j, levels := range r.levels {
+		vals, err := modeljson.EncodeValues(levels)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", r.colNames[j], err)
+		}
+		out.Levels[j] = vals
+	}
+	for k, chain := range r.chains {
+		out.Chains[k] = make([]miceStepJSON, len(chain))
+		for i, step := range chain {
+			out.Chains[k][i] = miceStepJSON{Col: step.col, Coef: modeljson.Floats(step.coef), Sigma: modeljson.Float(step.sigma)}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a MICE imputer written by MarshalJSON.
+func (r *MICEResult) UnmarshalJSON(data []byte) error {
+	var in miceJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if err := in.Check(modelKindMICE); err != nil {
+		return err
+	}
+	p := len(in.Columns)
+	if len(in.Chains) == 0 || len(in.Binary) != p || len(in.Levels) != p || len(in.Start) != p {
+		return errors.New("MICE imputer payload is incomplete")
+	}
+	levels := make([][]any, p)
+	for j, vals := range in.Levels {
+		decoded, err := modeljson.DecodeValues(vals)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", in.Columns[j], err)
+		}
+		if in.Binary[j] && len(decoded) != 2 {
+			return fmt.Errorf("binary column %q needs two classes", in.Columns[j])
+		}
+		levels[j] = decoded
+	}
+	chains := make([][]miceStep, len(in.Chains))
+	for k, steps := range in.Chains {
+		chains[k] = make([]miceStep, len(steps))
+		for i, st := range steps {
+			if st.Col < 0 || st.Col >= p || len(st.Coef) != p {
+				return errors.New("MICE chain step does not match the fitted columns")
+			}
+			chains[k][i] = miceStep{col: st.Col, coef: modeljson.ToFloats(st.Coef), sigma: float64(st.Sigma)}
+		}
+	}
+	*r = MICEResult{
+		Imputations: in.Imputations,
+		MaxIter:     in.MaxIter,
+		colNames:    in.Columns,
+		binary:      in.Binary,
+		levels:      levels,
+		start:       modeljson.ToFloats(in.Start),
+		chains:      chains,
+		seed:        in.Seed,
+	}
+	return nil
+}
+
+func denseRows(m *mat.Dense) [][]float64 {
+	r, _ := m.Dims()
+	out := make([][]float64, r)
+	for i := range r {
+		out[i] = mat.Row(nil, i, m)
+	}
+	return out
+}
+
+func rowsDense(rows [][]float64) (*mat.Dense, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, errors.New("matrix is empty")
+	}
+	out := mat.NewDense(len(rows), len(rows[0]), nil)
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, errors.New("matrix rows have different lengths")
+		}
+		out.SetRow(i, row)
+	}
+	return out, nil
+}
+
+// ======================== Pipeline steps ========================
+
+// PipelineStep wraps the PCA as a pipeline step whose Transform replaces
+// the columns with component scores; its inverse reconstructs them.
+func (r *PCAResult) PipelineStep() insyra.PipelineStep { return &pcaStep{r: r} }
+
+// PipelineStep wraps the k-means model as a pipeline step whose Transform
+// appends a "Cluster" column of predicted labels.
+func (r *KMeansResult) PipelineStep() insyra.PipelineStep { return &kmeansStep{r: r} }
+
+// PipelineStep wraps the factor model as a pipeline step whose Transform
+// replaces the columns with factor scores.
+func (m *FactorModel) PipelineStep() insyra.PipelineStep { return &factorStep{m: m} }
+
+// PipelineStep wraps the GLM as a pipeline step whose Transform appends a
+// "Prediction" column on the response scale, using every column of the
+// table as a predictor in the order the model was fitted.
+func (r *GLMResult) PipelineStep() insyra.PipelineStep { return &glmStep{r: r} }
+
+// PipelineStep wraps the fitted imputer for use in an insyra.Pipeline; it
+// outputs the first completed table.
+func (r *KNNImputeResult) PipelineStep() insyra.PipelineStep { return &knnImputeStep{r: r} }
+
+// PipelineStep wraps the fitted imputer for use in an insyra.Pipeline; it
+// outputs the first completed table.
+func (r *MICEResult) PipelineStep() insyra.PipelineStep { return &miceImputeStep{r: r} }
+
+type pcaStep struct{ r *PCAResult }
+
+func (s *pcaStep) Kind() string                    { return modelKindPCA }
+func (s *pcaStep) MarshalJSON() ([]byte, error)    { return s.r.MarshalJSON() }
+func (s *pcaStep) UnmarshalJSON(data []byte) error { return s.r.UnmarshalJSON(data) }
+
+func (s *pcaStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	out, err := s.r.Transform(dt)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*insyra.DataTable), nil
+}
+
+func (s *pcaStep) InverseTransform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	out, err := s.r.InverseTransform(dt)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*insyra.DataTable), nil
+}
+
+type kmeansStep struct{ r *KMeansResult }
+
+func (s *kmeansStep) Kind() string                    { return modelKindKMeans }
+func (s *kmeansStep) MarshalJSON() ([]byte, error)    { return s.r.MarshalJSON() }
+func (s *kmeansStep) UnmarshalJSON(data []byte) error { return s.r.UnmarshalJSON(data) }
+
+func (s *kmeansStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	labels, err := s.r.Predict(dt)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]any, len(labels))
+	for i, l := range labels {
+		vals[i] = l
+	}
+	return appendPredictionColumn(dt, insyra.NewDataList(vals...).SetName("Cluster")), nil
+}
+
+type factorStep struct{ m *FactorModel }
+
+func (s *factorStep) Kind() string                    { return modelKindFactor }
+func (s *factorStep) MarshalJSON() ([]byte, error)    { return s.m.MarshalJSON() }
+func (s *factorStep) UnmarshalJSON(data []byte) error { return s.m.UnmarshalJSON(data) }
+
+func (s *factorStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	out, err := s.m.Score(dt)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*insyra.DataTable), nil
+}
+
+type glmStep struct{ r *GLMResult }
+
+func (s *glmStep) Kind() string                    { return modelKindGLM }
+func (s *glmStep) MarshalJSON() ([]byte, error)    { return s.r.MarshalJSON() }
+func (s *glmStep) UnmarshalJSON(data []byte) error { return s.r.UnmarshalJSON(data) }
+
+func (s *glmStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	var xs []insyra.IDataList
+	dt.AtomicDo(func(t *insyra.DataTable) {
+		_, cols := t.Size()
+		for j := range cols {
+			xs = append(xs, t.GetColByNumber(j))
+		}
+	})
+	pred, err := s.r.Predict(PredictResponse, xs...)
+	if err != nil {
+		return nil, err
+	}
+	return appendPredictionColumn(dt, pred.SetName("Prediction")), nil
+}
+
+type knnImputeStep struct{ r *KNNImputeResult }
+
+func (s *knnImputeStep) Kind() string                    { return modelKindKNNImp }
+func (s *knnImputeStep) MarshalJSON() ([]byte, error)    { return s.r.MarshalJSON() }
+func (s *knnImputeStep) UnmarshalJSON(data []byte) error { return s.r.UnmarshalJSON(data) }
+
+func (s *knnImputeStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	out, err := s.r.Transform(dt)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*insyra.DataTable), nil
+}
+
+// InverseTransform is the identity, as for the insyra imputers, so that a
+// pipeline containing an imputer stays invertible.
+func (s *knnImputeStep) InverseTransform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	return dt.Clone(), nil
+}
+
+type miceImputeStep struct{ r *MICEResult }
+
+func (s *miceImputeStep) Kind() string                    { return modelKindMICE }
+func (s *miceImputeStep) MarshalJSON() ([]byte, error)    { return s.r.MarshalJSON() }
+func (s *miceImputeStep) UnmarshalJSON(data []byte) error { return s.r.UnmarshalJSON(data) }
+
+func (s *miceImputeStep) Transform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	out, err := s.r.Transform(dt)
+	if err != nil {
+		return nil, err
+	}
+	return out.(*insyra.DataTable), nil
+}
+
+func (s *miceImputeStep) InverseTransform(dt *insyra.DataTable) (*insyra.DataTable, error) {
+	return dt.Clone(), nil
+}
+
+// appendPredictionColumn returns a copy of dt with col appended.
+func appendPredictionColumn(dt *insyra.DataTable, col *insyra.DataList) *insyra.DataTable {
+	out := dt.Clone()
+	out.AppendCols(col)
+	return out
+}
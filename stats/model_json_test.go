@@ -0,0 +1,169 @@
+package stats_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+	json "github.com/goccy/go-json"
+)
+
+func roundTrip(t *testing.T, model json.Marshaler, into json.Unmarshaler) {
+	t.Helper()
+	data, err := json.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := into.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertSameTable(t *testing.T, got, want insyra.IDataTable) {
+	t.Helper()
+	g, w := tableToFloatMatrix(got.(*insyra.DataTable)), tableToFloatMatrix(want.(*insyra.DataTable))
+	if len(g) != len(w) {
+		t.Fatalf("table has %d rows, want %d", len(g), len(w))
+	}
+	for i := range w {
+		for j := range w[i] {
+			if !floatAlmostEqual(g[i][j], w[i][j], 1e-12) {
+				t.Fatalf("[%d][%d] = %v, want %v", i, j, g[i][j], w[i][j])
+			}
+		}
+	}
+}
+
+func TestFittedModelsJSONRoundTrip(t *testing.T) {
+	dt, _ := blobTable(51, 25, [][2]float64{{0, 0}, {4, 1}}, []float64{0.7, 0.7})
+
+	pca, err := stats.PCA(dt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pca2 stats.PCAResult
+	roundTrip(t, pca, &pca2)
+	want, _ := pca.Transform(dt)
+	got, err := pca2.Transform(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameTable(t, got, want)
+	assertSameTable(t, pca2.Components, pca.Components)
+
+	seed := int64(4)
+	km, err := stats.KMeans(dt, 2, stats.KMeansOptions{NStart: 3, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var km2 stats.KMeansResult
+	roundTrip(t, km, &km2)
+	labels, err := km2.Predict(dt)
+	if err != nil || !reflect.DeepEqual(labels, km.Cluster) {
+		t.Errorf("reloaded k-means predicts %v (%v), want %v", labels, err, km.Cluster)
+	}
+
+	x := dt.GetColByName("x")
+	y := insyra.NewDataList()
+	for i, v := range x.Data() {
+		y.Append(2*v.(float64) + 1 + 0.1*float64(i%3))
+	}
+	glm, err := stats.GLM(stats.GLMOptions{Family: stats.Gaussian}, y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var glm2 stats.GLMResult
+	roundTrip(t, glm, &glm2)
+	p1, _ := glm.Predict(stats.PredictResponse, x)
+	p2, err := glm2.Predict(stats.PredictResponse, x)
+	if err != nil || !reflect.DeepEqual(p1.Data(), p2.Data()) {
+		t.Errorf("reloaded GLM predictions differ (%v)", err)
+	}
+	if glm2.AIC != glm.AIC || glm2.Link != glm.Link {
+		t.Errorf("reloaded GLM summary AIC %v link %v", glm2.AIC, glm2.Link)
+	}
+}
+
+func TestNegativeBinomialGLMJSONRoundTrip(t *testing.T) {
+	y := insyra.NewDataList(0, 9, 1, 17, 0, 3, 30, 1, 0, 5, 2, 14, 40, 0, 6, 22, 1, 0, 11, 3)
+	x := insyra.NewDataList(0.1, 0.3, 0.2, 0.9, 0.4, 0.5, 1.3, 0.2, 0.6, 0.8, 1.1, 0.3, 1.6, 0.7, 0.1, 1.8, 1.0, 0.4, 1.4, 0.6)
+	glm, err := stats.GLM(stats.GLMOptions{Family: stats.NegativeBinomial}, y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var glm2 stats.GLMResult
+	roundTrip(t, glm, &glm2)
+	if glm2.Theta != glm.Theta || glm2.ThetaSE != glm.ThetaSE || glm2.Family != stats.NegativeBinomial {
+		t.Errorf("reloaded theta %v (se %v), want %v (se %v)", glm2.Theta, glm2.ThetaSE, glm.Theta, glm.ThetaSE)
+	}
+	p1, _ := glm.Predict(stats.PredictResponse, x)
+	p2, err := glm2.Predict(stats.PredictResponse, x)
+	if err != nil || !reflect.DeepEqual(p1.Data(), p2.Data()) {
+		t.Errorf("reloaded GLM predictions differ (%v)", err)
+	}
+}
+
+func TestFactorModelJSONRoundTrip(t *testing.T) {
+	z := seededNormals(61, 200)
+	rows := make([][]float64, 50)
+	for i := range rows {
+		f := z[4*i]
+		rows[i] = []float64{f + 0.5*z[4*i+1], f + 0.6*z[4*i+2], f + 0.7*z[4*i+3]}
+	}
+	dt := dataTableFromRows(rows)
+	opt := stats.DefaultFactorAnalysisOptions()
+	opt.Count = stats.FactorCountSpec{Method: stats.FactorCountFixed, FixedK: 1}
+	fa, err := stats.FactorAnalysis(dt, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fa2 stats.FactorModel
+	roundTrip(t, fa, &fa2)
+	assertSameTable(t, fa2.Loadings, fa.Loadings)
+	if fa2.BartlettTest == nil || fa2.BartlettTest.ChiSquare != fa.BartlettTest.ChiSquare {
+		t.Errorf("Bartlett test not restored: %+v", fa2.BartlettTest)
+	}
+	scores, err := fa2.Score(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameTable(t, scores, fa.Scores)
+}
+
+func TestPipelineWithModelSteps(t *testing.T) {
+	dt, _ := blobTable(71, 20, [][2]float64{{0, 0}, {5, 5}}, []float64{0.5, 0.5})
+	scaled, scaler, err := dt.StandardScale("x", "y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := int64(5)
+	km, err := stats.KMeans(scaled, 2, stats.KMeansOptions{NStart: 3, Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := insyra.NewPipeline(scaler, km.PipelineStep())
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := insyra.SaveModel(path, p); err != nil {
+		t.Fatal(err)
+	}
+	var loaded insyra.Pipeline
+	if err := insyra.LoadModel(path, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	out, err := loaded.Transform(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := out.GetColByName("Cluster").Data()
+	for i, c := range km.Cluster {
+		if got[i] != c {
+			t.Fatalf("row %d assigned %v, want %d", i, got[i], c)
+		}
+	}
+	if _, err := loaded.InverseTransform(out); err == nil {
+		t.Errorf("expected error: k-means step is not invertible")
+	}
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra/stats/internal/fa"
+	"gonum.org/v1/gonum/mat"
+)
+
+// numericGradientStep mirrors optim()'s default ndeps.
+const numericGradientStep = 1e-3
+
+// numericGradient fills g with central differences of fn at x. Steps are
+// scaled by parscale (when given) the same way optim() applies ndeps on the
+// scaled parameters.
+func numericGradient(fn func([]float64) float64, x, g, parscale []float64) {
+	xx := append([]float64(nil), x...)
+	for i := range x {
+		h := numericGradientStep
+		if i < len(parscale) && parscale[i] > 0 {
+			h *= parscale[i]
+		}
+		xx[i] = x[i] + h
+		fp := fn(xx)
+		xx[i] = x[i] - h
+		fm := fn(xx)
+		xx[i] = x[i]
+		g[i] = (fp - fm) / (2 * h)
+	}
+}
+
+// numericHessian returns the symmetric finite-difference Hessian of fn at x,
+// using the same second-difference scheme as optimHess().
+func numericHessian(fn func([]float64) float64, x, parscale []float64) [][]float64 {
+	k := len(x)
+	h := make([][]float64, k)
+	gp := make([]float64, k)
+	gm := make([]float64, k)
+	xx := append([]float64(nil), x...)
+	for i := range k {
+		step := numericGradientStep
+		if i < len(parscale) && parscale[i] > 0 {
+			step *= parscale[i]
+		}
+		xx[i] = x[i] + step
+		numericGradient(fn, xx, gp, parscale)
+		xx[i] = x[i] - step
+		numericGradient(fn, xx, gm, parscale)
+		xx[i] = x[i]
+		h[i] = make([]float64, k)
+		for j := range k {
+			h[i][j] = (gp[j] - gm[j]) / (2 * step)
+		}
+	}
+	for i := range k {
+		for j := i + 1; j < k; j++ {
+			avg := 0.5 * (h[i][j] + h[j][i])
+			h[i][j], h[j][i] = avg, avg
+		}
+	}
+	return h
+}
+
+// boundedMinimizeOptions configures minimizeNumeric. Nil bounds mean the
+// parameter is unbounded.
+type boundedMinimizeOptions struct {
+	lower, upper []float64
+	parscale     []float64
+	maxIter      int
+}
+
+// minimizeNumeric runs the vendored L-BFGS-B with central-difference
+// gradients, matching optim(method = "L-BFGS-B") without an analytic gr.
+func minimizeNumeric(fn func([]float64) float64, start []float64, opts boundedMinimizeOptions) (*fa.BoundedResult, error) {
+	k := len(start)
+	if k == 0 {
+		return nil, errors.New("no parameters to optimise")
+	}
+	lower := opts.lower
+	if lower == nil {
+		lower = make([]float64, k)
+		for i := range lower {
+			lower[i] = math.Inf(-1)
+		}
+	}
+	upper := opts.upper
+	if upper == nil {
+		upper = make([]float64, k)
+		for i := range upper {
+			upper[i] = math.Inf(1)
+		}
+	}
+	safeFn := func(x []float64) float64 {
+		v := fn(x)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return math.MaxFloat64 / 1e10
+		}
+		return v
+	}
+	grad := func(g, x []float64) {
+		numericGradient(safeFn, x, g, opts.parscale)
+	}
+	maxIter := opts.maxIter
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+	return fa.MinimizeBounded(start, lower, upper, safeFn, grad, fa.BoundedOptions{
+		MaxIter:  maxIter,
+		Parscale: opts.parscale,
+	})
+}
+
+// invertSymmetric inverts a symmetric positive-definite matrix, returning
+// nil when it is singular.
+func invertSymmetric(a [][]float64) [][]float64 {
+	k := len(a)
+	if k == 0 {
+		return nil
+	}
+	m := mat.NewDense(k, k, nil)
+	for i := range k {
+		for j := range k {
+			m.Set(i, j, a[i][j])
+		}
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(m); err != nil {
+		return nil
+	}
+	out := make([][]float64, k)
+	for i := range k {
+		out[i] = make([]float64, k)
+		for j := range k {
+			out[i][j] = inv.At(i, j)
+		}
+	}
+	return out
+}
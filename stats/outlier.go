@@ -0,0 +1,916 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	internalknn "github.com/HazelnutParadise/insyra/stats/internal/knn"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// OutlierResult holds one anomaly score and one flag per observation, in
+// input order. Larger scores are more anomalous. Flags are true where the
+// observation is declared an outlier, which for most methods means
+// Score > Threshold. Missing observations (nil or NaN) in the univariate
+// methods get a NaN score and a false flag.
+type OutlierResult struct {
+	Scores    insyra.IDataList
+	Flags     insyra.IDataList
+	Threshold float64
+}
+
+func newOutlierResult(scores []float64, flags []bool, threshold float64) OutlierResult {
+	s := make([]any, len(scores))
+	f := make([]any, len(flags))
+	for i := range scores {
+		s[i] = scores[i]
+		f[i] = flags[i]
+	}
+	return OutlierResult{
+		Scores:    insyra.NewDataList(s...).SetName("Score"),
+		Flags:     insyra.NewDataList(f...).SetName("Outlier"),
+		Threshold: threshold,
+	}
+}
+
+func flagsAbove(scores []float64, threshold float64) []bool {
+	flags := make([]bool, len(scores))
+	for i, s := range scores {
+		flags[i] = s > threshold
+	}
+	return flags
+}
+
+// ============================================================
+// Mahalanobis distance with MCD covariance
+// ============================================================
+
+// MahalanobisOptions configures MahalanobisOutliers.
+type MahalanobisOptions struct {
+	// Classical uses the sample mean and covariance instead of the MCD.
+	Classical bool
+	// SupportFraction is the MCD alpha in [0.5, 1] (default 0.5, the most
+	// robust choice); the MCD subset has about alpha*n observations.
+	SupportFraction float64
+	// Quantile of the chi-square distribution with p degrees of freedom
+	// used as the flag cutoff for squared distances (default 0.975).
+	Quantile float64
+	// NTrials is the number of random starts of FAST-MCD (default 500).
+	NTrials int
+	// Seed and UseSeed make the random starts reproducible.
+	Seed    uint64
+	UseSeed bool
+}
+
+// MahalanobisResult is the outcome of MahalanobisOutliers. Scores are
+// squared Mahalanobis distances from Center under Covariance.
+type MahalanobisResult struct {
+	OutlierResult
+	Center     []float64
+	Covariance insyra.IDataTable
+	// Support lists the 0-based rows of the raw MCD subset (nil when
+	// Classical is set).
+	Support []int
+}
+
+// MahalanobisOutliers scores every row by its squared Mahalanobis distance
+// and flags distances above the chi-square quantile. By default the center
+// and covariance are the reweighted Minimum Covariance Determinant
+// estimates (FAST-MCD, Rousseeuw & Van Driessen 1999), which unlike the
+// sample estimates are not pulled towards the outliers they are meant to
+// reveal. The raw and reweighted covariances carry the same consistency
+// factors as robustbase::covMcd, without its small-sample correction.
+func MahalanobisOutliers(dataTable insyra.IDataTable, opts ...MahalanobisOptions) (*MahalanobisResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt MahalanobisOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.SupportFraction == 0 {
+		opt.SupportFraction = 0.5
+	}
+	if opt.Quantile == 0 {
+		opt.Quantile = 0.975
+	}
+	if opt.NTrials == 0 {
+		opt.NTrials = 500
+	}
+	if opt.SupportFraction < 0.5 || opt.SupportFraction > 1 {
+		return nil, errors.New("support fraction must be in [0.5, 1]")
+	}
+	if opt.Quantile <= 0 || opt.Quantile >= 1 {
+		return nil, errors.New("quantile must be in (0, 1)")
+	}
+	if opt.NTrials < 1 {
+		return nil, errors.New("number of trials must be positive")
+	}
+	x, err := outlierMatrix(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	n, p := len(x), len(x[0])
+	if n <= p+1 {
+		return nil, fmt.Errorf("need more than %d rows for %d columns", p+1, p)
+	}
+
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	var center []float64
+	var cov *mat.SymDense
+	var support []int
+	if opt.Classical {
+		center, cov = meanCovOf(x, all)
+	} else {
+		// robustbase h.alpha.n: the subset size for support fraction alpha.
+		half := (n + p + 1) / 2
+		h := int(math.Floor(float64(2*half-n) + 2*opt.SupportFraction*float64(n-half)))
+		rng := replicateRNG(resolveSeed(opt.Seed, opt.UseSeed), 0)
+		support, center, cov, err = fastMCD(x, h, opt.NTrials, rng)
+		if err != nil {
+			return nil, err
+		}
+		alpha := float64(h) / float64(n)
+		cov.ScaleSym(alpha/distuv.ChiSquared{K: float64(p + 2)}.CDF(distuv.ChiSquared{K: float64(p)}.Quantile(alpha)), cov)
+
+		// Reweighting step: re-estimate from the rows within the 97.5%
+		// chi-square cutoff of the raw fit.
+		d2, err := squaredMahalanobis(x, center, cov)
+		if err != nil {
+			return nil, err
+		}
+		cut := distuv.ChiSquared{K: float64(p)}.Quantile(0.975)
+		var kept []int
+		for i, d := range d2 {
+			if d <= cut {
+				kept = append(kept, i)
+			}
+		}
+		if len(kept) > p {
+			center, cov = meanCovOf(x, kept)
+			cov.ScaleSym(0.975/distuv.ChiSquared{K: float64(p + 2)}.CDF(cut), cov)
+		}
+	}
+	scores, err := squaredMahalanobis(x, center, cov)
+	if err != nil {
+		return nil, err
+	}
+	threshold := distuv.ChiSquared{K: float64(p)}.Quantile(opt.Quantile)
+	names := outlierColumnNames(dataTable)
+	return &MahalanobisResult{
+		OutlierResult: newOutlierResult(scores, flagsAbove(scores, threshold), threshold),
+		Center:        center,
+		Covariance:    matrixToDataTableWithNames(cov, "Covariance", names, names),
+		Support:       support,
+	}, nil
+}
+
+// fastMCD returns the h-subset with the smallest covariance determinant
+// found from trials random (p+1)-subsets, each refined by C-steps.
+func fastMCD(x [][]float64, h, trials int, rng *rand.Rand) ([]int, []float64, *mat.SymDense, error) {
+	n, p := len(x), len(x[0])
+	type candidate struct {
+		subset []int
+		logDet float64
+	}
+	var best []candidate
+	for range trials {
+		perm := rng.Perm(n)
+		size := p + 1
+		var subset []int
+		for ; size <= n; size++ {
+			_, cov := meanCovOf(x, perm[:size])
+			var chol mat.Cholesky
+			if chol.Factorize(cov) {
+				subset = perm[:size]
+				break
+			}
+		}
+		if subset == nil {
+			continue
+		}
+		ok := true
+		logDet := 0.0
+		for range 2 {
+			var err error
+			subset, logDet, err = mcdCStep(x, subset, h)
+			if err != nil {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		best = append(best, candidate{subset, logDet})
+		sort.Slice(best, func(a, b int) bool { return best[a].logDet < best[b].logDet })
+		if len(best) > 10 {
+			best = best[:10]
+		}
+	}
+	if len(best) == 0 {
+		return nil, nil, nil, fmt.Errorf("covariance of every %d-subset is singular; more than half the rows may lie on a hyperplane", h)
+	}
+	winner := candidate{logDet: math.Inf(1)}
+	for _, c := range best {
+		for range 100 {
+			next, logDet, err := mcdCStep(x, c.subset, h)
+			if err != nil || logDet >= c.logDet-1e-12 {
+				break
+			}
+			c = candidate{next, logDet}
+		}
+		if c.logDet < winner.logDet {
+			winner = c
+		}
+	}
+	sort.Ints(winner.subset)
+	center, cov := meanCovOf(x, winner.subset)
+	return winner.subset, center, cov, nil
+}
+
+// mcdCStep estimates location and scatter from subset and returns the h
+// rows closest under them, with the log-determinant of that new subset.
+func mcdCStep(x [][]float64, subset []int, h int) ([]int, float64, error) {
+	center, cov := meanCovOf(x, subset)
+	d2, err := squaredMahalanobis(x, center, cov)
+	if err != nil {
+		return nil, 0, err
+	}
+	order := make([]int, len(x))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return d2[order[a]] < d2[order[b]] })
+	next := append([]int(nil), order[:h]...)
+	_, nextCov := meanCovOf(x, next)
+	var chol mat.Cholesky
+	if !chol.Factorize(nextCov) {
+		return nil, 0, errors.New("singular subset covariance")
+	}
+	return next, chol.LogDet(), nil
+}
+
+// meanCovOf returns the mean and sample covariance (n-1 denominator) of
+// the given rows.
+func meanCovOf(x [][]float64, rows []int) ([]float64, *mat.SymDense) {
+	p := len(x[0])
+	center := make([]float64, p)
+	for _, i := range rows {
+		for j, v := range x[i] {
+			center[j] += v
+		}
+	}
+	for j := range center {
+		center[j] /= float64(len(rows))
+	}
+	cov := mat.NewSymDense(p, nil)
+	for _, i := range rows {
+		for a := range p {
+			da := x[i][a] - center[a]
+			for b := a; b < p; b++ {
+				cov.SetSym(a, b, cov.At(a, b)+da*(x[i][b]-center[b]))
+			}
+		}
+	}
+	cov.ScaleSym(1/float64(len(rows)-1), cov)
+	return center, cov
+}
+
+func squaredMahalanobis(x [][]float64, center []float64, cov *mat.SymDense) ([]float64, error) {
+	var chol mat.Cholesky
+	if !chol.Factorize(cov) {
+		return nil, errors.New("covariance matrix is singular")
+	}
+	p := len(center)
+	out := make([]float64, len(x))
+	diff := mat.NewVecDense(p, nil)
+	var z mat.VecDense
+	for i, row := range x {
+		for j := range p {
+			diff.SetVec(j, row[j]-center[j])
+		}
+		if err := z.SolveVec(&chol, diff); err != nil {
+			return nil, err
+		}
+		out[i] = mat.Dot(diff, &z)
+	}
+	return out, nil
+}
+
+// ============================================================
+// Isolation Forest
+// ============================================================
+
+// IsolationForestOptions configures IsolationForest.
+type IsolationForestOptions struct {
+	// NTrees is the number of isolation trees (default 100).
+	NTrees int
+	// SampleSize is the subsample drawn without replacement for each tree
+	// (default min(256, n)).
+	SampleSize int
+	// Contamination, when in (0, 0.5], sets Threshold so that this share of
+	// the training rows is flagged. Otherwise rows scoring above 0.5 are
+	// flagged.
+	Contamination float64
+	// Seed and UseSeed make the trees reproducible regardless of how many
+	// cores are used.
+	Seed    uint64
+	UseSeed bool
+}
+
+// IsolationForestModel is a fitted isolation forest. The embedded result
+// holds the training scores; Score applies the forest to new rows.
+type IsolationForestModel struct {
+	OutlierResult
+	NTrees     int
+	SampleSize int
+
+	nCols int
+	trees []*isolationNode
+}
+
+type isolationNode struct {
+	feature     int
+	split       float64
+	left, right *isolationNode
+	size        int
+}
+
+// IsolationForest fits an isolation forest (Liu, Ting & Zhou 2008). The
+// score of a row is 2^(-E[h]/c(ψ)), where E[h] is its mean path length over
+// the trees and c(ψ) the average path length of an unsuccessful binary
+// search tree lookup among ψ = SampleSize points. Scores near 1 are easy to
+// isolate and therefore anomalous; scores well below 0.5 are normal.
+func IsolationForest(dataTable insyra.IDataTable, opts ...IsolationForestOptions) (*IsolationForestModel, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt IsolationForestOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	x, err := outlierMatrix(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	n := len(x)
+	if opt.NTrees == 0 {
+		opt.NTrees = 100
+	}
+	if opt.SampleSize == 0 {
+		opt.SampleSize = min(256, n)
+	}
+	if opt.NTrees < 1 {
+		return nil, errors.New("number of trees must be positive")
+	}
+	if opt.SampleSize < 2 || opt.SampleSize > n {
+		return nil, fmt.Errorf("sample size must be between 2 and the number of rows (%d)", n)
+	}
+	if opt.Contamination < 0 || opt.Contamination > 0.5 {
+		return nil, errors.New("contamination must be in [0, 0.5]")
+	}
+
+	seed := resolveSeed(opt.Seed, opt.UseSeed)
+	limit := int(math.Ceil(math.Log2(float64(opt.SampleSize))))
+	trees := make([]*isolationNode, opt.NTrees)
+	runParallelChunks(opt.NTrees, func(start, end int) {
+		for t := start; t < end; t++ {
+			rng := replicateRNG(seed, uint64(t))
+			sample := rng.Perm(n)[:opt.SampleSize]
+			trees[t] = buildIsolationTree(x, sample, 0, limit, rng)
+		}
+	})
+	m := &IsolationForestModel{NTrees: opt.NTrees, SampleSize: opt.SampleSize, nCols: len(x[0]), trees: trees}
+	scores := m.scoreRows(x)
+	threshold := 0.5
+	if opt.Contamination > 0 {
+		sorted := append([]float64(nil), scores...)
+		sort.Float64s(sorted)
+		threshold = sortedQuantile(sorted, 1-opt.Contamination)
+	}
+	m.OutlierResult = newOutlierResult(scores, flagsAbove(scores, threshold), threshold)
+	return m, nil
+}
+
+// Score scores new rows with the fitted forest, flagging them with the
+// training Threshold.
+func (m *IsolationForestModel) Score(dataTable insyra.IDataTable) (*OutlierResult, error) {
+	if m == nil || len(m.trees) == 0 {
+		return nil, errors.New("isolation forest is not fitted")
+	}
+	x, err := outlierMatrix(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(x[0]) != m.nCols {
+		return nil, fmt.Errorf("data has %d columns, the forest was fitted on %d", len(x[0]), m.nCols)
+	}
+	scores := m.scoreRows(x)
+	res := newOutlierResult(scores, flagsAbove(scores, m.Threshold), m.Threshold)
+	return &res, nil
+}
+
+func (m *IsolationForestModel) scoreRows(x [][]float64) []float64 {
+	scores := make([]float64, len(x))
+	norm := averagePathLength(m.SampleSize)
+	runParallelChunks(len(x), func(start, end int) {
+		for i := start; i < end; i++ {
+			total := 0.0
+			for _, tree := range m.trees {
+				total += isolationPathLength(tree, x[i], 0)
+			}
+			scores[i] = math.Pow(2, -total/float64(len(m.trees))/norm)
+		}
+	})
+	return scores
+}
+
+func buildIsolationTree(x [][]float64, rows []int, depth, limit int, rng *rand.Rand) *isolationNode {
+	if depth >= limit || len(rows) <= 1 {
+		return &isolationNode{size: len(rows)}
+	}
+	var candidates []int
+	lows := make([]float64, len(x[0]))
+	highs := make([]float64, len(x[0]))
+	for j := range x[0] {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, i := range rows {
+			lo = math.Min(lo, x[i][j])
+			hi = math.Max(hi, x[i][j])
+		}
+		lows[j], highs[j] = lo, hi
+		if hi > lo {
+			candidates = append(candidates, j)
+		}
+	}
+	if len(candidates) == 0 {
+		return &isolationNode{size: len(rows)}
+	}
+	feature := candidates[rng.IntN(len(candidates))]
+	split := lows[feature] + rng.Float64()*(highs[feature]-lows[feature])
+	var left, right []int
+	for _, i := range rows {
+		if x[i][feature] < split {
+			left = append(left, i)
+		} else {
+			right = append(right, i)
+		}
+	}
+	return &isolationNode{
+		feature: feature,
+		split:   split,
+		left:    buildIsolationTree(x, left, depth+1, limit, rng),
+		right:   buildIsolationTree(x, right, depth+1, limit, rng),
+		size:    len(rows),
+	}
+}
+
+func isolationPathLength(node *isolationNode, row []float64, depth int) float64 {
+	if node.left == nil {
+		return float64(depth) + averagePathLength(node.size)
+	}
+	if row[node.feature] < node.split {
+		return isolationPathLength(node.left, row, depth+1)
+	}
+	return isolationPathLength(node.right, row, depth+1)
+}
+
+// averagePathLength is c(n), the mean path length of an unsuccessful
+// search in a binary search tree of n points.
+func averagePathLength(n int) float64 {
+	switch {
+	case n <= 1:
+		return 0
+	case n == 2:
+		return 1
+	}
+	const eulerGamma = 0.5772156649015329
+	f := float64(n)
+	return 2*(math.Log(f-1)+eulerGamma) - 2*(f-1)/f
+}
+
+// ============================================================
+// Local Outlier Factor
+// ============================================================
+
+// LOFOptions configures LocalOutlierFactor.
+type LOFOptions struct {
+	// K is the neighbourhood size (default 20, capped at n-1).
+	K         int
+	Algorithm KNNAlgorithm
+	LeafSize  int
+	// Threshold flags rows whose LOF exceeds it (default 1.5).
+	Threshold float64
+}
+
+// LocalOutlierFactor computes the LOF of every row (Breunig et al. 2000)
+// using the KNN search backend: the ratio of the neighbours' local
+// reachability density to the row's own. Values near 1 mean the row is as
+// dense as its neighbourhood; values well above 1 mark local outliers.
+func LocalOutlierFactor(dataTable insyra.IDataTable, opts ...LOFOptions) (*OutlierResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt LOFOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	x, err := outlierMatrix(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	n := len(x)
+	if n < 2 {
+		return nil, errors.New("need at least 2 rows")
+	}
+	if opt.K == 0 {
+		opt.K = 20
+	}
+	if opt.K < 1 {
+		return nil, errors.New("k must be positive")
+	}
+	k := min(opt.K, n-1)
+	if opt.Threshold == 0 {
+		opt.Threshold = 1.5
+	}
+	got, err := internalknn.Neighbors(x, x, k+1, internalknn.Options{
+		Algorithm: internalknn.Algorithm(opt.Algorithm),
+		LeafSize:  opt.LeafSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Drop each row from its own neighbour list; with duplicate points the
+	// row may not be listed first, in which case the farthest is dropped.
+	neighbors := make([][]int, n)
+	dists := make([][]float64, n)
+	kdist := make([]float64, n)
+	for i := range n {
+		skip := len(got.Indices[i]) - 1
+		for q, j := range got.Indices[i] {
+			if j == i {
+				skip = q
+				break
+			}
+		}
+		for q, j := range got.Indices[i] {
+			if q != skip {
+				neighbors[i] = append(neighbors[i], j)
+				dists[i] = append(dists[i], got.Distances[i][q])
+			}
+		}
+		kdist[i] = dists[i][k-1]
+	}
+	lrd := make([]float64, n)
+	for i := range n {
+		sum := 0.0
+		for q, j := range neighbors[i] {
+			sum += math.Max(kdist[j], dists[i][q])
+		}
+		// The 1e-10 guard (as in scikit-learn) keeps duplicates finite.
+		lrd[i] = 1 / (sum/float64(k) + 1e-10)
+	}
+	scores := make([]float64, n)
+	for i := range n {
+		sum := 0.0
+		for _, j := range neighbors[i] {
+			sum += lrd[j]
+		}
+		scores[i] = sum / float64(k) / lrd[i]
+	}
+	res := newOutlierResult(scores, flagsAbove(scores, opt.Threshold), opt.Threshold)
+	return &res, nil
+}
+
+// ============================================================
+// Univariate tests
+// ============================================================
+
+// IQROptions configures IQROutliers.
+type IQROptions struct {
+	// Multiplier of the IQR that places the fences (default 1.5).
+	Multiplier float64
+}
+
+// IQROutlierResult is the outcome of IQROutliers. Scores are the distance
+// beyond the nearer fence in IQR units (0 inside the fences).
+type IQROutlierResult struct {
+	OutlierResult
+	Q1, Q3, IQR  float64
+	Lower, Upper float64
+}
+
+// IQROutliers flags values outside [Q1 - m·IQR, Q3 + m·IQR] (Tukey's
+// fences), with type-7 quartiles as in R's quantile default.
+func IQROutliers(data insyra.IDataList, opts ...IQROptions) (*IQROutlierResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	m := 1.5
+	if len(opts) == 1 && opts[0].Multiplier != 0 {
+		m = opts[0].Multiplier
+	}
+	if m < 0 {
+		return nil, errors.New("multiplier must be non-negative")
+	}
+	x, observed, err := outlierValues(data)
+	if err != nil {
+		return nil, err
+	}
+	sort.Float64s(observed)
+	q1, q3 := sortedQuantile(observed, 0.25), sortedQuantile(observed, 0.75)
+	iqr := q3 - q1
+	lower, upper := q1-m*iqr, q3+m*iqr
+	scores := make([]float64, len(x))
+	flags := make([]bool, len(x))
+	for i, v := range x {
+		beyond := math.Max(lower-v, v-upper)
+		switch {
+		case math.IsNaN(v):
+			scores[i] = math.NaN()
+		case beyond <= 0:
+		case iqr == 0:
+			scores[i] = math.Inf(1)
+		default:
+			scores[i] = beyond / iqr
+		}
+		flags[i] = beyond > 0
+	}
+	return &IQROutlierResult{
+		OutlierResult: newOutlierResult(scores, flags, 0),
+		Q1:            q1, Q3: q3, IQR: iqr,
+		Lower: lower, Upper: upper,
+	}, nil
+}
+
+// HampelOptions configures HampelOutliers.
+type HampelOptions struct {
+	// HalfWindow is the number of neighbours on each side used for the
+	// local median; 0 uses the whole series. Windows are truncated at the
+	// ends of the series.
+	HalfWindow int
+	// Threshold on the robust z-score (default 3).
+	Threshold float64
+}
+
+// HampelResult is the outcome of HampelOutliers. Medians holds the
+// reference median for each observation.
+type HampelResult struct {
+	OutlierResult
+	Medians insyra.IDataList
+}
+
+// HampelOutliers is the Hampel identifier: each value is compared with the
+// median of its window, and the score is |x - median| / (1.4826·MAD), a
+// z-score that the outliers themselves barely influence.
+func HampelOutliers(data insyra.IDataList, opts ...HampelOptions) (*HampelResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt HampelOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	if opt.Threshold == 0 {
+		opt.Threshold = 3
+	}
+	if opt.HalfWindow < 0 || opt.Threshold < 0 {
+		return nil, errors.New("half window and threshold must be non-negative")
+	}
+	x, _, err := outlierValues(data)
+	if err != nil {
+		return nil, err
+	}
+	n := len(x)
+	scores := make([]float64, n)
+	medians := make([]any, n)
+	windowStats := func(lo, hi int) (float64, float64) {
+		var w []float64
+		for _, v := range x[lo:hi] {
+			if !math.IsNaN(v) {
+				w = append(w, v)
+			}
+		}
+		if len(w) == 0 {
+			return math.NaN(), math.NaN()
+		}
+		med := sortedMedian(w)
+		for i := range w {
+			w[i] = math.Abs(w[i] - med)
+		}
+		return med, 1.4826 * sortedMedian(w)
+	}
+	med, scale := windowStats(0, n)
+	for i, v := range x {
+		if opt.HalfWindow > 0 {
+			med, scale = windowStats(max(0, i-opt.HalfWindow), min(n, i+opt.HalfWindow+1))
+		}
+		medians[i] = med
+		dev := math.Abs(v - med)
+		switch {
+		case math.IsNaN(v):
+			scores[i] = math.NaN()
+		case dev == 0:
+		case scale == 0:
+			scores[i] = math.Inf(1)
+		default:
+			scores[i] = dev / scale
+		}
+	}
+	return &HampelResult{
+		OutlierResult: newOutlierResult(scores, flagsAbove(scores, opt.Threshold), opt.Threshold),
+		Medians:       insyra.NewDataList(medians...).SetName("Median"),
+	}, nil
+}
+
+// sortedMedian sorts w in place and returns its median.
+func sortedMedian(w []float64) float64 {
+	sort.Float64s(w)
+	mid := len(w) / 2
+	if len(w)%2 == 0 {
+		return (w[mid-1] + w[mid]) / 2
+	}
+	return w[mid]
+}
+
+// ESDOptions configures GeneralizedESD.
+type ESDOptions struct {
+	// MaxOutliers is the upper bound r on the number of outliers (default
+	// 10% of the observations, at least 1).
+	MaxOutliers int
+	// Alpha is the significance level (default 0.05).
+	Alpha float64
+}
+
+// ESDResult is the outcome of GeneralizedESD. Statistics and
+// CriticalValues hold R_i and λ_i for i = 1..MaxOutliers. Scores are
+// |x - mean| / sd over the full sample, and Threshold is NaN because the
+// flags come from the stepwise test rather than a single cutoff.
+type ESDResult struct {
+	OutlierResult
+	Statistics     []float64
+	CriticalValues []float64
+	NumOutliers    int
+}
+
+// GeneralizedESD runs Rosner's (1983) generalized extreme Studentized
+// deviate test. It removes the most extreme value r times, comparing each
+// R_i = max|x - mean|/sd with λ_i = (n-i)·t / sqrt((n-i-1+t²)(n-i+1)),
+// t = t_{1-α/(2(n-i+1)), n-i-1}; the number of outliers is the largest i
+// with R_i > λ_i. It assumes the remaining data are approximately normal.
+func GeneralizedESD(data insyra.IDataList, opts ...ESDOptions) (*ESDResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt ESDOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	x, observed, err := outlierValues(data)
+	if err != nil {
+		return nil, err
+	}
+	n := len(observed)
+	if opt.MaxOutliers == 0 {
+		opt.MaxOutliers = max(1, n/10)
+	}
+	if opt.Alpha == 0 {
+		opt.Alpha = 0.05
+	}
+	if opt.Alpha <= 0 || opt.Alpha >= 1 {
+		return nil, errors.New("alpha must be in (0, 1)")
+	}
+	if opt.MaxOutliers < 1 || opt.MaxOutliers > n-3 {
+		return nil, fmt.Errorf("max outliers must be between 1 and %d", n-3)
+	}
+
+	mean, sd := meanSD(observed)
+	scores := make([]float64, len(x))
+	for i, v := range x {
+		scores[i] = math.Abs(v-mean) / sd
+	}
+
+	// Work on positions into x so removed values map back to observations.
+	var active []int
+	for i, v := range x {
+		if !math.IsNaN(v) {
+			active = append(active, i)
+		}
+	}
+	statistics := make([]float64, opt.MaxOutliers)
+	crit := make([]float64, opt.MaxOutliers)
+	removed := make([]int, opt.MaxOutliers)
+	numOutliers := 0
+	for step := range opt.MaxOutliers {
+		vals := make([]float64, len(active))
+		for q, i := range active {
+			vals[q] = x[i]
+		}
+		m, s := meanSD(vals)
+		worst := 0
+		for q, v := range vals {
+			if math.Abs(v-m) > math.Abs(vals[worst]-m) {
+				worst = q
+			}
+		}
+		statistics[step] = math.Abs(vals[worst]-m) / s
+		i := float64(step + 1)
+		nf := float64(n)
+		t := tQuantile(1-opt.Alpha/(2*(nf-i+1)), nf-i-1)
+		crit[step] = (nf - i) * t / math.Sqrt((nf-i-1+t*t)*(nf-i+1))
+		if statistics[step] > crit[step] {
+			numOutliers = step + 1
+		}
+		removed[step] = active[worst]
+		active = append(active[:worst], active[worst+1:]...)
+	}
+	flags := make([]bool, len(x))
+	for _, i := range removed[:numOutliers] {
+		flags[i] = true
+	}
+	return &ESDResult{
+		OutlierResult:  newOutlierResult(scores, flags, math.NaN()),
+		Statistics:     statistics,
+		CriticalValues: crit,
+		NumOutliers:    numOutliers,
+	}, nil
+}
+
+func meanSD(v []float64) (float64, float64) {
+	m := 0.0
+	for _, x := range v {
+		m += x
+	}
+	m /= float64(len(v))
+	ss := 0.0
+	for _, x := range v {
+		ss += (x - m) * (x - m)
+	}
+	return m, math.Sqrt(ss / float64(len(v)-1))
+}
+
+// ============================================================
+// Input helpers
+// ============================================================
+
+func outlierMatrix(dataTable insyra.IDataTable) ([][]float64, error) {
+	if dataTable == nil {
+		return nil, errors.New("data table is nil")
+	}
+	x, _, err := numericMatrixFromTable(dataTable)
+	return x, err
+}
+
+func outlierColumnNames(dataTable insyra.IDataTable) []string {
+	var names []string
+	dataTable.AtomicDo(func(dt *insyra.DataTable) {
+		names = dt.ColNames()
+	})
+	for j, name := range names {
+		if name == "" {
+			names[j] = fmt.Sprintf("V%d", j+1)
+		}
+	}
+	return names
+}
+
+// outlierValues reads a DataList as float64 with NaN for missing values,
+// and also returns the observed values.
+func outlierValues(data insyra.IDataList) ([]float64, []float64, error) {
+	if data == nil {
+		return nil, nil, errors.New("data list is nil")
+	}
+	var raw []any
+	data.AtomicDo(func(dl *insyra.DataList) {
+		raw = dl.Data()
+	})
+	x := make([]float64, len(raw))
+	var observed []float64
+	for i, v := range raw {
+		if v == nil {
+			x[i] = math.NaN()
+			continue
+		}
+		f, ok := insyra.ToFloat64Safe(v)
+		if !ok {
+			return nil, nil, fmt.Errorf("value %v at index %d is not numeric", v, i)
+		}
+		x[i] = f
+		if !math.IsNaN(f) {
+			observed = append(observed, f)
+		}
+	}
+	if len(observed) < 4 {
+		return nil, nil, errors.New("need at least 4 observed values")
+	}
+	return x, observed, nil
+}
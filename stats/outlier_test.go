@@ -0,0 +1,278 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+func outlierFlags(t *testing.T, r stats.OutlierResult) []bool {
+	t.Helper()
+	raw := r.Flags.Data()
+	out := make([]bool, len(raw))
+	for i, v := range raw {
+		out[i] = v.(bool)
+	}
+	return out
+}
+
+func outlierScores(r stats.OutlierResult) []float64 {
+	raw := r.Scores.Data()
+	out := make([]float64, len(raw))
+	for i, v := range raw {
+		out[i] = v.(float64)
+	}
+	return out
+}
+
+// contaminatedRows returns n correlated bivariate normal rows whose last
+// nBad rows are shifted against the correlation.
+func contaminatedRows(n, nBad int) [][]float64 {
+	z := seededNormals(41, 2*n)
+	rows := make([][]float64, n)
+	for i := range rows {
+		x := z[2*i]
+		rows[i] = []float64{x, 0.9*x + 0.3*z[2*i+1]}
+		if i >= n-nBad {
+			rows[i] = []float64{2 + 0.1*z[2*i], -2 + 0.1*z[2*i+1]}
+		}
+	}
+	return rows
+}
+
+func TestMahalanobisMCDResistsMasking(t *testing.T) {
+	n, nBad := 100, 15
+	dt := dataTableFromRows(contaminatedRows(n, nBad))
+	robust, err := stats.MahalanobisOutliers(dt, stats.MahalanobisOptions{Seed: 1, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := outlierFlags(t, robust.OutlierResult)
+	for i := n - nBad; i < n; i++ {
+		if !flags[i] {
+			t.Errorf("MCD missed planted outlier %d (d² = %v)", i, robust.Scores.Data()[i])
+		}
+	}
+	clean := 0
+	for i := range n - nBad {
+		if flags[i] {
+			clean++
+		}
+	}
+	if clean > 8 {
+		t.Errorf("MCD flagged %d of %d clean rows", clean, n-nBad)
+	}
+	if math.Abs(robust.Center[0]) > 0.3 || math.Abs(robust.Center[1]) > 0.3 {
+		t.Errorf("robust center %v pulled towards the outliers", robust.Center)
+	}
+	if len(robust.Support) != (n+3)/2 {
+		t.Errorf("support has %d rows, want h = %d", len(robust.Support), (n+3)/2)
+	}
+	if want := 7.377758908227871; !floatAlmostEqual(robust.Threshold, want, 1e-9) {
+		t.Errorf("threshold %v, want qchisq(0.975, 2) = %v", robust.Threshold, want)
+	}
+
+	classical, err := stats.MahalanobisOutliers(dt, stats.MahalanobisOptions{Classical: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caught := 0
+	for i, f := range outlierFlags(t, classical.OutlierResult) {
+		if f && i >= n-nBad {
+			caught++
+		}
+	}
+	if caught >= nBad {
+		t.Errorf("classical distances should be masked by the cluster, caught %d/%d", caught, nBad)
+	}
+}
+
+func TestMahalanobisClassicalDistances(t *testing.T) {
+	rows := [][]float64{{1, 2}, {2, 1}, {3, 5}, {4, 3}, {5, 4}}
+	res, err := stats.MahalanobisOutliers(dataTableFromRows(rows), stats.MahalanobisOptions{Classical: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mean (3, 3), covariance [[2.5, 1.5], [1.5, 2.5]]. Squared distances
+	// of a sample from its own mean and covariance sum to (n-1)·p.
+	sum := 0.0
+	for _, s := range outlierScores(res.OutlierResult) {
+		sum += s
+	}
+	if !floatAlmostEqual(sum, 8, 1e-9) {
+		t.Errorf("sum of d² = %v, want 8", sum)
+	}
+	// Row 1: diff (-2, -1); d² = diffᵀ Σ⁻¹ diff with det Σ = 4.
+	want := (2.5*4 - 2*1.5*2 + 2.5*1) / 4.0
+	if got := res.Scores.Data()[0].(float64); !floatAlmostEqual(got, want, 1e-9) {
+		t.Errorf("d²[0] = %v, want %v", got, want)
+	}
+	if c := res.Covariance.(*insyra.DataTable).GetColByNumber(1).Data()[0]; !floatAlmostEqual(c.(float64), 1.5, 1e-12) {
+		t.Errorf("covariance[0][1] = %v, want 1.5", c)
+	}
+}
+
+func TestIsolationForestSeparatesAnomalies(t *testing.T) {
+	n := 200
+	z := seededNormals(77, 2*n)
+	rows := make([][]float64, n)
+	for i := range rows {
+		rows[i] = []float64{z[2*i], z[2*i+1]}
+	}
+	rows[0] = []float64{8, 8}
+	rows[1] = []float64{-7, 6}
+	dt := dataTableFromRows(rows)
+	opt := stats.IsolationForestOptions{Seed: 5, UseSeed: true}
+	model, err := stats.IsolationForest(dt, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := outlierScores(model.OutlierResult)
+	mean := 0.0
+	for _, s := range scores[2:] {
+		mean += s
+	}
+	mean /= float64(n - 2)
+	if scores[0] < 0.6 || scores[1] < 0.6 || mean > 0.5 {
+		t.Errorf("anomaly scores %v, %v; mean normal score %v", scores[0], scores[1], mean)
+	}
+	flags := outlierFlags(t, model.OutlierResult)
+	if !flags[0] || !flags[1] {
+		t.Errorf("planted anomalies not flagged")
+	}
+
+	again, _ := stats.IsolationForest(dt, opt)
+	for i, s := range outlierScores(again.OutlierResult) {
+		if s != scores[i] {
+			t.Fatalf("score %d differs for the same seed", i)
+		}
+	}
+
+	fresh, err := model.Score(dataTableFromRows([][]float64{{0, 0}, {9, -9}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := outlierFlags(t, *fresh); f[0] || !f[1] {
+		t.Errorf("new-data flags %v, want [false true]", f)
+	}
+
+	top, err := stats.IsolationForest(dt, stats.IsolationForestOptions{Contamination: 0.05, Seed: 5, UseSeed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged := 0
+	for _, f := range outlierFlags(t, top.OutlierResult) {
+		if f {
+			flagged++
+		}
+	}
+	if flagged != 10 {
+		t.Errorf("contamination 0.05 flagged %d of %d rows, want 10", flagged, n)
+	}
+}
+
+func TestLocalOutlierFactorByHand(t *testing.T) {
+	// With k = 1 the k-distances are 1, 1, 1, 8, so the three clustered
+	// points have LOF 1 and the isolated point has LOF 8.
+	dt := dataTableFromRows([][]float64{{0}, {1}, {2}, {10}})
+	res, err := stats.LocalOutlierFactor(dt, stats.LOFOptions{K: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{1, 1, 1, 8}
+	for i, s := range outlierScores(*res) {
+		if !floatAlmostEqual(s, want[i], 1e-6) {
+			t.Errorf("LOF[%d] = %v, want %v", i, s, want[i])
+		}
+	}
+	if f := outlierFlags(t, *res); f[2] || !f[3] {
+		t.Errorf("flags %v", f)
+	}
+}
+
+func TestIQRAndHampelOutliers(t *testing.T) {
+	data := insyra.NewDataList(1, 2, 3, 4, 5, 6, 7, 8, 9, 30, nil)
+	iqr, err := stats.IQROutliers(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iqr.Q1 != 3.25 || iqr.Q3 != 7.75 || iqr.Upper != 14.5 {
+		t.Errorf("quartiles %v %v, upper fence %v", iqr.Q1, iqr.Q3, iqr.Upper)
+	}
+	scores := outlierScores(iqr.OutlierResult)
+	if !floatAlmostEqual(scores[9], 15.5/4.5, 1e-12) || scores[8] != 0 || !math.IsNaN(scores[10]) {
+		t.Errorf("scores %v", scores)
+	}
+	if f := outlierFlags(t, iqr.OutlierResult); !f[9] || f[8] || f[10] {
+		t.Errorf("flags %v", f)
+	}
+
+	h, err := stats.HampelOutliers(insyra.NewDataList(1, 2, 3, 4, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Median 3, MAD 1.
+	hs := outlierScores(h.OutlierResult)
+	if !floatAlmostEqual(hs[4], 97/1.4826, 1e-9) || !floatAlmostEqual(hs[0], 2/1.4826, 1e-9) {
+		t.Errorf("Hampel scores %v", hs)
+	}
+	if f := outlierFlags(t, h.OutlierResult); f[0] || !f[4] {
+		t.Errorf("Hampel flags %v", f)
+	}
+
+	// A spike on a trend is only visible against a local window.
+	trend := insyra.NewDataList()
+	for i := range 40 {
+		v := float64(i) + 0.1*float64(i%3)
+		if i == 20 {
+			v += 20
+		}
+		trend.Append(v)
+	}
+	local, err := stats.HampelOutliers(trend, stats.HampelOptions{HalfWindow: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range outlierFlags(t, local.OutlierResult) {
+		if f != (i == 20) {
+			t.Errorf("windowed Hampel flag[%d] = %v", i, f)
+		}
+	}
+	if global, _ := stats.HampelOutliers(trend); outlierFlags(t, global.OutlierResult)[20] {
+		t.Errorf("global Hampel should not see the spike on a trend")
+	}
+}
+
+// TestGeneralizedESDNIST reproduces the worked example of the NIST/SEMATECH
+// e-Handbook of Statistical Methods, section 1.3.5.17.3.
+func TestGeneralizedESDNIST(t *testing.T) {
+	data := insyra.NewDataList(
+		-0.25, 0.68, 0.94, 1.15, 1.20, 1.26, 1.26, 1.34, 1.38, 1.43, 1.49, 1.49,
+		1.55, 1.56, 1.58, 1.65, 1.69, 1.70, 1.76, 1.77, 1.81, 1.91, 1.94, 1.96,
+		1.99, 2.06, 2.09, 2.10, 2.14, 2.15, 2.23, 2.24, 2.26, 2.35, 2.37, 2.40,
+		2.47, 2.54, 2.62, 2.64, 2.90, 2.92, 2.92, 2.93, 3.21, 3.26, 3.30, 3.59,
+		3.68, 4.30, 4.64, 5.34, 5.42, 6.01,
+	)
+	res, err := stats.GeneralizedESD(data, stats.ESDOptions{MaxOutliers: 10, Alpha: 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantR := []float64{3.118, 2.942, 3.179, 2.810, 2.815, 2.848, 2.279, 2.310, 2.101, 2.067}
+	wantLambda := []float64{3.158, 3.151, 3.143, 3.136, 3.128, 3.120, 3.111, 3.103, 3.094, 3.085}
+	for i := range wantR {
+		if !floatAlmostEqual(res.Statistics[i], wantR[i], 1e-3) || !floatAlmostEqual(res.CriticalValues[i], wantLambda[i], 1e-3) {
+			t.Errorf("step %d: R = %.4f, λ = %.4f, want %.3f, %.3f", i+1, res.Statistics[i], res.CriticalValues[i], wantR[i], wantLambda[i])
+		}
+	}
+	if res.NumOutliers != 3 {
+		t.Fatalf("found %d outliers, want 3", res.NumOutliers)
+	}
+	flags := outlierFlags(t, res.OutlierResult)
+	for i, f := range flags {
+		if f != (i >= 51) {
+			t.Errorf("flag[%d] = %v", i, f)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	internalcluster "github.com/HazelnutParadise/insyra/stats/internal/clustering"
+)
+
+type DistanceMetric string
+
+const (
+	DistanceEuclidean DistanceMetric = "euclidean"
+	DistanceManhattan DistanceMetric = "manhattan"
+	DistanceChebyshev DistanceMetric = "chebyshev"
+)
+
+// PAMOptions configures PAM. Distance, if set, overrides Metric and may be
+// any symmetric non-negative dissimilarity. MaxIter bounds the SWAP phase
+// (default 100).
+type PAMOptions struct {
+	Metric   DistanceMetric
+	Distance func(a, b []float64) float64
+	MaxIter  int
+}
+
+// PAMResult holds a k-medoids partition. Medoids are 0-based row indices;
+// Cluster is 1-based as in KMeansResult; Cost is the total dissimilarity
+// of every row to its medoid.
+type PAMResult struct {
+	Medoids []int
+	Centers insyra.IDataTable
+	Cluster []int
+	Cost    float64
+	Size    []int
+	Swaps   int
+}
+
+// PAM partitions the rows around k medoids with the BUILD and SWAP
+// algorithm of R's cluster::pam.
+func PAM(dataTable insyra.IDataTable, k int, opts ...PAMOptions) (*PAMResult, error) {
+	data, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var options PAMOptions
+	if len(opts) == 1 {
+		options = opts[0]
+	}
+	dist := options.Distance
+	if dist == nil {
+		dist, err = distanceFunc(options.Metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+	got, err := internalcluster.PAM(internalcluster.DissimilarityMatrix(data, dist), k, options.MaxIter)
+	if err != nil {
+		return nil, err
+	}
+	centers := make([][]float64, len(got.Medoids))
+	for i, m := range got.Medoids {
+		centers[i] = data[m]
+	}
+	return &PAMResult{
+		Medoids: got.Medoids,
+		Centers: matrixToDataTable(centers, "V"),
+		Cluster: got.Cluster,
+		Cost:    got.Cost,
+		Size:    got.Size,
+		Swaps:   got.Swaps,
+	}, nil
+}
+
+func distanceFunc(metric DistanceMetric) (func(a, b []float64) float64, error) {
+	switch metric {
+	case "", DistanceEuclidean:
+		return func(a, b []float64) float64 {
+			s := 0.0
+			for i := range a {
+				s += (a[i] - b[i]) * (a[i] - b[i])
+			}
+			return math.Sqrt(s)
+		}, nil
+	case DistanceManhattan:
+		return func(a, b []float64) float64 {
+			s := 0.0
+			for i := range a {
+				s += math.Abs(a[i] - b[i])
+			}
+			return s
+		}, nil
+	case DistanceChebyshev:
+		return func(a, b []float64) float64 {
+			s := 0.0
+			for i := range a {
+				s = math.Max(s, math.Abs(a[i]-b[i]))
+			}
+			return s
+		}, nil
+	}
+	return nil, errors.New("unsupported distance metric")
+}
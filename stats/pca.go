@@ -17,12 +17,19 @@ type PCAResult struct {
 	Components        insyra.IDataTable // component loadings matrix
 	Eigenvalues       []float64
 	ExplainedVariance []float64
+
+	// Training standardisation and loadings, kept for Transform.
+	colNames []string
+	means    []float64
+	stds     []float64
+	loadings *mat.Dense // colNum x numComponents
 }
 
 // PCA calculates the Principal Component Analysis of a DataTable.
 func PCA(dataTable insyra.IDataTable, nComponents ...int) (*PCAResult, error) {
 	var rowNum, colNum, numComponents int
 	var data *mat.Dense
+	var colNames []string
 	// Bulk-load per column via ToF64Slice. The previous nested-loop form
 	// (`dt.GetRow(i).Get(j)` for every cell) profiled to 67% runtime.Stack —
 	// each Get goes through the DataList actor, whose getGID call walks the
@@ -32,6 +39,7 @@ func PCA(dataTable insyra.IDataTable, nComponents ...int) (*PCAResult, error) {
 	// to colNum entries (= 12 here, ≈800× fewer actor handshakes).
 	dataTable.AtomicDo(func(dt *insyra.DataTable) {
 		rowNum, colNum = dt.Size()
+		colNames = dt.ColNames()
 
 		numComponents = colNum
 		if len(nComponents) == 1 {
@@ -171,6 +179,7 @@ func PCA(dataTable insyra.IDataTable, nComponents ...int) (*PCAResult, error) {
 	evStride := evRaw.Stride
 	evRows := evRaw.Rows
 	componentCols := make([]*insyra.DataList, numComponents)
+	loadings := mat.NewDense(colNum, numComponents, nil)
 	for compIndex := range numComponents {
 		col := indices[compIndex]
 		sign := 1.0
@@ -179,7 +188,9 @@ func PCA(dataTable insyra.IDataTable, nComponents ...int) (*PCAResult, error) {
 		}
 		vals := make([]any, evRows)
 		for i := range evRows {
-			vals[i] = sign * evRaw.Data[i*evStride+col]
+			v := sign * evRaw.Data[i*evStride+col]
+			vals[i] = v
+			loadings.Set(i, compIndex, v)
 		}
 		componentCols[compIndex] = insyra.NewDataList(vals...).SetName(fmt.Sprintf("PC%d", compIndex+1))
 	}
@@ -203,5 +214,75 @@ func PCA(dataTable insyra.IDataTable, nComponents ...int) (*PCAResult, error) {
 		Components:        componentTable,
 		Eigenvalues:       sortedEigenvalues,
 		ExplainedVariance: explainedVariance,
+		colNames:          colNames,
+		means:             means,
+		stds:              stds,
+		loadings:          loadings,
 	}, nil
 }
+
+// Transform projects new observations onto the fitted components. The
+// columns of dataTable must be in the training order; they are
+// standardised with the training means and standard deviations, so
+// Transform on the training data returns the principal component scores.
+// The result has one column per component (PC1, PC2, ...) and keeps the
+// row names of dataTable.
+func (r *PCAResult) Transform(dataTable insyra.IDataTable) (insyra.IDataTable, error) {
+	if r == nil || r.loadings == nil {
+		return nil, errors.New("PCA result is not fitted")
+	}
+	data, rowNames, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	p, k := r.loadings.Dims()
+	if len(data[0]) != p {
+		return nil, fmt.Errorf("data has %d columns, PCA was fitted on %d", len(data[0]), p)
+	}
+	z := mat.NewDense(len(data), p, nil)
+	for i, row := range data {
+		for j, v := range row {
+			z.Set(i, j, (v-r.means[j])/r.stds[j])
+		}
+	}
+	var scores mat.Dense
+	scores.Mul(z, r.loadings)
+	return matrixToDataTableWithNames(&scores, "", pcaComponentNames(k), rowNames), nil
+}
+
+// InverseTransform maps component scores back to the original variables,
+// undoing the standardisation. With fewer components than variables this
+// is the rank-k reconstruction of the data.
+func (r *PCAResult) InverseTransform(scores insyra.IDataTable) (insyra.IDataTable, error) {
+	if r == nil || r.loadings == nil {
+		return nil, errors.New("PCA result is not fitted")
+	}
+	data, rowNames, err := numericMatrixFromTable(scores)
+	if err != nil {
+		return nil, err
+	}
+	p, k := r.loadings.Dims()
+	if len(data[0]) != k {
+		return nil, fmt.Errorf("scores have %d columns, PCA has %d components", len(data[0]), k)
+	}
+	s := mat.NewDense(len(data), k, nil)
+	for i, row := range data {
+		s.SetRow(i, row)
+	}
+	var x mat.Dense
+	x.Mul(s, r.loadings.T())
+	for i := range len(data) {
+		for j := range p {
+			x.Set(i, j, x.At(i, j)*r.stds[j]+r.means[j])
+		}
+	}
+	return matrixToDataTableWithNames(&x, "", r.colNames, rowNames), nil
+}
+
+func pcaComponentNames(k int) []string {
+	names := make([]string, k)
+	for i := range names {
+		names[i] = fmt.Sprintf("PC%d", i+1)
+	}
+	return names
+}
@@ -272,3 +272,70 @@ func TestPCA_Errors(t *testing.T) {
 		t.Error("expected error for non-numeric cell")
 	}
 }
+
+func TestPCATransformRoundTrip(t *testing.T) {
+	z := seededNormals(23, 120)
+	rows := make([][]float64, 40)
+	for i := range rows {
+		rows[i] = []float64{z[3*i], 2*z[3*i] + z[3*i+1], 10 + z[3*i+2]}
+	}
+	dt := dataTableFromPCARows(rows)
+	full, err := stats.PCA(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores, err := full.Transform(dt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Training scores have the eigenvalues as variances.
+	s := tableToFloatMatrix(scores.(*insyra.DataTable))
+	for j, ev := range full.Eigenvalues {
+		col := make([]float64, len(s))
+		for i := range s {
+			col[i] = s[i][j]
+		}
+		if sd := sampleStdDevOf(col); !pClose(sd*sd, ev, 1e-9) {
+			t.Errorf("PC%d variance %v, eigenvalue %v", j+1, sd*sd, ev)
+		}
+	}
+	back, err := full.InverseTransform(scores)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := tableToFloatMatrix(back.(*insyra.DataTable))
+	for i := range rows {
+		for j := range rows[i] {
+			if !pClose(b[i][j], rows[i][j], 1e-9) {
+				t.Fatalf("round trip [%d][%d] = %v, want %v", i, j, b[i][j], rows[i][j])
+			}
+		}
+	}
+
+	two, err := stats.PCA(dt, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := two.Transform(dataTableFromPCARows(rows[:5]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := proj.Size(); r != 5 || c != 2 {
+		t.Fatalf("projection is %dx%d", r, c)
+	}
+	if _, err := two.Transform(dataTableFromPCARows([][]float64{{1, 2}})); err == nil {
+		t.Errorf("expected error for mismatched column count")
+	}
+}
+
+func sampleStdDevOf(x []float64) float64 {
+	mean := 0.0
+	for _, v := range x {
+		mean += v / float64(len(x))
+	}
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(ss / float64(len(x)-1))
+}
@@ -0,0 +1,150 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+type PermutationDesign string
+
+const (
+	// PermutationTwoSample shuffles group labels between independent samples.
+	PermutationTwoSample PermutationDesign = "two-sample"
+	// PermutationPaired swaps the members of each pair at random.
+	PermutationPaired PermutationDesign = "paired"
+)
+
+type PermutationTestOptions struct {
+	// Design defaults to PermutationTwoSample.
+	Design PermutationDesign
+	// Alternative defaults to TwoSided, which compares |statistic|.
+	Alternative AlternativeHypothesis
+	// Permutations defaults to 9999.
+	Permutations int
+	Seed         uint64
+	UseSeed      bool
+}
+
+type PermutationTestResult struct {
+	testResultBase
+	Design       PermutationDesign
+	Alternative  AlternativeHypothesis
+	Permutations int
+}
+
+// PermutationTest is a Monte Carlo permutation test of statFn(x, y). A nil
+// statFn uses mean(x) - mean(y), which for the paired design is the mean
+// difference. The p-value is (1 + #{extreme}) / (1 + Permutations), so it is
+// never zero. statFn is called concurrently and must not retain or modify
+// its arguments.
+func PermutationTest(x, y insyra.IDataList, statFn func(x, y []float64) float64, opts ...PermutationTestOptions) (*PermutationTestResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt PermutationTestOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	design := opt.Design
+	if design == "" {
+		design = PermutationTwoSample
+	}
+	if design != PermutationTwoSample && design != PermutationPaired {
+		return nil, errors.New("unsupported permutation design")
+	}
+	alt := opt.Alternative
+	if alt == "" {
+		alt = TwoSided
+	}
+	if alt != TwoSided && alt != Greater && alt != Less {
+		return nil, errors.New("unsupported alternative hypothesis")
+	}
+	R := opt.Permutations
+	if R <= 0 {
+		R = 9999
+	}
+	if x == nil || y == nil {
+		return nil, errors.New("samples must not be nil")
+	}
+	xs, err := numericColumn(x.Data(), x.GetName())
+	if err != nil {
+		return nil, err
+	}
+	ys, err := numericColumn(y.Data(), y.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if len(xs) < 1 || len(ys) < 1 {
+		return nil, errors.New("both samples must be non-empty")
+	}
+	if design == PermutationPaired && len(xs) != len(ys) {
+		return nil, errors.New("paired design requires samples of equal length")
+	}
+	if statFn == nil {
+		statFn = func(a, b []float64) float64 { return sampleMean(a) - sampleMean(b) }
+	}
+	observed := statFn(xs, ys)
+	if math.IsNaN(observed) || math.IsInf(observed, 0) {
+		return nil, errors.New("statistic is not finite on the observed data")
+	}
+
+	seed := resolveSeed(opt.Seed, opt.UseSeed)
+	nx, ny := len(xs), len(ys)
+	permStats := make([]float64, R)
+	runParallelChunks(R, func(start, end int) {
+		px := make([]float64, nx)
+		py := make([]float64, ny)
+		pooled := make([]float64, nx+ny)
+		for r := start; r < end; r++ {
+			rng := replicateRNG(seed, uint64(r))
+			if design == PermutationPaired {
+				for i := range px {
+					if rng.IntN(2) == 0 {
+						px[i], py[i] = xs[i], ys[i]
+					} else {
+						px[i], py[i] = ys[i], xs[i]
+					}
+				}
+			} else {
+				copy(pooled, xs)
+				copy(pooled[nx:], ys)
+				rng.Shuffle(len(pooled), func(i, j int) { pooled[i], pooled[j] = pooled[j], pooled[i] })
+				copy(px, pooled[:nx])
+				copy(py, pooled[nx:])
+			}
+			permStats[r] = statFn(px, py)
+		}
+	})
+
+	// A small tolerance keeps permutations that reproduce the observed
+	// statistic up to rounding counted as extreme.
+	tol := 1e-12 * math.Max(1, math.Abs(observed))
+	extreme := 0
+	for _, s := range permStats {
+		switch alt {
+		case Greater:
+			if s >= observed-tol {
+				extreme++
+			}
+		case Less:
+			if s <= observed+tol {
+				extreme++
+			}
+		default:
+			if math.Abs(s) >= math.Abs(observed)-tol {
+				extreme++
+			}
+		}
+	}
+	return &PermutationTestResult{
+		testResultBase: testResultBase{
+			Statistic: observed,
+			PValue:    float64(extreme+1) / float64(R+1),
+		},
+		Design:       design,
+		Alternative:  alt,
+		Permutations: R,
+	}, nil
+}
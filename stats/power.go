@@ -0,0 +1,328 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+type PowerDesign string
+
+const (
+	PowerOneSample PowerDesign = "one-sample"
+	PowerTwoSample PowerDesign = "two-sample"
+	PowerPaired    PowerDesign = "paired"
+)
+
+// PowerResult is a solved power analysis. Exactly one of N, EffectSize and
+// Power was computed; the other two echo the inputs. N is per group for
+// t-tests, proportion tests and ANOVA and the total sample size for the
+// chi-square test. A solved N is fractional: round it up to plan a study.
+type PowerResult struct {
+	N              float64
+	EffectSize     float64
+	EffectSizeType string // "cohen_d", "cohen_h", "cohen_f" or "cohen_w"
+	Power          float64
+	SigLevel       float64
+	Alternative    AlternativeHypothesis
+}
+
+// PowerTTestOptions describes a t-test power analysis in terms of Cohen's
+// d, the effect size reported by the t-test results. Leave exactly one of
+// N, D and Power at zero to solve for it. For the paired design D is the
+// standardised mean difference of the pairs (d_z). With Alternative Less, D
+// is expected to be negative.
+type PowerTTestOptions struct {
+	// Design defaults to PowerTwoSample.
+	Design PowerDesign
+	// N is the sample size per group (pairs for the paired design).
+	N           float64
+	D           float64
+	Power       float64
+	SigLevel    float64 // default 0.05
+	Alternative AlternativeHypothesis
+}
+
+// PowerTTest solves for the power, per-group sample size or minimum
+// detectable effect of a one-sample, two-sample or paired t-test using the
+// noncentral t distribution (both rejection tails for two-sided tests, as in
+// G*Power).
+func PowerTTest(opts PowerTTestOptions) (*PowerResult, error) {
+	design := opts.Design
+	if design == "" {
+		design = PowerTwoSample
+	}
+	var dfOf, ncpScale func(n float64) float64
+	var minN float64
+	switch design {
+	case PowerOneSample, PowerPaired:
+		dfOf = func(n float64) float64 { return n - 1 }
+		ncpScale = math.Sqrt
+		minN = 2
+	case PowerTwoSample:
+		dfOf = func(n float64) float64 { return 2*n - 2 }
+		ncpScale = func(n float64) float64 { return math.Sqrt(n / 2) }
+		minN = 2
+	default:
+		return nil, errors.New("unsupported t-test power design")
+	}
+	spec, err := newPowerSpec(opts.N, opts.D, opts.Power, opts.SigLevel, opts.Alternative, minN)
+	if err != nil {
+		return nil, err
+	}
+	powerFn := func(n, d float64) float64 {
+		df := dfOf(n)
+		ncp := d * ncpScale(n)
+		switch spec.alt {
+		case Greater:
+			return 1 - noncentralTCDF(tQuantile(1-spec.alpha, df), df, ncp)
+		case Less:
+			return noncentralTCDF(tQuantile(spec.alpha, df), df, ncp)
+		default:
+			q := tQuantile(1-spec.alpha/2, df)
+			return 1 - noncentralTCDF(q, df, ncp) + noncentralTCDF(-q, df, ncp)
+		}
+	}
+	return spec.solve(powerFn, "cohen_d")
+}
+
+// PowerProportionOptions describes a proportion test power analysis with
+// Cohen's h (see CohenH), using the arcsine-transformed normal
+// approximation like R's pwr.p.test and pwr.2p.test. Leave exactly one of
+// N, H and Power at zero to solve for it.
+type PowerProportionOptions struct {
+	// Design is PowerOneSample or PowerTwoSample (default).
+	Design      PowerDesign
+	N           float64 // per group
+	H           float64
+	Power       float64
+	SigLevel    float64 // default 0.05
+	Alternative AlternativeHypothesis
+}
+
+// PowerProportionTest solves a one- or two-sample proportion test power
+// analysis.
+func PowerProportionTest(opts PowerProportionOptions) (*PowerResult, error) {
+	design := opts.Design
+	if design == "" {
+		design = PowerTwoSample
+	}
+	scale := 1.0
+	switch design {
+	case PowerOneSample:
+	case PowerTwoSample:
+		scale = 0.5
+	default:
+		return nil, errors.New("unsupported proportion power design")
+	}
+	spec, err := newPowerSpec(opts.N, opts.H, opts.Power, opts.SigLevel, opts.Alternative, 1)
+	if err != nil {
+		return nil, err
+	}
+	powerFn := func(n, h float64) float64 {
+		z := h * math.Sqrt(n*scale)
+		switch spec.alt {
+		case Greater:
+			return zCDF(z - zQuantile(1-spec.alpha))
+		case Less:
+			return zCDF(-z - zQuantile(1-spec.alpha))
+		default:
+			zc := zQuantile(1 - spec.alpha/2)
+			return zCDF(z-zc) + zCDF(-z-zc)
+		}
+	}
+	return spec.solve(powerFn, "cohen_h")
+}
+
+// PowerANOVAOptions describes a balanced one-way ANOVA power analysis with
+// Cohen's f (see CohenFFromEtaSquared for the eta squared reported by
+// OneWayANOVA). Leave exactly one of N, F and Power at zero to solve for it.
+type PowerANOVAOptions struct {
+	Groups   int
+	N        float64 // per group
+	F        float64
+	Power    float64
+	SigLevel float64 // default 0.05
+}
+
+// PowerANOVA solves a one-way ANOVA power analysis from the noncentral F
+// distribution with noncentrality k·n·f².
+func PowerANOVA(opts PowerANOVAOptions) (*PowerResult, error) {
+	if opts.Groups < 2 {
+		return nil, errors.New("groups must be at least 2")
+	}
+	k := float64(opts.Groups)
+	spec, err := newPowerSpec(opts.N, opts.F, opts.Power, opts.SigLevel, Greater, 2)
+	if err != nil {
+		return nil, err
+	}
+	powerFn := func(n, f float64) float64 {
+		df1, df2 := k-1, k*(n-1)
+		crit := distuv.F{D1: df1, D2: df2}.Quantile(1 - spec.alpha)
+		return 1 - noncentralFCDF(crit, df1, df2, k*n*f*f)
+	}
+	res, err := spec.solve(powerFn, "cohen_f")
+	if res != nil {
+		res.Alternative = ""
+	}
+	return res, err
+}
+
+// PowerChiSquareOptions describes a chi-square test power analysis with
+// Cohen's w (see CohenW). N is the total sample size. Leave exactly one of
+// N, W and Power at zero to solve for it.
+type PowerChiSquareOptions struct {
+	DF       int
+	N        float64
+	W        float64
+	Power    float64
+	SigLevel float64 // default 0.05
+}
+
+// PowerChiSquare solves a chi-square goodness-of-fit or independence test
+// power analysis from the noncentral chi-square with noncentrality N·w².
+func PowerChiSquare(opts PowerChiSquareOptions) (*PowerResult, error) {
+	if opts.DF < 1 {
+		return nil, errors.New("df must be at least 1")
+	}
+	df := float64(opts.DF)
+	spec, err := newPowerSpec(opts.N, opts.W, opts.Power, opts.SigLevel, Greater, 1)
+	if err != nil {
+		return nil, err
+	}
+	crit := distuv.ChiSquared{K: df}.Quantile(1 - spec.alpha)
+	powerFn := func(n, w float64) float64 {
+		return 1 - noncentralChiSquaredCDF(crit, df, n*w*w)
+	}
+	res, err := spec.solve(powerFn, "cohen_w")
+	if res != nil {
+		res.Alternative = ""
+	}
+	return res, err
+}
+
+// CohenH is the effect size for comparing two proportions:
+// 2·asin(√p1) − 2·asin(√p2).
+func CohenH(p1, p2 float64) float64 {
+	if p1 < 0 || p1 > 1 || p2 < 0 || p2 > 1 {
+		return math.NaN()
+	}
+	return 2*math.Asin(math.Sqrt(p1)) - 2*math.Asin(math.Sqrt(p2))
+}
+
+// CohenFFromEtaSquared converts eta squared to Cohen's f = √(η²/(1−η²)).
+func CohenFFromEtaSquared(eta2 float64) float64 {
+	if eta2 < 0 || eta2 >= 1 {
+		return math.NaN()
+	}
+	return math.Sqrt(eta2 / (1 - eta2))
+}
+
+// CohenW is the chi-square effect size √Σ(p1−p0)²/p0 between the cell
+// probabilities p0 under the null and p1 under the alternative.
+func CohenW(p0, p1 []float64) float64 {
+	if len(p0) != len(p1) || len(p0) == 0 {
+		return math.NaN()
+	}
+	s := 0.0
+	for i := range p0 {
+		if p0[i] <= 0 {
+			return math.NaN()
+		}
+		d := p1[i] - p0[i]
+		s += d * d / p0[i]
+	}
+	return math.Sqrt(s)
+}
+
+type powerSpec struct {
+	n, effect, power float64
+	alpha            float64
+	alt              AlternativeHypothesis
+	minN             float64
+}
+
+func newPowerSpec(n, effect, power, sigLevel float64, alt AlternativeHypothesis, minN float64) (*powerSpec, error) {
+	if sigLevel == 0 {
+		sigLevel = 0.05
+	}
+	if sigLevel <= 0 || sigLevel >= 1 {
+		return nil, errors.New("sig level must be in (0, 1)")
+	}
+	if alt == "" {
+		alt = TwoSided
+	}
+	if alt != TwoSided && alt != Greater && alt != Less {
+		return nil, errors.New("unsupported alternative hypothesis")
+	}
+	missing := 0
+	for _, v := range []float64{n, effect, power} {
+		if v == 0 {
+			missing++
+		}
+	}
+	if missing != 1 {
+		return nil, errors.New("exactly one of sample size, effect size and power must be zero")
+	}
+	if n != 0 && n < minN {
+		return nil, errors.New("sample size is too small")
+	}
+	if power != 0 && (power <= sigLevel || power >= 1) {
+		return nil, errors.New("power must be between the sig level and 1")
+	}
+	if effect < 0 && alt != Less {
+		return nil, errors.New("effect size must be positive unless the alternative is less")
+	}
+	if effect > 0 && alt == Less {
+		return nil, errors.New("effect size must be negative when the alternative is less")
+	}
+	return &powerSpec{n: n, effect: effect, power: power, alpha: sigLevel, alt: alt, minN: minN}, nil
+}
+
+// solve fills in whichever quantity is zero. Power increases monotonically
+// in both n and |effect|, so the unknown is bracketed by doubling and then
+// found by bisection.
+func (s *powerSpec) solve(powerFn func(n, effect float64) float64, effectType string) (*PowerResult, error) {
+	res := &PowerResult{
+		N:              s.n,
+		EffectSize:     s.effect,
+		EffectSizeType: effectType,
+		Power:          s.power,
+		SigLevel:       s.alpha,
+		Alternative:    s.alt,
+	}
+	sign := 1.0
+	if s.alt == Less {
+		sign = -1
+	}
+	switch {
+	case s.power == 0:
+		res.Power = powerFn(s.n, s.effect)
+	case s.n == 0:
+		f := func(n float64) float64 { return powerFn(n, s.effect) - s.power }
+		if f(s.minN) >= 0 {
+			res.N = s.minN
+			return res, nil
+		}
+		hi := 2 * s.minN
+		for f(hi) < 0 {
+			hi *= 2
+			if hi > 1e10 {
+				return nil, errors.New("required sample size is too large")
+			}
+		}
+		res.N = uniroot(f, hi/2, hi, 1e-9*hi)
+	default:
+		f := func(e float64) float64 { return powerFn(s.n, sign*e) - s.power }
+		hi := 1.0
+		for f(hi) < 0 {
+			hi *= 2
+			if hi > 1e6 {
+				return nil, errors.New("no detectable effect reaches the requested power")
+			}
+		}
+		res.EffectSize = sign * uniroot(f, 0, hi, 1e-10)
+	}
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+func TestPowerTTest(t *testing.T) {
+	// G*Power: two-tailed two-sample t, d = 0.5, n = 64 per group -> power 0.8014596.
+	res, err := stats.PowerTTest(stats.PowerTTestOptions{N: 64, D: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(res.Power, 0.8014596, 1e-6) || res.EffectSizeType != "cohen_d" {
+		t.Errorf("power = %v", res.Power)
+	}
+	// pwr.t.test(d = 0.5, power = 0.8) -> n = 63.76561.
+	res, err = stats.PowerTTest(stats.PowerTTestOptions{D: 0.5, Power: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(res.N, 63.76561, 1e-3) {
+		t.Errorf("n = %v", res.N)
+	}
+	// pwr.t.test(d = 0.5, power = 0.8, type = "one.sample") -> n = 33.36713.
+	res, err = stats.PowerTTest(stats.PowerTTestOptions{Design: stats.PowerOneSample, D: 0.5, Power: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(res.N, 33.36713, 1e-3) {
+		t.Errorf("one-sample n = %v", res.N)
+	}
+
+	// The minimum detectable effect inverts the power calculation.
+	mde, err := stats.PowerTTest(stats.PowerTTestOptions{Design: stats.PowerPaired, N: 30, Power: 0.9, Alternative: stats.Less})
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := stats.PowerTTest(stats.PowerTTestOptions{Design: stats.PowerPaired, N: 30, D: mde.EffectSize, Alternative: stats.Less})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mde.EffectSize >= 0 || !floatAlmostEqual(back.Power, 0.9, 1e-8) {
+		t.Errorf("MDE = %v, power back = %v", mde.EffectSize, back.Power)
+	}
+
+	if _, err := stats.PowerTTest(stats.PowerTTestOptions{N: 20, D: 0.5, Power: 0.8}); err == nil {
+		t.Error("expected error when nothing is left to solve")
+	}
+}
+
+func TestPowerProportionANOVAChiSquare(t *testing.T) {
+	// pwr.2p.test(h = 0.3, power = 0.8) -> n = 174.4195.
+	prop, err := stats.PowerProportionTest(stats.PowerProportionOptions{H: 0.3, Power: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(prop.N, 174.4195, 1e-3) {
+		t.Errorf("proportion n = %v", prop.N)
+	}
+	if h := stats.CohenH(0.65, 0.45); !floatAlmostEqual(h, 2*math.Asin(math.Sqrt(0.65))-2*math.Asin(math.Sqrt(0.45)), 1e-15) {
+		t.Errorf("CohenH = %v", h)
+	}
+
+	// pwr.anova.test(k = 4, f = 0.25, power = 0.8) -> n = 44.59927.
+	anova, err := stats.PowerANOVA(stats.PowerANOVAOptions{Groups: 4, F: 0.25, Power: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(anova.N, 44.59927, 1e-3) {
+		t.Errorf("ANOVA n = %v", anova.N)
+	}
+	if f := stats.CohenFFromEtaSquared(0.0588235294117647); !floatAlmostEqual(f, 0.25, 1e-12) {
+		t.Errorf("Cohen's f = %v", f)
+	}
+
+	// pwr.chisq.test(w = 0.3, df = 1, power = 0.8) -> N = 87.20994.
+	chi, err := stats.PowerChiSquare(stats.PowerChiSquareOptions{DF: 1, W: 0.3, Power: 0.8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(chi.N, 87.20994, 1e-3) {
+		t.Errorf("chi-square N = %v", chi.N)
+	}
+	// With df = 1 the noncentral chi-square reduces to a folded normal.
+	direct, err := stats.PowerChiSquare(stats.PowerChiSquareOptions{DF: 1, W: 0.3, N: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shift := math.Sqrt(50 * 0.09)
+	crit := 1.959963984540054
+	want := 1 - (normCDF(crit-shift) - normCDF(-crit-shift))
+	if !floatAlmostEqual(direct.Power, want, 1e-9) {
+		t.Errorf("chi-square power = %v, want %v", direct.Power, want)
+	}
+	if w := stats.CohenW([]float64{0.25, 0.25, 0.25, 0.25}, []float64{0.4, 0.2, 0.2, 0.2}); !floatAlmostEqual(w, math.Sqrt(0.12), 1e-12) {
+		t.Errorf("CohenW = %v", w)
+	}
+}
+
+func normCDF(x float64) float64 { return 0.5 * math.Erfc(-x/math.Sqrt2) }
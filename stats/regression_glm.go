@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/HazelnutParadise/insyra"
 )
@@ -16,6 +17,11 @@ type GLMOptions struct {
 	Tolerance       float64
 	Offset          insyra.IDataList
 	Weights         insyra.IDataList
+	// Theta fixes the NegativeBinomial shape; 0 estimates it by maximum
+	// likelihood.
+	Theta float64
+	// TweediePower is the Tweedie variance power, in (1, 2).
+	TweediePower float64
 }
 
 type GLMResult struct {
@@ -43,16 +49,21 @@ type GLMResult struct {
 	Iterations          int
 	Converged           bool
 	ConfidenceLevel     float64
-	family              glmFamily
-	link                glmLink
-	hasOffset           bool
+	// Theta is the NegativeBinomial shape and ThetaSE its standard error,
+	// which is 0 when Theta was fixed. Both are 0 for other families.
+	Theta        float64
+	ThetaSE      float64
+	TweediePower float64
+	family       glmFamily
+	link         glmLink
+	hasOffset    bool
 }
 
 func GLM(opts GLMOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*GLMResult, error) {
 	if len(dlXs) == 0 {
 		return nil, errors.New("no independent variables provided")
 	}
-	fam, err := resolveGLMFamily(opts.Family)
+	fam, err := resolveGLMFamily(opts.Family, opts.Theta, opts.TweediePower)
 	if err != nil {
 		return nil, err
 	}
@@ -96,11 +107,20 @@ func GLM(opts GLMOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*GLMR
 		offset:    offset,
 		weights:   priorWeights,
 	}
-	fit, err := fitIRLS(X, y, fam, link, fitOpts)
+	weights := priorWeightsOrOnes(priorWeights, n)
+	var fit *irlsFit
+	var thetaSE float64
+	estimateTheta := false
+	if nb, ok := fam.(negativeBinomialFamily); ok && nb.theta == 0 {
+		estimateTheta = true
+		fit, nb.theta, thetaSE, err = fitNegativeBinomial(X, y, link, fitOpts, weights)
+		fam = nb
+	} else {
+		fit, err = fitIRLS(X, y, fam, link, fitOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
-	weights := priorWeightsOrOnes(priorWeights, n)
 	nullFit, err := fitNullIRLSWithOptions(y, fam, link, offset, weights, fitOpts)
 	if err != nil {
 		return nil, err
@@ -112,7 +132,7 @@ func GLM(opts GLMOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*GLMR
 		dispersion = pearsonDispersion(pearson, dfResidual)
 	}
 	se, z, p := computeGLMInference(fit.beta, fit.covUnscaled, dispersion)
-	if fam.name() == string(Gaussian) {
+	if !fixed {
 		for i := range z {
 			if !math.IsNaN(z[i]) && dfResidual > 0 {
 				p[i] = tTwoTailedPValue(z[i], float64(dfResidual))
@@ -125,11 +145,22 @@ func GLM(opts GLMOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*GLMR
 	if fam.name() == string(Gaussian) && n > 0 {
 		logLikDispersion = fit.deviance / float64(n)
 	}
+	if _, ok := fam.(glmDispersedFamily); ok {
+		sumW := 0.0
+		for _, w := range weights {
+			sumW += w
+		}
+		logLikDispersion = fit.deviance / sumW
+	}
 	logLik := glmLogLik(y, fit.mu, weights, fam, logLikDispersion)
 	nullLogLik := glmLogLik(y, nullFit.mu, weights, fam, logLikDispersion)
+	var theta float64
+	if nb, ok := fam.(negativeBinomialFamily); ok {
+		theta = nb.theta
+	}
 	k := len(fit.beta)
 	aicK := k
-	if fam.name() == string(Gaussian) {
+	if _, ok := fam.(glmDispersedFamily); ok || fam.name() == string(Gaussian) || estimateTheta {
 		aicK++
 	}
 
@@ -158,13 +189,18 @@ func GLM(opts GLMOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*GLMR
 		Iterations:          fit.iterations,
 		Converged:           fit.converged,
 		ConfidenceLevel:     cl,
+		Theta:               theta,
+		ThetaSE:             thetaSE,
+		TweediePower:        opts.TweediePower,
 		family:              fam,
 		link:                link,
 		hasOffset:           offsetIdx >= 0,
 	}, nil
 }
 
-func resolveGLMFamily(family GLMFamily) (glmFamily, error) {
+// resolveGLMFamily builds the family. A NegativeBinomial theta of 0 is
+// returned as is and tells GLM to estimate it.
+func resolveGLMFamily(family GLMFamily, theta, tweediePower float64) (glmFamily, error) {
 	switch family {
 	case Binomial:
 		return binomialFamily{}, nil
@@ -172,37 +208,63 @@ func resolveGLMFamily(family GLMFamily) (glmFamily, error) {
 		return poissonFamily{}, nil
 	case Gaussian:
 		return gaussianFamily{}, nil
+	case Gamma:
+		return gammaFamily{}, nil
+	case InverseGaussian:
+		return inverseGaussianFamily{}, nil
+	case NegativeBinomial:
+		if theta < 0 || math.IsNaN(theta) || math.IsInf(theta, 0) {
+			return nil, errors.New("negative binomial theta must be positive and finite")
+		}
+		return negativeBinomialFamily{theta: theta}, nil
+	case Tweedie:
+		if !(tweediePower > 1 && tweediePower < 2) {
+			return nil, fmt.Errorf("tweedie power must be in (1, 2), got %v; use Poisson, Gamma or InverseGaussian for 1, 2 or 3", tweediePower)
+		}
+		return tweedieFamily{power: tweediePower}, nil
 	default:
 		return nil, fmt.Errorf("unsupported GLM family %q", family)
 	}
 }
 
+// glmFamilyLinks lists the links each family accepts, following the
+// choices R offers for the positive-response families.
+var glmFamilyLinks = map[string][]GLMLink{
+	string(Binomial):         {Logit},
+	string(Poisson):          {Log},
+	string(Gaussian):         {Identity},
+	string(Gamma):            {Inverse, Log, Identity},
+	string(InverseGaussian):  {InverseSquared, Inverse, Log, Identity},
+	string(NegativeBinomial): {Log, Identity},
+	string(Tweedie):          {Log, Identity, Inverse},
+}
+
 func resolveGLMLink(fam glmFamily, linkName GLMLink) (glmLink, GLMLink, error) {
 	if linkName == "" {
 		link := fam.canonicalLink()
 		return link, GLMLink(link.name()), nil
 	}
+	var link glmLink
 	switch linkName {
 	case Logit:
-		if fam.name() != string(Binomial) {
-			return nil, "", fmt.Errorf("link %q is not supported for family %q", linkName, fam.name())
-		}
-		return logitLink{}, linkName, nil
+		link = logitLink{}
 	case Log:
-		if fam.name() != string(Poisson) {
-			return nil, "", fmt.Errorf("link %q is not supported for family %q", linkName, fam.name())
-		}
-		return logLink{}, linkName, nil
+		link = logLink{}
 	case Identity:
-		if fam.name() != string(Gaussian) {
-			return nil, "", fmt.Errorf("link %q is not supported for family %q", linkName, fam.name())
-		}
-		return identityLink{}, linkName, nil
+		link = identityLink{}
+	case Inverse:
+		link = inverseLink{}
+	case InverseSquared:
+		link = inverseSquaredLink{}
 	case Probit, Cloglog:
 		return nil, "", fmt.Errorf("link %q is reserved but not implemented", linkName)
 	default:
 		return nil, "", fmt.Errorf("unsupported GLM link %q", linkName)
 	}
+	if !slices.Contains(glmFamilyLinks[fam.name()], linkName) {
+		return nil, "", fmt.Errorf("link %q is not supported for family %q", linkName, fam.name())
+	}
+	return link, linkName, nil
 }
 
 func validateGLMResponse(y []float64, fam glmFamily) error {
@@ -215,9 +277,13 @@ func validateGLMResponse(y []float64, fam glmFamily) error {
 			if v < 0 || v > 1 {
 				return fmt.Errorf("binomial response must be in [0,1] (index %d)", i)
 			}
-		case string(Poisson):
+		case string(Poisson), string(NegativeBinomial), string(Tweedie):
 			if v < 0 {
-				return fmt.Errorf("poisson response must be non-negative (index %d)", i)
+				return fmt.Errorf("%s response must be non-negative (index %d)", fam.name(), i)
+			}
+		case string(Gamma), string(InverseGaussian):
+			if v <= 0 {
+				return fmt.Errorf("%s response must be positive (index %d)", fam.name(), i)
 			}
 		}
 	}
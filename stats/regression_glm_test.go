@@ -82,3 +82,184 @@ func TestGLMPredict(t *testing.T) {
 		t.Fatalf("expected class prediction error for poisson")
 	}
 }
+
+// Clotting times from McCullagh & Nelder (1989, §8.4.2), the example in R's
+// ?glm: glm(lot1 ~ log(u), family = Gamma) prints coefficients -0.01655438
+// and 0.01534311, residual deviance 0.01673, dispersion 0.002446059 and AIC
+// 37.99. R's dispersion uses the working weights of its last IRLS step, so
+// the fully converged Pearson estimate agrees only to about 1e-5 relative.
+func TestGLMGammaClotting(t *testing.T) {
+	u := []float64{5, 10, 15, 20, 30, 40, 60, 80, 100}
+	logU := insyra.NewDataList()
+	for _, v := range u {
+		logU.Append(math.Log(v))
+	}
+	lot1 := insyra.NewDataList(118, 58, 42, 35, 27, 25, 21, 19, 18)
+	fit, err := GLM(GLMOptions{Family: Gamma}, lot1, logU)
+	if err != nil {
+		t.Fatalf("GLM error: %v", err)
+	}
+	if fit.Link != Inverse {
+		t.Fatalf("default link = %q", fit.Link)
+	}
+	want := []float64{-0.01655438, 0.01534311}
+	for i := range want {
+		if math.Abs(fit.Coefficients[i]-want[i]) > 5e-9 {
+			t.Errorf("coef[%d] = %.10g, want %.10g", i, fit.Coefficients[i], want[i])
+		}
+	}
+	if math.Abs(fit.Deviance-0.01673) > 5e-6 {
+		t.Errorf("deviance = %.8g", fit.Deviance)
+	}
+	if math.Abs(fit.Dispersion-0.002446059) > 5e-8 {
+		t.Errorf("dispersion = %.10g", fit.Dispersion)
+	}
+	if math.Abs(fit.AIC-37.99) > 5e-3 {
+		t.Errorf("AIC = %.6g", fit.AIC)
+	}
+}
+
+// With a single two-level regressor every family fits the group means, so
+// coefficients, deviance and AIC have closed forms.
+func TestGLMPositiveFamiliesFitGroupMeans(t *testing.T) {
+	yVals := []float64{1.2, 3.4, 2.2, 0.8, 2.9, 4.1, 6.5, 3.3, 5.0, 7.2}
+	g := insyra.NewDataList(0, 0, 0, 0, 0, 1, 1, 1, 1, 1)
+	var means [2]float64
+	for i, v := range yVals {
+		means[i/5] += v / 5
+	}
+	groupMean := func(i int) float64 { return means[i/5] }
+
+	cases := []struct {
+		opts GLMOptions
+		fam  glmFamily
+		eta  func(float64) float64
+	}{
+		{GLMOptions{Family: Gamma}, gammaFamily{}, func(m float64) float64 { return 1 / m }},
+		{GLMOptions{Family: Gamma, Link: Log}, gammaFamily{}, math.Log},
+		{GLMOptions{Family: InverseGaussian}, inverseGaussianFamily{}, func(m float64) float64 { return 1 / (m * m) }},
+		{GLMOptions{Family: NegativeBinomial, Theta: 2.5}, negativeBinomialFamily{theta: 2.5}, math.Log},
+		{GLMOptions{Family: Tweedie, TweediePower: 1.5}, tweedieFamily{power: 1.5}, math.Log},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.opts.Family)+"/"+string(tc.opts.Link), func(t *testing.T) {
+			tc.opts.Tolerance = 1e-12
+			tc.opts.MaxIter = 100
+			fit, err := GLM(tc.opts, insyra.NewDataList(yVals), g)
+			if err != nil {
+				t.Fatalf("GLM error: %v", err)
+			}
+			wantCoef := []float64{tc.eta(means[0]), tc.eta(means[1]) - tc.eta(means[0])}
+			for i := range wantCoef {
+				if math.Abs(fit.Coefficients[i]-wantCoef[i]) > 1e-7*math.Max(1, math.Abs(wantCoef[i])) {
+					t.Errorf("coef[%d] = %.12g, want %.12g", i, fit.Coefficients[i], wantCoef[i])
+				}
+			}
+			dev, pearson, sumW := 0.0, 0.0, 0.0
+			for i, v := range yVals {
+				m := groupMean(i)
+				dev += tc.fam.devianceResidualSq(v, m, 1)
+				pearson += (v - m) * (v - m) / tc.fam.variance(m)
+				sumW++
+			}
+			if math.Abs(fit.Deviance-dev) > 1e-8 {
+				t.Errorf("deviance = %.12g, want %.12g", fit.Deviance, dev)
+			}
+			if phi, fixed := tc.fam.dispersionFixed(); fixed {
+				if fit.Dispersion != phi {
+					t.Errorf("dispersion = %v, want fixed %v", fit.Dispersion, phi)
+				}
+			} else if math.Abs(fit.Dispersion-pearson/8) > 1e-8 {
+				t.Errorf("dispersion = %.12g, want %.12g", fit.Dispersion, pearson/8)
+			}
+			if df, ok := tc.fam.(glmDispersedFamily); ok {
+				ll := 0.0
+				for i, v := range yVals {
+					ll += df.logLikDispersion(v, groupMean(i), 1, dev/sumW)
+				}
+				if math.Abs(fit.AIC-(-2*ll+6)) > 1e-7 {
+					t.Errorf("AIC = %.12g, want %.12g", fit.AIC, -2*ll+6)
+				}
+			}
+		})
+	}
+}
+
+func TestGLMNegativeBinomialThetaMLE(t *testing.T) {
+	counts := []float64{0, 9, 1, 17, 0, 3, 30, 1, 0, 5, 2, 14, 40, 0, 6, 22, 1, 0, 11, 3}
+	y := insyra.NewDataList(counts)
+	x := insyra.NewDataList(0.1, 0.3, 0.2, 0.9, 0.4, 0.5, 1.3, 0.2, 0.6, 0.8, 1.1, 0.3, 1.6, 0.7, 0.1, 1.8, 1.0, 0.4, 1.4, 0.6)
+	fit, err := GLM(GLMOptions{Family: NegativeBinomial, Tolerance: 1e-12, MaxIter: 100}, y, x)
+	if err != nil {
+		t.Fatalf("GLM error: %v", err)
+	}
+	if fit.Theta <= 0 || fit.ThetaSE <= 0 {
+		t.Fatalf("theta = %v, se = %v", fit.Theta, fit.ThetaSE)
+	}
+	// θ̂ maximises the profile likelihood: refits at a fixed nearby theta
+	// are worse.
+	for _, f := range []float64{0.9, 1.1} {
+		other, err := GLM(GLMOptions{Family: NegativeBinomial, Theta: fit.Theta * f, Tolerance: 1e-12, MaxIter: 100}, y, x)
+		if err != nil {
+			t.Fatalf("GLM error: %v", err)
+		}
+		if other.LogLikelihood >= fit.LogLikelihood {
+			t.Errorf("logLik at %.3g·θ̂ = %.10g exceeds %.10g", f, other.LogLikelihood, fit.LogLikelihood)
+		}
+		if other.ThetaSE != 0 || other.AIC != -2*other.LogLikelihood+4 {
+			t.Errorf("fixed theta: se %v, AIC %v", other.ThetaSE, other.AIC)
+		}
+	}
+	score, _ := negBinThetaScore(counts, fit.FittedValues, priorWeightsOrOnes(nil, len(counts)), fit.Theta)
+	if math.Abs(score) > 1e-6 {
+		t.Errorf("theta score = %v", score)
+	}
+	if math.Abs(fit.AIC-(-2*fit.LogLikelihood+6)) > 1e-9 {
+		t.Errorf("AIC = %v should count theta", fit.AIC)
+	}
+	// Overdispersed counts shrink the z statistics relative to Poisson.
+	pois, err := GLM(GLMOptions{Family: Poisson}, y, x)
+	if err != nil {
+		t.Fatalf("GLM error: %v", err)
+	}
+	if fit.StandardErrors[1] <= pois.StandardErrors[1] {
+		t.Errorf("NB se %v should exceed Poisson se %v", fit.StandardErrors[1], pois.StandardErrors[1])
+	}
+}
+
+func TestGLMFamilyValidation(t *testing.T) {
+	x := insyra.NewDataList(1, 2, 3, 4, 5)
+	pos := insyra.NewDataList(1.5, 2.0, 2.8, 3.1, 4.4)
+	withZero := insyra.NewDataList(0, 2.0, 2.8, 3.1, 4.4)
+	bad := []struct {
+		opts GLMOptions
+		y    insyra.IDataList
+	}{
+		{GLMOptions{Family: Gamma}, withZero},
+		{GLMOptions{Family: InverseGaussian}, withZero},
+		{GLMOptions{Family: Gamma, Link: Logit}, pos},
+		{GLMOptions{Family: Poisson, Link: Inverse}, pos},
+		{GLMOptions{Family: Tweedie}, pos},
+		{GLMOptions{Family: Tweedie, TweediePower: 2}, pos},
+		{GLMOptions{Family: NegativeBinomial, Theta: -1}, pos},
+	}
+	for _, tc := range bad {
+		if _, err := GLM(tc.opts, tc.y, x); err == nil {
+			t.Errorf("expected error for %+v", tc.opts)
+		}
+	}
+	tw, err := GLM(GLMOptions{Family: Tweedie, TweediePower: 1.4}, withZero, x)
+	if err != nil {
+		t.Fatalf("GLM error: %v", err)
+	}
+	if !math.IsNaN(tw.LogLikelihood) || !math.IsNaN(tw.AIC) || tw.Link != Log {
+		t.Errorf("tweedie logLik %v, AIC %v, link %q", tw.LogLikelihood, tw.AIC, tw.Link)
+	}
+	pred, err := tw.Predict(PredictResponse, insyra.NewDataList(6))
+	if err != nil {
+		t.Fatalf("Predict error: %v", err)
+	}
+	if got, want := pred.Get(0).(float64), math.Exp(tw.Coefficients[0]+6*tw.Coefficients[1]); math.Abs(got-want) > 1e-12 {
+		t.Errorf("prediction = %v, want %v", got, want)
+	}
+}
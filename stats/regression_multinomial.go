@@ -0,0 +1,371 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type MultinomialLogisticOptions struct {
+	ConfidenceLevel float64
+	MaxIter         int
+	Tolerance       float64
+	// Reference is the baseline class. It defaults to the first class in
+	// sorted order, like the first factor level in R's nnet::multinom.
+	Reference any
+}
+
+// MultinomialLogisticResult holds a baseline-category logit model. Classes
+// lists the reference class first; row k of the coefficient matrices is the
+// log-odds of Classes[k+1] against Reference, with the intercept in
+// column 0. OddsRatios are the exponentiated coefficients (relative risk
+// ratios).
+type MultinomialLogisticResult struct {
+	Classes             []any
+	Reference           any
+	TermNames           []string
+	Coefficients        [][]float64
+	StandardErrors      [][]float64
+	ZValues             [][]float64
+	PValues             [][]float64
+	ConfidenceIntervals [][][2]float64
+	OddsRatios          [][]float64
+	OddsRatioCIs        [][][2]float64
+	// FittedProbabilities has one column per class, named after it.
+	FittedProbabilities *insyra.DataTable
+	LogLikelihood       float64
+	NullLogLikelihood   float64
+	Deviance            float64
+	LikelihoodRatio     float64
+	LikelihoodRatioP    float64
+	AIC                 float64
+	BIC                 float64
+	McFaddenR2          float64
+	N                   int
+	Iterations          int
+	Converged           bool
+	ConfidenceLevel     float64
+}
+
+// MultinomialLogisticRegression fits a multinomial (softmax) logistic
+// regression by Newton-Raphson. The response may hold any class labels.
+func MultinomialLogisticRegression(opts MultinomialLogisticOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*MultinomialLogisticResult, error) {
+	xs, names, rawY, err := classResponseInputs(dlY, dlXs)
+	if err != nil {
+		return nil, err
+	}
+	classes := sortedClasses(rawY)
+	if len(classes) < 2 {
+		return nil, errors.New("multinomial logistic regression requires at least two response classes")
+	}
+	if opts.Reference != nil {
+		idx := -1
+		for i, c := range classes {
+			if classEqual(c, opts.Reference) {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("reference class %v is not present in response", opts.Reference)
+		}
+		classes = append([]any{classes[idx]}, append(classes[:idx:idx], classes[idx+1:]...)...)
+	}
+	y := encodeClasses(rawY, classes)
+	n, p, K := len(y), len(xs)+1, len(classes)
+	if n <= (K-1)*p {
+		return nil, errors.New("not enough observations for the number of parameters")
+	}
+	X := buildDesignMatrix(xs, n)
+
+	counts := make([]float64, K)
+	for _, c := range y {
+		counts[c]++
+	}
+	start := make([]float64, (K-1)*p)
+	for k := 1; k < K; k++ {
+		start[(k-1)*p] = math.Log(counts[k] / counts[0])
+	}
+	obj := func(beta []float64) (float64, []float64, *mat.Dense, error) {
+		return multinomialLogLik(X, y, K, beta)
+	}
+	fit, err := fitNewton(start, obj, irlsOptions{maxIter: opts.MaxIter, tolerance: opts.Tolerance})
+	if err != nil {
+		return nil, err
+	}
+
+	cl := resolveConfidenceLevel(opts.ConfidenceLevel)
+	se, z, pv := computeGLMInference(fit.beta, fit.covUnscaled, 1)
+	cis := buildGLMCoeffCIs(fit.beta, se, cl)
+	res := &MultinomialLogisticResult{
+		Classes:         classes,
+		Reference:       classes[0],
+		TermNames:       append([]string{"(Intercept)"}, names...),
+		N:               n,
+		Iterations:      fit.iterations,
+		Converged:       fit.converged,
+		ConfidenceLevel: cl,
+	}
+	for k := range K - 1 {
+		blk := fit.beta[k*p : (k+1)*p]
+		res.Coefficients = append(res.Coefficients, append([]float64(nil), blk...))
+		res.StandardErrors = append(res.StandardErrors, se[k*p:(k+1)*p])
+		res.ZValues = append(res.ZValues, z[k*p:(k+1)*p])
+		res.PValues = append(res.PValues, pv[k*p:(k+1)*p])
+		res.ConfidenceIntervals = append(res.ConfidenceIntervals, cis[k*p:(k+1)*p])
+		res.OddsRatios = append(res.OddsRatios, expSlice(blk))
+		res.OddsRatioCIs = append(res.OddsRatioCIs, expCIs(cis[k*p:(k+1)*p]))
+	}
+	res.FittedProbabilities = classProbabilityTable(classes, multinomialProbabilities(X, res.Coefficients))
+
+	res.LogLikelihood = -fit.deviance / 2
+	res.NullLogLikelihood = nullClassLogLik(counts)
+	res.Deviance = fit.deviance
+	res.LikelihoodRatio = 2 * (res.LogLikelihood - res.NullLogLikelihood)
+	res.LikelihoodRatioP = chiSquaredPValue(res.LikelihoodRatio, float64((K-1)*(p-1)))
+	res.AIC = glmAIC(res.LogLikelihood, len(fit.beta))
+	res.BIC = glmBIC(res.LogLikelihood, len(fit.beta), n)
+	res.McFaddenR2 = mcFaddenR2(res.LogLikelihood, res.NullLogLikelihood)
+	return res, nil
+}
+
+// CoefficientTable returns one row per class and term with columns Class,
+// Term, Estimate, StdError, Z, P, Lower, Upper and OddsRatio.
+func (r *MultinomialLogisticResult) CoefficientTable() *insyra.DataTable {
+	cols := coefficientTableColumns("Class")
+	for k := range r.Coefficients {
+		for j, term := range r.TermNames {
+			appendCoefficientRow(cols, r.Classes[k+1], term, r.Coefficients[k][j], r.StandardErrors[k][j],
+				r.ZValues[k][j], r.PValues[k][j], r.ConfidenceIntervals[k][j])
+		}
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+// PredictProbabilities returns the predicted class probabilities for new
+// predictor values, one column per class.
+func (r *MultinomialLogisticResult) PredictProbabilities(newXs ...insyra.IDataList) (*insyra.DataTable, error) {
+	probs, err := r.predict(newXs)
+	if err != nil {
+		return nil, err
+	}
+	return classProbabilityTable(r.Classes, probs), nil
+}
+
+// PredictClass returns the most probable class for each new row.
+func (r *MultinomialLogisticResult) PredictClass(newXs ...insyra.IDataList) (*insyra.DataList, error) {
+	probs, err := r.predict(newXs)
+	if err != nil {
+		return nil, err
+	}
+	return mostProbableClass(r.Classes, probs), nil
+}
+
+func (r *MultinomialLogisticResult) predict(newXs []insyra.IDataList) ([][]float64, error) {
+	if r == nil {
+		return nil, errors.New("multinomial logistic result is nil")
+	}
+	if len(newXs) != len(r.TermNames)-1 {
+		return nil, fmt.Errorf("expected %d predictors, got %d", len(r.TermNames)-1, len(newXs))
+	}
+	xs, n, err := gatherPredictorInputs(newXs)
+	if err != nil {
+		return nil, err
+	}
+	return multinomialProbabilities(buildDesignMatrix(xs, n), r.Coefficients), nil
+}
+
+// multinomialLogLik returns the log-likelihood, score and information of
+// the baseline-category logit model. beta stacks the K-1 coefficient
+// vectors.
+func multinomialLogLik(X *mat.Dense, y []int, K int, beta []float64) (float64, []float64, *mat.Dense, error) {
+	n, p := X.Dims()
+	m := (K - 1) * p
+	coef := make([][]float64, K-1)
+	for k := range coef {
+		coef[k] = beta[k*p : (k+1)*p]
+	}
+	probs := multinomialProbabilities(X, coef)
+	ll := 0.0
+	score := make([]float64, m)
+	info := mat.NewDense(m, m, nil)
+	for i := range n {
+		pr := probs[i]
+		ll += math.Log(math.Max(pr[y[i]], 1e-300))
+		x := X.RawRowView(i)
+		for k := 1; k < K; k++ {
+			r := 0.0
+			if y[i] == k {
+				r = 1
+			}
+			r -= pr[k]
+			for a := range p {
+				score[(k-1)*p+a] += r * x[a]
+			}
+			for l := 1; l < K; l++ {
+				w := -pr[k] * pr[l]
+				if k == l {
+					w += pr[k]
+				}
+				for a := range p {
+					for b := range p {
+						info.Set((k-1)*p+a, (l-1)*p+b, info.At((k-1)*p+a, (l-1)*p+b)+w*x[a]*x[b])
+					}
+				}
+			}
+		}
+	}
+	return ll, score, info, nil
+}
+
+// multinomialProbabilities applies the softmax with the reference class's
+// linear predictor fixed at zero.
+func multinomialProbabilities(X *mat.Dense, coef [][]float64) [][]float64 {
+	n, p := X.Dims()
+	out := make([][]float64, n)
+	for i := range n {
+		x := X.RawRowView(i)
+		eta := make([]float64, len(coef)+1)
+		maxEta := 0.0
+		for k, c := range coef {
+			for a := range p {
+				eta[k+1] += x[a] * c[a]
+			}
+			maxEta = math.Max(maxEta, eta[k+1])
+		}
+		sum := 0.0
+		for k := range eta {
+			eta[k] = math.Exp(eta[k] - maxEta)
+			sum += eta[k]
+		}
+		for k := range eta {
+			eta[k] /= sum
+		}
+		out[i] = eta
+	}
+	return out
+}
+
+// classResponseInputs reads a categorical response and numeric predictors,
+// naming predictors after their data lists.
+func classResponseInputs(dlY insyra.IDataList, dlXs []insyra.IDataList) ([][]float64, []string, []any, error) {
+	if len(dlXs) == 0 {
+		return nil, nil, nil, errors.New("no independent variables provided")
+	}
+	if dlY == nil {
+		return nil, nil, nil, errors.New("y data list is nil")
+	}
+	xs, n, err := gatherPredictorInputs(dlXs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var rawY []any
+	dlY.AtomicDo(func(l *insyra.DataList) {
+		rawY = l.Data()
+	})
+	if len(rawY) != n {
+		return nil, nil, nil, errors.New("x and y must have the same length")
+	}
+	for i, v := range rawY {
+		if v == nil {
+			return nil, nil, nil, fmt.Errorf("response is missing at index %d", i)
+		}
+	}
+	names := make([]string, len(dlXs))
+	for j, dl := range dlXs {
+		names[j] = dl.GetName()
+		if names[j] == "" {
+			names[j] = fmt.Sprintf("x%d", j+1)
+		}
+	}
+	return xs, names, rawY, nil
+}
+
+func sortedClasses(raw []any) []any {
+	unique := make(map[string]any)
+	for _, v := range raw {
+		unique[classKey(v)] = v
+	}
+	classes := make([]any, 0, len(unique))
+	for _, v := range unique {
+		classes = append(classes, v)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classSortKey(classes[i]) < classSortKey(classes[j])
+	})
+	return classes
+}
+
+// encodeClasses maps each response to its index in classes, or -1.
+func encodeClasses(raw []any, classes []any) []int {
+	index := make(map[string]int, len(classes))
+	for i, c := range classes {
+		index[classKey(c)] = i
+	}
+	out := make([]int, len(raw))
+	for i, v := range raw {
+		c, ok := index[classKey(v)]
+		if !ok {
+			c = -1
+		}
+		out[i] = c
+	}
+	return out
+}
+
+func nullClassLogLik(counts []float64) float64 {
+	n := 0.0
+	for _, c := range counts {
+		n += c
+	}
+	ll := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			ll += c * math.Log(c/n)
+		}
+	}
+	return ll
+}
+
+func classProbabilityTable(classes []any, probs [][]float64) *insyra.DataTable {
+	cols := make([]*insyra.DataList, len(classes))
+	for k, c := range classes {
+		col := make([]any, len(probs))
+		for i := range probs {
+			col[i] = probs[i][k]
+		}
+		cols[k] = insyra.NewDataList(col).SetName(fmt.Sprint(c))
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+func mostProbableClass(classes []any, probs [][]float64) *insyra.DataList {
+	out := make([]any, len(probs))
+	for i, pr := range probs {
+		best := 0
+		for k := range pr {
+			if pr[k] > pr[best] {
+				best = k
+			}
+		}
+		out[i] = classes[best]
+	}
+	return insyra.NewDataList(out)
+}
+
+func coefficientTableColumns(group string) []*insyra.DataList {
+	cols := []*insyra.DataList{insyra.NewDataList().SetName(group), insyra.NewDataList().SetName("Term")}
+	for _, name := range []string{"Estimate", "StdError", "Z", "P", "Lower", "Upper", "OddsRatio"} {
+		cols = append(cols, insyra.NewDataList().SetName(name))
+	}
+	return cols
+}
+
+func appendCoefficientRow(cols []*insyra.DataList, group any, term string, est, se, z, p float64, ci [2]float64) {
+	for i, v := range []any{group, term, est, se, z, p, ci[0], ci[1], math.Exp(est)} {
+		cols[i].Append(v)
+	}
+}
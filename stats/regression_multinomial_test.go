@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// groupedClasses expands class counts for a binary predictor: counts[g][k]
+// rows with x = g and class labels[k].
+func groupedClasses(labels []any, counts [][]int) (*insyra.DataList, *insyra.DataList) {
+	x, y := expandCounts(counts, []any{0.0, 1.0}, labels)
+	return y, x.SetName("g")
+}
+
+// With one binary predictor the multinomial model is saturated, so the
+// coefficients are empirical log-odds and the standard errors are the
+// familiar sums of reciprocal counts.
+func TestMultinomialLogisticSaturated(t *testing.T) {
+	labels := []any{"bus", "car", "walk"}
+	counts := [][]int{{12, 20, 8}, {15, 9, 16}}
+	y, x := groupedClasses(labels, counts)
+	res, err := MultinomialLogisticRegression(MultinomialLogisticOptions{Tolerance: 1e-12, MaxIter: 100}, y, x)
+	if err != nil {
+		t.Fatalf("MultinomialLogisticRegression error: %v", err)
+	}
+	if res.Reference != "bus" || res.Classes[1] != "car" || res.TermNames[1] != "g" {
+		t.Fatalf("classes %v, terms %v", res.Classes, res.TermNames)
+	}
+	c := func(g, k int) float64 { return float64(counts[g][k]) }
+	for k := 1; k < 3; k++ {
+		b0 := math.Log(c(0, k) / c(0, 0))
+		b1 := math.Log(c(1, k)/c(1, 0)) - b0
+		se0 := math.Sqrt(1/c(0, k) + 1/c(0, 0))
+		se1 := math.Sqrt(1/c(0, k) + 1/c(0, 0) + 1/c(1, k) + 1/c(1, 0))
+		got := res.Coefficients[k-1]
+		if math.Abs(got[0]-b0) > 1e-8 || math.Abs(got[1]-b1) > 1e-8 {
+			t.Errorf("class %v coefficients %v, want [%v %v]", labels[k], got, b0, b1)
+		}
+		se := res.StandardErrors[k-1]
+		if math.Abs(se[0]-se0) > 1e-8 || math.Abs(se[1]-se1) > 1e-8 {
+			t.Errorf("class %v standard errors %v, want [%v %v]", labels[k], se, se0, se1)
+		}
+		if math.Abs(res.OddsRatios[k-1][1]-math.Exp(b1)) > 1e-8 {
+			t.Errorf("odds ratio %v", res.OddsRatios[k-1][1])
+		}
+	}
+
+	probs, err := res.PredictProbabilities(insyra.NewDataList(0, 1))
+	if err != nil {
+		t.Fatalf("PredictProbabilities error: %v", err)
+	}
+	for g := range 2 {
+		row := probs.GetRow(g)
+		for k := range labels {
+			if want := c(g, k) / 40; math.Abs(row.Get(k).(float64)-want) > 1e-8 {
+				t.Errorf("P(%v | g=%d) = %v, want %v", labels[k], g, row.Get(k), want)
+			}
+		}
+	}
+	if got := probs.ColNames(); got[0] != "bus" || got[2] != "walk" {
+		t.Errorf("probability columns %v", got)
+	}
+	classes, err := res.PredictClass(insyra.NewDataList(0, 1))
+	if err != nil {
+		t.Fatalf("PredictClass error: %v", err)
+	}
+	if classes.Get(0) != "car" || classes.Get(1) != "walk" {
+		t.Errorf("predicted classes %v", classes.Data())
+	}
+	if rows, _ := res.CoefficientTable().Size(); rows != 4 {
+		t.Errorf("coefficient table has %d rows", rows)
+	}
+	ll0 := 0.0
+	for k := range labels {
+		tot := c(0, k) + c(1, k)
+		ll0 += tot * math.Log(tot/80)
+	}
+	if math.Abs(res.NullLogLikelihood-ll0) > 1e-10 || math.Abs(res.AIC-(-2*res.LogLikelihood+8)) > 1e-10 {
+		t.Errorf("null logLik %v (want %v), AIC %v", res.NullLogLikelihood, ll0, res.AIC)
+	}
+
+	ref, err := MultinomialLogisticRegression(MultinomialLogisticOptions{Reference: "walk", Tolerance: 1e-12, MaxIter: 100}, y, x)
+	if err != nil {
+		t.Fatalf("MultinomialLogisticRegression error: %v", err)
+	}
+	if ref.Classes[0] != "walk" || ref.Classes[1] != "bus" || ref.Classes[2] != "car" {
+		t.Fatalf("classes with reference %v", ref.Classes)
+	}
+	if math.Abs(ref.LogLikelihood-res.LogLikelihood) > 1e-9 {
+		t.Errorf("changing the reference changed logLik: %v vs %v", ref.LogLikelihood, res.LogLikelihood)
+	}
+	if _, err := MultinomialLogisticRegression(MultinomialLogisticOptions{Reference: "train"}, y, x); err == nil {
+		t.Errorf("expected error for an unknown reference class")
+	}
+}
+
+func TestMultinomialLogisticTwoClassesMatchesLogistic(t *testing.T) {
+	y := insyra.NewDataList("no", "no", "yes", "no", "yes", "yes", "no", "yes", "yes", "no")
+	x := insyra.NewDataList(-2.1, -1.5, -0.4, 0.1, 0.6, 1.2, 1.7, 2.1, 2.8, 3.3)
+	logit, err := LogisticRegressionWithOptions(LogisticRegressionOptions{PositiveClass: "yes", MaxIter: 100, Tolerance: 1e-12}, y, x)
+	if err != nil {
+		t.Fatalf("LogisticRegression error: %v", err)
+	}
+	multi, err := MultinomialLogisticRegression(MultinomialLogisticOptions{MaxIter: 100, Tolerance: 1e-12}, y, x)
+	if err != nil {
+		t.Fatalf("MultinomialLogisticRegression error: %v", err)
+	}
+	for j := range logit.Coefficients {
+		if math.Abs(multi.Coefficients[0][j]-logit.Coefficients[j]) > 1e-7 ||
+			math.Abs(multi.StandardErrors[0][j]-logit.StandardErrors[j]) > 1e-7 {
+			t.Errorf("term %d: %v (se %v), want %v (se %v)", j, multi.Coefficients[0][j], multi.StandardErrors[0][j],
+				logit.Coefficients[j], logit.StandardErrors[j])
+		}
+	}
+	if math.Abs(multi.LogLikelihood-logit.LogLikelihood) > 1e-9 {
+		t.Errorf("logLik %v, want %v", multi.LogLikelihood, logit.LogLikelihood)
+	}
+}
@@ -0,0 +1,505 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+// NonlinearModel evaluates the mean response at one observation's
+// predictors x for the parameter vector params.
+type NonlinearModel func(x []float64, params []float64) float64
+
+type NonlinearBuiltin string
+
+const (
+	// NonlinearLogistic is Asym / (1 + exp((xmid - x) / scal)), as SSlogis.
+	NonlinearLogistic NonlinearBuiltin = "logistic"
+	// NonlinearGompertz is Asym * exp(-b2 * b3^x), as SSgompertz.
+	NonlinearGompertz NonlinearBuiltin = "gompertz"
+	// NonlinearMichaelisMenten is Vm * x / (K + x), as SSmicmen.
+	NonlinearMichaelisMenten NonlinearBuiltin = "michaelis_menten"
+)
+
+type NonlinearRegressionOptions struct {
+	// Model is the mean function. Leave it nil and set Builtin to use one
+	// of the built-in single-predictor models.
+	Model   NonlinearModel
+	Builtin NonlinearBuiltin
+	// Start is required for a custom Model; built-in models compute their
+	// own starting values when it is nil.
+	Start      []float64
+	ParamNames []string
+	// Lower and Upper bound the parameters elementwise; nil means
+	// unbounded and ±Inf entries leave one side open. Equal bounds fix a
+	// parameter at that value.
+	Lower []float64
+	Upper []float64
+	// MaxIter defaults to 200 and Tolerance to 1e-8. Tolerance applies to
+	// nls's relative-offset convergence criterion.
+	MaxIter   int
+	Tolerance float64
+}
+
+// NonlinearRegressionResult holds a nonlinear least squares fit. Standard
+// errors come from s²(J'J)⁻¹ with the Jacobian J at the solution, as in
+// summary.nls, and the intervals are Wald intervals on n-p degrees of
+// freedom, where p counts the estimated parameters. AtBound flags
+// parameters that finished on a bound, for which the standard errors are
+// only indicative. Parameters fixed by equal bounds have zero standard
+// error and covariance, NaN t and p values and a degenerate interval.
+type NonlinearRegressionResult struct {
+	ParamNames          []string
+	Parameters          []float64
+	StandardErrors      []float64
+	TValues             []float64
+	PValues             []float64
+	ConfidenceIntervals [][2]float64
+	Covariance          [][]float64
+	Fitted              []float64
+	Residuals           []float64
+	RSS                 float64
+	ResidualStdError    float64
+	DF                  int
+	AtBound             []bool
+	Iterations          int
+	Converged           bool
+
+	model NonlinearModel
+}
+
+// NonlinearRegression fits y = f(x, θ) + ε by Levenberg-Marquardt with
+// central-difference Jacobians. Parameters are projected onto their bounds
+// after each step.
+func NonlinearRegression(opts NonlinearRegressionOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*NonlinearRegressionResult, error) {
+	if len(dlXs) == 0 {
+		return nil, errors.New("no independent variables provided")
+	}
+	y, xs, _, n, err := gatherRegressionInputs(dlY, dlXs)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]float64, n)
+	for i := range n {
+		rows[i] = make([]float64, len(xs))
+		for j := range xs {
+			rows[i][j] = xs[j][i]
+		}
+	}
+	for i, v := range y {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("response must be finite (index %d)", i)
+		}
+	}
+
+	model, names, start := opts.Model, opts.ParamNames, opts.Start
+	if opts.Builtin != "" {
+		if model != nil {
+			return nil, errors.New("set either Model or Builtin, not both")
+		}
+		if len(dlXs) != 1 {
+			return nil, errors.New("built-in nonlinear models take exactly one predictor")
+		}
+		var selfStart []float64
+		model, names, selfStart, err = nonlinearBuiltin(opts.Builtin, xs[0], y)
+		if err != nil {
+			return nil, err
+		}
+		if start == nil {
+			start = selfStart
+		}
+		if len(start) != len(selfStart) {
+			return nil, fmt.Errorf("built-in model %q has %d parameters, got %d starting values", opts.Builtin, len(selfStart), len(start))
+		}
+		if opts.ParamNames != nil {
+			if len(opts.ParamNames) != len(selfStart) {
+				return nil, fmt.Errorf("built-in model %q has %d parameters, got %d parameter names", opts.Builtin, len(selfStart), len(opts.ParamNames))
+			}
+			names = opts.ParamNames
+		}
+	}
+	if model == nil {
+		return nil, errors.New("nonlinear model is nil")
+	}
+	p := len(start)
+	if p == 0 {
+		return nil, errors.New("starting values are required")
+	}
+	if names == nil {
+		names = make([]string, p)
+		for j := range names {
+			names[j] = fmt.Sprintf("p%d", j+1)
+		}
+	}
+	if len(names) != p {
+		return nil, fmt.Errorf("got %d parameter names for %d parameters", len(names), p)
+	}
+	lower, upper, err := nonlinearBounds(opts.Lower, opts.Upper, p)
+	if err != nil {
+		return nil, err
+	}
+	var estimated []int
+	for j := range p {
+		if lower[j] < upper[j] {
+			estimated = append(estimated, j)
+		}
+	}
+	if len(estimated) == 0 {
+		return nil, errors.New("every parameter is fixed by its bounds")
+	}
+	if n <= len(estimated) {
+		return nil, errors.New("need more observations than parameters")
+	}
+	theta := make([]float64, p)
+	for j, v := range start {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("starting value %d must be finite", j)
+		}
+		theta[j] = math.Min(math.Max(v, lower[j]), upper[j])
+	}
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 200
+	}
+	tol := opts.Tolerance
+	if tol <= 0 {
+		tol = 1e-8
+	}
+
+	rss := func(th []float64) float64 {
+		s := 0.0
+		for i := range n {
+			r := y[i] - model(rows[i], th)
+			s += r * r
+		}
+		return s
+	}
+	cur := rss(theta)
+	if math.IsNaN(cur) || math.IsInf(cur, 0) {
+		return nil, errors.New("model is not finite at the starting values")
+	}
+
+	lambda := 1e-3
+	converged := false
+	iterations := 0
+	for iter := 1; iter <= maxIter; iter++ {
+		iterations = iter
+		J := nonlinearJacobian(model, rows, theta, lower, upper)
+		resid := make([]float64, n)
+		for i := range n {
+			resid[i] = y[i] - model(rows[i], theta)
+		}
+		var A mat.SymDense
+		A.SymOuterK(1, J.T())
+		g := mat.NewVecDense(p, nil)
+		g.MulVec(J.T(), mat.NewVecDense(n, resid))
+
+		// Fixed parameters and those held at a bound by the gradient drop
+		// out of the step and of the convergence test.
+		free := make([]bool, p)
+		for j := range p {
+			gj := g.AtVec(j)
+			free[j] = lower[j] < upper[j] && !((theta[j] <= lower[j] && gj < 0) || (theta[j] >= upper[j] && gj > 0))
+		}
+		if nonlinearRelativeOffset(&A, g, free, cur) < tol {
+			converged = true
+			break
+		}
+
+		improved := false
+		for !improved && lambda < 1e16 {
+			M := mat.NewDense(p, p, nil)
+			rhs := mat.NewVecDense(p, nil)
+			for a := range p {
+				if !free[a] {
+					M.Set(a, a, 1)
+					continue
+				}
+				rhs.SetVec(a, g.AtVec(a))
+				for b := range p {
+					if free[b] {
+						M.Set(a, b, A.At(a, b))
+					}
+				}
+				M.Set(a, a, A.At(a, a)+lambda*math.Max(A.At(a, a), 1e-12))
+			}
+			var step mat.VecDense
+			if err := step.SolveVec(M, rhs); err != nil {
+				lambda *= 10
+				continue
+			}
+			next := make([]float64, p)
+			for j := range p {
+				next[j] = math.Min(math.Max(theta[j]+step.AtVec(j), lower[j]), upper[j])
+			}
+			if val := rss(next); val <= cur && !math.IsNaN(val) {
+				improved = true
+				moved := false
+				for j := range p {
+					if next[j] != theta[j] {
+						moved = true
+					}
+				}
+				theta, cur = next, val
+				lambda = math.Max(lambda/10, 1e-12)
+				if !moved {
+					lambda = 1e16
+				}
+			} else {
+				lambda *= 10
+			}
+		}
+		if !improved || lambda >= 1e16 {
+			// No further decrease is possible at working precision.
+			break
+		}
+	}
+
+	J := nonlinearJacobian(model, rows, theta, lower, upper)
+
+	fitted := make([]float64, n)
+	resid := make([]float64, n)
+	for i := range n {
+		fitted[i] = model(rows[i], theta)
+		resid[i] = y[i] - fitted[i]
+	}
+	// Inference covers the estimated parameters only; fixed ones keep zero
+	// rows and columns in the covariance.
+	q := len(estimated)
+	df := n - q
+	s2 := cur / float64(df)
+	A := mat.NewDense(q, q, nil)
+	for a, ja := range estimated {
+		for b, jb := range estimated {
+			A.Set(a, b, mat.Dot(J.ColView(ja), J.ColView(jb)))
+		}
+	}
+	var inv mat.Dense
+	if err := inv.Inverse(A); err != nil {
+		return nil, errors.New("singular Jacobian at the solution; parameters are not identifiable")
+	}
+	thetaEst := make([]float64, q)
+	xtxInv := make([][]float64, q)
+	for a, ja := range estimated {
+		thetaEst[a] = theta[ja]
+		xtxInv[a] = make([]float64, q)
+		for b := range q {
+			xtxInv[a][b] = inv.At(a, b)
+		}
+	}
+	seEst, tvEst, pvEst := computeCoeffInference(thetaEst, xtxInv, s2, float64(df))
+	ciEst := buildMultiCoeffCIs(thetaEst, seEst, float64(df))
+	cov := make([][]float64, p)
+	se := make([]float64, p)
+	tv := make([]float64, p)
+	pv := make([]float64, p)
+	ci := make([][2]float64, p)
+	for j := range p {
+		cov[j] = make([]float64, p)
+		tv[j], pv[j] = math.NaN(), math.NaN()
+		ci[j] = [2]float64{theta[j], theta[j]}
+	}
+	for a, ja := range estimated {
+		se[ja], tv[ja], pv[ja], ci[ja] = seEst[a], tvEst[a], pvEst[a], ciEst[a]
+		for b, jb := range estimated {
+			cov[ja][jb] = s2 * xtxInv[a][b]
+		}
+	}
+	atBound := make([]bool, p)
+	for j := range p {
+		atBound[j] = theta[j] <= lower[j] || theta[j] >= upper[j]
+	}
+	return &NonlinearRegressionResult{
+		ParamNames:          append([]string(nil), names...),
+		Parameters:          theta,
+		StandardErrors:      se,
+		TValues:             tv,
+		PValues:             pv,
+		ConfidenceIntervals: ci,
+		Covariance:          cov,
+		Fitted:              fitted,
+		Residuals:           resid,
+		RSS:                 cur,
+		ResidualStdError:    math.Sqrt(s2),
+		DF:                  df,
+		AtBound:             atBound,
+		Iterations:          iterations,
+		Converged:           converged,
+		model:               model,
+	}, nil
+}
+
+// Predict evaluates the fitted model at new predictor values.
+func (r *NonlinearRegressionResult) Predict(newXs ...insyra.IDataList) (*insyra.DataList, error) {
+	if r == nil || r.model == nil {
+		return nil, errors.New("nonlinear regression result is nil")
+	}
+	xs, n, err := gatherPredictorInputs(newXs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float64, n)
+	row := make([]float64, len(xs))
+	for i := range n {
+		for j := range xs {
+			row[j] = xs[j][i]
+		}
+		out[i] = r.model(row, r.Parameters)
+	}
+	return insyra.NewDataList(out), nil
+}
+
+func nonlinearBounds(lower, upper []float64, p int) ([]float64, []float64, error) {
+	lo := make([]float64, p)
+	hi := make([]float64, p)
+	for j := range p {
+		lo[j], hi[j] = math.Inf(-1), math.Inf(1)
+	}
+	if lower != nil {
+		if len(lower) != p {
+			return nil, nil, fmt.Errorf("got %d lower bounds for %d parameters", len(lower), p)
+		}
+		copy(lo, lower)
+	}
+	if upper != nil {
+		if len(upper) != p {
+			return nil, nil, fmt.Errorf("got %d upper bounds for %d parameters", len(upper), p)
+		}
+		copy(hi, upper)
+	}
+	for j := range p {
+		if math.IsNaN(lo[j]) || math.IsNaN(hi[j]) || lo[j] > hi[j] {
+			return nil, nil, fmt.Errorf("invalid bounds for parameter %d", j)
+		}
+	}
+	return lo, hi, nil
+}
+
+// nonlinearJacobian returns ∂f/∂θ by central differences, stepping one-sided
+// where a bound would be crossed. Columns of parameters fixed by equal
+// bounds are zero.
+func nonlinearJacobian(model NonlinearModel, rows [][]float64, theta, lower, upper []float64) *mat.Dense {
+	n, p := len(rows), len(theta)
+	J := mat.NewDense(n, p, nil)
+	th := append([]float64(nil), theta...)
+	for j := range p {
+		h := 6e-6 * math.Max(math.Abs(theta[j]), 1e-3)
+		up, down := theta[j]+h, theta[j]-h
+		if up > upper[j] {
+			up = theta[j]
+		}
+		if down < lower[j] {
+			down = theta[j]
+		}
+		if up == down {
+			continue
+		}
+		for i := range n {
+			th[j] = up
+			fu := model(rows[i], th)
+			th[j] = down
+			fd := model(rows[i], th)
+			J.Set(i, j, (fu-fd)/(up-down))
+		}
+		th[j] = theta[j]
+	}
+	return J
+}
+
+// nonlinearRelativeOffset is the nls convergence criterion: the length of
+// the residual's projection onto the tangent plane relative to the
+// orthogonal part, restricted to the free parameters.
+func nonlinearRelativeOffset(A *mat.SymDense, g *mat.VecDense, free []bool, rss float64) float64 {
+	var idx []int
+	for j, f := range free {
+		if f {
+			idx = append(idx, j)
+		}
+	}
+	if len(idx) == 0 {
+		return 0
+	}
+	sub := mat.NewSymDense(len(idx), nil)
+	gs := mat.NewVecDense(len(idx), nil)
+	for a, ja := range idx {
+		gs.SetVec(a, g.AtVec(ja))
+		for b, jb := range idx {
+			sub.SetSym(a, b, A.At(ja, jb))
+		}
+	}
+	var sol mat.VecDense
+	if err := sol.SolveVec(sub, gs); err != nil {
+		return math.Inf(1)
+	}
+	proj := mat.Dot(gs, &sol)
+	rest := rss - proj
+	if rest <= 1e-30*math.Max(rss, 1) {
+		if proj <= 1e-30 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Sqrt(math.Max(proj, 0) / rest)
+}
+
+// nonlinearBuiltin returns a built-in model with its parameter names and
+// starting values from a linearising transformation.
+func nonlinearBuiltin(kind NonlinearBuiltin, x, y []float64) (NonlinearModel, []string, []float64, error) {
+	maxY := math.Inf(-1)
+	for _, v := range y {
+		maxY = math.Max(maxY, v)
+	}
+	switch kind {
+	case NonlinearLogistic, NonlinearGompertz:
+		if !(maxY > 0) {
+			return nil, nil, nil, errors.New("built-in growth models need a positive response")
+		}
+		asym := 1.05 * maxY
+		var tx, tz []float64
+		for i, v := range y {
+			if v <= 0 {
+				continue
+			}
+			var z float64
+			if kind == NonlinearLogistic {
+				z = math.Log(v / (asym - v))
+			} else {
+				z = math.Log(-math.Log(v / asym))
+			}
+			tx = append(tx, x[i])
+			tz = append(tz, z)
+		}
+		a, b, ok := simpleOLSCoeffs(tx, tz)
+		if !ok || b == 0 {
+			return nil, nil, nil, errors.New("cannot compute starting values; supply Start")
+		}
+		if kind == NonlinearLogistic {
+			return func(x, th []float64) float64 {
+				return th[0] / (1 + math.Exp((th[1]-x[0])/th[2]))
+			}, []string{"Asym", "xmid", "scal"}, []float64{asym, -a / b, 1 / b}, nil
+		}
+		return func(x, th []float64) float64 {
+			return th[0] * math.Exp(-th[1]*math.Pow(th[2], x[0]))
+		}, []string{"Asym", "b2", "b3"}, []float64{asym, math.Exp(a), math.Exp(b)}, nil
+	case NonlinearMichaelisMenten:
+		// Lineweaver-Burk: 1/y = 1/Vm + (K/Vm)(1/x).
+		var tx, tz []float64
+		for i, v := range y {
+			if v > 0 && x[i] > 0 {
+				tx = append(tx, 1/x[i])
+				tz = append(tz, 1/v)
+			}
+		}
+		a, b, ok := simpleOLSCoeffs(tx, tz)
+		if !ok || a <= 0 {
+			return nil, nil, nil, errors.New("cannot compute starting values; supply Start")
+		}
+		return func(x, th []float64) float64 {
+			return th[0] * x[0] / (th[1] + x[0])
+		}, []string{"Vm", "K"}, []float64{1 / a, b / a}, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unsupported built-in nonlinear model %q", kind)
+}
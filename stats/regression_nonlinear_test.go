@@ -0,0 +1,156 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// dnase1 is run 1 of R's datasets::DNase with log(conc) as predictor.
+func dnase1() (density, logConc insyra.IDataList) {
+	conc := []float64{0.04882812, 0.04882812, 0.1953125, 0.1953125, 0.390625, 0.390625, 0.78125, 0.78125, 1.5625, 1.5625, 3.125, 3.125, 6.25, 6.25, 12.5, 12.5}
+	lc := make([]float64, len(conc))
+	for i, c := range conc {
+		lc[i] = math.Log(c)
+	}
+	density = insyra.NewDataList(0.017, 0.018, 0.121, 0.124, 0.206, 0.215, 0.377, 0.374, 0.614, 0.609, 1.019, 1.001, 1.334, 1.364, 1.730, 1.710)
+	return density, insyra.NewDataList(lc)
+}
+
+func assertNonlinearFit(t *testing.T, res *NonlinearRegressionResult, params, se []float64, rse, tol float64) {
+	t.Helper()
+	if !res.Converged {
+		t.Fatalf("did not converge after %d iterations", res.Iterations)
+	}
+	for j := range params {
+		if math.Abs(res.Parameters[j]-params[j]) > tol*math.Abs(params[j]) {
+			t.Fatalf("%s = %.8g, want %.8g", res.ParamNames[j], res.Parameters[j], params[j])
+		}
+		if se != nil && math.Abs(res.StandardErrors[j]-se[j]) > 1e-3*se[j] {
+			t.Fatalf("se(%s) = %.6g, want %.6g", res.ParamNames[j], res.StandardErrors[j], se[j])
+		}
+	}
+	if math.Abs(res.ResidualStdError-rse) > 1e-3*rse {
+		t.Fatalf("residual standard error = %.6g, want %.6g", res.ResidualStdError, rse)
+	}
+}
+
+func TestNonlinearRegressionBuiltinsMatchNLS(t *testing.T) {
+	density, logConc := dnase1()
+
+	// nls(density ~ SSlogis(log(conc), Asym, xmid, scal), DNase1)
+	res, err := NonlinearRegression(NonlinearRegressionOptions{Builtin: NonlinearLogistic}, density, logConc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNonlinearFit(t, res, []float64{2.34518, 1.48309, 1.04146}, []float64{0.07815, 0.08135, 0.03227}, 0.01919, 1e-5)
+
+	// nls(density ~ SSgompertz(log(conc), Asym, b2, b3), DNase1)
+	res, err = NonlinearRegression(NonlinearRegressionOptions{Builtin: NonlinearGompertz}, density, logConc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNonlinearFit(t, res, []float64{4.6033, 2.2713, 0.7165}, nil, 0.02684, 1e-4)
+
+	// nls(rate ~ SSmicmen(conc, Vm, K), Puromycin, subset = state == "treated")
+	conc := insyra.NewDataList(0.02, 0.02, 0.06, 0.06, 0.11, 0.11, 0.22, 0.22, 0.56, 0.56, 1.10, 1.10)
+	rate := insyra.NewDataList(76, 47, 97, 107, 123, 139, 159, 152, 191, 201, 207, 200)
+	res, err = NonlinearRegression(NonlinearRegressionOptions{Builtin: NonlinearMichaelisMenten}, rate, conc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNonlinearFit(t, res, []float64{212.6837, 0.06412123}, []float64{6.947, 0.008281}, 10.93, 1e-6)
+
+	pred, err := res.Predict(insyra.NewDataList(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := res.Parameters[0] * 0.5 / (res.Parameters[1] + 0.5)
+	if got := pred.Get(0).(float64); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("prediction = %v, want %v", got, want)
+	}
+}
+
+func TestNonlinearRegressionCustomModelAndBounds(t *testing.T) {
+	x := insyra.NewDataList(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	y := insyra.NewDataList(5.1, 3.1, 1.9, 1.2, 0.72, 0.45, 0.26, 0.17, 0.1, 0.06)
+	decay := func(x, th []float64) float64 { return th[0] * math.Exp(-th[1]*x[0]) }
+
+	free, err := NonlinearRegression(NonlinearRegressionOptions{
+		Model: decay, Start: []float64{1, 1}, ParamNames: []string{"a", "k"},
+	}, y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !free.Converged || math.Abs(free.Parameters[1]-0.5) > 0.02 {
+		t.Fatalf("k = %v (converged %v), want near 0.5", free.Parameters[1], free.Converged)
+	}
+
+	bounded, err := NonlinearRegression(NonlinearRegressionOptions{
+		Model: decay, Start: []float64{1, 0.1}, Upper: []float64{math.Inf(1), 0.4},
+	}, y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounded.Parameters[1] != 0.4 || !bounded.AtBound[1] || bounded.AtBound[0] {
+		t.Fatalf("bounded fit = %v, at bound %v", bounded.Parameters, bounded.AtBound)
+	}
+	if !bounded.Converged || bounded.RSS < free.RSS {
+		t.Fatalf("bounded RSS %v (converged %v) below unconstrained %v", bounded.RSS, bounded.Converged, free.RSS)
+	}
+	// With k fixed at the bound, a is the least squares slope on exp(-0.4x).
+	num, den := 0.0, 0.0
+	for i := range 10 {
+		e := math.Exp(-0.4 * float64(i))
+		num += e * y.Get(i).(float64)
+		den += e * e
+	}
+	if math.Abs(bounded.Parameters[0]-num/den) > 1e-6 {
+		t.Fatalf("a = %v, want %v", bounded.Parameters[0], num/den)
+	}
+
+	// Equal bounds fix k, leaving a linear fit in a on n - 1 df.
+	fixed, err := NonlinearRegression(NonlinearRegressionOptions{
+		Model: decay, Start: []float64{1, 1}, Lower: []float64{math.Inf(-1), 0.4}, Upper: []float64{math.Inf(1), 0.4},
+	}, y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fixed.Converged || fixed.Parameters[1] != 0.4 || math.Abs(fixed.Parameters[0]-num/den) > 1e-6 {
+		t.Fatalf("fixed fit = %v (converged %v), want a = %v", fixed.Parameters, fixed.Converged, num/den)
+	}
+	if fixed.DF != 9 {
+		t.Fatalf("df = %d, want 9", fixed.DF)
+	}
+	if want := math.Sqrt(fixed.RSS / 9 / den); math.Abs(fixed.StandardErrors[0]-want) > 1e-6*want {
+		t.Fatalf("se(a) = %v, want %v", fixed.StandardErrors[0], want)
+	}
+	if fixed.StandardErrors[1] != 0 || fixed.Covariance[0][1] != 0 || !math.IsNaN(fixed.PValues[1]) ||
+		fixed.ConfidenceIntervals[1] != [2]float64{0.4, 0.4} || math.IsNaN(fixed.ConfidenceIntervals[0][0]) {
+		t.Fatalf("fixed parameter inference: se %v, cov %v, p %v, ci %v",
+			fixed.StandardErrors, fixed.Covariance, fixed.PValues, fixed.ConfidenceIntervals)
+	}
+}
+
+func TestNonlinearRegressionValidation(t *testing.T) {
+	density, logConc := dnase1()
+	model := func(x, th []float64) float64 { return th[0] + th[1]*x[0] }
+	for name, opts := range map[string]NonlinearRegressionOptions{
+		"no model":           {Start: []float64{1}},
+		"no start":           {Model: model},
+		"both":               {Model: model, Builtin: NonlinearLogistic},
+		"unknown":            {Builtin: "weibull"},
+		"bad bounds":         {Model: model, Start: []float64{0, 1}, Lower: []float64{1, 0}, Upper: []float64{0, 2}},
+		"bounds length":      {Model: model, Start: []float64{0, 1}, Lower: []float64{0}},
+		"names length":       {Model: model, Start: []float64{0, 1}, ParamNames: []string{"a"}},
+		"all fixed":          {Model: model, Start: []float64{0, 1}, Lower: []float64{0, 1}, Upper: []float64{0, 1}},
+		"builtin start":      {Builtin: NonlinearLogistic, Start: []float64{2, 1}},
+		"builtin names":      {Builtin: NonlinearLogistic, Start: []float64{2, 1}, ParamNames: []string{"a", "b"}},
+		"builtin name count": {Builtin: NonlinearLogistic, ParamNames: []string{"a", "b"}},
+	} {
+		if _, err := NonlinearRegression(opts, density, logConc); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type OrdinalLogisticOptions struct {
+	ConfidenceLevel float64
+	MaxIter         int
+	Tolerance       float64
+	// Levels orders the response categories from lowest to highest. It
+	// defaults to sorted order, which suits numeric Likert codes; string
+	// labels usually need it.
+	Levels []any
+}
+
+// OrdinalLogisticResult holds a proportional-odds model in the
+// parameterisation of R's MASS::polr:
+//
+//	logit P(Y <= k) = Thresholds[k] - x'β
+//
+// so a positive coefficient shifts responses toward higher levels.
+// Threshold k separates Levels[k] from Levels[k+1].
+type OrdinalLogisticResult struct {
+	Levels              []any
+	CoefficientNames    []string
+	Coefficients        []float64
+	StandardErrors      []float64
+	ZValues             []float64
+	PValues             []float64
+	ConfidenceIntervals [][2]float64
+	OddsRatios          []float64
+	OddsRatioCIs        [][2]float64
+	ThresholdNames      []string
+	Thresholds          []float64
+	ThresholdSEs        []float64
+	// FittedProbabilities has one column per level, named after it.
+	FittedProbabilities *insyra.DataTable
+	LogLikelihood       float64
+	NullLogLikelihood   float64
+	Deviance            float64
+	LikelihoodRatio     float64
+	LikelihoodRatioP    float64
+	AIC                 float64
+	BIC                 float64
+	McFaddenR2          float64
+	N                   int
+	Iterations          int
+	Converged           bool
+	ConfidenceLevel     float64
+	x                   *mat.Dense
+	y                   []int
+}
+
+// OrdinalLogisticRegression fits a proportional-odds (cumulative logit)
+// model by Newton-Raphson.
+func OrdinalLogisticRegression(opts OrdinalLogisticOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*OrdinalLogisticResult, error) {
+	xs, names, rawY, err := classResponseInputs(dlY, dlXs)
+	if err != nil {
+		return nil, err
+	}
+	levels := opts.Levels
+	if levels == nil {
+		levels = sortedClasses(rawY)
+	}
+	if len(levels) < 2 {
+		return nil, errors.New("ordinal logistic regression requires at least two response levels")
+	}
+	if len(sortedClasses(levels)) != len(levels) {
+		return nil, errors.New("levels must be distinct")
+	}
+	y := encodeClasses(rawY, levels)
+	K, p := len(levels), len(xs)
+	counts := make([]float64, K)
+	for i, c := range y {
+		if c < 0 {
+			return nil, fmt.Errorf("response %v at index %d is not one of the levels", rawY[i], i)
+		}
+		counts[c]++
+	}
+	for k, c := range counts {
+		if c == 0 {
+			return nil, fmt.Errorf("level %v has no observations", levels[k])
+		}
+	}
+	n := len(y)
+	if n <= K-1+p {
+		return nil, errors.New("not enough observations for the number of parameters")
+	}
+	x := mat.NewDense(n, p, nil)
+	for j := range p {
+		for i := range n {
+			x.Set(i, j, xs[j][i])
+		}
+	}
+
+	start := make([]float64, K-1+p)
+	cum := 0.0
+	for k := range K - 1 {
+		cum += counts[k] / float64(n)
+		start[k] = math.Log(cum / (1 - cum))
+	}
+	obj := func(theta []float64) (float64, []float64, *mat.Dense, error) {
+		return ordinalLogLik(x, y, K, theta)
+	}
+	fit, err := fitNewton(start, obj, irlsOptions{maxIter: opts.MaxIter, tolerance: opts.Tolerance})
+	if err != nil {
+		return nil, err
+	}
+
+	cl := resolveConfidenceLevel(opts.ConfidenceLevel)
+	se, z, pv := computeGLMInference(fit.beta, fit.covUnscaled, 1)
+	cis := buildGLMCoeffCIs(fit.beta, se, cl)
+	res := &OrdinalLogisticResult{
+		Levels:              levels,
+		CoefficientNames:    names,
+		Coefficients:        append([]float64(nil), fit.beta[K-1:]...),
+		StandardErrors:      se[K-1:],
+		ZValues:             z[K-1:],
+		PValues:             pv[K-1:],
+		ConfidenceIntervals: cis[K-1:],
+		OddsRatios:          expSlice(fit.beta[K-1:]),
+		OddsRatioCIs:        expCIs(cis[K-1:]),
+		Thresholds:          append([]float64(nil), fit.beta[:K-1]...),
+		ThresholdSEs:        se[:K-1],
+		N:                   n,
+		Iterations:          fit.iterations,
+		Converged:           fit.converged,
+		ConfidenceLevel:     cl,
+		x:                   x,
+		y:                   y,
+	}
+	for k := range K - 1 {
+		res.ThresholdNames = append(res.ThresholdNames, fmt.Sprintf("%v|%v", levels[k], levels[k+1]))
+	}
+	res.FittedProbabilities = classProbabilityTable(levels, ordinalProbabilities(x, res.Thresholds, res.Coefficients))
+
+	res.LogLikelihood = -fit.deviance / 2
+	res.NullLogLikelihood = nullClassLogLik(counts)
+	res.Deviance = fit.deviance
+	res.LikelihoodRatio = 2 * (res.LogLikelihood - res.NullLogLikelihood)
+	res.LikelihoodRatioP = chiSquaredPValue(res.LikelihoodRatio, float64(p))
+	res.AIC = glmAIC(res.LogLikelihood, len(fit.beta))
+	res.BIC = glmBIC(res.LogLikelihood, len(fit.beta), n)
+	res.McFaddenR2 = mcFaddenR2(res.LogLikelihood, res.NullLogLikelihood)
+	return res, nil
+}
+
+// CoefficientTable returns the thresholds followed by the coefficients,
+// with columns Type ("threshold" or "coefficient"), Term, Estimate,
+// StdError, Z, P, Lower, Upper and OddsRatio.
+func (r *OrdinalLogisticResult) CoefficientTable() *insyra.DataTable {
+	cols := coefficientTableColumns("Type")
+	zcrit := zQuantile(1 - (1-r.ConfidenceLevel)/2)
+	for k, name := range r.ThresholdNames {
+		est, se := r.Thresholds[k], r.ThresholdSEs[k]
+		appendCoefficientRow(cols, "threshold", name, est, se, est/se, zPValue(est/se, TwoSided),
+			[2]float64{est - zcrit*se, est + zcrit*se})
+	}
+	for j, name := range r.CoefficientNames {
+		appendCoefficientRow(cols, "coefficient", name, r.Coefficients[j], r.StandardErrors[j],
+			r.ZValues[j], r.PValues[j], r.ConfidenceIntervals[j])
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+// PredictProbabilities returns the predicted level probabilities for new
+// predictor values, one column per level.
+func (r *OrdinalLogisticResult) PredictProbabilities(newXs ...insyra.IDataList) (*insyra.DataTable, error) {
+	probs, err := r.predict(newXs)
+	if err != nil {
+		return nil, err
+	}
+	return classProbabilityTable(r.Levels, probs), nil
+}
+
+// PredictClass returns the most probable level for each new row.
+func (r *OrdinalLogisticResult) PredictClass(newXs ...insyra.IDataList) (*insyra.DataList, error) {
+	probs, err := r.predict(newXs)
+	if err != nil {
+		return nil, err
+	}
+	return mostProbableClass(r.Levels, probs), nil
+}
+
+func (r *OrdinalLogisticResult) predict(newXs []insyra.IDataList) ([][]float64, error) {
+	if r == nil {
+		return nil, errors.New("ordinal logistic result is nil")
+	}
+	if len(newXs) != len(r.Coefficients) {
+		return nil, fmt.Errorf("expected %d predictors, got %d", len(r.Coefficients), len(newXs))
+	}
+	xs, n, err := gatherPredictorInputs(newXs)
+	if err != nil {
+		return nil, err
+	}
+	x := mat.NewDense(n, len(xs), nil)
+	for j := range xs {
+		for i := range n {
+			x.Set(i, j, xs[j][i])
+		}
+	}
+	return ordinalProbabilities(x, r.Thresholds, r.Coefficients), nil
+}
+
+// BrantTestTerm is one row of a Brant test.
+type BrantTestTerm struct {
+	Name      string
+	Statistic float64
+	DF        int
+	PValue    float64
+}
+
+// BrantTestResult tests the proportional-odds assumption. Omnibus tests all
+// coefficients at once; Terms has one test per predictor.
+type BrantTestResult struct {
+	Omnibus BrantTestTerm
+	Terms   []BrantTestTerm
+}
+
+// BrantTest compares the coefficients of the K-1 binary logits
+// 1{Y > level k} with a Wald test of their equality (Brant, 1990), as R's
+// brant package does. A small p-value means the proportional-odds
+// assumption fails for that predictor.
+func (r *OrdinalLogisticResult) BrantTest() (*BrantTestResult, error) {
+	if r == nil || r.x == nil {
+		return nil, errors.New("ordinal logistic result has no training data")
+	}
+	J := len(r.Levels)
+	if J < 3 {
+		return nil, errors.New("the Brant test needs at least three response levels")
+	}
+	n, p := r.x.Dims()
+	xs := make([][]float64, p)
+	for j := range p {
+		xs[j] = mat.Col(nil, j, r.x)
+	}
+	X := buildDesignMatrix(xs, n)
+
+	// Binary logit m models P(Y > level m).
+	fits := make([]*irlsFit, J-1)
+	for m := range J - 1 {
+		yb := make([]float64, n)
+		for i, c := range r.y {
+			if c > m {
+				yb[i] = 1
+			}
+		}
+		fit, err := fitIRLS(X, yb, binomialFamily{}, logitLink{}, irlsOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("binary logit %d: %w", m+1, err)
+		}
+		fits[m] = fit
+	}
+
+	// Stack the slopes (dropping intercepts) and their joint covariance;
+	// off-diagonal blocks are (X'W_m X)⁻¹ X'W_ml X (X'W_l X)⁻¹ with
+	// W_ml = π_l(1 - π_m) for m < l.
+	q := (J - 1) * p
+	beta := make([]float64, q)
+	cov := mat.NewDense(q, q, nil)
+	for m := range J - 1 {
+		for a := range p {
+			beta[m*p+a] = fits[m].beta[a+1]
+			for b := range p {
+				cov.Set(m*p+a, m*p+b, fits[m].covUnscaled[a+1][b+1])
+			}
+		}
+	}
+	for m := range J - 1 {
+		for l := m + 1; l < J-1; l++ {
+			w := make([]float64, n)
+			for i := range n {
+				w[i] = fits[l].mu[i] * (1 - fits[m].mu[i])
+			}
+			mid, _ := weightedNormalSystem(X, w, make([]float64, n), 0)
+			var block mat.Dense
+			block.Mul(denseFromRows(fits[m].covUnscaled), mid)
+			block.Mul(&block, denseFromRows(fits[l].covUnscaled))
+			for a := range p {
+				for b := range p {
+					v := block.At(a+1, b+1)
+					cov.Set(m*p+a, l*p+b, v)
+					cov.Set(l*p+b, m*p+a, v)
+				}
+			}
+		}
+	}
+
+	res := &BrantTestResult{}
+	all := make([]int, p)
+	for a := range p {
+		all[a] = a
+	}
+	res.Omnibus = brantWald("Omnibus", beta, cov, J, p, all)
+	for a, name := range r.CoefficientNames {
+		res.Terms = append(res.Terms, brantWald(name, beta, cov, J, p, []int{a}))
+	}
+	return res, nil
+}
+
+// brantWald tests β_1 = β_m for m = 2..J-1 on the selected predictors.
+func brantWald(name string, beta []float64, cov *mat.Dense, J, p int, vars []int) BrantTestTerm {
+	rows := (J - 2) * len(vars)
+	D := mat.NewDense(rows, len(beta), nil)
+	r := 0
+	for m := 1; m < J-1; m++ {
+		for _, a := range vars {
+			D.Set(r, a, 1)
+			D.Set(r, m*p+a, -1)
+			r++
+		}
+	}
+	var d mat.VecDense
+	d.MulVec(D, mat.NewVecDense(len(beta), beta))
+	var dv, v mat.Dense
+	dv.Mul(D, cov)
+	v.Mul(&dv, D.T())
+	stat := math.NaN()
+	var sol mat.VecDense
+	if err := sol.SolveVec(&v, &d); err == nil {
+		stat = mat.Dot(&d, &sol)
+	}
+	return BrantTestTerm{Name: name, Statistic: stat, DF: rows, PValue: chiSquaredPValue(stat, float64(rows))}
+}
+
+// ordinalLogLik returns the proportional-odds log-likelihood, score and
+// information. theta holds the K-1 thresholds followed by the slopes;
+// thresholds out of order give a NaN log-likelihood so fitNewton halves
+// the step.
+func ordinalLogLik(x *mat.Dense, y []int, K int, theta []float64) (float64, []float64, *mat.Dense, error) {
+	n, p := x.Dims()
+	m := len(theta)
+	for k := 1; k < K-1; k++ {
+		if theta[k] <= theta[k-1] {
+			return math.NaN(), nil, nil, nil
+		}
+	}
+	beta := theta[K-1:]
+	ll := 0.0
+	score := make([]float64, m)
+	info := mat.NewDense(m, m, nil)
+	dP := make([]float64, m)
+	for i := range n {
+		xi := x.RawRowView(i)
+		eta := 0.0
+		for j := range p {
+			eta += xi[j] * beta[j]
+		}
+		c := y[i]
+		// P = F(a) - F(b) with a = ζ_c - η (upper) and b = ζ_{c-1} - η.
+		var Fa, fa, ha, Fb, fb, hb float64
+		Fa = 1
+		if c < K-1 {
+			Fa = sigmoid(theta[c] - eta)
+			fa = Fa * (1 - Fa)
+			ha = fa * (1 - 2*Fa)
+		}
+		if c > 0 {
+			Fb = sigmoid(theta[c-1] - eta)
+			fb = Fb * (1 - Fb)
+			hb = fb * (1 - 2*Fb)
+		}
+		P := math.Max(Fa-Fb, 1e-300)
+		ll += math.Log(P)
+
+		for k := range dP {
+			dP[k] = 0
+		}
+		if c < K-1 {
+			dP[c] = fa
+		}
+		if c > 0 {
+			dP[c-1] = -fb
+		}
+		for j := range p {
+			dP[K-1+j] = -xi[j] * (fa - fb)
+		}
+		// Second derivatives of P, then -∂²log P = dP dP'/P² - d²P/P.
+		d2 := func(s, t int) float64 {
+			sx, tx := s >= K-1, t >= K-1
+			switch {
+			case !sx && !tx:
+				if s != t {
+					return 0
+				}
+				if s == c {
+					return ha
+				}
+				return -hb
+			case sx && tx:
+				return xi[s-K+1] * xi[t-K+1] * (ha - hb)
+			default:
+				if sx {
+					s, t = t, s
+				}
+				if s == c {
+					return -xi[t-K+1] * ha
+				}
+				return xi[t-K+1] * hb
+			}
+		}
+		idx := make([]int, 0, 2+p)
+		if c < K-1 {
+			idx = append(idx, c)
+		}
+		if c > 0 {
+			idx = append(idx, c-1)
+		}
+		for j := range p {
+			idx = append(idx, K-1+j)
+		}
+		for _, s := range idx {
+			score[s] += dP[s] / P
+			for _, t := range idx {
+				info.Set(s, t, info.At(s, t)+dP[s]*dP[t]/(P*P)-d2(s, t)/P)
+			}
+		}
+	}
+	return ll, score, info, nil
+}
+
+func ordinalProbabilities(x *mat.Dense, thresholds, beta []float64) [][]float64 {
+	n, p := x.Dims()
+	K := len(thresholds) + 1
+	out := make([][]float64, n)
+	for i := range n {
+		eta := 0.0
+		for j := range p {
+			eta += x.At(i, j) * beta[j]
+		}
+		pr := make([]float64, K)
+		prev := 0.0
+		for k := range K - 1 {
+			F := sigmoid(thresholds[k] - eta)
+			pr[k] = F - prev
+			prev = F
+		}
+		pr[K-1] = 1 - prev
+		out[i] = pr
+	}
+	return out
+}
+
+func denseFromRows(rows [][]float64) *mat.Dense {
+	d := mat.NewDense(len(rows), len(rows[0]), nil)
+	for i, row := range rows {
+		d.SetRow(i, row)
+	}
+	return d
+}
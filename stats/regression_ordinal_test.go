@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// With two levels the proportional-odds model is binary logistic
+// regression with threshold -β0.
+func TestOrdinalLogisticTwoLevelsMatchesLogistic(t *testing.T) {
+	y := insyra.NewDataList(0, 0, 1, 0, 1, 1, 0, 1, 1, 0)
+	x := insyra.NewDataList(-2.1, -1.5, -0.4, 0.1, 0.6, 1.2, 1.7, 2.1, 2.8, 3.3)
+	logit, err := LogisticRegressionWithOptions(LogisticRegressionOptions{MaxIter: 100, Tolerance: 1e-12}, y, x)
+	if err != nil {
+		t.Fatalf("LogisticRegression error: %v", err)
+	}
+	ord, err := OrdinalLogisticRegression(OrdinalLogisticOptions{MaxIter: 100, Tolerance: 1e-12}, y, x)
+	if err != nil {
+		t.Fatalf("OrdinalLogisticRegression error: %v", err)
+	}
+	if math.Abs(ord.Thresholds[0]+logit.Coefficients[0]) > 1e-7 || math.Abs(ord.ThresholdSEs[0]-logit.StandardErrors[0]) > 1e-7 {
+		t.Errorf("threshold %v (se %v), want %v (se %v)", ord.Thresholds[0], ord.ThresholdSEs[0], -logit.Coefficients[0], logit.StandardErrors[0])
+	}
+	if math.Abs(ord.Coefficients[0]-logit.Coefficients[1]) > 1e-7 || math.Abs(ord.StandardErrors[0]-logit.StandardErrors[1]) > 1e-7 {
+		t.Errorf("slope %v (se %v), want %v (se %v)", ord.Coefficients[0], ord.StandardErrors[0], logit.Coefficients[1], logit.StandardErrors[1])
+	}
+	if _, err := ord.BrantTest(); err == nil {
+		t.Errorf("expected Brant test error with two levels")
+	}
+}
+
+func likertData() (*insyra.DataList, *insyra.DataList, *insyra.DataList) {
+	y := insyra.NewDataList("low", "low", "mid", "low", "mid", "high", "mid", "high", "high", "mid",
+		"low", "mid", "high", "mid", "high", "high", "low", "mid", "high", "low", "mid", "high", "mid", "low")
+	x1 := insyra.NewDataList(0.2, 0.5, 1.1, 0.9, 1.4, 2.2, 1.0, 2.8, 1.9, 1.6,
+		0.4, 1.3, 2.5, 0.8, 2.0, 3.1, 1.2, 1.7, 2.4, 0.1, 2.1, 1.5, 1.8, 0.7).SetName("score")
+	x2 := insyra.NewDataList(1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 0, 1, 1, 0, 1, 0, 1, 0, 0, 1, 1, 0).SetName("group")
+	return y, x1, x2
+}
+
+func TestOrdinalLogisticLevelsAndPrediction(t *testing.T) {
+	y, x1, x2 := likertData()
+	levels := []any{"low", "mid", "high"}
+	res, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Levels: levels, Tolerance: 1e-12, MaxIter: 100}, y, x1, x2)
+	if err != nil {
+		t.Fatalf("OrdinalLogisticRegression error: %v", err)
+	}
+	if !res.Converged || res.Coefficients[0] <= 0 || res.Thresholds[0] >= res.Thresholds[1] {
+		t.Fatalf("fit %+v", res)
+	}
+	if res.ThresholdNames[1] != "mid|high" || res.CoefficientNames[0] != "score" {
+		t.Errorf("names %v %v", res.ThresholdNames, res.CoefficientNames)
+	}
+
+	// Reversing the level order mirrors the model.
+	rev, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Levels: []any{"high", "mid", "low"}, Tolerance: 1e-12, MaxIter: 100}, y, x1, x2)
+	if err != nil {
+		t.Fatalf("OrdinalLogisticRegression error: %v", err)
+	}
+	for j := range res.Coefficients {
+		if math.Abs(rev.Coefficients[j]+res.Coefficients[j]) > 1e-6 || math.Abs(rev.StandardErrors[j]-res.StandardErrors[j]) > 1e-6 {
+			t.Errorf("reversed coef %d = %v, want %v", j, rev.Coefficients[j], -res.Coefficients[j])
+		}
+	}
+	if math.Abs(rev.Thresholds[0]+res.Thresholds[1]) > 1e-6 || math.Abs(rev.LogLikelihood-res.LogLikelihood) > 1e-9 {
+		t.Errorf("reversed thresholds %v, want negated %v", rev.Thresholds, res.Thresholds)
+	}
+
+	// A perturbed parameter vector has a lower log-likelihood.
+	theta := append(append([]float64(nil), res.Thresholds...), res.Coefficients...)
+	for i := range theta {
+		for _, h := range []float64{-1e-3, 1e-3} {
+			moved := append([]float64(nil), theta...)
+			moved[i] += h
+			if ll, _, _, _ := ordinalLogLik(res.x, res.y, 3, moved); ll >= res.LogLikelihood {
+				t.Errorf("logLik not maximal in parameter %d", i)
+			}
+		}
+	}
+
+	probs, err := res.PredictProbabilities(insyra.NewDataList(0.5, 2.5), insyra.NewDataList(0, 1))
+	if err != nil {
+		t.Fatalf("PredictProbabilities error: %v", err)
+	}
+	for i := range 2 {
+		row := probs.GetRow(i)
+		sum := 0.0
+		for k := range 3 {
+			sum += row.Get(k).(float64)
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("row %d probabilities sum to %v", i, sum)
+		}
+	}
+	classes, err := res.PredictClass(insyra.NewDataList(0.1, 3.0), insyra.NewDataList(0, 0))
+	if err != nil {
+		t.Fatalf("PredictClass error: %v", err)
+	}
+	if classes.Get(0) != "low" || classes.Get(1) != "high" {
+		t.Errorf("predicted classes %v", classes.Data())
+	}
+	if rows, _ := res.CoefficientTable().Size(); rows != 4 {
+		t.Errorf("coefficient table has %d rows", rows)
+	}
+
+	if _, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Levels: []any{"low", "mid"}}, y, x1); err == nil {
+		t.Errorf("expected error for a response outside Levels")
+	}
+	if _, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Levels: []any{"low", "mid", "high", "top"}}, y, x1); err == nil {
+		t.Errorf("expected error for an empty level")
+	}
+}
+
+// With a single binary predictor both binary logits are saturated, so the
+// Brant statistic has a closed form: the slopes are log odds ratios with
+// variances Σ_g 1/(n_g π_g(1-π_g)) and covariance Σ_g 1/(n_g π_1g(1-π_2g)).
+func TestBrantTestClosedForm(t *testing.T) {
+	counts := [][]int{{10, 12, 8}, {5, 9, 19}}
+	y, x := groupedClasses([]any{1, 2, 3}, counts)
+	res, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Tolerance: 1e-12, MaxIter: 100}, y, x)
+	if err != nil {
+		t.Fatalf("OrdinalLogisticRegression error: %v", err)
+	}
+	brant, err := res.BrantTest()
+	if err != nil {
+		t.Fatalf("BrantTest error: %v", err)
+	}
+	var slope, v [2]float64
+	cov := 0.0
+	var pi [2][2]float64
+	for g, row := range counts {
+		n := float64(row[0] + row[1] + row[2])
+		pi[g][0] = float64(row[1]+row[2]) / n
+		pi[g][1] = float64(row[2]) / n
+		for m := range 2 {
+			v[m] += 1 / (n * pi[g][m] * (1 - pi[g][m]))
+		}
+		cov += 1 / (n * pi[g][0] * (1 - pi[g][1]))
+	}
+	logit := func(p float64) float64 { return math.Log(p / (1 - p)) }
+	for m := range 2 {
+		slope[m] = logit(pi[1][m]) - logit(pi[0][m])
+	}
+	d := slope[0] - slope[1]
+	want := d * d / (v[0] + v[1] - 2*cov)
+	if math.Abs(brant.Omnibus.Statistic-want) > 1e-6 || brant.Omnibus.DF != 1 {
+		t.Errorf("Brant statistic %v (df %d), want %v", brant.Omnibus.Statistic, brant.Omnibus.DF, want)
+	}
+	if len(brant.Terms) != 1 || brant.Terms[0].Name != "g" || math.Abs(brant.Terms[0].Statistic-want) > 1e-6 {
+		t.Errorf("Brant terms %+v", brant.Terms)
+	}
+	if math.Abs(brant.Omnibus.PValue-chiSquaredPValue(want, 1)) > 1e-8 {
+		t.Errorf("Brant p-value %v", brant.Omnibus.PValue)
+	}
+
+	y2, x1, x2 := likertData()
+	res2, err := OrdinalLogisticRegression(OrdinalLogisticOptions{Levels: []any{"low", "mid", "high"}}, y2, x1, x2)
+	if err != nil {
+		t.Fatalf("OrdinalLogisticRegression error: %v", err)
+	}
+	brant2, err := res2.BrantTest()
+	if err != nil {
+		t.Fatalf("BrantTest error: %v", err)
+	}
+	if brant2.Omnibus.DF != 2 || len(brant2.Terms) != 2 || brant2.Terms[1].DF != 1 || math.IsNaN(brant2.Omnibus.Statistic) {
+		t.Errorf("Brant result %+v", brant2)
+	}
+}
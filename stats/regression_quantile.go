@@ -0,0 +1,466 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type QuantileSEMethod string
+
+const (
+	// QuantileSEBootstrap resamples (x, y) pairs, like quantreg's
+	// se = "boot".
+	QuantileSEBootstrap QuantileSEMethod = "bootstrap"
+	// QuantileSEIID assumes i.i.d. errors and estimates the sparsity
+	// 1/f(F⁻¹(τ)) from the residual order statistics with the
+	// Hall-Sheather bandwidth, like quantreg's se = "iid".
+	QuantileSEIID QuantileSEMethod = "iid"
+)
+
+type QuantileRegressionOptions struct {
+	// Tau is the quantile in (0, 1); 0 means 0.5, the median (LAD) fit.
+	Tau float64
+	// SEMethod defaults to QuantileSEBootstrap.
+	SEMethod QuantileSEMethod
+	// Replicates is the number of bootstrap resamples (default 200).
+	Replicates int
+	// Seed and UseSeed make the bootstrap reproducible.
+	Seed    uint64
+	UseSeed bool
+}
+
+// QuantileRegressionResult holds a linear quantile regression fit.
+// Coefficients[0] is the intercept, as in LinearRegressionResult.
+// Objective is the minimised check loss Σρ_τ(r) and PseudoRSquared is
+// Koenker and Machado's R¹ = 1 - Objective / (check loss of the
+// intercept-only fit).
+type QuantileRegressionResult struct {
+	Tau                 float64
+	SEMethod            QuantileSEMethod
+	Coefficients        []float64
+	StandardErrors      []float64
+	TValues             []float64
+	PValues             []float64
+	ConfidenceIntervals [][2]float64
+	Residuals           []float64
+	Objective           float64
+	PseudoRSquared      float64
+}
+
+// QuantileRegression fits the conditional τ-quantile of y as a linear
+// function of the predictors by minimising the check loss exactly: the
+// solution interpolates p observations and is found by simplex-type
+// descent over such bases (as quantreg's "br" method). Inference uses t
+// p-values on n-p degrees of freedom.
+func QuantileRegression(opts QuantileRegressionOptions, dlY insyra.IDataList, dlXs ...insyra.IDataList) (*QuantileRegressionResult, error) {
+	tau := opts.Tau
+	if tau == 0 {
+		tau = 0.5
+	}
+	if !(tau > 0 && tau < 1) {
+		return nil, errors.New("tau must be in (0, 1)")
+	}
+	seMethod := opts.SEMethod
+	if seMethod == "" {
+		seMethod = QuantileSEBootstrap
+	}
+	if seMethod != QuantileSEBootstrap && seMethod != QuantileSEIID {
+		return nil, fmt.Errorf("unsupported quantile SE method %q", seMethod)
+	}
+	reps := opts.Replicates
+	if reps == 0 {
+		reps = 200
+	}
+	if reps < 2 {
+		return nil, errors.New("replicates must be at least 2")
+	}
+	y, X, n, err := linearModelInputs(dlY, dlXs)
+	if err != nil {
+		return nil, err
+	}
+	_, p := X.Dims()
+	beta, err := quantileFit(X, y, tau)
+	if err != nil {
+		return nil, err
+	}
+	resid := linearResiduals(X, y, beta)
+
+	var se []float64
+	switch seMethod {
+	case QuantileSEIID:
+		se, err = quantileIIDStdErrors(X, resid, tau)
+	default:
+		se = quantileBootstrapStdErrors(X, y, tau, reps, resolveSeed(opts.Seed, opts.UseSeed))
+	}
+	if err != nil {
+		return nil, err
+	}
+	rdf := float64(n - p)
+	tv := make([]float64, p)
+	pv := make([]float64, p)
+	for j := range p {
+		tv[j] = beta[j] / se[j]
+		pv[j] = tTwoTailedPValue(tv[j], rdf)
+	}
+
+	obj := checkLoss(resid, tau)
+	ones := mat.NewDense(n, 1, nil)
+	for i := range n {
+		ones.Set(i, 0, 1)
+	}
+	q0, err := quantileFit(ones, y, tau)
+	if err != nil {
+		return nil, err
+	}
+	obj0 := checkLoss(linearResiduals(ones, y, q0), tau)
+	r1 := math.NaN()
+	if obj0 > 0 {
+		r1 = 1 - obj/obj0
+	}
+	return &QuantileRegressionResult{
+		Tau:                 tau,
+		SEMethod:            seMethod,
+		Coefficients:        beta,
+		StandardErrors:      se,
+		TValues:             tv,
+		PValues:             pv,
+		ConfidenceIntervals: buildMultiCoeffCIs(beta, se, rdf),
+		Residuals:           resid,
+		Objective:           obj,
+		PseudoRSquared:      r1,
+	}, nil
+}
+
+// LADRegression is least absolute deviation regression, the median case of
+// QuantileRegression with bootstrap standard errors.
+func LADRegression(dlY insyra.IDataList, dlXs ...insyra.IDataList) (*QuantileRegressionResult, error) {
+	return QuantileRegression(QuantileRegressionOptions{Tau: 0.5}, dlY, dlXs...)
+}
+
+func checkLoss(resid []float64, tau float64) float64 {
+	s := 0.0
+	for _, r := range resid {
+		if r < 0 {
+			s += (tau - 1) * r
+		} else {
+			s += tau * r
+		}
+	}
+	return s
+}
+
+// quantileFit minimises Σρ_τ(y - Xβ). Tied data make degenerate vertices
+// (more than p zero residuals) where edge descent can stall or cycle, so
+// the basis is found for y plus a tiny deterministic perturbation and the
+// coefficients are then solved on the original y; a basis optimal for the
+// perturbed problem stays optimal for the original one.
+func quantileFit(X *mat.Dense, y []float64, tau float64) ([]float64, error) {
+	_, p := X.Dims()
+	scale := 0.0
+	for _, v := range y {
+		scale = math.Max(scale, math.Abs(v))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+	yp := make([]float64, len(y))
+	for i, v := range y {
+		// Fractional parts of multiples of the golden ratio are well spread
+		// and free of simple linear relations.
+		u := math.Mod(float64(i+1)*0.6180339887498949, 1)
+		yp[i] = v + 1e-9*scale*(u-0.5)
+	}
+	basis, err := quantileBasis(X, yp, tau)
+	if err != nil {
+		return nil, err
+	}
+	B := mat.NewDense(p, p, nil)
+	yb := mat.NewVecDense(p, nil)
+	for k, i := range basis {
+		B.SetRow(k, X.RawRowView(i))
+		yb.SetVec(k, y[i])
+	}
+	var beta mat.VecDense
+	if err := beta.SolveVec(B, yb); err != nil {
+		return nil, errors.New("quantile regression basis is singular")
+	}
+	return beta.RawVector().Data, nil
+}
+
+// quantileBasis returns the p observations interpolated by a minimiser of
+// Σρ_τ(y - Xβ). Starting from a basis of small residuals under an IRLS
+// approximation, it moves to the neighbouring basis along the steepest
+// descending edge, taking the exact weighted-median step along it, until
+// no edge descends. At a non-degenerate basis the directional derivative
+// is separable across edges, so that is the optimum.
+func quantileBasis(X *mat.Dense, y []float64, tau float64) ([]int, error) {
+	n, p := X.Dims()
+	basis, err := quantileStartBasis(X, y, tau)
+	if err != nil {
+		return nil, err
+	}
+	inBasis := make([]bool, n)
+	for _, i := range basis {
+		inBasis[i] = true
+	}
+	B := mat.NewDense(p, p, nil)
+	var Binv mat.Dense
+	beta := make([]float64, p)
+	g := make([]float64, n)
+	for iter := 0; iter < 50*n+100; iter++ {
+		yb := make([]float64, p)
+		for k, i := range basis {
+			B.SetRow(k, X.RawRowView(i))
+			yb[k] = y[i]
+		}
+		if err := Binv.Inverse(B); err != nil {
+			return nil, errors.New("quantile regression basis is singular")
+		}
+		bv := mat.NewVecDense(p, beta)
+		bv.MulVec(&Binv, mat.NewVecDense(p, yb))
+		r := linearResiduals(X, y, beta)
+		for _, i := range basis {
+			r[i] = 0
+		}
+
+		bestSlope, bestJ, bestSign := -1e-10, -1, 0.0
+		for j := range p {
+			for i := range n {
+				if inBasis[i] {
+					continue
+				}
+				row := X.RawRowView(i)
+				v := 0.0
+				for a := range p {
+					v += row[a] * Binv.At(a, j)
+				}
+				g[i] = v
+			}
+			for _, sign := range []float64{1, -1} {
+				slope := 1 - tau
+				if sign < 0 {
+					slope = tau
+				}
+				for i := range n {
+					if inBasis[i] {
+						continue
+					}
+					slope += quantileSlope(r[i], -sign*g[i], tau)
+				}
+				if slope < bestSlope {
+					bestSlope, bestJ, bestSign = slope, j, sign
+				}
+			}
+		}
+		if bestJ < 0 {
+			return basis, nil
+		}
+
+		// Walk the breakpoints where non-basis residuals cross zero; each
+		// crossing raises the slope by |rate|.
+		type crossing struct {
+			t    float64
+			i    int
+			rate float64
+		}
+		var cross []crossing
+		for i := range n {
+			if inBasis[i] {
+				continue
+			}
+			row := X.RawRowView(i)
+			v := 0.0
+			for a := range p {
+				v += row[a] * Binv.At(a, bestJ)
+			}
+			rate := -bestSign * v
+			if rate != 0 && r[i]*rate < 0 {
+				cross = append(cross, crossing{t: -r[i] / rate, i: i, rate: rate})
+			}
+		}
+		sort.Slice(cross, func(a, b int) bool { return cross[a].t < cross[b].t })
+		slope := bestSlope
+		enter := -1
+		for _, c := range cross {
+			slope += math.Abs(c.rate)
+			if slope >= 0 {
+				enter = c.i
+				break
+			}
+		}
+		if enter < 0 {
+			return nil, errors.New("quantile regression objective is unbounded")
+		}
+		inBasis[basis[bestJ]] = false
+		basis[bestJ] = enter
+		inBasis[enter] = true
+	}
+	return nil, errors.New("quantile regression did not converge")
+}
+
+// quantileSlope is the directional derivative of ρ_τ(r + c·t) at t = 0+.
+func quantileSlope(r, c, tau float64) float64 {
+	switch {
+	case r > 0 || (r == 0 && c > 0):
+		return tau * c
+	default:
+		return (tau - 1) * c
+	}
+}
+
+// quantileStartBasis runs a few IRLS steps on the check loss and picks the
+// p linearly independent observations with the smallest residuals.
+func quantileStartBasis(X *mat.Dense, y []float64, tau float64) ([]int, error) {
+	n, p := X.Dims()
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	beta, err := solveWeightedNormalEquations(X, w, y, 0)
+	if err != nil {
+		return nil, errors.New("design matrix is singular")
+	}
+	for range 20 {
+		r := linearResiduals(X, y, beta)
+		for i, ri := range r {
+			a := tau
+			if ri < 0 {
+				a = 1 - tau
+			}
+			w[i] = a / math.Max(math.Abs(ri), 1e-6)
+		}
+		next, err := solveWeightedNormalEquations(X, w, y, 0)
+		if err != nil {
+			break
+		}
+		beta = next
+	}
+	r := linearResiduals(X, y, beta)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return math.Abs(r[order[a]]) < math.Abs(r[order[b]]) })
+
+	// Gram-Schmidt keeps rows that add a new direction.
+	var basis []int
+	var q [][]float64
+	for _, i := range order {
+		v := append([]float64(nil), X.RawRowView(i)...)
+		norm0 := math.Sqrt(floats64Dot(v, v))
+		for _, u := range q {
+			d := floats64Dot(v, u)
+			for a := range v {
+				v[a] -= d * u[a]
+			}
+		}
+		norm := math.Sqrt(floats64Dot(v, v))
+		if norm <= 1e-8*math.Max(norm0, 1) {
+			continue
+		}
+		for a := range v {
+			v[a] /= norm
+		}
+		q = append(q, v)
+		basis = append(basis, i)
+		if len(basis) == p {
+			return basis, nil
+		}
+	}
+	return nil, errors.New("design matrix is singular")
+}
+
+func floats64Dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func quantileIIDStdErrors(X *mat.Dense, resid []float64, tau float64) ([]float64, error) {
+	n, p := X.Dims()
+	pz := 0
+	for _, r := range resid {
+		if math.Abs(r) < 3.6e-11 {
+			pz++
+		}
+	}
+	x0 := zQuantile(tau)
+	f0 := math.Exp(-x0*x0/2) / math.Sqrt(2*math.Pi)
+	zc := zQuantile(0.975)
+	bw := math.Pow(float64(n), -1.0/3) * math.Pow(zc, 2.0/3) * math.Pow(1.5*f0*f0/(2*x0*x0+1), 1.0/3)
+	h := max(p+1, int(math.Ceil(float64(n)*bw)))
+	hi := min(h+pz+1, n)
+	if hi-pz < 2 {
+		return nil, errors.New("too few residuals to estimate the sparsity")
+	}
+	byAbs := append([]float64(nil), resid...)
+	sort.Slice(byAbs, func(a, b int) bool { return math.Abs(byAbs[a]) < math.Abs(byAbs[b]) })
+	ord := append([]float64(nil), byAbs[pz:hi]...)
+	sort.Float64s(ord)
+	xt := mat.NewDense(len(ord), 2, nil)
+	for k := range ord {
+		xt.Set(k, 0, 1)
+		xt.Set(k, 1, float64(pz+1+k)/float64(n-p))
+	}
+	sb, err := quantileFit(xt, ord, 0.5)
+	if err != nil {
+		return nil, err
+	}
+	sparsity := sb[1]
+
+	var xtx, inv mat.Dense
+	xtx.Mul(X.T(), X)
+	if err := inv.Inverse(&xtx); err != nil {
+		return nil, errors.New("design matrix is singular")
+	}
+	se := make([]float64, p)
+	for j := range p {
+		se[j] = math.Abs(sparsity) * math.Sqrt(inv.At(j, j)*tau*(1-tau))
+	}
+	return se, nil
+}
+
+func quantileBootstrapStdErrors(X *mat.Dense, y []float64, tau float64, reps int, seed uint64) []float64 {
+	n, p := X.Dims()
+	draws := make([][]float64, reps)
+	runParallelChunks(reps, func(start, end int) {
+		Xb := mat.NewDense(n, p, nil)
+		yb := make([]float64, n)
+		for b := start; b < end; b++ {
+			rng := replicateRNG(seed, uint64(b))
+			for i := range n {
+				k := rng.IntN(n)
+				Xb.SetRow(i, X.RawRowView(k))
+				yb[i] = y[k]
+			}
+			if beta, err := quantileFit(Xb, yb, tau); err == nil {
+				draws[b] = beta
+			}
+		}
+	})
+	se := make([]float64, p)
+	for j := range p {
+		var sum, sumSq float64
+		m := 0
+		for _, d := range draws {
+			if d != nil {
+				sum += d[j]
+				sumSq += d[j] * d[j]
+				m++
+			}
+		}
+		se[j] = math.NaN()
+		if m > 1 {
+			mean := sum / float64(m)
+			se[j] = math.Sqrt((sumSq - float64(m)*mean*mean) / float64(m-1))
+		}
+	}
+	return se
+}
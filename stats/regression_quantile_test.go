@@ -0,0 +1,105 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestQuantileRegressionStacklossMedian(t *testing.T) {
+	y, xs := stacklossData()
+	res, err := QuantileRegression(QuantileRegressionOptions{SEMethod: QuantileSEIID}, y, xs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// quantreg::rq(stack.loss ~ ., data = stackloss).
+	want := []float64{-39.68985507, 0.83188406, 0.57391304, -0.06086957}
+	for j := range want {
+		if math.Abs(res.Coefficients[j]-want[j]) > 1e-7 {
+			t.Fatalf("coef[%d] = %.8f, want %.8f", j, res.Coefficients[j], want[j])
+		}
+	}
+	for j, se := range res.StandardErrors {
+		if !(se > 0) || math.IsInf(se, 0) {
+			t.Fatalf("se[%d] = %v", j, se)
+		}
+	}
+	if !(res.PseudoRSquared > 0 && res.PseudoRSquared < 1) {
+		t.Fatalf("pseudo R² = %v", res.PseudoRSquared)
+	}
+}
+
+// The check loss is minimised at a basis of p interpolated points, so the
+// exhaustive minimum over all 4-point bases is the true optimum.
+func TestQuantileRegressionMatchesExhaustiveSearch(t *testing.T) {
+	y, xs := stacklossData()
+	yv, X, n, err := linearModelInputs(y, xs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tau := range []float64{0.1, 0.25, 0.5, 0.75, 0.9} {
+		res, err := QuantileRegression(QuantileRegressionOptions{Tau: tau, SEMethod: QuantileSEIID}, y, xs...)
+		if err != nil {
+			t.Fatalf("tau %v: %v", tau, err)
+		}
+		best := math.Inf(1)
+		B := mat.NewDense(4, 4, nil)
+		yb := mat.NewVecDense(4, nil)
+		for a := 0; a < n; a++ {
+			for b := a + 1; b < n; b++ {
+				for c := b + 1; c < n; c++ {
+					for d := c + 1; d < n; d++ {
+						for k, i := range []int{a, b, c, d} {
+							B.SetRow(k, X.RawRowView(i))
+							yb.SetVec(k, yv[i])
+						}
+						var beta mat.VecDense
+						if beta.SolveVec(B, yb) != nil {
+							continue
+						}
+						best = math.Min(best, checkLoss(linearResiduals(X, yv, beta.RawVector().Data), tau))
+					}
+				}
+			}
+		}
+		if res.Objective > best+1e-8 {
+			t.Fatalf("tau %v: objective %.10f, exhaustive minimum %.10f", tau, res.Objective, best)
+		}
+	}
+}
+
+func TestQuantileRegressionBootstrapSeeded(t *testing.T) {
+	y, xs := stacklossData()
+	opts := QuantileRegressionOptions{Tau: 0.75, Replicates: 50, Seed: 7, UseSeed: true}
+	a, err := QuantileRegression(opts, y, xs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := QuantileRegression(opts, y, xs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := range a.StandardErrors {
+		if a.StandardErrors[j] != b.StandardErrors[j] || !(a.StandardErrors[j] > 0) {
+			t.Fatalf("se[%d] = %v and %v", j, a.StandardErrors[j], b.StandardErrors[j])
+		}
+	}
+	if a.SEMethod != QuantileSEBootstrap {
+		t.Fatalf("SE method = %q", a.SEMethod)
+	}
+}
+
+func TestQuantileRegressionValidation(t *testing.T) {
+	y, xs := stacklossData()
+	for _, opts := range []QuantileRegressionOptions{
+		{Tau: 1},
+		{Tau: -0.2},
+		{SEMethod: "rank"},
+		{Replicates: 1},
+	} {
+		if _, err := QuantileRegression(opts, y, xs...); err == nil {
+			t.Fatalf("expected error for %+v", opts)
+		}
+	}
+}
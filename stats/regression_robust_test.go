@@ -0,0 +1,81 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// stacklossData is R's datasets::stackloss.
+func stacklossData() (y insyra.IDataList, xs []insyra.IDataList) {
+	air := insyra.NewDataList(80, 80, 75, 62, 62, 62, 62, 62, 58, 58, 58, 58, 58, 58, 50, 50, 50, 50, 50, 56, 70)
+	water := insyra.NewDataList(27, 27, 25, 24, 22, 23, 24, 24, 23, 18, 18, 17, 18, 19, 18, 18, 19, 19, 20, 20, 20)
+	acid := insyra.NewDataList(89, 88, 90, 87, 87, 87, 93, 93, 87, 80, 89, 88, 82, 93, 89, 86, 72, 79, 80, 82, 91)
+	y = insyra.NewDataList(42, 37, 37, 28, 18, 18, 19, 20, 15, 14, 14, 13, 11, 12, 8, 7, 8, 8, 9, 15, 15)
+	return y, []insyra.IDataList{air, water, acid}
+}
+
+func TestRobustRegressionStackloss(t *testing.T) {
+	y, xs := stacklossData()
+	// MASS::rlm(stack.loss ~ ., stackloss) and psi = psi.bisquare.
+	cases := []struct {
+		method RobustMethod
+		coef   []float64
+		scale  float64
+	}{
+		{RobustHuber, []float64{-41.0265311, 0.8293739, 0.9261082, -0.1278492}, 2.441},
+		{RobustBisquare, []float64{-42.2853, 0.9275, 0.6507, -0.1123}, 2.282},
+	}
+	for _, tc := range cases {
+		res, err := RobustRegression(RobustRegressionOptions{Method: tc.method}, y, xs...)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.method, err)
+		}
+		if !res.Converged {
+			t.Fatalf("%s: did not converge", tc.method)
+		}
+		for j, want := range tc.coef {
+			if math.Abs(res.Coefficients[j]-want) > 1e-4 {
+				t.Fatalf("%s coef[%d] = %.7f, want %.7f", tc.method, j, res.Coefficients[j], want)
+			}
+		}
+		if math.Abs(res.Scale-tc.scale) > 1e-3 {
+			t.Fatalf("%s scale = %.5f, want %.3f", tc.method, res.Scale, tc.scale)
+		}
+	}
+}
+
+func TestRobustRegressionDownweightsOutlier(t *testing.T) {
+	x := insyra.NewDataList(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12)
+	y := insyra.NewDataList(2.1, 3.9, 6.2, 7.8, 10.1, 12.0, 13.8, 16.1, 18.0, 19.9, 22.2, 90)
+	lm, err := LinearRegression(y, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []RobustMethod{RobustHuber, RobustBisquare} {
+		res, err := RobustRegression(RobustRegressionOptions{Method: m}, y, x)
+		if err != nil {
+			t.Fatalf("%s: %v", m, err)
+		}
+		if math.Abs(res.Coefficients[1]-2) > 0.1 || math.Abs(lm.Coefficients[1]-2) < 1 {
+			t.Fatalf("%s slope = %.4f (OLS %.4f), want near 2", m, res.Coefficients[1], lm.Coefficients[1])
+		}
+		if res.Weights[11] >= res.Weights[0] {
+			t.Fatalf("%s outlier weight %.4f not below %.4f", m, res.Weights[11], res.Weights[0])
+		}
+	}
+}
+
+func TestRobustRegressionValidation(t *testing.T) {
+	y, xs := stacklossData()
+	if _, err := RobustRegression(RobustRegressionOptions{Method: "cauchy"}, y, xs...); err == nil {
+		t.Fatal("expected error for unknown method")
+	}
+	if _, err := RobustRegression(RobustRegressionOptions{TuningConstant: -1}, y, xs...); err == nil {
+		t.Fatal("expected error for negative tuning constant")
+	}
+	if _, err := RobustRegression(RobustRegressionOptions{}, y); err == nil {
+		t.Fatal("expected error without predictors")
+	}
+}
@@ -0,0 +1,340 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// CronbachAlphaResult holds internal-consistency statistics for a scale.
+// ItemStatistics has one row per item with columns Item, Mean, SD,
+// ItemTotalR (correlation with the total score), CorrectedItemTotalR
+// (correlation with the total of the other items) and AlphaIfDeleted.
+type CronbachAlphaResult struct {
+	Alpha             float64 // from the item covariances
+	StandardizedAlpha float64 // from the item correlations
+	AverageR          float64 // mean inter-item correlation
+	N                 int
+	NItems            int
+	ItemStatistics    *insyra.DataTable
+}
+
+// CronbachAlpha computes Cronbach's alpha for the items in the columns of
+// dataTable (rows are respondents), as psych::alpha does for complete
+// data. Reverse-keyed items must be recoded first.
+func CronbachAlpha(dataTable insyra.IDataTable) (*CronbachAlphaResult, error) {
+	cols, names, err := reliabilityItems(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	k, n := len(cols), len(cols[0])
+	cov := itemCovariances(cols)
+	alpha := alphaFromCovariance(cov, nil)
+
+	rSum := 0.0
+	for i := range k {
+		for j := range k {
+			if i != j {
+				rSum += cov[i][j] / math.Sqrt(cov[i][i]*cov[j][j])
+			}
+		}
+	}
+	avgR := rSum / float64(k*(k-1))
+	stdAlpha := float64(k) * avgR / (1 + float64(k-1)*avgR)
+
+	total := make([]float64, n)
+	for _, c := range cols {
+		for i, v := range c {
+			total[i] += v
+		}
+	}
+	colNames := []string{"Item", "Mean", "SD", "ItemTotalR", "CorrectedItemTotalR", "AlphaIfDeleted"}
+	lists := make([]*insyra.DataList, len(colNames))
+	for i, name := range colNames {
+		lists[i] = insyra.NewDataList().SetName(name)
+	}
+	rest := make([]float64, n)
+	for j, c := range cols {
+		for i := range n {
+			rest[i] = total[i] - c[i]
+		}
+		mean, sd := meanSD(c)
+		drop := math.NaN()
+		if k > 2 {
+			drop = alphaFromCovariance(cov, map[int]bool{j: true})
+		}
+		// A constant item has no correlation; pearsonOnSlices returns NaN
+		// with its error, and NaN is what the table reports.
+		itemTotal, _, _ := pearsonOnSlices(c, total)
+		corrected, _, _ := pearsonOnSlices(c, rest)
+		for col, v := range []any{names[j], mean, sd, itemTotal, corrected, drop} {
+			lists[col].Append(v)
+		}
+	}
+	return &CronbachAlphaResult{
+		Alpha:             alpha,
+		StandardizedAlpha: stdAlpha,
+		AverageR:          avgR,
+		N:                 n,
+		NItems:            k,
+		ItemStatistics:    insyra.NewDataTable(lists...),
+	}, nil
+}
+
+type SplitHalfOptions struct {
+	// Samples is the number of random splits drawn when there are more
+	// than 16 items (default 10000); with 16 or fewer every split is used.
+	Samples int
+	Seed    uint64
+	UseSeed bool
+}
+
+// SplitHalfResult reports split-half reliability. The odd-even split puts
+// items 1, 3, 5, ... in one half. Each split's coefficient is Guttman's
+// 2(1 - (V_A + V_B) / V_total); over all splits its maximum is Guttman's
+// λ4 and its minimum Revelle's β.
+type SplitHalfResult struct {
+	OddEvenR             float64 // correlation of the two half scores
+	OddEvenSpearmanBrown float64 // 2r / (1 + r)
+	OddEvenGuttman       float64
+	MaxSplitHalf         float64
+	MeanSplitHalf        float64
+	MinSplitHalf         float64
+	Splits               int
+	Exhaustive           bool
+}
+
+// SplitHalf computes split-half reliability of the items in the columns of
+// dataTable. Coefficients use item covariances (psych::splitHalf with
+// covar = TRUE).
+func SplitHalf(dataTable insyra.IDataTable, opts ...SplitHalfOptions) (*SplitHalfResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt SplitHalfOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	samples := opt.Samples
+	if samples == 0 {
+		samples = 10000
+	}
+	if samples < 1 {
+		return nil, errors.New("samples must be positive")
+	}
+	cols, _, err := reliabilityItems(dataTable)
+	if err != nil {
+		return nil, err
+	}
+	k := len(cols)
+	cov := itemCovariances(cols)
+	vt := 0.0
+	for i := range k {
+		for j := range k {
+			vt += cov[i][j]
+		}
+	}
+	// guttman returns the coefficient and the half variances for the split
+	// with inA marking the first half.
+	guttman := func(inA []bool) (coef, va, vb, cab float64) {
+		for i := range k {
+			for j := range k {
+				switch {
+				case inA[i] && inA[j]:
+					va += cov[i][j]
+				case !inA[i] && !inA[j]:
+					vb += cov[i][j]
+				default:
+					cab += cov[i][j]
+				}
+			}
+		}
+		return 2 * (1 - (va+vb)/vt), va, vb, cab / 2
+	}
+
+	inA := make([]bool, k)
+	for i := 0; i < k; i += 2 {
+		inA[i] = true
+	}
+	g, va, vb, cab := guttman(inA)
+	r := cab / math.Sqrt(va*vb)
+	res := &SplitHalfResult{
+		OddEvenR:             r,
+		OddEvenSpearmanBrown: 2 * r / (1 + r),
+		OddEvenGuttman:       g,
+		MaxSplitHalf:         math.Inf(-1),
+		MinSplitHalf:         math.Inf(1),
+	}
+	sum := 0.0
+	record := func(c float64) {
+		res.MaxSplitHalf = math.Max(res.MaxSplitHalf, c)
+		res.MinSplitHalf = math.Min(res.MinSplitHalf, c)
+		sum += c
+		res.Splits++
+	}
+	half := k / 2
+	if k <= 16 {
+		res.Exhaustive = true
+		for mask := uint32(0); mask < 1<<k; mask++ {
+			// With an even number of items each split and its mirror image
+			// are the same; keep the one containing the first item.
+			if bits.OnesCount32(mask) != half || (k%2 == 0 && mask&1 == 0) {
+				continue
+			}
+			for i := range k {
+				inA[i] = mask&(1<<i) != 0
+			}
+			c, _, _, _ := guttman(inA)
+			record(c)
+		}
+	} else {
+		rng := replicateRNG(resolveSeed(opt.Seed, opt.UseSeed), 0)
+		for range samples {
+			perm := rng.Perm(k)
+			for i := range k {
+				inA[i] = false
+			}
+			for _, i := range perm[:half] {
+				inA[i] = true
+			}
+			c, _, _, _ := guttman(inA)
+			record(c)
+		}
+	}
+	res.MeanSplitHalf = sum / float64(res.Splits)
+	return res, nil
+}
+
+// McDonaldOmega returns omega total from a fitted factor model:
+// 1'ΛΦΛ'1 / (1'ΛΦΛ'1 + Σψ), with Φ the factor correlations (identity for
+// orthogonal solutions) and ψ the uniquenesses. With one factor this is
+// (Σλ)² / ((Σλ)² + Σψ). Items should be keyed in the same direction.
+func McDonaldOmega(model *FactorModel) (float64, error) {
+	if model == nil || model.Loadings == nil || model.Uniquenesses == nil {
+		return math.NaN(), errors.New("factor model is nil")
+	}
+	loadings, _, err := numericMatrixFromTable(model.Loadings)
+	if err != nil {
+		return math.NaN(), err
+	}
+	uniq, _, err := numericMatrixFromTable(model.Uniquenesses)
+	if err != nil {
+		return math.NaN(), err
+	}
+	m := len(loadings[0])
+	if len(uniq) != len(loadings) {
+		return math.NaN(), errors.New("loadings and uniquenesses do not match")
+	}
+	phi := make([][]float64, m)
+	for a := range m {
+		phi[a] = make([]float64, m)
+		phi[a][a] = 1
+	}
+	if model.Phi != nil {
+		if phi, _, err = numericMatrixFromTable(model.Phi); err != nil {
+			return math.NaN(), err
+		}
+		if len(phi) != m {
+			return math.NaN(), errors.New("factor correlation matrix does not match the loadings")
+		}
+	}
+	colSums := make([]float64, m)
+	psi := 0.0
+	for i, row := range loadings {
+		for a, v := range row {
+			colSums[a] += v
+		}
+		psi += uniq[i][0]
+	}
+	common := 0.0
+	for a := range m {
+		for b := range m {
+			common += colSums[a] * phi[a][b] * colSums[b]
+		}
+	}
+	return common / (common + psi), nil
+}
+
+// reliabilityItems reads the item columns of dataTable.
+func reliabilityItems(dataTable insyra.IDataTable) ([][]float64, []string, error) {
+	if dataTable == nil {
+		return nil, nil, errors.New("data table is nil")
+	}
+	rows, _, err := numericMatrixFromTable(dataTable)
+	if err != nil {
+		return nil, nil, err
+	}
+	k := len(rows[0])
+	if k < 2 {
+		return nil, nil, errors.New("at least two items are required")
+	}
+	if len(rows) < 2 {
+		return nil, nil, errors.New("at least two respondents are required")
+	}
+	names := dataTable.ColNames()
+	cols := make([][]float64, k)
+	for j := range k {
+		cols[j] = make([]float64, len(rows))
+		for i, row := range rows {
+			cols[j][i] = row[j]
+		}
+		if j >= len(names) || names[j] == "" {
+			if j >= len(names) {
+				names = append(names, "")
+			}
+			names[j] = fmt.Sprintf("Item%d", j+1)
+		}
+	}
+	for j, c := range cols {
+		if _, sd := meanSD(c); sd == 0 {
+			return nil, nil, fmt.Errorf("item %q has zero variance", names[j])
+		}
+	}
+	return cols, names[:k], nil
+}
+
+func itemCovariances(cols [][]float64) [][]float64 {
+	k, n := len(cols), len(cols[0])
+	means := make([]float64, k)
+	for j, c := range cols {
+		means[j] = sampleMean(c)
+	}
+	cov := make([][]float64, k)
+	for a := range k {
+		cov[a] = make([]float64, k)
+	}
+	for a := range k {
+		for b := 0; b <= a; b++ {
+			s := 0.0
+			for i := range n {
+				s += (cols[a][i] - means[a]) * (cols[b][i] - means[b])
+			}
+			cov[a][b] = s / float64(n-1)
+			cov[b][a] = cov[a][b]
+		}
+	}
+	return cov
+}
+
+// alphaFromCovariance is k/(k-1) (1 - Σσᵢ² / σ²_total) over the items not
+// in drop.
+func alphaFromCovariance(cov [][]float64, drop map[int]bool) float64 {
+	k := 0
+	diag, total := 0.0, 0.0
+	for i := range cov {
+		if drop[i] {
+			continue
+		}
+		k++
+		diag += cov[i][i]
+		for j := range cov {
+			if !drop[j] {
+				total += cov[i][j]
+			}
+		}
+	}
+	return float64(k) / float64(k-1) * (1 - diag/total)
+}
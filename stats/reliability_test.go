@@ -0,0 +1,225 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/stat"
+)
+
+// reliabilityScaleRows simulates five Likert-like items driven by one trait.
+func reliabilityScaleRows(n int) [][]float64 {
+	rng := rand.New(rand.NewPCG(7, 11))
+	rows := make([][]float64, n)
+	for i := range rows {
+		trait := rng.NormFloat64()
+		rows[i] = make([]float64, 5)
+		for j := range rows[i] {
+			v := math.Round(3 + (0.5+0.2*float64(j))*trait + rng.NormFloat64())
+			rows[i][j] = math.Max(1, math.Min(5, v))
+		}
+	}
+	return rows
+}
+
+func dropColumn(rows [][]float64, j int) [][]float64 {
+	out := make([][]float64, len(rows))
+	for i, r := range rows {
+		out[i] = append(append([]float64(nil), r[:j]...), r[j+1:]...)
+	}
+	return out
+}
+
+func TestCronbachAlpha(t *testing.T) {
+	rows := reliabilityScaleRows(150)
+	res, err := CronbachAlpha(dataTableFromMatrix(rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Direct definition: k/(k-1) (1 - Σ var(item) / var(total)).
+	k := len(rows[0])
+	total := make([]float64, len(rows))
+	itemVar := 0.0
+	for j := range k {
+		col := make([]float64, len(rows))
+		for i, r := range rows {
+			col[i] = r[j]
+			total[i] += r[j]
+		}
+		_, sd := meanSD(col)
+		itemVar += sd * sd
+	}
+	_, sdTotal := meanSD(total)
+	want := float64(k) / float64(k-1) * (1 - itemVar/(sdTotal*sdTotal))
+	if math.Abs(res.Alpha-want) > 1e-12 {
+		t.Fatalf("alpha = %v, want %v", res.Alpha, want)
+	}
+	if res.NItems != k || res.N != len(rows) {
+		t.Fatalf("sizes = %d items, %d rows", res.NItems, res.N)
+	}
+	if res.StandardizedAlpha <= 0 || res.StandardizedAlpha >= 1 || res.AverageR <= 0 {
+		t.Fatalf("standardized alpha %v, average r %v", res.StandardizedAlpha, res.AverageR)
+	}
+
+	for j := range k {
+		dropped, err := CronbachAlpha(dataTableFromMatrix(dropColumn(rows, j)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := res.ItemStatistics.GetElementByNumberIndex(j, 5).(float64)
+		if math.Abs(got-dropped.Alpha) > 1e-12 {
+			t.Fatalf("item %d: alpha if deleted = %v, want %v", j, got, dropped.Alpha)
+		}
+		col := make([]float64, len(rows))
+		rest := make([]float64, len(rows))
+		for i, r := range rows {
+			col[i] = r[j]
+			rest[i] = total[i] - r[j]
+		}
+		corrected := res.ItemStatistics.GetElementByNumberIndex(j, 4).(float64)
+		if math.Abs(corrected-stat.Correlation(col, rest, nil)) > 1e-12 {
+			t.Fatalf("item %d: corrected item-total r = %v", j, corrected)
+		}
+		if raw := res.ItemStatistics.GetElementByNumberIndex(j, 3).(float64); raw <= corrected {
+			t.Fatalf("item %d: item-total r %v should exceed corrected %v", j, raw, corrected)
+		}
+	}
+}
+
+func TestSplitHalf(t *testing.T) {
+	rows := reliabilityScaleRows(150)
+	twoItems := make([][]float64, len(rows))
+	for i, r := range rows {
+		twoItems[i] = r[:2]
+	}
+	alpha, err := CronbachAlpha(dataTableFromMatrix(twoItems))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := SplitHalf(dataTableFromMatrix(twoItems))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With two items the only split is item against item and Guttman's
+	// coefficient reduces to alpha.
+	if res.Splits != 1 || math.Abs(res.OddEvenGuttman-alpha.Alpha) > 1e-12 {
+		t.Fatalf("splits = %d, guttman = %v, alpha = %v", res.Splits, res.OddEvenGuttman, alpha.Alpha)
+	}
+
+	four := make([][]float64, len(rows))
+	for i, r := range rows {
+		four[i] = r[:4]
+	}
+	res, err = SplitHalf(dataTableFromMatrix(four))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exhaustive || res.Splits != 3 {
+		t.Fatalf("4 items: exhaustive = %v, splits = %d; want 3 distinct splits", res.Exhaustive, res.Splits)
+	}
+	if res.MinSplitHalf > res.OddEvenGuttman || res.OddEvenGuttman > res.MaxSplitHalf {
+		t.Fatalf("odd-even %v outside [%v, %v]", res.OddEvenGuttman, res.MinSplitHalf, res.MaxSplitHalf)
+	}
+	if math.Abs(res.OddEvenSpearmanBrown-2*res.OddEvenR/(1+res.OddEvenR)) > 1e-12 {
+		t.Fatalf("Spearman-Brown = %v for r = %v", res.OddEvenSpearmanBrown, res.OddEvenR)
+	}
+	// With an even number of items the mean of Guttman's coefficient over
+	// all equal splits is exactly alpha (Cronbach, 1951).
+	alpha, _ = CronbachAlpha(dataTableFromMatrix(four))
+	if math.Abs(res.MeanSplitHalf-alpha.Alpha) > 1e-12 {
+		t.Fatalf("mean split-half %v, want alpha %v", res.MeanSplitHalf, alpha.Alpha)
+	}
+
+	wide := make([][]float64, len(rows))
+	for i, r := range rows {
+		wide[i] = append(append(append(append([]float64(nil), r...), r...), r...), r...)
+		for j := range wide[i] {
+			wide[i][j] += float64((i*7+j*3)%5) / 10
+		}
+	}
+	opt := SplitHalfOptions{Samples: 200, Seed: 3, UseSeed: true}
+	a, err := SplitHalf(dataTableFromMatrix(wide), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := SplitHalf(dataTableFromMatrix(wide), opt)
+	if a.Exhaustive || a.Splits != 200 || a.MeanSplitHalf != b.MeanSplitHalf {
+		t.Fatalf("sampled splits: exhaustive = %v, splits = %d, means %v vs %v", a.Exhaustive, a.Splits, a.MeanSplitHalf, b.MeanSplitHalf)
+	}
+}
+
+func TestMcDonaldOmega(t *testing.T) {
+	column := func(name string, v ...any) *insyra.DataTable {
+		return insyra.NewDataTable(insyra.NewDataList(v...).SetName(name))
+	}
+	model := &FactorModel{FactorAnalysisResult: FactorAnalysisResult{
+		Loadings:     column("F1", 0.8, 0.7, 0.6, 0.5),
+		Uniquenesses: column("Uniqueness", 0.36, 0.51, 0.64, 0.75),
+	}}
+	got, err := McDonaldOmega(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (Σλ)² / ((Σλ)² + Σψ) = 6.76 / (6.76 + 2.26)
+	if want := 6.76 / 9.02; math.Abs(got-want) > 1e-12 {
+		t.Fatalf("omega = %v, want %v", got, want)
+	}
+
+	// Two correlated factors: 1'ΛΦΛ'1 = 1.5² + 1.5² + 2(0.5)(1.5)(1.5).
+	loadings := insyra.NewDataTable(
+		insyra.NewDataList(0.8, 0.7, 0.0, 0.0).SetName("F1"),
+		insyra.NewDataList(0.0, 0.0, 0.9, 0.6).SetName("F2"),
+	)
+	phi := insyra.NewDataTable(
+		insyra.NewDataList(1.0, 0.5).SetName("F1"),
+		insyra.NewDataList(0.5, 1.0).SetName("F2"),
+	)
+	model = &FactorModel{FactorAnalysisResult: FactorAnalysisResult{
+		Loadings:     loadings,
+		Uniquenesses: column("Uniqueness", 0.36, 0.51, 0.19, 0.64),
+		Phi:          phi,
+	}}
+	got, err = McDonaldOmega(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 6.75 / (6.75 + 1.7); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("oblique omega = %v, want %v", got, want)
+	}
+
+	fitted, err := FactorAnalysis(dataTableFromMatrix(reliabilityScaleRows(300)), FactorAnalysisOptions{
+		Count:      FactorCountSpec{Method: FactorCountFixed, FixedK: 1},
+		Extraction: FactorExtractionMINRES,
+		Rotation:   FactorRotationOptions{Method: FactorRotationNone},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	omega, err := McDonaldOmega(fitted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if omega <= 0 || omega >= 1 {
+		t.Fatalf("omega from fitted model = %v", omega)
+	}
+}
+
+func TestReliabilityValidation(t *testing.T) {
+	if _, err := CronbachAlpha(nil); err == nil {
+		t.Fatal("expected error for nil table")
+	}
+	if _, err := CronbachAlpha(dataTableFromMatrix([][]float64{{1}, {2}, {3}})); err == nil {
+		t.Fatal("expected error for a single item")
+	}
+	if _, err := CronbachAlpha(dataTableFromMatrix([][]float64{{1, 2}, {1, 3}, {1, 4}})); err == nil {
+		t.Fatal("expected error for a constant item")
+	}
+	if _, err := SplitHalf(dataTableFromMatrix(reliabilityScaleRows(20)), SplitHalfOptions{}, SplitHalfOptions{}); err == nil {
+		t.Fatal("expected error for more than one options value")
+	}
+	if _, err := McDonaldOmega(nil); err == nil {
+		t.Fatal("expected error for nil model")
+	}
+}
@@ -0,0 +1,853 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats/internal/fa"
+	"gonum.org/v1/gonum/mat"
+)
+
+type SEMOptions struct {
+	// StdLV identifies each latent variable by fixing its (residual)
+	// variance to 1 and freeing all its loadings, like lavaan's
+	// std.lv = TRUE. By default the first indicator's loading is fixed to 1.
+	StdLV bool
+	// MaxIter caps the L-BFGS-B iterations (default 1000).
+	MaxIter int
+}
+
+// SEMParameter is one row of the parameter table, named as in lavaan:
+// Op is "=~" for loadings, "~" for regressions and "~~" for variances and
+// covariances. Fixed parameters have Free false and NaN inference. StdAll
+// is the completely standardised estimate (lavaan's std.all); covariances
+// between residuals are reported as residual correlations.
+type SEMParameter struct {
+	LHS                string
+	Op                 string
+	RHS                string
+	Free               bool
+	Estimate           float64
+	StdError           float64
+	ZValue             float64
+	PValue             float64
+	ConfidenceInterval [2]float64
+	StdAll             float64
+}
+
+// SEMFitIndices summarises model fit. The baseline model is the
+// independence model, keeping the covariances among exogenous observed
+// predictors free. RMSEACI is the 90% interval.
+type SEMFitIndices struct {
+	ChiSquare         float64
+	DF                int
+	PValue            float64
+	BaselineChiSquare float64
+	BaselineDF        int
+	CFI               float64
+	TLI               float64
+	RMSEA             float64
+	RMSEACI           [2]float64
+	SRMR              float64
+	LogLikelihood     float64
+	AIC               float64
+	BIC               float64
+	NumParameters     int
+}
+
+type SEMResult struct {
+	ObservedVariables []string
+	LatentVariables   []string
+	Parameters        []SEMParameter
+	Fit               SEMFitIndices
+	// SampleCovariance uses the divisor N, as the ML discrepancy does.
+	SampleCovariance  [][]float64
+	ImpliedCovariance [][]float64
+	N                 int
+	Iterations        int
+	Converged         bool
+}
+
+// semParam is one entry of the RAM matrices: A holds directed paths
+// (A[i][j] is the effect of j on i) and S the (residual) covariances.
+type semParam struct {
+	lhs, op, rhs string
+	sym          bool // in S rather than A
+	row, col     int
+	free         bool
+	value        float64
+}
+
+type semModel struct {
+	observed, latents []string
+	nObs, nAll        int
+	params            []semParam
+	free              []int // indices into params, in theta order
+	exo               []int // exogenous observed predictors
+	sample            *mat.SymDense
+	logDetS           float64
+}
+
+// CFA fits a confirmatory factor analysis. It is SEM restricted to
+// measurement (=~) and covariance (~~) statements.
+func CFA(dataTable insyra.IDataTable, model string, opts SEMOptions) (*SEMResult, error) {
+	stmts, err := parseSEMModel(model)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stmts {
+		if st.op == "~" {
+			return nil, errors.New("CFA does not take regressions; use SEM")
+		}
+	}
+	return fitSEM(dataTable, stmts, opts)
+}
+
+// SEM fits a structural equation model given in lavaan-style syntax (see
+// the documentation for the supported subset) by maximum likelihood on
+// the sample covariance matrix. Defaults follow lavaan's sem(): residual
+// variances of all variables, covariances among exogenous latent
+// variables, among exogenous observed predictors and among the residuals
+// of pure outcome variables are free. Estimation uses L-BFGS-B with the
+// analytic gradient followed by Fisher scoring, and standard errors come
+// from the expected information.
+func SEM(dataTable insyra.IDataTable, model string, opts SEMOptions) (*SEMResult, error) {
+	stmts, err := parseSEMModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return fitSEM(dataTable, stmts, opts)
+}
+
+func fitSEM(dataTable insyra.IDataTable, stmts []semStatement, opts SEMOptions) (*SEMResult, error) {
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 1000
+	}
+	m, n, err := newSEMModel(dataTable, stmts, opts.StdLV)
+	if err != nil {
+		return nil, err
+	}
+	p := m.nObs
+	q := len(m.free)
+	moments := p * (p + 1) / 2
+	if q > moments {
+		return nil, fmt.Errorf("model is not identified: %d free parameters for %d sample moments", q, moments)
+	}
+
+	theta := make([]float64, q)
+	for k, idx := range m.free {
+		theta[k] = m.params[idx].value
+	}
+	lower := make([]float64, q)
+	upper := make([]float64, q)
+	for k := range q {
+		lower[k], upper[k] = math.Inf(-1), math.Inf(1)
+	}
+	res, err := fa.MinimizeBounded(theta, lower, upper, m.discrepancy, m.gradient, fa.BoundedOptions{
+		MaxIter: maxIter,
+		Factr:   10,
+	})
+	if err != nil {
+		return nil, err
+	}
+	theta = res.X
+	iterations := res.Iters
+	converged := res.Converged
+
+	// Fisher scoring sharpens the optimum and supplies the information.
+	var info *mat.SymDense
+	for range 50 {
+		f := m.discrepancy(theta)
+		g := make([]float64, q)
+		m.gradient(g, theta)
+		info = m.information(theta)
+		if info == nil {
+			return nil, errors.New("model is not identified: information matrix is singular")
+		}
+		var step mat.VecDense
+		var ic mat.Cholesky
+		if !ic.Factorize(info) {
+			return nil, errors.New("model is not identified: information matrix is singular")
+		}
+		if err := ic.SolveVecTo(&step, mat.NewVecDense(q, g)); err != nil {
+			return nil, err
+		}
+		done := true
+		for k := range q {
+			if math.Abs(step.AtVec(k)) > 1e-10*(1+math.Abs(theta[k])) {
+				done = false
+			}
+		}
+		if done {
+			converged = true
+			break
+		}
+		next := make([]float64, q)
+		accepted := false
+		for t := 1.0; t > 1e-6; t /= 2 {
+			for k := range q {
+				next[k] = theta[k] - t*step.AtVec(k)
+			}
+			if m.discrepancy(next) <= f {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			break
+		}
+		theta = append([]float64(nil), next...)
+		iterations++
+	}
+	info = m.information(theta)
+	var cov mat.Dense
+	if info == nil || cov.Inverse(info) != nil {
+		return nil, errors.New("model is not identified: information matrix is singular")
+	}
+	cov.Scale(2/float64(n), &cov)
+
+	_, sigmaAll, sigma, ok := m.implied(theta)
+	if !ok {
+		return nil, errors.New("model-implied covariance matrix is not positive definite")
+	}
+	fmin := m.discrepancy(theta)
+	result := &SEMResult{
+		ObservedVariables: m.observed,
+		LatentVariables:   m.latents,
+		N:                 n,
+		Iterations:        iterations,
+		Converged:         converged,
+		SampleCovariance:  symRows(m.sample),
+		ImpliedCovariance: symRows(sigma),
+	}
+
+	values := m.values(theta)
+	zc := zQuantile(0.975)
+	freeSlot := make(map[int]int, q)
+	for k, idx := range m.free {
+		freeSlot[idx] = k
+	}
+	for i, par := range m.params {
+		est := values[i]
+		row := SEMParameter{LHS: par.lhs, Op: par.op, RHS: par.rhs, Free: par.free, Estimate: est,
+			StdError: math.NaN(), ZValue: math.NaN(), PValue: math.NaN(),
+			ConfidenceInterval: [2]float64{math.NaN(), math.NaN()}}
+		if k, ok := freeSlot[i]; ok {
+			se := math.Sqrt(cov.At(k, k))
+			row.StdError = se
+			row.ZValue = est / se
+			row.PValue = zPValue(row.ZValue, TwoSided)
+			row.ConfidenceInterval = [2]float64{est - zc*se, est + zc*se}
+		}
+		switch {
+		case !par.sym:
+			row.StdAll = est * math.Sqrt(sigmaAll.At(par.col, par.col)/sigmaAll.At(par.row, par.row))
+		case par.row == par.col:
+			row.StdAll = est / sigmaAll.At(par.row, par.row)
+		default:
+			row.StdAll = est / math.Sqrt(m.residualVariance(values, par.row)*m.residualVariance(values, par.col))
+		}
+		result.Parameters = append(result.Parameters, row)
+	}
+
+	result.Fit = m.fitIndices(fmin, sigma, n, q)
+	return result, nil
+}
+
+// build lays out the parameter table: user statements in order, then the
+// default variances and covariances.
+func (m *semModel) build(stmts []semStatement, latents []string, isLatent map[string]bool, index map[string]int, stdLV bool) error {
+	key := func(sym bool, r, c int) [3]int {
+		if sym && c > r {
+			r, c = c, r
+		}
+		s := 0
+		if sym {
+			s = 1
+		}
+		return [3]int{s, r, c}
+	}
+	seen := map[[3]int]bool{}
+	add := func(par semParam) error {
+		k := key(par.sym, par.row, par.col)
+		if seen[k] {
+			return fmt.Errorf("parameter %s %s %s is specified twice", par.lhs, par.op, par.rhs)
+		}
+		seen[k] = true
+		if par.sym && par.col > par.row {
+			par.row, par.col = par.col, par.row
+		}
+		m.params = append(m.params, par)
+		return nil
+	}
+	sv := func(i int) float64 {
+		if i < m.nObs {
+			return m.sample.At(i, i)
+		}
+		return 1
+	}
+
+	indicators := map[string][]string{}
+	isIndicator := map[string]bool{}
+	isOutcome := map[string]bool{}
+	isPredictor := map[string]bool{}
+	for _, st := range stmts {
+		switch st.op {
+		case "=~":
+			indicators[st.lhs] = append(indicators[st.lhs], st.rhs)
+			isIndicator[st.rhs] = true
+		case "~":
+			isOutcome[st.lhs] = true
+			isPredictor[st.rhs] = true
+		}
+	}
+	// Starting variance of each latent from its marker indicator.
+	latentStart := map[string]float64{}
+	for _, f := range latents {
+		latentStart[f] = 1
+		ind := indicators[f]
+		if stdLV || isLatent[ind[0]] {
+			continue
+		}
+		mk := index[ind[0]]
+		s, c := 0.0, 0
+		for _, other := range ind[1:] {
+			if !isLatent[other] {
+				s += math.Abs(m.sample.At(mk, index[other]))
+				c++
+			}
+		}
+		if c > 0 {
+			latentStart[f] = math.Max(s/float64(c), 0.05)
+		} else {
+			latentStart[f] = math.Max(0.5*sv(mk), 0.05)
+		}
+	}
+
+	firstIndicator := map[string]bool{}
+	for _, st := range stmts {
+		i, j := index[st.lhs], index[st.rhs]
+		par := semParam{lhs: st.lhs, op: st.op, rhs: st.rhs, free: !st.fixed, value: st.value}
+		switch st.op {
+		case "=~":
+			if st.rhs == st.lhs {
+				return fmt.Errorf("latent variable %s cannot measure itself", st.lhs)
+			}
+			par.row, par.col = j, i
+			marker := !firstIndicator[st.lhs]
+			firstIndicator[st.lhs] = true
+			if !st.fixed {
+				switch {
+				case marker && !stdLV && !st.free:
+					par.free, par.value = false, 1
+				case isLatent[st.rhs]:
+					par.value = 1
+				case stdLV || marker:
+					par.value = math.Sqrt(0.5 * sv(j))
+					if ind := indicators[st.lhs]; !isLatent[ind[0]] && m.sample.At(j, index[ind[0]]) < 0 {
+						par.value = -par.value
+					}
+				case isLatent[indicators[st.lhs][0]]:
+					par.value = 1
+				default:
+					par.value = m.sample.At(j, index[indicators[st.lhs][0]]) / latentStart[st.lhs]
+				}
+			}
+		case "~":
+			if i == j {
+				return fmt.Errorf("variable %s cannot regress on itself", st.lhs)
+			}
+			par.row, par.col = i, j
+		case "~~":
+			par.sym, par.row, par.col = true, i, j
+			if !st.fixed {
+				switch {
+				case i != j:
+					par.value = 0
+				case isLatent[st.lhs]:
+					par.value = latentStart[st.lhs]
+				default:
+					par.value = 0.5 * sv(i)
+				}
+			}
+		}
+		if err := add(par); err != nil {
+			return err
+		}
+	}
+
+	exoObs := map[int]bool{}
+	for v, i := range index {
+		if i < m.nObs && !isIndicator[v] && !isOutcome[v] {
+			exoObs[i] = true
+		}
+	}
+	names := make([]string, m.nAll)
+	for v, i := range index {
+		names[i] = v
+	}
+	for i := range m.nAll {
+		if seen[key(true, i, i)] {
+			continue
+		}
+		par := semParam{lhs: names[i], op: "~~", rhs: names[i], sym: true, row: i, col: i, free: true}
+		switch {
+		case i >= m.nObs && stdLV:
+			par.free, par.value = false, 1
+		case i >= m.nObs:
+			par.value = latentStart[names[i]]
+		case exoObs[i]:
+			par.value = sv(i)
+		default:
+			par.value = 0.5 * sv(i)
+		}
+		_ = add(par)
+	}
+	// Default covariances: exogenous latents, exogenous observed
+	// predictors and pure outcomes, each among themselves.
+	groups := make([][]int, 3)
+	for i := range m.nAll {
+		v := names[i]
+		switch {
+		case i >= m.nObs && !isOutcome[v]:
+			groups[0] = append(groups[0], i)
+		case exoObs[i] && isPredictor[v]:
+			groups[1] = append(groups[1], i)
+		case isOutcome[v] && !isPredictor[v] && !isIndicator[v]:
+			groups[2] = append(groups[2], i)
+		}
+	}
+	for g, members := range groups {
+		for a := range members {
+			for b := a + 1; b < len(members); b++ {
+				i, j := members[a], members[b]
+				if seen[key(true, i, j)] {
+					continue
+				}
+				val := 0.0
+				if g == 1 {
+					val = m.sample.At(i, j)
+				}
+				_ = add(semParam{lhs: names[i], op: "~~", rhs: names[j], sym: true, row: j, col: i, free: true, value: val})
+			}
+		}
+	}
+	for k, par := range m.params {
+		if par.free {
+			m.free = append(m.free, k)
+		}
+	}
+	if len(m.free) == 0 {
+		return errors.New("model has no free parameters")
+	}
+	return nil
+}
+
+func (m *semModel) values(theta []float64) []float64 {
+	out := make([]float64, len(m.params))
+	for i, par := range m.params {
+		out[i] = par.value
+	}
+	for k, idx := range m.free {
+		out[idx] = theta[k]
+	}
+	return out
+}
+
+// implied returns B = (I - A)⁻¹, Σ_all = B S B' over all variables and
+// its observed block Σ.
+func (m *semModel) implied(theta []float64) (B, sigmaAll *mat.Dense, sigma *mat.SymDense, ok bool) {
+	vals := m.values(theta)
+	IA := mat.NewDense(m.nAll, m.nAll, nil)
+	S := mat.NewDense(m.nAll, m.nAll, nil)
+	for i := range m.nAll {
+		IA.Set(i, i, 1)
+	}
+	for i, par := range m.params {
+		if par.sym {
+			S.Set(par.row, par.col, vals[i])
+			S.Set(par.col, par.row, vals[i])
+		} else {
+			IA.Set(par.row, par.col, -vals[i])
+		}
+	}
+	B = new(mat.Dense)
+	if err := B.Inverse(IA); err != nil {
+		return nil, nil, nil, false
+	}
+	var tmp mat.Dense
+	tmp.Mul(B, S)
+	sigmaAll = new(mat.Dense)
+	sigmaAll.Mul(&tmp, B.T())
+	p := m.nObs
+	sigma = mat.NewSymDense(p, nil)
+	for a := range p {
+		for b := 0; b <= a; b++ {
+			sigma.SetSym(a, b, 0.5*(sigmaAll.At(a, b)+sigmaAll.At(b, a)))
+		}
+	}
+	return B, sigmaAll, sigma, true
+}
+
+// inverseSigma returns Σ⁻¹ and log|Σ|, or false when Σ is not positive
+// definite.
+func inverseSigma(sigma *mat.SymDense) (*mat.SymDense, float64, bool) {
+	var chol mat.Cholesky
+	if !chol.Factorize(sigma) {
+		return nil, 0, false
+	}
+	inv := new(mat.SymDense)
+	if err := chol.InverseTo(inv); err != nil {
+		return nil, 0, false
+	}
+	return inv, chol.LogDet(), true
+}
+
+// discrepancy is the ML fit function log|Σ| + tr(SΣ⁻¹) - log|S| - p.
+func (m *semModel) discrepancy(theta []float64) float64 {
+	_, _, sigma, ok := m.implied(theta)
+	if !ok {
+		return math.Inf(1)
+	}
+	inv, logDet, ok := inverseSigma(sigma)
+	if !ok {
+		return math.Inf(1)
+	}
+	tr := 0.0
+	for a := range m.nObs {
+		for b := range m.nObs {
+			tr += m.sample.At(a, b) * inv.At(b, a)
+		}
+	}
+	return logDet + tr - m.logDetS - float64(m.nObs)
+}
+
+// gradient fills g with dF/dθ = tr(W dΣ/dθ), W = Σ⁻¹(Σ - S)Σ⁻¹.
+func (m *semModel) gradient(g, theta []float64) {
+	B, sigmaAll, sigma, ok := m.implied(theta)
+	if !ok {
+		return
+	}
+	inv, _, ok := inverseSigma(sigma)
+	if !ok {
+		return
+	}
+	p := m.nObs
+	var diff, tmp, W mat.Dense
+	diff.Sub(sigma, m.sample)
+	tmp.Mul(inv, &diff)
+	W.Mul(&tmp, inv)
+	u := make([]float64, p)
+	v := make([]float64, p)
+	quad := func() float64 {
+		s := 0.0
+		for a := range p {
+			for b := range p {
+				s += u[a] * W.At(a, b) * v[b]
+			}
+		}
+		return s
+	}
+	for k, idx := range m.free {
+		par := m.params[idx]
+		for a := range p {
+			u[a] = B.At(a, par.row)
+			if par.sym {
+				v[a] = B.At(a, par.col)
+			} else {
+				v[a] = sigmaAll.At(par.col, a)
+			}
+		}
+		if par.sym && par.row == par.col {
+			g[k] = quad()
+		} else {
+			g[k] = 2 * quad()
+		}
+	}
+}
+
+// information is the expected Hessian of F, tr(Σ⁻¹ Dₐ Σ⁻¹ D_b) with
+// Dₐ = dΣ/dθₐ; the ML information for the sample is N/2 times it.
+func (m *semModel) information(theta []float64) *mat.SymDense {
+	B, sigmaAll, sigma, ok := m.implied(theta)
+	if !ok {
+		return nil
+	}
+	inv, _, ok := inverseSigma(sigma)
+	if !ok {
+		return nil
+	}
+	p, q := m.nObs, len(m.free)
+	M := make([]*mat.Dense, q)
+	for k, idx := range m.free {
+		par := m.params[idx]
+		u := mat.NewVecDense(p, nil)
+		v := mat.NewVecDense(p, nil)
+		for a := range p {
+			u.SetVec(a, B.At(a, par.row))
+			if par.sym {
+				v.SetVec(a, B.At(a, par.col))
+			} else {
+				v.SetVec(a, sigmaAll.At(par.col, a))
+			}
+		}
+		D := mat.NewDense(p, p, nil)
+		if par.sym && par.row == par.col {
+			D.Outer(1, u, u)
+		} else {
+			var vu mat.Dense
+			D.Outer(1, u, v)
+			vu.Outer(1, v, u)
+			D.Add(D, &vu)
+		}
+		M[k] = new(mat.Dense)
+		M[k].Mul(inv, D)
+	}
+	info := mat.NewSymDense(q, nil)
+	for a := range q {
+		for b := 0; b <= a; b++ {
+			s := 0.0
+			for k := range p {
+				for l := range p {
+					s += M[a].At(k, l) * M[b].At(l, k)
+				}
+			}
+			info.SetSym(a, b, s)
+		}
+	}
+	return info
+}
+
+func (m *semModel) residualVariance(values []float64, i int) float64 {
+	for k, par := range m.params {
+		if par.sym && par.row == i && par.col == i {
+			return values[k]
+		}
+	}
+	return math.NaN()
+}
+
+// exogenousObserved lists the observed variables that only predict, whose
+// covariances the baseline model keeps.
+func (m *semModel) exogenousObserved(stmts []semStatement, isLatent map[string]bool, index map[string]int) []int {
+	dependent := map[string]bool{}
+	predictor := map[string]bool{}
+	for _, st := range stmts {
+		switch st.op {
+		case "=~":
+			dependent[st.rhs] = true
+		case "~":
+			dependent[st.lhs] = true
+			predictor[st.rhs] = true
+		}
+	}
+	var out []int
+	for v := range predictor {
+		if !isLatent[v] && !dependent[v] {
+			out = append(out, index[v])
+		}
+	}
+	return out
+}
+
+func (m *semModel) fitIndices(fmin float64, sigma *mat.SymDense, n, q int) SEMFitIndices {
+	p, exo := m.nObs, m.exo
+	N := float64(n)
+	moments := p * (p + 1) / 2
+	df := moments - q
+	chi2 := math.Max(N*fmin, 0)
+
+	// Baseline: Σ₀ keeps the exogenous block of S and the diagonal
+	// elsewhere, so F₀ = log|Σ₀| - log|S|.
+	isExo := map[int]bool{}
+	for _, i := range exo {
+		isExo[i] = true
+	}
+	logDet0 := 0.0
+	if len(exo) > 0 {
+		block := mat.NewSymDense(len(exo), nil)
+		for a, i := range exo {
+			for b, j := range exo {
+				block.SetSym(a, b, m.sample.At(i, j))
+			}
+		}
+		var c mat.Cholesky
+		if c.Factorize(block) {
+			logDet0 += c.LogDet()
+		}
+	}
+	for i := range p {
+		if !isExo[i] {
+			logDet0 += math.Log(m.sample.At(i, i))
+		}
+	}
+	k := len(exo)
+	chi20 := N * (logDet0 - m.logDetS)
+	df0 := moments - p - k*(k-1)/2
+
+	fit := SEMFitIndices{
+		ChiSquare:         chi2,
+		DF:                df,
+		PValue:            math.NaN(),
+		BaselineChiSquare: chi20,
+		BaselineDF:        df0,
+		CFI:               1,
+		TLI:               1,
+		NumParameters:     q,
+	}
+	d, d0 := float64(df), float64(df0)
+	if df > 0 {
+		fit.PValue = chiSquaredPValue(chi2, d)
+		fit.RMSEA = math.Sqrt(math.Max(chi2-d, 0) / (d * N))
+		fit.RMSEACI = [2]float64{semRMSEABound(chi2, d, N, 0.95), semRMSEABound(chi2, d, N, 0.05)}
+		if df0 > 0 {
+			fit.TLI = (chi20/d0 - chi2/d) / (chi20/d0 - 1)
+		}
+	}
+	if den := math.Max(math.Max(chi2-d, chi20-d0), 0); den > 0 {
+		fit.CFI = 1 - math.Max(chi2-d, 0)/den
+	}
+
+	srmr := 0.0
+	for a := range p {
+		for b := 0; b <= a; b++ {
+			r := (m.sample.At(a, b) - sigma.At(a, b)) / math.Sqrt(m.sample.At(a, a)*m.sample.At(b, b))
+			srmr += r * r
+		}
+	}
+	fit.SRMR = math.Sqrt(srmr / float64(moments))
+
+	fit.LogLikelihood = -0.5 * N * (float64(p)*math.Log(2*math.Pi) + fmin + m.logDetS + float64(p))
+	fit.AIC = -2*fit.LogLikelihood + 2*float64(q)
+	fit.BIC = -2*fit.LogLikelihood + float64(q)*math.Log(N)
+	return fit
+}
+
+// semRMSEABound finds the noncentrality λ with P(χ²(df, λ) <= chi2) = prob
+// and converts it to the RMSEA scale; it is 0 when even λ = 0 falls short.
+func semRMSEABound(chi2, df, n, prob float64) float64 {
+	if noncentralChiSquaredCDF(chi2, df, 0) < prob {
+		return 0
+	}
+	lo, hi := 0.0, math.Max(chi2, 1)
+	for noncentralChiSquaredCDF(chi2, df, hi) > prob {
+		hi *= 2
+	}
+	for range 100 {
+		mid := 0.5 * (lo + hi)
+		if noncentralChiSquaredCDF(chi2, df, mid) > prob {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.Sqrt(0.5 * (lo + hi) / (df * n))
+}
+
+func symRows(s *mat.SymDense) [][]float64 {
+	k := s.SymmetricDim()
+	out := make([][]float64, k)
+	for i := range k {
+		out[i] = make([]float64, k)
+		for j := range k {
+			out[i][j] = s.At(i, j)
+		}
+	}
+	return out
+}
+
+// ParameterTable returns the parameter estimates as a DataTable with one
+// row per parameter.
+func (r *SEMResult) ParameterTable() *insyra.DataTable {
+	names := []string{"LHS", "Op", "RHS", "Estimate", "StdError", "Z", "P", "Lower", "Upper", "StdAll"}
+	cols := make([]*insyra.DataList, len(names))
+	for i, name := range names {
+		cols[i] = insyra.NewDataList().SetName(name)
+	}
+	for _, par := range r.Parameters {
+		for i, v := range []any{par.LHS, par.Op, par.RHS, par.Estimate, par.StdError, par.ZValue, par.PValue,
+			par.ConfidenceInterval[0], par.ConfidenceInterval[1], par.StdAll} {
+			cols[i].Append(v)
+		}
+	}
+	return insyra.NewDataTable(cols...)
+}
+
+// newSEMModel resolves the variables of the model, computes the sample
+// covariance matrix and lays out the parameters.
+func newSEMModel(dataTable insyra.IDataTable, stmts []semStatement, stdLV bool) (*semModel, int, error) {
+	// Latent variables are the left-hand sides of =~; everything else
+	// must be a column.
+	isLatent := map[string]bool{}
+	var latents []string
+	for _, st := range stmts {
+		if st.op == "=~" && !isLatent[st.lhs] {
+			isLatent[st.lhs] = true
+			latents = append(latents, st.lhs)
+		}
+	}
+	seenObs := map[string]bool{}
+	var observed []string
+	for _, st := range stmts {
+		for _, v := range []string{st.lhs, st.rhs} {
+			if !isLatent[v] && !seenObs[v] {
+				seenObs[v] = true
+				observed = append(observed, v)
+			}
+		}
+	}
+	if len(observed) < 2 {
+		return nil, 0, errors.New("model needs at least two observed variables")
+	}
+	index := map[string]int{}
+	for i, v := range observed {
+		index[v] = i
+	}
+	for i, v := range latents {
+		index[v] = len(observed) + i
+	}
+
+	raw, n, err := rawColumnsByName(dataTable, observed)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := len(observed)
+	if n <= p {
+		return nil, 0, fmt.Errorf("need more than %d rows for %d observed variables", p, p)
+	}
+	cols := make([][]float64, p)
+	for j, name := range observed {
+		if cols[j], err = numericColumn(raw[j], name); err != nil {
+			return nil, 0, err
+		}
+	}
+	means := make([]float64, p)
+	for j := range p {
+		means[j] = sampleMean(cols[j])
+	}
+	sample := mat.NewSymDense(p, nil)
+	for a := range p {
+		for b := 0; b <= a; b++ {
+			s := 0.0
+			for i := range n {
+				s += (cols[a][i] - means[a]) * (cols[b][i] - means[b])
+			}
+			sample.SetSym(a, b, s/float64(n))
+		}
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(sample) {
+		return nil, 0, errors.New("sample covariance matrix is not positive definite")
+	}
+
+	m := &semModel{nObs: p, nAll: p + len(latents), sample: sample, logDetS: chol.LogDet(),
+		observed: observed, latents: latents}
+	if err := m.build(stmts, latents, isLatent, index, stdLV); err != nil {
+		return nil, 0, err
+	}
+	m.exo = m.exogenousObserved(stmts, isLatent, index)
+	return m, n, nil
+}
@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semStatement is one parameter specification from the model syntax.
+type semStatement struct {
+	lhs, op, rhs string
+	fixed        bool // numeric premultiplier, e.g. 1*x1
+	free         bool // NA premultiplier, e.g. NA*x1
+	value        float64
+}
+
+// parseSEMModel reads the lavaan subset used by SEM and CFA:
+//
+//	F1 =~ x1 + x2 + x3   latent F1 is measured by x1, x2, x3
+//	y ~ F1 + z           regression of y on F1 and z
+//	x1 ~~ x2             (residual) variance or covariance
+//
+// Terms may carry a numeric premultiplier to fix the parameter (0.5*x2) or
+// NA to free one that would be fixed by default (NA*x1). Several variables
+// may share a left-hand side (y1 + y2 ~ x). Statements are separated by
+// newlines or semicolons and # starts a comment.
+func parseSEMModel(model string) ([]semStatement, error) {
+	var out []semStatement
+	for lineNo, line := range strings.Split(model, "\n") {
+		if k := strings.IndexByte(line, '#'); k >= 0 {
+			line = line[:k]
+		}
+		for _, stmt := range strings.Split(line, ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" {
+				continue
+			}
+			op, k := "", -1
+			for _, cand := range []string{"=~", "~~", "~"} {
+				if k = strings.Index(stmt, cand); k >= 0 {
+					op = cand
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("line %d: no operator in %q", lineNo+1, stmt)
+			}
+			lhs := splitSEMTerms(stmt[:k])
+			rhs := splitSEMTerms(stmt[k+len(op):])
+			if len(lhs) == 0 || len(rhs) == 0 {
+				return nil, fmt.Errorf("line %d: incomplete statement %q", lineNo+1, stmt)
+			}
+			for _, l := range lhs {
+				if !isSEMIdent(l) {
+					return nil, fmt.Errorf("line %d: invalid variable name %q", lineNo+1, l)
+				}
+				for _, r := range rhs {
+					st, err := parseSEMTerm(r)
+					if err != nil {
+						return nil, fmt.Errorf("line %d: %w", lineNo+1, err)
+					}
+					st.lhs, st.op = l, op
+					out = append(out, st)
+				}
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("model syntax is empty")
+	}
+	return out, nil
+}
+
+func splitSEMTerms(s string) []string {
+	var out []string
+	for _, t := range strings.Split(s, "+") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func parseSEMTerm(term string) (semStatement, error) {
+	var st semStatement
+	name := term
+	if k := strings.IndexByte(term, '*'); k >= 0 {
+		mod := strings.TrimSpace(term[:k])
+		name = strings.TrimSpace(term[k+1:])
+		if mod == "NA" {
+			st.free = true
+		} else {
+			v, err := strconv.ParseFloat(mod, 64)
+			if err != nil {
+				return st, fmt.Errorf("unsupported modifier %q (only numbers and NA are allowed)", mod)
+			}
+			st.fixed, st.value = true, v
+		}
+	}
+	if !isSEMIdent(name) {
+		return st, fmt.Errorf("invalid variable name %q", name)
+	}
+	st.rhs = name
+	return st, nil
+}
+
+func isSEMIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,375 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+// semTwoFactorData simulates six indicators of two correlated factors.
+func semTwoFactorData(n int) *insyra.DataTable {
+	rng := rand.New(rand.NewPCG(1, 2))
+	loadings := []float64{1, 0.8, 1.2, 1, 0.7, 0.9}
+	cols := make([][]any, 6)
+	for range n {
+		f1 := rng.NormFloat64()
+		f2 := 0.5*f1 + 0.8*rng.NormFloat64()
+		for j := range 6 {
+			f := f1
+			if j >= 3 {
+				f = f2
+			}
+			cols[j] = append(cols[j], loadings[j]*f+0.6*rng.NormFloat64())
+		}
+	}
+	lists := make([]*insyra.DataList, 6)
+	for j, name := range []string{"x1", "x2", "x3", "x4", "x5", "x6"} {
+		lists[j] = insyra.NewDataList(cols[j]...).SetName(name)
+	}
+	return insyra.NewDataTable(lists...)
+}
+
+func findSEMParam(t *testing.T, res *SEMResult, lhs, op, rhs string) SEMParameter {
+	t.Helper()
+	for _, p := range res.Parameters {
+		if p.LHS == lhs && p.Op == op && p.RHS == rhs {
+			return p
+		}
+	}
+	t.Fatalf("parameter %s %s %s not found", lhs, op, rhs)
+	return SEMParameter{}
+}
+
+func TestCFAJustIdentifiedClosedForm(t *testing.T) {
+	dt := semTwoFactorData(200)
+	res, err := CFA(dt, "F =~ x1 + x2 + x3", SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := res.SampleCovariance
+	// Three indicators with a marker: φ = s12 s13 / s23, λ2 = s23 / s13,
+	// λ3 = s23 / s12 and θᵢ = sᵢᵢ - λᵢ² φ.
+	phi := s[0][1] * s[0][2] / s[1][2]
+	want := map[[3]string]float64{
+		{"F", "~~", "F"}:   phi,
+		{"F", "=~", "x2"}:  s[1][2] / s[0][2],
+		{"F", "=~", "x3"}:  s[1][2] / s[0][1],
+		{"x1", "~~", "x1"}: s[0][0] - phi,
+	}
+	for k, v := range want {
+		if got := findSEMParam(t, res, k[0], k[1], k[2]).Estimate; math.Abs(got-v) > 1e-7 {
+			t.Fatalf("%v = %.10f, want %.10f", k, got, v)
+		}
+	}
+	if res.Fit.DF != 0 || res.Fit.ChiSquare > 1e-8 || res.Fit.CFI != 1 || res.Fit.RMSEA != 0 {
+		t.Fatalf("saturated fit = %+v", res.Fit)
+	}
+}
+
+func TestSEMObservedRegressionMatchesOLS(t *testing.T) {
+	dt := semTwoFactorData(150)
+	res, err := SEM(dt, "x3 ~ x1 + x2", SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm, err := LinearRegression(dt.GetColByName("x3"), dt.GetColByName("x1"), dt.GetColByName("x2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ML standard errors use N rather than the residual degrees of freedom.
+	scale := math.Sqrt(float64(150-3) / 150)
+	for j, name := range []string{"x1", "x2"} {
+		par := findSEMParam(t, res, "x3", "~", name)
+		if math.Abs(par.Estimate-lm.Coefficients[j+1]) > 1e-8 {
+			t.Fatalf("%s = %.10f, want %.10f", name, par.Estimate, lm.Coefficients[j+1])
+		}
+		if math.Abs(par.StdError-lm.StandardErrors[j+1]*scale) > 1e-6 {
+			t.Fatalf("se(%s) = %.8f, want %.8f", name, par.StdError, lm.StandardErrors[j+1]*scale)
+		}
+	}
+	if res.Fit.DF != 0 || res.Fit.BaselineDF != 2 {
+		t.Fatalf("df = %d, baseline df = %d", res.Fit.DF, res.Fit.BaselineDF)
+	}
+}
+
+func TestCFATwoFactor(t *testing.T) {
+	dt := semTwoFactorData(300)
+	model := `
+		# two correlated factors
+		F1 =~ x1 + x2 + x3
+		F2 =~ x4 + x5 + x6`
+	marker, err := CFA(dt, model, SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, err := CFA(dt, model, SEMOptions{StdLV: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !marker.Converged || !std.Converged {
+		t.Fatal("did not converge")
+	}
+	fit := marker.Fit
+	if fit.DF != 8 || fit.NumParameters != 13 || fit.BaselineDF != 15 {
+		t.Fatalf("df = %d, parameters = %d, baseline df = %d", fit.DF, fit.NumParameters, fit.BaselineDF)
+	}
+	if math.Abs(fit.ChiSquare-std.Fit.ChiSquare) > 1e-6 {
+		t.Fatalf("chi-square %v differs from std.lv %v", fit.ChiSquare, std.Fit.ChiSquare)
+	}
+	if fit.CFI < 0.99 || fit.RMSEA > 0.05 || fit.SRMR > 0.05 || fit.PValue < 0.05 {
+		t.Fatalf("correct model fits poorly: %+v", fit)
+	}
+	if !(fit.RMSEACI[0] <= fit.RMSEA && fit.RMSEA <= fit.RMSEACI[1]) {
+		t.Fatalf("RMSEA %v outside its interval %v", fit.RMSEA, fit.RMSEACI)
+	}
+	// λ·sd(F) is invariant to the identification constraint.
+	phi := findSEMParam(t, marker, "F1", "~~", "F1").Estimate
+	for _, x := range []string{"x2", "x3"} {
+		a := findSEMParam(t, marker, "F1", "=~", x)
+		b := findSEMParam(t, std, "F1", "=~", x)
+		if math.Abs(a.Estimate*math.Sqrt(phi)-b.Estimate) > 1e-5 || math.Abs(a.StdAll-b.StdAll) > 1e-6 {
+			t.Fatalf("%s: marker %v (std.all %v), std.lv %v (std.all %v)", x, a.Estimate, a.StdAll, b.Estimate, b.StdAll)
+		}
+	}
+	if r := findSEMParam(t, std, "F1", "~~", "F2"); math.Abs(r.Estimate-r.StdAll) > 1e-8 {
+		t.Fatalf("factor correlation %v, std.all %v", r.Estimate, r.StdAll)
+	}
+
+	// A regression between the factors is an equivalent model.
+	path, err := SEM(dt, model+"\nF2 ~ F1", SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(path.Fit.ChiSquare-fit.ChiSquare) > 1e-6 {
+		t.Fatalf("path model chi-square %v, CFA %v", path.Fit.ChiSquare, fit.ChiSquare)
+	}
+
+	// Forcing one factor on two-factor data is rejected.
+	one, err := CFA(dt, "F =~ x1 + x2 + x3 + x4 + x5 + x6", SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one.Fit.PValue > 0.001 || one.Fit.CFI > fit.CFI || one.Fit.RMSEA < 0.1 {
+		t.Fatalf("misspecified model fit = %+v", one.Fit)
+	}
+}
+
+// semTableWithCovariance builds n rows whose sample covariance (divisor
+// n - 1) is exactly cov, so a fit sees the same S as lavaan given
+// sample.cov = cov and sample.nobs = n.
+func semTableWithCovariance(names []string, lower [][]float64, n int) *insyra.DataTable {
+	p := len(names)
+	target := mat.NewSymDense(p, nil)
+	for i := range lower {
+		for j, v := range lower[i] {
+			target.SetSym(i, j, v)
+		}
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	z := mat.NewDense(n, p, nil)
+	for j := range p {
+		m := 0.0
+		for i := range n {
+			z.Set(i, j, rng.NormFloat64())
+			m += z.At(i, j)
+		}
+		for i := range n {
+			z.Set(i, j, z.At(i, j)-m/float64(n))
+		}
+	}
+	// x = z Lz⁻ᵀ Ltᵀ, where Lz and Lt are the Cholesky factors of cov(z)
+	// and the target.
+	var zz mat.SymDense
+	zz.SymOuterK(1/float64(n-1), z.T())
+	var cz, ct mat.Cholesky
+	cz.Factorize(&zz)
+	ct.Factorize(target)
+	var lz, lt mat.TriDense
+	cz.LTo(&lz)
+	ct.LTo(&lt)
+	var w, x mat.Dense
+	w.Inverse(lz.T())
+	w.Mul(&w, lt.T())
+	x.Mul(z, &w)
+	lists := make([]*insyra.DataList, p)
+	for j, name := range names {
+		lists[j] = insyra.NewDataList(mat.Col(nil, j, &x)).SetName(name)
+	}
+	return insyra.NewDataTable(lists...)
+}
+
+// TestSEMWheatonLavaan fits the Wheaton et al. (1977) alienation model from
+// the lavaan tutorial's covariance-matrix example:
+//
+//	fit <- sem(model, sample.cov = wheaton.cov, sample.nobs = 932)
+//
+// Estimates and standard errors are lavaan's printed summary (three
+// decimals). The tutorial does not print fitMeasures for this model, so
+// the baseline χ² and the indices built on it are checked against their
+// lavaan definitions evaluated directly on the input matrix.
+func TestSEMWheatonLavaan(t *testing.T) {
+	names := []string{"anomia67", "powerless67", "anomia71", "powerless71", "education", "sei"}
+	lower := [][]float64{
+		{11.834},
+		{6.947, 9.364},
+		{6.819, 5.091, 12.532},
+		{4.783, 5.028, 7.495, 9.986},
+		{-3.839, -3.889, -3.841, -3.625, 9.610},
+		{-21.899, -18.831, -21.748, -18.775, 35.522, 450.288},
+	}
+	const n = 932
+	dt := semTableWithCovariance(names, lower, n)
+	res, err := SEM(dt, `
+		ses     =~ education + sei
+		alien67 =~ anomia67 + powerless67
+		alien71 =~ anomia71 + powerless71
+		alien71 ~ alien67 + ses
+		alien67 ~ ses
+		anomia67 ~~ anomia71
+		powerless67 ~~ powerless71`, SEMOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fit := res.Fit
+	if math.Abs(fit.ChiSquare-4.735) > 5e-4 || fit.DF != 4 || math.Abs(fit.PValue-0.316) > 5e-4 || fit.NumParameters != 17 {
+		t.Fatalf("chi-square %v on %d df (p = %v), %d parameters; want 4.735 on 4 (p = 0.316), 17",
+			fit.ChiSquare, fit.DF, fit.PValue, fit.NumParameters)
+	}
+	lavaan := []struct {
+		lhs, op, rhs string
+		est, se      float64
+	}{
+		{"ses", "=~", "sei", 5.219, 0.422},
+		{"alien67", "=~", "powerless67", 0.979, 0.062},
+		{"alien71", "=~", "powerless71", 0.922, 0.059},
+		{"alien71", "~", "alien67", 0.607, 0.051},
+		{"alien71", "~", "ses", -0.227, 0.052},
+		{"alien67", "~", "ses", -0.575, 0.056},
+		{"anomia67", "~~", "anomia71", 1.623, 0.314},
+		{"powerless67", "~~", "powerless71", 0.339, 0.261},
+		{"education", "~~", "education", 2.801, 0.507},
+		{"sei", "~~", "sei", 264.597, 18.126},
+		{"anomia67", "~~", "anomia67", 4.731, 0.453},
+		{"powerless67", "~~", "powerless67", 2.563, 0.403},
+		{"anomia71", "~~", "anomia71", 4.399, 0.515},
+		{"powerless71", "~~", "powerless71", 3.070, 0.434},
+		{"ses", "~~", "ses", 6.798, 0.649},
+		{"alien67", "~~", "alien67", 4.841, 0.467},
+		{"alien71", "~~", "alien71", 4.083, 0.404},
+	}
+	for _, w := range lavaan {
+		got := findSEMParam(t, res, w.lhs, w.op, w.rhs)
+		if math.Abs(got.Estimate-w.est) > 5e-4 || math.Abs(got.StdError-w.se) > 5e-4 {
+			t.Errorf("%s %s %s = %.4f (%.4f), lavaan %.3f (%.3f)", w.lhs, w.op, w.rhs, got.Estimate, got.StdError, w.est, w.se)
+		}
+	}
+
+	// No observed variable is exogenous, so the baseline is the diagonal of
+	// S: χ²₀ = N (Σ log sᵢᵢ - log|S|) on p(p - 1)/2 df.
+	s := mat.NewSymDense(6, nil)
+	logDiag := 0.0
+	for i := range lower {
+		for j, v := range lower[i] {
+			s.SetSym(i, j, v*(n-1)/n)
+		}
+		logDiag += math.Log(lower[i][i] * (n - 1) / n)
+	}
+	var chol mat.Cholesky
+	chol.Factorize(s)
+	chi0 := n * (logDiag - chol.LogDet())
+	if math.Abs(fit.BaselineChiSquare-chi0) > 1e-6*chi0 || fit.BaselineDF != 15 {
+		t.Fatalf("baseline chi-square %v on %d df, want %v on 15", fit.BaselineChiSquare, fit.BaselineDF, chi0)
+	}
+	chi, df := fit.ChiSquare, 4.0
+	cfi := 1 - (chi-df)/(chi0-15)
+	tli := (chi0/15 - chi/df) / (chi0/15 - 1)
+	rmsea := math.Sqrt((chi - df) / (df * n))
+	if math.Abs(fit.CFI-cfi) > 1e-12 || math.Abs(fit.TLI-tli) > 1e-12 || math.Abs(fit.RMSEA-rmsea) > 1e-12 {
+		t.Fatalf("CFI %v, TLI %v, RMSEA %v; want %v, %v, %v", fit.CFI, fit.TLI, fit.RMSEA, cfi, tli, rmsea)
+	}
+	// P(χ²₄ <= 4.735) < 0.95, so the lower RMSEA bound is 0; the upper
+	// bound solves P(χ²₄(λ) <= χ²) = 0.05 with λ = N df RMSEA².
+	lambda := n * df * fit.RMSEACI[1] * fit.RMSEACI[1]
+	if fit.RMSEACI[0] != 0 || math.Abs(noncentralChiSquaredCDF(chi, df, lambda)-0.05) > 1e-8 {
+		t.Fatalf("RMSEA interval %v", fit.RMSEACI)
+	}
+	// lavaan's SRMR averages the standardised residuals over the lower
+	// triangle, diagonal included.
+	srmr := 0.0
+	for i := range 6 {
+		for j := 0; j <= i; j++ {
+			r := (res.SampleCovariance[i][j] - res.ImpliedCovariance[i][j]) /
+				math.Sqrt(res.SampleCovariance[i][i]*res.SampleCovariance[j][j])
+			srmr += r * r
+		}
+	}
+	if want := math.Sqrt(srmr / 21); math.Abs(fit.SRMR-want) > 1e-12 {
+		t.Fatalf("SRMR = %v, want %v", fit.SRMR, want)
+	}
+}
+
+func TestSEMGradientMatchesNumeric(t *testing.T) {
+	dt := semTwoFactorData(100)
+	stmts, err := parseSEMModel("F1 =~ x1 + x2 + x3\nF2 =~ x4 + x5 + x6\nF2 ~ F1\nx1 ~~ x4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _, err := newSEMModel(dt, stmts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Check at the starting values, away from the optimum.
+	theta := make([]float64, len(m.free))
+	for k, idx := range m.free {
+		theta[k] = m.params[idx].value
+	}
+	g := make([]float64, len(theta))
+	m.gradient(g, theta)
+	num := make([]float64, len(theta))
+	numericGradient(m.discrepancy, theta, num, nil)
+	for k := range g {
+		if math.Abs(g[k]-num[k]) > 1e-5*(1+math.Abs(num[k])) {
+			t.Fatalf("gradient[%d] = %.8g, numeric %.8g", k, g[k], num[k])
+		}
+	}
+}
+
+func TestParseSEMModel(t *testing.T) {
+	stmts, err := parseSEMModel("F =~ NA*x1 + 0.5*x2; y1 + y2 ~ F  # comment\n x1 ~~ x2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 5 {
+		t.Fatalf("got %d statements", len(stmts))
+	}
+	if !stmts[0].free || !stmts[1].fixed || stmts[1].value != 0.5 {
+		t.Fatalf("modifiers = %+v %+v", stmts[0], stmts[1])
+	}
+	if stmts[2].lhs != "y1" || stmts[3].lhs != "y2" || stmts[3].op != "~" || stmts[4].op != "~~" {
+		t.Fatalf("statements = %+v", stmts)
+	}
+	for _, bad := range []string{"", "F x1", "F =~", "F =~ a*x1", "2F =~ x1"} {
+		if _, err := parseSEMModel(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSEMValidation(t *testing.T) {
+	dt := semTwoFactorData(50)
+	if _, err := CFA(dt, "F =~ x1 + x2\nx1 ~ x3", SEMOptions{}); err == nil {
+		t.Fatal("expected CFA to reject regressions")
+	}
+	if _, err := CFA(dt, "F =~ x1 + missing", SEMOptions{}); err == nil {
+		t.Fatal("expected error for a missing column")
+	}
+	if _, err := CFA(dt, "F =~ x1 + x2\nx1 ~~ x2", SEMOptions{}); err == nil {
+		t.Fatal("expected error for an unidentified model")
+	}
+	if _, err := SEM(dt, "x1 ~ x2\nx1 ~ x2", SEMOptions{}); err == nil {
+		t.Fatal("expected error for a duplicated parameter")
+	}
+}
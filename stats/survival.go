@@ -0,0 +1,382 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type KaplanMeierConfType string
+
+const (
+	KMConfLog    KaplanMeierConfType = "log"
+	KMConfLogLog KaplanMeierConfType = "log-log"
+	KMConfPlain  KaplanMeierConfType = "plain"
+)
+
+type KaplanMeierOptions struct {
+	ConfidenceLevel float64
+	// ConfType selects the confidence band transform (default KMConfLog,
+	// as in R's survfit).
+	ConfType KaplanMeierConfType
+}
+
+// KaplanMeierResult holds a product-limit survival curve.
+//
+// Table has one row per distinct observed time with columns Time, NRisk,
+// NEvent, NCensor, Survival, StdError (Greenwood), Lower and Upper.
+// MedianSurvival and its confidence limits are NaN when the curve (or the
+// corresponding band) never drops to 0.5.
+type KaplanMeierResult struct {
+	Table           *insyra.DataTable
+	N               int
+	Events          int
+	MedianSurvival  float64
+	MedianCI        [2]float64
+	ConfidenceLevel float64
+	ConfType        KaplanMeierConfType
+}
+
+// KaplanMeier estimates the survival function from follow-up times and
+// event indicators (1 = event, 0 = censored).
+func KaplanMeier(times, events insyra.IDataList, opts ...KaplanMeierOptions) (*KaplanMeierResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt KaplanMeierOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	confType := opt.ConfType
+	if confType == "" {
+		confType = KMConfLog
+	}
+	switch confType {
+	case KMConfLog, KMConfLogLog, KMConfPlain:
+	default:
+		return nil, fmt.Errorf("unsupported confidence type %q", confType)
+	}
+	t, d, err := survivalInputs(times, events)
+	if err != nil {
+		return nil, err
+	}
+	cl := resolveConfidenceLevel(opt.ConfidenceLevel)
+	zq := zQuantile((1 + cl) / 2)
+
+	steps := survivalSteps(t, d)
+	timeCol := insyra.NewDataList().SetName("Time")
+	riskCol := insyra.NewDataList().SetName("NRisk")
+	eventCol := insyra.NewDataList().SetName("NEvent")
+	censorCol := insyra.NewDataList().SetName("NCensor")
+	survCol := insyra.NewDataList().SetName("Survival")
+	seCol := insyra.NewDataList().SetName("StdError")
+	lowerCol := insyra.NewDataList().SetName("Lower")
+	upperCol := insyra.NewDataList().SetName("Upper")
+
+	surv, greenwood := 1.0, 0.0
+	survs := make([]float64, len(steps))
+	lowers := make([]float64, len(steps))
+	uppers := make([]float64, len(steps))
+	totalEvents := 0
+	for i, s := range steps {
+		totalEvents += s.events
+		if s.events > 0 {
+			surv *= 1 - float64(s.events)/float64(s.atRisk)
+			if s.atRisk > s.events {
+				greenwood += float64(s.events) / (float64(s.atRisk) * float64(s.atRisk-s.events))
+			} else {
+				greenwood = math.Inf(1)
+			}
+		}
+		seLog := math.Sqrt(greenwood)
+		lo, hi := kaplanMeierBand(surv, seLog, zq, confType)
+		survs[i], lowers[i], uppers[i] = surv, lo, hi
+
+		timeCol.Append(s.time)
+		riskCol.Append(s.atRisk)
+		eventCol.Append(s.events)
+		censorCol.Append(s.censored)
+		survCol.Append(surv)
+		seCol.Append(surv * seLog)
+		lowerCol.Append(lo)
+		upperCol.Append(hi)
+	}
+
+	stepTimes := make([]float64, len(steps))
+	for i, s := range steps {
+		stepTimes[i] = s.time
+	}
+	return &KaplanMeierResult{
+		Table:          insyra.NewDataTable(timeCol, riskCol, eventCol, censorCol, survCol, seCol, lowerCol, upperCol),
+		N:              len(t),
+		Events:         totalEvents,
+		MedianSurvival: survivalQuantile(stepTimes, survs, true),
+		MedianCI: [2]float64{
+			survivalQuantile(stepTimes, lowers, false),
+			survivalQuantile(stepTimes, uppers, false),
+		},
+		ConfidenceLevel: cl,
+		ConfType:        confType,
+	}, nil
+}
+
+// kaplanMeierBand follows survfit's conf.type transforms; seLog is the
+// Greenwood standard error of the cumulative hazard.
+func kaplanMeierBand(surv, seLog, zq float64, confType KaplanMeierConfType) (float64, float64) {
+	if surv <= 0 || math.IsInf(seLog, 0) || math.IsNaN(seLog) {
+		return math.NaN(), math.NaN()
+	}
+	switch confType {
+	case KMConfPlain:
+		return math.Max(0, surv-zq*surv*seLog), math.Min(1, surv+zq*surv*seLog)
+	case KMConfLogLog:
+		if surv >= 1 {
+			return math.NaN(), math.NaN()
+		}
+		ll := math.Log(-math.Log(surv))
+		shift := zq * seLog / math.Abs(math.Log(surv))
+		return math.Exp(-math.Exp(ll + shift)), math.Exp(-math.Exp(ll - shift))
+	default:
+		return surv * math.Exp(-zq*seLog), math.Min(1, surv*math.Exp(zq*seLog))
+	}
+}
+
+// survivalQuantile returns the first time at which curve <= 0.5. When
+// interpolate is set and the curve sits exactly on 0.5, the midpoint to the
+// next time is used, matching R's median rule.
+func survivalQuantile(times, curve []float64, interpolate bool) float64 {
+	const eps = 1e-10
+	for i, s := range curve {
+		if math.IsNaN(s) || s > 0.5+eps {
+			continue
+		}
+		if interpolate && math.Abs(s-0.5) <= eps {
+			for j := i + 1; j < len(curve); j++ {
+				if curve[j] < s-eps {
+					return (times[i] + times[j]) / 2
+				}
+			}
+		}
+		return times[i]
+	}
+	return math.NaN()
+}
+
+type survivalStep struct {
+	time     float64
+	atRisk   int
+	events   int
+	censored int
+}
+
+// survivalSteps collapses observations into distinct times with risk-set
+// sizes.
+func survivalSteps(t []float64, d []bool) []survivalStep {
+	idx := make([]int, len(t))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return t[idx[a]] < t[idx[b]] })
+	var steps []survivalStep
+	atRisk := len(t)
+	for i := 0; i < len(idx); {
+		cur := t[idx[i]]
+		step := survivalStep{time: cur, atRisk: atRisk}
+		for i < len(idx) && t[idx[i]] == cur {
+			if d[idx[i]] {
+				step.events++
+			} else {
+				step.censored++
+			}
+			i++
+		}
+		atRisk -= step.events + step.censored
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// survivalInputs reads non-negative times and 0/1 (or boolean) event
+// indicators.
+func survivalInputs(times, events insyra.IDataList) ([]float64, []bool, error) {
+	if times == nil || events == nil {
+		return nil, nil, errors.New("times and events must not be nil")
+	}
+	var t []float64
+	var d []bool
+	var tErr, dErr error
+	times.AtomicDo(func(dl *insyra.DataList) {
+		t = make([]float64, dl.Len())
+		for i := range t {
+			v, ok := insyra.ToFloat64Safe(dl.Get(i))
+			if !ok || math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+				tErr = errors.New("times must be non-negative finite numbers")
+				return
+			}
+			t[i] = v
+		}
+	})
+	if tErr != nil {
+		return nil, nil, tErr
+	}
+	events.AtomicDo(func(dl *insyra.DataList) {
+		d = make([]bool, dl.Len())
+		for i := range d {
+			switch v := dl.Get(i).(type) {
+			case bool:
+				d[i] = v
+			default:
+				f, ok := insyra.ToFloat64Safe(v)
+				if !ok || (f != 0 && f != 1) {
+					dErr = errors.New("events must be 0/1 or boolean")
+					return
+				}
+				d[i] = f == 1
+			}
+		}
+	})
+	if dErr != nil {
+		return nil, nil, dErr
+	}
+	if len(t) != len(d) {
+		return nil, nil, errors.New("times and events must have the same length")
+	}
+	if len(t) == 0 {
+		return nil, nil, errors.New("no observations")
+	}
+	return t, d, nil
+}
+
+// LogRankTestResult holds the (Mantel-Haenszel) log-rank chi-square test.
+// Table has one row per group with columns Group, N, Observed, Expected,
+// (O-E)^2/E and (O-E)^2/V, as printed by R's survdiff.
+type LogRankTestResult struct {
+	testResultBase
+	Table *insyra.DataTable
+}
+
+// LogRankTest compares survival curves across groups, matching R's
+// survdiff(rho = 0). Groups are ordered by their sorted labels.
+func LogRankTest(times, events, groups insyra.IDataList) (*LogRankTestResult, error) {
+	t, d, err := survivalInputs(times, events)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		return nil, errors.New("groups must not be nil")
+	}
+	var raw []any
+	groups.AtomicDo(func(dl *insyra.DataList) { raw = dl.Data() })
+	if len(raw) != len(t) {
+		return nil, errors.New("groups must have the same length as times")
+	}
+	for _, v := range raw {
+		if v == nil {
+			return nil, errors.New("groups must not contain nil values")
+		}
+	}
+	g, levels, err := factorLevels(raw, "groups")
+	if err != nil {
+		return nil, err
+	}
+	G := len(levels)
+	if G < 2 {
+		return nil, errors.New("log-rank test needs at least two groups")
+	}
+
+	idx := make([]int, len(t))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return t[idx[a]] < t[idx[b]] })
+	atRisk := make([]float64, G)
+	counts := make([]int, G)
+	for _, gi := range g {
+		atRisk[gi]++
+		counts[gi]++
+	}
+	obs := make([]float64, G)
+	exp := make([]float64, G)
+	V := mat.NewDense(G, G, nil)
+	for i := 0; i < len(idx); {
+		cur := t[idx[i]]
+		deaths := make([]float64, G)
+		leaving := make([]float64, G)
+		for i < len(idx) && t[idx[i]] == cur {
+			j := idx[i]
+			if d[j] {
+				deaths[g[j]]++
+			}
+			leaving[g[j]]++
+			i++
+		}
+		n, dt := 0.0, 0.0
+		for k := range G {
+			n += atRisk[k]
+			dt += deaths[k]
+		}
+		if dt > 0 {
+			for a := range G {
+				obs[a] += deaths[a]
+				exp[a] += dt * atRisk[a] / n
+				if n > 1 {
+					f := dt * (n - dt) / (n - 1)
+					for b := range G {
+						delta := 0.0
+						if a == b {
+							delta = 1
+						}
+						V.Set(a, b, V.At(a, b)+f*atRisk[a]/n*(delta-atRisk[b]/n))
+					}
+				}
+			}
+		}
+		for k := range G {
+			atRisk[k] -= leaving[k]
+		}
+	}
+
+	// Chi-square on the first G-1 groups (the full V is singular).
+	sub := mat.NewDense(G-1, G-1, nil)
+	diff := mat.NewVecDense(G-1, nil)
+	for a := range G - 1 {
+		diff.SetVec(a, obs[a]-exp[a])
+		for b := range G - 1 {
+			sub.Set(a, b, V.At(a, b))
+		}
+	}
+	var sol mat.VecDense
+	if err := sol.SolveVec(sub, diff); err != nil {
+		return nil, errors.New("log-rank variance matrix is singular")
+	}
+	chi := mat.Dot(diff, &sol)
+	df := float64(G - 1)
+
+	groupCol := insyra.NewDataList().SetName("Group")
+	nCol := insyra.NewDataList().SetName("N")
+	oCol := insyra.NewDataList().SetName("Observed")
+	eCol := insyra.NewDataList().SetName("Expected")
+	oeCol := insyra.NewDataList().SetName("(O-E)^2/E")
+	ovCol := insyra.NewDataList().SetName("(O-E)^2/V")
+	for a := range G {
+		groupCol.Append(levels[a])
+		nCol.Append(counts[a])
+		oCol.Append(obs[a])
+		eCol.Append(exp[a])
+		diffA := obs[a] - exp[a]
+		oeCol.Append(diffA * diffA / exp[a])
+		ovCol.Append(diffA * diffA / V.At(a, a))
+	}
+	return &LogRankTestResult{
+		testResultBase: testResultBase{
+			Statistic: chi,
+			PValue:    chiSquaredPValue(chi, df),
+			DF:        &df,
+		},
+		Table: insyra.NewDataTable(groupCol, nCol, oCol, eCol, oeCol, ovCol),
+	}, nil
+}
@@ -0,0 +1,172 @@
+package stats_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// ovarian and aml are the survival package datasets of the same names.
+var (
+	ovarianTime = []float64{59, 115, 156, 421, 431, 448, 464, 475, 477, 563, 638, 744, 769, 770, 803, 855, 1040, 1106, 1129, 1206, 1227, 268, 329, 353, 365, 377}
+	ovarianStat = []float64{1, 1, 1, 0, 1, 0, 1, 1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 0}
+	ovarianAge  = []float64{72.3315, 74.4932, 66.4658, 53.3644, 50.3397, 56.4301, 56.9370, 59.8548, 64.1753, 55.1781, 56.7562, 50.1096, 59.6301, 57.0521, 39.2712, 43.1233, 38.8932, 44.6000, 53.9068, 44.2055, 59.5890, 74.5041, 43.1370, 63.2192, 64.4247, 58.3096}
+	ovarianRx   = []float64{1, 1, 1, 2, 1, 1, 2, 2, 1, 2, 1, 2, 2, 2, 1, 1, 1, 1, 2, 2, 2, 1, 1, 2, 2, 2}
+	ovarianEcog = []float64{1, 1, 2, 1, 1, 2, 2, 2, 1, 2, 2, 1, 2, 1, 1, 2, 2, 1, 1, 1, 2, 2, 1, 2, 1, 1}
+
+	amlTime   = []float64{9, 13, 13, 18, 23, 28, 31, 34, 45, 48, 161, 5, 5, 8, 8, 12, 16, 23, 27, 30, 33, 43, 45}
+	amlStatus = []float64{1, 1, 0, 1, 1, 0, 1, 1, 0, 1, 0, 1, 1, 1, 1, 1, 0, 1, 1, 1, 1, 1, 1}
+	amlGroup  = []string{"Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained", "Maintained",
+		"Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained", "Nonmaintained"}
+)
+
+func TestKaplanMeier(t *testing.T) {
+	res, err := stats.KaplanMeier(insyra.NewDataList(ovarianTime), insyra.NewDataList(ovarianStat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// R: survfit(Surv(futime, fustat) ~ 1, ovarian) -> n 26, events 12,
+	// median 638, 0.95LCL 464, 0.95UCL NA.
+	if res.N != 26 || res.Events != 12 {
+		t.Errorf("N = %d, Events = %d", res.N, res.Events)
+	}
+	if res.MedianSurvival != 638 || res.MedianCI[0] != 464 || !math.IsNaN(res.MedianCI[1]) {
+		t.Errorf("median = %v, CI = %v", res.MedianSurvival, res.MedianCI)
+	}
+	if r, c := res.Table.Size(); r != 26 || c != 8 {
+		t.Fatalf("table size = %dx%d", r, c)
+	}
+	// S(59) = 25/26 with Greenwood SE sqrt(1/(26*25)) on the log scale.
+	s := res.Table.GetColByName("Survival").Get(0).(float64)
+	se := res.Table.GetColByName("StdError").Get(0).(float64)
+	if !floatAlmostEqual(s, 25.0/26, 1e-12) || !floatAlmostEqual(se, s*math.Sqrt(1.0/650), 1e-12) {
+		t.Errorf("first step S = %v, SE = %v", s, se)
+	}
+	lower := res.Table.GetColByName("Lower").Get(0).(float64)
+	if !floatAlmostEqual(lower, s*math.Exp(-1.959964*math.Sqrt(1.0/650)), 1e-6) {
+		t.Errorf("log band lower = %v", lower)
+	}
+
+	plain, err := stats.KaplanMeier(insyra.NewDataList(ovarianTime), insyra.NewDataList(ovarianStat), stats.KaplanMeierOptions{ConfType: stats.KMConfPlain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plain.Table.GetColByName("Upper").Get(0).(float64); got != 1 {
+		t.Errorf("plain band should be clipped at 1, got %v", got)
+	}
+
+	// A curve that sits exactly on 0.5 uses the midpoint rule.
+	half, err := stats.KaplanMeier(insyra.NewDataList(1, 2, 3, 4), insyra.NewDataList(1, 1, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if half.MedianSurvival != 2.5 {
+		t.Errorf("median = %v, want 2.5", half.MedianSurvival)
+	}
+
+	if _, err := stats.KaplanMeier(insyra.NewDataList(1, -2), insyra.NewDataList(1, 0)); err == nil {
+		t.Error("expected error for negative time")
+	}
+	if _, err := stats.KaplanMeier(insyra.NewDataList(1, 2), insyra.NewDataList(1, 2)); err == nil {
+		t.Error("expected error for non-binary events")
+	}
+}
+
+func TestLogRankTest(t *testing.T) {
+	// R: survdiff(Surv(time, status) ~ x, data = aml)
+	// Maintained N=11 O=7 E=10.69; Nonmaintained N=12 O=11 E=7.31; Chisq 3.4.
+	res, err := stats.LogRankTest(insyra.NewDataList(amlTime), insyra.NewDataList(amlStatus), insyra.NewDataList(amlGroup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(res.Statistic, 3.396, 1e-3) || *res.DF != 1 {
+		t.Errorf("chisq = %v, df = %v", res.Statistic, *res.DF)
+	}
+	if !floatAlmostEqual(res.PValue, 0.0653, 1e-4) {
+		t.Errorf("p = %v", res.PValue)
+	}
+	if g := res.Table.GetColByName("Group").Get(0); g != "Maintained" {
+		t.Errorf("first group = %v", g)
+	}
+	if e := res.Table.GetColByName("Expected").Get(0).(float64); !floatAlmostEqual(e, 10.69, 5e-3) {
+		t.Errorf("expected = %v", e)
+	}
+
+	// survdiff(Surv(futime, fustat) ~ rx, ovarian): Chisq 1.1, E 5.23 / 6.77.
+	ov, err := stats.LogRankTest(insyra.NewDataList(ovarianTime), insyra.NewDataList(ovarianStat), insyra.NewDataList(ovarianRx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(ov.Statistic, 1.06, 5e-3) {
+		t.Errorf("ovarian chisq = %v", ov.Statistic)
+	}
+
+	if _, err := stats.LogRankTest(insyra.NewDataList(1, 2), insyra.NewDataList(1, 1), insyra.NewDataList("a", "a")); err == nil {
+		t.Error("expected error for a single group")
+	}
+	if _, err := stats.LogRankTest(insyra.NewDataList(1, 2), insyra.NewDataList(1, 1), insyra.NewDataList("a", nil)); err == nil || err.Error() != "groups must not contain nil values" {
+		t.Errorf("expected nil group error, got %v", err)
+	}
+}
+
+func TestCoxPH(t *testing.T) {
+	// R: coxph(Surv(futime, fustat) ~ age + ecog.ps, data = ovarian)
+	// age 0.16150 (se 0.04992), ecog.ps 0.01866; LR test 14.29 on 2 df.
+	res, err := stats.CoxPH(stats.CoxPHOptions{},
+		insyra.NewDataList(ovarianTime), insyra.NewDataList(ovarianStat),
+		insyra.NewDataList(ovarianAge).SetName("age"), insyra.NewDataList(ovarianEcog).SetName("ecog.ps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CoefficientNames[0] != "age" || res.CoefficientNames[1] != "ecog.ps" {
+		t.Errorf("names = %v", res.CoefficientNames)
+	}
+	if !floatAlmostEqual(res.Coefficients[0], 0.16150, 1e-4) || !floatAlmostEqual(res.Coefficients[1], 0.01866, 1e-4) {
+		t.Errorf("coefficients = %v", res.Coefficients)
+	}
+	if !floatAlmostEqual(res.StandardErrors[0], 0.04992, 1e-4) {
+		t.Errorf("se = %v", res.StandardErrors)
+	}
+	if !floatAlmostEqual(res.HazardRatios[0], math.Exp(res.Coefficients[0]), 1e-12) {
+		t.Errorf("hazard ratio = %v", res.HazardRatios[0])
+	}
+	if !floatAlmostEqual(res.LikelihoodRatio, 14.29, 0.01) || !res.Converged {
+		t.Errorf("LR = %v, converged = %v", res.LikelihoodRatio, res.Converged)
+	}
+	ci := res.HazardRatioCIs[0]
+	if !(ci[0] < res.HazardRatios[0] && res.HazardRatios[0] < ci[1]) {
+		t.Errorf("hazard ratio CI %v does not cover %v", ci, res.HazardRatios[0])
+	}
+
+	// Tied event times: R coxph(Surv(time, status) ~ x, data = aml) gives
+	// 0.9155 (se 0.5119, p 0.0737) and LR 3.38 under Efron.
+	x := make([]float64, len(amlGroup))
+	for i, g := range amlGroup {
+		if g == "Nonmaintained" {
+			x[i] = 1
+		}
+	}
+	efron, err := stats.CoxPH(stats.CoxPHOptions{}, insyra.NewDataList(amlTime), insyra.NewDataList(amlStatus), insyra.NewDataList(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floatAlmostEqual(efron.Coefficients[0], 0.9155, 1e-4) || !floatAlmostEqual(efron.StandardErrors[0], 0.5119, 1e-4) {
+		t.Errorf("efron coef = %v, se = %v", efron.Coefficients, efron.StandardErrors)
+	}
+	if !floatAlmostEqual(efron.LikelihoodRatio, 3.38, 0.01) {
+		t.Errorf("efron LR = %v", efron.LikelihoodRatio)
+	}
+	breslow, err := stats.CoxPH(stats.CoxPHOptions{Ties: stats.CoxBreslow}, insyra.NewDataList(amlTime), insyra.NewDataList(amlStatus), insyra.NewDataList(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if breslow.Ties != stats.CoxBreslow || breslow.Coefficients[0] >= efron.Coefficients[0] {
+		t.Errorf("breslow coef = %v should be shrunk relative to efron %v", breslow.Coefficients[0], efron.Coefficients[0])
+	}
+
+	if _, err := stats.CoxPH(stats.CoxPHOptions{}, insyra.NewDataList(amlTime), insyra.NewDataList(amlStatus)); err == nil {
+		t.Error("expected error without covariates")
+	}
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// rawColumnsByName pulls the named columns of a table (in the order given)
+// and checks that they share one length.
+func rawColumnsByName(dataTable insyra.IDataTable, names []string) ([][]any, int, error) {
+	if dataTable == nil {
+		return nil, 0, errors.New("data table is nil")
+	}
+	out := make([][]any, len(names))
+	var missing string
+	dataTable.AtomicDo(func(dt *insyra.DataTable) {
+		existing := map[string]bool{}
+		for _, name := range dt.ColNames() {
+			existing[name] = true
+		}
+		for i, name := range names {
+			if !existing[name] {
+				missing = name
+				return
+			}
+			out[i] = dt.GetColByName(name).Data()
+		}
+	})
+	if missing != "" {
+		return nil, 0, fmt.Errorf("column %q not found", missing)
+	}
+	n := -1
+	for i, col := range out {
+		if n >= 0 && len(col) != n {
+			return nil, 0, fmt.Errorf("column %q has a different length", names[i])
+		}
+		n = len(col)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return out, n, nil
+}
+
+// numericColumn converts a raw column to finite float64 values.
+func numericColumn(raw []any, name string) ([]float64, error) {
+	out := make([]float64, len(raw))
+	for i, v := range raw {
+		f, ok := insyra.ToFloat64Safe(v)
+		if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("column %q must contain finite numeric values (row %d)", name, i)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
+
+// factorLevels maps raw values to level indices, with levels ordered by
+// their sorted labels.
+func factorLevels(raw []any, name string) ([]int, []any, error) {
+	index := map[string]int{}
+	var levels []any
+	for i, v := range raw {
+		if v == nil {
+			return nil, nil, fmt.Errorf("column %q contains a missing value (row %d)", name, i)
+		}
+		key := classKey(v)
+		if _, ok := index[key]; !ok {
+			index[key] = len(levels)
+			levels = append(levels, v)
+		}
+	}
+	sortClassLevels(levels)
+	for i, v := range levels {
+		index[classKey(v)] = i
+	}
+	codes := make([]int, len(raw))
+	for i, v := range raw {
+		codes[i] = index[classKey(v)]
+	}
+	return codes, levels, nil
+}
+
+// isCategoricalColumn reports whether a predictor column holds strings or
+// booleans and so should be coded as a factor.
+func isCategoricalColumn(raw []any) bool {
+	for _, v := range raw {
+		switch v.(type) {
+		case string, bool:
+			return true
+		}
+	}
+	return false
+}
+
+// treatmentContrasts codes a factor with k levels as k-1 indicator columns
+// of R's contr.treatment; the first level is the reference.
+func treatmentContrasts(codes []int, k int) [][]float64 {
+	cols := make([][]float64, k-1)
+	for j := range cols {
+		cols[j] = make([]float64, len(codes))
+	}
+	for i, c := range codes {
+		if c > 0 {
+			cols[c-1][i] = 1
+		}
+	}
+	return cols
+}
+
+func sortClassLevels(levels []any) {
+	sort.SliceStable(levels, func(a, b int) bool { return classSortKey(levels[a]) < classSortKey(levels[b]) })
+}
@@ -0,0 +1,173 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// ACF computes the sample autocorrelation function of a series for lags
+// 1..maxLag, matching R's acf(x, lag.max = maxLag, plot = FALSE).
+// When maxLag <= 0 the R default floor(10*log10(n)) is used.
+//
+// The returned table has columns Lag, ACF, Lower and Upper, where
+// Lower/Upper is the white-noise band ±z/√n at the given confidence level
+// (default 0.95).
+func ACF(data insyra.IDataList, maxLag int, confidenceLevel ...float64) (*insyra.DataTable, error) {
+	x, cl, err := prepareCorrelogramInput(data, confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+	maxLag, err = resolveMaxLag(len(x), maxLag)
+	if err != nil {
+		return nil, err
+	}
+	acf := autocorrelations(x, maxLag)
+	return correlogramTable("ACF", acf[1:], len(x), cl), nil
+}
+
+// PACF computes the sample partial autocorrelation function for lags
+// 1..maxLag via the Durbin-Levinson recursion, matching R's pacf().
+// The returned table has the same layout as ACF.
+func PACF(data insyra.IDataList, maxLag int, confidenceLevel ...float64) (*insyra.DataTable, error) {
+	x, cl, err := prepareCorrelogramInput(data, confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+	maxLag, err = resolveMaxLag(len(x), maxLag)
+	if err != nil {
+		return nil, err
+	}
+	acf := autocorrelations(x, maxLag)
+	pacf, _ := durbinLevinson(acf, maxLag)
+	return correlogramTable("PACF", pacf, len(x), cl), nil
+}
+
+func prepareCorrelogramInput(data insyra.IDataList, confidenceLevel []float64) ([]float64, float64, error) {
+	if len(confidenceLevel) > 1 {
+		return nil, 0, errors.New("confidenceLevel accepts at most one value")
+	}
+	cl := defaultConfidenceLevel
+	if len(confidenceLevel) == 1 {
+		cl = confidenceLevel[0]
+		if cl <= 0 || cl >= 1 {
+			return nil, 0, errors.New("confidenceLevel must be between 0 and 1")
+		}
+	}
+	x, err := seriesFromDataList(data, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+	return x, cl, nil
+}
+
+func resolveMaxLag(n, maxLag int) (int, error) {
+	if maxLag <= 0 {
+		maxLag = int(math.Floor(10 * math.Log10(float64(n))))
+	}
+	if maxLag >= n {
+		maxLag = n - 1
+	}
+	if maxLag < 1 {
+		return 0, errors.New("series is too short for the requested lag")
+	}
+	return maxLag, nil
+}
+
+// seriesFromDataList reads a finite numeric series of at least minLen
+// observations.
+func seriesFromDataList(data insyra.IDataList, minLen int) ([]float64, error) {
+	if data == nil {
+		return nil, errors.New("series is nil")
+	}
+	var out []float64
+	ok := true
+	data.AtomicDo(func(dl *insyra.DataList) {
+		out = make([]float64, dl.Len())
+		for i := 0; i < dl.Len(); i++ {
+			v, isNum := insyra.ToFloat64Safe(dl.Get(i))
+			if !isNum || math.IsNaN(v) || math.IsInf(v, 0) {
+				ok = false
+				return
+			}
+			out[i] = v
+		}
+	})
+	if !ok {
+		return nil, errors.New("series must contain finite numeric values")
+	}
+	if len(out) < minLen {
+		return nil, errors.New("series has too few observations")
+	}
+	return out, nil
+}
+
+// autocorrelations returns r_0..r_maxLag using the biased (1/n) estimator.
+func autocorrelations(x []float64, maxLag int) []float64 {
+	n := len(x)
+	mean := 0.0
+	for _, v := range x {
+		mean += v
+	}
+	mean /= float64(n)
+	acov := make([]float64, maxLag+1)
+	for k := 0; k <= maxLag; k++ {
+		s := 0.0
+		for t := 0; t+k < n; t++ {
+			s += (x[t] - mean) * (x[t+k] - mean)
+		}
+		acov[k] = s / float64(n)
+	}
+	out := make([]float64, maxLag+1)
+	if acov[0] == 0 {
+		for k := range out {
+			out[k] = math.NaN()
+		}
+		return out
+	}
+	for k := range out {
+		out[k] = acov[k] / acov[0]
+	}
+	return out
+}
+
+// durbinLevinson solves the Yule-Walker equations recursively. It returns
+// the partial autocorrelations for lags 1..order and the AR(order)
+// coefficients.
+func durbinLevinson(acf []float64, order int) ([]float64, []float64) {
+	pacf := make([]float64, order)
+	phi := make([]float64, order)
+	prev := make([]float64, order)
+	v := 1.0
+	for k := 1; k <= order; k++ {
+		num := acf[k]
+		for j := 1; j < k; j++ {
+			num -= prev[j-1] * acf[k-j]
+		}
+		a := num / v
+		phi[k-1] = a
+		for j := 1; j < k; j++ {
+			phi[j-1] = prev[j-1] - a*prev[k-j-1]
+		}
+		v *= 1 - a*a
+		pacf[k-1] = a
+		copy(prev, phi)
+	}
+	return pacf, phi
+}
+
+func correlogramTable(name string, values []float64, n int, cl float64) *insyra.DataTable {
+	bound := zQuantile((1+cl)/2) / math.Sqrt(float64(n))
+	lags := insyra.NewDataList().SetName("Lag")
+	vals := insyra.NewDataList().SetName(name)
+	lower := insyra.NewDataList().SetName("Lower")
+	upper := insyra.NewDataList().SetName("Upper")
+	for i, v := range values {
+		lags.Append(i + 1)
+		vals.Append(v)
+		lower.Append(-bound)
+		upper.Append(bound)
+	}
+	return insyra.NewDataTable(lags, vals, lower, upper)
+}
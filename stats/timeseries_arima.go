@@ -0,0 +1,683 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+// ARIMAOrder is a (p, d, q) order triple.
+type ARIMAOrder struct {
+	P, D, Q int
+}
+
+type ARIMAOptions struct {
+	Order ARIMAOrder
+	// Seasonal is the (P, D, Q) seasonal order; Period must be >= 2 when it
+	// is non-zero.
+	Seasonal ARIMAOrder
+	Period   int
+	// IncludeMean adds an intercept for undifferenced models (default
+	// true). It is ignored once d + D > 0, as in R's arima().
+	IncludeMean *bool
+	MaxIter     int
+}
+
+// ARIMAResult holds an (S)ARIMA fit estimated by exact Gaussian maximum
+// likelihood (CSS starting values, Kalman filter likelihood), mirroring
+// R's arima(method = "CSS-ML").
+//
+// Coefficients are ordered ar1..arp, ma1..maq, sar1..sarP, sma1..smaQ,
+// intercept, with matching CoefficientNames. Residuals are the
+// standardized innovations of the differenced series.
+type ARIMAResult struct {
+	Order            ARIMAOrder
+	Seasonal         ARIMAOrder
+	Period           int
+	CoefficientNames []string
+	Coefficients     []float64
+	StandardErrors   []float64
+	ZValues          []float64
+	PValues          []float64
+	Sigma2           float64
+	LogLikelihood    float64
+	AIC              float64
+	AICc             float64
+	BIC              float64
+	Residuals        []float64
+	NObs             int
+	Converged        bool
+	Iterations       int
+
+	series   []float64
+	phi      []float64
+	theta    []float64
+	mean     float64
+	diffPoly []float64
+	state    []float64
+}
+
+// ARIMA fits a seasonal ARIMA(p,d,q)(P,D,Q)[s] model to a series.
+func ARIMA(data insyra.IDataList, opts ARIMAOptions) (*ARIMAResult, error) {
+	ord, sea := opts.Order, opts.Seasonal
+	if ord.P < 0 || ord.D < 0 || ord.Q < 0 || sea.P < 0 || sea.D < 0 || sea.Q < 0 {
+		return nil, errors.New("ARIMA orders must be non-negative")
+	}
+	seasonal := sea.P+sea.D+sea.Q > 0
+	period := opts.Period
+	if seasonal && period < 2 {
+		return nil, errors.New("period must be at least 2 for a seasonal model")
+	}
+	if !seasonal {
+		period = 1
+	}
+	x, err := seriesFromDataList(data, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	diffPoly := arimaDifferencePolynomial(ord.D, sea.D, period)
+	w := applyDifference(x, diffPoly)
+	includeMean := ord.D+sea.D == 0
+	if includeMean && opts.IncludeMean != nil {
+		includeMean = *opts.IncludeMean
+	}
+	narma := ord.P + ord.Q + sea.P + sea.Q
+	npar := narma
+	if includeMean {
+		npar++
+	}
+	m := len(w)
+	if m <= npar+1 {
+		return nil, errors.New("series is too short for the requested ARIMA order")
+	}
+
+	spec := arimaSpec{p: ord.P, q: ord.Q, sp: sea.P, sq: sea.Q, s: period, mean: includeMean}
+	meanW := 0.0
+	for _, v := range w {
+		meanW += v
+	}
+	meanW /= float64(m)
+	var parscale []float64
+	if includeMean {
+		parscale = make([]float64, npar)
+		for i := range parscale {
+			parscale[i] = 1
+		}
+		sd := 0.0
+		for _, v := range w {
+			sd += (v - meanW) * (v - meanW)
+		}
+		sd = math.Sqrt(sd / float64(m-1))
+		parscale[npar-1] = math.Max(10*sd/math.Sqrt(float64(m)), 1e-4)
+	}
+
+	coef := make([]float64, npar)
+	if includeMean {
+		coef[npar-1] = meanW
+	}
+	converged := true
+	iterations := 0
+	maxIter := opts.MaxIter
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+	if npar > 0 {
+		// Conditional sum of squares for starting values.
+		css := func(par []float64) float64 {
+			phi, theta, mu := spec.expand(par)
+			return arimaCSS(w, mu, phi, theta)
+		}
+		if res, err := minimizeNumeric(css, coef, boundedMinimizeOptions{parscale: parscale, maxIter: maxIter}); err == nil {
+			start := res.X
+			if arStationary(start[:spec.p]) && arStationary(start[spec.p+spec.q:spec.p+spec.q+spec.sp]) {
+				copy(coef, start)
+			}
+		}
+
+		// Exact likelihood on the transformed (stationary) parameters.
+		raw := spec.invTransform(coef)
+		ml := func(par []float64) float64 {
+			phi, theta, mu := spec.expand(spec.transform(par))
+			return arimaExactObjective(w, mu, phi, theta)
+		}
+		res, err := minimizeNumeric(ml, raw, boundedMinimizeOptions{parscale: parscale, maxIter: maxIter})
+		if err != nil {
+			return nil, fmt.Errorf("ARIMA optimisation failed: %w", err)
+		}
+		coef = spec.transform(res.X)
+		converged = res.Converged
+		iterations = res.Iters
+	}
+
+	phi, theta, mu := spec.expand(coef)
+	kf := arimaKalman(w, mu, phi, theta, true)
+	if kf == nil {
+		return nil, errors.New("non-stationary AR part in fitted ARIMA model")
+	}
+	sigma2 := kf.ssq / float64(kf.nu)
+	obj := 0.5 * (math.Log(sigma2) + kf.sumlog/float64(kf.nu))
+	mf := float64(m)
+	logLik := -0.5 * (2*mf*obj + mf + mf*math.Log(2*math.Pi))
+	k := float64(npar + 1)
+	aic := -2*logLik + 2*k
+	aicc := math.NaN()
+	if mf-k-1 > 0 {
+		aicc = aic + 2*k*(k+1)/(mf-k-1)
+	}
+
+	se := make([]float64, npar)
+	zv := make([]float64, npar)
+	pv := make([]float64, npar)
+	for i := range se {
+		se[i], zv[i], pv[i] = math.NaN(), math.NaN(), math.NaN()
+	}
+	if npar > 0 {
+		exact := func(par []float64) float64 {
+			ph, th, mean := spec.expand(par)
+			return arimaExactObjective(w, mean, ph, th)
+		}
+		h := numericHessian(exact, coef, parscale)
+		for i := range h {
+			for j := range h[i] {
+				h[i][j] *= mf
+			}
+		}
+		if inv := invertSymmetric(h); inv != nil {
+			for i := range se {
+				if inv[i][i] > 0 {
+					se[i] = math.Sqrt(inv[i][i])
+					zv[i] = coef[i] / se[i]
+					pv[i] = 2 * (1 - zCDF(math.Abs(zv[i])))
+				}
+			}
+		}
+	}
+
+	return &ARIMAResult{
+		Order:            ord,
+		Seasonal:         sea,
+		Period:           opts.Period,
+		CoefficientNames: spec.names(),
+		Coefficients:     coef,
+		StandardErrors:   se,
+		ZValues:          zv,
+		PValues:          pv,
+		Sigma2:           sigma2,
+		LogLikelihood:    logLik,
+		AIC:              aic,
+		AICc:             aicc,
+		BIC:              -2*logLik + math.Log(mf)*k,
+		Residuals:        kf.resid,
+		NObs:             m,
+		Converged:        converged,
+		Iterations:       iterations,
+		series:           x,
+		phi:              phi,
+		theta:            theta,
+		mean:             mu,
+		diffPoly:         diffPoly,
+		state:            kf.state,
+	}, nil
+}
+
+// Forecast returns h-step-ahead forecasts on the original scale with
+// prediction intervals at the given confidence level (default 0.95).
+// The table has columns Step, Forecast, StdError, Lower and Upper.
+func (r *ARIMAResult) Forecast(h int, confidenceLevel ...float64) (*insyra.DataTable, error) {
+	if r == nil {
+		return nil, errors.New("nil ARIMA result")
+	}
+	if h < 1 {
+		return nil, errors.New("forecast horizon must be positive")
+	}
+	cl, err := forecastConfidenceLevel(confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forecast the differenced (stationary) series from the filtered state.
+	rdim := len(r.state)
+	a := append([]float64(nil), r.state...)
+	wHat := make([]float64, h)
+	for step := range h {
+		next := make([]float64, rdim)
+		for i := range rdim {
+			if i < len(r.phi) {
+				next[i] += r.phi[i] * a[0]
+			}
+			if i+1 < rdim {
+				next[i] += a[i+1]
+			}
+		}
+		a = next
+		wHat[step] = a[0] + r.mean
+	}
+
+	// Integrate back through the difference polynomial.
+	nd := len(r.diffPoly) - 1
+	hist := append([]float64(nil), r.series...)
+	point := make([]float64, h)
+	for step := range h {
+		v := wHat[step]
+		t := len(hist)
+		for i := 1; i <= nd; i++ {
+			v -= r.diffPoly[i] * hist[t-i]
+		}
+		hist = append(hist, v)
+		point[step] = v
+	}
+
+	// Prediction variance from the psi-weights of the full model.
+	fullAR := polyMultiply(arPolynomial(r.phi), r.diffPoly)
+	psi := make([]float64, h)
+	psi[0] = 1
+	for j := 1; j < h; j++ {
+		v := 0.0
+		if j-1 < len(r.theta) {
+			v = r.theta[j-1]
+		}
+		for i := 1; i < len(fullAR) && i <= j; i++ {
+			v -= fullAR[i] * psi[j-i]
+		}
+		psi[j] = v
+	}
+	zq := zQuantile((1 + cl) / 2)
+	steps := insyra.NewDataList().SetName("Step")
+	fc := insyra.NewDataList().SetName("Forecast")
+	ses := insyra.NewDataList().SetName("StdError")
+	lower := insyra.NewDataList().SetName("Lower")
+	upper := insyra.NewDataList().SetName("Upper")
+	cum := 0.0
+	for step := range h {
+		cum += psi[step] * psi[step]
+		se := math.Sqrt(r.Sigma2 * cum)
+		steps.Append(step + 1)
+		fc.Append(point[step])
+		ses.Append(se)
+		lower.Append(point[step] - zq*se)
+		upper.Append(point[step] + zq*se)
+	}
+	return insyra.NewDataTable(steps, fc, ses, lower, upper), nil
+}
+
+func forecastConfidenceLevel(confidenceLevel []float64) (float64, error) {
+	if len(confidenceLevel) > 1 {
+		return 0, errors.New("confidenceLevel accepts at most one value")
+	}
+	if len(confidenceLevel) == 0 {
+		return defaultConfidenceLevel, nil
+	}
+	cl := confidenceLevel[0]
+	if cl <= 0 || cl >= 1 {
+		return 0, errors.New("confidenceLevel must be between 0 and 1")
+	}
+	return cl, nil
+}
+
+// arimaSpec describes the layout of the ARIMA parameter vector:
+// ar(p), ma(q), sar(P), sma(Q), intercept.
+type arimaSpec struct {
+	p, q, sp, sq, s int
+	mean            bool
+}
+
+func (s arimaSpec) names() []string {
+	var out []string
+	for i := 1; i <= s.p; i++ {
+		out = append(out, fmt.Sprintf("ar%d", i))
+	}
+	for i := 1; i <= s.q; i++ {
+		out = append(out, fmt.Sprintf("ma%d", i))
+	}
+	for i := 1; i <= s.sp; i++ {
+		out = append(out, fmt.Sprintf("sar%d", i))
+	}
+	for i := 1; i <= s.sq; i++ {
+		out = append(out, fmt.Sprintf("sma%d", i))
+	}
+	if s.mean {
+		out = append(out, "intercept")
+	}
+	return out
+}
+
+// expand multiplies out the seasonal polynomials into the full AR and MA
+// coefficient vectors and returns the mean.
+func (s arimaSpec) expand(par []float64) (phi, theta []float64, mean float64) {
+	ar := par[:s.p]
+	ma := par[s.p : s.p+s.q]
+	sar := par[s.p+s.q : s.p+s.q+s.sp]
+	sma := par[s.p+s.q+s.sp : s.p+s.q+s.sp+s.sq]
+	phi = make([]float64, s.p+s.s*s.sp)
+	theta = make([]float64, s.q+s.s*s.sq)
+	copy(phi, ar)
+	copy(theta, ma)
+	for j := range sar {
+		phi[(j+1)*s.s-1] += sar[j]
+		for i := range ar {
+			phi[(j+1)*s.s+i] -= ar[i] * sar[j]
+		}
+	}
+	for j := range sma {
+		theta[(j+1)*s.s-1] += sma[j]
+		for i := range ma {
+			theta[(j+1)*s.s+i] += ma[i] * sma[j]
+		}
+	}
+	if s.mean {
+		mean = par[len(par)-1]
+	}
+	return phi, theta, mean
+}
+
+// transform maps unconstrained parameters to stationary AR blocks.
+func (s arimaSpec) transform(raw []float64) []float64 {
+	out := append([]float64(nil), raw...)
+	partrans(out[:s.p])
+	partrans(out[s.p+s.q : s.p+s.q+s.sp])
+	return out
+}
+
+func (s arimaSpec) invTransform(par []float64) []float64 {
+	out := append([]float64(nil), par...)
+	invPartrans(out[:s.p])
+	invPartrans(out[s.p+s.q : s.p+s.q+s.sp])
+	return out
+}
+
+// partrans maps R^p onto the stationary region through tanh-transformed
+// partial autocorrelations and the Durbin-Levinson recursion (in place).
+func partrans(x []float64) {
+	p := len(x)
+	work := make([]float64, p)
+	for j := range p {
+		x[j] = math.Tanh(x[j])
+		work[j] = x[j]
+	}
+	for j := 1; j < p; j++ {
+		a := x[j]
+		for k := range j {
+			work[k] -= a * x[j-k-1]
+		}
+		copy(x[:j], work[:j])
+	}
+}
+
+func invPartrans(x []float64) {
+	p := len(x)
+	work := append([]float64(nil), x...)
+	for j := p - 1; j > 0; j-- {
+		a := x[j]
+		for k := range j {
+			work[k] = (x[k] + a*x[j-k-1]) / (1 - a*a)
+		}
+		copy(x[:j], work[:j])
+	}
+	for j := range p {
+		x[j] = math.Atanh(math.Max(-0.999999, math.Min(0.999999, x[j])))
+	}
+}
+
+// arStationary reports whether all roots of 1 - sum(ar_i z^i) lie outside
+// the unit circle, using the inverse Durbin-Levinson recursion.
+func arStationary(ar []float64) bool {
+	x := append([]float64(nil), ar...)
+	p := len(x)
+	for j := p - 1; j >= 0; j-- {
+		a := x[j]
+		if math.Abs(a) >= 1 || math.IsNaN(a) {
+			return false
+		}
+		work := make([]float64, j)
+		for k := range j {
+			work[k] = (x[k] + a*x[j-k-1]) / (1 - a*a)
+		}
+		copy(x[:j], work)
+	}
+	return true
+}
+
+func arimaCSS(w []float64, mean float64, phi, theta []float64) float64 {
+	n := len(w)
+	ncond := len(phi)
+	resid := make([]float64, n)
+	ssq, nu := 0.0, 0
+	for l := ncond; l < n; l++ {
+		tmp := w[l] - mean
+		for j := range phi {
+			tmp -= phi[j] * (w[l-j-1] - mean)
+		}
+		for j := 0; j < len(theta) && j < l-ncond; j++ {
+			tmp -= theta[j] * resid[l-j-1]
+		}
+		resid[l] = tmp
+		ssq += tmp * tmp
+		nu++
+	}
+	if nu == 0 {
+		return math.Inf(1)
+	}
+	return 0.5 * math.Log(ssq/float64(nu))
+}
+
+// arimaExactObjective is the concentrated negative log-likelihood per
+// observation used by R's arima(): 0.5*(log(ssq/n) + sum(log F_t)/n).
+func arimaExactObjective(w []float64, mean float64, phi, theta []float64) float64 {
+	kf := arimaKalman(w, mean, phi, theta, false)
+	if kf == nil || kf.nu == 0 || kf.ssq <= 0 {
+		return math.Inf(1)
+	}
+	n := float64(kf.nu)
+	return 0.5 * (math.Log(kf.ssq/n) + kf.sumlog/n)
+}
+
+type arimaFilterResult struct {
+	ssq, sumlog float64
+	nu          int
+	resid       []float64
+	state       []float64
+}
+
+// arimaKalman runs the Kalman filter on the Harvey state-space form of a
+// stationary ARMA model with unit innovation variance. The initial state
+// covariance is the stationary solution of P = TPT' + RR'. It returns nil
+// when the AR part is not stationary.
+func arimaKalman(w []float64, mean float64, phi, theta []float64, wantResid bool) *arimaFilterResult {
+	p, q := len(phi), len(theta)
+	r := max(p, q+1)
+	if !arStationary(phi) {
+		return nil
+	}
+	rv := make([]float64, r)
+	rv[0] = 1
+	copy(rv[1:], theta)
+	P := arimaInitialCovariance(phi, rv, r)
+	if P == nil {
+		return nil
+	}
+	a := make([]float64, r)
+	anew := make([]float64, r)
+	Pnew := make([]float64, r*r)
+	tp := make([]float64, r*r)
+	out := &arimaFilterResult{}
+	if wantResid {
+		out.resid = make([]float64, len(w))
+	}
+	for t, obs := range w {
+		if t > 0 {
+			// anew = T a
+			for i := range r {
+				v := 0.0
+				if i < p {
+					v = phi[i] * a[0]
+				}
+				if i+1 < r {
+					v += a[i+1]
+				}
+				anew[i] = v
+			}
+			// tp = T P
+			for j := range r {
+				for i := range r {
+					v := 0.0
+					if i < p {
+						v = phi[i] * P[j]
+					}
+					if i+1 < r {
+						v += P[(i+1)*r+j]
+					}
+					tp[i*r+j] = v
+				}
+			}
+			// Pnew = tp T' + R R'
+			for i := range r {
+				for j := range r {
+					v := 0.0
+					if j < p {
+						v = tp[i*r] * phi[j]
+					}
+					if j+1 < r {
+						v += tp[i*r+j+1]
+					}
+					Pnew[i*r+j] = v + rv[i]*rv[j]
+				}
+			}
+		} else {
+			copy(anew, a)
+			copy(Pnew, P)
+		}
+		F := Pnew[0]
+		if F <= 0 || math.IsNaN(F) {
+			return nil
+		}
+		v := obs - mean - anew[0]
+		for i := range r {
+			a[i] = anew[i] + Pnew[i*r]*v/F
+		}
+		for i := range r {
+			for j := range r {
+				P[i*r+j] = Pnew[i*r+j] - Pnew[i*r]*Pnew[j]/F
+			}
+		}
+		out.ssq += v * v / F
+		out.sumlog += math.Log(F)
+		out.nu++
+		if wantResid {
+			out.resid[t] = v / math.Sqrt(F)
+		}
+	}
+	out.state = a
+	return out
+}
+
+// arimaInitialCovariance solves P = T P T' + R R' with the doubling
+// algorithm (P_{k+1} = P_k + A_k P_k A_k', A_{k+1} = A_k^2).
+func arimaInitialCovariance(phi, rv []float64, r int) []float64 {
+	P := make([]float64, r*r)
+	for i := range r {
+		for j := range r {
+			P[i*r+j] = rv[i] * rv[j]
+		}
+	}
+	A := make([]float64, r*r)
+	for i := range r {
+		if i < len(phi) {
+			A[i*r] = phi[i]
+		}
+		if i+1 < r {
+			A[i*r+i+1] = 1
+		}
+	}
+	tmp := make([]float64, r*r)
+	next := make([]float64, r*r)
+	for range 100 {
+		matMulFlat(tmp, A, P, r, false)
+		matMulFlat(next, tmp, A, r, true)
+		change, scale := 0.0, 0.0
+		for i := range next {
+			change = math.Max(change, math.Abs(next[i]))
+			P[i] += next[i]
+			scale = math.Max(scale, math.Abs(P[i]))
+		}
+		if math.IsNaN(change) || math.IsInf(scale, 0) {
+			return nil
+		}
+		if change <= 1e-14*math.Max(1, scale) {
+			return P
+		}
+		matMulFlat(tmp, A, A, r, false)
+		copy(A, tmp)
+	}
+	return P
+}
+
+// matMulFlat writes a*b (or a*b' when transB) for r×r row-major matrices.
+func matMulFlat(dst, a, b []float64, r int, transB bool) {
+	for i := range r {
+		for j := range r {
+			s := 0.0
+			for k := range r {
+				if transB {
+					s += a[i*r+k] * b[j*r+k]
+				} else {
+					s += a[i*r+k] * b[k*r+j]
+				}
+			}
+			dst[i*r+j] = s
+		}
+	}
+}
+
+// arimaDifferencePolynomial returns the coefficients of (1-B)^d (1-B^s)^D
+// starting at B^0.
+func arimaDifferencePolynomial(d, sd, period int) []float64 {
+	poly := []float64{1}
+	for range d {
+		poly = polyMultiply(poly, []float64{1, -1})
+	}
+	seasonal := make([]float64, period+1)
+	seasonal[0], seasonal[period] = 1, -1
+	for range sd {
+		poly = polyMultiply(poly, seasonal)
+	}
+	return poly
+}
+
+func applyDifference(x, poly []float64) []float64 {
+	nd := len(poly) - 1
+	if nd >= len(x) {
+		return nil
+	}
+	out := make([]float64, len(x)-nd)
+	for t := nd; t < len(x); t++ {
+		v := 0.0
+		for i, c := range poly {
+			v += c * x[t-i]
+		}
+		out[t-nd] = v
+	}
+	return out
+}
+
+// arPolynomial returns 1 - sum(phi_i B^i) as coefficients from B^0.
+func arPolynomial(phi []float64) []float64 {
+	out := make([]float64, len(phi)+1)
+	out[0] = 1
+	for i, v := range phi {
+		out[i+1] = -v
+	}
+	return out
+}
+
+func polyMultiply(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
@@ -0,0 +1,402 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+type HoltWintersSeasonal string
+
+const (
+	HoltWintersAdditive       HoltWintersSeasonal = "additive"
+	HoltWintersMultiplicative HoltWintersSeasonal = "multiplicative"
+	HoltWintersNonSeasonal    HoltWintersSeasonal = "none"
+)
+
+type HoltWintersOptions struct {
+	// Period is the seasonal frequency. Seasonal models need at least two
+	// full periods of data.
+	Period int
+	// Seasonal defaults to additive when Period >= 2, otherwise none.
+	Seasonal HoltWintersSeasonal
+	// NoTrend drops the trend component (beta = FALSE in R).
+	NoTrend bool
+	// Alpha, Beta and Gamma fix the smoothing parameters; nil parameters
+	// are estimated by minimising the one-step-ahead squared error.
+	Alpha, Beta, Gamma *float64
+}
+
+// HoltWintersResult holds a fitted Holt-Winters filter, mirroring R's
+// HoltWinters(). Level, Trend and SeasonalCoefficients are the final states
+// used for forecasting; Fitted and Residuals start at the first filtered
+// observation.
+type HoltWintersResult struct {
+	Alpha                float64
+	Beta                 float64
+	Gamma                float64
+	Level                float64
+	Trend                float64
+	SeasonalCoefficients []float64
+	Seasonal             HoltWintersSeasonal
+	Period               int
+	SSE                  float64
+	Fitted               []float64
+	Residuals            []float64
+}
+
+// HoltWinters fits triple (or double, or simple) exponential smoothing.
+func HoltWinters(data insyra.IDataList, opts HoltWintersOptions) (*HoltWintersResult, error) {
+	x, err := seriesFromDataList(data, 3)
+	if err != nil {
+		return nil, err
+	}
+	seasonal := opts.Seasonal
+	if seasonal == "" {
+		seasonal = HoltWintersNonSeasonal
+		if opts.Period >= 2 {
+			seasonal = HoltWintersAdditive
+		}
+	}
+	switch seasonal {
+	case HoltWintersAdditive, HoltWintersMultiplicative, HoltWintersNonSeasonal:
+	default:
+		return nil, errors.New("unsupported Holt-Winters seasonal type")
+	}
+	for _, p := range []*float64{opts.Alpha, opts.Beta, opts.Gamma} {
+		if p != nil && (*p < 0 || *p > 1) {
+			return nil, errors.New("smoothing parameters must be in [0, 1]")
+		}
+	}
+
+	hw := holtWintersFilter{x: x, seasonal: seasonal, trend: !opts.NoTrend, period: 1}
+	if seasonal != HoltWintersNonSeasonal {
+		if opts.Period < 2 {
+			return nil, errors.New("period must be at least 2 for a seasonal model")
+		}
+		if len(x) < 2*opts.Period+1 {
+			return nil, errors.New("need at least two full periods of data")
+		}
+		if seasonal == HoltWintersMultiplicative {
+			for _, v := range x {
+				if v <= 0 {
+					return nil, errors.New("multiplicative seasonality requires positive data")
+				}
+			}
+		}
+		hw.period = opts.Period
+		if err := hw.initSeasonal(); err != nil {
+			return nil, err
+		}
+	} else {
+		hw.startTime = 3
+		hw.level0 = x[1]
+		hw.trend0 = x[1] - x[0]
+		if !hw.trend {
+			hw.startTime = 2
+			hw.level0 = x[0]
+			hw.trend0 = 0
+		}
+	}
+
+	// Collect the free parameters in alpha, beta, gamma order.
+	fixed := [3]*float64{opts.Alpha, opts.Beta, opts.Gamma}
+	used := [3]bool{true, hw.trend, seasonal != HoltWintersNonSeasonal}
+	starts := [3]float64{0.3, 0.1, 0.1}
+	params := [3]float64{}
+	var free []int
+	for i := range 3 {
+		switch {
+		case !used[i]:
+			params[i] = 0
+		case fixed[i] != nil:
+			params[i] = *fixed[i]
+		default:
+			free = append(free, i)
+			params[i] = starts[i]
+		}
+	}
+	if len(free) > 0 {
+		start := make([]float64, len(free))
+		lower := make([]float64, len(free))
+		upper := make([]float64, len(free))
+		for j, i := range free {
+			start[j] = params[i]
+			upper[j] = 1
+		}
+		sse := func(p []float64) float64 {
+			trial := params
+			for j, i := range free {
+				trial[i] = math.Max(0, math.Min(1, p[j]))
+			}
+			return hw.run(trial[0], trial[1], trial[2]).sse
+		}
+		res, err := minimizeNumeric(sse, start, boundedMinimizeOptions{lower: lower, upper: upper})
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range free {
+			params[i] = res.X[j]
+		}
+	}
+
+	fit := hw.run(params[0], params[1], params[2])
+	return &HoltWintersResult{
+		Alpha:                params[0],
+		Beta:                 params[1],
+		Gamma:                params[2],
+		Level:                fit.level,
+		Trend:                fit.trend,
+		SeasonalCoefficients: fit.season,
+		Seasonal:             seasonal,
+		Period:               hw.period,
+		SSE:                  fit.sse,
+		Fitted:               fit.fitted,
+		Residuals:            fit.resid,
+	}, nil
+}
+
+// Forecast returns h-step-ahead forecasts with prediction intervals
+// (default 0.95) from R's predict.HoltWinters variance formula. The
+// additive formula is also used for multiplicative models, so their
+// intervals are approximate. The table has columns Step, Forecast,
+// StdError, Lower and Upper.
+func (r *HoltWintersResult) Forecast(h int, confidenceLevel ...float64) (*insyra.DataTable, error) {
+	if r == nil {
+		return nil, errors.New("nil Holt-Winters result")
+	}
+	if h < 1 {
+		return nil, errors.New("forecast horizon must be positive")
+	}
+	cl, err := forecastConfidenceLevel(confidenceLevel)
+	if err != nil {
+		return nil, err
+	}
+	n := len(r.Residuals)
+	mean := 0.0
+	for _, v := range r.Residuals {
+		mean += v
+	}
+	mean /= float64(n)
+	variance := 0.0
+	for _, v := range r.Residuals {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(n - 1)
+
+	zq := zQuantile((1 + cl) / 2)
+	steps := insyra.NewDataList().SetName("Step")
+	fc := insyra.NewDataList().SetName("Forecast")
+	ses := insyra.NewDataList().SetName("StdError")
+	lower := insyra.NewDataList().SetName("Lower")
+	upper := insyra.NewDataList().SetName("Upper")
+	cum := 1.0
+	for i := 1; i <= h; i++ {
+		point := r.Level + float64(i)*r.Trend
+		if len(r.SeasonalCoefficients) > 0 {
+			s := r.SeasonalCoefficients[(i-1)%r.Period]
+			if r.Seasonal == HoltWintersMultiplicative {
+				point *= s
+			} else {
+				point += s
+			}
+		}
+		if i > 1 {
+			j := float64(i - 1)
+			psi := r.Alpha * (1 + j*r.Beta)
+			if r.Period > 1 && (i-1)%r.Period == 0 {
+				psi += r.Gamma * (1 - r.Alpha)
+			}
+			cum += psi * psi
+		}
+		se := math.Sqrt(variance * cum)
+		steps.Append(i)
+		fc.Append(point)
+		ses.Append(se)
+		lower.Append(point - zq*se)
+		upper.Append(point + zq*se)
+	}
+	return insyra.NewDataTable(steps, fc, ses, lower, upper), nil
+}
+
+type holtWintersFilter struct {
+	x         []float64
+	seasonal  HoltWintersSeasonal
+	trend     bool
+	period    int
+	startTime int // 1-based, as in R
+	level0    float64
+	trend0    float64
+	season0   []float64
+}
+
+type holtWintersRun struct {
+	sse           float64
+	level, trend  float64
+	season        []float64
+	fitted, resid []float64
+}
+
+// initSeasonal reproduces R's start values: a classical decomposition of
+// the first two periods, a linear fit on its trend, and the seasonal
+// figure.
+func (hw *holtWintersFilter) initSeasonal() error {
+	f := hw.period
+	wind := 2 * f
+	xs := hw.x[:wind]
+	trendMA := centeredMovingAverage(xs, f)
+	detr := make([]float64, wind)
+	for i := range wind {
+		switch {
+		case math.IsNaN(trendMA[i]):
+			detr[i] = math.NaN()
+		case hw.seasonal == HoltWintersMultiplicative:
+			detr[i] = xs[i] / trendMA[i]
+		default:
+			detr[i] = xs[i] - trendMA[i]
+		}
+	}
+	figure := make([]float64, f)
+	for i := range f {
+		sum, cnt := 0.0, 0
+		for j := i; j < wind; j += f {
+			if !math.IsNaN(detr[j]) {
+				sum += detr[j]
+				cnt++
+			}
+		}
+		figure[i] = sum / float64(cnt)
+	}
+	avg := 0.0
+	for _, v := range figure {
+		avg += v
+	}
+	avg /= float64(f)
+	for i := range figure {
+		if hw.seasonal == HoltWintersMultiplicative {
+			figure[i] /= avg
+		} else {
+			figure[i] -= avg
+		}
+	}
+	var dat []float64
+	for _, v := range trendMA {
+		if !math.IsNaN(v) {
+			dat = append(dat, v)
+		}
+	}
+	if len(dat) < 2 {
+		return errors.New("not enough data to initialise the seasonal model")
+	}
+	t := make([]float64, len(dat))
+	for i := range t {
+		t[i] = float64(i + 1)
+	}
+	intercept, slope, ok := simpleOLSCoeffs(t, dat)
+	if !ok {
+		return errors.New("not enough data to initialise the seasonal model")
+	}
+	hw.level0 = intercept
+	hw.trend0 = slope
+	if !hw.trend {
+		hw.trend0 = 0
+	}
+	hw.season0 = figure
+	hw.startTime = f + 1
+	return nil
+}
+
+func (hw *holtWintersFilter) run(alpha, beta, gamma float64) holtWintersRun {
+	x := hw.x
+	f := hw.period
+	seasonalOn := hw.seasonal != HoltWintersNonSeasonal
+	mult := hw.seasonal == HoltWintersMultiplicative
+	n := len(x) - hw.startTime + 1
+	level := make([]float64, n+1)
+	trend := make([]float64, n+1)
+	level[0] = hw.level0
+	trend[0] = hw.trend0
+	var season []float64
+	if seasonalOn {
+		season = make([]float64, n+f)
+		copy(season, hw.season0)
+	}
+	out := holtWintersRun{fitted: make([]float64, n), resid: make([]float64, n)}
+	for i := hw.startTime - 1; i < len(x); i++ {
+		i0 := i - hw.startTime + 2
+		s0 := i0 + f - 1
+		xhat := level[i0-1] + trend[i0-1]
+		stmp := 0.0
+		if seasonalOn {
+			stmp = season[s0-f]
+			if mult {
+				xhat *= stmp
+			} else {
+				xhat += stmp
+			}
+		}
+		res := x[i] - xhat
+		out.fitted[i0-1] = xhat
+		out.resid[i0-1] = res
+		out.sse += res * res
+		deseason := x[i]
+		if seasonalOn {
+			if mult {
+				deseason = x[i] / stmp
+			} else {
+				deseason = x[i] - stmp
+			}
+		}
+		level[i0] = alpha*deseason + (1-alpha)*(level[i0-1]+trend[i0-1])
+		if hw.trend {
+			trend[i0] = beta*(level[i0]-level[i0-1]) + (1-beta)*trend[i0-1]
+		}
+		if seasonalOn {
+			if mult {
+				season[s0] = gamma*(x[i]/level[i0]) + (1-gamma)*stmp
+			} else {
+				season[s0] = gamma*(x[i]-level[i0]) + (1-gamma)*stmp
+			}
+		}
+	}
+	out.level = level[n]
+	out.trend = trend[n]
+	if seasonalOn {
+		out.season = append([]float64(nil), season[n:n+f]...)
+	}
+	return out
+}
+
+// centeredMovingAverage is filter(x, sides = 2) with the classical
+// decomposition weights: a 2×f average for even f and an f-term average for
+// odd f. Positions without a full window are NaN.
+func centeredMovingAverage(x []float64, f int) []float64 {
+	var w []float64
+	if f%2 == 0 {
+		w = make([]float64, f+1)
+		for i := range w {
+			w[i] = 1 / float64(f)
+		}
+		w[0], w[f] = 0.5/float64(f), 0.5/float64(f)
+	} else {
+		w = make([]float64, f)
+		for i := range w {
+			w[i] = 1 / float64(f)
+		}
+	}
+	half := len(w) / 2
+	out := make([]float64, len(x))
+	for i := range x {
+		if i-half < 0 || i+half >= len(x) {
+			out[i] = math.NaN()
+			continue
+		}
+		s := 0.0
+		for j, wj := range w {
+			s += wj * x[i-half+j]
+		}
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,268 @@
+package stats_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// lhSeries is R's datasets::lh (48 luteinizing hormone samples).
+var lhSeries = []float64{
+	2.4, 2.4, 2.4, 2.2, 2.1, 1.5, 2.3, 2.3, 2.5, 2.0, 1.9, 1.7, 2.2, 1.8, 3.2, 3.2,
+	2.7, 2.2, 2.2, 1.9, 1.9, 1.8, 2.7, 3.0, 2.3, 2.0, 2.0, 2.9, 2.9, 2.7, 2.7, 2.3,
+	2.6, 2.4, 1.8, 1.7, 1.5, 1.4, 2.1, 3.3, 3.5, 3.5, 3.1, 2.6, 2.1, 3.4, 3.0, 2.9,
+}
+
+// usAccDeaths is R's datasets::USAccDeaths (monthly, 1973-1978).
+var usAccDeaths = []float64{
+	9007, 8106, 8928, 9137, 10017, 10826, 11317, 10744, 9713, 9938, 9161, 8927,
+	7750, 6981, 8038, 8422, 8714, 9512, 10120, 9823, 8743, 9129, 8710, 8680,
+	8162, 7306, 8124, 7870, 9387, 9556, 10093, 9620, 8285, 8466, 8160, 8034,
+	7717, 7461, 7767, 7925, 8623, 8945, 10078, 9179, 8037, 8488, 7874, 8647,
+	7792, 6957, 7726, 8106, 8890, 9299, 10625, 9302, 8314, 8850, 8265, 8796,
+	7836, 6892, 7791, 8192, 9115, 9434, 10484, 9827, 9110, 9070, 8633, 9240,
+}
+
+func seededNormals(seed uint64, n int) []float64 {
+	rng := rand.New(rand.NewPCG(seed, seed^0x9E3779B97F4A7C15))
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = rng.NormFloat64()
+	}
+	return out
+}
+
+func TestACFAndPACF(t *testing.T) {
+	acf, err := stats.ACF(insyra.NewDataList(lhSeries), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := acf.Size(); r != 5 || c != 4 {
+		t.Fatalf("ACF size = %dx%d, want 5x4", r, c)
+	}
+	// R: acf(lh, plot = FALSE) prints 0.576 at lag 1.
+	if got := acf.GetColByName("ACF").Get(0).(float64); !floatAlmostEqual(got, 0.576, 5e-4) {
+		t.Errorf("ACF lag 1 = %v, want 0.576", got)
+	}
+	band := acf.GetColByName("Upper").Get(0).(float64)
+	if !floatAlmostEqual(band, 1.959964/math.Sqrt(48), 1e-6) {
+		t.Errorf("white-noise band = %v", band)
+	}
+
+	pacf, err := stats.PACF(insyra.NewDataList(lhSeries), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first partial autocorrelation always equals the first ACF value.
+	p1 := pacf.GetColByName("PACF").Get(0).(float64)
+	a1 := acf.GetColByName("ACF").Get(0).(float64)
+	if !floatAlmostEqual(p1, a1, 1e-12) {
+		t.Errorf("PACF lag 1 = %v, want %v", p1, a1)
+	}
+
+	if _, err := stats.ACF(insyra.NewDataList(1.0, "x", 3.0), 1); err == nil {
+		t.Error("expected error for non-numeric series")
+	}
+}
+
+// Reference values are the printed outputs of the examples in R's ?arima.
+func TestARIMAMatchesR(t *testing.T) {
+	cases := []struct {
+		order  stats.ARIMAOrder
+		coef   []float64
+		se     []float64
+		sigma2 float64
+		logLik float64
+		aic    float64
+	}{
+		{stats.ARIMAOrder{P: 1}, []float64{0.5739, 2.4133}, []float64{0.1161, 0.1466}, 0.1975, -29.38, 64.76},
+		{stats.ARIMAOrder{P: 3}, []float64{0.6448, -0.0634, -0.2198, 2.3931}, []float64{0.1394, 0.1668, 0.1421, 0.0963}, 0.1787, -27.09, 64.18},
+		{stats.ARIMAOrder{P: 1, Q: 1}, []float64{0.4522, 0.1982, 2.4101}, []float64{0.1769, 0.1705, 0.1358}, 0.1923, -28.76, 65.52},
+	}
+	for _, tc := range cases {
+		res, err := stats.ARIMA(insyra.NewDataList(lhSeries), stats.ARIMAOptions{Order: tc.order})
+		if err != nil {
+			t.Fatalf("%v: %v", tc.order, err)
+		}
+		for i := range tc.coef {
+			if !floatAlmostEqual(res.Coefficients[i], tc.coef[i], 1e-3) {
+				t.Errorf("%v coef[%d] = %v, want %v", tc.order, i, res.Coefficients[i], tc.coef[i])
+			}
+			if !floatAlmostEqual(res.StandardErrors[i], tc.se[i], 2e-3) {
+				t.Errorf("%v se[%d] = %v, want %v", tc.order, i, res.StandardErrors[i], tc.se[i])
+			}
+		}
+		if !floatAlmostEqual(res.Sigma2, tc.sigma2, 5e-4) {
+			t.Errorf("%v sigma2 = %v, want %v", tc.order, res.Sigma2, tc.sigma2)
+		}
+		if !floatAlmostEqual(res.LogLikelihood, tc.logLik, 0.01) {
+			t.Errorf("%v logLik = %v, want %v", tc.order, res.LogLikelihood, tc.logLik)
+		}
+		if !floatAlmostEqual(res.AIC, tc.aic, 0.01) {
+			t.Errorf("%v AIC = %v, want %v", tc.order, res.AIC, tc.aic)
+		}
+	}
+}
+
+func TestSeasonalARIMAAndForecast(t *testing.T) {
+	// R: arima(USAccDeaths, order = c(0,1,1), seasonal = list(order = c(0,1,1)))
+	// ma1 = -0.4303, sma1 = -0.5528, log likelihood = -425.44, aic = 856.88.
+	res, err := stats.ARIMA(insyra.NewDataList(usAccDeaths), stats.ARIMAOptions{
+		Order:    stats.ARIMAOrder{D: 1, Q: 1},
+		Seasonal: stats.ARIMAOrder{D: 1, Q: 1},
+		Period:   12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CoefficientNames[0] != "ma1" || res.CoefficientNames[1] != "sma1" {
+		t.Fatalf("unexpected names %v", res.CoefficientNames)
+	}
+	if !floatAlmostEqual(res.Coefficients[0], -0.4303, 1e-3) || !floatAlmostEqual(res.Coefficients[1], -0.5528, 1e-3) {
+		t.Errorf("coefficients = %v", res.Coefficients)
+	}
+	if !floatAlmostEqual(res.LogLikelihood, -425.44, 0.01) || !floatAlmostEqual(res.AIC, 856.88, 0.01) {
+		t.Errorf("logLik = %v, AIC = %v", res.LogLikelihood, res.AIC)
+	}
+	if res.NObs != 72-13 || len(res.Residuals) != res.NObs {
+		t.Errorf("NObs = %d, residuals = %d", res.NObs, len(res.Residuals))
+	}
+
+	fc, err := res.Forecast(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := fc.Size(); r != 6 || c != 5 {
+		t.Fatalf("forecast size = %dx%d", r, c)
+	}
+	// predict(fit, n.ahead = 1)$pred is 8336.06 for January 1979.
+	if got := fc.GetColByName("Forecast").Get(0).(float64); !floatAlmostEqual(got, 8336.06, 0.5) {
+		t.Errorf("first forecast = %v", got)
+	}
+	prev := 0.0
+	for i := range 6 {
+		se := fc.GetColByName("StdError").Get(i).(float64)
+		if se <= prev {
+			t.Errorf("forecast standard errors should grow, step %d: %v", i+1, se)
+		}
+		prev = se
+		lo := fc.GetColByName("Lower").Get(i).(float64)
+		hi := fc.GetColByName("Upper").Get(i).(float64)
+		mid := fc.GetColByName("Forecast").Get(i).(float64)
+		if !floatAlmostEqual(mid-lo, hi-mid, 1e-8) {
+			t.Errorf("interval not symmetric at step %d", i+1)
+		}
+	}
+}
+
+func TestARIMAValidation(t *testing.T) {
+	dl := insyra.NewDataList(lhSeries)
+	if _, err := stats.ARIMA(dl, stats.ARIMAOptions{Order: stats.ARIMAOrder{P: -1}}); err == nil {
+		t.Error("expected error for negative order")
+	}
+	if _, err := stats.ARIMA(dl, stats.ARIMAOptions{Seasonal: stats.ARIMAOrder{P: 1}}); err == nil {
+		t.Error("expected error for seasonal model without period")
+	}
+	res, err := stats.ARIMA(dl, stats.ARIMAOptions{Order: stats.ARIMAOrder{P: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.Forecast(0); err == nil {
+		t.Error("expected error for zero horizon")
+	}
+}
+
+func TestUnitRootTests(t *testing.T) {
+	noise := seededNormals(11, 200)
+	walk := make([]float64, len(noise))
+	acc := 0.0
+	for i, v := range noise {
+		acc += v
+		walk[i] = acc
+	}
+
+	adfNoise, err := stats.ADFTest(insyra.NewDataList(noise))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adfNoise.PValue > 0.01 || adfNoise.Lag != 5 {
+		t.Errorf("ADF on white noise: p = %v, lag = %d", adfNoise.PValue, adfNoise.Lag)
+	}
+	adfWalk, err := stats.ADFTest(insyra.NewDataList(walk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adfWalk.PValue < 0.05 {
+		t.Errorf("ADF on random walk should not reject: p = %v", adfWalk.PValue)
+	}
+
+	kpssNoise, err := stats.KPSSTest(insyra.NewDataList(noise))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kpssNoise.PValue < 0.05 || kpssNoise.Lag != 4 {
+		t.Errorf("KPSS on white noise: p = %v, lag = %d", kpssNoise.PValue, kpssNoise.Lag)
+	}
+	kpssWalk, err := stats.KPSSTest(insyra.NewDataList(walk), stats.KPSSOptions{Trend: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kpssWalk.PValue > 0.05 {
+		t.Errorf("trend KPSS on random walk should reject: p = %v", kpssWalk.PValue)
+	}
+}
+
+func TestHoltWinters(t *testing.T) {
+	// A noiseless additive series: level 10, slope 0.5, period-4 pattern.
+	pattern := []float64{3, -1, -4, 2}
+	x := make([]float64, 40)
+	for i := range x {
+		x[i] = 10 + 0.5*float64(i) + pattern[i%4]
+	}
+	res, err := stats.HoltWinters(insyra.NewDataList(x), stats.HoltWintersOptions{Period: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// R's start values (trend regression anchored at the first moving
+	// average) leave a start-up error, but the filter locks on quickly.
+	for _, r := range res.Residuals[len(res.Residuals)-8:] {
+		if math.Abs(r) > 1e-2 {
+			t.Errorf("late residual on noiseless series = %v", r)
+		}
+	}
+	fc, err := res.Forecast(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for h := 1; h <= 8; h++ {
+		i := len(x) - 1 + h
+		want := 10 + 0.5*float64(i) + pattern[i%4]
+		if got := fc.GetColByName("Forecast").Get(h - 1).(float64); !floatAlmostEqual(got, want, 1e-2) {
+			t.Errorf("h=%d forecast = %v, want %v", h, got, want)
+		}
+	}
+
+	alpha := 0.5
+	simple, err := stats.HoltWinters(insyra.NewDataList(lhSeries), stats.HoltWintersOptions{NoTrend: true, Alpha: &alpha})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simple.Alpha != 0.5 || simple.Beta != 0 || simple.Gamma != 0 {
+		t.Errorf("fixed parameters not respected: %v %v %v", simple.Alpha, simple.Beta, simple.Gamma)
+	}
+	// Simple exponential smoothing with alpha 0.5 from l0 = x[0].
+	level := lhSeries[0]
+	for _, v := range lhSeries[1:] {
+		level = 0.5*v + 0.5*level
+	}
+	if !floatAlmostEqual(simple.Level, level, 1e-12) {
+		t.Errorf("level = %v, want %v", simple.Level, level)
+	}
+
+	if _, err := stats.HoltWinters(insyra.NewDataList(lhSeries), stats.HoltWintersOptions{Period: 30}); err == nil {
+		t.Error("expected error with fewer than two periods")
+	}
+}
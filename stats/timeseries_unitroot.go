@@ -0,0 +1,255 @@
+package stats
+
+import (
+	"errors"
+	"math"
+
+	"github.com/HazelnutParadise/insyra"
+	"gonum.org/v1/gonum/mat"
+)
+
+type ADFOptions struct {
+	// Lag is the number of lagged differences. Nil uses the R default
+	// trunc((n-1)^(1/3)).
+	Lag *int
+}
+
+// ADFTestResult holds the augmented Dickey-Fuller statistic. PValue is
+// interpolated from the Banerjee et al. (1993) table, so it is clamped to
+// [0.01, 0.99] just like tseries::adf.test.
+type ADFTestResult struct {
+	testResultBase
+	Lag int
+	N   int
+}
+
+// ADFTest runs the augmented Dickey-Fuller test with constant and linear
+// trend against the stationary alternative.
+func ADFTest(data insyra.IDataList, opts ...ADFOptions) (*ADFTestResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	x, err := seriesFromDataList(data, 4)
+	if err != nil {
+		return nil, err
+	}
+	k := int(math.Trunc(math.Pow(float64(len(x)-1), 1.0/3.0)))
+	if len(opts) == 1 && opts[0].Lag != nil {
+		k = *opts[0].Lag
+	}
+	if k < 0 {
+		return nil, errors.New("lag must be non-negative")
+	}
+	k++
+
+	y := make([]float64, len(x)-1)
+	for i := range y {
+		y[i] = x[i+1] - x[i]
+	}
+	n := len(y)
+	rows := n - k + 1
+	cols := 3 + (k - 1)
+	if rows <= cols {
+		return nil, errors.New("series is too short for the requested lag")
+	}
+	X := mat.NewDense(rows, cols, nil)
+	yt := make([]float64, rows)
+	for r := range rows {
+		i := k - 1 + r // 0-based index into y
+		yt[r] = y[i]
+		X.Set(r, 0, 1)
+		X.Set(r, 1, x[i])
+		X.Set(r, 2, float64(i+1))
+		for j := 1; j < k; j++ {
+			X.Set(r, 2+j, y[i-j])
+		}
+	}
+	beta, xtxInv := solveOLS(X, yt)
+	if beta == nil {
+		return nil, errors.New("singular ADF regression")
+	}
+	rss := 0.0
+	for r := range rows {
+		fit := 0.0
+		for c := range cols {
+			fit += X.At(r, c) * beta[c]
+		}
+		rss += (yt[r] - fit) * (yt[r] - fit)
+	}
+	mse := rss / float64(rows-cols)
+	stat := beta[1] / math.Sqrt(mse*xtxInv[1][1])
+
+	tableT := []float64{25, 50, 100, 250, 500, 100000}
+	tableP := []float64{0.01, 0.025, 0.05, 0.10, 0.90, 0.95, 0.975, 0.99}
+	table := [][]float64{
+		{4.38, 4.15, 4.04, 3.99, 3.98, 3.96},
+		{3.95, 3.80, 3.73, 3.69, 3.68, 3.66},
+		{3.60, 3.50, 3.45, 3.43, 3.42, 3.41},
+		{3.24, 3.18, 3.15, 3.13, 3.13, 3.12},
+		{1.14, 1.19, 1.22, 1.23, 1.24, 1.25},
+		{0.80, 0.87, 0.90, 0.92, 0.93, 0.94},
+		{0.50, 0.58, 0.62, 0.64, 0.65, 0.66},
+		{0.15, 0.24, 0.28, 0.31, 0.32, 0.33},
+	}
+	crit := make([]float64, len(tableP))
+	for i, row := range table {
+		neg := make([]float64, len(row))
+		for j, v := range row {
+			neg[j] = -v
+		}
+		crit[i] = approxClamped(tableT, neg, float64(n))
+	}
+	df := float64(rows - cols)
+	return &ADFTestResult{
+		testResultBase: testResultBase{
+			Statistic: stat,
+			PValue:    approxClamped(crit, tableP, stat),
+			DF:        &df,
+		},
+		Lag: k - 1,
+		N:   len(x),
+	}, nil
+}
+
+type KPSSOptions struct {
+	// Trend tests trend stationarity instead of level stationarity.
+	Trend bool
+	// LongLag uses the long truncation lag trunc(12*(n/100)^0.25)
+	// instead of the short trunc(4*(n/100)^0.25).
+	LongLag bool
+	// Lag overrides the truncation lag when non-nil.
+	Lag *int
+}
+
+// KPSSTestResult holds the KPSS statistic. PValue is interpolated from the
+// Kwiatkowski et al. (1992) table and clamped to [0.01, 0.10].
+type KPSSTestResult struct {
+	testResultBase
+	Lag   int
+	Trend bool
+	N     int
+}
+
+// KPSSTest runs the Kwiatkowski-Phillips-Schmidt-Shin test whose null
+// hypothesis is level (or trend) stationarity.
+func KPSSTest(data insyra.IDataList, opts ...KPSSOptions) (*KPSSTestResult, error) {
+	if len(opts) > 1 {
+		return nil, errors.New("opts accepts at most one value")
+	}
+	var opt KPSSOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	x, err := seriesFromDataList(data, 3)
+	if err != nil {
+		return nil, err
+	}
+	n := len(x)
+	nf := float64(n)
+
+	e := make([]float64, n)
+	if opt.Trend {
+		X := mat.NewDense(n, 2, nil)
+		for i := range n {
+			X.Set(i, 0, 1)
+			X.Set(i, 1, float64(i+1))
+		}
+		beta, _ := solveOLS(X, x)
+		if beta == nil {
+			return nil, errors.New("singular KPSS trend regression")
+		}
+		for i := range n {
+			e[i] = x[i] - beta[0] - beta[1]*float64(i+1)
+		}
+	} else {
+		mean := 0.0
+		for _, v := range x {
+			mean += v
+		}
+		mean /= nf
+		for i := range n {
+			e[i] = x[i] - mean
+		}
+	}
+
+	var l int
+	switch {
+	case opt.Lag != nil:
+		l = *opt.Lag
+	case opt.LongLag:
+		l = int(math.Trunc(12 * math.Pow(nf/100, 0.25)))
+	default:
+		l = int(math.Trunc(4 * math.Pow(nf/100, 0.25)))
+	}
+	if l < 0 || l >= n {
+		return nil, errors.New("lag must be between 0 and n-1")
+	}
+
+	s, eta := 0.0, 0.0
+	for _, v := range e {
+		s += v
+		eta += s * s
+	}
+	eta /= nf * nf
+	s2 := 0.0
+	for _, v := range e {
+		s2 += v * v
+	}
+	s2 /= nf
+	if l > 0 {
+		tmp := 0.0
+		for i := 1; i <= l; i++ {
+			cov := 0.0
+			for j := i; j < n; j++ {
+				cov += e[j] * e[j-i]
+			}
+			tmp += (1 - float64(i)/float64(l+1)) * cov
+		}
+		s2 += 2 * tmp / nf
+	}
+	stat := eta / s2
+
+	tableP := []float64{0.01, 0.025, 0.05, 0.10}
+	table := []float64{0.739, 0.574, 0.463, 0.347}
+	if opt.Trend {
+		table = []float64{0.216, 0.176, 0.146, 0.119}
+	}
+	// approx needs increasing x; the table is decreasing in p.
+	xs := make([]float64, len(table))
+	ys := make([]float64, len(table))
+	for i := range table {
+		xs[i] = table[len(table)-1-i]
+		ys[i] = tableP[len(table)-1-i]
+	}
+	return &KPSSTestResult{
+		testResultBase: testResultBase{
+			Statistic: stat,
+			PValue:    approxClamped(xs, ys, stat),
+		},
+		Lag:   l,
+		Trend: opt.Trend,
+		N:     n,
+	}, nil
+}
+
+// approxClamped is R's approx(x, y, xout, rule = 2): linear interpolation
+// on increasing x, clamped to the end values outside the range.
+func approxClamped(x, y []float64, xout float64) float64 {
+	if math.IsNaN(xout) {
+		return math.NaN()
+	}
+	if xout <= x[0] {
+		return y[0]
+	}
+	last := len(x) - 1
+	if xout >= x[last] {
+		return y[last]
+	}
+	for i := 1; i <= last; i++ {
+		if xout <= x[i] {
+			w := (xout - x[i-1]) / (x[i] - x[i-1])
+			return y[i-1] + w*(y[i]-y[i-1])
+		}
+	}
+	return y[last]
+}
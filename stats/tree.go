@@ -0,0 +1,742 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/HazelnutParadise/insyra"
+)
+
+type TreeCriterion string
+
+const (
+	TreeGini    TreeCriterion = "gini"
+	TreeEntropy TreeCriterion = "entropy"
+)
+
+// DecisionTreeOptions configures CART training. Zero values select the
+// defaults noted on each field.
+type DecisionTreeOptions struct {
+	// MaxDepth limits the tree depth; 0 means unlimited.
+	MaxDepth int
+	// MinSamplesSplit is the smallest node that may be split (default 2).
+	MinSamplesSplit int
+	// MinSamplesLeaf is the smallest allowed child (default 1).
+	MinSamplesLeaf int
+	// MaxFeatures is the number of features tried at each split; 0 tries
+	// all of them.
+	MaxFeatures int
+	// Criterion is the classification impurity (default TreeGini);
+	// regression trees always use the squared error.
+	Criterion TreeCriterion
+	// Seed and UseSeed make the MaxFeatures subsampling reproducible.
+	Seed    uint64
+	UseSeed bool
+}
+
+// DecisionTreeModel is a fitted CART tree. Classes is nil for regression
+// trees. FeatureImportances is the normalised impurity decrease credited to
+// each feature.
+type DecisionTreeModel struct {
+	FeatureNames       []string
+	FeatureImportances []float64
+	Classes            insyra.IDataList
+	Depth              int
+	NLeaves            int
+
+	tree   *cartTree
+	schema *treeSchema
+}
+
+// DecisionTreeClassifier trains a classification tree. Numeric columns
+// split on thresholds and non-numeric columns split on category subsets;
+// missing values (nil or NaN) are sent to whichever child gives the better
+// split, and categories unseen during training follow the same path.
+func DecisionTreeClassifier(trainData insyra.IDataTable, trainLabels insyra.IDataList, opts ...DecisionTreeOptions) (*DecisionTreeModel, error) {
+	opt, err := parseDecisionTreeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newTreeTrainingData(trainData, trainLabels, true)
+	if err != nil {
+		return nil, err
+	}
+	params, err := newCARTParams(opt, data)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]int, data.n)
+	for i := range rows {
+		rows[i] = i
+	}
+	tree := growCART(data, rows, params, treeRNG(opt.Seed, opt.UseSeed, 0))
+	return newDecisionTreeModel(tree, data), nil
+}
+
+// DecisionTreeRegressor trains a regression tree on numeric targets with
+// the same feature handling as DecisionTreeClassifier.
+func DecisionTreeRegressor(trainData insyra.IDataTable, trainTargets insyra.IDataList, opts ...DecisionTreeOptions) (*DecisionTreeModel, error) {
+	opt, err := parseDecisionTreeOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newTreeTrainingData(trainData, trainTargets, false)
+	if err != nil {
+		return nil, err
+	}
+	params, err := newCARTParams(opt, data)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]int, data.n)
+	for i := range rows {
+		rows[i] = i
+	}
+	tree := growCART(data, rows, params, treeRNG(opt.Seed, opt.UseSeed, 0))
+	return newDecisionTreeModel(tree, data), nil
+}
+
+// Predict returns class labels for a classifier or numeric predictions for
+// a regressor. testData must contain the training columns by name.
+func (m *DecisionTreeModel) Predict(testData insyra.IDataTable) (insyra.IDataList, error) {
+	if m == nil || m.tree == nil {
+		return nil, errors.New("model is not fitted")
+	}
+	x, err := m.schema.encode(testData)
+	if err != nil {
+		return nil, err
+	}
+	out := insyra.NewDataList()
+	for i := range x[0] {
+		value := m.tree.predict(x, i)
+		if m.schema.classification {
+			out.Append(m.schema.classes[argmax(value)])
+		} else {
+			out.Append(value[0])
+		}
+	}
+	return out, nil
+}
+
+// PredictProba returns one column of class probabilities per class.
+func (m *DecisionTreeModel) PredictProba(testData insyra.IDataTable) (insyra.IDataTable, error) {
+	if m == nil || m.tree == nil {
+		return nil, errors.New("model is not fitted")
+	}
+	if !m.schema.classification {
+		return nil, errors.New("probabilities are only available for classifiers")
+	}
+	x, err := m.schema.encode(testData)
+	if err != nil {
+		return nil, err
+	}
+	probs := make([][]float64, len(x[0]))
+	for i := range probs {
+		probs[i] = append([]float64(nil), m.tree.predict(x, i)...)
+	}
+	return matrixToNamedDataTable(probs, m.schema.classNames()), nil
+}
+
+func newDecisionTreeModel(tree *cartTree, data *treeTrainingData) *DecisionTreeModel {
+	importances := normalizedImportances(tree.importance)
+	return &DecisionTreeModel{
+		FeatureNames:       append([]string(nil), data.schema.names...),
+		FeatureImportances: importances,
+		Classes:            data.schema.classList(),
+		Depth:              tree.depth(),
+		NLeaves:            tree.leaves(),
+		tree:               tree,
+		schema:             data.schema,
+	}
+}
+
+func parseDecisionTreeOptions(opts []DecisionTreeOptions) (DecisionTreeOptions, error) {
+	if len(opts) > 1 {
+		return DecisionTreeOptions{}, errors.New("opts accepts at most one value")
+	}
+	if len(opts) == 0 {
+		return DecisionTreeOptions{}, nil
+	}
+	return opts[0], nil
+}
+
+func treeRNG(seed uint64, useSeed bool, stream uint64) *rand.Rand {
+	return replicateRNG(resolveSeed(seed, useSeed), stream)
+}
+
+// ---------------------------------------------------------------------------
+// Feature schema
+
+type treeFeatureKind int
+
+const (
+	treeNumeric treeFeatureKind = iota
+	treeCategorical
+)
+
+// treeSchema records how training columns were encoded so prediction data
+// can be encoded the same way: numeric values as-is, categories as level
+// codes, and missing or unseen values as NaN.
+type treeSchema struct {
+	names          []string
+	kinds          []treeFeatureKind
+	categories     []map[string]int
+	classification bool
+	classes        []any
+}
+
+func (s *treeSchema) classNames() []string {
+	names := make([]string, len(s.classes))
+	for i, c := range s.classes {
+		names[i] = fmt.Sprint(c)
+	}
+	return names
+}
+
+func (s *treeSchema) classList() insyra.IDataList {
+	if !s.classification {
+		return nil
+	}
+	return insyra.NewDataList(s.classes...)
+}
+
+func treeMissing(v any) bool {
+	if v == nil {
+		return true
+	}
+	f, ok := v.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// encode returns the features column-major, one slice per training column.
+func (s *treeSchema) encode(dataTable insyra.IDataTable) ([][]float64, error) {
+	raw, n, err := rawColumnsByName(dataTable, s.names)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errors.New("test data has no rows")
+	}
+	x := make([][]float64, len(s.names))
+	for j, col := range raw {
+		x[j] = make([]float64, n)
+		for i, v := range col {
+			x[j][i] = math.NaN()
+			if treeMissing(v) {
+				continue
+			}
+			switch s.kinds[j] {
+			case treeNumeric:
+				f, ok := insyra.ToFloat64Safe(v)
+				if !ok {
+					return nil, fmt.Errorf("column %q must be numeric (row %d)", s.names[j], i)
+				}
+				x[j][i] = f
+			case treeCategorical:
+				if code, ok := s.categories[j][classKey(v)]; ok {
+					x[j][i] = float64(code)
+				}
+			}
+		}
+	}
+	return x, nil
+}
+
+type treeTrainingData struct {
+	schema *treeSchema
+	x      [][]float64 // column-major features
+	y      []float64   // class codes or numeric targets
+	n      int
+	k      int // number of classes, 0 for regression
+}
+
+func newTreeTrainingData(dataTable insyra.IDataTable, labels insyra.IDataList, classification bool) (*treeTrainingData, error) {
+	if dataTable == nil || labels == nil {
+		return nil, errors.New("training data and labels must not be nil")
+	}
+	var names []string
+	dataTable.AtomicDo(func(dt *insyra.DataTable) { names = dt.ColNames() })
+	if len(names) == 0 {
+		return nil, errors.New("training data has no columns")
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if name == "" || seen[name] {
+			return nil, errors.New("training columns must have unique, non-empty names")
+		}
+		seen[name] = true
+	}
+	raw, n, err := rawColumnsByName(dataTable, names)
+	if err != nil {
+		return nil, err
+	}
+	labelValues := labels.Data()
+	if len(labelValues) != n {
+		return nil, errors.New("labels length must match training row count")
+	}
+	if n < 2 {
+		return nil, errors.New("at least two training rows are required")
+	}
+
+	schema := &treeSchema{
+		names:          names,
+		kinds:          make([]treeFeatureKind, len(names)),
+		categories:     make([]map[string]int, len(names)),
+		classification: classification,
+	}
+	for j, col := range raw {
+		numeric := true
+		for _, v := range col {
+			if treeMissing(v) {
+				continue
+			}
+			if _, ok := insyra.ToFloat64Safe(v); !ok {
+				numeric = false
+				break
+			}
+		}
+		if numeric {
+			continue
+		}
+		schema.kinds[j] = treeCategorical
+		var levels []any
+		keys := map[string]bool{}
+		for _, v := range col {
+			if treeMissing(v) || keys[classKey(v)] {
+				continue
+			}
+			keys[classKey(v)] = true
+			levels = append(levels, v)
+		}
+		sortClassLevels(levels)
+		schema.categories[j] = make(map[string]int, len(levels))
+		for code, v := range levels {
+			schema.categories[j][classKey(v)] = code
+		}
+	}
+	x, err := schema.encode(dataTable)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &treeTrainingData{schema: schema, x: x, n: n, y: make([]float64, n)}
+	if classification {
+		codes, levels, err := factorLevels(labelValues, "labels")
+		if err != nil {
+			return nil, err
+		}
+		if len(levels) < 2 {
+			return nil, errors.New("labels must contain at least two classes")
+		}
+		schema.classes = levels
+		data.k = len(levels)
+		for i, c := range codes {
+			data.y[i] = float64(c)
+		}
+	} else {
+		y, err := numericColumn(labelValues, "targets")
+		if err != nil {
+			return nil, err
+		}
+		data.y = y
+	}
+	return data, nil
+}
+
+// ---------------------------------------------------------------------------
+// CART
+
+type cartParams struct {
+	maxDepth        int
+	minSamplesSplit int
+	minSamplesLeaf  int
+	maxFeatures     int
+	criterion       TreeCriterion
+}
+
+func newCARTParams(opt DecisionTreeOptions, data *treeTrainingData) (cartParams, error) {
+	p := cartParams{
+		maxDepth:        opt.MaxDepth,
+		minSamplesSplit: opt.MinSamplesSplit,
+		minSamplesLeaf:  opt.MinSamplesLeaf,
+		maxFeatures:     opt.MaxFeatures,
+		criterion:       opt.Criterion,
+	}
+	if p.maxDepth < 0 || p.minSamplesSplit < 0 || p.minSamplesLeaf < 0 || p.maxFeatures < 0 {
+		return p, errors.New("tree options must not be negative")
+	}
+	if p.minSamplesSplit == 0 {
+		p.minSamplesSplit = 2
+	}
+	if p.minSamplesLeaf == 0 {
+		p.minSamplesLeaf = 1
+	}
+	nFeatures := len(data.x)
+	if p.maxFeatures == 0 || p.maxFeatures > nFeatures {
+		p.maxFeatures = nFeatures
+	}
+	if p.criterion == "" {
+		p.criterion = TreeGini
+	}
+	if p.criterion != TreeGini && p.criterion != TreeEntropy {
+		return p, errors.New("unsupported tree criterion")
+	}
+	return p, nil
+}
+
+type cartNode struct {
+	feature     int // -1 for leaves
+	threshold   float64
+	leftCats    []bool
+	missingLeft bool
+	left, right int
+	value       []float64 // class probabilities or {mean}
+	samples     int
+}
+
+type cartTree struct {
+	nodes      []cartNode
+	importance []float64
+}
+
+func (t *cartTree) predict(x [][]float64, row int) []float64 {
+	i := 0
+	for {
+		node := &t.nodes[i]
+		if node.feature < 0 {
+			return node.value
+		}
+		v := x[node.feature][row]
+		var goLeft bool
+		switch {
+		case math.IsNaN(v):
+			goLeft = node.missingLeft
+		case node.leftCats != nil:
+			code := int(v)
+			goLeft = code < len(node.leftCats) && node.leftCats[code]
+		default:
+			goLeft = v <= node.threshold
+		}
+		if goLeft {
+			i = node.left
+		} else {
+			i = node.right
+		}
+	}
+}
+
+func (t *cartTree) depth() int {
+	var walk func(i int) int
+	walk = func(i int) int {
+		n := t.nodes[i]
+		if n.feature < 0 {
+			return 0
+		}
+		return 1 + max(walk(n.left), walk(n.right))
+	}
+	return walk(0)
+}
+
+func (t *cartTree) leaves() int {
+	count := 0
+	for _, n := range t.nodes {
+		if n.feature < 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// treeStats accumulates class counts (classification) or {sum, sum of
+// squares} (regression) together with the sample count.
+type treeStats struct {
+	v []float64
+	n float64
+}
+
+func (d *treeTrainingData) newStats() treeStats {
+	if d.k > 0 {
+		return treeStats{v: make([]float64, d.k)}
+	}
+	return treeStats{v: make([]float64, 2)}
+}
+
+func (d *treeTrainingData) addStats(s *treeStats, row int, sign float64) {
+	y := d.y[row]
+	if d.k > 0 {
+		s.v[int(y)] += sign
+	} else {
+		s.v[0] += sign * y
+		s.v[1] += sign * y * y
+	}
+	s.n += sign
+}
+
+func combineStats(a, b treeStats, sign float64) treeStats {
+	out := treeStats{v: make([]float64, len(a.v)), n: a.n + sign*b.n}
+	for i := range a.v {
+		out.v[i] = a.v[i] + sign*b.v[i]
+	}
+	return out
+}
+
+// impurity returns the node impurity times its size, so that split gains
+// are differences of plain sums.
+func (d *treeTrainingData) impurity(s treeStats, criterion TreeCriterion) float64 {
+	if s.n <= 0 {
+		return 0
+	}
+	if d.k == 0 {
+		return math.Max(0, s.v[1]-s.v[0]*s.v[0]/s.n)
+	}
+	imp := 0.0
+	if criterion == TreeEntropy {
+		for _, c := range s.v {
+			if c > 0 {
+				p := c / s.n
+				imp -= p * math.Log2(p)
+			}
+		}
+	} else {
+		imp = 1
+		for _, c := range s.v {
+			p := c / s.n
+			imp -= p * p
+		}
+	}
+	return imp * s.n
+}
+
+func (d *treeTrainingData) leafValue(s treeStats) []float64 {
+	if d.k == 0 {
+		return []float64{s.v[0] / s.n}
+	}
+	out := make([]float64, d.k)
+	for i, c := range s.v {
+		out[i] = c / s.n
+	}
+	return out
+}
+
+type cartSplit struct {
+	feature     int
+	threshold   float64
+	leftCats    []bool
+	missingLeft bool
+	gain        float64
+}
+
+// growCART builds a tree over the given rows (which may repeat, as in a
+// bootstrap sample).
+func growCART(data *treeTrainingData, rows []int, params cartParams, rng *rand.Rand) *cartTree {
+	tree := &cartTree{importance: make([]float64, len(data.x))}
+	features := make([]int, len(data.x))
+	for i := range features {
+		features[i] = i
+	}
+	var build func(rows []int, depth int) int
+	build = func(rows []int, depth int) int {
+		stats := data.newStats()
+		for _, r := range rows {
+			data.addStats(&stats, r, 1)
+		}
+		idx := len(tree.nodes)
+		tree.nodes = append(tree.nodes, cartNode{feature: -1, value: data.leafValue(stats), samples: len(rows)})
+		nodeImpurity := data.impurity(stats, params.criterion)
+		if len(rows) < params.minSamplesSplit || (params.maxDepth > 0 && depth >= params.maxDepth) || nodeImpurity <= 1e-12 {
+			return idx
+		}
+
+		best := cartSplit{feature: -1}
+		rng.Shuffle(len(features), func(i, j int) { features[i], features[j] = features[j], features[i] })
+		for _, f := range features[:params.maxFeatures] {
+			split := data.bestSplit(f, rows, stats, nodeImpurity, params)
+			if split.feature >= 0 && split.gain > best.gain {
+				best = split
+			}
+		}
+		if best.feature < 0 || best.gain <= 1e-12 {
+			return idx
+		}
+		var left, right []int
+		for _, r := range rows {
+			v := data.x[best.feature][r]
+			var goLeft bool
+			switch {
+			case math.IsNaN(v):
+				goLeft = best.missingLeft
+			case best.leftCats != nil:
+				goLeft = best.leftCats[int(v)]
+			default:
+				goLeft = v <= best.threshold
+			}
+			if goLeft {
+				left = append(left, r)
+			} else {
+				right = append(right, r)
+			}
+		}
+		tree.importance[best.feature] += best.gain
+		l := build(left, depth+1)
+		r := build(right, depth+1)
+		node := &tree.nodes[idx]
+		node.feature = best.feature
+		node.threshold = best.threshold
+		node.leftCats = best.leftCats
+		node.missingLeft = best.missingLeft
+		node.left, node.right = l, r
+		return idx
+	}
+	build(rows, 0)
+	return tree
+}
+
+// bestSplit scans one feature. Non-missing values are ordered (numerically,
+// or for categories by their mean response) and every boundary is tried
+// with the missing rows sent left and right.
+func (d *treeTrainingData) bestSplit(f int, rows []int, total treeStats, nodeImpurity float64, params cartParams) cartSplit {
+	col := d.x[f]
+	missing := d.newStats()
+	var present []int
+	for _, r := range rows {
+		if math.IsNaN(col[r]) {
+			d.addStats(&missing, r, 1)
+		} else {
+			present = append(present, r)
+		}
+	}
+	if len(present) < 2 {
+		return cartSplit{feature: -1}
+	}
+	categorical := d.schema.kinds[f] == treeCategorical
+
+	// Group present rows into ordered bins: distinct values for numeric
+	// features, categories for categorical ones.
+	type bin struct {
+		key   float64
+		order float64
+		stats treeStats
+	}
+	var bins []bin
+	if categorical {
+		byCode := map[int]*bin{}
+		for _, r := range present {
+			code := int(col[r])
+			b, ok := byCode[code]
+			if !ok {
+				b = &bin{key: float64(code), stats: d.newStats()}
+				byCode[code] = b
+			}
+			d.addStats(&b.stats, r, 1)
+		}
+		// Order categories by mean response (regression), by the share of
+		// the node's majority class (classification).
+		major := 0
+		if d.k > 0 {
+			major = argmax(total.v)
+		}
+		for _, b := range byCode {
+			if d.k > 0 {
+				b.order = b.stats.v[major] / b.stats.n
+			} else {
+				b.order = b.stats.v[0] / b.stats.n
+			}
+			bins = append(bins, *b)
+		}
+		sort.Slice(bins, func(a, c int) bool {
+			if bins[a].order != bins[c].order {
+				return bins[a].order < bins[c].order
+			}
+			return bins[a].key < bins[c].key
+		})
+	} else {
+		sort.Slice(present, func(a, c int) bool { return col[present[a]] < col[present[c]] })
+		for _, r := range present {
+			v := col[r]
+			if len(bins) == 0 || bins[len(bins)-1].key != v {
+				bins = append(bins, bin{key: v, stats: d.newStats()})
+			}
+			d.addStats(&bins[len(bins)-1].stats, r, 1)
+		}
+	}
+	if len(bins) < 2 {
+		return cartSplit{feature: -1}
+	}
+
+	presentStats := combineStats(total, missing, -1)
+	minLeaf := float64(params.minSamplesLeaf)
+	best := cartSplit{feature: -1}
+	left := d.newStats()
+	for b := 0; b < len(bins)-1; b++ {
+		left = combineStats(left, bins[b].stats, 1)
+		right := combineStats(presentStats, left, -1)
+		for _, missLeft := range []bool{true, false} {
+			l, r := left, right
+			if missing.n > 0 {
+				if missLeft {
+					l = combineStats(left, missing, 1)
+				} else {
+					r = combineStats(right, missing, 1)
+				}
+			} else if !missLeft {
+				continue
+			}
+			if l.n < minLeaf || r.n < minLeaf {
+				continue
+			}
+			gain := nodeImpurity - d.impurity(l, params.criterion) - d.impurity(r, params.criterion)
+			if gain > best.gain+1e-12 {
+				best = cartSplit{feature: f, gain: gain, missingLeft: missLeft}
+				if categorical {
+					best.leftCats = make([]bool, len(d.schema.categories[f]))
+					for _, lb := range bins[:b+1] {
+						best.leftCats[int(lb.key)] = true
+					}
+				} else {
+					best.threshold = (bins[b].key + bins[b+1].key) / 2
+				}
+			}
+		}
+	}
+	if missing.n == 0 && best.feature >= 0 {
+		// With no missing training values, send future missing values to
+		// the larger child.
+		leftN := 0.0
+		for _, r := range present {
+			v := col[r]
+			if (categorical && best.leftCats[int(v)]) || (!categorical && v <= best.threshold) {
+				leftN++
+			}
+		}
+		best.missingLeft = leftN >= float64(len(present))-leftN
+	}
+	return best
+}
+
+func normalizedImportances(raw []float64) []float64 {
+	out := make([]float64, len(raw))
+	total := 0.0
+	for _, v := range raw {
+		total += v
+	}
+	if total == 0 {
+		return out
+	}
+	for i, v := range raw {
+		out[i] = v / total
+	}
+	return out
+}
+
+func argmax(v []float64) int {
+	best := 0
+	for i := range v {
+		if v[i] > v[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,157 @@
+package stats_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/HazelnutParadise/insyra"
+	"github.com/HazelnutParadise/insyra/stats"
+)
+
+// treeFixture has a numeric signal column "x", a categorical column "color"
+// that alone decides the class, a noise column and some missing values.
+func treeFixture(n int, seed uint64) (*insyra.DataTable, *insyra.DataList, *insyra.DataList) {
+	rng := rand.New(rand.NewPCG(seed, seed+1))
+	x := insyra.NewDataList().SetName("x")
+	color := insyra.NewDataList().SetName("color")
+	noise := insyra.NewDataList().SetName("noise")
+	labels := insyra.NewDataList()
+	targets := insyra.NewDataList()
+	colors := []string{"red", "green", "blue"}
+	for i := 0; i < n; i++ {
+		c := colors[rng.IntN(3)]
+		xv := rng.Float64() * 10
+		if i%17 == 0 {
+			x.Append(nil)
+		} else {
+			x.Append(xv)
+		}
+		color.Append(c)
+		noise.Append(rng.NormFloat64())
+		if c == "blue" {
+			labels.Append("yes")
+		} else {
+			labels.Append("no")
+		}
+		y := 2 * xv
+		if c == "red" {
+			y += 20
+		}
+		targets.Append(y + 0.1*rng.NormFloat64())
+	}
+	return insyra.NewDataTable(x, color, noise), labels, targets
+}
+
+func TestDecisionTreeClassifierCategoricalSplit(t *testing.T) {
+	train, labels, _ := treeFixture(120, 1)
+	model, err := stats.DecisionTreeClassifier(train, labels)
+	if err != nil {
+		t.Fatalf("DecisionTreeClassifier returned error: %v", err)
+	}
+	if model.Depth != 1 || model.NLeaves != 2 {
+		t.Fatalf("expected a single split, got depth %d with %d leaves", model.Depth, model.NLeaves)
+	}
+	if !floatAlmostEqual(model.FeatureImportances[1], 1, 1e-12) {
+		t.Fatalf("color importance = %v, want 1", model.FeatureImportances)
+	}
+
+	test := insyra.NewDataTable(
+		insyra.NewDataList(1.0, nil, 3.0).SetName("x"),
+		insyra.NewDataList("blue", "red", "purple").SetName("color"),
+		insyra.NewDataList(0.0, 0.0, 0.0).SetName("noise"),
+	)
+	pred, err := model.Predict(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pred.Get(0) != "yes" || pred.Get(1) != "no" {
+		t.Fatalf("predictions = %v", pred.Data())
+	}
+	// An unseen category follows the larger child, which is "no".
+	if pred.Get(2) != "no" {
+		t.Fatalf("unseen category predicted %v", pred.Get(2))
+	}
+	proba, err := model.PredictProba(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, c := proba.(*insyra.DataTable).Size(); r != 3 || c != 2 {
+		t.Fatalf("probability shape %dx%d", r, c)
+	}
+}
+
+func TestDecisionTreeRegressorFitsStepFunction(t *testing.T) {
+	x := insyra.NewDataList().SetName("x")
+	y := insyra.NewDataList()
+	for i := 0; i < 40; i++ {
+		x.Append(float64(i))
+		if i < 20 {
+			y.Append(1.0)
+		} else {
+			y.Append(5.0)
+		}
+	}
+	model, err := stats.DecisionTreeRegressor(insyra.NewDataTable(x), y, stats.DecisionTreeOptions{MaxDepth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pred, err := model.Predict(insyra.NewDataTable(insyra.NewDataList(19.4, 19.6, nil).SetName("x")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pred.Get(0) != 1.0 || pred.Get(1) != 5.0 {
+		t.Fatalf("predictions = %v, want threshold at 19.5", pred.Data())
+	}
+	if model.NLeaves != 2 {
+		t.Fatalf("pure children should not split further, got %d leaves", model.NLeaves)
+	}
+	if _, err := model.PredictProba(insyra.NewDataTable(x)); err == nil {
+		t.Fatal("PredictProba should fail for a regressor")
+	}
+}
+
+func TestRandomForestOOBAndImportance(t *testing.T) {
+	train, labels, targets := treeFixture(300, 7)
+	opts := stats.RandomForestOptions{NTrees: 60, Seed: 3, UseSeed: true}
+
+	clf, err := stats.RandomForestClassifier(train, labels, opts)
+	if err != nil {
+		t.Fatalf("RandomForestClassifier returned error: %v", err)
+	}
+	if clf.OOBError > 0.05 {
+		t.Fatalf("OOB error = %v, want near zero", clf.OOBError)
+	}
+	if clf.FeatureImportances[1] < clf.FeatureImportances[2] {
+		t.Fatalf("color should outrank noise: %v", clf.FeatureImportances)
+	}
+	again, err := stats.RandomForestClassifier(train, labels, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.OOBError != clf.OOBError || again.FeatureImportances[0] != clf.FeatureImportances[0] {
+		t.Fatal("seeded forests differ")
+	}
+
+	reg, err := stats.RandomForestRegressor(train, targets, opts)
+	if err != nil {
+		t.Fatalf("RandomForestRegressor returned error: %v", err)
+	}
+	if reg.OOBError > 10 || math.IsNaN(reg.OOBError) {
+		t.Fatalf("regression OOB MSE = %v", reg.OOBError)
+	}
+	if imp := reg.FeatureImportances; imp[2] > imp[0] || imp[2] > imp[1] {
+		t.Fatalf("noise should rank last: %v", imp)
+	}
+	pred, err := reg.Predict(insyra.NewDataTable(
+		insyra.NewDataList(5.0).SetName("x"),
+		insyra.NewDataList("red").SetName("color"),
+		insyra.NewDataList(0.0).SetName("noise"),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := pred.Get(0).(float64); math.Abs(v-30) > 2 {
+		t.Fatalf("prediction %v, want about 30", v)
+	}
+}